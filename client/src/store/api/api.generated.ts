@@ -3,7 +3,12 @@ import { api } from "./base";
 const injectedRtkApi = api.injectEndpoints({
 	endpoints: (build) => ({
 		getUsersMe: build.query<GetUsersMeApiResponse, GetUsersMeApiArg>({
-			query: () => ({ url: `/users/me` }),
+			query: (queryArg) => ({
+				url: `/users/me`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
 		}),
 		updateUsersMe: build.mutation<
 			UpdateUsersMeApiResponse,
@@ -13,6 +18,10 @@ const injectedRtkApi = api.injectEndpoints({
 				url: `/users/me`,
 				method: "PATCH",
 				body: queryArg.updateUserRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
 			}),
 		}),
 		listOrganizations: build.query<
@@ -29,13 +38,21 @@ const injectedRtkApi = api.injectEndpoints({
 				url: `/organizations`,
 				method: "POST",
 				body: queryArg.createOrganizationRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
 			}),
 		}),
 		getOrganization: build.query<
 			GetOrganizationApiResponse,
 			GetOrganizationApiArg
 		>({
-			query: (queryArg) => ({ url: `/organizations/${queryArg.orgId}` }),
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
 		}),
 		updateOrganization: build.mutation<
 			UpdateOrganizationApiResponse,
@@ -45,6 +62,10 @@ const injectedRtkApi = api.injectEndpoints({
 				url: `/organizations/${queryArg.orgId}`,
 				method: "PATCH",
 				body: queryArg.updateOrganizationRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
 			}),
 		}),
 		listOrganizationMembers: build.query<
@@ -55,6 +76,17 @@ const injectedRtkApi = api.injectEndpoints({
 				url: `/organizations/${queryArg.orgId}/members`,
 			}),
 		}),
+		getOrganizationMember: build.query<
+			GetOrganizationMemberApiResponse,
+			GetOrganizationMemberApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/members/${queryArg.userId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
 		addOrganizationMember: build.mutation<
 			AddOrganizationMemberApiResponse,
 			AddOrganizationMemberApiArg
@@ -63,6 +95,9 @@ const injectedRtkApi = api.injectEndpoints({
 				url: `/organizations/${queryArg.orgId}/members`,
 				method: "POST",
 				body: queryArg.addMemberRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
 			}),
 		}),
 		updateOrganizationMember: build.mutation<
@@ -73,6 +108,10 @@ const injectedRtkApi = api.injectEndpoints({
 				url: `/organizations/${queryArg.orgId}/members/${queryArg.userId}`,
 				method: "PATCH",
 				body: queryArg.updateMemberRoleRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
 			}),
 		}),
 		removeOrganizationMember: build.mutation<
@@ -80,26 +119,712 @@ const injectedRtkApi = api.injectEndpoints({
 			RemoveOrganizationMemberApiArg
 		>({
 			query: (queryArg) => ({
-				url: `/organizations/${queryArg.orgId}/members/${queryArg.userId}`,
+				url: `/organizations/${queryArg.orgId}/members/${queryArg.userId}`,
+				method: "DELETE",
+			}),
+		}),
+		listApiKeys: build.query<ListApiKeysApiResponse, ListApiKeysApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/api-keys`,
+			}),
+		}),
+		createApiKey: build.mutation<CreateApiKeyApiResponse, CreateApiKeyApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/api-keys`,
+				method: "POST",
+				body: queryArg.createApiKeyRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getApiKey: build.query<GetApiKeyApiResponse, GetApiKeyApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/api-keys/${queryArg.keyId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		revokeApiKey: build.mutation<RevokeApiKeyApiResponse, RevokeApiKeyApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/api-keys/${queryArg.keyId}`,
+				method: "DELETE",
+			}),
+		}),
+		listSources: build.query<ListSourcesApiResponse, ListSourcesApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources`,
+			}),
+		}),
+		createSource: build.mutation<CreateSourceApiResponse, CreateSourceApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources`,
+				method: "POST",
+				body: queryArg.createSourceRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getSource: build.query<GetSourceApiResponse, GetSourceApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		updateSource: build.mutation<UpdateSourceApiResponse, UpdateSourceApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}`,
+				method: "PATCH",
+				body: queryArg.updateSourceRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
+			}),
+		}),
+		deleteSource: build.mutation<DeleteSourceApiResponse, DeleteSourceApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}`,
+				method: "DELETE",
+			}),
+		}),
+		rotateSourceToken: build.mutation<
+			RotateSourceTokenApiResponse,
+			RotateSourceTokenApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}/token`,
+				method: "POST",
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getSourceHealth: build.query<
+			GetSourceHealthApiResponse,
+			GetSourceHealthApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}/health`,
+				params: {
+					window_minutes: queryArg.windowMinutes,
+				},
+			}),
+		}),
+		listGrokPatterns: build.query<
+			ListGrokPatternsApiResponse,
+			ListGrokPatternsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/grok-patterns`,
+			}),
+		}),
+		createGrokPattern: build.mutation<
+			CreateGrokPatternApiResponse,
+			CreateGrokPatternApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/grok-patterns`,
+				method: "POST",
+				body: queryArg.createGrokPatternRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		testGrokPattern: build.mutation<
+			TestGrokPatternApiResponse,
+			TestGrokPatternApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/grok-patterns/test`,
+				method: "POST",
+				body: queryArg.testGrokPatternRequest,
+			}),
+		}),
+		getGrokPattern: build.query<
+			GetGrokPatternApiResponse,
+			GetGrokPatternApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/grok-patterns/${queryArg.patternId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		updateGrokPattern: build.mutation<
+			UpdateGrokPatternApiResponse,
+			UpdateGrokPatternApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/grok-patterns/${queryArg.patternId}`,
+				method: "PATCH",
+				body: queryArg.updateGrokPatternRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
+			}),
+		}),
+		deleteGrokPattern: build.mutation<
+			DeleteGrokPatternApiResponse,
+			DeleteGrokPatternApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/grok-patterns/${queryArg.patternId}`,
+				method: "DELETE",
+			}),
+		}),
+		setSourceMapping: build.mutation<
+			SetSourceMappingApiResponse,
+			SetSourceMappingApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}/mapping`,
+				method: "PUT",
+				body: queryArg.setSourceMappingRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		clearSourceMapping: build.mutation<
+			ClearSourceMappingApiResponse,
+			ClearSourceMappingApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}/mapping`,
+				method: "DELETE",
+			}),
+		}),
+		listMappings: build.query<ListMappingsApiResponse, ListMappingsApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings`,
+			}),
+		}),
+		createMapping: build.mutation<
+			CreateMappingApiResponse,
+			CreateMappingApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings`,
+				method: "POST",
+				body: queryArg.createMappingRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		previewMapping: build.mutation<
+			PreviewMappingApiResponse,
+			PreviewMappingApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/preview`,
+				method: "POST",
+				body: queryArg.previewMappingRequest,
+			}),
+		}),
+		getMapping: build.query<GetMappingApiResponse, GetMappingApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		updateMapping: build.mutation<
+			UpdateMappingApiResponse,
+			UpdateMappingApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}`,
+				method: "PATCH",
+				body: queryArg.updateMappingRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
+			}),
+		}),
+		deleteMapping: build.mutation<
+			DeleteMappingApiResponse,
+			DeleteMappingApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}`,
+				method: "DELETE",
+			}),
+		}),
+		listMappingVersions: build.query<
+			ListMappingVersionsApiResponse,
+			ListMappingVersionsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}/versions`,
+			}),
+		}),
+		createMappingVersion: build.mutation<
+			CreateMappingVersionApiResponse,
+			CreateMappingVersionApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}/versions`,
+				method: "POST",
+				body: queryArg.createMappingVersionRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getMappingVersion: build.query<
+			GetMappingVersionApiResponse,
+			GetMappingVersionApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}/versions/${queryArg.version}`,
+			}),
+		}),
+		testMappingVersion: build.mutation<
+			TestMappingVersionApiResponse,
+			TestMappingVersionApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}/versions/${queryArg.version}/test`,
+				method: "POST",
+			}),
+		}),
+		listMappingFixtures: build.query<
+			ListMappingFixturesApiResponse,
+			ListMappingFixturesApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}/fixtures`,
+			}),
+		}),
+		createMappingFixture: build.mutation<
+			CreateMappingFixtureApiResponse,
+			CreateMappingFixtureApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}/fixtures`,
+				method: "POST",
+				body: queryArg.createMappingFixtureRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		deleteMappingFixture: build.mutation<
+			DeleteMappingFixtureApiResponse,
+			DeleteMappingFixtureApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/mappings/${queryArg.mappingId}/fixtures/${queryArg.fixtureId}`,
+				method: "DELETE",
+			}),
+		}),
+		listContentPacks: build.query<
+			ListContentPacksApiResponse,
+			ListContentPacksApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/content-packs`,
+			}),
+		}),
+		getContentPack: build.query<
+			GetContentPackApiResponse,
+			GetContentPackApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/content-packs/${queryArg.packId}`,
+			}),
+		}),
+		installContentPack: build.mutation<
+			InstallContentPackApiResponse,
+			InstallContentPackApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/content-packs/${queryArg.packId}/install`,
+				method: "POST",
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		listReplays: build.query<ListReplaysApiResponse, ListReplaysApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/replays`,
+			}),
+		}),
+		createReplay: build.mutation<CreateReplayApiResponse, CreateReplayApiArg>(
+			{
+				query: (queryArg) => ({
+					url: `/organizations/${queryArg.orgId}/replays`,
+					method: "POST",
+					body: queryArg.createReplayRequest,
+					headers: {
+						"Idempotency-Key": queryArg["Idempotency-Key"],
+					},
+				}),
+			},
+		),
+		getReplay: build.query<GetReplayApiResponse, GetReplayApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/replays/${queryArg.replayId}`,
+			}),
+		}),
+		cancelReplay: build.mutation<CancelReplayApiResponse, CancelReplayApiArg>(
+			{
+				query: (queryArg) => ({
+					url: `/organizations/${queryArg.orgId}/replays/${queryArg.replayId}/cancel`,
+					method: "POST",
+				}),
+			},
+		),
+		listReplayShadowEvents: build.query<
+			ListReplayShadowEventsApiResponse,
+			ListReplayShadowEventsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/replays/${queryArg.replayId}/shadow-events`,
+				params: {
+					after: queryArg.after,
+					failed: queryArg.failed,
+					limit: queryArg.limit,
+				},
+			}),
+		}),
+		listThreatIndicators: build.query<
+			ListThreatIndicatorsApiResponse,
+			ListThreatIndicatorsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/threat-indicators`,
+				params: {
+					type: queryArg.type,
+					tag: queryArg.tag,
+					active: queryArg.active,
+					after: queryArg.after,
+					limit: queryArg.limit,
+				},
+			}),
+		}),
+		createThreatIndicator: build.mutation<
+			CreateThreatIndicatorApiResponse,
+			CreateThreatIndicatorApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/threat-indicators`,
+				method: "POST",
+				body: queryArg.createThreatIndicatorRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		importThreatIndicators: build.mutation<
+			ImportThreatIndicatorsApiResponse,
+			ImportThreatIndicatorsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/threat-indicators/import`,
+				method: "POST",
+				body: queryArg.importThreatIndicatorsRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getThreatIndicator: build.query<
+			GetThreatIndicatorApiResponse,
+			GetThreatIndicatorApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/threat-indicators/${queryArg.indicatorId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		updateThreatIndicator: build.mutation<
+			UpdateThreatIndicatorApiResponse,
+			UpdateThreatIndicatorApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/threat-indicators/${queryArg.indicatorId}`,
+				method: "PATCH",
+				body: queryArg.updateThreatIndicatorRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
+			}),
+		}),
+		deleteThreatIndicator: build.mutation<
+			DeleteThreatIndicatorApiResponse,
+			DeleteThreatIndicatorApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/threat-indicators/${queryArg.indicatorId}`,
+				method: "DELETE",
+			}),
+		}),
+		listTaxiiFeeds: build.query<
+			ListTaxiiFeedsApiResponse,
+			ListTaxiiFeedsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds`,
+			}),
+		}),
+		createTaxiiFeed: build.mutation<
+			CreateTaxiiFeedApiResponse,
+			CreateTaxiiFeedApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds`,
+				method: "POST",
+				body: queryArg.createTaxiiFeedRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getTaxiiFeed: build.query<GetTaxiiFeedApiResponse, GetTaxiiFeedApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds/${queryArg.feedId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		updateTaxiiFeed: build.mutation<
+			UpdateTaxiiFeedApiResponse,
+			UpdateTaxiiFeedApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds/${queryArg.feedId}`,
+				method: "PATCH",
+				body: queryArg.updateTaxiiFeedRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
+			}),
+		}),
+		deleteTaxiiFeed: build.mutation<
+			DeleteTaxiiFeedApiResponse,
+			DeleteTaxiiFeedApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds/${queryArg.feedId}`,
+				method: "DELETE",
+			}),
+		}),
+		discoverTaxiiCollections: build.mutation<
+			DiscoverTaxiiCollectionsApiResponse,
+			DiscoverTaxiiCollectionsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds/${queryArg.feedId}/discover`,
+				method: "POST",
+			}),
+		}),
+		syncTaxiiFeed: build.mutation<
+			SyncTaxiiFeedApiResponse,
+			SyncTaxiiFeedApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds/${queryArg.feedId}/sync`,
+				method: "POST",
+			}),
+		}),
+		listTaxiiCollections: build.query<
+			ListTaxiiCollectionsApiResponse,
+			ListTaxiiCollectionsApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds/${queryArg.feedId}/collections`,
+			}),
+		}),
+		updateTaxiiCollection: build.mutation<
+			UpdateTaxiiCollectionApiResponse,
+			UpdateTaxiiCollectionApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/taxii-feeds/${queryArg.feedId}/collections/${queryArg.collectionId}`,
+				method: "PATCH",
+				body: queryArg.updateTaxiiCollectionRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		listEntities: build.query<ListEntitiesApiResponse, ListEntitiesApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/entities`,
+				params: {
+					type: queryArg.type,
+					criticality: queryArg.criticality,
+					q: queryArg.q,
+					after: queryArg.after,
+					limit: queryArg.limit,
+				},
+			}),
+		}),
+		createEntity: build.mutation<CreateEntityApiResponse, CreateEntityApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/entities`,
+				method: "POST",
+				body: queryArg.createEntityRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		importEntities: build.mutation<
+			ImportEntitiesApiResponse,
+			ImportEntitiesApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/entities/import`,
+				method: "POST",
+				body: queryArg.importEntitiesRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getEntity: build.query<GetEntityApiResponse, GetEntityApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/entities/${queryArg.entityId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		updateEntity: build.mutation<UpdateEntityApiResponse, UpdateEntityApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/entities/${queryArg.entityId}`,
+				method: "PATCH",
+				body: queryArg.updateEntityRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
+			}),
+		}),
+		deleteEntity: build.mutation<DeleteEntityApiResponse, DeleteEntityApiArg>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/entities/${queryArg.entityId}`,
+				method: "DELETE",
+			}),
+		}),
+		getEntityTimeline: build.query<
+			GetEntityTimelineApiResponse,
+			GetEntityTimelineApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/entities/${queryArg.entityId}/timeline`,
+				params: {
+					from: queryArg.from,
+					to: queryArg.to,
+				},
+			}),
+		}),
+		listRedactionPolicies: build.query<
+			ListRedactionPoliciesApiResponse,
+			ListRedactionPoliciesApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/redaction-policies`,
+			}),
+		}),
+		createRedactionPolicy: build.mutation<
+			CreateRedactionPolicyApiResponse,
+			CreateRedactionPolicyApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/redaction-policies`,
+				method: "POST",
+				body: queryArg.createRedactionPolicyRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
+			}),
+		}),
+		getRedactionPolicy: build.query<
+			GetRedactionPolicyApiResponse,
+			GetRedactionPolicyApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/redaction-policies/${queryArg.policyId}`,
+				headers: {
+					"If-None-Match": queryArg["If-None-Match"],
+				},
+			}),
+		}),
+		updateRedactionPolicy: build.mutation<
+			UpdateRedactionPolicyApiResponse,
+			UpdateRedactionPolicyApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/redaction-policies/${queryArg.policyId}`,
+				method: "PATCH",
+				body: queryArg.updateRedactionPolicyRequest,
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+					"If-Match": queryArg["If-Match"],
+				},
+			}),
+		}),
+		deleteRedactionPolicy: build.mutation<
+			DeleteRedactionPolicyApiResponse,
+			DeleteRedactionPolicyApiArg
+		>({
+			query: (queryArg) => ({
+				url: `/organizations/${queryArg.orgId}/redaction-policies/${queryArg.policyId}`,
 				method: "DELETE",
 			}),
 		}),
-		listApiKeys: build.query<ListApiKeysApiResponse, ListApiKeysApiArg>({
+		listSourceRedactions: build.query<
+			ListSourceRedactionsApiResponse,
+			ListSourceRedactionsApiArg
+		>({
 			query: (queryArg) => ({
-				url: `/organizations/${queryArg.orgId}/api-keys`,
+				url: `/organizations/${queryArg.orgId}/sources/${queryArg.sourceId}/redactions`,
+				params: {
+					from: queryArg.from,
+					to: queryArg.to,
+				},
 			}),
 		}),
-		createApiKey: build.mutation<CreateApiKeyApiResponse, CreateApiKeyApiArg>({
+		listJobs: build.query<ListJobsApiResponse, ListJobsApiArg>({
 			query: (queryArg) => ({
-				url: `/organizations/${queryArg.orgId}/api-keys`,
-				method: "POST",
-				body: queryArg.createApiKeyRequest,
+				url: `/admin/jobs`,
+				params: {
+					state: queryArg.state,
+					kind: queryArg.kind,
+					limit: queryArg.limit,
+				},
 			}),
 		}),
-		revokeApiKey: build.mutation<RevokeApiKeyApiResponse, RevokeApiKeyApiArg>({
+		getJob: build.query<GetJobApiResponse, GetJobApiArg>({
+			query: (queryArg) => ({ url: `/admin/jobs/${queryArg.jobId}` }),
+		}),
+		retryJob: build.mutation<RetryJobApiResponse, RetryJobApiArg>({
 			query: (queryArg) => ({
-				url: `/organizations/${queryArg.orgId}/api-keys/${queryArg.keyId}`,
-				method: "DELETE",
+				url: `/admin/jobs/${queryArg.jobId}/retry`,
+				method: "POST",
+				headers: {
+					"Idempotency-Key": queryArg["Idempotency-Key"],
+				},
 			}),
 		}),
 	}),
@@ -107,9 +832,16 @@ const injectedRtkApi = api.injectEndpoints({
 });
 export { injectedRtkApi as horizonApi };
 export type GetUsersMeApiResponse = /** status 200 OK */ User;
-export type GetUsersMeApiArg = void;
+export type GetUsersMeApiArg = {
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
 export type UpdateUsersMeApiResponse = /** status 200 OK */ User;
 export type UpdateUsersMeApiArg = {
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
 	updateUserRequest: UpdateUserRequest;
 };
 export type ListOrganizationsApiResponse = /** status 200 OK */ Organization[];
@@ -117,15 +849,23 @@ export type ListOrganizationsApiArg = void;
 export type CreateOrganizationApiResponse =
 	/** status 201 Created */ Organization;
 export type CreateOrganizationApiArg = {
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
 	createOrganizationRequest: CreateOrganizationRequest;
 };
 export type GetOrganizationApiResponse = /** status 200 OK */ Organization;
 export type GetOrganizationApiArg = {
 	orgId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
 };
 export type UpdateOrganizationApiResponse = /** status 200 OK */ Organization;
 export type UpdateOrganizationApiArg = {
 	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
 	updateOrganizationRequest: UpdateOrganizationRequest;
 };
 export type ListOrganizationMembersApiResponse =
@@ -133,10 +873,20 @@ export type ListOrganizationMembersApiResponse =
 export type ListOrganizationMembersApiArg = {
 	orgId: string;
 };
+export type GetOrganizationMemberApiResponse =
+	/** status 200 OK */ OrganizationMember;
+export type GetOrganizationMemberApiArg = {
+	orgId: string;
+	userId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
 export type AddOrganizationMemberApiResponse =
 	/** status 201 Created */ OrganizationMember;
 export type AddOrganizationMemberApiArg = {
 	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
 	addMemberRequest: AddMemberRequest;
 };
 export type UpdateOrganizationMemberApiResponse =
@@ -144,6 +894,10 @@ export type UpdateOrganizationMemberApiResponse =
 export type UpdateOrganizationMemberApiArg = {
 	orgId: string;
 	userId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
 	updateMemberRoleRequest: UpdateMemberRoleRequest;
 };
 export type RemoveOrganizationMemberApiResponse = unknown;
@@ -158,15 +912,482 @@ export type ListApiKeysApiArg = {
 export type CreateApiKeyApiResponse = /** status 201 Created */ CreatedApiKey;
 export type CreateApiKeyApiArg = {
 	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
 	createApiKeyRequest: CreateApiKeyRequest;
 };
+export type GetApiKeyApiResponse = /** status 200 OK */ ApiKey;
+export type GetApiKeyApiArg = {
+	orgId: string;
+	keyId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
 export type RevokeApiKeyApiResponse = unknown;
 export type RevokeApiKeyApiArg = {
 	orgId: string;
 	keyId: string;
 };
+export type ListSourcesApiResponse = /** status 200 OK */ Source[];
+export type ListSourcesApiArg = {
+	orgId: string;
+};
+export type CreateSourceApiResponse = /** status 201 Created */ CreatedSource;
+export type CreateSourceApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createSourceRequest: CreateSourceRequest;
+};
+export type GetSourceApiResponse = /** status 200 OK */ Source;
+export type GetSourceApiArg = {
+	orgId: string;
+	sourceId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
+export type UpdateSourceApiResponse = /** status 200 OK */ Source;
+export type UpdateSourceApiArg = {
+	orgId: string;
+	sourceId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
+	updateSourceRequest: UpdateSourceRequest;
+};
+export type DeleteSourceApiResponse = unknown;
+export type DeleteSourceApiArg = {
+	orgId: string;
+	sourceId: string;
+};
+export type RotateSourceTokenApiResponse = /** status 200 OK */ CreatedSource;
+export type RotateSourceTokenApiArg = {
+	orgId: string;
+	sourceId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+};
+export type GetSourceHealthApiResponse = /** status 200 OK */ SourceHealth;
+export type GetSourceHealthApiArg = {
+	orgId: string;
+	sourceId: string;
+	/** Length of the window the rates are computed over. */
+	windowMinutes?: number;
+};
+export type ListGrokPatternsApiResponse = /** status 200 OK */ GrokPattern[];
+export type ListGrokPatternsApiArg = {
+	orgId: string;
+};
+export type CreateGrokPatternApiResponse = /** status 201 Created */ GrokPattern;
+export type CreateGrokPatternApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createGrokPatternRequest: CreateGrokPatternRequest;
+};
+export type TestGrokPatternApiResponse = /** status 200 OK */ TestGrokPatternResult;
+export type TestGrokPatternApiArg = {
+	orgId: string;
+	testGrokPatternRequest: TestGrokPatternRequest;
+};
+export type GetGrokPatternApiResponse = /** status 200 OK */ GrokPattern;
+export type GetGrokPatternApiArg = {
+	orgId: string;
+	patternId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
+export type UpdateGrokPatternApiResponse = /** status 200 OK */ GrokPattern;
+export type UpdateGrokPatternApiArg = {
+	orgId: string;
+	patternId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
+	updateGrokPatternRequest: UpdateGrokPatternRequest;
+};
+export type DeleteGrokPatternApiResponse = unknown;
+export type DeleteGrokPatternApiArg = {
+	orgId: string;
+	patternId: string;
+};
+export type SetSourceMappingApiResponse = /** status 200 OK */ Source;
+export type SetSourceMappingApiArg = {
+	orgId: string;
+	sourceId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	setSourceMappingRequest: SetSourceMappingRequest;
+};
+export type ClearSourceMappingApiResponse = unknown;
+export type ClearSourceMappingApiArg = {
+	orgId: string;
+	sourceId: string;
+};
+export type ListMappingsApiResponse = /** status 200 OK */ Mapping[];
+export type ListMappingsApiArg = {
+	orgId: string;
+};
+export type CreateMappingApiResponse = /** status 201 Created */ Mapping;
+export type CreateMappingApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createMappingRequest: CreateMappingRequest;
+};
+export type PreviewMappingApiResponse = /** status 200 OK */ MappingPreviewResult;
+export type PreviewMappingApiArg = {
+	orgId: string;
+	previewMappingRequest: PreviewMappingRequest;
+};
+export type GetMappingApiResponse = /** status 200 OK */ Mapping;
+export type GetMappingApiArg = {
+	orgId: string;
+	mappingId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
+export type UpdateMappingApiResponse = /** status 200 OK */ Mapping;
+export type UpdateMappingApiArg = {
+	orgId: string;
+	mappingId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
+	updateMappingRequest: UpdateMappingRequest;
+};
+export type DeleteMappingApiResponse = unknown;
+export type DeleteMappingApiArg = {
+	orgId: string;
+	mappingId: string;
+};
+export type ListMappingVersionsApiResponse =
+	/** status 200 OK */ MappingVersion[];
+export type ListMappingVersionsApiArg = {
+	orgId: string;
+	mappingId: string;
+};
+export type CreateMappingVersionApiResponse = /** status 201 Created */ MappingVersion;
+export type CreateMappingVersionApiArg = {
+	orgId: string;
+	mappingId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createMappingVersionRequest: CreateMappingVersionRequest;
+};
+export type GetMappingVersionApiResponse = /** status 200 OK */ MappingVersion;
+export type GetMappingVersionApiArg = {
+	orgId: string;
+	mappingId: string;
+	version: number;
+};
+export type TestMappingVersionApiResponse = /** status 200 OK */ MappingTestResult;
+export type TestMappingVersionApiArg = {
+	orgId: string;
+	mappingId: string;
+	version: number;
+};
+export type ListMappingFixturesApiResponse =
+	/** status 200 OK */ MappingFixture[];
+export type ListMappingFixturesApiArg = {
+	orgId: string;
+	mappingId: string;
+};
+export type CreateMappingFixtureApiResponse = /** status 201 Created */ MappingFixture;
+export type CreateMappingFixtureApiArg = {
+	orgId: string;
+	mappingId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createMappingFixtureRequest: CreateMappingFixtureRequest;
+};
+export type DeleteMappingFixtureApiResponse = unknown;
+export type DeleteMappingFixtureApiArg = {
+	orgId: string;
+	mappingId: string;
+	fixtureId: string;
+};
+export type ListContentPacksApiResponse = /** status 200 OK */ ContentPack[];
+export type ListContentPacksApiArg = {
+	orgId: string;
+};
+export type GetContentPackApiResponse = /** status 200 OK */ ContentPack;
+export type GetContentPackApiArg = {
+	orgId: string;
+	packId: string;
+};
+export type InstallContentPackApiResponse =
+	/** status 200 OK */ ContentPackInstallResult;
+export type InstallContentPackApiArg = {
+	orgId: string;
+	packId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+};
+export type ListReplaysApiResponse = /** status 200 OK */ Replay[];
+export type ListReplaysApiArg = {
+	orgId: string;
+};
+export type CreateReplayApiResponse = /** status 202 Accepted */ Replay;
+export type CreateReplayApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createReplayRequest: CreateReplayRequest;
+};
+export type GetReplayApiResponse = /** status 200 OK */ Replay;
+export type GetReplayApiArg = {
+	orgId: string;
+	replayId: string;
+};
+export type CancelReplayApiResponse = /** status 200 OK */ Replay;
+export type CancelReplayApiArg = {
+	orgId: string;
+	replayId: string;
+};
+export type ListReplayShadowEventsApiResponse =
+	/** status 200 OK */ ReplayShadowEvent[];
+export type ListReplayShadowEventsApiArg = {
+	orgId: string;
+	replayId: string;
+	/** Event ID to continue after, from the last page. */
+	after?: string;
+	/** Only events that failed processing. */
+	failed?: boolean;
+	limit?: number;
+};
+export type ListThreatIndicatorsApiResponse =
+	/** status 200 OK */ ThreatIndicator[];
+export type ListThreatIndicatorsApiArg = {
+	orgId: string;
+	type?: ThreatIndicatorType;
+	/** Only indicators with this tag. */
+	tag?: string;
+	/** Only indicators that have not expired. */
+	active?: boolean;
+	/** Indicator ID to continue after, from the last page. */
+	after?: string;
+	limit?: number;
+};
+export type CreateThreatIndicatorApiResponse = /** status 201 Created */ ThreatIndicator;
+export type CreateThreatIndicatorApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createThreatIndicatorRequest: CreateThreatIndicatorRequest;
+};
+export type ImportThreatIndicatorsApiResponse = /** status 200 OK */ ThreatIndicatorImportResult;
+export type ImportThreatIndicatorsApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	importThreatIndicatorsRequest: ImportThreatIndicatorsRequest;
+};
+export type GetThreatIndicatorApiResponse = /** status 200 OK */ ThreatIndicator;
+export type GetThreatIndicatorApiArg = {
+	orgId: string;
+	indicatorId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
+export type UpdateThreatIndicatorApiResponse = /** status 200 OK */ ThreatIndicator;
+export type UpdateThreatIndicatorApiArg = {
+	orgId: string;
+	indicatorId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
+	updateThreatIndicatorRequest: UpdateThreatIndicatorRequest;
+};
+export type DeleteThreatIndicatorApiResponse = unknown;
+export type DeleteThreatIndicatorApiArg = {
+	orgId: string;
+	indicatorId: string;
+};
+export type ListTaxiiFeedsApiResponse = /** status 200 OK */ TaxiiFeed[];
+export type ListTaxiiFeedsApiArg = {
+	orgId: string;
+};
+export type CreateTaxiiFeedApiResponse = /** status 201 Created */ TaxiiFeed;
+export type CreateTaxiiFeedApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createTaxiiFeedRequest: CreateTaxiiFeedRequest;
+};
+export type GetTaxiiFeedApiResponse = /** status 200 OK */ TaxiiFeed;
+export type GetTaxiiFeedApiArg = {
+	orgId: string;
+	feedId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
+export type UpdateTaxiiFeedApiResponse = /** status 200 OK */ TaxiiFeed;
+export type UpdateTaxiiFeedApiArg = {
+	orgId: string;
+	feedId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
+	updateTaxiiFeedRequest: UpdateTaxiiFeedRequest;
+};
+export type DeleteTaxiiFeedApiResponse = unknown;
+export type DeleteTaxiiFeedApiArg = {
+	orgId: string;
+	feedId: string;
+};
+export type DiscoverTaxiiCollectionsApiResponse =
+	/** status 200 OK */ TaxiiCollection[];
+export type DiscoverTaxiiCollectionsApiArg = {
+	orgId: string;
+	feedId: string;
+};
+export type SyncTaxiiFeedApiResponse = /** status 202 Accepted */ TaxiiFeed;
+export type SyncTaxiiFeedApiArg = {
+	orgId: string;
+	feedId: string;
+};
+export type ListTaxiiCollectionsApiResponse =
+	/** status 200 OK */ TaxiiCollection[];
+export type ListTaxiiCollectionsApiArg = {
+	orgId: string;
+	feedId: string;
+};
+export type UpdateTaxiiCollectionApiResponse = /** status 200 OK */ TaxiiCollection;
+export type UpdateTaxiiCollectionApiArg = {
+	orgId: string;
+	feedId: string;
+	collectionId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	updateTaxiiCollectionRequest: UpdateTaxiiCollectionRequest;
+};
+export type ListEntitiesApiResponse = /** status 200 OK */ Entity[];
+export type ListEntitiesApiArg = {
+	orgId: string;
+	type?: EntityType;
+	criticality?: EntityCriticality;
+	/** Only entities whose value contains this text, ignoring case, or with it as an alias. */
+	q?: string;
+	/** Entity ID to continue after, from the last page. */
+	after?: string;
+	limit?: number;
+};
+export type CreateEntityApiResponse = /** status 201 Created */ Entity;
+export type CreateEntityApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createEntityRequest: CreateEntityRequest;
+};
+export type ImportEntitiesApiResponse = /** status 200 OK */ EntityImportResult;
+export type ImportEntitiesApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	importEntitiesRequest: ImportEntitiesRequest;
+};
+export type GetEntityApiResponse = /** status 200 OK */ Entity;
+export type GetEntityApiArg = {
+	orgId: string;
+	entityId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
+export type UpdateEntityApiResponse = /** status 200 OK */ Entity;
+export type UpdateEntityApiArg = {
+	orgId: string;
+	entityId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
+	updateEntityRequest: UpdateEntityRequest;
+};
+export type DeleteEntityApiResponse = unknown;
+export type DeleteEntityApiArg = {
+	orgId: string;
+	entityId: string;
+};
+export type GetEntityTimelineApiResponse =
+	/** status 200 OK */ EntityTimelineBucket[];
+export type GetEntityTimelineApiArg = {
+	orgId: string;
+	entityId: string;
+	/** Start of the range. Defaults to 7 days before its end. */
+	from?: string;
+	/** End of the range, at most 31 days after its start. Defaults to now. */
+	to?: string;
+};
+export type ListRedactionPoliciesApiResponse =
+	/** status 200 OK */ RedactionPolicy[];
+export type ListRedactionPoliciesApiArg = {
+	orgId: string;
+};
+export type CreateRedactionPolicyApiResponse = /** status 201 Created */ RedactionPolicy;
+export type CreateRedactionPolicyApiArg = {
+	orgId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	createRedactionPolicyRequest: CreateRedactionPolicyRequest;
+};
+export type GetRedactionPolicyApiResponse = /** status 200 OK */ RedactionPolicy;
+export type GetRedactionPolicyApiArg = {
+	orgId: string;
+	policyId: string;
+	/** Entity tag from a previous read. 304 is returned when the resource still carries this tag. */
+	"If-None-Match"?: string;
+};
+export type UpdateRedactionPolicyApiResponse = /** status 200 OK */ RedactionPolicy;
+export type UpdateRedactionPolicyApiArg = {
+	orgId: string;
+	policyId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+	/** Entity tag from a previous read. The update is applied only if the resource still carries this tag; otherwise 412 is returned. */
+	"If-Match"?: string;
+	updateRedactionPolicyRequest: UpdateRedactionPolicyRequest;
+};
+export type DeleteRedactionPolicyApiResponse = unknown;
+export type DeleteRedactionPolicyApiArg = {
+	orgId: string;
+	policyId: string;
+};
+export type ListSourceRedactionsApiResponse =
+	/** status 200 OK */ RedactionCount[];
+export type ListSourceRedactionsApiArg = {
+	orgId: string;
+	sourceId: string;
+	/** Start of the range. Defaults to 7 days before its end. */
+	from?: string;
+	/** End of the range, at most 31 days after its start. Defaults to now. */
+	to?: string;
+};
+export type ListJobsApiResponse = /** status 200 OK */ Job[];
+export type ListJobsApiArg = {
+	state?: JobState;
+	kind?: string;
+	limit?: number;
+};
+export type GetJobApiResponse = /** status 200 OK */ Job;
+export type GetJobApiArg = {
+	jobId: string;
+};
+export type RetryJobApiResponse = /** status 200 OK */ Job;
+export type RetryJobApiArg = {
+	jobId: string;
+	/** Client-chosen key that makes a retried request safe. The first response is stored per caller and replayed for retries carrying the same key and body; reusing the key with a different body is rejected with 422. */
+	"Idempotency-Key"?: string;
+};
 export type BaseEntity = {
 	id: string;
+	/** Row version, bumped on every change. Exposed as the ETag. */
+	version: number;
 	created_at: string;
 	updated_at: string;
 };
@@ -243,9 +1464,649 @@ export type CreatedApiKey = ApiKey & {
 };
 export type CreateApiKeyRequest = {
 	name: string;
-	/** Allowed values: ingest, read */
+	/** Allowed values: events:ingest, read */
 	scopes: string[];
 };
+/** Transport and format a log source sends events in. */
+export type SourceType = "syslog" | "http-json" | "cloudtrail" | "windows-evtx";
+/** Syslog listener a source receives on. */
+export type SyslogListener = "udp" | "tcp" | "tls";
+/** Where a source's events carry the time they happened. Omitted fields use the defaults: the first of @timestamp, timestamp and time, read as RFC 3339 or Unix time in UTC, accepted up to 60 minutes ahead of and 30 days behind the received time. */
+export type TimestampConfig = {
+	/** Dotted path to the time field, e.g. extensions.rt. It may be a field the source's parser extracts. */
+	field?: string;
+	/** Tried in order: rfc3339, epoch (unit told by magnitude), epoch_s, epoch_ms, epoch_us, epoch_ns, syslog (BSD, without year), filetime (Windows), or a Go layout such as 2006-01-02 15:04:05. */
+	layouts?: string[];
+	/** IANA zone for times without an offset, e.g. Europe/Berlin. */
+	timezone?: string;
+	max_future_minutes?: number;
+	max_past_days?: number;
+	/** What happens to a time outside the accepted range: clamp replaces it with the received time, flag keeps it. Either way the event is counted as out of range. */
+	out_of_range?: "clamp" | "flag";
+};
+export type Source = BaseEntity & {
+	org_id: string;
+	name: string;
+	/** Transport and format a log source sends events in. */
+	type: SourceType;
+	/** Parser applied to raw events: json, cef, leef, xml, or a grok pattern as grok:NAME. */
+	parser?: string | null;
+	/** Mapping that normalizes parsed events into OCSF, set with PUT /organizations/{orgId}/sources/{sourceId}/mapping. */
+	mapping_id?: string | null;
+	/** Active version of the mapping. */
+	mapping_version?: number | null;
+	/** Disabled sources reject incoming events. */
+	enabled: boolean;
+	/** Leading characters of the ingest token, for identification. */
+	token_prefix: string;
+	/** Listener syslog senders must use; null accepts any. */
+	syslog_listener?: SyslogListener | null;
+	/** CIDR blocks whose syslog messages belong to this source. Only used by syslog sources; the most specific match across all sources wins. */
+	syslog_senders: string[];
+	/** Where events carry their time; null uses the defaults. */
+	timestamp_config?: TimestampConfig | null;
+};
+export type CreatedSource = Source & {
+	/** The raw ingest token. Only returned once — store it securely. */
+	ingest_token: string;
+};
+export type CreateSourceRequest = {
+	name: string;
+	/** Transport and format a log source sends events in. */
+	type: SourceType;
+	parser?: string;
+	enabled?: boolean;
+	/** Syslog listener a source receives on. */
+	syslog_listener?: SyslogListener;
+	/** CIDR blocks or single addresses. */
+	syslog_senders?: string[];
+	/** Where a source's events carry the time they happened. Omitted fields use the defaults: the first of @timestamp, timestamp and time, read as RFC 3339 or Unix time in UTC, accepted up to 60 minutes ahead of and 30 days behind the received time. */
+	timestamp_config?: TimestampConfig;
+};
+/** Omitted fields are left unchanged; an empty parser or syslog_listener clears it, syslog_senders replaces the list and timestamp_config replaces the config, an empty one restoring the defaults. */
+export type UpdateSourceRequest = {
+	name?: string;
+	parser?: string;
+	enabled?: boolean;
+	/** One of udp, tcp or tls, or empty to accept any. */
+	syslog_listener?: string;
+	/** CIDR blocks or single addresses. */
+	syslog_senders?: string[];
+	/** Where a source's events carry the time they happened. Omitted fields use the defaults: the first of @timestamp, timestamp and time, read as RFC 3339 or Unix time in UTC, accepted up to 60 minutes ahead of and 30 days behind the received time. */
+	timestamp_config?: TimestampConfig;
+};
+export type SourceHealth = {
+	source_id: string;
+	last_event_at?: string | null;
+	window_minutes: number;
+	/** Events received in the window. */
+	events: number;
+	/** Events in the window that failed to parse: rejected at ingest as malformed, or by the source's parser or mapping when normalized. */
+	parse_errors: number;
+	events_per_minute: number;
+	/** Share of the window's events that failed to parse, from 0 to 1. */
+	parse_error_rate: number;
+	/** Events in the window whose time came from the event and was in range. */
+	timed_events: number;
+	/** Events in the window whose time was too far ahead or behind. */
+	out_of_range_events: number;
+	/** Mean delay from event time to receipt over timed events. */
+	ingestion_lag_avg_seconds?: number | null;
+	/** Longest delay from event time to receipt over timed events. */
+	ingestion_lag_max_seconds?: number | null;
+};
+export type GrokPattern = BaseEntity & {
+	org_id: string;
+	/** Name expressions refer to it by, as in %{NAME}. */
+	name: string;
+	/** Regular expression, which may refer to other patterns. */
+	pattern: string;
+	description?: string | null;
+};
+export type CreateGrokPatternRequest = {
+	/** Must not be the name of a built-in pattern. */
+	name: string;
+	pattern: string;
+	description?: string;
+};
+/** Omitted fields are left unchanged; an empty description clears it. */
+export type UpdateGrokPatternRequest = {
+	pattern?: string;
+	description?: string;
+};
+export type GrokSampleResult = {
+	matched: boolean;
+	/** Extracted fields, when the sample matched. */
+	fields?: {
+		[key: string]: any;
+	};
+	error?: string;
+};
+export type TestGrokPatternResult = {
+	/** One result per sample, in order. */
+	results: GrokSampleResult[];
+};
+export type TestGrokPatternRequest = {
+	/** Grok expression, e.g. %{SYSLOGBASE} %{GREEDYDATA:message}. */
+	expression: string;
+	samples: string[];
+};
+export type SetSourceMappingRequest = {
+	mapping_id: string;
+	version: number;
+};
+/** Declarative mapping from parsed fields to OCSF attributes: defaults, fields (each to a path, from a field, value or concat, through transforms), conditional rules, where the first whose condition holds applies, and unmapped, which keeps the fields no expression read. */
+export type MappingDefinition = {
+	[key: string]: any;
+};
+export type Mapping = BaseEntity & {
+	org_id: string;
+	name: string;
+	description?: string | null;
+	/** Number of the newest version. */
+	latest_version: number;
+};
+export type CreateMappingRequest = {
+	name: string;
+	description?: string;
+	/** Declarative mapping from parsed fields to OCSF attributes: defaults, fields (each to a path, from a field, value or concat, through transforms), conditional rules, where the first whose condition holds applies, and unmapped, which keeps the fields no expression read. */
+	definition: MappingDefinition;
+};
+export type OcsfFieldError = {
+	/** Dotted attribute path, e.g. src_endpoint.ip. */
+	path: string;
+	message: string;
+};
+export type MappingSampleResult = {
+	/** Whether the mapped attributes make a valid OCSF event. */
+	valid: boolean;
+	/** The OCSF event when valid, otherwise the attributes the mapping produced. Absent when the sample could not be parsed. */
+	output?: {
+		[key: string]: any;
+	};
+	errors: OcsfFieldError[];
+	/** Parsed fields that no expression of the mapping read. */
+	unmapped?: {
+		[key: string]: any;
+	};
+	/** Why the sample could not be parsed. */
+	error?: string;
+};
+export type MappingPreviewResult = {
+	/** One result per sample, in order. */
+	results: MappingSampleResult[];
+};
+/** Exactly one of definition, or mapping_id with version. */
+export type PreviewMappingRequest = {
+	/** Declarative mapping from parsed fields to OCSF attributes: defaults, fields (each to a path, from a field, value or concat, through transforms), conditional rules, where the first whose condition holds applies, and unmapped, which keeps the fields no expression read. */
+	definition?: MappingDefinition;
+	mapping_id?: string;
+	version?: number;
+	/** Parser reading each sample's message; none when omitted. */
+	parser?: string;
+	/** Events as queued, e.g. {"message": "..."} for syslog. */
+	samples: {
+		[key: string]: any;
+	}[];
+};
+/** Omitted fields are left unchanged; an empty description clears it. */
+export type UpdateMappingRequest = {
+	name?: string;
+	description?: string;
+};
+export type MappingVersion = {
+	mapping_id: string;
+	version: number;
+	/** Declarative mapping from parsed fields to OCSF attributes: defaults, fields (each to a path, from a field, value or concat, through transforms), conditional rules, where the first whose condition holds applies, and unmapped, which keeps the fields no expression read. */
+	definition: MappingDefinition;
+	created_at: string;
+};
+export type CreateMappingVersionRequest = {
+	/** Declarative mapping from parsed fields to OCSF attributes: defaults, fields (each to a path, from a field, value or concat, through transforms), conditional rules, where the first whose condition holds applies, and unmapped, which keeps the fields no expression read. */
+	definition: MappingDefinition;
+};
+export type MappingFixtureResult = {
+	fixture_id: string;
+	name: string;
+	passed: boolean;
+	/** What the version produced, as in a preview. */
+	output?: {
+		[key: string]: any;
+	};
+	/** Validation errors, and attributes that differ from the expected ones. */
+	errors: OcsfFieldError[];
+	/** Why the input could not be parsed. */
+	error?: string;
+};
+export type MappingTestResult = {
+	/** Whether every fixture passed. */
+	passed: boolean;
+	fixtures: MappingFixtureResult[];
+};
+export type MappingFixture = {
+	id: string;
+	mapping_id: string;
+	name: string;
+	/** Parser reading the input's message; none when null. */
+	parser?: string | null;
+	/** Event as queued, e.g. {"message": "..."} for syslog. */
+	input: {
+		[key: string]: any;
+	};
+	/** Attributes the OCSF event must have. Objects are compared by the attributes they list, anything else exactly. */
+	expected: {
+		[key: string]: any;
+	};
+	created_at: string;
+};
+export type CreateMappingFixtureRequest = {
+	name: string;
+	parser?: string;
+	input: {
+		[key: string]: any;
+	};
+	expected: {
+		[key: string]: any;
+	};
+};
+export type ContentPackMapping = {
+	name: string;
+	description: string;
+	/** Parser a source of these events should use. */
+	parser: string;
+	/** Number of fixtures the mapping comes with. */
+	fixtures: number;
+};
+export type ContentPack = {
+	id: string;
+	name: string;
+	description: string;
+	/** Version of the bundled pack, raised with every change to its content. */
+	version: number;
+	/** Names of the custom grok patterns the pack installs. */
+	patterns: string[];
+	mappings: ContentPackMapping[];
+	/** Version the organization last installed, or null when it has not. */
+	installed_version: number | null;
+};
+export type ContentPackItemKind = "pattern" | "mapping";
+/** What installing did to the item: created it, wrote the pack's new content to it, found it already current, kept the organization's changes to it, or skipped it because the organization deleted it. */
+export type ContentPackItemAction =
+	| "created"
+	| "updated"
+	| "unchanged"
+	| "kept"
+	| "skipped";
+export type ContentPackItemResult = {
+	kind: ContentPackItemKind;
+	name: string;
+	action: ContentPackItemAction;
+	/** The pattern or mapping, unless it was skipped. */
+	id?: string;
+	/** The pattern's row version, or the mapping's latest version. */
+	version?: number;
+};
+export type ContentPackInstallResult = {
+	pack: ContentPack;
+	/** Version installed before, or null for a first install. */
+	previous_version: number | null;
+	items: ContentPackItemResult[];
+};
+/** Where processed events go: upsert replaces the stored events, shadow keeps them aside per replay for comparison. */
+export type ReplayTarget = "upsert" | "shadow";
+export type ReplayStatus =
+	| "pending"
+	| "running"
+	| "succeeded"
+	| "failed"
+	| "cancelled";
+export type Replay = {
+	id: string;
+	org_id: string;
+	/** Source replayed; every source of the organization when null. */
+	source_id?: string | null;
+	from: string;
+	/** End of the time range, exclusive. */
+	to: string;
+	/** Mapping version pinned; each source's current mapping when null. */
+	mapping_version?: number | null;
+	target: ReplayTarget;
+	status: ReplayStatus;
+	/** Archive segments in the time range when the replay started. */
+	segments_total: number;
+	segments_done: number;
+	events_read: number;
+	events_processed: number;
+	/** Events that could not be processed. */
+	events_failed: number;
+	error?: string | null;
+	started_at?: string | null;
+	finished_at?: string | null;
+	created_at: string;
+	updated_at: string;
+};
+export type CreateReplayRequest = {
+	/** Replay one source; every source when omitted. */
+	source_id?: string;
+	from: string;
+	/** End of the time range, exclusive. */
+	to: string;
+	/** Pin a version of the source's mapping. Requires source_id. */
+	mapping_version?: number;
+	target: ReplayTarget;
+};
+export type ReplayShadowEvent = {
+	event_id: string;
+	source_id: string;
+	time: string;
+	/** The processed event, unless processing failed. */
+	output?: {
+		[key: string]: any;
+	} | null;
+	error?: string | null;
+};
+/** What the value is. A domain also matches its subdomains; a file hash is an MD5, SHA-1, SHA-256 or SHA-512 digest in hex. */
+export type ThreatIndicatorType =
+	| "ip"
+	| "cidr"
+	| "domain"
+	| "url"
+	| "file_hash"
+	| "email";
+/** Traffic Light Protocol 2.0 level the indicator may be shared at. */
+export type Tlp = "clear" | "green" | "amber" | "amber+strict" | "red";
+export type ThreatIndicator = BaseEntity & {
+	org_id: string;
+	type: ThreatIndicatorType;
+	/** The value, normalized for its type: lower-case domains, emails and hashes, and addresses and networks in canonical form. */
+	value: string;
+	/** Feed or team the indicator came from. */
+	source: string;
+	confidence: number;
+	tlp: Tlp;
+	tags: string[];
+	description?: string | null;
+	/** ID of the indicator where it was imported from, such as its STIX ID. */
+	external_id?: string | null;
+	/** When the indicator stops being matched; never when null. */
+	expires_at?: string | null;
+};
+export type CreateThreatIndicatorRequest = {
+	type: ThreatIndicatorType;
+	value: string;
+	/** Defaults to manual. */
+	source?: string;
+	/** Defaults to 50. */
+	confidence?: number;
+	tlp?: Tlp;
+	tags?: string[];
+	description?: string;
+	expires_at?: string;
+};
+/** Omitted fields are left unchanged; an empty description clears it, and clear_expiry makes the indicator never expire. */
+export type UpdateThreatIndicatorRequest = {
+	source?: string;
+	confidence?: number;
+	tlp?: Tlp;
+	tags?: string[];
+	description?: string;
+	expires_at?: string;
+	clear_expiry?: boolean;
+};
+/** csv has a header row naming its columns: value, and optionally type, source, confidence, tlp, tags (separated by ;), description and expires_at. stix is a STIX 2.1 bundle, whose indicators with STIX patterns are imported. misp is a MISP event export, whose attributes marked for IDS are imported. */
+export type ThreatIndicatorImportFormat = "csv" | "stix" | "misp";
+export type ImportThreatIndicatorsRequest = {
+	format: ThreatIndicatorImportFormat;
+	/** The file imported. */
+	content: string;
+	/** Source of indicators the file does not name one for. Defaults to the format's name. */
+	source?: string;
+	/** Confidence of indicators the file does not give one for. Defaults to 50. */
+	confidence?: number;
+	tlp?: Tlp;
+	/** Tags added to every indicator imported. */
+	tags?: string[];
+	/** Expiry of indicators the file does not give one for. */
+	expires_at?: string;
+};
+export type ThreatIndicatorImportError = {
+	/** Where the entry is, such as a CSV line or a STIX ID. */
+	entry: string;
+	message: string;
+};
+export type ThreatIndicatorImportResult = {
+	created: number;
+	updated: number;
+	/** Entries that are not indicators of a supported type. */
+	skipped: number;
+	/** Entries that could not be imported, at most 100. */
+	errors: ThreatIndicatorImportError[];
+};
+/** How requests to the server authenticate: not at all, with HTTP basic auth, or with a bearer token. */
+export type TaxiiAuthType = "none" | "basic" | "token";
+export type TaxiiFeed = BaseEntity & {
+	org_id: string;
+	/** Source of the indicators imported from the feed. */
+	name: string;
+	discovery_url: string;
+	auth_type: TaxiiAuthType;
+	username?: string | null;
+	/** Whether a password or token is stored. It is never returned. */
+	has_secret: boolean;
+	poll_interval_minutes: number;
+	enabled: boolean;
+	/** Confidence of indicators that do not give one; 50 when null. */
+	confidence?: number | null;
+	/** TLP level of indicators not marked with one; amber when null. */
+	tlp?: Tlp | null;
+	/** Tags added to every indicator imported. */
+	tags: string[];
+	next_poll_at: string;
+	last_polled_at?: string | null;
+	/** Why the last poll failed to discover the feed's collections. */
+	error?: string | null;
+};
+export type CreateTaxiiFeedRequest = {
+	name: string;
+	/** http or https URL of the server's discovery resource. */
+	discovery_url: string;
+	auth_type?: TaxiiAuthType;
+	/** Required for basic auth. */
+	username?: string;
+	/** Password for basic auth or token for token auth, stored encrypted. */
+	secret?: string;
+	/** Defaults to 60. */
+	poll_interval_minutes?: number;
+	/** Defaults to true. */
+	enabled?: boolean;
+	confidence?: number;
+	tlp?: Tlp;
+	tags?: string[];
+};
+/** Omitted fields are left unchanged. Changing the auth type discards the stored credentials, so a change to basic or token auth must give its secret. */
+export type UpdateTaxiiFeedRequest = {
+	name?: string;
+	discovery_url?: string;
+	auth_type?: TaxiiAuthType;
+	username?: string;
+	secret?: string;
+	poll_interval_minutes?: number;
+	enabled?: boolean;
+	confidence?: number;
+	tlp?: Tlp;
+	tags?: string[];
+};
+export type TaxiiCollection = {
+	id: string;
+	org_id: string;
+	feed_id: string;
+	api_root: string;
+	/** The collection's ID on the server. */
+	collection_id: string;
+	title: string;
+	description?: string | null;
+	can_read: boolean;
+	enabled: boolean;
+	/** When the server added the last object synced; the next sync asks for objects added after it. Null until the first object is synced. */
+	added_after?: string | null;
+	last_synced_at?: string | null;
+	/** Why the last sync of the collection failed. */
+	error?: string | null;
+	indicators_created: number;
+	indicators_updated: number;
+	created_at: string;
+	updated_at: string;
+};
+export type UpdateTaxiiCollectionRequest = {
+	enabled?: boolean;
+	/** Forget the sync state, so the next sync reads the collection from the start. */
+	resync?: boolean;
+};
+/** What the entity is. A host is named by its hostname, an IP by its address, a user by their name and a process by its executable's path or name. */
+export type EntityType = "host" | "ip" | "user" | "process";
+/** How much the organization depends on the entity. */
+export type EntityCriticality = "low" | "medium" | "high" | "critical";
+export type Entity = BaseEntity & {
+	org_id: string;
+	type: EntityType;
+	/** The value, normalized for its type: addresses in canonical form, and everything else in lower case. */
+	value: string;
+	/** Other identifiers of the entity, such as the addresses and IDs a host was observed with, or a user's email address. */
+	aliases: string[];
+	criticality?: EntityCriticality | null;
+	owner?: string | null;
+	business_unit?: string | null;
+	tags: string[];
+	/** Time of the earliest event mentioning the entity; null until it is observed. */
+	first_seen_at?: string | null;
+	/** Time of the latest event mentioning the entity; null until it is observed. */
+	last_seen_at?: string | null;
+};
+export type CreateEntityRequest = {
+	type: EntityType;
+	value: string;
+	aliases?: string[];
+	criticality?: EntityCriticality;
+	owner?: string;
+	business_unit?: string;
+	tags?: string[];
+};
+/** Omitted fields are left unchanged; an empty owner or business unit clears it, and clear_criticality removes the criticality. */
+export type UpdateEntityRequest = {
+	aliases?: string[];
+	criticality?: EntityCriticality;
+	clear_criticality?: boolean;
+	owner?: string;
+	business_unit?: string;
+	tags?: string[];
+};
+export type ImportEntitiesRequest = {
+	/** A CSV file with a header row naming its columns: type and value, and optionally criticality, owner, business_unit, and tags and aliases separated by semicolons. Empty cells leave what the entity has. */
+	content: string;
+};
+export type EntityImportError = {
+	/** The CSV line of the row. */
+	entry: string;
+	message: string;
+};
+export type EntityImportResult = {
+	created: number;
+	updated: number;
+	/** Rows that could not be imported, at most 100. */
+	errors: EntityImportError[];
+};
+export type EntityTimelineBucket = {
+	/** Start of the hour counted. */
+	bucket: string;
+	class_uid: number;
+	class_name: string;
+	/** Events of the class processed in the hour that mentioned the entity. */
+	events: number;
+};
+/** When a policy applies. Archive policies redact events as they are ingested, before they are queued, so the archive and everything processed from it only hold the redacted event. Normalization policies redact the fields parsed from an event before it is mapped, and leave the archived event as it was received. */
+export type RedactionStage = "archive" | "normalization";
+/** What a rule does to what it matches: drop removes the field, hash replaces the value with its HMAC-SHA256 under a key kept for the organization, mask replaces its letters and digits with asterisks, and scrub replaces it with the rule's replacement. */
+export type RedactionAction = "drop" | "hash" | "mask" | "scrub";
+/** A built-in detector of sensitive values in text: email addresses, payment card numbers that pass the Luhn check, AWS access key IDs and secret access keys, and JSON Web Tokens. */
+export type RedactionDetector = "email" | "credit_card" | "aws_key" | "jwt";
+/** With a detector or pattern, the rule acts on each match in the text of its fields, and drop removes the fields with a match. Without one, it acts on the whole value of its fields, which it must name; values that are not text are hashed or masked as their JSON. */
+export type RedactionRule = {
+	/** Names the rule in the counts of its redactions; unique in the policy. */
+	name: string;
+	action: RedactionAction;
+	/** Paths of the fields the rule applies to, with nested fields separated by dots. Every field when empty. */
+	fields?: string[];
+	detector?: RedactionDetector;
+	/** A regular expression (RE2 syntax) matching what to redact, instead of a detector. */
+	pattern?: string;
+	/** What scrub replaces matches with. Defaults to [REDACTED]. */
+	replacement?: string;
+	/** Letters and digits mask leaves at the start of a value. */
+	keep_first?: number;
+	/** Letters and digits mask leaves at the end of a value. */
+	keep_last?: number;
+};
+export type RedactionPolicy = BaseEntity & {
+	org_id: string;
+	name: string;
+	description?: string | null;
+	enabled: boolean;
+	stage: RedactionStage;
+	/** The sources whose events the policy applies to; all of them when empty. */
+	source_ids: string[];
+	/** Applied in order, after those of policies before it by name. */
+	rules: RedactionRule[];
+};
+export type CreateRedactionPolicyRequest = {
+	name: string;
+	description?: string;
+	/** Defaults to true. */
+	enabled?: boolean;
+	stage: RedactionStage;
+	source_ids?: string[];
+	rules: RedactionRule[];
+};
+/** Omitted fields are left unchanged; an empty description clears it, and rules and source_ids replace those the policy has. */
+export type UpdateRedactionPolicyRequest = {
+	name?: string;
+	description?: string;
+	enabled?: boolean;
+	stage?: RedactionStage;
+	source_ids?: string[];
+	rules?: RedactionRule[];
+};
+export type RedactionCount = {
+	/** Start of the hour counted. */
+	bucket: string;
+	policy_id: string;
+	/** The policy's name; null once it is deleted. */
+	policy_name?: string | null;
+	rule: string;
+	stage: RedactionStage;
+	/** Values the rule redacted in the source's events in the hour. */
+	redactions: number;
+};
+export type JobState = "pending" | "running" | "succeeded" | "dead";
+export type Job = {
+	id: string;
+	kind: string;
+	payload: {
+		[key: string]: any;
+	};
+	/** Lifecycle state of a background job. Failed attempts return the job to pending with a backoff; it becomes dead once its attempts are used up. */
+	state: JobState;
+	attempts: number;
+	max_attempts: number;
+	/** Earliest time the job may next run. */
+	run_at: string;
+	unique_key?: string | null;
+	last_error?: string | null;
+	locked_by?: string | null;
+	locked_at?: string | null;
+	finished_at?: string | null;
+	created_at: string;
+	updated_at: string;
+};
 export const {
 	useGetUsersMeQuery,
 	useLazyGetUsersMeQuery,
@@ -258,11 +2119,110 @@ export const {
 	useUpdateOrganizationMutation,
 	useListOrganizationMembersQuery,
 	useLazyListOrganizationMembersQuery,
+	useGetOrganizationMemberQuery,
+	useLazyGetOrganizationMemberQuery,
 	useAddOrganizationMemberMutation,
 	useUpdateOrganizationMemberMutation,
 	useRemoveOrganizationMemberMutation,
 	useListApiKeysQuery,
 	useLazyListApiKeysQuery,
 	useCreateApiKeyMutation,
+	useGetApiKeyQuery,
+	useLazyGetApiKeyQuery,
 	useRevokeApiKeyMutation,
+	useListSourcesQuery,
+	useLazyListSourcesQuery,
+	useCreateSourceMutation,
+	useGetSourceQuery,
+	useLazyGetSourceQuery,
+	useUpdateSourceMutation,
+	useDeleteSourceMutation,
+	useRotateSourceTokenMutation,
+	useGetSourceHealthQuery,
+	useLazyGetSourceHealthQuery,
+	useListGrokPatternsQuery,
+	useLazyListGrokPatternsQuery,
+	useCreateGrokPatternMutation,
+	useTestGrokPatternMutation,
+	useGetGrokPatternQuery,
+	useLazyGetGrokPatternQuery,
+	useUpdateGrokPatternMutation,
+	useDeleteGrokPatternMutation,
+	useSetSourceMappingMutation,
+	useClearSourceMappingMutation,
+	useListMappingsQuery,
+	useLazyListMappingsQuery,
+	useCreateMappingMutation,
+	usePreviewMappingMutation,
+	useGetMappingQuery,
+	useLazyGetMappingQuery,
+	useUpdateMappingMutation,
+	useDeleteMappingMutation,
+	useListMappingVersionsQuery,
+	useLazyListMappingVersionsQuery,
+	useCreateMappingVersionMutation,
+	useGetMappingVersionQuery,
+	useLazyGetMappingVersionQuery,
+	useTestMappingVersionMutation,
+	useListMappingFixturesQuery,
+	useLazyListMappingFixturesQuery,
+	useCreateMappingFixtureMutation,
+	useDeleteMappingFixtureMutation,
+	useListContentPacksQuery,
+	useLazyListContentPacksQuery,
+	useGetContentPackQuery,
+	useLazyGetContentPackQuery,
+	useInstallContentPackMutation,
+	useListReplaysQuery,
+	useLazyListReplaysQuery,
+	useCreateReplayMutation,
+	useGetReplayQuery,
+	useLazyGetReplayQuery,
+	useCancelReplayMutation,
+	useListReplayShadowEventsQuery,
+	useLazyListReplayShadowEventsQuery,
+	useListThreatIndicatorsQuery,
+	useLazyListThreatIndicatorsQuery,
+	useCreateThreatIndicatorMutation,
+	useImportThreatIndicatorsMutation,
+	useGetThreatIndicatorQuery,
+	useLazyGetThreatIndicatorQuery,
+	useUpdateThreatIndicatorMutation,
+	useDeleteThreatIndicatorMutation,
+	useListTaxiiFeedsQuery,
+	useLazyListTaxiiFeedsQuery,
+	useCreateTaxiiFeedMutation,
+	useGetTaxiiFeedQuery,
+	useLazyGetTaxiiFeedQuery,
+	useUpdateTaxiiFeedMutation,
+	useDeleteTaxiiFeedMutation,
+	useDiscoverTaxiiCollectionsMutation,
+	useSyncTaxiiFeedMutation,
+	useListTaxiiCollectionsQuery,
+	useLazyListTaxiiCollectionsQuery,
+	useUpdateTaxiiCollectionMutation,
+	useListEntitiesQuery,
+	useLazyListEntitiesQuery,
+	useCreateEntityMutation,
+	useImportEntitiesMutation,
+	useGetEntityQuery,
+	useLazyGetEntityQuery,
+	useUpdateEntityMutation,
+	useDeleteEntityMutation,
+	useGetEntityTimelineQuery,
+	useLazyGetEntityTimelineQuery,
+	useListRedactionPoliciesQuery,
+	useLazyListRedactionPoliciesQuery,
+	useCreateRedactionPolicyMutation,
+	useGetRedactionPolicyQuery,
+	useLazyGetRedactionPolicyQuery,
+	useUpdateRedactionPolicyMutation,
+	useDeleteRedactionPolicyMutation,
+	useListSourceRedactionsQuery,
+	useLazyListSourceRedactionsQuery,
+	useListJobsQuery,
+	useLazyListJobsQuery,
+	useGetJobQuery,
+	useLazyGetJobQuery,
+	useRetryJobMutation,
 } = injectedRtkApi;
@@ -5,6 +5,8 @@ const config = {
 	apiImportSpecifier: "@/store/api/base",
 	outputFile: "./api.generated.ts",
 	exportName: "horizonApi",
+	// ingestion is for log shippers authenticating with API keys
+	filterEndpoints: (name) => name !== "ingestEvents",
 	hooks: {
 		queries: true,
 		lazyQueries: true,
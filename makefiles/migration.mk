@@ -9,19 +9,19 @@ mcreate: ## Creates a sequential migration in the server directory using goose
 mup: ## Runs the latest migrations that have yet to be ran
 	@$(call use_env,local) \
 		&& cd ./server && \
-		go tool goose -dir migrations postgres "$$DATABASE_URL" up && \
-		go tool jet -dsn="$$DATABASE_URL" -schema=public -path=./generated
+		go tool goose -dir migrations postgres "$${DATABASE_PRIVILEGED_URL:-$$DATABASE_URL}" up && \
+		go tool jet -dsn="$${DATABASE_PRIVILEGED_URL:-$$DATABASE_URL}" -schema=public -path=./generated
 	@$(MAKE) format-server
 
 mdown: ## Rollback database migrations by 1
 	@$(call use_env,local) \
 		&& cd ./server && \
-		go tool goose -dir migrations postgres "$$DATABASE_URL" down
+		go tool goose -dir migrations postgres "$${DATABASE_PRIVILEGED_URL:-$$DATABASE_URL}" down
 
 mstatus: ## Gets the migration status with goose
 	@$(call use_env,local) \
 		&& cd ./server && \
-		go tool goose -dir migrations postgres "$$DATABASE_URL" status
+		go tool goose -dir migrations postgres "$${DATABASE_PRIVILEGED_URL:-$$DATABASE_URL}" status
 
 mreset: ## Recreates the database (WARNING - This command drops the database)
 	@$(call use_env,local) \
@@ -1,13 +1,40 @@
 package main
 
 import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/luketeo/horizon/internal/boot"
 	"github.com/luketeo/horizon/internal/config"
 )
 
 func main() {
 	c := config.NewConfig()
-	s := boot.NewServer(c)
+	pipe := boot.NewPipeline(c)
+	s := boot.NewServer(c, pipe)
+	w := boot.NewWorker(c, pipe)
+	sl := boot.NewSyslog(c)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workerDone := make(chan struct{})
+	go func() {
+		w.Start(ctx)
+		close(workerDone)
+	}()
+	syslogDone := make(chan struct{})
+	go func() {
+		sl.Start(ctx)
+		close(syslogDone)
+	}()
+	go s.Start()
 
-	s.Start()
+	// on shutdown, let in-flight jobs finish and pending syslog events be
+	// queued before exiting
+	<-ctx.Done()
+	<-workerDone
+	<-syslogDone
 }
@@ -24,4 +24,5 @@ type APIKeys struct {
 	RevokedAt  *time.Time
 	CreatedAt  time.Time
 	UpdatedAt  time.Time
+	Version    int64
 }
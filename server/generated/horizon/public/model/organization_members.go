@@ -20,4 +20,5 @@ type OrganizationMembers struct {
 	Role      string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Version   int64
 }
@@ -21,4 +21,5 @@ type Organizations struct {
 	Settings  string
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Version   int64
 }
@@ -23,4 +23,5 @@ type Users struct {
 	LastLoginAt *time.Time
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
+	Version     int64
 }
@@ -26,6 +26,7 @@ type aPIKeysTable struct {
 	RevokedAt  postgres.ColumnTimestampz
 	CreatedAt  postgres.ColumnTimestampz
 	UpdatedAt  postgres.ColumnTimestampz
+	Version    postgres.ColumnInteger
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -76,9 +77,10 @@ func newAPIKeysTableImpl(schemaName, tableName, alias string) aPIKeysTable {
 		RevokedAtColumn  = postgres.TimestampzColumn("revoked_at")
 		CreatedAtColumn  = postgres.TimestampzColumn("created_at")
 		UpdatedAtColumn  = postgres.TimestampzColumn("updated_at")
-		allColumns       = postgres.ColumnList{IDColumn, OrgIDColumn, NameColumn, KeyHashColumn, ScopesColumn, LastUsedAtColumn, RevokedAtColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns   = postgres.ColumnList{OrgIDColumn, NameColumn, KeyHashColumn, ScopesColumn, LastUsedAtColumn, RevokedAtColumn, CreatedAtColumn, UpdatedAtColumn}
-		defaultColumns   = postgres.ColumnList{IDColumn, ScopesColumn, CreatedAtColumn, UpdatedAtColumn}
+		VersionColumn    = postgres.IntegerColumn("version")
+		allColumns       = postgres.ColumnList{IDColumn, OrgIDColumn, NameColumn, KeyHashColumn, ScopesColumn, LastUsedAtColumn, RevokedAtColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
+		mutableColumns   = postgres.ColumnList{OrgIDColumn, NameColumn, KeyHashColumn, ScopesColumn, LastUsedAtColumn, RevokedAtColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
+		defaultColumns   = postgres.ColumnList{IDColumn, ScopesColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
 	)
 
 	return aPIKeysTable{
@@ -94,6 +96,7 @@ func newAPIKeysTableImpl(schemaName, tableName, alias string) aPIKeysTable {
 		RevokedAt:  RevokedAtColumn,
 		CreatedAt:  CreatedAtColumn,
 		UpdatedAt:  UpdatedAtColumn,
+		Version:    VersionColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
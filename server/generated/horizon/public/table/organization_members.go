@@ -23,6 +23,7 @@ type organizationMembersTable struct {
 	Role      postgres.ColumnString
 	CreatedAt postgres.ColumnTimestampz
 	UpdatedAt postgres.ColumnTimestampz
+	Version   postgres.ColumnInteger
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -70,9 +71,10 @@ func newOrganizationMembersTableImpl(schemaName, tableName, alias string) organi
 		RoleColumn      = postgres.StringColumn("role")
 		CreatedAtColumn = postgres.TimestampzColumn("created_at")
 		UpdatedAtColumn = postgres.TimestampzColumn("updated_at")
-		allColumns      = postgres.ColumnList{IDColumn, OrgIDColumn, UserIDColumn, RoleColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns  = postgres.ColumnList{OrgIDColumn, UserIDColumn, RoleColumn, CreatedAtColumn, UpdatedAtColumn}
-		defaultColumns  = postgres.ColumnList{IDColumn, RoleColumn, CreatedAtColumn, UpdatedAtColumn}
+		VersionColumn   = postgres.IntegerColumn("version")
+		allColumns      = postgres.ColumnList{IDColumn, OrgIDColumn, UserIDColumn, RoleColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
+		mutableColumns  = postgres.ColumnList{OrgIDColumn, UserIDColumn, RoleColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
+		defaultColumns  = postgres.ColumnList{IDColumn, RoleColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
 	)
 
 	return organizationMembersTable{
@@ -85,6 +87,7 @@ func newOrganizationMembersTableImpl(schemaName, tableName, alias string) organi
 		Role:      RoleColumn,
 		CreatedAt: CreatedAtColumn,
 		UpdatedAt: UpdatedAtColumn,
+		Version:   VersionColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
@@ -24,6 +24,7 @@ type organizationsTable struct {
 	Settings  postgres.ColumnString
 	CreatedAt postgres.ColumnTimestampz
 	UpdatedAt postgres.ColumnTimestampz
+	Version   postgres.ColumnInteger
 
 	AllColumns     postgres.ColumnList
 	MutableColumns postgres.ColumnList
@@ -72,9 +73,10 @@ func newOrganizationsTableImpl(schemaName, tableName, alias string) organization
 		SettingsColumn  = postgres.StringColumn("settings")
 		CreatedAtColumn = postgres.TimestampzColumn("created_at")
 		UpdatedAtColumn = postgres.TimestampzColumn("updated_at")
-		allColumns      = postgres.ColumnList{IDColumn, NameColumn, SlugColumn, PlanColumn, SettingsColumn, CreatedAtColumn, UpdatedAtColumn}
-		mutableColumns  = postgres.ColumnList{NameColumn, SlugColumn, PlanColumn, SettingsColumn, CreatedAtColumn, UpdatedAtColumn}
-		defaultColumns  = postgres.ColumnList{IDColumn, PlanColumn, SettingsColumn, CreatedAtColumn, UpdatedAtColumn}
+		VersionColumn   = postgres.IntegerColumn("version")
+		allColumns      = postgres.ColumnList{IDColumn, NameColumn, SlugColumn, PlanColumn, SettingsColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
+		mutableColumns  = postgres.ColumnList{NameColumn, SlugColumn, PlanColumn, SettingsColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
+		defaultColumns  = postgres.ColumnList{IDColumn, PlanColumn, SettingsColumn, CreatedAtColumn, UpdatedAtColumn, VersionColumn}
 	)
 
 	return organizationsTable{
@@ -88,6 +90,7 @@ func newOrganizationsTableImpl(schemaName, tableName, alias string) organization
 		Settings:  SettingsColumn,
 		CreatedAt: CreatedAtColumn,
 		UpdatedAt: UpdatedAtColumn,
+		Version:   VersionColumn,
 
 		AllColumns:     allColumns,
 		MutableColumns: mutableColumns,
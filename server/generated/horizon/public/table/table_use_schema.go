@@ -15,4 +15,34 @@ func UseSchema(schema string) {
 	OrganizationMembers = OrganizationMembers.FromSchema(schema)
 	Organizations = Organizations.FromSchema(schema)
 	Users = Users.FromSchema(schema)
+	IdempotencyKeys = IdempotencyKeys.FromSchema(schema)
+	Jobs = Jobs.FromSchema(schema)
+	OutboxEvents = OutboxEvents.FromSchema(schema)
+	OutboxCheckpoints = OutboxCheckpoints.FromSchema(schema)
+	Sources = Sources.FromSchema(schema)
+	SourceStats = SourceStats.FromSchema(schema)
+	IngestEvents = IngestEvents.FromSchema(schema)
+	IngestCursors = IngestCursors.FromSchema(schema)
+	IngestDeadLetters = IngestDeadLetters.FromSchema(schema)
+	GrokPatterns = GrokPatterns.FromSchema(schema)
+	ArchiveSegments = ArchiveSegments.FromSchema(schema)
+	Replays = Replays.FromSchema(schema)
+	ReplayShadowEvents = ReplayShadowEvents.FromSchema(schema)
+	Mappings = Mappings.FromSchema(schema)
+	MappingVersions = MappingVersions.FromSchema(schema)
+	MappingFixtures = MappingFixtures.FromSchema(schema)
+	ContentPackInstalls = ContentPackInstalls.FromSchema(schema)
+	ContentPackItems = ContentPackItems.FromSchema(schema)
+	ThreatIndicators = ThreatIndicators.FromSchema(schema)
+	ThreatIndicatorRevisions = ThreatIndicatorRevisions.FromSchema(schema)
+	TaxiiFeeds = TaxiiFeeds.FromSchema(schema)
+	TaxiiCollections = TaxiiCollections.FromSchema(schema)
+	Entities = Entities.FromSchema(schema)
+	EntitySightings = EntitySightings.FromSchema(schema)
+	EntityRevisions = EntityRevisions.FromSchema(schema)
+	RedactionPolicies = RedactionPolicies.FromSchema(schema)
+	RedactionSalts = RedactionSalts.FromSchema(schema)
+	RedactionRevisions = RedactionRevisions.FromSchema(schema)
+	RedactionStats = RedactionStats.FromSchema(schema)
+	Events = Events.FromSchema(schema)
 }
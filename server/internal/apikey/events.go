@@ -0,0 +1,40 @@
+package apikey
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per key. Payloads never
+// include key material.
+const (
+	aggregateType = "api_key"
+
+	EventCreated = "api_key.created"
+	EventRevoked = "api_key.revoked"
+)
+
+// RevokedPayload is the payload of EventRevoked.
+type RevokedPayload struct {
+	ID    uuid.UUID `json:"id"`
+	OrgID uuid.UUID `json:"org_id"`
+}
+
+// emit appends an event about keyID to the outbox, in the transaction on ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, keyID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   keyID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/luketeo/horizon/internal/platform/authz"
 	"github.com/luketeo/horizon/internal/platform/httpx"
 	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -28,31 +29,33 @@ func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handl
 	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
 }
 
-// requireMembership resolves identity and org membership in one step.
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
 func (h *Handler) requireMembership(
 	ctx context.Context,
 	orgID uuid.UUID,
-) (oapi.OrgRole, bool) {
+) (context.Context, oapi.OrgRole, bool) {
 	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
 	if !ok {
-		return "", false
+		return ctx, "", false
 	}
 	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
 	if err != nil {
-		return "", false
+		return ctx, "", false
 	}
+	ctx = tenant.WithUser(ctx, userID)
 	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
 	if err != nil {
-		return "", false
+		return ctx, "", false
 	}
-	return role, true
+	return tenant.WithOrg(ctx, orgID), role, true
 }
 
 func (h *Handler) ListApiKeys(
 	ctx context.Context,
 	request oapi.ListApiKeysRequestObject,
 ) (oapi.ListApiKeysResponseObject, error) {
-	role, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.ListApiKeys403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
@@ -82,7 +85,7 @@ func (h *Handler) CreateApiKey(
 	ctx context.Context,
 	request oapi.CreateApiKeyRequestObject,
 ) (oapi.CreateApiKeyResponseObject, error) {
-	role, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.CreateApiKey403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
@@ -105,11 +108,52 @@ func (h *Handler) CreateApiKey(
 	return oapi.CreateApiKey201JSONResponse(key), nil
 }
 
+func (h *Handler) GetApiKey(
+	ctx context.Context,
+	request oapi.GetApiKeyRequestObject,
+) (oapi.GetApiKeyResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetApiKey403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
+				httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+			),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.GetApiKey403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
+				httpx.Prob(403, "Forbidden", "Admin or owner role required to view API keys"),
+			),
+		}, nil
+	}
+
+	key, err := h.svc.Get(ctx, request.OrgId, request.KeyId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetApiKey404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: oapi.NotFoundApplicationProblemPlusJSONResponse(
+					httpx.Prob(404, "Not Found", "API key not found"),
+				),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(key.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetApiKey304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetApiKey200JSONResponse{
+		Body:    key,
+		Headers: oapi.GetApiKey200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
 func (h *Handler) RevokeApiKey(
 	ctx context.Context,
 	request oapi.RevokeApiKeyRequestObject,
 ) (oapi.RevokeApiKeyResponseObject, error) {
-	role, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.RevokeApiKey403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
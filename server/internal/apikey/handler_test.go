@@ -12,7 +12,10 @@ import (
 	"github.com/luketeo/horizon/internal/apikey"
 	"github.com/luketeo/horizon/internal/org"
 	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
 	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -24,8 +27,8 @@ func newSeededHandler(t *testing.T) (*apikey.Handler, oapi.Organization) {
 	ctx := context.Background()
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
 
-	userSvc := user.NewService(user.NewRepo(db), logger)
-	orgSvc := org.NewService(org.NewRepo(db), logger)
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
 	_, userID, err := userSvc.GetOrCreateUser(
 		ctx,
 		fakeClerkUser("user_apikey_handler_owner", "ownr@example.com"),
@@ -33,12 +36,12 @@ func newSeededHandler(t *testing.T) (*apikey.Handler, oapi.Organization) {
 	if err != nil {
 		t.Fatalf("seed user: %v", err)
 	}
-	o, err := orgSvc.CreateOrg(ctx, "Apikey Handler Org", nil, userID)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), "Apikey Handler Org", nil, userID)
 	if err != nil {
 		t.Fatalf("seed org: %v", err)
 	}
 
-	svc := apikey.NewService(apikey.NewRepo(db), logger)
+	svc := apikey.NewService(apikey.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
 	return apikey.NewHandler(svc, userSvc, orgSvc), o
 }
 
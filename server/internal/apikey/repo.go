@@ -2,25 +2,28 @@ package apikey
 
 import (
 	"context"
-	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 
 	"github.com/luketeo/horizon/generated/horizon/public/model"
 	"github.com/luketeo/horizon/generated/horizon/public/table"
 	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
 )
 
-// Repo owns api_keys SQL and row→DTO mapping.
+// Repo owns api_keys SQL and row→DTO mapping. api_keys is protected by RLS,
+// so callers run its statements inside tx.Manager.RunInTx.
 type Repo struct {
-	db *sql.DB
+	db qrm.DB
 }
 
 // NewRepo wires a Repo to the given database.
-func NewRepo(db *sql.DB) *Repo {
+func NewRepo(db qrm.DB) *Repo {
 	return &Repo{db: db}
 }
 
@@ -33,6 +36,7 @@ func toOapiApiKey(m model.APIKeys) oapi.ApiKey {
 		Scopes:      []string(m.Scopes),
 		LastUsedAt:  m.LastUsedAt,
 		RevokedAt:   m.RevokedAt,
+		Version:     m.Version,
 		CreatedAt:   m.CreatedAt,
 		UpdatedAt:   m.UpdatedAt,
 	}
@@ -59,12 +63,14 @@ func (r *Repo) Insert(
 			table.APIKeys.OrgID,
 			table.APIKeys.Name,
 			table.APIKeys.Scopes,
+			table.APIKeys.Version,
 			table.APIKeys.CreatedAt,
 			table.APIKeys.UpdatedAt,
 		)
 
 	var out model.APIKeys
-	if err := stmt.QueryContext(ctx, r.db, &out); err != nil {
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &out)
+	if err != nil {
 		return oapi.CreatedApiKey{}, fmt.Errorf("inserting api key: %w", err)
 	}
 	return oapi.CreatedApiKey{
@@ -72,6 +78,7 @@ func (r *Repo) Insert(
 		OrgId:     out.OrgID,
 		Name:      out.Name,
 		Scopes:    []string(out.Scopes),
+		Version:   out.Version,
 		CreatedAt: out.CreatedAt,
 		UpdatedAt: out.UpdatedAt,
 	}, nil
@@ -89,7 +96,8 @@ func (r *Repo) ListActive(ctx context.Context, orgID uuid.UUID) ([]oapi.ApiKey,
 		ORDER_BY(table.APIKeys.CreatedAt.DESC())
 
 	var rows []model.APIKeys
-	if err := stmt.QueryContext(ctx, r.db, &rows); err != nil {
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows)
+	if err != nil {
 		return nil, fmt.Errorf("listing api keys: %w", err)
 	}
 
@@ -100,19 +108,42 @@ func (r *Repo) ListActive(ctx context.Context, orgID uuid.UUID) ([]oapi.ApiKey,
 	return keys, nil
 }
 
+// Get returns a single key of the organisation, revoked or not. Returns
+// ErrNotFound if the key does not exist in that organisation.
+func (r *Repo) Get(ctx context.Context, orgID, keyID uuid.UUID) (oapi.ApiKey, error) {
+	stmt := postgres.
+		SELECT(table.APIKeys.AllColumns).
+		FROM(table.APIKeys).
+		WHERE(
+			table.APIKeys.ID.EQ(postgres.UUID(keyID)).
+				AND(table.APIKeys.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.APIKeys
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.ApiKey{}, ErrNotFound
+		}
+		return oapi.ApiKey{}, fmt.Errorf("getting api key: %w", err)
+	}
+	return toOapiApiKey(row), nil
+}
+
 // Revoke soft-deletes a key by stamping revoked_at. Returns ErrNotFound if the
 // key is unknown or has already been revoked.
 func (r *Repo) Revoke(ctx context.Context, orgID, keyID uuid.UUID) error {
 	stmt := table.APIKeys.
-		UPDATE(table.APIKeys.RevokedAt).
-		SET(postgres.NOW()).
+		UPDATE(table.APIKeys.RevokedAt, table.APIKeys.Version, table.APIKeys.UpdatedAt).
+		SET(postgres.NOW(), table.APIKeys.Version.ADD(postgres.Int(1)), postgres.NOW()).
 		WHERE(
 			table.APIKeys.ID.EQ(postgres.UUID(keyID)).
 				AND(table.APIKeys.OrgID.EQ(postgres.UUID(orgID))).
 				AND(table.APIKeys.RevokedAt.IS_NULL()),
 		)
 
-	res, err := stmt.ExecContext(ctx, r.db)
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
 	if err != nil {
 		return fmt.Errorf("revoking api key: %w", err)
 	}
@@ -123,3 +154,54 @@ func (r *Repo) Revoke(ctx context.Context, orgID, keyID uuid.UUID) error {
 	return nil
 }
 
+
+// Resolve looks up the active key with the given hash. It runs before any
+// tenant scope exists and goes through a SECURITY DEFINER function, which
+// returns only what is needed to establish one. Returns ErrNotFound for an
+// unknown or revoked key.
+func (r *Repo) Resolve(ctx context.Context, keyHash string) (Credential, error) {
+	rows, err := tx.Executor(ctx, r.db).QueryContext(
+		ctx,
+		"SELECT id, org_id, scopes FROM app_api_key_for_hash($1)",
+		keyHash,
+	)
+	if err != nil {
+		return Credential{}, fmt.Errorf("resolving api key: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return Credential{}, fmt.Errorf("resolving api key: %w", err)
+		}
+		return Credential{}, ErrNotFound
+	}
+	var c Credential
+	var scopes pq.StringArray
+	if err := rows.Scan(&c.KeyID, &c.OrgID, &scopes); err != nil {
+		return Credential{}, fmt.Errorf("resolving api key: %w", err)
+	}
+	c.Scopes = []string(scopes)
+	return c, nil
+}
+
+// Touch stamps last_used_at, at most once a minute per key so busy keys do not
+// rewrite their row on every request. It does not bump the version.
+func (r *Repo) Touch(ctx context.Context, keyID uuid.UUID) error {
+	t := table.APIKeys
+	stmt := t.
+		UPDATE(t.LastUsedAt).
+		SET(postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(keyID)).
+				AND(
+					t.LastUsedAt.IS_NULL().
+						OR(t.LastUsedAt.LT(postgres.NOW().SUB(postgres.INTERVAL(1, postgres.MINUTE)))),
+				),
+		)
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("touching api key: %w", err)
+	}
+	return nil
+}
@@ -11,24 +11,48 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 
 	"github.com/google/uuid"
 
 	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/tx"
 )
 
 // ErrNotFound indicates the API key does not exist or is already revoked.
 var ErrNotFound = errors.New("api key not found")
 
-// Service orchestrates API key operations.
+// ScopeEventsIngest allows a key to submit events to the ingestion API.
+const ScopeEventsIngest = "events:ingest"
+
+// Credential is what an authenticated API key grants: its org and scopes.
+type Credential struct {
+	KeyID  uuid.UUID
+	OrgID  uuid.UUID
+	Scopes []string
+}
+
+// HasScope reports whether the key was granted scope.
+func (c Credential) HasScope(scope string) bool {
+	return slices.Contains(c.Scopes, scope)
+}
+
+// Service orchestrates API key operations. Each runs in a transaction carrying
+// the caller's tenant scope, joining one already on ctx. Mutations append
+// their domain event to the outbox in that same transaction.
 type Service struct {
 	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
 	logger *slog.Logger
 }
 
-// NewService wires a Service with its repo and logger.
-func NewService(repo *Repo, logger *slog.Logger) *Service {
-	return &Service{repo: repo, logger: logger}
+// NewService wires a Service with its repo, transaction manager, outbox and
+// logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{repo: repo, tx: txm, events: events, logger: logger}
 }
 
 // generateRawKey returns a freshly generated key (with the "hrz_" prefix) and
@@ -39,9 +63,13 @@ func generateRawKey() (rawKey, keyHash string, err error) {
 		return "", "", fmt.Errorf("generating random bytes: %w", err)
 	}
 	rawKey = "hrz_" + hex.EncodeToString(b)
+	return rawKey, hashKey(rawKey), nil
+}
+
+// hashKey returns the stored form of a raw key.
+func hashKey(rawKey string) string {
 	h := sha256.Sum256([]byte(rawKey))
-	keyHash = hex.EncodeToString(h[:])
-	return rawKey, keyHash, nil
+	return hex.EncodeToString(h[:])
 }
 
 // Create generates a new API key, stores its hash, and returns the raw key one
@@ -56,7 +84,24 @@ func (s *Service) Create(
 	if err != nil {
 		return oapi.CreatedApiKey{}, err
 	}
-	k, err := s.repo.Insert(ctx, orgID, name, keyHash, scopes)
+	var k oapi.CreatedApiKey
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if k, err = s.repo.Insert(ctx, orgID, name, keyHash, scopes); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, k.Id, EventCreated, oapi.ApiKey{
+			Id:         k.Id,
+			OrgId:      k.OrgId,
+			Name:       k.Name,
+			Scopes:     k.Scopes,
+			LastUsedAt: k.LastUsedAt,
+			RevokedAt:  k.RevokedAt,
+			CreatedAt:  k.CreatedAt,
+			UpdatedAt:  k.UpdatedAt,
+			Version:    k.Version,
+		})
+	})
 	if err != nil {
 		return oapi.CreatedApiKey{}, err
 	}
@@ -66,11 +111,54 @@ func (s *Service) Create(
 
 // List returns all active keys for an organisation.
 func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.ApiKey, error) {
-	return s.repo.ListActive(ctx, orgID)
+	var keys []oapi.ApiKey
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		keys, err = s.repo.ListActive(ctx, orgID)
+		return err
+	})
+	return keys, err
+}
+
+// Get returns a single key of the organisation, including revoked ones.
+func (s *Service) Get(ctx context.Context, orgID, keyID uuid.UUID) (oapi.ApiKey, error) {
+	var k oapi.ApiKey
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		k, err = s.repo.Get(ctx, orgID, keyID)
+		return err
+	})
+	return k, err
 }
 
 // Revoke soft-deletes a key. Returns ErrNotFound if the key is unknown or was
 // already revoked.
 func (s *Service) Revoke(ctx context.Context, orgID, keyID uuid.UUID) error {
-	return s.repo.Revoke(ctx, orgID, keyID)
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if err := s.repo.Revoke(ctx, orgID, keyID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, keyID, EventRevoked, RevokedPayload{ID: keyID, OrgID: orgID})
+	})
+}
+
+// Authenticate resolves a raw key to its credential and records that the key
+// was used. Returns ErrNotFound for an unknown or revoked key.
+func (s *Service) Authenticate(ctx context.Context, rawKey string) (Credential, error) {
+	var c Credential
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		c, err = s.repo.Resolve(ctx, hashKey(rawKey))
+		return err
+	})
+	if err != nil {
+		return Credential{}, err
+	}
+	err = s.tx.RunInTx(tenant.WithOrg(ctx, c.OrgID), nil, func(ctx context.Context) error {
+		return s.repo.Touch(ctx, c.KeyID)
+	})
+	if err != nil {
+		return Credential{}, err
+	}
+	return c, nil
 }
@@ -16,7 +16,10 @@ import (
 
 	"github.com/luketeo/horizon/internal/apikey"
 	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
 	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -41,14 +44,21 @@ func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
 	ctx := context.Background()
 	userSvc := user.NewService(
 		user.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
 		slog.New(slog.NewTextHandler(io.Discard, nil)),
 	)
 	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
 	if err != nil {
 		t.Fatalf("seed user: %v", err)
 	}
-	orgSvc := org.NewService(org.NewRepo(db), slog.New(slog.NewTextHandler(io.Discard, nil)))
-	o, err := orgSvc.CreateOrg(ctx, orgName, nil, userID)
+	orgSvc := org.NewService(
+		org.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
 	if err != nil {
 		t.Fatalf("seed org: %v", err)
 	}
@@ -62,6 +72,8 @@ func newSeededService(t *testing.T) (*apikey.Service, uuid.UUID) {
 	orgID := seedOrg(t, db, "user_apikey_seed", "Apikey Test Org")
 	svc := apikey.NewService(
 		apikey.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
 		slog.New(slog.NewTextHandler(io.Discard, nil)),
 	)
 	return svc, orgID
@@ -69,7 +81,7 @@ func newSeededService(t *testing.T) (*apikey.Service, uuid.UUID) {
 
 func TestCreateAPIKey_ReturnsRawKeyAndStoresHash(t *testing.T) {
 	svc, orgID := newSeededService(t)
-	ctx := context.Background()
+	ctx := tenant.WithOrg(context.Background(), orgID)
 
 	created, err := svc.Create(ctx, orgID, "ingestor", []string{"read", "write"})
 	if err != nil {
@@ -106,7 +118,7 @@ func TestCreateAPIKey_ReturnsRawKeyAndStoresHash(t *testing.T) {
 
 func TestListAPIKeys_ExcludesRevoked(t *testing.T) {
 	svc, orgID := newSeededService(t)
-	ctx := context.Background()
+	ctx := tenant.WithOrg(context.Background(), orgID)
 
 	active, err := svc.Create(ctx, orgID, "active", []string{"read"})
 	if err != nil {
@@ -137,7 +149,7 @@ func TestListAPIKeys_ExcludesRevoked(t *testing.T) {
 
 func TestRevokeAPIKey_UnknownKeyReturnsNotFound(t *testing.T) {
 	svc, orgID := newSeededService(t)
-	ctx := context.Background()
+	ctx := tenant.WithOrg(context.Background(), orgID)
 
 	missing := uuid.MustParse("00000000-0000-0000-0000-000000000001")
 	err := svc.Revoke(ctx, orgID, missing)
@@ -148,7 +160,7 @@ func TestRevokeAPIKey_UnknownKeyReturnsNotFound(t *testing.T) {
 
 func TestRevokeAPIKey_IdempotentOnSecondCall(t *testing.T) {
 	svc, orgID := newSeededService(t)
-	ctx := context.Background()
+	ctx := tenant.WithOrg(context.Background(), orgID)
 
 	created, err := svc.Create(ctx, orgID, "once", []string{})
 	if err != nil {
@@ -162,3 +174,78 @@ func TestRevokeAPIKey_IdempotentOnSecondCall(t *testing.T) {
 		t.Fatalf("second revoke: want ErrNotFound, got %v", err)
 	}
 }
+
+func TestAPIKeys_InvisibleAcrossTenants(t *testing.T) {
+	svc, orgA := newSeededService(t)
+	orgB := seedOrg(t, testhelper.DB(t), "user_apikey_other", "Other Org")
+
+	created, err := svc.Create(tenant.WithOrg(context.Background(), orgA), orgA, "a-key", []string{"read"})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	// Scoped to org B, even queries naming org A explicitly see nothing.
+	ctxB := tenant.WithOrg(context.Background(), orgB)
+	keys, err := svc.List(ctxB, orgA)
+	if err != nil {
+		t.Fatalf("ListAPIKeys: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("cross-tenant list: want 0 keys, got %d", len(keys))
+	}
+	if _, err := svc.Get(ctxB, orgA, created.Id); !errors.Is(err, apikey.ErrNotFound) {
+		t.Errorf("cross-tenant get: want ErrNotFound, got %v", err)
+	}
+	if err := svc.Revoke(ctxB, orgA, created.Id); !errors.Is(err, apikey.ErrNotFound) {
+		t.Errorf("cross-tenant revoke: want ErrNotFound, got %v", err)
+	}
+
+	// Without any scope the table is empty as far as the app role can tell.
+	keys, err = svc.List(context.Background(), orgA)
+	if err != nil {
+		t.Fatalf("ListAPIKeys unscoped: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("unscoped list: want 0 keys, got %d", len(keys))
+	}
+}
+
+func TestAuthenticate_ResolvesActiveKeysOnly(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	created, err := svc.Create(ctx, orgID, "shipper", []string{apikey.ScopeEventsIngest})
+	if err != nil {
+		t.Fatalf("CreateAPIKey: %v", err)
+	}
+
+	// Authentication starts without a tenant scope.
+	cred, err := svc.Authenticate(context.Background(), created.Key)
+	if err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+	if cred.KeyID != created.Id || cred.OrgID != orgID {
+		t.Errorf("credential: got %+v", cred)
+	}
+	if !cred.HasScope(apikey.ScopeEventsIngest) || cred.HasScope("read") {
+		t.Errorf("scopes: got %v", cred.Scopes)
+	}
+
+	k, err := svc.Get(ctx, orgID, created.Id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if k.LastUsedAt == nil {
+		t.Error("last_used_at: want set after authentication")
+	}
+	if k.Version != created.Version {
+		t.Errorf("version: want unchanged %d, got %d", created.Version, k.Version)
+	}
+
+	if err := svc.Revoke(ctx, orgID, created.Id); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := svc.Authenticate(context.Background(), created.Key); !errors.Is(err, apikey.ErrNotFound) {
+		t.Errorf("revoked key: want ErrNotFound, got %v", err)
+	}
+}
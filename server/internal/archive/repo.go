@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns archive_segments SQL. The table is protected by RLS: the
+// archiver writes with the privileged pool, tenant reads run inside
+// tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toSegment(m model.ArchiveSegments) Segment {
+	return Segment{
+		Version:         manifestVersion,
+		Key:             m.Key,
+		Format:          Format,
+		OrgID:           m.OrgID,
+		SourceID:        m.SourceID,
+		Events:          int(m.Events),
+		Bytes:           m.Bytes,
+		SHA256:          m.Sha256,
+		MinTime:         m.MinTime,
+		MaxTime:         m.MaxTime,
+		MinReceivedTime: m.MinReceivedTime,
+		MaxReceivedTime: m.MaxReceivedTime,
+		CreatedAt:       m.CreatedAt,
+	}
+}
+
+// Save records the segment's manifest, replacing that of an earlier write of
+// the same key.
+func (r *Repo) Save(ctx context.Context, seg Segment) error {
+	t := table.ArchiveSegments
+	stmt := t.
+		INSERT(
+			t.OrgID, t.SourceID, t.Key, t.Events, t.Bytes, t.Sha256,
+			t.MinTime, t.MaxTime, t.MinReceivedTime, t.MaxReceivedTime, t.CreatedAt,
+		).
+		VALUES(
+			seg.OrgID, seg.SourceID, seg.Key, seg.Events, seg.Bytes, seg.SHA256,
+			seg.MinTime, seg.MaxTime, seg.MinReceivedTime, seg.MaxReceivedTime, seg.CreatedAt,
+		).
+		ON_CONFLICT(t.Key).
+		DO_UPDATE(postgres.SET(
+			t.Events.SET(t.EXCLUDED.Events),
+			t.Bytes.SET(t.EXCLUDED.Bytes),
+			t.Sha256.SET(t.EXCLUDED.Sha256),
+			t.MinTime.SET(t.EXCLUDED.MinTime),
+			t.MaxTime.SET(t.EXCLUDED.MaxTime),
+			t.MinReceivedTime.SET(t.EXCLUDED.MinReceivedTime),
+			t.MaxReceivedTime.SET(t.EXCLUDED.MaxReceivedTime),
+			t.CreatedAt.SET(t.EXCLUDED.CreatedAt),
+		))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("saving archive segment: %w", err)
+	}
+	return nil
+}
+
+// List returns the org's segments that may hold events with times in
+// [from, to), of one source unless sourceID is nil, oldest first.
+func (r *Repo) List(
+	ctx context.Context,
+	orgID uuid.UUID,
+	sourceID *uuid.UUID,
+	from, to time.Time,
+) ([]Segment, error) {
+	t := table.ArchiveSegments
+	cond := t.OrgID.EQ(postgres.UUID(orgID)).
+		AND(t.MinTime.LT(postgres.TimestampzT(to))).
+		AND(t.MaxTime.GT_EQ(postgres.TimestampzT(from)))
+	if sourceID != nil {
+		cond = cond.AND(t.SourceID.EQ(postgres.UUID(*sourceID)))
+	}
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(cond).
+		ORDER_BY(t.MinTime.ASC(), t.Key.ASC())
+
+	var rows []model.ArchiveSegments
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing archive segments: %w", err)
+	}
+	segments := make([]Segment, len(rows))
+	for i, row := range rows {
+		segments[i] = toSegment(row)
+	}
+	return segments, nil
+}
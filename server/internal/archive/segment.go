@@ -0,0 +1,164 @@
+// Package archive keeps every raw event as received, in compressed segment
+// files in a blob store, so events can be located and replayed by time range,
+// for instance to normalize them again under a new mapping.
+//
+// The archive is a consumer of the ingest queue. Each segment holds events of
+// one source from one hour of event time, as zstd-compressed NDJSON of queue
+// messages. Every segment has a manifest: a JSON object stored next to it and
+// a row in archive_segments, which is what replays search.
+package archive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/luketeo/horizon/internal/platform/queue"
+)
+
+// Consumer is the archive's ingest queue consumer name.
+const Consumer = "archive"
+
+// Format names the encoding of segment files.
+const Format = "ndjson+zstd"
+
+const (
+	manifestVersion = 1
+	segmentSuffix   = ".ndjson.zst"
+	manifestSuffix  = ".manifest.json"
+)
+
+// ErrCorrupt is returned when a segment does not match its manifest.
+var ErrCorrupt = errors.New("archive segment corrupt")
+
+// Segment is a segment's manifest.
+type Segment struct {
+	Version  int       `json:"version"`
+	Key      string    `json:"key"`
+	Format   string    `json:"format"`
+	OrgID    uuid.UUID `json:"org_id"`
+	SourceID uuid.UUID `json:"source_id"`
+	Events   int       `json:"events"`
+	Bytes    int64     `json:"bytes"`
+	// SHA256 is the hex digest of the segment file.
+	SHA256 string `json:"sha256"`
+	// MinTime and MaxTime bound the event times in the segment, and the
+	// received times the times the events arrived.
+	MinTime         time.Time `json:"min_time"`
+	MaxTime         time.Time `json:"max_time"`
+	MinReceivedTime time.Time `json:"min_received_time"`
+	MaxReceivedTime time.Time `json:"max_received_time"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ManifestKey returns the key of the segment's manifest object.
+func (s Segment) ManifestKey() string {
+	return s.Key[:len(s.Key)-len(segmentSuffix)] + manifestSuffix
+}
+
+// Overlaps reports whether the segment may hold events with times in
+// [from, to).
+func (s Segment) Overlaps(from, to time.Time) bool {
+	return s.MinTime.Before(to) && !s.MaxTime.Before(from)
+}
+
+// partitionKey names the blob of a segment: by org, source and hour of event
+// time, then by the segment's first event, which is what makes writing the
+// same batch again overwrite rather than duplicate it.
+func partitionKey(orgID, sourceID uuid.UUID, hour time.Time, first uuid.UUID) string {
+	return fmt.Sprintf("raw/%s/%s/%s/%s%s", orgID, sourceID, hour.UTC().Format("2006/01/02/15"), first, segmentSuffix)
+}
+
+// hourOf truncates t to its hour in UTC.
+func hourOf(t time.Time) time.Time {
+	return t.UTC().Truncate(time.Hour)
+}
+
+var (
+	encoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	decoder, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0))
+)
+
+// Encode builds a segment of msgs, which share an org, source and hour, and
+// its manifest.
+func Encode(msgs []queue.Message) ([]byte, Segment, error) {
+	if len(msgs) == 0 {
+		return nil, Segment{}, errors.New("encoding an empty segment")
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	first := msgs[0]
+	seg := Segment{
+		Version:         manifestVersion,
+		Key:             partitionKey(first.OrgID, first.SourceID, hourOf(first.Time), first.ID),
+		Format:          Format,
+		OrgID:           first.OrgID,
+		SourceID:        first.SourceID,
+		Events:          len(msgs),
+		MinTime:         first.Time,
+		MaxTime:         first.Time,
+		MinReceivedTime: first.ReceivedTime,
+		MaxReceivedTime: first.ReceivedTime,
+	}
+	for _, m := range msgs {
+		if err := enc.Encode(m); err != nil {
+			return nil, Segment{}, fmt.Errorf("encoding archived event: %w", err)
+		}
+		seg.MinTime = earliest(seg.MinTime, m.Time)
+		seg.MaxTime = latest(seg.MaxTime, m.Time)
+		seg.MinReceivedTime = earliest(seg.MinReceivedTime, m.ReceivedTime)
+		seg.MaxReceivedTime = latest(seg.MaxReceivedTime, m.ReceivedTime)
+	}
+
+	data := encoder.EncodeAll(buf.Bytes(), nil)
+	sum := sha256.Sum256(data)
+	seg.Bytes = int64(len(data))
+	seg.SHA256 = hex.EncodeToString(sum[:])
+	return data, seg, nil
+}
+
+// Decode reads the events of a segment, checking it against its manifest.
+func Decode(seg Segment, data []byte) ([]queue.Message, error) {
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != seg.SHA256 {
+		return nil, fmt.Errorf("%w: %s: checksum mismatch", ErrCorrupt, seg.Key)
+	}
+	raw, err := decoder.DecodeAll(data, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrCorrupt, seg.Key, err)
+	}
+	msgs := make([]queue.Message, 0, seg.Events)
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	for {
+		var m queue.Message
+		if err := dec.Decode(&m); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrCorrupt, seg.Key, err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+func earliest(a, b time.Time) time.Time {
+	if b.Before(a) {
+		return b
+	}
+	return a
+}
+
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
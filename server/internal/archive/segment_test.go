@@ -0,0 +1,101 @@
+package archive_test
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/platform/queue"
+)
+
+func messages(orgID, sourceID uuid.UUID, times ...time.Time) []queue.Message {
+	msgs := make([]queue.Message, len(times))
+	for i, t := range times {
+		msgs[i] = queue.Message{
+			ID:           uuid.New(),
+			OrgID:        orgID,
+			SourceID:     sourceID,
+			Payload:      json.RawMessage(`{"msg":"login failed","user":"alice"}`),
+			Time:         t,
+			TimeStatus:   "parsed",
+			ReceivedTime: t.Add(time.Second),
+		}
+	}
+	return msgs
+}
+
+func TestEncode_RoundTripsAndDescribesSegment(t *testing.T) {
+	orgID, sourceID := uuid.New(), uuid.New()
+	base := time.Date(2026, 10, 19, 14, 5, 0, 0, time.UTC)
+	msgs := messages(orgID, sourceID, base.Add(time.Minute), base, base.Add(30*time.Minute))
+
+	data, seg, err := archive.Encode(msgs)
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	wantPrefix := "raw/" + orgID.String() + "/" + sourceID.String() + "/2026/10/19/14/" + msgs[0].ID.String()
+	if !strings.HasPrefix(seg.Key, wantPrefix) || !strings.HasSuffix(seg.Key, ".ndjson.zst") {
+		t.Errorf("Key: want %s…, got %s", wantPrefix, seg.Key)
+	}
+	if !strings.HasSuffix(seg.ManifestKey(), ".manifest.json") {
+		t.Errorf("ManifestKey: got %s", seg.ManifestKey())
+	}
+	if seg.Events != 3 || seg.Bytes != int64(len(data)) || seg.Format != archive.Format {
+		t.Errorf("manifest: got %+v", seg)
+	}
+	if !seg.MinTime.Equal(base) || !seg.MaxTime.Equal(base.Add(30*time.Minute)) {
+		t.Errorf("time range: got %v – %v", seg.MinTime, seg.MaxTime)
+	}
+	if !seg.MinReceivedTime.Equal(base.Add(time.Second)) {
+		t.Errorf("MinReceivedTime: got %v", seg.MinReceivedTime)
+	}
+
+	got, err := archive.Decode(seg, data)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("Decode: want 3 events, got %d", len(got))
+	}
+	for i := range got {
+		if got[i].ID != msgs[i].ID || !got[i].Time.Equal(msgs[i].Time) ||
+			string(got[i].Payload) != string(msgs[i].Payload) {
+			t.Errorf("event %d: want %+v, got %+v", i, msgs[i], got[i])
+		}
+	}
+}
+
+func TestDecode_RejectsCorruptSegment(t *testing.T) {
+	data, seg, err := archive.Encode(messages(uuid.New(), uuid.New(), time.Now()))
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	data[len(data)/2] ^= 0xff
+	if _, err := archive.Decode(seg, data); !errors.Is(err, archive.ErrCorrupt) {
+		t.Errorf("want ErrCorrupt, got %v", err)
+	}
+}
+
+func TestSegment_Overlaps(t *testing.T) {
+	base := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+	seg := archive.Segment{MinTime: base.Add(10 * time.Minute), MaxTime: base.Add(20 * time.Minute)}
+	cases := []struct {
+		from, to time.Time
+		want     bool
+	}{
+		{base, base.Add(10 * time.Minute), false},
+		{base, base.Add(11 * time.Minute), true},
+		{base.Add(20 * time.Minute), base.Add(time.Hour), true},
+		{base.Add(21 * time.Minute), base.Add(time.Hour), false},
+	}
+	for _, c := range cases {
+		if got := seg.Overlaps(c.from, c.to); got != c.want {
+			t.Errorf("Overlaps(%v, %v): want %v, got %v", c.from, c.to, c.want, got)
+		}
+	}
+}
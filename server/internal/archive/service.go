@@ -0,0 +1,152 @@
+package archive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/blob"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrDisabled is returned when no archive store is configured.
+var ErrDisabled = errors.New("archive disabled")
+
+// ── Writing ──────────────────────────────────────────────────────────────────
+
+// Archiver writes batches from the ingest queue to segments. Writing a batch
+// again, as a redelivery does, overwrites its segments, so an event is
+// archived once however often it is delivered, unless the batch is cut
+// differently the second time; readers deduplicate on Message.ID.
+type Archiver struct {
+	store  blob.Store
+	repo   *Repo
+	logger *slog.Logger
+}
+
+// NewArchiver wires an Archiver. repo must use the privileged pool: batches
+// span tenants.
+func NewArchiver(store blob.Store, repo *Repo, logger *slog.Logger) *Archiver {
+	return &Archiver{store: store, repo: repo, logger: logger}
+}
+
+// Handle archives a batch, splitting it into a segment per org, source and
+// hour of event time. It is the archive consumer's queue.BatchHandler.
+func (a *Archiver) Handle(ctx context.Context, ds []queue.Delivery) error {
+	type partition struct {
+		orgID, sourceID uuid.UUID
+		hour            time.Time
+	}
+	var order []partition
+	groups := map[partition][]queue.Message{}
+	for _, d := range ds {
+		p := partition{orgID: d.OrgID, sourceID: d.SourceID, hour: hourOf(d.Time)}
+		if _, ok := groups[p]; !ok {
+			order = append(order, p)
+		}
+		groups[p] = append(groups[p], d.Message)
+	}
+
+	for _, p := range order {
+		if err := a.write(ctx, groups[p]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// write stores a segment, then its manifest object, then its manifest row,
+// so a segment is only found once it is complete.
+func (a *Archiver) write(ctx context.Context, msgs []queue.Message) error {
+	data, seg, err := Encode(msgs)
+	if err != nil {
+		return queue.Permanent(err)
+	}
+	seg.CreatedAt = time.Now().UTC()
+	manifest, err := json.Marshal(seg)
+	if err != nil {
+		return queue.Permanent(fmt.Errorf("encoding manifest: %w", err))
+	}
+
+	if err := a.store.Put(ctx, seg.Key, data); err != nil {
+		return fmt.Errorf("writing archive segment: %w", err)
+	}
+	if err := a.store.Put(ctx, seg.ManifestKey(), manifest); err != nil {
+		return fmt.Errorf("writing archive manifest: %w", err)
+	}
+	if err := a.repo.Save(ctx, seg); err != nil {
+		return err
+	}
+	a.logger.DebugContext(ctx, "archived segment",
+		slog.String("key", seg.Key), slog.Int("events", seg.Events), slog.Int64("bytes", seg.Bytes))
+	return nil
+}
+
+// ── Reading ──────────────────────────────────────────────────────────────────
+
+// Service locates and reads an org's archived events, under the caller's
+// tenant scope.
+type Service struct {
+	store blob.Store
+	repo  *Repo
+	tx    *tx.Manager
+}
+
+// NewService wires a Service. store is nil when the archive is disabled.
+func NewService(store blob.Store, repo *Repo, txm *tx.Manager) *Service {
+	return &Service{store: store, repo: repo, tx: txm}
+}
+
+// Enabled reports whether an archive store is configured.
+func (s *Service) Enabled() bool {
+	return s.store != nil
+}
+
+// Segments returns the org's segments that may hold events with times in
+// [from, to), of one source unless sourceID is nil, oldest first.
+func (s *Service) Segments(
+	ctx context.Context,
+	orgID uuid.UUID,
+	sourceID *uuid.UUID,
+	from, to time.Time,
+) ([]Segment, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+	var segments []Segment
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		segments, err = s.repo.List(ctx, orgID, sourceID, from, to)
+		return err
+	})
+	return segments, err
+}
+
+// Read returns the segment's events with times in [from, to), in the order
+// they were received.
+func (s *Service) Read(ctx context.Context, seg Segment, from, to time.Time) ([]queue.Message, error) {
+	if s.store == nil {
+		return nil, ErrDisabled
+	}
+	data, err := s.store.Get(ctx, seg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("reading archive segment: %w", err)
+	}
+	msgs, err := Decode(seg, data)
+	if err != nil {
+		return nil, err
+	}
+	kept := msgs[:0]
+	for _, m := range msgs {
+		if !m.Time.Before(from) && m.Time.Before(to) {
+			kept = append(kept, m)
+		}
+	}
+	return kept, nil
+}
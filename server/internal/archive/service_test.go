@@ -0,0 +1,153 @@
+package archive_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/platform/blob"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+type fixture struct {
+	store    *blob.Local
+	archiver *archive.Archiver
+	svc      *archive.Service
+	orgID    uuid.UUID
+	sourceID uuid.UUID
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	var orgID, sourceID uuid.UUID
+	if err := db.QueryRowContext(ctx,
+		`INSERT INTO organizations (name, slug) VALUES ('Archive Org', 'archive-org') RETURNING id`,
+	).Scan(&orgID); err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	if err := db.QueryRowContext(ctx,
+		`INSERT INTO sources (org_id, name, type, token_hash, token_prefix)
+		 VALUES ($1, 'firewall', 'http-json', 'hash', 'hzn_src_') RETURNING id`,
+		orgID,
+	).Scan(&sourceID); err != nil {
+		t.Fatalf("seed source: %v", err)
+	}
+
+	store, err := blob.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	return fixture{
+		store:    store,
+		archiver: archive.NewArchiver(store, archive.NewRepo(testhelper.PrivilegedDB(t)), logger),
+		svc:      archive.NewService(store, archive.NewRepo(db), tx.NewManager(db)),
+		orgID:    orgID,
+		sourceID: sourceID,
+	}
+}
+
+func deliveries(msgs []queue.Message) []queue.Delivery {
+	ds := make([]queue.Delivery, len(msgs))
+	for i, m := range msgs {
+		ds[i] = queue.Delivery{Message: m, Seq: int64(i + 1), Attempt: 1}
+	}
+	return ds
+}
+
+func TestArchiver_WritesSegmentPerHourWithManifests(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	base := time.Date(2026, 10, 19, 14, 50, 0, 0, time.UTC)
+	msgs := messages(f.orgID, f.sourceID, base, base.Add(5*time.Minute), base.Add(15*time.Minute))
+
+	if err := f.archiver.Handle(ctx, deliveries(msgs)); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	scoped := tenant.WithOrg(ctx, f.orgID)
+	segments, err := f.svc.Segments(scoped, f.orgID, nil, base.Add(-time.Hour), base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Segments: %v", err)
+	}
+	if len(segments) != 2 || segments[0].Events != 2 || segments[1].Events != 1 {
+		t.Fatalf("Segments: want hours of 2 and 1 events, got %+v", segments)
+	}
+	for _, seg := range segments {
+		data, err := f.store.Get(ctx, seg.ManifestKey())
+		if err != nil {
+			t.Fatalf("manifest of %s: %v", seg.Key, err)
+		}
+		var manifest archive.Segment
+		if err := json.Unmarshal(data, &manifest); err != nil || manifest.SHA256 != seg.SHA256 {
+			t.Errorf("manifest of %s: want checksum %s, got %+v (%v)", seg.Key, seg.SHA256, manifest, err)
+		}
+	}
+
+	got, err := f.svc.Read(scoped, segments[0], base.Add(time.Minute), base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if len(got) != 1 || got[0].ID != msgs[1].ID {
+		t.Errorf("Read: want only the second event, got %+v", got)
+	}
+
+	late, err := f.svc.Segments(scoped, f.orgID, &f.sourceID, base.Add(10*time.Minute), base.Add(time.Hour))
+	if err != nil || len(late) != 1 || late[0].Key != segments[1].Key {
+		t.Errorf("Segments from 15:00: want the second segment, got %+v (%v)", late, err)
+	}
+}
+
+func TestArchiver_RedeliveryOverwritesSegment(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	base := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+	msgs := messages(f.orgID, f.sourceID, base, base.Add(time.Minute), base.Add(2*time.Minute))
+
+	// A redelivered batch may have grown by the events queued since.
+	if err := f.archiver.Handle(ctx, deliveries(msgs[:2])); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+	if err := f.archiver.Handle(ctx, deliveries(msgs)); err != nil {
+		t.Fatalf("Handle again: %v", err)
+	}
+
+	scoped := tenant.WithOrg(ctx, f.orgID)
+	segments, err := f.svc.Segments(scoped, f.orgID, nil, base, base.Add(time.Hour))
+	if err != nil || len(segments) != 1 || segments[0].Events != 3 {
+		t.Fatalf("Segments: want one of 3 events, got %+v (%v)", segments, err)
+	}
+	got, err := f.svc.Read(scoped, segments[0], base, base.Add(time.Hour))
+	if err != nil || len(got) != 3 {
+		t.Errorf("Read: want 3 events, got %d (%v)", len(got), err)
+	}
+}
+
+func TestSegments_InvisibleAcrossTenants(t *testing.T) {
+	f := newFixture(t)
+	ctx := context.Background()
+	base := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+	if err := f.archiver.Handle(ctx, deliveries(messages(f.orgID, f.sourceID, base))); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	other := uuid.New()
+	segments, err := f.svc.Segments(tenant.WithOrg(ctx, other), f.orgID, nil, base, base.Add(time.Hour))
+	if err != nil || len(segments) != 0 {
+		t.Errorf("Segments from another org: want none, got %+v (%v)", segments, err)
+	}
+}
@@ -0,0 +1,45 @@
+package boot
+
+import (
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/config"
+	"github.com/luketeo/horizon/internal/entity"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+// NewPipeline wires the pipeline events are processed through, shared by the
+// ingest normalizer, replays and the API validating replay requests, so they
+// all process events alike. It acts for one org at a time, so it runs under
+// its tenant scope on the application pool.
+func NewPipeline(config *config.Config) *pipeline.Pipeline {
+	db := config.DB()
+	logger := config.Logger()
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+
+	sourceSvc := source.NewService(source.NewRepo(db), txm, events, logger)
+	patternSvc := pattern.NewService(pattern.NewRepo(db), txm, events, logger)
+	mappingSvc := normalization.NewService(normalization.NewRepo(db), sourceSvc, patternSvc, txm, events, logger)
+	var enrichers []pipeline.Enricher
+	if g := config.GeoIP(); g != nil {
+		enrichers = append(enrichers, g)
+	}
+	enrichers = append(enrichers, threatintel.NewMatcher(threatintel.NewRepo(db), txm, logger, 0))
+	// The tracker adds entity context to events and records the entities of
+	// those stored for the first time, with them.
+	tracker := entity.NewTracker(entity.NewRepo(db), txm, logger, 0)
+	enrichers = append(enrichers, tracker)
+	// Events are redacted with the normalization-stage policies, the
+	// redactions counted with the events stored.
+	redactor := redaction.NewRedactor(redaction.NewRepo(db), txm, logger, oapi.Normalization, 0)
+	return pipeline.New(sourceSvc, patternSvc, mappingSvc, enrichers...).
+		WithRedactor(redactor).
+		WithRecorders(tracker)
+}
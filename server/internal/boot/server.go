@@ -12,6 +12,8 @@ import (
 
 	"github.com/luketeo/horizon/generated/oapi"
 	"github.com/luketeo/horizon/internal/config"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/idempotency"
 	"github.com/luketeo/horizon/internal/platform/middleware"
 	"github.com/luketeo/horizon/internal/web"
 )
@@ -21,8 +23,8 @@ type Server struct {
 	portAddr string
 }
 
-func NewServer(config *config.Config) *Server {
-	h := web.NewHandler(config)
+func NewServer(config *config.Config, pipe *pipeline.Pipeline) *Server {
+	h := web.NewHandler(config, pipe)
 	portAddr := fmt.Sprintf(":%s", config.Env().ServerPort())
 
 	r := chi.NewRouter()
@@ -30,12 +32,22 @@ func NewServer(config *config.Config) *Server {
 	r.Use(chimiddleware.Recoverer)
 
 	r.Get("/health", h.GetHealth)
+	// authenticated by API key rather than a Clerk session
+	r.Post("/ingest/{sourceId}", h.IngestEvents)
 	r.Group(func(r chi.Router) {
 		baseURL := ""
 
 		// clerk auth middleware
 		r.Use(middleware.NewClerkAuthMiddleware(config))
 
+		// replay retried POST/PATCH requests carrying an Idempotency-Key
+		r.Use(idempotency.NewMiddleware(
+			idempotency.NewRepo(config.DB()),
+			middleware.Principal,
+			config.Env().IdempotencyTTL(),
+			config.Logger(),
+		))
+
 		serverOptions := oapi.StrictHTTPServerOptions{
 			RequestErrorHandlerFunc: func(w http.ResponseWriter, _ *http.Request, err error) {
 				http.Error(w, err.Error(), http.StatusBadRequest)
@@ -0,0 +1,71 @@
+package boot
+
+import (
+	"context"
+	"crypto/tls"
+	"log/slog"
+	"os"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/config"
+	"github.com/luketeo/horizon/internal/ingest"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/syslog"
+)
+
+// Syslog runs the syslog listeners next to the HTTP server. Like HTTP
+// ingestion it uses the request pool and queues events under the scope of
+// the org owning the sender's source.
+type Syslog struct {
+	server  *syslog.Server
+	enabled bool
+}
+
+// NewSyslog binds the listeners configured in the environment, exiting if one
+// cannot be opened.
+func NewSyslog(config *config.Config) *Syslog {
+	env := config.Env()
+	cfg := syslog.Config{
+		UDPAddr: env.SyslogUDPAddr(),
+		TCPAddr: env.SyslogTCPAddr(),
+		TLSAddr: env.SyslogTLSAddr(),
+	}
+	if cfg.UDPAddr == "" && cfg.TCPAddr == "" && cfg.TLSAddr == "" {
+		return &Syslog{}
+	}
+	if cfg.TLSAddr != "" {
+		cert, err := tls.LoadX509KeyPair(env.SyslogTLSCertFile(), env.SyslogTLSKeyFile())
+		if err != nil {
+			slog.Default().Error("Failed to load syslog TLS certificate", slog.Any("err", err))
+			os.Exit(1)
+		}
+		cfg.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	}
+
+	db := config.DB()
+	logger := config.Logger()
+	txm := tx.NewManager(db)
+	sourceSvc := source.NewService(source.NewRepo(db), txm, outbox.NewRepo(db), logger)
+	redactor := redaction.NewRedactor(redaction.NewRepo(db), txm, logger, oapi.Archive, 0)
+	ingestSvc := ingest.NewService(config.IngestQueue(), sourceSvc, redactor, txm, logger)
+
+	server := syslog.NewServer(sourceSvc, ingestSvc, cfg, logger)
+	if err := server.Listen(); err != nil {
+		slog.Default().Error("Failed to open syslog listeners", slog.Any("err", err))
+		os.Exit(1)
+	}
+	return &Syslog{server: server, enabled: true}
+}
+
+// Start serves syslog until ctx is cancelled, submitting pending events before
+// it returns. It is a no-op when no listener is configured.
+func (s *Syslog) Start(ctx context.Context) {
+	if !s.enabled {
+		slog.Default().Info("Syslog receiver disabled")
+		return
+	}
+	s.server.Serve(ctx)
+}
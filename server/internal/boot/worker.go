@@ -0,0 +1,277 @@
+package boot
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/config"
+	"github.com/luketeo/horizon/internal/entity"
+	"github.com/luketeo/horizon/internal/eventstore"
+	"github.com/luketeo/horizon/internal/ingest"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/idempotency"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/replay"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/taxii"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+const (
+	// outboxRetention is how long delivered outbox events are kept, so a new
+	// sink can be backfilled.
+	outboxRetention = 7 * 24 * time.Hour
+
+	// sourceStatsRetention is how long per-minute source counters are kept;
+	// source health looks back a day at most.
+	sourceStatsRetention = 7 * 24 * time.Hour
+
+	// ingestQueueRetention is how long the Postgres ingest queue keeps
+	// events its consumers have acknowledged, matching JetStream's max age.
+	ingestQueueRetention = 7 * 24 * time.Hour
+
+	// entitySightingsRetention is how long the hourly counts backing entity
+	// timelines are kept.
+	entitySightingsRetention = 90 * 24 * time.Hour
+
+	// redactionStatsRetention is how long hourly redaction counts are kept
+	// for audit.
+	redactionStatsRetention = 400 * 24 * time.Hour
+
+	outboxRedisStream = "horizon:events"
+	outboxNATSStream  = "HORIZON_EVENTS"
+	outboxNATSPrefix  = "horizon.events"
+)
+
+// Worker runs background jobs and the outbox relay next to the HTTP server.
+// Both use the privileged pool: they act across tenants and are not subject
+// to row-level security.
+type Worker struct {
+	worker    *jobs.Worker
+	relay     *outbox.Relay
+	consumers []func(ctx context.Context)
+	// runJobs is false when JOB_WORKER_CONCURRENCY=0 leaves jobs and their
+	// schedules to other processes.
+	runJobs bool
+}
+
+// NewWorker wires the job worker, registering every job kind and schedule,
+// the outbox relay with the sinks configured in the environment, and the
+// ingest queue consumers: the normalizer, and the archiver when an archive
+// store is configured. The normalizer and replays process events through
+// pipe.
+func NewWorker(config *config.Config, pipe *pipeline.Pipeline) *Worker {
+	concurrency := config.Env().JobConcurrency()
+	db := config.PrivilegedDB()
+	logger := config.Logger()
+	w := jobs.NewWorker(jobs.NewRepo(db), jobs.WorkerConfig{Concurrency: concurrency}, logger)
+
+	idempotencyRepo := idempotency.NewRepo(db)
+	jobs.Register(w, func(ctx context.Context, _ idempotency.PurgeArgs) error {
+		n, err := idempotencyRepo.PurgeExpired(ctx)
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "purged expired idempotency keys", slog.Int64("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "@hourly", idempotency.PurgeArgs{})
+
+	outboxRepo := outbox.NewRepo(db)
+	relay := outbox.NewRelay(
+		outboxRepo,
+		tx.NewUnscopedManager(db),
+		outboxSinks(config),
+		outbox.RelayConfig{},
+		logger,
+	)
+	jobs.Register(w, func(ctx context.Context, _ outbox.PruneArgs) error {
+		n, err := outboxRepo.Prune(ctx, relay.Consumers(), time.Now().Add(-outboxRetention))
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "pruned delivered outbox events", slog.Int64("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "@daily", outbox.PruneArgs{})
+
+	sourceRepo := source.NewRepo(db)
+	jobs.Register(w, func(ctx context.Context, _ source.PruneStatsArgs) error {
+		n, err := sourceRepo.PruneStats(ctx, time.Now().Add(-sourceStatsRetention))
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "pruned source stats", slog.Int64("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "@daily", source.PruneStatsArgs{})
+
+	// ingestConsumers names the ingest queue consumers this deployment runs.
+	// The Postgres queue keeps an event until each of them has acknowledged
+	// it.
+	var ingestConsumers []string
+	var consumers []func(ctx context.Context)
+	if store := config.ArchiveStore(); store != nil {
+		archiver := archive.NewArchiver(store, archive.NewRepo(db), logger)
+		batching := queue.Batching{
+			Size:   config.Env().ArchiveSegmentMaxEvents(),
+			Linger: config.Env().ArchiveSegmentMaxAge(),
+		}
+		ingestConsumers = append(ingestConsumers, archive.Consumer)
+		consumers = append(consumers, func(ctx context.Context) {
+			if err := config.IngestQueue().ConsumeBatches(ctx, archive.Consumer, batching, archiver.Handle); err != nil {
+				logger.Error("archive consumer stopped", slog.Any("err", err))
+			}
+		})
+	}
+
+	// The normalizer and replays act for one org at a time, so they run under
+	// its tenant scope on the application pool, like the pipeline.
+	appDB := config.DB()
+	appTx := tx.NewManager(appDB)
+	appEvents := outbox.NewRepo(appDB)
+	sourceSvc := source.NewService(source.NewRepo(appDB), appTx, appEvents, logger)
+	appStore := eventstore.NewStore(appDB, appTx)
+
+	normalizer := ingest.NewNormalizer(pipe, sourceSvc, appStore, appTx)
+	ingestConsumers = append(ingestConsumers, ingest.NormalizeConsumer)
+	consumers = append(consumers, func(ctx context.Context) {
+		err := config.IngestQueue().ConsumeBatches(ctx, ingest.NormalizeConsumer, queue.Batching{}, normalizer.Handle)
+		if err != nil {
+			logger.Error("normalize consumer stopped", slog.Any("err", err))
+		}
+	})
+
+	replaySvc := replay.NewService(
+		replay.NewRepo(appDB),
+		archive.NewService(config.ArchiveStore(), archive.NewRepo(appDB), appTx),
+		pipe,
+		appStore,
+		jobs.NewRepo(db),
+		appTx,
+		appEvents,
+		logger,
+	)
+	jobs.Register(w, replaySvc.Run)
+
+	// TAXII syncs import into one org's indicators, so they run like replays;
+	// finding the feeds due a poll spans every org.
+	taxiiSvc := taxii.NewService(
+		taxii.NewRepo(appDB),
+		threatintel.NewService(threatintel.NewRepo(appDB), appTx, appEvents, logger),
+		config.Secrets(),
+		nil,
+		jobs.NewRepo(db),
+		appTx,
+		appEvents,
+		logger,
+	)
+	jobs.Register(w, taxiiSvc.Run)
+	taxiiRepo := taxii.NewRepo(db)
+	jobs.Register(w, func(ctx context.Context, _ taxii.ScheduleArgs) error {
+		n, err := taxii.EnqueueDue(ctx, taxiiRepo, jobs.NewRepo(db), tx.NewUnscopedManager(db), time.Now())
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "enqueued TAXII feed syncs", slog.Int("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "* * * * *", taxii.ScheduleArgs{})
+
+	entityRepo := entity.NewRepo(db)
+	jobs.Register(w, func(ctx context.Context, _ entity.PruneSightingsArgs) error {
+		n, err := entityRepo.PruneSightings(ctx, time.Now().Add(-entitySightingsRetention))
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "pruned entity sightings", slog.Int64("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "@daily", entity.PruneSightingsArgs{})
+
+	redactionRepo := redaction.NewRepo(db)
+	jobs.Register(w, func(ctx context.Context, _ redaction.PruneStatsArgs) error {
+		n, err := redactionRepo.PruneStats(ctx, time.Now().Add(-redactionStatsRetention))
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "pruned redaction stats", slog.Int64("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "@daily", redaction.PruneStatsArgs{})
+
+	eventStore := eventstore.NewStore(db, tx.NewUnscopedManager(db))
+	jobs.Register(w, func(ctx context.Context, _ eventstore.PartitionsArgs) error {
+		now := time.Now()
+		if err := eventStore.CreatePartitions(ctx, now, eventstore.PartitionsAhead); err != nil {
+			return err
+		}
+		n, err := eventStore.DropPartitions(ctx, now.Add(-eventstore.Retention))
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "dropped expired event partitions", slog.Int("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "@hourly", eventstore.PartitionsArgs{})
+
+	queueRepo := queue.NewRepo(db)
+	jobs.Register(w, func(ctx context.Context, _ queue.PruneArgs) error {
+		n, err := queueRepo.Prune(ctx, ingestConsumers, time.Now().Add(-ingestQueueRetention))
+		if err == nil && n > 0 {
+			logger.InfoContext(ctx, "pruned ingest queue", slog.Int64("count", n))
+		}
+		return err
+	})
+	mustSchedule(w, "@hourly", queue.PruneArgs{})
+
+	return &Worker{worker: w, relay: relay, consumers: consumers, runJobs: concurrency > 0}
+}
+
+// outboxSinks connects the outbox sinks whose URL is configured.
+func outboxSinks(config *config.Config) []outbox.Sink {
+	ctx := context.Background()
+	var sinks []outbox.Sink
+	if url := config.Env().OutboxRedisURL(); url != "" {
+		sink, err := outbox.NewRedisSink(ctx, url, outboxRedisStream)
+		if err != nil {
+			slog.Default().Error("Failed to connect outbox Redis sink", slog.Any("err", err))
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+	if url := config.Env().OutboxNATSURL(); url != "" {
+		sink, err := outbox.NewNATSSink(ctx, url, outboxNATSStream, outboxNATSPrefix)
+		if err != nil {
+			slog.Default().Error("Failed to connect outbox NATS sink", slog.Any("err", err))
+			os.Exit(1)
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks
+}
+
+func mustSchedule(w *jobs.Worker, spec string, args jobs.Args) {
+	if err := w.Schedule(spec, args); err != nil {
+		slog.Default().Error("Invalid job schedule", slog.String("spec", spec), slog.Any("err", err))
+		os.Exit(1)
+	}
+}
+
+// Start runs the relay, consumers and job worker until ctx is cancelled. The
+// job worker, with its schedules, does not run when disabled with
+// JOB_WORKER_CONCURRENCY=0; the relay and ingest queue consumers always do.
+func (w *Worker) Start(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Go(func() { w.relay.Run(ctx) })
+	for _, consume := range w.consumers {
+		wg.Go(func() { consume(ctx) })
+	}
+	if w.runJobs {
+		w.worker.Run(ctx)
+	} else {
+		slog.Default().Info("Job worker disabled")
+	}
+	wg.Wait()
+}
@@ -8,13 +8,22 @@ import (
 	"github.com/clerk/clerk-sdk-go/v2/user"
 
 	"github.com/luketeo/horizon/internal/config/provider"
+	"github.com/luketeo/horizon/internal/geoip"
+	"github.com/luketeo/horizon/internal/platform/blob"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/secret"
 )
 
 type Config struct {
 	clerk  *user.Client
 	db     *sql.DB
+	privDB *sql.DB
 	env    *provider.EnvProvider
 	logger *slog.Logger
+	queue  queue.Queue
+	store  blob.Store
+	geoip  *geoip.Enricher
+	box    *secret.Box
 }
 
 func NewConfig() *Config {
@@ -46,6 +55,56 @@ func (c *Config) DB() *sql.DB {
 	return c.db
 }
 
+// PrivilegedDB returns the pool for the table-owning role, used by migrations
+// and background jobs. It bypasses row-level security.
+func (c *Config) PrivilegedDB() *sql.DB {
+	if c.privDB == nil {
+		c.privDB = provider.NewPrivilegedDBProvider(c.env)
+	}
+
+	return c.privDB
+}
+
+// IngestQueue returns the queue carrying raw events from ingestion to their
+// consumers, shared by every receiver in the process.
+func (c *Config) IngestQueue() queue.Queue {
+	if c.queue == nil {
+		c.queue = provider.NewIngestQueueProvider(c.env, c.PrivilegedDB(), c.Logger())
+	}
+
+	return c.queue
+}
+
+// ArchiveStore returns the blob store of the raw event archive, or nil when
+// the archive is disabled.
+func (c *Config) ArchiveStore() blob.Store {
+	if c.store == nil && c.env.ArchiveEnabled() {
+		c.store = provider.NewArchiveStoreProvider(c.env)
+	}
+
+	return c.store
+}
+
+// GeoIP returns the enricher adding the location and autonomous system of
+// events' IP addresses, or nil when no GeoIP database is configured.
+func (c *Config) GeoIP() *geoip.Enricher {
+	if c.geoip == nil && c.env.GeoIPEnabled() {
+		c.geoip = provider.NewGeoIPProvider(c.env, c.Logger())
+	}
+
+	return c.geoip
+}
+
+// Secrets returns the box sealing the credentials orgs store for external
+// services, or nil when no master key is configured.
+func (c *Config) Secrets() *secret.Box {
+	if c.box == nil && c.env.CredentialsEnabled() {
+		c.box = provider.NewSecretBoxProvider(c.env)
+	}
+
+	return c.box
+}
+
 func (c *Config) Env() *provider.EnvProvider {
 	return c.env
 }
@@ -0,0 +1,39 @@
+package provider
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/luketeo/horizon/internal/platform/blob"
+)
+
+// NewArchiveStoreProvider opens the raw event archive's blob store: S3 when
+// ARCHIVE_S3_BUCKET is set, otherwise the directory ARCHIVE_DIR. Returns nil
+// when neither is set and the archive is disabled.
+func NewArchiveStoreProvider(env *EnvProvider) blob.Store {
+	switch {
+	case env.archiveS3.bucket != "":
+		store, err := blob.NewS3(blob.S3Config{
+			Endpoint:        env.archiveS3.endpoint,
+			Region:          env.archiveS3.region,
+			Bucket:          env.archiveS3.bucket,
+			AccessKeyID:     env.archiveS3.accessKey,
+			SecretAccessKey: env.archiveS3.secretKey,
+			PathStyle:       env.archiveS3.pathStyle,
+		}, nil)
+		if err != nil {
+			slog.Default().Error("Failed to configure S3 archive store", slog.Any("err", err))
+			os.Exit(1)
+		}
+		return store
+	case env.archiveDir != "":
+		store, err := blob.NewLocal(env.archiveDir)
+		if err != nil {
+			slog.Default().Error("Failed to open local archive store", slog.Any("err", err))
+			os.Exit(1)
+		}
+		return store
+	default:
+		return nil
+	}
+}
@@ -9,13 +9,23 @@ import (
 )
 
 func NewDBProvider(env *EnvProvider) *sql.DB {
-	db, err := sql.Open("postgres", env.databaseURL)
+	return openDB(env.databaseURL, env.databaseMaxConns)
+}
+
+// NewPrivilegedDBProvider opens the connection pool for the table-owning role.
+// It is not subject to row-level security and must not serve request traffic.
+func NewPrivilegedDBProvider(env *EnvProvider) *sql.DB {
+	return openDB(env.privilegedDBURL, env.databaseMaxConns)
+}
+
+func openDB(url string, maxConns int) *sql.DB {
+	db, err := sql.Open("postgres", url)
 	if err != nil {
 		slog.Default().Error("Unable to connect to database", slog.Any("err", err))
 		os.Exit(1)
 	}
 
-	db.SetMaxOpenConns(env.databaseMaxConns)
+	db.SetMaxOpenConns(maxConns)
 
 	return db
 }
@@ -4,14 +4,48 @@ import (
 	"log/slog"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 type EnvProvider struct {
 	appEnv           string
 	serverPort       string
 	databaseURL      string
+	privilegedDBURL  string
 	databaseMaxConns int
 	clerkSecretKey   string
+	idempotencyTTL   time.Duration
+	jobConcurrency   int
+	platformAdminIDs []string
+	outboxRedisURL   string
+	outboxNATSURL    string
+	ingestMaxBytes   int64
+	ingestQueueNATS  string
+	ingestMaxDeliver int
+	syslogUDPAddr    string
+	syslogTCPAddr    string
+	syslogTLSAddr    string
+	syslogTLSCert    string
+	syslogTLSKey     string
+	archiveDir       string
+	archiveS3        archiveS3Env
+	archiveMaxEvents int
+	archiveMaxAge    time.Duration
+	geoipCityDB      string
+	geoipASNDB       string
+	geoipCacheSize   int
+	geoipReload      time.Duration
+	credentialsKey   string
+}
+
+type archiveS3Env struct {
+	endpoint  string
+	region    string
+	bucket    string
+	accessKey string
+	secretKey string
+	pathStyle bool
 }
 
 func NewEnvProvider() *EnvProvider {
@@ -21,6 +55,8 @@ func NewEnvProvider() *EnvProvider {
 
 	// database
 	databaseURL := requiredEnvLookup("DATABASE_URL")
+	// owner role for migrations and background jobs; bypasses row-level security
+	privilegedDBURL := fallbackEnvLookup("DATABASE_PRIVILEGED_URL", databaseURL)
 	databaseMaxConns := fallbackEnvLookup("DATABASE_MAX_CONNS", "5")
 	parsedDatabaseMaxConns, err := strconv.Atoi(databaseMaxConns)
 	if err != nil {
@@ -32,12 +68,151 @@ func NewEnvProvider() *EnvProvider {
 	// clerk auth
 	clerkSecretKey := requiredEnvLookup("CLERK_SECRET_KEY")
 
+	// idempotency
+	idempotencyTTL := fallbackEnvLookup("IDEMPOTENCY_KEY_TTL", "24h")
+	parsedIdempotencyTTL, err := time.ParseDuration(idempotencyTTL)
+	if err != nil {
+		slog.Default().
+			Error("Failed to parse env value 'IDEMPOTENCY_KEY_TTL' as a duration", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	// background jobs; 0 disables the job worker in this process
+	jobConcurrency := fallbackEnvLookup("JOB_WORKER_CONCURRENCY", "4")
+	parsedJobConcurrency, err := strconv.Atoi(jobConcurrency)
+	if err != nil {
+		slog.Default().
+			Error("Failed to parse env value 'JOB_WORKER_CONCURRENCY' as an int", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	// platform admins (comma-separated Clerk user IDs)
+	var platformAdminIDs []string
+	for _, id := range strings.Split(fallbackEnvLookup("PLATFORM_ADMIN_IDS", ""), ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			platformAdminIDs = append(platformAdminIDs, id)
+		}
+	}
+
+	// outbox sinks; each is enabled when its URL is set
+	outboxRedisURL := fallbackEnvLookup("OUTBOX_REDIS_URL", "")
+	outboxNATSURL := fallbackEnvLookup("OUTBOX_NATS_URL", "")
+
+	// ingestion; bounds a request body, as sent and once decompressed
+	ingestMaxBytes := fallbackEnvLookup("INGEST_MAX_BODY_BYTES", "10485760")
+	parsedIngestMaxBytes, err := strconv.ParseInt(ingestMaxBytes, 10, 64)
+	if err != nil || parsedIngestMaxBytes <= 0 {
+		slog.Default().
+			Error("Failed to parse env value 'INGEST_MAX_BODY_BYTES' as a positive int", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	// ingest queue; JetStream when the URL is set, Postgres otherwise
+	ingestQueueNATSURL := fallbackEnvLookup("INGEST_QUEUE_NATS_URL", "")
+	ingestQueueMaxDeliver := fallbackEnvLookup("INGEST_QUEUE_MAX_DELIVER", "5")
+	parsedIngestQueueMaxDeliver, err := strconv.Atoi(ingestQueueMaxDeliver)
+	if err != nil || parsedIngestQueueMaxDeliver <= 0 {
+		slog.Default().
+			Error("Failed to parse env value 'INGEST_QUEUE_MAX_DELIVER' as a positive int", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	// syslog listeners; each is enabled when its address is set
+	syslogUDPAddr := fallbackEnvLookup("SYSLOG_UDP_ADDR", "")
+	syslogTCPAddr := fallbackEnvLookup("SYSLOG_TCP_ADDR", "")
+	syslogTLSAddr := fallbackEnvLookup("SYSLOG_TLS_ADDR", "")
+	syslogTLSCert := fallbackEnvLookup("SYSLOG_TLS_CERT_FILE", "")
+	syslogTLSKey := fallbackEnvLookup("SYSLOG_TLS_KEY_FILE", "")
+	if syslogTLSAddr != "" && (syslogTLSCert == "" || syslogTLSKey == "") {
+		slog.Default().Error("SYSLOG_TLS_ADDR requires SYSLOG_TLS_CERT_FILE and SYSLOG_TLS_KEY_FILE")
+		os.Exit(1)
+	}
+
+	// raw event archive; S3 when a bucket is set, else a local directory when
+	// set, else disabled
+	archiveDir := fallbackEnvLookup("ARCHIVE_DIR", "")
+	archiveS3Region := fallbackEnvLookup("ARCHIVE_S3_REGION", "us-east-1")
+	archiveS3 := archiveS3Env{
+		endpoint:  fallbackEnvLookup("ARCHIVE_S3_ENDPOINT", ""),
+		region:    archiveS3Region,
+		bucket:    fallbackEnvLookup("ARCHIVE_S3_BUCKET", ""),
+		accessKey: fallbackEnvLookup("ARCHIVE_S3_ACCESS_KEY_ID", ""),
+		secretKey: fallbackEnvLookup("ARCHIVE_S3_SECRET_ACCESS_KEY", ""),
+	}
+	if archiveS3.endpoint == "" {
+		archiveS3.endpoint = "https://s3." + archiveS3Region + ".amazonaws.com"
+	}
+	archiveS3.pathStyle, err = strconv.ParseBool(fallbackEnvLookup("ARCHIVE_S3_PATH_STYLE", "false"))
+	if err != nil {
+		slog.Default().
+			Error("Failed to parse env value 'ARCHIVE_S3_PATH_STYLE' as a bool", slog.Any("err", err))
+		os.Exit(1)
+	}
+	archiveMaxEvents := fallbackEnvLookup("ARCHIVE_SEGMENT_MAX_EVENTS", "10000")
+	parsedArchiveMaxEvents, err := strconv.Atoi(archiveMaxEvents)
+	if err != nil || parsedArchiveMaxEvents <= 0 {
+		slog.Default().
+			Error("Failed to parse env value 'ARCHIVE_SEGMENT_MAX_EVENTS' as a positive int", slog.Any("err", err))
+		os.Exit(1)
+	}
+	archiveMaxAge := fallbackEnvLookup("ARCHIVE_SEGMENT_MAX_AGE", "5m")
+	parsedArchiveMaxAge, err := time.ParseDuration(archiveMaxAge)
+	if err != nil {
+		slog.Default().
+			Error("Failed to parse env value 'ARCHIVE_SEGMENT_MAX_AGE' as a duration", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	// GeoIP enrichment; enabled when either database is set
+	geoipCityDB := fallbackEnvLookup("GEOIP_CITY_DB", "")
+	geoipASNDB := fallbackEnvLookup("GEOIP_ASN_DB", "")
+	geoipCacheSize := fallbackEnvLookup("GEOIP_CACHE_SIZE", "65536")
+	parsedGeoIPCacheSize, err := strconv.Atoi(geoipCacheSize)
+	if err != nil || parsedGeoIPCacheSize <= 0 {
+		slog.Default().
+			Error("Failed to parse env value 'GEOIP_CACHE_SIZE' as a positive int", slog.Any("err", err))
+		os.Exit(1)
+	}
+	geoipReload := fallbackEnvLookup("GEOIP_RELOAD_INTERVAL", "1m")
+	parsedGeoIPReload, err := time.ParseDuration(geoipReload)
+	if err != nil || parsedGeoIPReload <= 0 {
+		slog.Default().
+			Error("Failed to parse env value 'GEOIP_RELOAD_INTERVAL' as a positive duration", slog.Any("err", err))
+		os.Exit(1)
+	}
+
+	// master key encrypting the credentials orgs store for external services
+	credentialsKey := fallbackEnvLookup("CREDENTIALS_KEY", "")
+
 	envProvider := EnvProvider{
 		appEnv:           appEnv,
 		serverPort:       serverPort,
 		databaseURL:      databaseURL,
+		privilegedDBURL:  privilegedDBURL,
 		databaseMaxConns: parsedDatabaseMaxConns,
 		clerkSecretKey:   clerkSecretKey,
+		idempotencyTTL:   parsedIdempotencyTTL,
+		jobConcurrency:   parsedJobConcurrency,
+		platformAdminIDs: platformAdminIDs,
+		outboxRedisURL:   outboxRedisURL,
+		outboxNATSURL:    outboxNATSURL,
+		ingestMaxBytes:   parsedIngestMaxBytes,
+		ingestQueueNATS:  ingestQueueNATSURL,
+		ingestMaxDeliver: parsedIngestQueueMaxDeliver,
+		syslogUDPAddr:    syslogUDPAddr,
+		syslogTCPAddr:    syslogTCPAddr,
+		syslogTLSAddr:    syslogTLSAddr,
+		syslogTLSCert:    syslogTLSCert,
+		syslogTLSKey:     syslogTLSKey,
+		archiveDir:       archiveDir,
+		archiveS3:        archiveS3,
+		archiveMaxEvents: parsedArchiveMaxEvents,
+		archiveMaxAge:    parsedArchiveMaxAge,
+		geoipCityDB:      geoipCityDB,
+		geoipASNDB:       geoipASNDB,
+		geoipCacheSize:   parsedGeoIPCacheSize,
+		geoipReload:      parsedGeoIPReload,
+		credentialsKey:   credentialsKey,
 	}
 
 	return &envProvider
@@ -54,3 +229,71 @@ func (e *EnvProvider) ServerPort() string {
 func (e *EnvProvider) ClerkSecretKey() string {
 	return e.clerkSecretKey
 }
+
+func (e *EnvProvider) IdempotencyTTL() time.Duration {
+	return e.idempotencyTTL
+}
+
+func (e *EnvProvider) JobConcurrency() int {
+	return e.jobConcurrency
+}
+
+func (e *EnvProvider) PlatformAdminIDs() []string {
+	return e.platformAdminIDs
+}
+
+func (e *EnvProvider) OutboxRedisURL() string {
+	return e.outboxRedisURL
+}
+
+func (e *EnvProvider) OutboxNATSURL() string {
+	return e.outboxNATSURL
+}
+
+func (e *EnvProvider) IngestMaxBodyBytes() int64 {
+	return e.ingestMaxBytes
+}
+
+func (e *EnvProvider) SyslogUDPAddr() string {
+	return e.syslogUDPAddr
+}
+
+func (e *EnvProvider) SyslogTCPAddr() string {
+	return e.syslogTCPAddr
+}
+
+func (e *EnvProvider) SyslogTLSAddr() string {
+	return e.syslogTLSAddr
+}
+
+func (e *EnvProvider) SyslogTLSCertFile() string {
+	return e.syslogTLSCert
+}
+
+func (e *EnvProvider) SyslogTLSKeyFile() string {
+	return e.syslogTLSKey
+}
+
+// ArchiveEnabled reports whether a raw event archive store is configured.
+func (e *EnvProvider) ArchiveEnabled() bool {
+	return e.archiveS3.bucket != "" || e.archiveDir != ""
+}
+
+func (e *EnvProvider) ArchiveSegmentMaxEvents() int {
+	return e.archiveMaxEvents
+}
+
+func (e *EnvProvider) ArchiveSegmentMaxAge() time.Duration {
+	return e.archiveMaxAge
+}
+
+// GeoIPEnabled reports whether a GeoIP database is configured.
+func (e *EnvProvider) GeoIPEnabled() bool {
+	return e.geoipCityDB != "" || e.geoipASNDB != ""
+}
+
+// CredentialsEnabled reports whether a master key for stored credentials is
+// configured.
+func (e *EnvProvider) CredentialsEnabled() bool {
+	return e.credentialsKey != ""
+}
@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/luketeo/horizon/internal/geoip"
+)
+
+// NewGeoIPProvider loads the GeoLite2 databases GEOIP_CITY_DB and
+// GEOIP_ASN_DB name, either of which may be unset.
+func NewGeoIPProvider(env *EnvProvider, logger *slog.Logger) *geoip.Enricher {
+	enricher, err := geoip.Open(geoip.Config{
+		CityPath:       env.geoipCityDB,
+		ASNPath:        env.geoipASNDB,
+		CacheSize:      env.geoipCacheSize,
+		ReloadInterval: env.geoipReload,
+	}, logger)
+	if err != nil {
+		slog.Default().Error("Failed to load GeoIP databases", slog.Any("err", err))
+		os.Exit(1)
+	}
+	return enricher
+}
@@ -0,0 +1,32 @@
+package provider
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ingestQueueMaxAge is how long JetStream keeps queued events, consumed or
+// not.
+const ingestQueueMaxAge = 7 * 24 * time.Hour
+
+// NewIngestQueueProvider opens the ingest queue: JetStream when
+// INGEST_QUEUE_NATS_URL is set, otherwise the Postgres queue, whose consumers
+// use privDB.
+func NewIngestQueueProvider(env *EnvProvider, privDB *sql.DB, logger *slog.Logger) queue.Queue {
+	cfg := queue.Config{MaxDeliver: env.ingestMaxDeliver}
+	if env.ingestQueueNATS == "" {
+		return queue.NewPostgres(queue.NewRepo(privDB), tx.NewUnscopedManager(privDB), cfg, logger)
+	}
+	q, err := queue.NewJetStream(context.Background(), env.ingestQueueNATS, cfg, ingestQueueMaxAge, logger)
+	if err != nil {
+		slog.Default().Error("Failed to connect ingest queue to NATS", slog.Any("err", err))
+		os.Exit(1)
+	}
+	return q
+}
@@ -0,0 +1,24 @@
+package provider
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/luketeo/horizon/internal/platform/secret"
+)
+
+// NewSecretBoxProvider builds the box sealing stored credentials with the
+// master key CREDENTIALS_KEY holds.
+func NewSecretBoxProvider(env *EnvProvider) *secret.Box {
+	key, err := secret.ParseKey(env.credentialsKey)
+	if err != nil {
+		slog.Default().Error("Failed to parse env value 'CREDENTIALS_KEY'", slog.Any("err", err))
+		os.Exit(1)
+	}
+	box, err := secret.NewBox(key)
+	if err != nil {
+		slog.Default().Error("Failed to create credentials box", slog.Any("err", err))
+		os.Exit(1)
+	}
+	return box
+}
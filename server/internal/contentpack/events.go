@@ -0,0 +1,46 @@
+package contentpack
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per install. The
+// patterns and mappings an install writes raise their own events too.
+const (
+	aggregateType = "content_pack"
+
+	EventInstalled = "content_pack.installed"
+	EventUpgraded  = "content_pack.upgraded"
+)
+
+// InstalledPayload is the payload of EventInstalled and EventUpgraded.
+type InstalledPayload struct {
+	ID              uuid.UUID                    `json:"id"`
+	OrgID           uuid.UUID                    `json:"org_id"`
+	Pack            string                       `json:"pack"`
+	Version         int                          `json:"version"`
+	PreviousVersion *int                         `json:"previous_version"`
+	Items           []oapi.ContentPackItemResult `json:"items"`
+}
+
+// emit appends an event about installID to the outbox, in the transaction on
+// ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, installID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   installID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
@@ -0,0 +1,147 @@
+package contentpack
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// Handler serves the /organizations/{orgId}/content-packs/* endpoints.
+// Members may browse the bundled packs; admins install them.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound() oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(httpx.Prob(404, "Not Found", "Content pack not found"))
+}
+
+func conflict(detail string) oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", detail),
+	)
+}
+
+func (h *Handler) ListContentPacks(
+	ctx context.Context,
+	request oapi.ListContentPacksRequestObject,
+) (oapi.ListContentPacksResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListContentPacks403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	packs, err := h.svc.List(ctx, request.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	return oapi.ListContentPacks200JSONResponse(packs), nil
+}
+
+func (h *Handler) GetContentPack(
+	ctx context.Context,
+	request oapi.GetContentPackRequestObject,
+) (oapi.GetContentPackResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetContentPack403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	p, err := h.svc.Get(ctx, request.OrgId, request.PackId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetContentPack404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.GetContentPack200JSONResponse(p), nil
+}
+
+func (h *Handler) InstallContentPack(
+	ctx context.Context,
+	request oapi.InstallContentPackRequestObject,
+) (oapi.InstallContentPackResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.InstallContentPack403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.InstallContentPack403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	res, err := h.svc.Install(ctx, request.OrgId, request.PackId)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.InstallContentPack404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.InstallContentPack409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.InstallContentPack200JSONResponse(res), nil
+}
@@ -0,0 +1,92 @@
+package contentpack_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/contentpack"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func newSeededHandler(t *testing.T) (*contentpack.Handler, oapi.Organization) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(
+		ctx,
+		fakeClerkUser("user_pack_handler_owner", "ownr@example.com"),
+	)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), "Content Pack Handler Org", nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+
+	return contentpack.NewHandler(newServices(db).packs, userSvc, orgSvc), o
+}
+
+func ownerCtx() context.Context {
+	return middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_pack_handler_owner", "ownr@example.com"),
+	)
+}
+
+func TestListContentPacks_UnauthenticatedReturnsForbidden(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	resp, err := h.ListContentPacks(context.Background(), oapi.ListContentPacksRequestObject{OrgId: org.Id})
+	if err != nil {
+		t.Fatalf("ListContentPacks: %v", err)
+	}
+	if _, ok := resp.(oapi.ListContentPacks403ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 403, got %T", resp)
+	}
+}
+
+func TestInstallContentPack(t *testing.T) {
+	h, org := newSeededHandler(t)
+	ctx := ownerCtx()
+
+	resp, err := h.InstallContentPack(ctx, oapi.InstallContentPackRequestObject{OrgId: org.Id, PackId: "nope"})
+	if err != nil {
+		t.Fatalf("InstallContentPack: %v", err)
+	}
+	if _, ok := resp.(oapi.InstallContentPack404ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("unknown pack: want 404, got %T", resp)
+	}
+
+	resp, err = h.InstallContentPack(ctx, oapi.InstallContentPackRequestObject{OrgId: org.Id, PackId: "web-access"})
+	if err != nil {
+		t.Fatalf("InstallContentPack: %v", err)
+	}
+	if _, ok := resp.(oapi.InstallContentPack200JSONResponse); !ok {
+		t.Fatalf("want 200, got %T", resp)
+	}
+
+	got, err := h.GetContentPack(ctx, oapi.GetContentPackRequestObject{OrgId: org.Id, PackId: "web-access"})
+	if err != nil {
+		t.Fatalf("GetContentPack: %v", err)
+	}
+	p, ok := got.(oapi.GetContentPack200JSONResponse)
+	if !ok || p.InstalledVersion == nil || *p.InstalledVersion != p.Version {
+		t.Fatalf("GetContentPack: want installed at its version, got %+v", got)
+	}
+}
@@ -0,0 +1,179 @@
+package contentpack
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+//go:embed packs/*.json
+var bundled embed.FS
+
+// Pack is a bundled content pack: the grok patterns, OCSF mappings and
+// fixtures that normalize one kind of log source. Its Version goes up with
+// every change to its content.
+type Pack struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Version     int    `json:"version"`
+	// Patterns are the custom grok patterns the pack's parsers use, by name.
+	Patterns map[string]string `json:"patterns,omitempty"`
+	Mappings []Mapping         `json:"mappings"`
+}
+
+// Mapping is a mapping a pack installs, with the fixtures it must pass.
+type Mapping struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parser is the parser a source of these events should use.
+	Parser     string         `json:"parser"`
+	Definition map[string]any `json:"definition"`
+	Fixtures   []Fixture      `json:"fixtures"`
+}
+
+// Fixture is a sample event of a mapping and attributes its OCSF event must
+// have. It is read with the mapping's parser unless it names its own.
+type Fixture struct {
+	Name     string         `json:"name"`
+	Parser   string         `json:"parser,omitempty"`
+	Input    map[string]any `json:"input"`
+	Expected map[string]any `json:"expected"`
+}
+
+// FixtureParser returns the parser f is read with.
+func (m Mapping) FixtureParser(f Fixture) string {
+	if f.Parser != "" {
+		return f.Parser
+	}
+	return m.Parser
+}
+
+// Library returns a grok library with the pack's patterns.
+func (p *Pack) Library() *parser.Library {
+	return parser.NewLibrary(p.Patterns)
+}
+
+var packID = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+
+// catalog loads the bundled packs once. A pack that does not load is a bug in
+// the build, which the package's tests catch.
+var catalog = sync.OnceValues(func() ([]*Pack, error) { return load(bundled) })
+
+// Catalog returns the bundled packs, ordered by ID.
+func Catalog() ([]*Pack, error) {
+	return catalog()
+}
+
+// Lookup returns the bundled pack with the given ID. Returns ErrNotFound when
+// there is none.
+func Lookup(id string) (*Pack, error) {
+	packs, err := Catalog()
+	if err != nil {
+		return nil, err
+	}
+	i, found := slices.BinarySearchFunc(packs, id, func(p *Pack, id string) int { return strings.Compare(p.ID, id) })
+	if !found {
+		return nil, ErrNotFound
+	}
+	return packs[i], nil
+}
+
+// load reads and checks every pack in fsys.
+func load(fsys fs.FS) ([]*Pack, error) {
+	names, err := fs.Glob(fsys, "packs/*.json")
+	if err != nil {
+		return nil, err
+	}
+	packs := make([]*Pack, 0, len(names))
+	for _, name := range names {
+		raw, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		var p Pack
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return nil, fmt.Errorf("content pack %s: %w", name, err)
+		}
+		if want := strings.TrimSuffix(path.Base(name), ".json"); p.ID != want {
+			return nil, fmt.Errorf("content pack %s: id %q does not match its file", name, p.ID)
+		}
+		if err := p.check(); err != nil {
+			return nil, fmt.Errorf("content pack %s: %w", p.ID, err)
+		}
+		packs = append(packs, &p)
+	}
+	slices.SortFunc(packs, func(a, b *Pack) int { return strings.Compare(a.ID, b.ID) })
+	return packs, nil
+}
+
+// check reports the first problem with the pack: patterns that are not valid
+// custom patterns, parsers that do not resolve, definitions that do not
+// compile and names that repeat.
+func (p *Pack) check() error {
+	switch {
+	case !packID.MatchString(p.ID):
+		return errors.New("id must be lowercase words joined by hyphens")
+	case p.Name == "":
+		return errors.New("name is required")
+	case p.Version < 1:
+		return errors.New("version must be positive")
+	case len(p.Mappings) == 0:
+		return errors.New("no mappings")
+	}
+	lib := p.Library()
+	for name, expr := range p.Patterns {
+		if !parser.PatternName(name) || parser.IsBuiltinPattern(name) {
+			return fmt.Errorf("pattern %s: not a custom pattern name", name)
+		}
+		if _, err := lib.Compile(expr); err != nil {
+			return fmt.Errorf("pattern %s: %w", name, err)
+		}
+	}
+
+	mappings := map[string]bool{}
+	for _, m := range p.Mappings {
+		if m.Name == "" || mappings[m.Name] {
+			return fmt.Errorf("mapping %q: names must be present and distinct", m.Name)
+		}
+		mappings[m.Name] = true
+		raw, err := json.Marshal(m.Definition)
+		if err != nil {
+			return fmt.Errorf("mapping %s: %w", m.Name, err)
+		}
+		if _, err := mapping.Compile(raw); err != nil {
+			return fmt.Errorf("mapping %s: %w", m.Name, err)
+		}
+		if m.Parser != "" {
+			if _, err := parser.Resolve(m.Parser, lib); err != nil {
+				return fmt.Errorf("mapping %s: %w", m.Name, err)
+			}
+		}
+		if len(m.Fixtures) == 0 {
+			return fmt.Errorf("mapping %s: no fixtures", m.Name)
+		}
+		fixtures := map[string]bool{}
+		for _, f := range m.Fixtures {
+			if f.Name == "" || fixtures[f.Name] {
+				return fmt.Errorf("mapping %s: fixture %q: names must be present and distinct", m.Name, f.Name)
+			}
+			fixtures[f.Name] = true
+			if f.Parser != "" {
+				if _, err := parser.Resolve(f.Parser, lib); err != nil {
+					return fmt.Errorf("mapping %s: fixture %s: %w", m.Name, f.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
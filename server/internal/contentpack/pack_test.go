@@ -0,0 +1,116 @@
+package contentpack_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/contentpack"
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pipeline"
+)
+
+func TestCatalog_LoadsEveryPack(t *testing.T) {
+	packs, err := contentpack.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	var ids []string
+	for _, p := range packs {
+		ids = append(ids, p.ID)
+	}
+	want := []string{
+		"aws-cloudtrail", "linux-auditd", "linux-authlog", "okta-system-log", "osquery",
+		"suricata-eve", "web-access", "windows-security", "zeek",
+	}
+	if !slices.Equal(ids, want) {
+		t.Errorf("Catalog: want %v, got %v", want, ids)
+	}
+
+	p, err := contentpack.Lookup("zeek")
+	if err != nil || p.ID != "zeek" {
+		t.Errorf("Lookup zeek: got %v (%v)", p, err)
+	}
+	if _, err := contentpack.Lookup("nope"); !errors.Is(err, contentpack.ErrNotFound) {
+		t.Errorf("Lookup unknown: want ErrNotFound, got %v", err)
+	}
+}
+
+// TestCatalog_FixturesPass runs every fixture of every pack as activating its
+// mapping would: parsed with the pack's parser, mapped, and checked as OCSF.
+func TestCatalog_FixturesPass(t *testing.T) {
+	packs, err := contentpack.Catalog()
+	if err != nil {
+		t.Fatalf("Catalog: %v", err)
+	}
+	for _, p := range packs {
+		lib := p.Library()
+		for _, m := range p.Mappings {
+			raw, _ := json.Marshal(m.Definition)
+			compiled, err := mapping.Compile(raw)
+			if err != nil {
+				t.Fatalf("%s/%s: %v", p.ID, m.Name, err)
+			}
+			for _, f := range m.Fixtures {
+				t.Run(p.ID+"/"+m.Name+"/"+f.Name, func(t *testing.T) {
+					got, err := run(lib, m.FixtureParser(f), compiled, f.Input)
+					if err != nil {
+						t.Fatal(err)
+					}
+					for _, e := range mismatches("", f.Expected, got) {
+						t.Error(e)
+					}
+				})
+			}
+		}
+	}
+}
+
+// run returns the OCSF event a queued event with the given payload becomes.
+func run(lib *parser.Library, name string, m *mapping.Mapping, input map[string]any) (map[string]any, error) {
+	proc := pipeline.NewProcessor("", nil)
+	if name != "" {
+		p, err := parser.Resolve(name, lib)
+		if err != nil {
+			return nil, err
+		}
+		proc = pipeline.NewProcessor(name, p)
+	}
+	payload, _ := json.Marshal(input)
+	fields, err := proc.Parse(payload)
+	if err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	attrs := m.Apply(fields)
+	ev, err := ocsf.Normalize(attrs)
+	if err != nil {
+		return nil, fmt.Errorf("normalize %v: %w", attrs, err)
+	}
+	raw, _ := json.Marshal(ev)
+	var out map[string]any
+	return out, json.Unmarshal(raw, &out)
+}
+
+// mismatches compares the attributes want lists, as activation does.
+func mismatches(prefix string, want, got map[string]any) []string {
+	var errs []string
+	for _, name := range slices.Sorted(maps.Keys(want)) {
+		w, g := want[name], got[name]
+		if wObj, ok := w.(map[string]any); ok {
+			if gObj, ok := g.(map[string]any); ok {
+				errs = append(errs, mismatches(prefix+name+".", wObj, gObj)...)
+				continue
+			}
+		}
+		if !reflect.DeepEqual(w, g) {
+			errs = append(errs, fmt.Sprintf("%s%s: want %v, got %v", prefix, name, w, g))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,174 @@
+package contentpack
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// errNotInstalled and errInstalled are returned when the org has not, or has,
+// installed a pack.
+var (
+	errNotInstalled = errors.New("content pack not installed")
+	errInstalled    = errors.New("content pack already installed")
+)
+
+// Repo owns content_pack_installs and content_pack_items SQL. The tables are
+// protected by RLS, so callers run statements inside tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// item identifies a pattern or mapping an install tracks.
+type item struct {
+	kind string
+	name string
+}
+
+const (
+	kindPattern = "pattern"
+	kindMapping = "mapping"
+)
+
+// ── Installs ─────────────────────────────────────────────────────────────────
+
+// ListInstalls returns the org's installs by pack.
+func (r *Repo) ListInstalls(ctx context.Context, orgID uuid.UUID) (map[string]model.ContentPackInstalls, error) {
+	t := table.ContentPackInstalls
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID)))
+
+	var rows []model.ContentPackInstalls
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing content pack installs: %w", err)
+	}
+	installs := make(map[string]model.ContentPackInstalls, len(rows))
+	for _, row := range rows {
+		installs[row.Pack] = row
+	}
+	return installs, nil
+}
+
+// LockInstall returns the org's install of a pack, locked until the
+// transaction ends so that installs of one pack queue behind each other.
+// Returns errNotInstalled when the org has not installed it.
+func (r *Repo) LockInstall(ctx context.Context, orgID uuid.UUID, pack string) (model.ContentPackInstalls, error) {
+	t := table.ContentPackInstalls
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.OrgID.EQ(postgres.UUID(orgID)).
+				AND(t.Pack.EQ(postgres.String(pack))),
+		).
+		LIMIT(1).
+		FOR(postgres.UPDATE())
+
+	var row model.ContentPackInstalls
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return model.ContentPackInstalls{}, errNotInstalled
+		}
+		return model.ContentPackInstalls{}, fmt.Errorf("getting content pack install: %w", err)
+	}
+	return row, nil
+}
+
+// InsertInstall records a first install of a pack at its version. Returns
+// errInstalled when the org has installed the pack already, waiting for a
+// concurrent first install to finish first.
+func (r *Repo) InsertInstall(
+	ctx context.Context,
+	orgID uuid.UUID,
+	pack string,
+	version int,
+) (model.ContentPackInstalls, error) {
+	t := table.ContentPackInstalls
+	stmt := t.
+		INSERT(t.OrgID, t.Pack, t.PackVersion).
+		VALUES(orgID, pack, int32(version)).
+		ON_CONFLICT(t.OrgID, t.Pack).
+		DO_NOTHING().
+		RETURNING(t.AllColumns)
+
+	var row model.ContentPackInstalls
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return model.ContentPackInstalls{}, errInstalled
+		}
+		return model.ContentPackInstalls{}, fmt.Errorf("inserting content pack install: %w", err)
+	}
+	return row, nil
+}
+
+// UpdateInstall records that the install now has the pack at version, and
+// bumps its row version.
+func (r *Repo) UpdateInstall(ctx context.Context, installID uuid.UUID, version int) (model.ContentPackInstalls, error) {
+	t := table.ContentPackInstalls
+	stmt := t.
+		UPDATE(t.PackVersion, t.Version, t.UpdatedAt).
+		SET(postgres.Int32(int32(version)), t.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(t.ID.EQ(postgres.UUID(installID))).
+		RETURNING(t.AllColumns)
+
+	var row model.ContentPackInstalls
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		return model.ContentPackInstalls{}, fmt.Errorf("updating content pack install: %w", err)
+	}
+	return row, nil
+}
+
+// ── Items ────────────────────────────────────────────────────────────────────
+
+// ListItems returns the patterns and mappings the install tracks.
+func (r *Repo) ListItems(ctx context.Context, installID uuid.UUID) (map[item]model.ContentPackItems, error) {
+	t := table.ContentPackItems
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.InstallID.EQ(postgres.UUID(installID)))
+
+	var rows []model.ContentPackItems
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing content pack items: %w", err)
+	}
+	items := make(map[item]model.ContentPackItems, len(rows))
+	for _, row := range rows {
+		items[item{kind: row.Kind, name: row.Name}] = row
+	}
+	return items, nil
+}
+
+// PutItem tracks the pattern or mapping the install wrote, at the version it
+// wrote.
+func (r *Repo) PutItem(ctx context.Context, row model.ContentPackItems) error {
+	t := table.ContentPackItems
+	stmt := t.
+		INSERT(t.InstallID, t.Kind, t.Name, t.OrgID, t.ItemID, t.ItemVersion).
+		MODEL(row).
+		ON_CONFLICT(t.InstallID, t.Kind, t.Name).
+		DO_UPDATE(postgres.SET(
+			t.ItemID.SET(t.EXCLUDED.ItemID),
+			t.ItemVersion.SET(t.EXCLUDED.ItemVersion),
+		))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("tracking content pack item: %w", err)
+	}
+	return nil
+}
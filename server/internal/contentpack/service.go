@@ -0,0 +1,419 @@
+// Package contentpack ships ready-made normalization content for common log
+// sources: grok patterns, OCSF mappings and the fixtures that prove them,
+// bundled in the binary as versioned packs. Installing a pack creates its
+// patterns and mappings in an organisation; installing a newer version
+// upgrades those the organisation has left as the pack wrote them, and keeps
+// those it has changed or deleted.
+package contentpack
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrNotFound is returned when no bundled pack has the ID.
+var ErrNotFound = errors.New("content pack not found")
+
+// ErrConflict is returned when installing a pack would take the name of a
+// pattern or mapping the pack did not create, or the pack's content does not
+// fit with the organisation's own patterns.
+var ErrConflict = errors.New("content pack conflicts with existing content")
+
+// Service installs bundled packs into organisations. Each operation runs in a
+// transaction carrying the caller's tenant scope, so an install creates or
+// upgrades every item of the pack or none of them. The pattern and mapping
+// services append their own domain events in that same transaction.
+type Service struct {
+	repo     *Repo
+	patterns *pattern.Service
+	mappings *normalization.Service
+	tx       *tx.Manager
+	events   *outbox.Repo
+	logger   *slog.Logger
+}
+
+// NewService wires a Service with its repo, the pattern and mapping services,
+// transaction manager, outbox and logger.
+func NewService(
+	repo *Repo,
+	patterns *pattern.Service,
+	mappings *normalization.Service,
+	txm *tx.Manager,
+	events *outbox.Repo,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		repo:     repo,
+		patterns: patterns,
+		mappings: mappings,
+		tx:       txm,
+		events:   events,
+		logger:   logger,
+	}
+}
+
+// describe returns the pack as the API shows it, with the version the org has
+// installed, if any.
+func describe(p *Pack, installed *int) oapi.ContentPack {
+	mappings := make([]oapi.ContentPackMapping, 0, len(p.Mappings))
+	for _, m := range p.Mappings {
+		mappings = append(mappings, oapi.ContentPackMapping{
+			Name:        m.Name,
+			Description: m.Description,
+			Parser:      m.Parser,
+			Fixtures:    len(m.Fixtures),
+		})
+	}
+	return oapi.ContentPack{
+		Id:               p.ID,
+		Name:             p.Name,
+		Description:      p.Description,
+		Version:          p.Version,
+		Patterns:         slices.Sorted(maps.Keys(p.Patterns)),
+		Mappings:         mappings,
+		InstalledVersion: installed,
+	}
+}
+
+func installedVersion(installs map[string]model.ContentPackInstalls, pack string) *int {
+	install, ok := installs[pack]
+	if !ok {
+		return nil
+	}
+	v := int(install.PackVersion)
+	return &v
+}
+
+// List returns the bundled packs with the versions the org has installed.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.ContentPack, error) {
+	packs, err := Catalog()
+	if err != nil {
+		return nil, err
+	}
+	var installs map[string]model.ContentPackInstalls
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		installs, err = s.repo.ListInstalls(ctx, orgID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	out := make([]oapi.ContentPack, 0, len(packs))
+	for _, p := range packs {
+		out = append(out, describe(p, installedVersion(installs, p.ID)))
+	}
+	return out, nil
+}
+
+// Get returns one bundled pack with the version the org has installed.
+// Returns ErrNotFound when no pack has the ID.
+func (s *Service) Get(ctx context.Context, orgID uuid.UUID, packID string) (oapi.ContentPack, error) {
+	p, err := Lookup(packID)
+	if err != nil {
+		return oapi.ContentPack{}, err
+	}
+	var installs map[string]model.ContentPackInstalls
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		installs, err = s.repo.ListInstalls(ctx, orgID)
+		return err
+	})
+	if err != nil {
+		return oapi.ContentPack{}, err
+	}
+	return describe(p, installedVersion(installs, p.ID)), nil
+}
+
+// ── Installs ─────────────────────────────────────────────────────────────────
+
+// Install creates the pack's patterns and mappings in the org, or brings an
+// earlier install up to the bundled version. Items the org changed since the
+// pack last wrote them are kept, and items it deleted are not brought back;
+// items a newer pack no longer has are left to the org. Returns ErrNotFound
+// when no pack has the ID and ErrConflict when a new item's name is taken.
+func (s *Service) Install(
+	ctx context.Context,
+	orgID uuid.UUID,
+	packID string,
+) (oapi.ContentPackInstallResult, error) {
+	p, err := Lookup(packID)
+	if err != nil {
+		return oapi.ContentPackInstallResult{}, err
+	}
+	var res oapi.ContentPackInstallResult
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		install, err := s.repo.InsertInstall(ctx, orgID, p.ID, p.Version)
+		var previous *int
+		switch {
+		case errors.Is(err, errInstalled):
+			if install, err = s.repo.LockInstall(ctx, orgID, p.ID); err != nil {
+				return err
+			}
+			v := int(install.PackVersion)
+			previous = &v
+		case err != nil:
+			return err
+		}
+
+		in := &installer{svc: s, orgID: orgID, installID: install.ID}
+		if in.tracked, err = s.repo.ListItems(ctx, install.ID); err != nil {
+			return err
+		}
+		var items []oapi.ContentPackItemResult
+		for _, name := range slices.Sorted(maps.Keys(p.Patterns)) {
+			item, err := in.pattern(ctx, p, name)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+		for _, m := range p.Mappings {
+			item, err := in.mapping(ctx, m)
+			if err != nil {
+				return err
+			}
+			items = append(items, item)
+		}
+
+		if previous != nil {
+			if _, err := s.repo.UpdateInstall(ctx, install.ID, p.Version); err != nil {
+				return err
+			}
+		}
+		res = oapi.ContentPackInstallResult{
+			Pack:            describe(p, &p.Version),
+			PreviousVersion: previous,
+			Items:           items,
+		}
+		event := EventInstalled
+		if previous != nil {
+			event = EventUpgraded
+		}
+		return s.emit(ctx, orgID, install.ID, event, InstalledPayload{
+			ID:              install.ID,
+			OrgID:           orgID,
+			Pack:            p.ID,
+			Version:         p.Version,
+			PreviousVersion: previous,
+			Items:           items,
+		})
+	})
+	return res, err
+}
+
+// installer writes one pack's items into an org, given the items the org's
+// install of the pack tracks.
+type installer struct {
+	svc       *Service
+	orgID     uuid.UUID
+	installID uuid.UUID
+	tracked   map[item]model.ContentPackItems
+}
+
+// track records that the install wrote an item at version.
+func (in *installer) track(
+	ctx context.Context,
+	kind oapi.ContentPackItemKind,
+	name string,
+	id uuid.UUID,
+	version int64,
+	action oapi.ContentPackItemAction,
+) (oapi.ContentPackItemResult, error) {
+	err := in.svc.repo.PutItem(ctx, model.ContentPackItems{
+		InstallID:   in.installID,
+		Kind:        string(kind),
+		Name:        name,
+		OrgID:       in.orgID,
+		ItemID:      id,
+		ItemVersion: version,
+	})
+	return oapi.ContentPackItemResult{Kind: kind, Name: name, Action: action, Id: &id, Version: &version}, err
+}
+
+// pattern creates or upgrades one of the pack's grok patterns.
+func (in *installer) pattern(ctx context.Context, p *Pack, name string) (oapi.ContentPackItemResult, error) {
+	kind := oapi.ContentPackItemKindPattern
+	expr := p.Patterns[name]
+	tracked, ok := in.tracked[item{kind: kindPattern, name: name}]
+	if !ok {
+		description := "Installed by the " + p.Name + " content pack."
+		created, err := in.svc.patterns.Create(ctx, in.orgID, oapi.CreateGrokPatternRequest{
+			Name:        name,
+			Pattern:     expr,
+			Description: &description,
+		})
+		if err != nil {
+			return oapi.ContentPackItemResult{}, patternErr(name, err)
+		}
+		return in.track(ctx, kind, name, created.Id, created.Version, oapi.Created)
+	}
+
+	current, err := in.svc.patterns.Get(ctx, in.orgID, tracked.ItemID)
+	switch {
+	case errors.Is(err, pattern.ErrNotFound):
+		return oapi.ContentPackItemResult{Kind: kind, Name: name, Action: oapi.Skipped}, nil
+	case err != nil:
+		return oapi.ContentPackItemResult{}, err
+	case current.Version != tracked.ItemVersion:
+		return oapi.ContentPackItemResult{
+			Kind: kind, Name: name, Action: oapi.Kept, Id: &current.Id, Version: &current.Version,
+		}, nil
+	case current.Pattern == expr:
+		return oapi.ContentPackItemResult{
+			Kind: kind, Name: name, Action: oapi.Unchanged, Id: &current.Id, Version: &current.Version,
+		}, nil
+	}
+	updated, err := in.svc.patterns.Update(
+		ctx, in.orgID, current.Id,
+		oapi.UpdateGrokPatternRequest{Pattern: &expr},
+		[]int64{current.Version},
+	)
+	if err != nil {
+		return oapi.ContentPackItemResult{}, patternErr(name, err)
+	}
+	return in.track(ctx, kind, name, updated.Id, updated.Version, oapi.Updated)
+}
+
+// patternErr reports a pattern the org's patterns leave no room for as
+// ErrConflict.
+func patternErr(name string, err error) error {
+	if errors.Is(err, pattern.ErrConflict) || errors.Is(err, pattern.ErrInvalid) {
+		return fmt.Errorf("%w: grok pattern %s: %w", ErrConflict, name, err)
+	}
+	return err
+}
+
+// mapping creates or upgrades one of the pack's mappings with its fixtures.
+// An upgrade adds the pack's definition as the mapping's next version when it
+// differs from the latest, and replaces the pack's fixtures by name, leaving
+// fixtures the org added alone.
+func (in *installer) mapping(ctx context.Context, m Mapping) (oapi.ContentPackItemResult, error) {
+	kind := oapi.ContentPackItemKindMapping
+	tracked, ok := in.tracked[item{kind: kindMapping, name: m.Name}]
+	if !ok {
+		created, err := in.svc.mappings.Create(ctx, in.orgID, oapi.CreateMappingRequest{
+			Name:        m.Name,
+			Description: &m.Description,
+			Definition:  m.Definition,
+		})
+		if err != nil {
+			return oapi.ContentPackItemResult{}, mappingErr(m.Name, err)
+		}
+		for _, f := range m.Fixtures {
+			if _, err := in.svc.mappings.CreateFixture(ctx, in.orgID, created.Id, fixture(m, f)); err != nil {
+				return oapi.ContentPackItemResult{}, mappingErr(m.Name, err)
+			}
+		}
+		return in.track(ctx, kind, m.Name, created.Id, int64(created.LatestVersion), oapi.Created)
+	}
+
+	current, err := in.svc.mappings.Get(ctx, in.orgID, tracked.ItemID)
+	latest := int64(current.LatestVersion)
+	switch {
+	case errors.Is(err, normalization.ErrNotFound):
+		return oapi.ContentPackItemResult{Kind: kind, Name: m.Name, Action: oapi.Skipped}, nil
+	case err != nil:
+		return oapi.ContentPackItemResult{}, err
+	case latest != tracked.ItemVersion:
+		return oapi.ContentPackItemResult{
+			Kind: kind, Name: m.Name, Action: oapi.Kept, Id: &current.Id, Version: &latest,
+		}, nil
+	}
+
+	action := oapi.Unchanged
+	v, err := in.svc.mappings.GetVersion(ctx, in.orgID, current.Id, current.LatestVersion)
+	if err != nil {
+		return oapi.ContentPackItemResult{}, err
+	}
+	if !sameJSON(v.Definition, m.Definition) {
+		if v, err = in.svc.mappings.CreateVersion(ctx, in.orgID, current.Id, m.Definition); err != nil {
+			return oapi.ContentPackItemResult{}, mappingErr(m.Name, err)
+		}
+		latest, action = int64(v.Version), oapi.Updated
+	}
+	changed, err := in.fixtures(ctx, current.Id, m)
+	if err != nil {
+		return oapi.ContentPackItemResult{}, mappingErr(m.Name, err)
+	}
+	if changed {
+		action = oapi.Updated
+	}
+	return in.track(ctx, kind, m.Name, current.Id, latest, action)
+}
+
+// fixtures brings the mapping's fixtures of the pack's names up to the pack,
+// and reports whether any changed.
+func (in *installer) fixtures(ctx context.Context, mappingID uuid.UUID, m Mapping) (bool, error) {
+	existing, err := in.svc.mappings.ListFixtures(ctx, in.orgID, mappingID)
+	if err != nil {
+		return false, err
+	}
+	byName := make(map[string]oapi.MappingFixture, len(existing))
+	for _, f := range existing {
+		byName[f.Name] = f
+	}
+	changed := false
+	for _, f := range m.Fixtures {
+		want := fixture(m, f)
+		if have, ok := byName[f.Name]; ok {
+			if sameFixture(have, want) {
+				continue
+			}
+			if err := in.svc.mappings.DeleteFixture(ctx, in.orgID, mappingID, have.Id); err != nil {
+				return false, err
+			}
+		}
+		if _, err := in.svc.mappings.CreateFixture(ctx, in.orgID, mappingID, want); err != nil {
+			return false, err
+		}
+		changed = true
+	}
+	return changed, nil
+}
+
+// mappingErr reports a mapping whose name is taken, or whose fixtures name a
+// parser the org no longer has, as ErrConflict.
+func mappingErr(name string, err error) error {
+	if errors.Is(err, normalization.ErrConflict) || errors.Is(err, normalization.ErrInvalid) {
+		return fmt.Errorf("%w: mapping %s: %w", ErrConflict, name, err)
+	}
+	return err
+}
+
+// fixture returns the request creating f on the mapping.
+func fixture(m Mapping, f Fixture) oapi.CreateMappingFixtureRequest {
+	req := oapi.CreateMappingFixtureRequest{Name: f.Name, Input: f.Input, Expected: f.Expected}
+	if parser := m.FixtureParser(f); parser != "" {
+		req.Parser = &parser
+	}
+	return req
+}
+
+func sameFixture(have oapi.MappingFixture, want oapi.CreateMappingFixtureRequest) bool {
+	return (have.Parser == nil) == (want.Parser == nil) &&
+		(have.Parser == nil || *have.Parser == *want.Parser) &&
+		sameJSON(have.Input, want.Input) &&
+		sameJSON(have.Expected, want.Expected)
+}
+
+// sameJSON reports whether a and b encode alike. Objects encode with sorted
+// keys, so decoded documents compare by content.
+func sameJSON(a, b any) bool {
+	x, errX := json.Marshal(a)
+	y, errY := json.Marshal(b)
+	return errX == nil && errY == nil && bytes.Equal(x, y)
+}
@@ -0,0 +1,277 @@
+package contentpack_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/contentpack"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+type services struct {
+	packs    *contentpack.Service
+	patterns *pattern.Service
+	mappings *normalization.Service
+}
+
+func newServices(db *sql.DB) services {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+	sourceSvc := source.NewService(source.NewRepo(db), txm, events, logger)
+	patternSvc := pattern.NewService(pattern.NewRepo(db), txm, events, logger)
+	mappingSvc := normalization.NewService(normalization.NewRepo(db), sourceSvc, patternSvc, txm, events, logger)
+	return services{
+		packs:    contentpack.NewService(contentpack.NewRepo(db), patternSvc, mappingSvc, txm, events, logger),
+		patterns: patternSvc,
+		mappings: mappingSvc,
+	}
+}
+
+func newSeededServices(t *testing.T) (services, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_pack_seed", "Content Pack Test Org")
+	return newServices(db), orgID
+}
+
+// actions returns what the install did to each item, by kind and name.
+func actions(res oapi.ContentPackInstallResult) map[string]oapi.ContentPackItemAction {
+	out := map[string]oapi.ContentPackItemAction{}
+	for _, item := range res.Items {
+		out[string(item.Kind)+"/"+item.Name] = item.Action
+	}
+	return out
+}
+
+// itemID returns the pattern or mapping the install wrote for an item.
+func itemID(t *testing.T, res oapi.ContentPackInstallResult, kind oapi.ContentPackItemKind, name string) uuid.UUID {
+	t.Helper()
+	for _, item := range res.Items {
+		if item.Kind == kind && item.Name == name && item.Id != nil {
+			return *item.Id
+		}
+	}
+	t.Fatalf("no %s %s in %+v", kind, name, res.Items)
+	return uuid.Nil
+}
+
+func TestInstall_CreatesItemsAndReinstallsUnchanged(t *testing.T) {
+	svcs, orgID := newSeededServices(t)
+	ctx := context.Background()
+
+	res, err := svcs.packs.Install(ctx, orgID, "linux-auditd")
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	if res.PreviousVersion != nil || res.Pack.InstalledVersion == nil ||
+		*res.Pack.InstalledVersion != res.Pack.Version {
+		t.Errorf("Install: want first install of version %d, got %+v", res.Pack.Version, res)
+	}
+	want := map[string]oapi.ContentPackItemAction{
+		"pattern/AUDITD":       oapi.Created,
+		"mapping/Linux auditd": oapi.Created,
+	}
+	if got := actions(res); len(got) != len(want) || got["pattern/AUDITD"] != oapi.Created ||
+		got["mapping/Linux auditd"] != oapi.Created {
+		t.Errorf("Install: want %v, got %v", want, got)
+	}
+
+	mappingID := itemID(t, res, oapi.ContentPackItemKindMapping, "Linux auditd")
+	fixtures, err := svcs.mappings.ListFixtures(ctx, orgID, mappingID)
+	if err != nil || len(fixtures) != res.Pack.Mappings[0].Fixtures {
+		t.Errorf("ListFixtures: want %d, got %d (%v)", res.Pack.Mappings[0].Fixtures, len(fixtures), err)
+	}
+	test, err := svcs.mappings.Test(ctx, orgID, mappingID, 1)
+	if err != nil || !test.Passed {
+		t.Errorf("Test: want the pack's fixtures to pass, got %+v (%v)", test, err)
+	}
+
+	again, err := svcs.packs.Install(ctx, orgID, "linux-auditd")
+	if err != nil {
+		t.Fatalf("Install again: %v", err)
+	}
+	if again.PreviousVersion == nil || *again.PreviousVersion != res.Pack.Version {
+		t.Errorf("Install again: want previous version %d, got %v", res.Pack.Version, again.PreviousVersion)
+	}
+	for name, action := range actions(again) {
+		if action != oapi.Unchanged {
+			t.Errorf("Install again: %s: want unchanged, got %s", name, action)
+		}
+	}
+
+	packs, err := svcs.packs.List(ctx, orgID)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	for _, p := range packs {
+		installed := p.InstalledVersion != nil
+		if installed != (p.Id == "linux-auditd") {
+			t.Errorf("List: %s: installed version %v", p.Id, p.InstalledVersion)
+		}
+	}
+}
+
+func TestInstall_KeepsChangedAndSkipsDeletedItems(t *testing.T) {
+	svcs, orgID := newSeededServices(t)
+	ctx := context.Background()
+
+	res, err := svcs.packs.Install(ctx, orgID, "linux-authlog")
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	mappingID := itemID(t, res, oapi.ContentPackItemKindMapping, "sshd and auth.log")
+	if _, err := svcs.mappings.CreateVersion(ctx, orgID, mappingID, oapi.MappingDefinition{
+		"defaults": map[string]any{"class_uid": 0, "activity_id": 0, "severity_id": 1, "time": 0},
+	}); err != nil {
+		t.Fatalf("CreateVersion: %v", err)
+	}
+	patternID := itemID(t, res, oapi.ContentPackItemKindPattern, "AUTHLOG")
+	if err := svcs.patterns.Delete(ctx, orgID, patternID); err != nil {
+		t.Fatalf("Delete pattern: %v", err)
+	}
+
+	again, err := svcs.packs.Install(ctx, orgID, "linux-authlog")
+	if err != nil {
+		t.Fatalf("Install again: %v", err)
+	}
+	got := actions(again)
+	if got["pattern/AUTHLOG"] != oapi.Skipped || got["mapping/sshd and auth.log"] != oapi.Kept {
+		t.Errorf("Install again: want pattern skipped and mapping kept, got %v", got)
+	}
+	m, err := svcs.mappings.Get(ctx, orgID, mappingID)
+	if err != nil || m.LatestVersion != 2 {
+		t.Errorf("Get: want the org's version 2 kept, got %d (%v)", m.LatestVersion, err)
+	}
+	if _, err := svcs.patterns.Get(ctx, orgID, patternID); !errors.Is(err, pattern.ErrNotFound) {
+		t.Errorf("Get pattern: want it to stay deleted, got %v", err)
+	}
+}
+
+func TestInstall_RestoresPackFixtures(t *testing.T) {
+	svcs, orgID := newSeededServices(t)
+	ctx := context.Background()
+
+	res, err := svcs.packs.Install(ctx, orgID, "osquery")
+	if err != nil {
+		t.Fatalf("Install: %v", err)
+	}
+	mappingID := itemID(t, res, oapi.ContentPackItemKindMapping, "osquery results")
+	fixtures, err := svcs.mappings.ListFixtures(ctx, orgID, mappingID)
+	if err != nil || len(fixtures) == 0 {
+		t.Fatalf("ListFixtures: %v (%v)", fixtures, err)
+	}
+	if err := svcs.mappings.DeleteFixture(ctx, orgID, mappingID, fixtures[0].Id); err != nil {
+		t.Fatalf("DeleteFixture: %v", err)
+	}
+	own, err := svcs.mappings.CreateFixture(ctx, orgID, mappingID, oapi.CreateMappingFixtureRequest{
+		Name:     "our own",
+		Parser:   strPtr("json"),
+		Input:    map[string]any{"name": "pack_x", "columns": map[string]any{}},
+		Expected: map[string]any{"class_uid": 5001},
+	})
+	if err != nil {
+		t.Fatalf("CreateFixture: %v", err)
+	}
+
+	again, err := svcs.packs.Install(ctx, orgID, "osquery")
+	if err != nil {
+		t.Fatalf("Install again: %v", err)
+	}
+	if got := actions(again)["mapping/osquery results"]; got != oapi.Updated {
+		t.Errorf("Install again: want mapping updated, got %s", got)
+	}
+	after, err := svcs.mappings.ListFixtures(ctx, orgID, mappingID)
+	if err != nil || len(after) != len(fixtures)+1 {
+		t.Fatalf("ListFixtures: want %d, got %d (%v)", len(fixtures)+1, len(after), err)
+	}
+	kept := false
+	for _, f := range after {
+		kept = kept || f.Id == own.Id
+	}
+	if !kept {
+		t.Error("Install again: the org's own fixture was not kept")
+	}
+}
+
+func TestInstall_ConflictsWithExistingName(t *testing.T) {
+	svcs, orgID := newSeededServices(t)
+	ctx := context.Background()
+
+	if _, err := svcs.mappings.Create(ctx, orgID, oapi.CreateMappingRequest{
+		Name:       "Zeek dns",
+		Definition: oapi.MappingDefinition{"defaults": map[string]any{"class_uid": 0, "activity_id": 0}},
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	if _, err := svcs.packs.Install(ctx, orgID, "zeek"); !errors.Is(err, contentpack.ErrConflict) {
+		t.Fatalf("Install: want ErrConflict, got %v", err)
+	}
+	p, err := svcs.packs.Get(ctx, orgID, "zeek")
+	if err != nil || p.InstalledVersion != nil {
+		t.Errorf("Get: want the pack not installed, got %v (%v)", p.InstalledVersion, err)
+	}
+	mappings, err := svcs.mappings.List(ctx, orgID)
+	if err != nil || len(mappings) != 1 {
+		t.Errorf("List: want only the org's mapping, got %d (%v)", len(mappings), err)
+	}
+}
+
+func TestInstall_UnknownPack(t *testing.T) {
+	svcs, orgID := newSeededServices(t)
+
+	if _, err := svcs.packs.Install(context.Background(), orgID, "nope"); !errors.Is(err, contentpack.ErrNotFound) {
+		t.Fatalf("Install: want ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,111 @@
+package entity
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// csvColumns are the columns a CSV import reads, by their header.
+var csvColumns = []string{"type", "value", "criticality", "owner", "business_unit", "tags", "aliases"}
+
+// row is an entity read from a CSV import, or why it could not be, with the
+// line it is on.
+type row struct {
+	ref   string
+	draft Draft
+	err   error
+}
+
+// parseCSV reads a CSV file whose header row names its columns: type and
+// value, and optionally criticality, owner, business_unit, and tags and
+// aliases separated by semicolons. Other columns are ignored. The drafts
+// read are not yet normalized. Returns an error wrapping ErrInvalid when the
+// content cannot be read at all.
+func parseCSV(content string) ([]row, error) {
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%w: empty CSV file", ErrInvalid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: reading CSV header: %w", ErrInvalid, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, c := range csvColumns {
+			if name == c {
+				if _, dup := cols[name]; dup {
+					return nil, fmt.Errorf("%w: CSV column %s given twice", ErrInvalid, name)
+				}
+				cols[name] = i
+			}
+		}
+	}
+	for _, c := range []string{"type", "value"} {
+		if _, ok := cols[c]; !ok {
+			return nil, fmt.Errorf("%w: CSV header has no %s column", ErrInvalid, c)
+		}
+	}
+
+	var rows []row
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading CSV: %w", ErrInvalid, err)
+		}
+		line, _ := r.FieldPos(0)
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		d, err := csvDraft(field)
+		rows = append(rows, row{ref: "line " + strconv.Itoa(line), draft: d, err: err})
+	}
+}
+
+// csvDraft reads an entity from the fields of a CSV record. Empty fields are
+// left unset.
+func csvDraft(field func(name string) string) (Draft, error) {
+	d := Draft{
+		Type:  oapi.EntityType(strings.ToLower(field("type"))),
+		Value: field("value"),
+	}
+	if d.Value == "" {
+		return d, fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+	if s := field("criticality"); s != "" {
+		c := oapi.EntityCriticality(strings.ToLower(s))
+		if !validCriticality(c) {
+			return d, fmt.Errorf("%w: unknown criticality %q", ErrInvalid, s)
+		}
+		d.Criticality = &c
+	}
+	if s := field("owner"); s != "" {
+		d.Owner = &s
+	}
+	if s := field("business_unit"); s != "" {
+		d.BusinessUnit = &s
+	}
+	if s := field("tags"); s != "" {
+		d.Tags = strings.Split(s, ";")
+	}
+	if s := field("aliases"); s != "" {
+		d.Aliases = strings.Split(s, ";")
+	}
+	return d, nil
+}
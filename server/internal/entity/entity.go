@@ -0,0 +1,270 @@
+package entity
+
+import (
+	"fmt"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/ocsf"
+)
+
+// Limits of an entity's identifiers.
+const (
+	// maxValueLen caps the length of a value or alias.
+	maxValueLen = 1024
+	// maxAliases caps the aliases of an entity.
+	maxAliases = 50
+	// maxObservedAliases caps the aliases observation adds to an entity,
+	// leaving room for those given by hand.
+	maxObservedAliases = 20
+)
+
+// validType reports whether t is a known entity type.
+func validType(t oapi.EntityType) bool {
+	switch t {
+	case oapi.EntityTypeHost, oapi.EntityTypeIp, oapi.EntityTypeUser, oapi.EntityTypeProcess:
+		return true
+	}
+	return false
+}
+
+// Normalize returns value in the form entities of type t are stored in:
+// addresses in canonical form, and everything else trimmed and in lower case.
+// Returns an error wrapping ErrInvalid when value is not of type t.
+func Normalize(t oapi.EntityType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+	if utf8.RuneCountInString(value) > maxValueLen {
+		return "", fmt.Errorf("%w: value longer than %d characters", ErrInvalid, maxValueLen)
+	}
+	switch t {
+	case oapi.EntityTypeIp:
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q is not an IP address", ErrInvalid, value)
+		}
+		return addr.Unmap().WithZone("").String(), nil
+	case oapi.EntityTypeHost:
+		value = strings.TrimSuffix(strings.ToLower(value), ".")
+		if value == "" || strings.IndexFunc(value, unicode.IsSpace) >= 0 {
+			return "", fmt.Errorf("%w: %q is not a hostname", ErrInvalid, value)
+		}
+		return value, nil
+	case oapi.EntityTypeUser, oapi.EntityTypeProcess:
+		return strings.ToLower(value), nil
+	}
+	return "", fmt.Errorf("%w: unknown entity type %q", ErrInvalid, t)
+}
+
+// normalizeAlias returns an alias in the form it is stored and matched in:
+// an address in canonical form, anything else trimmed and in lower case.
+func normalizeAlias(alias string) string {
+	alias = strings.TrimSpace(alias)
+	if addr, err := netip.ParseAddr(alias); err == nil {
+		return addr.Unmap().WithZone("").String()
+	}
+	return strings.ToLower(alias)
+}
+
+// cleanAliases normalizes aliases and drops empty and repeated ones, and any
+// equal to the entity's own value, keeping their order.
+func cleanAliases(value string, aliases []string) []string {
+	out := make([]string, 0, len(aliases))
+	for _, a := range aliases {
+		if a = normalizeAlias(a); a != "" && a != value && !slices.Contains(out, a) {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// cleanTags trims tags and drops empty and repeated ones, keeping their order.
+func cleanTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// ── Extraction ───────────────────────────────────────────────────────────────
+
+// Observation is an entity an event mentions.
+type Observation struct {
+	Type  oapi.EntityType
+	Value string
+	// Aliases are the other identifiers the event gives the entity, such as
+	// a host's address or a user's email address.
+	Aliases []string
+	// Path is where in the event the entity is first mentioned, such as
+	// "src_endpoint" or "actor.user".
+	Path string
+}
+
+// key identifies an entity within an org.
+type key struct {
+	typ   oapi.EntityType
+	value string
+}
+
+// Extract returns the entities ev mentions, each once: the hosts and
+// addresses of its devices and endpoints, its users and its processes.
+func Extract(ev ocsf.Event) []Observation {
+	var out []Observation
+	index := make(map[key]int)
+	add := func(path string, t oapi.EntityType, value string, aliases ...string) {
+		v, err := Normalize(t, value)
+		if err != nil {
+			return
+		}
+		k := key{t, v}
+		i, ok := index[k]
+		if !ok {
+			i = len(out)
+			index[k] = i
+			out = append(out, Observation{Type: t, Value: v, Path: path})
+		}
+		o := &out[i]
+		o.Aliases = cleanAliases(v, append(o.Aliases, aliases...))
+		if len(o.Aliases) > maxObservedAliases {
+			o.Aliases = o.Aliases[:maxObservedAliases]
+		}
+	}
+	walk(reflect.ValueOf(ev), "", func(path string, v any) {
+		switch o := v.(type) {
+		case *ocsf.Device:
+			addHost(add, path, o.Hostname, o.Name, o.IP, o.UID, o.MAC)
+		case *ocsf.Endpoint:
+			addHost(add, path, o.Hostname, o.Name, o.IP, o.UID, o.MAC)
+		case *ocsf.User:
+			addUser(add, path, o)
+		case *ocsf.Process:
+			addProcess(add, path, o)
+		}
+	})
+	return out
+}
+
+// adder records an entity mentioned at path.
+type adder func(path string, t oapi.EntityType, value string, aliases ...string)
+
+// addHost records the host a device or endpoint names, known by its hostname or
+// else its name, and its address. A hostname that is an address names no
+// host.
+func addHost(add adder, path, hostname, name, ip, uid, mac string) {
+	if hostname == "" {
+		hostname = name
+	}
+	if _, err := netip.ParseAddr(strings.TrimSpace(hostname)); err == nil {
+		if ip == "" {
+			ip = hostname
+		}
+		hostname = ""
+	}
+	if h, err := Normalize(oapi.EntityTypeHost, hostname); err == nil {
+		hostname = h
+		add(path, oapi.EntityTypeHost, hostname, name, ip, uid, mac)
+	} else {
+		hostname = ""
+	}
+	if ip != "" {
+		add(path, oapi.EntityTypeIp, ip, hostname)
+	}
+}
+
+// addUser records a user, known by their name or else their email address or
+// ID. A name qualified by a domain is an alias.
+func addUser(add adder, path string, u *ocsf.User) {
+	var aliases []string
+	name := u.Name
+	if name != "" && u.Domain != "" && !strings.ContainsAny(name, `\@`) {
+		aliases = append(aliases, u.Domain+`\`+name)
+	}
+	switch {
+	case name != "":
+	case u.EmailAddr != "":
+		name = u.EmailAddr
+	default:
+		name = u.UID
+	}
+	add(path, oapi.EntityTypeUser, name, append(aliases, u.EmailAddr, u.UID)...)
+}
+
+// addProcess records a process, known by the path of its executable or else its
+// name.
+func addProcess(add adder, path string, p *ocsf.Process) {
+	name := p.Name
+	if p.File != nil && p.File.Path != "" {
+		add(path, oapi.EntityTypeProcess, p.File.Path, name)
+		return
+	}
+	add(path, oapi.EntityTypeProcess, name)
+}
+
+// walk calls fn with a pointer to every device, endpoint, user and process
+// in v, and its path in the event, parents before what they hold. Unmapped
+// attributes are not looked in.
+func walk(v reflect.Value, path string, fn func(path string, v any)) {
+	switch v.Kind() {
+	case reflect.Interface:
+		if !v.IsNil() {
+			walk(v.Elem(), path, fn)
+		}
+	case reflect.Pointer:
+		if v.IsNil() {
+			return
+		}
+		switch p := v.Interface().(type) {
+		case *ocsf.Device, *ocsf.Endpoint, *ocsf.User, *ocsf.Process:
+			fn(path, p)
+		}
+		walk(v.Elem(), path, fn)
+	case reflect.Slice:
+		for i := range v.Len() {
+			walk(v.Index(i).Addr(), path, fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			switch f.Type.Kind() {
+			case reflect.Pointer, reflect.Slice, reflect.Struct:
+			default:
+				continue
+			}
+			child := path
+			if !f.Anonymous {
+				child = join(path, jsonName(f))
+			}
+			fv := v.Field(i)
+			if f.Type.Kind() == reflect.Struct && fv.CanAddr() {
+				fv = fv.Addr()
+			}
+			walk(fv, child, fn)
+		}
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,146 @@
+package entity_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/entity"
+	"github.com/luketeo/horizon/internal/ocsf"
+)
+
+func TestNormalize(t *testing.T) {
+	cases := []struct {
+		typ   oapi.EntityType
+		value string
+		want  string
+	}{
+		{oapi.EntityTypeHost, " WS-01.Corp.Example. ", "ws-01.corp.example"},
+		{oapi.EntityTypeIp, "::ffff:203.0.113.7", "203.0.113.7"},
+		{oapi.EntityTypeIp, "2001:DB8::1%eth0", "2001:db8::1"},
+		{oapi.EntityTypeUser, "CORP\\Alice", "corp\\alice"},
+		{oapi.EntityTypeProcess, `C:\Windows\System32\CMD.EXE`, `c:\windows\system32\cmd.exe`},
+	}
+	for _, c := range cases {
+		got, err := entity.Normalize(c.typ, c.value)
+		if err != nil || got != c.want {
+			t.Errorf("Normalize(%s, %q): got %q, %v; want %q", c.typ, c.value, got, err, c.want)
+		}
+	}
+}
+
+func TestNormalize_RejectsValuesNotOfType(t *testing.T) {
+	cases := []struct {
+		typ   oapi.EntityType
+		value string
+	}{
+		{oapi.EntityTypeHost, " "},
+		{oapi.EntityTypeHost, "two words"},
+		{oapi.EntityTypeIp, "ws-01"},
+		{"service", "nginx"},
+	}
+	for _, c := range cases {
+		if _, err := entity.Normalize(c.typ, c.value); !errors.Is(err, entity.ErrInvalid) {
+			t.Errorf("Normalize(%s, %q): got %v, want ErrInvalid", c.typ, c.value, err)
+		}
+	}
+}
+
+// observations lists what Extract returns, as "type:value@path[aliases]".
+func observations(ev ocsf.Event) []string {
+	var out []string
+	for _, o := range entity.Extract(ev) {
+		out = append(out, fmt.Sprintf("%s:%s@%s%v", o.Type, o.Value, o.Path, o.Aliases))
+	}
+	return out
+}
+
+func TestExtract_HostsUsersAndProcesses(t *testing.T) {
+	ev := &ocsf.ProcessActivity{
+		BaseEvent: ocsf.BaseEvent{Device: &ocsf.Device{Hostname: "WS-01.corp.example.", IP: "10.0.0.5"}},
+		Actor: &ocsf.Actor{
+			User:    &ocsf.User{Name: "Alice", Domain: "CORP", EmailAddr: "Alice@Corp.Example"},
+			Process: &ocsf.Process{Name: "explorer.exe", File: &ocsf.File{Path: `C:\Windows\explorer.exe`}},
+		},
+		Process: &ocsf.Process{
+			Name: "cmd.exe",
+			File: &ocsf.File{Path: `C:\Windows\System32\cmd.exe`},
+			User: &ocsf.User{Name: "alice", UID: "S-1-5-21-1004"},
+		},
+	}
+
+	want := []string{
+		"host:ws-01.corp.example@device[10.0.0.5]",
+		"ip:10.0.0.5@device[ws-01.corp.example]",
+		`user:alice@actor.user[corp\alice alice@corp.example s-1-5-21-1004]`,
+		`process:c:\windows\explorer.exe@actor.process[explorer.exe]`,
+		`process:c:\windows\system32\cmd.exe@process[cmd.exe]`,
+	}
+	if got := observations(ev); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestExtract_AddressesAsHostnames(t *testing.T) {
+	ev := &ocsf.NetworkActivity{
+		Network: ocsf.Network{
+			SrcEndpoint: &ocsf.Endpoint{Hostname: "203.0.113.7"},
+			DstEndpoint: &ocsf.Endpoint{Name: "db-01", IP: "::ffff:10.0.0.9"},
+		},
+	}
+
+	want := []string{
+		"ip:203.0.113.7@src_endpoint[]",
+		"host:db-01@dst_endpoint[10.0.0.9]",
+		"ip:10.0.0.9@dst_endpoint[db-01]",
+	}
+	if got := observations(ev); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSetEnrich_AddsContextOfMentionedEntities(t *testing.T) {
+	critical, owner, unit := oapi.Critical, "alice", "Payments"
+	db := oapi.Entity{
+		Id:           uuid.New(),
+		Type:         oapi.EntityTypeHost,
+		Value:        "db-01.corp.example",
+		Aliases:      []string{"10.0.0.9"},
+		Criticality:  &critical,
+		Owner:        &owner,
+		BusinessUnit: &unit,
+		Tags:         []string{"pci"},
+	}
+	svc := oapi.Entity{Id: uuid.New(), Type: oapi.EntityTypeUser, Value: "svc-backup", Tags: []string{"service"}}
+	set := entity.NewSet([]oapi.Entity{db, svc})
+	if set.Len() != 2 {
+		t.Fatalf("Len: got %d, want 2", set.Len())
+	}
+	ev := &ocsf.Authentication{
+		BaseEvent:   ocsf.BaseEvent{Device: &ocsf.Device{Hostname: "DB-01.corp.example"}},
+		User:        &ocsf.User{Name: "svc-backup"},
+		DstEndpoint: &ocsf.Endpoint{IP: "10.0.0.9"},
+	}
+
+	set.Enrich(ev, entity.Extract(ev))
+
+	if len(ev.Enrichments) != 2 {
+		t.Fatalf("enrichments: got %+v", ev.Enrichments)
+	}
+	e := ev.Enrichments[0]
+	if e.Name != "device" || e.Value != "db-01.corp.example" || e.Type != entity.EnrichmentType ||
+		e.Provider != entity.Provider {
+		t.Errorf("enrichment: got %+v", e)
+	}
+	if e.Data["entity_id"] != db.Id.String() || e.Data["criticality"] != "critical" || e.Data["owner"] != "alice" ||
+		e.Data["business_unit"] != "Payments" || !slices.Equal(e.Data["tags"].([]string), []string{"pci"}) {
+		t.Errorf("enrichment data: got %+v", e.Data)
+	}
+	if e := ev.Enrichments[1]; e.Data["entity_id"] != svc.Id.String() || e.Data["criticality"] != nil {
+		t.Errorf("user enrichment: got %+v", e)
+	}
+}
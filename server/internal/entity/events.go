@@ -0,0 +1,54 @@
+package entity
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per entity. An import
+// is one event, whose aggregate is the org. Entities added or seen by
+// observation raise none.
+const (
+	aggregateType = "entity"
+
+	EventCreated  = "entity.created"
+	EventUpdated  = "entity.updated"
+	EventDeleted  = "entity.deleted"
+	EventImported = "entity.imported"
+)
+
+// DeletedPayload is the payload of EventDeleted.
+type DeletedPayload struct {
+	ID    uuid.UUID       `json:"id"`
+	OrgID uuid.UUID       `json:"org_id"`
+	Type  oapi.EntityType `json:"type"`
+	Value string          `json:"value"`
+}
+
+// ImportedPayload is the payload of EventImported.
+type ImportedPayload struct {
+	OrgID   uuid.UUID `json:"org_id"`
+	Created int       `json:"created"`
+	Updated int       `json:"updated"`
+}
+
+// emit appends an event about aggregateID to the outbox, in the transaction
+// on ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, aggregateID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
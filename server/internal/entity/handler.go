@@ -0,0 +1,323 @@
+package entity
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// Page sizes of ListEntities.
+const (
+	defaultListLimit = 100
+	maxListLimit     = 500
+)
+
+// Handler serves the /organizations/{orgId}/entities/* endpoints. Members
+// may read entities and their timelines; admins manage and import them.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound() oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", "Entity not found"),
+	)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func conflict(detail string) oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", detail),
+	)
+}
+
+func (h *Handler) ListEntities(
+	ctx context.Context,
+	request oapi.ListEntitiesRequestObject,
+) (oapi.ListEntitiesResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListEntities403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	params := request.Params
+	f := Filter{
+		Type:        params.Type,
+		Criticality: params.Criticality,
+		After:       params.After,
+		Limit:       defaultListLimit,
+	}
+	if params.Q != nil && *params.Q != "" {
+		f.Query = params.Q
+	}
+	if params.Limit != nil {
+		f.Limit = min(max(*params.Limit, 1), maxListLimit)
+	}
+	entities, err := h.svc.List(ctx, request.OrgId, f)
+	if err != nil {
+		return nil, err
+	}
+	return oapi.ListEntities200JSONResponse(entities), nil
+}
+
+func (h *Handler) CreateEntity(
+	ctx context.Context,
+	request oapi.CreateEntityRequestObject,
+) (oapi.CreateEntityResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateEntity403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateEntity403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	e, err := h.svc.Create(ctx, request.OrgId, *request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalid):
+			return oapi.CreateEntity400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.CreateEntity409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("The organisation already has this entity"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateEntity201JSONResponse(e), nil
+}
+
+func (h *Handler) ImportEntities(
+	ctx context.Context,
+	request oapi.ImportEntitiesRequestObject,
+) (oapi.ImportEntitiesResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ImportEntities403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.ImportEntities403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	res, err := h.svc.Import(ctx, request.OrgId, *request.Body)
+	if err != nil {
+		if errors.Is(err, ErrInvalid) {
+			return oapi.ImportEntities400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ImportEntities200JSONResponse(res), nil
+}
+
+func (h *Handler) GetEntity(
+	ctx context.Context,
+	request oapi.GetEntityRequestObject,
+) (oapi.GetEntityResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetEntity403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	e, err := h.svc.Get(ctx, request.OrgId, request.EntityId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetEntity404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(e.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetEntity304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetEntity200JSONResponse{
+		Body:    e,
+		Headers: oapi.GetEntity200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
+func (h *Handler) UpdateEntity(
+	ctx context.Context,
+	request oapi.UpdateEntityRequestObject,
+) (oapi.UpdateEntityResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateEntity403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateEntity403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	if body.Criticality != nil && body.ClearCriticality != nil && *body.ClearCriticality {
+		return oapi.UpdateEntity400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("criticality and clear_criticality are exclusive"),
+		}, nil
+	}
+
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return entityPreconditionFailed(), nil
+	}
+
+	e, err := h.svc.Update(ctx, request.OrgId, request.EntityId, body, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.UpdateEntity404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.UpdateEntity400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return entityPreconditionFailed(), nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateEntity200JSONResponse{
+		Body:    e,
+		Headers: oapi.UpdateEntity200ResponseHeaders{ETag: httpx.ETag(e.Version)},
+	}, nil
+}
+
+func entityPreconditionFailed() oapi.UpdateEntity412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateEntity412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Entity was modified since it was last read"),
+		),
+	}
+}
+
+func (h *Handler) DeleteEntity(
+	ctx context.Context,
+	request oapi.DeleteEntityRequestObject,
+) (oapi.DeleteEntityResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteEntity403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteEntity403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Delete(ctx, request.OrgId, request.EntityId); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.DeleteEntity404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteEntity204Response{}, nil
+}
+
+func (h *Handler) GetEntityTimeline(
+	ctx context.Context,
+	request oapi.GetEntityTimelineRequestObject,
+) (oapi.GetEntityTimelineResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetEntityTimeline403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	params := request.Params
+	buckets, err := h.svc.Timeline(ctx, request.OrgId, request.EntityId, params.From, params.To)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.GetEntityTimeline404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.GetEntityTimeline400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.GetEntityTimeline200JSONResponse(buckets), nil
+}
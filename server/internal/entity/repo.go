@@ -0,0 +1,577 @@
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns entities, entity_sightings and entity_revisions SQL and row→DTO
+// mapping. The tables are protected by RLS, so callers run statements inside
+// tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.Entities) oapi.Entity {
+	aliases := []string(m.Aliases)
+	if aliases == nil {
+		aliases = []string{}
+	}
+	tags := []string(m.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	var criticality *oapi.EntityCriticality
+	if m.Criticality != nil {
+		c := oapi.EntityCriticality(*m.Criticality)
+		criticality = &c
+	}
+	return oapi.Entity{
+		Id:           m.ID,
+		OrgId:        m.OrgID,
+		Type:         oapi.EntityType(m.Type),
+		Value:        m.Value,
+		Aliases:      aliases,
+		Criticality:  criticality,
+		Owner:        m.Owner,
+		BusinessUnit: m.BusinessUnit,
+		Tags:         tags,
+		FirstSeenAt:  m.FirstSeenAt,
+		LastSeenAt:   m.LastSeenAt,
+		Version:      m.Version,
+		CreatedAt:    m.CreatedAt,
+		UpdatedAt:    m.UpdatedAt,
+	}
+}
+
+func toOapiList(rows []model.Entities) []oapi.Entity {
+	entities := make([]oapi.Entity, 0, len(rows))
+	for _, row := range rows {
+		entities = append(entities, toOapi(row))
+	}
+	return entities
+}
+
+// valueTaken reports whether err is a unique violation on (org_id, type,
+// value).
+func valueTaken(err error) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// versionIn restricts an update to the listed versions; nil means any.
+func versionIn(col postgres.ColumnInteger, versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return col.IN(exprs...)
+}
+
+// criticalityOf returns a criticality as stored.
+func criticalityOf(c *oapi.EntityCriticality) *string {
+	if c == nil {
+		return nil
+	}
+	s := string(*c)
+	return &s
+}
+
+// Insert stores a new entity from a complete draft. Returns ErrConflict when
+// the org already has an entity of that type and value.
+func (r *Repo) Insert(ctx context.Context, orgID uuid.UUID, d Draft) (oapi.Entity, error) {
+	t := table.Entities
+	stmt := t.
+		INSERT(t.OrgID, t.Type, t.Value, t.Aliases, t.Criticality, t.Owner, t.BusinessUnit, t.Tags).
+		VALUES(
+			orgID, string(d.Type), d.Value, pq.StringArray(d.Aliases), criticalityOf(d.Criticality),
+			d.Owner, d.BusinessUnit, pq.StringArray(d.Tags),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Entities
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if valueTaken(err) {
+			return oapi.Entity{}, ErrConflict
+		}
+		return oapi.Entity{}, fmt.Errorf("inserting entity: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Filter narrows a listing of entities.
+type Filter struct {
+	// Type and Criticality, when set, keep entities of that type and
+	// criticality.
+	Type        *oapi.EntityType
+	Criticality *oapi.EntityCriticality
+	// Query, when set, keeps entities whose value contains it, ignoring
+	// case, or with it as an alias.
+	Query *string
+	// After continues a listing after the entity with that ID.
+	After *uuid.UUID
+	Limit int
+}
+
+// List returns up to f.Limit of the org's entities passing f, in ID order.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID, f Filter) ([]oapi.Entity, error) {
+	t := table.Entities
+	cond := t.OrgID.EQ(postgres.UUID(orgID))
+	if f.Type != nil {
+		cond = cond.AND(t.Type.EQ(postgres.String(string(*f.Type))))
+	}
+	if f.Criticality != nil {
+		cond = cond.AND(t.Criticality.EQ(postgres.String(string(*f.Criticality))))
+	}
+	if f.Query != nil {
+		cond = cond.AND(
+			t.Value.LIKE(postgres.String("%" + escapeLike(normalizeAlias(*f.Query)) + "%")).
+				OR(t.Aliases.CONTAINS(postgres.StringArray(normalizeAlias(*f.Query)))),
+		)
+	}
+	if f.After != nil {
+		cond = cond.AND(t.ID.GT(postgres.UUID(*f.After)))
+	}
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(cond).
+		ORDER_BY(t.ID.ASC()).
+		LIMIT(int64(f.Limit))
+
+	var rows []model.Entities
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing entities: %w", err)
+	}
+	return toOapiList(rows), nil
+}
+
+// escapeLike escapes the wildcards of a LIKE pattern in s.
+func escapeLike(s string) string {
+	var b []byte
+	for i := range len(s) {
+		if c := s[i]; c == '%' || c == '_' || c == '\\' {
+			b = append(b, '\\')
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// ListWithContext returns every entity of the org with a criticality, owner,
+// business unit or tag.
+func (r *Repo) ListWithContext(ctx context.Context, orgID uuid.UUID) ([]oapi.Entity, error) {
+	t := table.Entities
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.OrgID.EQ(postgres.UUID(orgID)).
+				AND(
+					t.Criticality.IS_NOT_NULL().
+						OR(t.Owner.IS_NOT_NULL()).
+						OR(t.BusinessUnit.IS_NOT_NULL()).
+						OR(postgres.RawBool("cardinality(entities.tags) > 0")),
+				),
+		)
+
+	var rows []model.Entities
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing entities with context: %w", err)
+	}
+	return toOapiList(rows), nil
+}
+
+// Get returns a single entity of the org. Returns ErrNotFound when it does
+// not exist in that org.
+func (r *Repo) Get(ctx context.Context, orgID, entityID uuid.UUID) (oapi.Entity, error) {
+	t := table.Entities
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(entityID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.Entities
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.Entity{}, ErrNotFound
+		}
+		return oapi.Entity{}, fmt.Errorf("getting entity: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Update patches the fields set in req, whose aliases and tags must be
+// cleaned, and bumps the version. An empty owner or business unit clears it,
+// as does ClearCriticality the criticality. When ifMatch is non-nil the
+// update only applies if the current version is listed, otherwise
+// ErrPreconditionFailed is returned.
+func (r *Repo) Update(
+	ctx context.Context,
+	orgID, entityID uuid.UUID,
+	req oapi.UpdateEntityRequest,
+	ifMatch []int64,
+) (oapi.Entity, error) {
+	t := table.Entities
+	aliases := postgres.Expression(t.Aliases)
+	if req.Aliases != nil {
+		aliases = textArray(*req.Aliases)
+	}
+	tags := postgres.Expression(t.Tags)
+	if req.Tags != nil {
+		tags = textArray(*req.Tags)
+	}
+	criticality := postgres.StringExpression(t.Criticality)
+	switch {
+	case req.ClearCriticality != nil && *req.ClearCriticality:
+		criticality = postgres.StringExp(postgres.NULL)
+	case req.Criticality != nil:
+		criticality = postgres.String(string(*req.Criticality))
+	}
+	owner := optionalString(t.Owner, req.Owner)
+	businessUnit := optionalString(t.BusinessUnit, req.BusinessUnit)
+
+	stmt := t.
+		UPDATE(t.Aliases, t.Criticality, t.Owner, t.BusinessUnit, t.Tags, t.Version, t.UpdatedAt).
+		SET(aliases, criticality, owner, businessUnit, tags, t.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(entityID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))).
+				AND(versionIn(t.Version, ifMatch)),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Entities
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	switch {
+	case err == nil:
+		return toOapi(row), nil
+	case !errors.Is(err, qrm.ErrNoRows):
+		return oapi.Entity{}, fmt.Errorf("updating entity: %w", err)
+	}
+	if _, err := r.Get(ctx, orgID, entityID); err != nil {
+		return oapi.Entity{}, err
+	}
+	return oapi.Entity{}, ErrPreconditionFailed
+}
+
+// textArray is a text[] of values, which a bare empty array literal is not.
+func textArray(values []string) postgres.Expression {
+	if len(values) == 0 {
+		return postgres.Raw("'{}'::text[]")
+	}
+	return postgres.StringArray(values...)
+}
+
+// optionalString sets col to s: unchanged when nil, NULL when empty.
+func optionalString(col postgres.ColumnString, s *string) postgres.StringExpression {
+	switch {
+	case s == nil:
+		return col
+	case *s == "":
+		return postgres.StringExp(postgres.NULL)
+	default:
+		return postgres.String(*s)
+	}
+}
+
+// Delete removes an entity and its sightings. Returns ErrNotFound when no
+// row matched.
+func (r *Repo) Delete(ctx context.Context, orgID, entityID uuid.UUID) error {
+	t := table.Entities
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(entityID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting entity: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ── Imports ──────────────────────────────────────────────────────────────────
+
+// upsertBatch bounds the rows of one upsert, keeping it under the protocol's
+// limit on bind parameters.
+const upsertBatch = 500
+
+// Existing returns which of the drafts the org already has an entity of.
+func (r *Repo) Existing(ctx context.Context, orgID uuid.UUID, drafts []Draft) (map[key]bool, error) {
+	t := table.Entities
+	existing := make(map[key]bool)
+	for batch := range slices.Chunk(drafts, upsertBatch) {
+		values := make([]postgres.Expression, 0, len(batch))
+		for _, d := range batch {
+			values = append(values, postgres.String(d.Value))
+		}
+		stmt := postgres.
+			SELECT(t.Type, t.Value).
+			FROM(t).
+			WHERE(t.OrgID.EQ(postgres.UUID(orgID)).AND(t.Value.IN(values...)))
+
+		var rows []model.Entities
+		if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+			return nil, fmt.Errorf("finding existing entities: %w", err)
+		}
+		for _, row := range rows {
+			existing[key{oapi.EntityType(row.Type), row.Value}] = true
+		}
+	}
+	return existing, nil
+}
+
+// Upsert stores complete drafts, none of the same type and value. The
+// entities of the org they match are given what the drafts set, keeping
+// what they leave empty, and their versions are bumped.
+func (r *Repo) Upsert(ctx context.Context, orgID uuid.UUID, drafts []Draft) error {
+	t := table.Entities
+	for batch := range slices.Chunk(drafts, upsertBatch) {
+		stmt := t.INSERT(t.OrgID, t.Type, t.Value, t.Aliases, t.Criticality, t.Owner, t.BusinessUnit, t.Tags)
+		for _, d := range batch {
+			stmt = stmt.VALUES(
+				orgID, string(d.Type), d.Value, pq.StringArray(d.Aliases), criticalityOf(d.Criticality),
+				d.Owner, d.BusinessUnit, pq.StringArray(d.Tags),
+			)
+		}
+		stmt = stmt.
+			ON_CONFLICT(t.OrgID, t.Type, t.Value).
+			DO_UPDATE(postgres.SET(
+				t.Aliases.SET(postgres.ArrayExp[postgres.StringExpression](postgres.Raw(
+					"CASE WHEN cardinality(excluded.aliases) = 0 THEN entities.aliases ELSE excluded.aliases END",
+				))),
+				t.Criticality.SET(postgres.StringExp(postgres.COALESCE(t.EXCLUDED.Criticality, t.Criticality))),
+				t.Owner.SET(postgres.StringExp(postgres.COALESCE(t.EXCLUDED.Owner, t.Owner))),
+				t.BusinessUnit.SET(postgres.StringExp(postgres.COALESCE(t.EXCLUDED.BusinessUnit, t.BusinessUnit))),
+				t.Tags.SET(postgres.ArrayExp[postgres.StringExpression](postgres.Raw(
+					"CASE WHEN cardinality(excluded.tags) = 0 THEN entities.tags ELSE excluded.tags END",
+				))),
+				t.Version.SET(t.Version.ADD(postgres.Int(1))),
+				t.UpdatedAt.SET(postgres.NOW()),
+			))
+
+		if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+			return fmt.Errorf("upserting entities: %w", err)
+		}
+	}
+	return nil
+}
+
+// ── Sightings ────────────────────────────────────────────────────────────────
+
+// Sighting is what the events processed since the last write said about an
+// entity.
+type Sighting struct {
+	Type      oapi.EntityType
+	Value     string
+	Aliases   []string
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// Counts are the events that mentioned the entity, by hour and class.
+	Counts []Count
+}
+
+// Count is the number of events of a class in an hour.
+type Count struct {
+	Bucket    time.Time
+	ClassUID  int
+	ClassName string
+	Events    int64
+}
+
+// add records an event at the given time with the aliases it gives the
+// entity, counted in c's hour and class.
+func (s *Sighting) add(at time.Time, aliases []string, c Count) {
+	if at.Before(s.FirstSeen) {
+		s.FirstSeen = at
+	}
+	if at.After(s.LastSeen) {
+		s.LastSeen = at
+	}
+	for _, a := range aliases {
+		if len(s.Aliases) < maxObservedAliases && !slices.Contains(s.Aliases, a) {
+			s.Aliases = append(s.Aliases, a)
+		}
+	}
+	for i := range s.Counts {
+		if s.Counts[i].Bucket.Equal(c.Bucket) && s.Counts[i].ClassUID == c.ClassUID {
+			s.Counts[i].Events++
+			return
+		}
+	}
+	c.Events = 1
+	s.Counts = append(s.Counts, c)
+}
+
+// mergeAliases keeps an entity's aliases and appends the observed ones it
+// lacks, up to maxObservedAliases in all.
+var mergeAliases = postgres.ArrayExp[postgres.StringExpression](postgres.Raw(fmt.Sprintf(
+	"entities.aliases || ARRAY(SELECT a FROM unnest(excluded.aliases) AS a "+
+		"WHERE NOT a = ANY(entities.aliases) LIMIT GREATEST(0, %d - cardinality(entities.aliases)))",
+	maxObservedAliases,
+)))
+
+// Observe records sightings of the org's entities, adding those it does not
+// have yet. An entity's first and last seen times are widened to cover its
+// sighting, and its hourly counts raised; neither bumps its version, which
+// tracks what is set on it by hand or import.
+func (r *Repo) Observe(ctx context.Context, orgID uuid.UUID, sightings []Sighting) error {
+	t := table.Entities
+	s := table.EntitySightings
+	for _, sg := range sightings {
+		upsert := t.
+			INSERT(t.OrgID, t.Type, t.Value, t.Aliases, t.FirstSeenAt, t.LastSeenAt).
+			VALUES(orgID, string(sg.Type), sg.Value, pq.StringArray(sg.Aliases), sg.FirstSeen, sg.LastSeen).
+			ON_CONFLICT(t.OrgID, t.Type, t.Value).
+			DO_UPDATE(postgres.SET(
+				t.Aliases.SET(mergeAliases),
+				t.FirstSeenAt.SET(postgres.TimestampzExp(postgres.LEAST(t.FirstSeenAt, t.EXCLUDED.FirstSeenAt))),
+				t.LastSeenAt.SET(postgres.TimestampzExp(postgres.GREATEST(t.LastSeenAt, t.EXCLUDED.LastSeenAt))),
+			)).
+			RETURNING(t.ID)
+
+		var row model.Entities
+		if err := upsert.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+			return fmt.Errorf("recording entity sighting: %w", err)
+		}
+
+		counts := s.INSERT(s.EntityID, s.OrgID, s.Bucket, s.ClassUID, s.ClassName, s.Events)
+		for _, c := range sg.Counts {
+			counts = counts.VALUES(row.ID, orgID, c.Bucket, int32(c.ClassUID), c.ClassName, c.Events)
+		}
+		counts = counts.
+			ON_CONFLICT(s.EntityID, s.Bucket, s.ClassUID).
+			DO_UPDATE(postgres.SET(
+				s.ClassName.SET(s.EXCLUDED.ClassName),
+				s.Events.SET(s.Events.ADD(s.EXCLUDED.Events)),
+			))
+		if _, err := counts.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+			return fmt.Errorf("recording entity sighting: %w", err)
+		}
+	}
+	return nil
+}
+
+// Timeline returns the entity's hourly counts for hours starting in [from,
+// to), by hour then class.
+func (r *Repo) Timeline(
+	ctx context.Context,
+	orgID, entityID uuid.UUID,
+	from, to time.Time,
+) ([]oapi.EntityTimelineBucket, error) {
+	s := table.EntitySightings
+	stmt := postgres.
+		SELECT(s.AllColumns).
+		FROM(s).
+		WHERE(
+			s.EntityID.EQ(postgres.UUID(entityID)).
+				AND(s.OrgID.EQ(postgres.UUID(orgID))).
+				AND(s.Bucket.GT_EQ(postgres.TimestampzT(from))).
+				AND(s.Bucket.LT(postgres.TimestampzT(to))),
+		).
+		ORDER_BY(s.Bucket.ASC(), s.ClassUID.ASC())
+
+	var rows []model.EntitySightings
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing entity timeline: %w", err)
+	}
+	buckets := make([]oapi.EntityTimelineBucket, 0, len(rows))
+	for _, row := range rows {
+		buckets = append(buckets, oapi.EntityTimelineBucket{
+			Bucket:    row.Bucket,
+			ClassUid:  int(row.ClassUID),
+			ClassName: row.ClassName,
+			Events:    row.Events,
+		})
+	}
+	return buckets, nil
+}
+
+// PruneSightings deletes counts for hours before cutoff. Returns the number
+// of rows deleted.
+func (r *Repo) PruneSightings(ctx context.Context, cutoff time.Time) (int64, error) {
+	s := table.EntitySightings
+	stmt := s.DELETE().WHERE(s.Bucket.LT(postgres.TimestampzT(cutoff)))
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return 0, fmt.Errorf("pruning entity sightings: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PruneSightingsArgs is the background job running PruneSightings.
+type PruneSightingsArgs struct{}
+
+// Kind implements jobs.Args.
+func (PruneSightingsArgs) Kind() string { return "entity.prune_sightings" }
+
+// ── Revisions ────────────────────────────────────────────────────────────────
+
+// Revision returns the org's entity context revision, which changes whenever
+// what is set on its entities by hand or import does; zero when it never
+// has.
+func (r *Repo) Revision(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	t := table.EntityRevisions
+	stmt := postgres.
+		SELECT(t.Revision).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID)))
+
+	var row model.EntityRevisions
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("getting entity revision: %w", err)
+	}
+	return row.Revision, nil
+}
+
+// Bump advances the org's entity context revision, telling trackers to
+// reload.
+func (r *Repo) Bump(ctx context.Context, orgID uuid.UUID) error {
+	t := table.EntityRevisions
+	stmt := t.
+		INSERT(t.OrgID).
+		VALUES(orgID).
+		ON_CONFLICT(t.OrgID).
+		DO_UPDATE(postgres.SET(t.Revision.SET(t.Revision.ADD(postgres.Int(1)))))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("bumping entity revision: %w", err)
+	}
+	return nil
+}
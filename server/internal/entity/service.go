@@ -0,0 +1,392 @@
+// Package entity owns an organisation's inventory of the hosts, IP
+// addresses, users and processes its events mention. Entities are extracted
+// from every normalized event as it is processed, recording when each was
+// first and last seen, the aliases it was seen with and hourly counts of the
+// events that mentioned it, which back its timeline. Their criticality,
+// owner, business unit and tags are set over the API or imported from CSV
+// files, and added to the events that mention them as enrichments.
+package entity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrNotFound is returned when an entity does not exist in the org.
+var ErrNotFound = errors.New("entity not found")
+
+// ErrConflict is returned when the org already has an entity of that type and
+// value.
+var ErrConflict = errors.New("entity already exists")
+
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("entity version mismatch")
+
+// ErrInvalid is returned when an entity's value is not of its type or an
+// attribute is out of range, when an import cannot be read at all, and when
+// a timeline's range is not.
+var ErrInvalid = errors.New("invalid entity")
+
+// Limits of an entity's attributes, an import and a timeline.
+const (
+	// maxNameLen caps the length of an owner or business unit.
+	maxNameLen = 255
+	// maxTags caps the tags of an entity.
+	maxTags = 50
+	// maxTagLen caps the length of a tag.
+	maxTagLen = 100
+	// maxImportEntries caps the rows of one import.
+	maxImportEntries = 50000
+	// maxImportErrors caps the errors an import reports.
+	maxImportErrors = 100
+	// maxTimelineRange caps the range of a timeline.
+	maxTimelineRange = 31 * 24 * time.Hour
+	// defaultTimelineRange is the range of a timeline not given a start.
+	defaultTimelineRange = 7 * 24 * time.Hour
+)
+
+// Service orchestrates entity operations. Each runs in a transaction carrying
+// the caller's tenant scope, joining one already on ctx. Mutations append
+// their domain event to the outbox and advance the org's entity context
+// revision in that same transaction.
+type Service struct {
+	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
+	logger *slog.Logger
+}
+
+// NewService wires a Service with its repo, transaction manager, outbox and
+// logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{repo: repo, tx: txm, events: events, logger: logger}
+}
+
+// Draft is an entity to be stored. Unset attributes are left as the entity
+// has them, and empty aliases and tags too.
+type Draft struct {
+	Type         oapi.EntityType
+	Value        string
+	Aliases      []string
+	Criticality  *oapi.EntityCriticality
+	Owner        *string
+	BusinessUnit *string
+	Tags         []string
+}
+
+// complete normalizes d's value, aliases and tags and checks its attributes.
+// Returns an error wrapping ErrInvalid when d is not a valid entity.
+func complete(d *Draft) error {
+	if !validType(d.Type) {
+		return fmt.Errorf("%w: unknown entity type %q", ErrInvalid, d.Type)
+	}
+	value, err := Normalize(d.Type, d.Value)
+	if err != nil {
+		return err
+	}
+	d.Value = value
+	d.Aliases = cleanAliases(value, d.Aliases)
+	d.Tags = cleanTags(d.Tags)
+	if d.Owner != nil && *d.Owner == "" {
+		d.Owner = nil
+	}
+	if d.BusinessUnit != nil && *d.BusinessUnit == "" {
+		d.BusinessUnit = nil
+	}
+	return checkAttributes(&d.Aliases, d.Criticality, d.Owner, d.BusinessUnit, &d.Tags)
+}
+
+// checkAttributes checks those of an entity's attributes that are set.
+func checkAttributes(
+	aliases *[]string,
+	criticality *oapi.EntityCriticality,
+	owner, businessUnit *string,
+	tags *[]string,
+) error {
+	switch {
+	case aliases != nil && len(*aliases) > maxAliases:
+		return fmt.Errorf("%w: more than %d aliases", ErrInvalid, maxAliases)
+	case criticality != nil && !validCriticality(*criticality):
+		return fmt.Errorf("%w: unknown criticality %q", ErrInvalid, *criticality)
+	case owner != nil && utf8.RuneCountInString(*owner) > maxNameLen:
+		return fmt.Errorf("%w: owner longer than %d characters", ErrInvalid, maxNameLen)
+	case businessUnit != nil && utf8.RuneCountInString(*businessUnit) > maxNameLen:
+		return fmt.Errorf("%w: business unit longer than %d characters", ErrInvalid, maxNameLen)
+	case tags != nil && len(*tags) > maxTags:
+		return fmt.Errorf("%w: more than %d tags", ErrInvalid, maxTags)
+	}
+	if aliases != nil {
+		for _, a := range *aliases {
+			if utf8.RuneCountInString(a) > maxValueLen {
+				return fmt.Errorf("%w: alias longer than %d characters", ErrInvalid, maxValueLen)
+			}
+		}
+	}
+	if tags != nil {
+		for _, tag := range *tags {
+			if utf8.RuneCountInString(tag) > maxTagLen {
+				return fmt.Errorf("%w: tag longer than %d characters", ErrInvalid, maxTagLen)
+			}
+		}
+	}
+	return nil
+}
+
+// validCriticality reports whether c is a known criticality.
+func validCriticality(c oapi.EntityCriticality) bool {
+	switch c {
+	case oapi.Low, oapi.Medium, oapi.High, oapi.Critical:
+		return true
+	}
+	return false
+}
+
+// Create adds an entity by hand, before it is observed. Returns ErrInvalid
+// when the value is not of the type or an attribute is out of range, and
+// ErrConflict when the org already has the entity.
+func (s *Service) Create(ctx context.Context, orgID uuid.UUID, req oapi.CreateEntityRequest) (oapi.Entity, error) {
+	d := Draft{
+		Type:         req.Type,
+		Value:        req.Value,
+		Criticality:  req.Criticality,
+		Owner:        req.Owner,
+		BusinessUnit: req.BusinessUnit,
+	}
+	if req.Aliases != nil {
+		d.Aliases = *req.Aliases
+	}
+	if req.Tags != nil {
+		d.Tags = *req.Tags
+	}
+	if err := complete(&d); err != nil {
+		return oapi.Entity{}, err
+	}
+
+	var e oapi.Entity
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if e, err = s.repo.Insert(ctx, orgID, d); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, e.Id, EventCreated, e)
+	})
+	return e, err
+}
+
+// List returns a page of the org's entities passing f.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID, f Filter) ([]oapi.Entity, error) {
+	var entities []oapi.Entity
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		entities, err = s.repo.List(ctx, orgID, f)
+		return err
+	})
+	return entities, err
+}
+
+// Get returns a single entity. Returns ErrNotFound when it does not exist in
+// the org.
+func (s *Service) Get(ctx context.Context, orgID, entityID uuid.UUID) (oapi.Entity, error) {
+	var e oapi.Entity
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		e, err = s.repo.Get(ctx, orgID, entityID)
+		return err
+	})
+	return e, err
+}
+
+// Update patches an entity's attributes; its type and value are fixed. A
+// non-nil ifMatch makes the update conditional on the entity's version.
+// Returns ErrInvalid when an attribute is out of range.
+func (s *Service) Update(
+	ctx context.Context,
+	orgID, entityID uuid.UUID,
+	req oapi.UpdateEntityRequest,
+	ifMatch []int64,
+) (oapi.Entity, error) {
+	if req.Tags != nil {
+		tags := cleanTags(*req.Tags)
+		req.Tags = &tags
+	}
+	if err := checkAttributes(nil, req.Criticality, req.Owner, req.BusinessUnit, req.Tags); err != nil {
+		return oapi.Entity{}, err
+	}
+
+	var e oapi.Entity
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if req.Aliases != nil {
+			cur, err := s.repo.Get(ctx, orgID, entityID)
+			if err != nil {
+				return err
+			}
+			aliases := cleanAliases(cur.Value, *req.Aliases)
+			if err := checkAttributes(&aliases, nil, nil, nil, nil); err != nil {
+				return err
+			}
+			req.Aliases = &aliases
+		}
+		var err error
+		if e, err = s.repo.Update(ctx, orgID, entityID, req, ifMatch); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, entityID, EventUpdated, e)
+	})
+	return e, err
+}
+
+// Delete removes an entity and its timeline. It is added again when next
+// observed. Returns ErrNotFound when it does not exist in the org.
+func (s *Service) Delete(ctx context.Context, orgID, entityID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		e, err := s.repo.Get(ctx, orgID, entityID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, orgID, entityID); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, entityID, EventDeleted, DeletedPayload{
+			ID:    entityID,
+			OrgID: orgID,
+			Type:  e.Type,
+			Value: e.Value,
+		})
+	})
+}
+
+// Timeline returns the entity's hourly event counts by class for hours
+// starting in [from, to). A nil to means now, and a nil from a week before
+// to. Returns ErrInvalid when the range is empty or longer than 31 days, and
+// ErrNotFound when the entity does not exist in the org.
+func (s *Service) Timeline(
+	ctx context.Context,
+	orgID, entityID uuid.UUID,
+	from, to *time.Time,
+) ([]oapi.EntityTimelineBucket, error) {
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultTimelineRange)
+	if from != nil {
+		start = *from
+	}
+	switch {
+	case !start.Before(end):
+		return nil, fmt.Errorf("%w: timeline must start before it ends", ErrInvalid)
+	case end.Sub(start) > maxTimelineRange:
+		return nil, fmt.Errorf("%w: timeline longer than 31 days", ErrInvalid)
+	}
+
+	var buckets []oapi.EntityTimelineBucket
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, entityID); err != nil {
+			return err
+		}
+		var err error
+		buckets, err = s.repo.Timeline(ctx, orgID, entityID, start, end)
+		return err
+	})
+	return buckets, err
+}
+
+// ── Imports ──────────────────────────────────────────────────────────────────
+
+// Import reads entities from a CSV file and stores them, setting on entities
+// the org already has what the file gives and leaving the rest. Rows that
+// are invalid are reported and the rest imported. Returns ErrInvalid when
+// the content cannot be read at all.
+func (s *Service) Import(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.ImportEntitiesRequest,
+) (oapi.EntityImportResult, error) {
+	res := oapi.EntityImportResult{Errors: []oapi.EntityImportError{}}
+	rows, err := parseCSV(req.Content)
+	if err != nil {
+		return res, err
+	}
+	if len(rows) > maxImportEntries {
+		return res, fmt.Errorf("%w: more than %d rows", ErrInvalid, maxImportEntries)
+	}
+
+	report := func(ref string, err error) {
+		if len(res.Errors) < maxImportErrors {
+			res.Errors = append(res.Errors, oapi.EntityImportError{Entry: ref, Message: err.Error()})
+		}
+	}
+
+	// An entity given twice is imported as last given.
+	drafts := make([]Draft, 0, len(rows))
+	index := make(map[key]int, len(rows))
+	for _, row := range rows {
+		if row.err != nil {
+			report(row.ref, row.err)
+			continue
+		}
+		d := row.draft
+		if err := complete(&d); err != nil {
+			report(row.ref, err)
+			continue
+		}
+		k := key{d.Type, d.Value}
+		if i, ok := index[k]; ok {
+			drafts[i] = d
+			continue
+		}
+		index[k] = len(drafts)
+		drafts = append(drafts, d)
+	}
+	if len(drafts) == 0 {
+		return res, nil
+	}
+
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		existing, err := s.repo.Existing(ctx, orgID, drafts)
+		if err != nil {
+			return err
+		}
+		for _, d := range drafts {
+			if existing[key{d.Type, d.Value}] {
+				res.Updated++
+			} else {
+				res.Created++
+			}
+		}
+		if err := s.repo.Upsert(ctx, orgID, drafts); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, orgID, EventImported, ImportedPayload{
+			OrgID:   orgID,
+			Created: res.Created,
+			Updated: res.Updated,
+		})
+	})
+	if err != nil {
+		return oapi.EntityImportResult{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,280 @@
+package entity_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/entity"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func boolPtr(b bool) *bool { return &b }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+func newSeededService(t *testing.T) (*entity.Service, *sql.DB, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_entity_seed", "Entity Test Org")
+	svc := entity.NewService(entity.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	return svc, db, orgID
+}
+
+func TestCreateEntity_NormalizesAndRejectsDuplicates(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	high := oapi.High
+
+	e, err := svc.Create(ctx, orgID, oapi.CreateEntityRequest{
+		Type:        oapi.EntityTypeHost,
+		Value:       "DB-01.Corp.Example.",
+		Aliases:     &[]string{"::ffff:10.0.0.9", "db-01.corp.example", ""},
+		Criticality: &high,
+		Owner:       strPtr("alice"),
+		Tags:        &[]string{"pci", " pci "},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if e.Value != "db-01.corp.example" || !slices.Equal(e.Aliases, []string{"10.0.0.9"}) ||
+		!slices.Equal(e.Tags, []string{"pci"}) || e.Criticality == nil || *e.Criticality != oapi.High ||
+		e.FirstSeenAt != nil || e.Version != 1 {
+		t.Errorf("created: got %+v", e)
+	}
+
+	_, err = svc.Create(ctx, orgID, oapi.CreateEntityRequest{Type: oapi.EntityTypeHost, Value: "db-01.corp.example"})
+	if !errors.Is(err, entity.ErrConflict) {
+		t.Errorf("duplicate: got %v, want ErrConflict", err)
+	}
+	bad := oapi.EntityCriticality("urgent")
+	for _, req := range []oapi.CreateEntityRequest{
+		{Type: oapi.EntityTypeIp, Value: "db-01"},
+		{Type: oapi.EntityTypeUser, Value: "bob", Criticality: &bad},
+		{Type: "service", Value: "nginx"},
+	} {
+		if _, err := svc.Create(ctx, orgID, req); !errors.Is(err, entity.ErrInvalid) {
+			t.Errorf("Create(%+v): got %v, want ErrInvalid", req, err)
+		}
+	}
+}
+
+func TestUpdateEntity(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	low := oapi.Low
+	e, err := svc.Create(ctx, orgID, oapi.CreateEntityRequest{
+		Type:         oapi.EntityTypeUser,
+		Value:        "alice",
+		Criticality:  &low,
+		BusinessUnit: strPtr("Payments"),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	updated, err := svc.Update(ctx, orgID, e.Id, oapi.UpdateEntityRequest{
+		Aliases:          &[]string{"CORP\\Alice", "alice"},
+		ClearCriticality: boolPtr(true),
+		Owner:            strPtr("it-ops"),
+		BusinessUnit:     strPtr(""),
+	}, []int64{e.Version})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if !slices.Equal(updated.Aliases, []string{"corp\\alice"}) || updated.Criticality != nil ||
+		updated.Owner == nil || *updated.Owner != "it-ops" || updated.BusinessUnit != nil || updated.Version != 2 {
+		t.Errorf("updated: got %+v", updated)
+	}
+
+	_, err = svc.Update(ctx, orgID, e.Id, oapi.UpdateEntityRequest{Owner: strPtr("bob")}, []int64{e.Version})
+	if !errors.Is(err, entity.ErrPreconditionFailed) {
+		t.Errorf("stale version: got %v, want ErrPreconditionFailed", err)
+	}
+	_, err = svc.Update(ctx, orgID, uuid.New(), oapi.UpdateEntityRequest{}, nil)
+	if !errors.Is(err, entity.ErrNotFound) {
+		t.Errorf("unknown entity: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestImportEntities_UpsertsAndReports(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	if _, err := svc.Create(ctx, orgID, oapi.CreateEntityRequest{
+		Type:  oapi.EntityTypeHost,
+		Value: "db-01",
+		Owner: strPtr("alice"),
+	}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	res, err := svc.Import(ctx, orgID, oapi.ImportEntitiesRequest{
+		Content: "type,value,criticality,owner,business_unit,tags,aliases\n" +
+			"host,DB-01,critical,,Payments,pci;prod,10.0.0.9\n" +
+			"user,bob,low,,,,\n" +
+			"user,BOB,medium,,,,\n" +
+			"ip,not an address,,,,,\n" +
+			"host,web-01,urgent,,,,\n",
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.Created != 1 || res.Updated != 1 || len(res.Errors) != 2 || res.Errors[0].Entry != "line 5" ||
+		res.Errors[1].Entry != "line 6" {
+		t.Errorf("result: got %+v", res)
+	}
+
+	list, err := svc.List(ctx, orgID, entity.Filter{Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("listed: got %+v", list)
+	}
+	for _, e := range list {
+		switch e.Value {
+		case "db-01":
+			if e.Criticality == nil || *e.Criticality != oapi.Critical || e.Owner == nil || *e.Owner != "alice" ||
+				e.BusinessUnit == nil || *e.BusinessUnit != "Payments" || !slices.Equal(e.Tags, []string{"pci", "prod"}) ||
+				!slices.Equal(e.Aliases, []string{"10.0.0.9"}) || e.Version != 2 {
+				t.Errorf("updated entity: got %+v", e)
+			}
+		case "bob":
+			if e.Criticality == nil || *e.Criticality != oapi.Medium {
+				t.Errorf("imported twice: got %+v", e)
+			}
+		}
+	}
+
+	_, err = svc.Import(ctx, orgID, oapi.ImportEntitiesRequest{Content: "value\nbob\n"})
+	if !errors.Is(err, entity.ErrInvalid) {
+		t.Errorf("no type column: got %v, want ErrInvalid", err)
+	}
+}
+
+func TestTracker_RecordsSightingsAndAddsContext(t *testing.T) {
+	svc, db, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	tracker := entity.NewTracker(entity.NewRepo(db), tx.NewManager(db), discard(), time.Nanosecond)
+	hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+	event := func(at time.Time) *ocsf.Authentication {
+		return &ocsf.Authentication{
+			BaseEvent: ocsf.BaseEvent{
+				ClassUID:  3002,
+				ClassName: "Authentication",
+				Time:      at.UnixMilli(),
+				Device:    &ocsf.Device{Hostname: "WS-01", IP: "10.0.0.5"},
+			},
+			User: &ocsf.User{Name: "Alice"},
+		}
+	}
+
+	var events []ocsf.Event
+	for _, at := range []time.Time{hour.Add(10 * time.Minute), hour.Add(70 * time.Minute), hour.Add(5 * time.Minute)} {
+		ev := event(at)
+		tracker.Enrich(context.Background(), orgID, ev)
+		if len(ev.Enrichments) != 0 {
+			t.Fatalf("no context: got %+v", ev.Enrichments)
+		}
+		events = append(events, ev)
+	}
+	if list, err := svc.List(ctx, orgID, entity.Filter{Limit: 10}); err != nil || len(list) != 0 {
+		t.Fatalf("enriching alone: want nothing recorded, got %+v (%v)", list, err)
+	}
+	if err := tracker.Record(context.Background(), orgID, events); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+
+	list, err := svc.List(ctx, orgID, entity.Filter{Query: strPtr("ws-01"), Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("by value and alias: got %+v", list)
+	}
+	var host oapi.Entity
+	for _, e := range list {
+		if e.Type == oapi.EntityTypeHost {
+			host = e
+		}
+	}
+	if host.Value != "ws-01" || !slices.Equal(host.Aliases, []string{"10.0.0.5"}) || host.FirstSeenAt == nil ||
+		!host.FirstSeenAt.Equal(hour.Add(5*time.Minute)) || !host.LastSeenAt.Equal(hour.Add(70*time.Minute)) ||
+		host.Version != 1 {
+		t.Errorf("observed host: got %+v", host)
+	}
+
+	buckets, err := svc.Timeline(ctx, orgID, host.Id, nil, nil)
+	if err != nil {
+		t.Fatalf("Timeline: %v", err)
+	}
+	if len(buckets) != 2 || !buckets[0].Bucket.Equal(hour) || buckets[0].Events != 2 || buckets[1].Events != 1 ||
+		buckets[0].ClassUid != 3002 || buckets[0].ClassName != "Authentication" {
+		t.Errorf("timeline: got %+v", buckets)
+	}
+	from, to := hour, hour.Add(-time.Hour)
+	if _, err := svc.Timeline(ctx, orgID, host.Id, &from, &to); !errors.Is(err, entity.ErrInvalid) {
+		t.Errorf("empty range: got %v, want ErrInvalid", err)
+	}
+
+	critical := oapi.Critical
+	if _, err := svc.Update(ctx, orgID, host.Id, oapi.UpdateEntityRequest{Criticality: &critical}, nil); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	ev := event(time.Now())
+	tracker.Enrich(context.Background(), orgID, ev)
+	if len(ev.Enrichments) != 1 || ev.Enrichments[0].Data["entity_id"] != host.Id.String() ||
+		ev.Enrichments[0].Data["criticality"] != "critical" {
+		t.Errorf("after update: got %+v", ev.Enrichments)
+	}
+}
@@ -0,0 +1,199 @@
+package entity
+
+import (
+	"cmp"
+	"context"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/platform/orgcache"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Provider names the tracker in the enrichments it adds to events.
+const Provider = "horizon-entities"
+
+// EnrichmentType is the type of the enrichments carrying an entity's context.
+const EnrichmentType = "entity"
+
+// ── Set ──────────────────────────────────────────────────────────────────────
+
+// Set holds the entities of an org that have context, by type and value and
+// by alias. It is safe for concurrent use once built.
+type Set struct {
+	entities map[key]*oapi.Entity
+	aliases  map[string][]*oapi.Entity
+}
+
+// NewSet builds a Set of the entities, whose values and aliases must be
+// normalized.
+func NewSet(entities []oapi.Entity) *Set {
+	s := &Set{entities: make(map[key]*oapi.Entity), aliases: make(map[string][]*oapi.Entity)}
+	for i := range entities {
+		e := &entities[i]
+		s.entities[key{e.Type, e.Value}] = e
+		for _, a := range e.Aliases {
+			s.aliases[a] = append(s.aliases[a], e)
+		}
+	}
+	return s
+}
+
+// Len returns the number of entities in the set.
+func (s *Set) Len() int {
+	return len(s.entities)
+}
+
+// lookup returns the entity o is, or else those o's value is an alias of.
+func (s *Set) lookup(o Observation) []*oapi.Entity {
+	if e, ok := s.entities[key{o.Type, o.Value}]; ok {
+		return []*oapi.Entity{e}
+	}
+	return s.aliases[o.Value]
+}
+
+// Enrich adds an enrichment to ev for every entity with context among those
+// observed in it, naming where it is mentioned.
+func (s *Set) Enrich(ev ocsf.Event, observed []Observation) {
+	if len(s.entities) == 0 {
+		return
+	}
+	base := ev.Common()
+	seen := make(map[uuid.UUID]bool)
+	for _, o := range observed {
+		for _, e := range s.lookup(o) {
+			if seen[e.Id] {
+				continue
+			}
+			seen[e.Id] = true
+			base.Enrichments = append(base.Enrichments, enrichment(o, e))
+		}
+	}
+}
+
+func enrichment(o Observation, e *oapi.Entity) ocsf.Enrichment {
+	data := map[string]any{
+		"entity_id":   e.Id.String(),
+		"entity_type": string(e.Type),
+		"entity":      e.Value,
+		"tags":        e.Tags,
+	}
+	if e.Criticality != nil {
+		data["criticality"] = string(*e.Criticality)
+	}
+	if e.Owner != nil {
+		data["owner"] = *e.Owner
+	}
+	if e.BusinessUnit != nil {
+		data["business_unit"] = *e.BusinessUnit
+	}
+	return ocsf.Enrichment{Name: o.Path, Value: o.Value, Type: EnrichmentType, Provider: Provider, Data: data}
+}
+
+// ── Tracker ──────────────────────────────────────────────────────────────────
+
+// Tracker keeps the entity inventory of each org as its normalized events are
+// processed and stored. It adds the context of the entities an event
+// mentions to the event, from each org's entities with context, reloaded
+// when the org's context has changed, which it checks for at most once a
+// refresh interval. Once events are stored, it records them as sightings of
+// the entities they mention, in the transaction storing them. It is safe for
+// concurrent use.
+type Tracker struct {
+	repo   *Repo
+	tx     *tx.Manager
+	logger *slog.Logger
+	sets   *orgcache.Cache[*Set]
+}
+
+// NewTracker wires a Tracker with the repo, transaction manager and logger.
+// A zero refresh means 30 seconds.
+func NewTracker(repo *Repo, txm *tx.Manager, logger *slog.Logger, refresh time.Duration) *Tracker {
+	t := &Tracker{repo: repo, tx: txm, logger: logger}
+	t.sets = orgcache.New(txm, refresh, repo.Revision, t.load)
+	return t
+}
+
+// Enrich adds the context of the org's entities that ev mentions to ev. When
+// the org's context cannot be loaded, the last loaded is used.
+func (t *Tracker) Enrich(ctx context.Context, orgID uuid.UUID, ev ocsf.Event) {
+	observed := Extract(ev)
+	if len(observed) == 0 {
+		return
+	}
+	if set := t.set(ctx, orgID); set != nil {
+		set.Enrich(ev, observed)
+	}
+}
+
+// Record records the org's events as sightings of the entities they mention,
+// at each event's time, or now when it has none, in a transaction carrying
+// the org's tenant scope, joining one already on ctx.
+func (t *Tracker) Record(ctx context.Context, orgID uuid.UUID, events []ocsf.Event) error {
+	sightings := make(map[key]*Sighting)
+	for _, ev := range events {
+		observed := Extract(ev)
+		if len(observed) == 0 {
+			continue
+		}
+		base := ev.Common()
+		at := time.Now()
+		if base.Time > 0 {
+			at = time.UnixMilli(base.Time)
+		}
+		at = at.UTC()
+		bucket := Count{Bucket: at.Truncate(time.Hour), ClassUID: base.ClassUID, ClassName: base.ClassName}
+		for _, o := range observed {
+			k := key{o.Type, o.Value}
+			s := sightings[k]
+			if s == nil {
+				s = &Sighting{Type: o.Type, Value: o.Value, FirstSeen: at, LastSeen: at}
+				sightings[k] = s
+			}
+			s.add(at, o.Aliases, bucket)
+		}
+	}
+	if len(sightings) == 0 {
+		return nil
+	}
+	// Entities are written in one order, so concurrent writers of an org's
+	// events do not deadlock.
+	sorted := make([]Sighting, 0, len(sightings))
+	for _, s := range sightings {
+		sorted = append(sorted, *s)
+	}
+	slices.SortFunc(sorted, func(a, b Sighting) int {
+		return cmp.Or(cmp.Compare(a.Type, b.Type), cmp.Compare(a.Value, b.Value))
+	})
+	return t.tx.RunInTx(tenant.WithOrg(ctx, orgID), nil, func(ctx context.Context) error {
+		return t.repo.Observe(ctx, orgID, sorted)
+	})
+}
+
+// set returns the org's entities with context, reloading them when they have
+// changed.
+func (t *Tracker) set(ctx context.Context, orgID uuid.UUID) *Set {
+	set, err := t.sets.Get(ctx, orgID)
+	if err != nil {
+		t.logger.WarnContext(ctx, "loading entity context failed",
+			slog.String("org_id", orgID.String()),
+			slog.Any("err", err),
+		)
+	}
+	return set
+}
+
+// load loads the org's entities with context.
+func (t *Tracker) load(ctx context.Context, orgID uuid.UUID, _ time.Time) (*Set, error) {
+	entities, err := t.repo.ListWithContext(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return NewSet(entities), nil
+}
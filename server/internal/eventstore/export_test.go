@@ -0,0 +1,14 @@
+package eventstore
+
+import (
+	"time"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/internal/pipeline"
+)
+
+// Row exposes the row an event is stored as at now, for checking its time and
+// the attributes promoted to columns without a database.
+func Row(ev pipeline.Event, now time.Time) (model.Events, error) {
+	return toRow(ev, now)
+}
@@ -0,0 +1,139 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+const (
+	// Retention is how long events are kept. They are dropped a day at a
+	// time, with the partition holding the day.
+	Retention = 90 * 24 * time.Hour
+
+	// PartitionsAhead is how many days of partitions are created ahead,
+	// today's included, so writes seldom have to create one.
+	PartitionsAhead = 3
+)
+
+// partitionPrefix starts the name of each partition of events, which ends in
+// the day it holds, as YYYYMMDD.
+const partitionPrefix = "events_p"
+
+// day returns the start of the UTC day of t, the day of the partition
+// holding events of time t.
+func day(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// partitionDays returns the days of the partitions rows go in.
+func partitionDays(rows []model.Events) []time.Time {
+	var days []time.Time
+	for _, r := range rows {
+		if d := day(r.Time); !slices.Contains(days, d) {
+			days = append(days, d)
+		}
+	}
+	return days
+}
+
+// createPartitions creates the partitions of days that do not exist yet, in
+// the transaction on ctx.
+func (s *Store) createPartitions(ctx context.Context, days []time.Time) error {
+	dates := make([]string, 0, len(days))
+	for _, d := range days {
+		dates = append(dates, d.Format(time.DateOnly))
+	}
+	_, err := tx.Executor(ctx, s.db).ExecContext(ctx,
+		"SELECT events_create_partition(d) FROM unnest($1::DATE[]) AS d", pq.Array(dates),
+	)
+	if err != nil {
+		return fmt.Errorf("creating event partitions: %w", err)
+	}
+	return nil
+}
+
+// CreatePartitions creates the partitions of the n days starting with the
+// day of from, unless they exist, so writes seldom have to.
+func (s *Store) CreatePartitions(ctx context.Context, from time.Time, n int) error {
+	days := make([]time.Time, 0, n)
+	for i := range n {
+		days = append(days, day(from).AddDate(0, 0, i))
+	}
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		return s.createPartitions(ctx, days)
+	})
+}
+
+// DropPartitions drops the partitions of days that ended by cutoff, and the
+// events in them, in one transaction. It needs the privileges of the owner of
+// events, so s must be on the privileged pool with an unscoped manager.
+// Returns the number of partitions dropped.
+func (s *Store) DropPartitions(ctx context.Context, cutoff time.Time) (int, error) {
+	var n int
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		expired, err := s.expiredPartitions(ctx, cutoff)
+		if err != nil {
+			return err
+		}
+		for _, name := range expired {
+			_, err := tx.Executor(ctx, s.db).ExecContext(ctx, "DROP TABLE IF EXISTS "+pq.QuoteIdentifier(name))
+			if err != nil {
+				return fmt.Errorf("dropping event partition %s: %w", name, err)
+			}
+		}
+		n = len(expired)
+		return nil
+	})
+	return n, err
+}
+
+// expiredPartitions returns the names of the partitions of days that ended by
+// cutoff, oldest first.
+func (s *Store) expiredPartitions(ctx context.Context, cutoff time.Time) ([]string, error) {
+	rows, err := tx.Executor(ctx, s.db).QueryContext(ctx, `SELECT c.relname
+		FROM pg_inherits i JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'events'::REGCLASS`)
+	if err != nil {
+		return nil, fmt.Errorf("listing event partitions: %w", err)
+	}
+	var expired []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			_ = rows.Close()
+			return nil, fmt.Errorf("listing event partitions: %w", err)
+		}
+		d, ok := strings.CutPrefix(name, partitionPrefix)
+		if !ok {
+			continue
+		}
+		start, err := time.Parse("20060102", d)
+		if err == nil && !start.AddDate(0, 0, 1).After(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	if err := rows.Close(); err != nil {
+		return nil, fmt.Errorf("listing event partitions: %w", err)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing event partitions: %w", err)
+	}
+	slices.Sort(expired)
+	return expired, nil
+}
+
+// PartitionsArgs is the background job creating the partitions of the days
+// ahead and dropping those past retention.
+type PartitionsArgs struct{}
+
+// Kind implements jobs.Args.
+func (PartitionsArgs) Kind() string { return "eventstore.partitions" }
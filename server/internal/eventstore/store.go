@@ -0,0 +1,355 @@
+// Package eventstore keeps normalized events in Postgres, in the events
+// table: org-scoped, and range-partitioned by day of event time so whole
+// days are dropped once past retention. The attributes searches filter on
+// most (class, severity, source and destination addresses, user and host)
+// are promoted to columns next to the full event, kept as JSONB. Writes are
+// batched with COPY, and the partitions they need are created as they go.
+package eventstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// stagingTable is the temporary table a batch is copied into. Postgres does
+// not COPY into tables with row-level security, so batches are copied there
+// and moved into events with INSERT, which the policies check.
+const stagingTable = "events_staging"
+
+// columns are the columns written, in the order rowValues lists them.
+var columns = []string{
+	"org_id", "id", "source_id", "time",
+	"class_uid", "severity_id", "src_ip", "dst_ip", "user_name", "hostname",
+	"event",
+}
+
+// Store owns the events table and its partitions. The table is protected by
+// RLS: writers run under the tenant scope of the events' org, and partitions
+// are dropped with the privileged pool.
+type Store struct {
+	db qrm.DB
+	tx *tx.Manager
+}
+
+// NewStore wires a Store to the given database and transaction manager.
+func NewStore(db qrm.DB, txm *tx.Manager) *Store {
+	return &Store{db: db, tx: txm}
+}
+
+// Upsert stores processed events, replacing those stored with the same IDs,
+// in a transaction joining one already on ctx, and returns those it stored
+// for the first time, so what is counted of an event is counted once however
+// often it is stored. Events are stored at the time rowTime gives them; one
+// stored before at another time, as when a replay's mapping reads it
+// differently, is moved to the partition of its new time. Upsert implements
+// replay.Store.
+func (s *Store) Upsert(ctx context.Context, events []pipeline.Event) ([]pipeline.Event, error) {
+	rows, err := toRows(events, time.Now())
+	if err != nil || len(rows) == 0 {
+		return nil, err
+	}
+	var stored map[eventKey]bool
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		txn, ok := tx.Executor(ctx, s.db).(*sql.Tx)
+		if !ok {
+			return errors.New("storing events: no transaction to copy in")
+		}
+		if err := s.createPartitions(ctx, partitionDays(rows)); err != nil {
+			return err
+		}
+		if _, err := txn.ExecContext(ctx,
+			"CREATE TEMP TABLE "+stagingTable+" (LIKE events INCLUDING DEFAULTS) ON COMMIT DROP",
+		); err != nil {
+			return fmt.Errorf("creating event staging table: %w", err)
+		}
+		if err := copyRows(ctx, txn, rows); err != nil {
+			return err
+		}
+		if stored, err = storedKeys(ctx, txn); err != nil {
+			return err
+		}
+		if _, err := txn.ExecContext(ctx, deleteMoved); err != nil {
+			return fmt.Errorf("moving events: %w", err)
+		}
+		if _, err := txn.ExecContext(ctx, upsertStaged); err != nil {
+			return fmt.Errorf("storing events: %w", err)
+		}
+		if _, err := txn.ExecContext(ctx, "DROP TABLE "+stagingTable); err != nil {
+			return fmt.Errorf("dropping event staging table: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return firstStored(events, stored), nil
+}
+
+// storedKeys returns the keys of the staged events already stored.
+func storedKeys(ctx context.Context, txn *sql.Tx) (map[eventKey]bool, error) {
+	rows, err := txn.QueryContext(ctx, `SELECT s.org_id, s.id FROM `+stagingTable+` s
+		JOIN events e ON e.org_id = s.org_id AND e.id = s.id`)
+	if err != nil {
+		return nil, fmt.Errorf("finding stored events: %w", err)
+	}
+	defer rows.Close()
+	stored := make(map[eventKey]bool)
+	for rows.Next() {
+		var k eventKey
+		if err := rows.Scan(&k.orgID, &k.id); err != nil {
+			return nil, fmt.Errorf("finding stored events: %w", err)
+		}
+		stored[k] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("finding stored events: %w", err)
+	}
+	return stored, nil
+}
+
+// firstStored returns the events not among those stored before, each once,
+// as it was given last.
+func firstStored(events []pipeline.Event, stored map[eventKey]bool) []pipeline.Event {
+	index := make(map[eventKey]int, len(events))
+	var first []pipeline.Event
+	for _, ev := range events {
+		k := eventKey{orgID: ev.OrgID, id: ev.ID}
+		if stored[k] {
+			continue
+		}
+		if i, ok := index[k]; ok {
+			first[i] = ev
+			continue
+		}
+		index[k] = len(first)
+		first = append(first, ev)
+	}
+	return first
+}
+
+// deleteMoved deletes the stored events the staged batch stores at another
+// time.
+const deleteMoved = `DELETE FROM events e USING ` + stagingTable + ` s
+	WHERE e.org_id = s.org_id AND e.id = s.id AND e.time <> s.time`
+
+// upsertStaged moves the staged batch into events, replacing stored events
+// with the same keys.
+const upsertStaged = `INSERT INTO events (org_id, id, source_id, time, class_uid, severity_id, src_ip, dst_ip,
+		user_name, hostname, event)
+	SELECT org_id, id, source_id, time, class_uid, severity_id, src_ip, dst_ip, user_name, hostname, event
+	FROM ` + stagingTable + `
+	ON CONFLICT (org_id, id, time) DO UPDATE SET
+		source_id = EXCLUDED.source_id,
+		class_uid = EXCLUDED.class_uid,
+		severity_id = EXCLUDED.severity_id,
+		src_ip = EXCLUDED.src_ip,
+		dst_ip = EXCLUDED.dst_ip,
+		user_name = EXCLUDED.user_name,
+		hostname = EXCLUDED.hostname,
+		event = EXCLUDED.event,
+		created_at = NOW()`
+
+// copyRows copies rows into the staging table with one COPY.
+func copyRows(ctx context.Context, txn *sql.Tx, rows []model.Events) error {
+	stmt, err := txn.PrepareContext(ctx, pq.CopyIn(stagingTable, columns...))
+	if err != nil {
+		return fmt.Errorf("copying events: %w", err)
+	}
+	for _, r := range rows {
+		if _, err := stmt.ExecContext(ctx, rowValues(r)...); err != nil {
+			return errors.Join(fmt.Errorf("copying events: %w", err), stmt.Close())
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		return errors.Join(fmt.Errorf("copying events: %w", err), stmt.Close())
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("copying events: %w", err)
+	}
+	return nil
+}
+
+// rowValues lists r's values in the order of columns, NULL for those unset.
+func rowValues(r model.Events) []any {
+	return []any{
+		r.OrgID, r.ID, r.SourceID, r.Time,
+		nullable(r.ClassUID), nullable(r.SeverityID),
+		nullable(r.SrcIP), nullable(r.DstIP), nullable(r.UserName), nullable(r.Hostname),
+		r.Event,
+	}
+}
+
+// nullable returns what p points to, or nil for NULL when p is nil.
+func nullable[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// ── Rows ─────────────────────────────────────────────────────────────────────
+
+// eventKey identifies a stored event.
+type eventKey struct {
+	orgID, id uuid.UUID
+}
+
+// toRows returns the rows storing events as of now. An event given twice is
+// stored as it was given last, since one statement cannot write a row twice.
+func toRows(events []pipeline.Event, now time.Time) ([]model.Events, error) {
+	rows := make([]model.Events, 0, len(events))
+	index := make(map[eventKey]int, len(events))
+	for _, ev := range events {
+		r, err := toRow(ev, now)
+		if err != nil {
+			return nil, err
+		}
+		k := eventKey{orgID: ev.OrgID, id: ev.ID}
+		if i, ok := index[k]; ok {
+			rows[i] = r
+			continue
+		}
+		index[k] = len(rows)
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+// toRow returns the row storing ev as of now, with the attributes of its OCSF
+// event promoted to their columns.
+func toRow(ev pipeline.Event, now time.Time) (model.Events, error) {
+	event, err := json.Marshal(ev.Output())
+	if err != nil {
+		return model.Events{}, fmt.Errorf("encoding event %s: %w", ev.ID, err)
+	}
+	r := model.Events{
+		OrgID:    ev.OrgID,
+		ID:       ev.ID,
+		SourceID: ev.SourceID,
+		Time:     rowTime(ev, now),
+		Event:    string(event),
+	}
+	if ev.OCSF != nil {
+		promote(&r, ev.OCSF)
+	}
+	return r, nil
+}
+
+// rowTime returns the time ev is stored at as of now: that of its OCSF event
+// when it has one, so the row agrees with the event it stores, and otherwise
+// the time extracted from its fields. Times outside the days whose partitions
+// are kept, from Retention ago to PartitionsAhead days from today, are
+// clamped into them, so no event makes a partition that is dropped at once
+// or one years ahead.
+func rowTime(ev pipeline.Event, now time.Time) time.Time {
+	t := ev.Time
+	if ev.OCSF != nil {
+		if ms := ev.OCSF.Common().Time; ms != 0 {
+			t = time.UnixMilli(ms)
+		}
+	}
+	earliest := now.Add(-Retention)
+	latest := day(now).AddDate(0, 0, PartitionsAhead).Add(-time.Millisecond)
+	switch {
+	case t.Before(earliest):
+		t = earliest
+	case t.After(latest):
+		t = latest
+	}
+	return t.UTC()
+}
+
+// promote sets the columns of r holding the attributes of ev searched on
+// most: its class and severity, the addresses of its source and destination
+// endpoints, the user it is about or else the actor's, and the hostname, or
+// else name, of the device it was observed on.
+func promote(r *model.Events, ev ocsf.Event) {
+	b := ev.Common()
+	r.ClassUID = int32Ptr(b.ClassUID)
+	r.SeverityID = int32Ptr(b.SeverityID)
+
+	var src, dst *ocsf.Endpoint
+	var user *ocsf.User
+	var actor *ocsf.Actor
+	device := b.Device
+	switch e := ev.(type) {
+	case *ocsf.NetworkActivity:
+		src, dst = e.SrcEndpoint, e.DstEndpoint
+	case *ocsf.HTTPActivity:
+		src, dst = e.SrcEndpoint, e.DstEndpoint
+	case *ocsf.DNSActivity:
+		src, dst = e.SrcEndpoint, e.DstEndpoint
+	case *ocsf.Authentication:
+		src, dst, user, actor = e.SrcEndpoint, e.DstEndpoint, e.User, e.Actor
+	case *ocsf.APIActivity:
+		src, actor = e.SrcEndpoint, e.Actor
+	case *ocsf.FileActivity:
+		actor = e.Actor
+	case *ocsf.ProcessActivity:
+		actor = e.Actor
+	case *ocsf.DetectionFinding:
+		actor = e.Actor
+	case *ocsf.InventoryInfo:
+		actor = e.Actor
+		if e.Device != nil {
+			device = e.Device
+		}
+	}
+	if user == nil && actor != nil {
+		user = actor.User
+	}
+
+	if src != nil {
+		r.SrcIP = ipText(src.IP)
+	}
+	if dst != nil {
+		r.DstIP = ipText(dst.IP)
+	}
+	if user != nil {
+		r.UserName = text(user.Name)
+	}
+	if device != nil {
+		r.Hostname = text(device.Hostname)
+		if r.Hostname == nil {
+			r.Hostname = text(device.Name)
+		}
+	}
+}
+
+// ipText returns s in canonical form when it is an IP address, and nil
+// otherwise; an inet column takes no zone.
+func ipText(s string) *string {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return nil
+	}
+	v := addr.WithZone("").String()
+	return &v
+}
+
+// text returns s, or nil when it is empty.
+func text(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func int32Ptr(i int) *int32 {
+	v := int32(i) //nolint:gosec // OCSF IDs fit
+	return &v
+}
@@ -0,0 +1,223 @@
+package eventstore_test
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/eventstore"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+func TestRow_PromotesHotAttributes(t *testing.T) {
+	ev := pipeline.Event{
+		ID:       uuid.New(),
+		OrgID:    uuid.New(),
+		SourceID: uuid.New(),
+		Time:     time.Date(2026, 10, 19, 14, 0, 0, 0, time.FixedZone("CEST", 2*60*60)),
+		OCSF: &ocsf.Authentication{
+			BaseEvent: ocsf.BaseEvent{
+				ClassUID:   3002,
+				SeverityID: 3,
+				Device:     &ocsf.Device{Name: "DC-01"},
+			},
+			Actor:       &ocsf.Actor{User: &ocsf.User{Name: "alice"}},
+			SrcEndpoint: &ocsf.Endpoint{IP: "fe80::1%eth0"},
+			DstEndpoint: &ocsf.Endpoint{IP: "not-an-address"},
+		},
+	}
+	r, err := eventstore.Row(ev, ev.Time)
+	if err != nil {
+		t.Fatalf("Row: %v", err)
+	}
+	if r.ClassUID == nil || *r.ClassUID != 3002 || r.SeverityID == nil || *r.SeverityID != 3 {
+		t.Errorf("class and severity: got %v and %v", r.ClassUID, r.SeverityID)
+	}
+	if r.SrcIP == nil || *r.SrcIP != "fe80::1" || r.DstIP != nil {
+		t.Errorf("addresses: got %v and %v, want fe80::1 and none", r.SrcIP, r.DstIP)
+	}
+	if r.UserName == nil || *r.UserName != "alice" || r.Hostname == nil || *r.Hostname != "DC-01" {
+		t.Errorf("user and host: got %v and %v, want the actor's and the device's name", r.UserName, r.Hostname)
+	}
+	if r.Time.Location() != time.UTC || !r.Time.Equal(ev.Time) {
+		t.Errorf("time: got %v, want %v in UTC", r.Time, ev.Time)
+	}
+
+	ev.OCSF = nil
+	ev.Fields = parser.Fields{"msg": "hello"}
+	r, err = eventstore.Row(ev, ev.Time)
+	if err != nil {
+		t.Fatalf("Row unmapped: %v", err)
+	}
+	if r.Event != `{"msg":"hello"}` || r.ClassUID != nil || r.SrcIP != nil || r.Hostname != nil {
+		t.Errorf("unmapped: want the fields and no promoted attributes, got %+v", r)
+	}
+}
+
+func TestRow_TimeOfTheOCSFEventWithinKeptDays(t *testing.T) {
+	now := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+	extracted := now.Add(-time.Minute)
+	row := func(ocsfTime time.Time) time.Time {
+		t.Helper()
+		ev := pipeline.Event{ID: uuid.New(), OrgID: uuid.New(), Time: extracted}
+		if !ocsfTime.IsZero() {
+			ev.OCSF = &ocsf.NetworkActivity{BaseEvent: ocsf.BaseEvent{ClassUID: 4001, Time: ocsfTime.UnixMilli()}}
+		}
+		r, err := eventstore.Row(ev, now)
+		if err != nil {
+			t.Fatalf("Row: %v", err)
+		}
+		return r.Time
+	}
+
+	for name, c := range map[string]struct{ ocsf, want time.Time }{
+		"unmapped":    {want: extracted},
+		"mapped":      {ocsf: now.Add(-2 * time.Hour), want: now.Add(-2 * time.Hour)},
+		"before kept": {ocsf: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), want: now.Add(-eventstore.Retention)},
+		"past last kept": {ocsf: time.Date(2099, 1, 1, 0, 0, 0, 0, time.UTC), want: now.Truncate(24*time.Hour).
+			AddDate(0, 0, eventstore.PartitionsAhead).Add(-time.Millisecond)},
+	} {
+		if got := row(c.ocsf); !got.Equal(c.want) {
+			t.Errorf("%s: time %v, want %v", name, got, c.want)
+		}
+	}
+}
+
+func TestUpsert_ReplacesEventsAndDropsExpiredDays(t *testing.T) {
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_eventstore_seed", "Event Store Test Org")
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	store := eventstore.NewStore(db, tx.NewManager(db))
+
+	day1 := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -10).Add(23*time.Hour + 59*time.Minute)
+	event := func(id uuid.UUID, at time.Time, msg string) pipeline.Event {
+		return pipeline.Event{
+			ID:       id,
+			OrgID:    orgID,
+			SourceID: uuid.New(),
+			Time:     at,
+			OCSF: &ocsf.NetworkActivity{
+				BaseEvent: ocsf.BaseEvent{ClassUID: 4001, Message: msg},
+				Network:   ocsf.Network{SrcEndpoint: &ocsf.Endpoint{IP: "10.0.0.1"}},
+			},
+		}
+	}
+	first, second := uuid.New(), uuid.New()
+	stored, err := store.Upsert(ctx, []pipeline.Event{
+		event(first, day1, "old"),
+		event(second, day1.Add(time.Hour), "kept"),
+	})
+	if err != nil {
+		t.Fatalf("Upsert: %v", err)
+	}
+	if len(stored) != 2 {
+		t.Errorf("Upsert: want both events stored for the first time, got %d", len(stored))
+	}
+	third := uuid.New()
+	stored, err = store.Upsert(ctx, []pipeline.Event{event(first, day1, "new"), event(third, day1, "added")})
+	if err != nil {
+		t.Fatalf("Upsert again: %v", err)
+	}
+	if len(stored) != 1 || stored[0].ID != third {
+		t.Errorf("Upsert again: want only the new event stored for the first time, got %+v", stored)
+	}
+
+	messages := func() map[uuid.UUID]string {
+		t.Helper()
+		rows, err := db.QueryContext(context.Background(),
+			`SELECT id, event->>'message' FROM events WHERE org_id = $1 AND src_ip = '10.0.0.1'`, orgID)
+		if err != nil {
+			t.Fatalf("querying events: %v", err)
+		}
+		defer rows.Close()
+		got := make(map[uuid.UUID]string)
+		for rows.Next() {
+			var id uuid.UUID
+			var msg string
+			if err := rows.Scan(&id, &msg); err != nil {
+				t.Fatalf("scanning events: %v", err)
+			}
+			got[id] = msg
+		}
+		return got
+	}
+	if got := messages(); len(got) != 3 || got[first] != "new" || got[second] != "kept" {
+		t.Fatalf("stored: got %v, want the first replaced", got)
+	}
+
+	// Stored again at another time, an event moves rather than doubles.
+	moved := day1.Add(2 * time.Hour)
+	if stored, err := store.Upsert(ctx, []pipeline.Event{event(second, moved, "kept")}); err != nil || len(stored) != 0 {
+		t.Fatalf("Upsert moved: got %+v, %v, want nothing stored for the first time", stored, err)
+	}
+	var rows int
+	var at time.Time
+	if err := db.QueryRowContext(context.Background(),
+		`SELECT count(*), max(time) FROM events WHERE org_id = $1 AND id = $2`, orgID, second).Scan(&rows, &at); err != nil {
+		t.Fatalf("querying moved event: %v", err)
+	}
+	if rows != 1 || !at.Equal(moved) {
+		t.Errorf("moved: got %d rows, latest at %v, want one at %v", rows, at, moved)
+	}
+
+	privileged := testhelper.PrivilegedDB(t)
+	dropper := eventstore.NewStore(privileged, tx.NewUnscopedManager(privileged))
+	n, err := dropper.DropPartitions(context.Background(), day1.Add(time.Hour))
+	if err != nil || n < 1 {
+		t.Fatalf("DropPartitions: got %d, %v", n, err)
+	}
+	if got := messages(); len(got) != 1 || got[second] != "kept" {
+		t.Errorf("after dropping the first day: got %v", got)
+	}
+}
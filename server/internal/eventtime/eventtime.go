@@ -0,0 +1,383 @@
+// Package eventtime extracts the time an event happened from its fields, as
+// configured per source: which field holds it, the layouts it may be in and
+// the zone to read zone-less values in. Events whose time is missing, does
+// not parse or lies too far from when they were received fall back to, or
+// are flagged against, the received time.
+package eventtime
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // zones must resolve on hosts without a zoneinfo database
+
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+// Layout names understood besides Go reference layouts such as
+// "2006-01-02 15:04:05".
+const (
+	LayoutRFC3339  = "rfc3339"  // RFC 3339, with or without fractional seconds
+	LayoutEpoch    = "epoch"    // Unix time, unit told apart by magnitude
+	LayoutEpochS   = "epoch_s"  // Unix seconds, fractions allowed
+	LayoutEpochMS  = "epoch_ms" // Unix milliseconds
+	LayoutEpochUS  = "epoch_us" // Unix microseconds
+	LayoutEpochNS  = "epoch_ns" // Unix nanoseconds
+	LayoutSyslog   = "syslog"   // BSD syslog "Jan _2 15:04:05", year inferred
+	LayoutFileTime = "filetime" // Windows FILETIME, 100ns ticks since 1601
+)
+
+// Out-of-range policies.
+const (
+	// Clamp replaces a time outside the accepted range with the received time.
+	Clamp = "clamp"
+	// Flag keeps a time outside the accepted range but marks it.
+	Flag = "flag"
+)
+
+// Status says where an event's time came from.
+type Status string
+
+const (
+	StatusParsed  Status = "parsed"  // from the event
+	StatusMissing Status = "missing" // no time field; received time used
+	StatusInvalid Status = "invalid" // time field did not parse; received time used
+	StatusFuture  Status = "future"  // too far ahead of the received time
+	StatusPast    Status = "past"    // too far behind the received time
+)
+
+// Defaults for a Spec that leaves them out.
+const (
+	DefaultMaxFuture = time.Hour
+	DefaultMaxPast   = 30 * 24 * time.Hour
+)
+
+// defaultFields are tried in order when a Spec names no field.
+var defaultFields = []string{"@timestamp", "timestamp", "time"}
+
+// defaultLayouts apply when a Spec lists none.
+var defaultLayouts = []string{LayoutRFC3339, LayoutEpoch}
+
+// ErrInvalidSpec is returned by Compile for a spec that cannot be used.
+var ErrInvalidSpec = errors.New("invalid timestamp config")
+
+// Spec is a source's timestamp configuration as stored. Zero values select
+// the defaults.
+type Spec struct {
+	// Field is a dotted path such as "extensions.rt".
+	Field            string   `json:"field,omitempty"`
+	Layouts          []string `json:"layouts,omitempty"`
+	Timezone         string   `json:"timezone,omitempty"`
+	MaxFutureMinutes int      `json:"max_future_minutes,omitempty"`
+	MaxPastDays      int      `json:"max_past_days,omitempty"`
+	OutOfRange       string   `json:"out_of_range,omitempty"`
+}
+
+// Extractor is a compiled Spec. It is safe for concurrent use.
+type Extractor struct {
+	fields    [][]string
+	layouts   []string
+	loc       *time.Location
+	maxFuture time.Duration
+	maxPast   time.Duration
+	clamp     bool
+}
+
+// Default is the extractor for sources without a timestamp config.
+var Default = must(Spec{}.Compile())
+
+func must(e *Extractor, err error) *Extractor {
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+// Compile validates s and returns its extractor.
+func (s Spec) Compile() (*Extractor, error) {
+	e := &Extractor{
+		layouts:   defaultLayouts,
+		loc:       time.UTC,
+		maxFuture: DefaultMaxFuture,
+		maxPast:   DefaultMaxPast,
+		clamp:     true,
+	}
+
+	if s.Field == "" {
+		for _, f := range defaultFields {
+			e.fields = append(e.fields, []string{f})
+		}
+	} else {
+		path := strings.Split(s.Field, ".")
+		if slices.Contains(path, "") {
+			return nil, fmt.Errorf("%w: field %q has an empty segment", ErrInvalidSpec, s.Field)
+		}
+		e.fields = [][]string{path}
+	}
+
+	if len(s.Layouts) > 0 {
+		for _, l := range s.Layouts {
+			if !validLayout(l) {
+				return nil, fmt.Errorf("%w: unknown layout %q", ErrInvalidSpec, l)
+			}
+		}
+		e.layouts = s.Layouts
+	}
+
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %q", ErrInvalidSpec, s.Timezone)
+		}
+		e.loc = loc
+	}
+
+	if s.MaxFutureMinutes < 0 || s.MaxPastDays < 0 {
+		return nil, fmt.Errorf("%w: limits must not be negative", ErrInvalidSpec)
+	}
+	if s.MaxFutureMinutes > 0 {
+		e.maxFuture = time.Duration(s.MaxFutureMinutes) * time.Minute
+	}
+	if s.MaxPastDays > 0 {
+		e.maxPast = time.Duration(s.MaxPastDays) * 24 * time.Hour
+	}
+
+	switch s.OutOfRange {
+	case "", Clamp:
+	case Flag:
+		e.clamp = false
+	default:
+		return nil, fmt.Errorf("%w: out_of_range must be %s or %s", ErrInvalidSpec, Clamp, Flag)
+	}
+	return e, nil
+}
+
+// layoutProbe checks that a Go layout has at least one element in it: a
+// layout without any formats to itself. It must not be the reference time.
+var layoutProbe = time.Date(2001, time.November, 12, 13, 14, 15, 0, time.UTC)
+
+func validLayout(l string) bool {
+	switch l {
+	case LayoutRFC3339, LayoutEpoch, LayoutEpochS, LayoutEpochMS, LayoutEpochUS, LayoutEpochNS,
+		LayoutSyslog, LayoutFileTime:
+		return true
+	}
+	return l != "" && layoutProbe.Format(l) != l
+}
+
+// Result is an event's time as extracted.
+type Result struct {
+	Time     time.Time
+	Received time.Time
+	Status   Status
+}
+
+// Lag is how long after it happened the event was received. It is only
+// meaningful for StatusParsed.
+func (r Result) Lag() time.Duration {
+	return r.Received.Sub(r.Time)
+}
+
+// Extract returns the time of the event with fields, received at received.
+// The first configured field present is used; its value is tried against the
+// layouts in order.
+func (e *Extractor) Extract(fields parser.Fields, received time.Time) Result {
+	received = received.UTC()
+	res := Result{Time: received, Received: received, Status: StatusMissing}
+
+	var v any
+	for _, path := range e.fields {
+		if found, ok := lookup(fields, path); ok {
+			v = found
+			break
+		}
+	}
+	if v == nil {
+		return res
+	}
+
+	t, ok := e.parse(v, received)
+	if !ok {
+		res.Status = StatusInvalid
+		return res
+	}
+	t = t.UTC()
+
+	switch {
+	case t.Sub(received) > e.maxFuture:
+		res.Status = StatusFuture
+	case received.Sub(t) > e.maxPast:
+		res.Status = StatusPast
+	default:
+		res.Time, res.Status = t, StatusParsed
+		return res
+	}
+	if !e.clamp {
+		res.Time = t
+	}
+	return res
+}
+
+// Parse reads v in the first of the configured layouts it fits, leaving out
+// the field lookup and range checks of Extract. Layouts without a year take
+// it from now.
+func (e *Extractor) Parse(v any, now time.Time) (time.Time, bool) {
+	t, ok := e.parse(v, now)
+	return t.UTC(), ok
+}
+
+func lookup(f parser.Fields, path []string) (any, bool) {
+	var cur any = f
+	for _, p := range path {
+		m, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = m[p]; !ok {
+			return nil, false
+		}
+	}
+	return cur, cur != nil
+}
+
+func (e *Extractor) parse(v any, received time.Time) (time.Time, bool) {
+	var s string
+	switch v := v.(type) {
+	case string:
+		s = strings.TrimSpace(v)
+	case json.Number:
+		s = v.String()
+	case float64:
+		s = strconv.FormatFloat(v, 'f', -1, 64)
+	case int64:
+		s = strconv.FormatInt(v, 10)
+	case int:
+		s = strconv.Itoa(v)
+	default:
+		return time.Time{}, false
+	}
+	if s == "" {
+		return time.Time{}, false
+	}
+	for _, l := range e.layouts {
+		if t, ok := e.parseLayout(l, s, received); ok {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (e *Extractor) parseLayout(layout, s string, received time.Time) (time.Time, bool) {
+	switch layout {
+	case LayoutRFC3339:
+		t, err := time.Parse(time.RFC3339Nano, s)
+		return t, err == nil
+	case LayoutEpoch:
+		return parseEpoch(s, 0)
+	case LayoutEpochS:
+		return parseEpoch(s, time.Second)
+	case LayoutEpochMS:
+		return parseEpoch(s, time.Millisecond)
+	case LayoutEpochUS:
+		return parseEpoch(s, time.Microsecond)
+	case LayoutEpochNS:
+		return parseEpoch(s, time.Nanosecond)
+	case LayoutFileTime:
+		return parseFileTime(s)
+	case LayoutSyslog:
+		t, err := time.ParseInLocation(time.Stamp, s, e.loc)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return withYear(t, received.In(e.loc)), true
+	}
+	t, err := time.ParseInLocation(layout, s, e.loc)
+	return t, err == nil
+}
+
+// parseEpoch reads Unix time in unit, or in the unit its magnitude suggests
+// when unit is 0: seconds up to 1e11 (year 5138), then milliseconds,
+// microseconds and nanoseconds.
+func parseEpoch(s string, unit time.Duration) (time.Time, bool) {
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) || f < 0 {
+		return time.Time{}, false
+	}
+	if unit == 0 {
+		switch {
+		case f < 1e11:
+			unit = time.Second
+		case f < 1e14:
+			unit = time.Millisecond
+		case f < 1e17:
+			unit = time.Microsecond
+		default:
+			unit = time.Nanosecond
+		}
+	}
+	// integers are read exactly; float64 cannot hold nanosecond epochs
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		if n > math.MaxInt64/int64(unit) {
+			return time.Time{}, false
+		}
+		return time.Unix(0, n*int64(unit)), true
+	}
+	// so are decimals, as float64 holds seconds epochs only to about a
+	// microsecond: 1792418400.123 would read as .122999808
+	if whole, frac, ok := strings.Cut(s, "."); ok && isDigits(whole) && isDigits(frac) {
+		n, err := strconv.ParseInt(whole, 10, 64)
+		if err != nil || n > math.MaxInt64/int64(unit)-1 {
+			return time.Time{}, false
+		}
+		nanos, _ := strconv.ParseInt((frac + "000000000")[:9], 10, 64)
+		return time.Unix(0, n*int64(unit)+nanos*int64(unit)/int64(time.Second)), true
+	}
+	ns := f * float64(unit)
+	if ns > math.MaxInt64 {
+		return time.Time{}, false
+	}
+	return time.Unix(0, int64(ns)), true
+}
+
+// fileTimeEpoch is 1970-01-01 in FILETIME ticks.
+const fileTimeEpoch = 116444736000000000
+
+func parseFileTime(s string) (time.Time, bool) {
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+	ticks := n - fileTimeEpoch
+	return time.Unix(ticks/1e7, ticks%1e7*100), true
+}
+
+// withYear places a year-less timestamp in the year that puts it closest to
+// now, allowing for clocks a month fast.
+func withYear(ts, now time.Time) time.Time {
+	ts = ts.AddDate(now.Year(), 0, 0)
+	switch {
+	case ts.Sub(now) > 30*24*time.Hour:
+		ts = ts.AddDate(-1, 0, 0)
+	case now.Sub(ts) > 335*24*time.Hour:
+		ts = ts.AddDate(1, 0, 0)
+	}
+	return ts
+}
+
+// isDigits reports whether s is one or more ASCII digits.
+func isDigits(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,200 @@
+package eventtime_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/internal/eventtime"
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+var received = time.Date(2025, time.March, 9, 12, 0, 0, 0, time.UTC)
+
+func compile(t *testing.T, s eventtime.Spec) *eventtime.Extractor {
+	t.Helper()
+	e, err := s.Compile()
+	if err != nil {
+		t.Fatalf("Compile(%+v): %v", s, err)
+	}
+	return e
+}
+
+func TestExtract_Layouts(t *testing.T) {
+	want := time.Date(2025, time.March, 9, 11, 58, 30, 0, time.UTC)
+
+	for _, c := range []struct {
+		name  string
+		spec  eventtime.Spec
+		value any
+		want  time.Time
+	}{
+		{"rfc3339", eventtime.Spec{}, "2025-03-09T13:58:30+02:00", want},
+		{"rfc3339 nano", eventtime.Spec{}, "2025-03-09T11:58:30.250Z", want.Add(250 * time.Millisecond)},
+		{"epoch seconds", eventtime.Spec{}, json.Number("1741521510"), want},
+		{"epoch fractional seconds", eventtime.Spec{}, 1741521510.5, want.Add(500 * time.Millisecond)},
+		{"epoch decimal seconds", eventtime.Spec{}, json.Number("1741521510.123"), want.Add(123 * time.Millisecond)},
+		{
+			"epoch decimal milliseconds",
+			eventtime.Spec{Layouts: []string{eventtime.LayoutEpochMS}},
+			"1741521510123.5",
+			want.Add(123*time.Millisecond + 500*time.Microsecond),
+		},
+		{"epoch milliseconds", eventtime.Spec{}, json.Number("1741521510123"), want.Add(123 * time.Millisecond)},
+		{"epoch microseconds", eventtime.Spec{}, "1741521510000001", want.Add(time.Microsecond)},
+		{"epoch nanoseconds", eventtime.Spec{}, json.Number("1741521510000000007"), want.Add(7)},
+		{
+			"explicit epoch_ms",
+			eventtime.Spec{Layouts: []string{eventtime.LayoutEpochMS}},
+			json.Number("1741521510000"),
+			want,
+		},
+		{
+			"filetime",
+			eventtime.Spec{Layouts: []string{eventtime.LayoutFileTime}},
+			json.Number("133859951100000000"),
+			want,
+		},
+		{
+			"syslog without year in zone",
+			eventtime.Spec{Layouts: []string{eventtime.LayoutSyslog}, Timezone: "Europe/Berlin"},
+			"Mar  9 12:58:30",
+			want,
+		},
+		{
+			"syslog single-spaced day",
+			eventtime.Spec{Layouts: []string{eventtime.LayoutSyslog}},
+			"Mar 9 11:58:30",
+			want,
+		},
+		{
+			"go layout in zone",
+			eventtime.Spec{Layouts: []string{"2006-01-02 15:04:05"}, Timezone: "America/New_York"},
+			"2025-03-09 07:58:30",
+			want,
+		},
+		{
+			"first matching layout wins",
+			eventtime.Spec{Layouts: []string{eventtime.LayoutRFC3339, "02/01/2006 15:04:05"}},
+			"09/03/2025 11:58:30",
+			want,
+		},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			res := compile(t, c.spec).Extract(parser.Fields{"timestamp": c.value}, received)
+			if res.Status != eventtime.StatusParsed || !res.Time.Equal(c.want) {
+				t.Errorf("got %v (%s), want %v", res.Time, res.Status, c.want)
+			}
+			if res.Lag() != received.Sub(c.want) {
+				t.Errorf("lag: got %v, want %v", res.Lag(), received.Sub(c.want))
+			}
+		})
+	}
+}
+
+func TestExtract_SyslogYearRollsBack(t *testing.T) {
+	e := compile(t, eventtime.Spec{Layouts: []string{eventtime.LayoutSyslog}})
+	newYear := time.Date(2026, time.January, 1, 0, 0, 5, 0, time.UTC)
+
+	res := e.Extract(parser.Fields{"timestamp": "Dec 31 23:59:58"}, newYear)
+	if want := time.Date(2025, time.December, 31, 23, 59, 58, 0, time.UTC); !res.Time.Equal(want) {
+		t.Errorf("got %v, want %v", res.Time, want)
+	}
+}
+
+func TestExtract_FieldPath(t *testing.T) {
+	e := compile(t, eventtime.Spec{Field: "extensions.rt", Layouts: []string{eventtime.LayoutEpochMS}})
+	fields := parser.Fields{
+		"timestamp":  "2020-01-01T00:00:00Z",
+		"extensions": parser.Fields{"rt": "1741521510000"},
+	}
+
+	res := e.Extract(fields, received)
+	if res.Status != eventtime.StatusParsed || res.Time.Unix() != 1741521510 {
+		t.Errorf("got %v (%s)", res.Time, res.Status)
+	}
+}
+
+func TestExtract_DefaultFieldsInOrder(t *testing.T) {
+	res := eventtime.Default.Extract(parser.Fields{
+		"time":       "2025-03-09T10:00:00Z",
+		"@timestamp": "2025-03-09T11:00:00Z",
+	}, received)
+	if res.Time.Hour() != 11 {
+		t.Errorf("want @timestamp to win, got %v", res.Time)
+	}
+}
+
+func TestExtract_FallsBackToReceived(t *testing.T) {
+	for _, c := range []struct {
+		name   string
+		fields parser.Fields
+		want   eventtime.Status
+	}{
+		{"no field", parser.Fields{"message": "hi"}, eventtime.StatusMissing},
+		{"null", parser.Fields{"timestamp": nil}, eventtime.StatusMissing},
+		{"unparseable", parser.Fields{"timestamp": "yesterday"}, eventtime.StatusInvalid},
+		{"wrong type", parser.Fields{"timestamp": true}, eventtime.StatusInvalid},
+		{"not a map on the way", parser.Fields{"timestamp": parser.Fields{}}, eventtime.StatusInvalid},
+	} {
+		res := eventtime.Default.Extract(c.fields, received)
+		if res.Status != c.want || !res.Time.Equal(received) {
+			t.Errorf("%s: got %v (%s), want received time (%s)", c.name, res.Time, res.Status, c.want)
+		}
+	}
+}
+
+func TestExtract_OutOfRange(t *testing.T) {
+	future := received.Add(2 * time.Hour).Format(time.RFC3339)
+	past := received.AddDate(0, 0, -45).Format(time.RFC3339)
+
+	clamp := eventtime.Default
+	if res := clamp.Extract(parser.Fields{"timestamp": future}, received); res.Status != eventtime.StatusFuture ||
+		!res.Time.Equal(received) {
+		t.Errorf("clamp future: got %v (%s)", res.Time, res.Status)
+	}
+	if res := clamp.Extract(parser.Fields{"timestamp": past}, received); res.Status != eventtime.StatusPast ||
+		!res.Time.Equal(received) {
+		t.Errorf("clamp past: got %v (%s)", res.Time, res.Status)
+	}
+
+	flag := compile(t, eventtime.Spec{OutOfRange: eventtime.Flag})
+	if res := flag.Extract(parser.Fields{"timestamp": future}, received); res.Status != eventtime.StatusFuture ||
+		!res.Time.Equal(received.Add(2*time.Hour)) {
+		t.Errorf("flag future: got %v (%s)", res.Time, res.Status)
+	}
+
+	wide := compile(t, eventtime.Spec{MaxFutureMinutes: 180, MaxPastDays: 60})
+	for _, v := range []string{future, past} {
+		if res := wide.Extract(parser.Fields{"timestamp": v}, received); res.Status != eventtime.StatusParsed {
+			t.Errorf("wide limits %s: got %s", v, res.Status)
+		}
+	}
+}
+
+func TestCompile_RejectsInvalidSpecs(t *testing.T) {
+	for _, s := range []eventtime.Spec{
+		{Field: "a..b"},
+		{Layouts: []string{"not a layout"}},
+		{Layouts: []string{""}},
+		{Timezone: "Mars/Olympus_Mons"},
+		{MaxPastDays: -1},
+		{OutOfRange: "drop"},
+	} {
+		if _, err := s.Compile(); !errors.Is(err, eventtime.ErrInvalidSpec) {
+			t.Errorf("Compile(%+v): want ErrInvalidSpec, got %v", s, err)
+		}
+	}
+}
+
+func TestParse_SkipsRangeChecks(t *testing.T) {
+	e := compile(t, eventtime.Spec{})
+	ts, ok := e.Parse("2001-11-12T13:14:15+01:00", received)
+	if !ok || !ts.Equal(time.Date(2001, time.November, 12, 12, 14, 15, 0, time.UTC)) || ts.Location() != time.UTC {
+		t.Errorf("Parse: want 2001-11-12T12:14:15Z, got %v (%v)", ts, ok)
+	}
+	if _, ok := e.Parse("yesterday", received); ok {
+		t.Error("Parse of text in no layout: want false")
+	}
+}
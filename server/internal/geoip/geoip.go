@@ -0,0 +1,424 @@
+// Package geoip enriches normalized events with where their IP addresses
+// are: the location and autonomous system of every endpoint with an address,
+// looked up in local GeoLite2 City and ASN databases in the MaxMind DB
+// format. Addresses in private and reserved ranges are flagged instead. The
+// databases are reloaded when their files change, so they can be updated in
+// place without a restart.
+package geoip
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/oschwald/maxminddb-golang/v2"
+
+	"github.com/luketeo/horizon/internal/ocsf"
+)
+
+// Provider names the enricher in the enrichments it adds to events.
+const Provider = "horizon-geoip"
+
+// EnrichmentType is the type of the enrichments flagging special-purpose
+// addresses.
+const EnrichmentType = "ip_scope"
+
+// Config locates the databases and tunes the enricher.
+type Config struct {
+	// CityPath and ASNPath are the GeoLite2 City and ASN databases. Either
+	// may be empty, leaving out what it would add.
+	CityPath string
+	ASNPath  string
+	// CacheSize caps the addresses whose lookups are kept; zero means 65536.
+	CacheSize int
+	// ReloadInterval is how often the files are checked for changes; zero
+	// means a minute.
+	ReloadInterval time.Duration
+}
+
+const (
+	defaultCacheSize      = 65536
+	defaultReloadInterval = time.Minute
+)
+
+// Result is what the databases know about an address.
+type Result struct {
+	Location         *ocsf.Location
+	AutonomousSystem *ocsf.AutonomousSystem
+	// Special is the special-purpose range of a private or reserved address,
+	// which the databases are not asked about.
+	Special *Range
+}
+
+// Enricher looks addresses up in the databases, keeping recent results. It
+// is safe for concurrent use.
+type Enricher struct {
+	cfg    Config
+	logger *slog.Logger
+
+	dbs     atomic.Pointer[databases]
+	checked atomic.Int64 // unix nanoseconds of the last check for changes
+	cache   *cache
+}
+
+// databases is one loaded set of databases.
+type databases struct {
+	city, asn *database
+}
+
+// database is a loaded database with the state of the file it came from.
+type database struct {
+	reader  *maxminddb.Reader
+	modTime time.Time
+	size    int64
+}
+
+// Open loads the databases cfg names. Returns an error when one does not
+// load.
+func Open(cfg Config, logger *slog.Logger) (*Enricher, error) {
+	if cfg.CacheSize <= 0 {
+		cfg.CacheSize = defaultCacheSize
+	}
+	if cfg.ReloadInterval <= 0 {
+		cfg.ReloadInterval = defaultReloadInterval
+	}
+	e := &Enricher{cfg: cfg, logger: logger, cache: newCache(cfg.CacheSize)}
+	dbs := &databases{}
+	var err error
+	if dbs.city, err = load(cfg.CityPath, nil); err != nil {
+		return nil, err
+	}
+	if dbs.asn, err = load(cfg.ASNPath, nil); err != nil {
+		return nil, err
+	}
+	e.dbs.Store(dbs)
+	e.checked.Store(time.Now().UnixNano())
+	return e, nil
+}
+
+// load reads the database at path, or returns current when the file has not
+// changed since current was read from it. An empty path loads nothing.
+func load(path string, current *database) (*database, error) {
+	if path == "" {
+		return nil, nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening GeoIP database: %w", err)
+	}
+	if current != nil && info.ModTime().Equal(current.modTime) && info.Size() == current.size {
+		return current, nil
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading GeoIP database: %w", err)
+	}
+	r, err := maxminddb.OpenBytes(b)
+	if err != nil {
+		return nil, fmt.Errorf("loading GeoIP database %s: %w", path, err)
+	}
+	return &database{reader: r, modTime: info.ModTime(), size: info.Size()}, nil
+}
+
+// reload swaps in the databases whose files changed, at most once per
+// ReloadInterval. A file that no longer loads, as while it is being
+// replaced, keeps the database loaded before.
+func (e *Enricher) reload() {
+	now := time.Now().UnixNano()
+	last := e.checked.Load()
+	if now-last < int64(e.cfg.ReloadInterval) || !e.checked.CompareAndSwap(last, now) {
+		return
+	}
+	current := e.dbs.Load()
+	next := &databases{}
+	var errs []error
+	var err error
+	if next.city, err = load(e.cfg.CityPath, current.city); err != nil {
+		next.city, errs = current.city, append(errs, err)
+	}
+	if next.asn, err = load(e.cfg.ASNPath, current.asn); err != nil {
+		next.asn, errs = current.asn, append(errs, err)
+	}
+	if err := errors.Join(errs...); err != nil {
+		e.logger.Warn("keeping GeoIP databases loaded before", slog.Any("err", err))
+	}
+	if next.city == current.city && next.asn == current.asn {
+		return
+	}
+	e.dbs.Store(next)
+	e.cache.purge()
+	e.logger.Info("reloaded GeoIP databases",
+		slog.String("city", e.cfg.CityPath),
+		slog.String("asn", e.cfg.ASNPath),
+	)
+}
+
+// Lookup returns what the databases know about addr.
+func (e *Enricher) Lookup(addr netip.Addr) Result {
+	e.reload()
+	addr = addr.Unmap()
+	if res, ok := e.cache.get(addr); ok {
+		return res
+	}
+	var res Result
+	if r, ok := Special(addr); ok {
+		res.Special = &r
+		e.cache.put(addr, res)
+		return res
+	}
+	dbs := e.dbs.Load()
+	if dbs.city != nil {
+		var rec cityRecord
+		if found, err := dbs.city.lookup(addr, &rec); err != nil {
+			e.logger.Warn("GeoIP city lookup failed", slog.String("ip", addr.String()), slog.Any("err", err))
+		} else if found {
+			res.Location = location(&rec)
+		}
+	}
+	if dbs.asn != nil {
+		var rec asnRecord
+		if found, err := dbs.asn.lookup(addr, &rec); err != nil {
+			e.logger.Warn("GeoIP ASN lookup failed", slog.String("ip", addr.String()), slog.Any("err", err))
+		} else if found {
+			res.AutonomousSystem = autonomousSystem(&rec)
+		}
+	}
+	e.cache.put(addr, res)
+	return res
+}
+
+// lookup decodes the record of the network addr is in into v, and reports
+// whether there is one.
+func (d *database) lookup(addr netip.Addr, v any) (bool, error) {
+	r := d.reader.Lookup(addr)
+	if err := r.Decode(v); err != nil {
+		return false, err
+	}
+	return r.Found(), nil
+}
+
+// cityRecord is what the enricher reads of a GeoLite2 City record.
+type cityRecord struct {
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	RegisteredCountry struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"registered_country"`
+	Continent struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"continent"`
+	Postal struct {
+		Code string `maxminddb:"code"`
+	} `maxminddb:"postal"`
+	Location struct {
+		Longitude *float64 `maxminddb:"longitude"`
+		Latitude  *float64 `maxminddb:"latitude"`
+	} `maxminddb:"location"`
+}
+
+// asnRecord is a GeoLite2 ASN record.
+type asnRecord struct {
+	Number       uint   `maxminddb:"autonomous_system_number"`
+	Organization string `maxminddb:"autonomous_system_organization"`
+}
+
+// location reads a GeoLite2 City record. Region is the code of the largest
+// subdivision, as OCSF defines it.
+func location(rec *cityRecord) *ocsf.Location {
+	loc := &ocsf.Location{
+		City:       rec.City.Names["en"],
+		Country:    rec.Country.ISOCode,
+		Continent:  rec.Continent.Names["en"],
+		PostalCode: rec.Postal.Code,
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = rec.Subdivisions[0].ISOCode
+	}
+	if loc.Country == "" {
+		loc.Country = rec.RegisteredCountry.ISOCode
+	}
+	if rec.Location.Longitude != nil && rec.Location.Latitude != nil {
+		loc.Coordinates = []float64{*rec.Location.Longitude, *rec.Location.Latitude}
+	}
+	if loc.City == "" && loc.Country == "" && loc.Continent == "" && loc.Coordinates == nil {
+		return nil
+	}
+	return loc
+}
+
+// autonomousSystem reads a GeoLite2 ASN record.
+func autonomousSystem(rec *asnRecord) *ocsf.AutonomousSystem {
+	as := &ocsf.AutonomousSystem{
+		Number: int(rec.Number), //nolint:gosec // AS numbers are 32-bit
+		Name:   rec.Organization,
+	}
+	if *as == (ocsf.AutonomousSystem{}) {
+		return nil
+	}
+	return as
+}
+
+// ── Events ───────────────────────────────────────────────────────────────────
+
+var (
+	endpointType = reflect.TypeFor[ocsf.Endpoint]()
+	deviceType   = reflect.TypeFor[ocsf.Device]()
+)
+
+// Enrich adds the location and autonomous system of every endpoint of ev
+// with an IP address, and the location of its devices, leaving what the
+// event already says. Special-purpose addresses get an enrichment naming
+// their range instead.
+func (e *Enricher) Enrich(_ context.Context, _ uuid.UUID, ev ocsf.Event) {
+	base := ev.Common()
+	walk(reflect.ValueOf(ev), "", func(path string, v reflect.Value) {
+		var ip string
+		var loc **ocsf.Location
+		var as **ocsf.AutonomousSystem
+		switch obj := v.Addr().Interface().(type) {
+		case *ocsf.Endpoint:
+			ip, loc, as = obj.IP, &obj.Location, &obj.AutonomousSystem
+		case *ocsf.Device:
+			ip, loc = obj.IP, &obj.Location
+		}
+		addr, err := netip.ParseAddr(ip)
+		if err != nil {
+			return
+		}
+		res := e.Lookup(addr)
+		if res.Special != nil {
+			base.Enrichments = append(base.Enrichments, ocsf.Enrichment{
+				Name:     path + ".ip",
+				Value:    ip,
+				Type:     EnrichmentType,
+				Provider: Provider,
+				Data: map[string]any{
+					"scope":   res.Special.Scope,
+					"range":   res.Special.Name,
+					"network": res.Special.Prefix.String(),
+				},
+			})
+			return
+		}
+		if *loc == nil && res.Location != nil {
+			l := *res.Location
+			*loc = &l
+		}
+		if as != nil && *as == nil && res.AutonomousSystem != nil {
+			a := *res.AutonomousSystem
+			*as = &a
+		}
+	})
+}
+
+// walk calls fn with every endpoint and device in v, and its path in the
+// event. Unmapped attributes are not looked in.
+func walk(v reflect.Value, path string, fn func(path string, v reflect.Value)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			walk(v.Elem(), path, fn)
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			walk(v.Index(i), path, fn)
+		}
+	case reflect.Struct:
+		if v.Type() == endpointType || v.Type() == deviceType {
+			fn(path, v)
+			return
+		}
+		t := v.Type()
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			child := path
+			if !f.Anonymous {
+				name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+				child = strings.TrimPrefix(path+"."+name, ".")
+			}
+			walk(v.Field(i), child, fn)
+		}
+	}
+}
+
+// ── Cache ────────────────────────────────────────────────────────────────────
+
+// cache keeps lookup results by address, dropping the least recently used
+// beyond its size.
+type cache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // of *cached, most recently used first
+	entries map[netip.Addr]*list.Element
+}
+
+type cached struct {
+	addr netip.Addr
+	res  Result
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, order: list.New(), entries: make(map[netip.Addr]*list.Element)}
+}
+
+func (c *cache) get(addr netip.Addr) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[addr]
+	if !ok {
+		return Result{}, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*cached).res, true
+}
+
+func (c *cache) put(addr netip.Addr, res Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[addr]; ok {
+		el.Value.(*cached).res = res
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[addr] = c.order.PushFront(&cached{addr: addr, res: res})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cached).addr)
+	}
+}
+
+func (c *cache) purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// Len returns the number of addresses whose lookups are cached.
+func (e *Enricher) Len() int {
+	e.cache.mu.Lock()
+	defer e.cache.mu.Unlock()
+	return e.cache.order.Len()
+}
@@ -0,0 +1,200 @@
+package geoip_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/netip"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/geoip"
+	"github.com/luketeo/horizon/internal/geoip/geoiptest"
+	"github.com/luketeo/horizon/internal/ocsf"
+)
+
+var (
+	london = geoiptest.City{
+		Name: "London", Subdivision: "ENG", Country: "GB", Continent: "Europe",
+		PostalCode: "EC1A", Longitude: -0.0931, Latitude: 51.5142,
+	}
+	boxford = geoiptest.City{
+		Name: "Boxford", Subdivision: "WBK", Country: "GB", Continent: "Europe", Longitude: -1.25, Latitude: 51.75,
+	}
+	linköping = geoiptest.City{Name: "Linköping", Subdivision: "E", Country: "SE", Continent: "Europe"}
+)
+
+func cityDB() geoiptest.Database {
+	return geoiptest.GeoLite2City(map[string]geoiptest.City{
+		"81.2.69.0/24":     london,
+		"2a02:cf40::/29":   linköping,
+		"89.160.20.112/28": linköping,
+	})
+}
+
+func asnDB() geoiptest.Database {
+	return geoiptest.GeoLite2ASN(map[string]geoiptest.AS{
+		"81.2.69.0/24":     {Number: 20712, Organization: "Andrews & Arnold Ltd"},
+		"89.160.20.112/28": {Number: 29518, Organization: "Bredband2 AB"},
+	})
+}
+
+func open(t *testing.T, cfg geoip.Config) *geoip.Enricher {
+	t.Helper()
+	e, err := geoip.Open(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	return e
+}
+
+func TestEnrich_AddsLocationAndAutonomousSystem(t *testing.T) {
+	e := open(t, geoip.Config{
+		CityPath: geoiptest.Write(t, "city.mmdb", cityDB()),
+		ASNPath:  geoiptest.Write(t, "asn.mmdb", asnDB()),
+	})
+	mine := &ocsf.Location{City: "Our office"}
+	ev := &ocsf.NetworkActivity{
+		BaseEvent: ocsf.BaseEvent{Device: &ocsf.Device{IP: "89.160.20.120"}},
+		Network: ocsf.Network{
+			SrcEndpoint: &ocsf.Endpoint{IP: "81.2.69.142"},
+			DstEndpoint: &ocsf.Endpoint{IP: "2a02:cf40::9", Location: mine},
+		},
+	}
+
+	e.Enrich(context.Background(), uuid.New(), ev)
+
+	src := ev.SrcEndpoint
+	if src.Location == nil || src.Location.City != "London" || src.Location.Region != "ENG" ||
+		src.Location.Country != "GB" || src.Location.Continent != "Europe" || src.Location.PostalCode != "EC1A" ||
+		!slices.Equal(src.Location.Coordinates, []float64{-0.0931, 51.5142}) {
+		t.Errorf("src_endpoint.location: got %+v", src.Location)
+	}
+	if src.AutonomousSystem == nil || *src.AutonomousSystem != (ocsf.AutonomousSystem{
+		Name: "Andrews & Arnold Ltd", Number: 20712,
+	}) {
+		t.Errorf("src_endpoint.autonomous_system: got %+v", src.AutonomousSystem)
+	}
+	if ev.DstEndpoint.Location != mine || ev.DstEndpoint.AutonomousSystem != nil {
+		t.Errorf("dst_endpoint: want its own location kept and no AS, got %+v", ev.DstEndpoint)
+	}
+	if ev.Device.Location == nil || ev.Device.Location.City != "Linköping" {
+		t.Errorf("device.location: got %+v", ev.Device.Location)
+	}
+	if len(ev.Enrichments) != 0 {
+		t.Errorf("Enrichments: want none for public addresses, got %+v", ev.Enrichments)
+	}
+}
+
+func TestEnrich_FlagsSpecialPurposeAddresses(t *testing.T) {
+	e := open(t, geoip.Config{CityPath: geoiptest.Write(t, "city.mmdb", cityDB())})
+	ev := &ocsf.Authentication{
+		SrcEndpoint: &ocsf.Endpoint{IP: "192.168.1.20"},
+		DstEndpoint: &ocsf.Endpoint{IP: "::1"},
+	}
+
+	e.Enrich(context.Background(), uuid.New(), ev)
+
+	if ev.SrcEndpoint.Location != nil || ev.DstEndpoint.Location != nil {
+		t.Errorf("special-purpose addresses were located: %+v, %+v", ev.SrcEndpoint, ev.DstEndpoint)
+	}
+	want := map[string][3]string{
+		"src_endpoint.ip": {"192.168.1.20", geoip.ScopePrivate, "192.168.0.0/16"},
+		"dst_endpoint.ip": {"::1", geoip.ScopeReserved, "::1/128"},
+	}
+	if len(ev.Enrichments) != len(want) {
+		t.Fatalf("Enrichments: want %d, got %+v", len(want), ev.Enrichments)
+	}
+	for _, en := range ev.Enrichments {
+		w, ok := want[en.Name]
+		if !ok || en.Value != w[0] || en.Data["scope"] != w[1] || en.Data["network"] != w[2] ||
+			en.Provider != geoip.Provider || en.Type != geoip.EnrichmentType {
+			t.Errorf("Enrichment %s: want %v, got %+v", en.Name, w, en)
+		}
+	}
+}
+
+func TestSpecial(t *testing.T) {
+	cases := map[string]string{
+		"10.1.2.3":         geoip.ScopePrivate,
+		"172.31.255.255":   geoip.ScopePrivate,
+		"100.64.0.1":       geoip.ScopePrivate,
+		"fd00::1":          geoip.ScopePrivate,
+		"::ffff:10.0.0.1":  geoip.ScopePrivate,
+		"127.0.0.1":        geoip.ScopeReserved,
+		"169.254.169.254":  geoip.ScopeReserved,
+		"198.51.100.7":     geoip.ScopeReserved,
+		"239.255.255.250":  geoip.ScopeReserved,
+		"255.255.255.255":  geoip.ScopeReserved,
+		"fe80::1":          geoip.ScopeReserved,
+		"2001:db8::1":      geoip.ScopeReserved,
+		"8.8.8.8":          "",
+		"172.32.0.1":       "",
+		"2606:4700::1111":  "",
+		"::ffff:81.2.69.1": "",
+	}
+	for ip, want := range cases {
+		r, ok := geoip.Special(netip.MustParseAddr(ip))
+		if got := r.Scope; got != want || ok != (want != "") {
+			t.Errorf("Special(%s): want %q, got %q", ip, want, got)
+		}
+	}
+}
+
+func TestLookup_CachesResults(t *testing.T) {
+	e := open(t, geoip.Config{CityPath: geoiptest.Write(t, "city.mmdb", cityDB()), CacheSize: 2})
+
+	for _, ip := range []string{"81.2.69.1", "81.2.69.2", "81.2.69.1", "10.0.0.1", "8.8.8.8"} {
+		e.Lookup(netip.MustParseAddr(ip))
+	}
+	if n := e.Len(); n != 2 {
+		t.Errorf("Len: want the cache capped at 2, got %d", n)
+	}
+	if res := e.Lookup(netip.MustParseAddr("81.2.69.1")); res.Location == nil || res.Location.City != "London" {
+		t.Errorf("Lookup: got %+v", res)
+	}
+}
+
+func TestLookup_ReloadsChangedDatabase(t *testing.T) {
+	path := geoiptest.Write(t, "city.mmdb", cityDB())
+	e := open(t, geoip.Config{CityPath: path, ReloadInterval: time.Nanosecond})
+	addr := netip.MustParseAddr("81.2.69.1")
+	if res := e.Lookup(addr); res.Location == nil || res.Location.City != "London" {
+		t.Fatalf("Lookup: got %+v", res.Location)
+	}
+
+	// a file being replaced does not load, and the database before is kept
+	if err := os.WriteFile(path, []byte("partial"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if res := e.Lookup(addr); res.Location == nil || res.Location.City != "London" {
+		t.Errorf("Lookup while replacing: want London kept, got %+v", res.Location)
+	}
+
+	moved := geoiptest.GeoLite2City(map[string]geoiptest.City{"81.2.69.0/24": boxford})
+	if err := os.WriteFile(path, geoiptest.Build(moved), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if res := e.Lookup(addr); res.Location == nil || res.Location.City != "Boxford" {
+		t.Errorf("Lookup after reload: want Boxford, got %+v", res.Location)
+	}
+}
+
+func TestOpen_InvalidDatabase(t *testing.T) {
+	path := t.TempDir() + "/city.mmdb"
+	if err := os.WriteFile(path, []byte("not a database"), 0o600); err != nil {
+		t.Fatalf("WriteFile: %v", err)
+	}
+	if _, err := geoip.Open(geoip.Config{CityPath: path}, slog.Default()); err == nil {
+		t.Error("Open: want an error for an invalid database")
+	}
+	if _, err := geoip.Open(geoip.Config{ASNPath: path + ".missing"}, slog.Default()); err == nil {
+		t.Error("Open: want an error for a missing database")
+	}
+}
@@ -0,0 +1,164 @@
+// Package geoiptest builds small MaxMind DB files for tests with MaxMind's
+// writer, in the format GeoLite2 databases are distributed in.
+package geoiptest
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/maxmind/mmdbwriter"
+	"github.com/maxmind/mmdbwriter/mmdbtype"
+)
+
+// Database is the content of a MaxMind DB: its type and the record of each
+// network. Records are maps, slices, strings, bools, float64s, float32s,
+// uint16s, uint32s, uint64s and ints, which are stored as int32.
+type Database struct {
+	Type string
+	// RecordSize is 24, 28 or 32; zero means 28, as in GeoLite2 City.
+	RecordSize int
+	Networks   map[string]any
+}
+
+// Write writes db to name in t's temporary directory and returns its path.
+func Write(t testing.TB, name string, db Database) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, Build(db), 0o600); err != nil {
+		t.Fatalf("writing %s: %v", name, err)
+	}
+	return path
+}
+
+// City is where the addresses of a network are.
+type City struct {
+	Name        string
+	Subdivision string // ISO 3166-2 code, without the country
+	Country     string // ISO 3166-1 alpha-2 code
+	Continent   string
+	PostalCode  string
+	Longitude   float64
+	Latitude    float64
+}
+
+// GeoLite2City returns a city database with the city of each network.
+func GeoLite2City(cities map[string]City) Database {
+	db := Database{Type: "GeoLite2-City", Networks: map[string]any{}}
+	for network, c := range cities {
+		db.Networks[network] = map[string]any{
+			"city":         map[string]any{"names": map[string]any{"en": c.Name}},
+			"subdivisions": []any{map[string]any{"iso_code": c.Subdivision}},
+			"country":      map[string]any{"iso_code": c.Country, "names": map[string]any{"en": c.Country}},
+			"continent":    map[string]any{"names": map[string]any{"en": c.Continent}},
+			"postal":       map[string]any{"code": c.PostalCode},
+			"location": map[string]any{
+				"longitude":       c.Longitude,
+				"latitude":        c.Latitude,
+				"accuracy_radius": uint16(20),
+			},
+		}
+	}
+	return db
+}
+
+// AS is the autonomous system announcing a network.
+type AS struct {
+	Number       uint32
+	Organization string
+}
+
+// GeoLite2ASN returns an ASN database with the AS of each network.
+func GeoLite2ASN(networks map[string]AS) Database {
+	db := Database{Type: "GeoLite2-ASN", RecordSize: 24, Networks: map[string]any{}}
+	for network, as := range networks {
+		db.Networks[network] = map[string]any{
+			"autonomous_system_number":       as.Number,
+			"autonomous_system_organization": as.Organization,
+		}
+	}
+	return db
+}
+
+// Build returns db encoded as a MaxMind DB with an IPv6 search tree. It
+// panics on a network that does not parse or a record of an unsupported type.
+func Build(db Database) []byte {
+	tree, err := mmdbwriter.New(mmdbwriter.Options{
+		DatabaseType: db.Type,
+		Description:  map[string]string{"en": "Test " + db.Type},
+		Languages:    []string{"en"},
+		RecordSize:   db.RecordSize,
+		BuildEpoch:   1741521510,
+	})
+	if err != nil {
+		panic(err)
+	}
+	// Wider networks first, so narrower ones replace the part they cover.
+	networks := slices.SortedFunc(maps.Keys(db.Networks), func(a, b string) int {
+		return bits(netip.MustParsePrefix(a)) - bits(netip.MustParsePrefix(b))
+	})
+	for _, network := range networks {
+		_, n, err := net.ParseCIDR(network)
+		if err != nil {
+			panic(err)
+		}
+		if err := tree.Insert(n, value(db.Networks[network])); err != nil {
+			panic(fmt.Sprintf("geoiptest: inserting %s: %v", network, err))
+		}
+	}
+	var out bytes.Buffer
+	if _, err := tree.WriteTo(&out); err != nil {
+		panic(err)
+	}
+	return out.Bytes()
+}
+
+// bits returns the prefix length of p in the IPv6 tree.
+func bits(p netip.Prefix) int {
+	if p.Addr().Is4() {
+		return p.Bits() + 96
+	}
+	return p.Bits()
+}
+
+// value returns v as the MaxMind DB type it is stored as.
+func value(v any) mmdbtype.DataType {
+	switch v := v.(type) {
+	case map[string]any:
+		m := make(mmdbtype.Map, len(v))
+		for k, e := range v {
+			m[mmdbtype.String(k)] = value(e)
+		}
+		return m
+	case []any:
+		a := make(mmdbtype.Slice, len(v))
+		for i, e := range v {
+			a[i] = value(e)
+		}
+		return a
+	case string:
+		return mmdbtype.String(v)
+	case bool:
+		return mmdbtype.Bool(v)
+	case float64:
+		return mmdbtype.Float64(v)
+	case float32:
+		return mmdbtype.Float32(v)
+	case uint16:
+		return mmdbtype.Uint16(v)
+	case uint32:
+		return mmdbtype.Uint32(v)
+	case uint64:
+		return mmdbtype.Uint64(v)
+	case int:
+		return mmdbtype.Int32(v) //nolint:gosec // test records are small
+	default:
+		panic(fmt.Sprintf("geoiptest: unsupported record type %T", v))
+	}
+}
@@ -0,0 +1,59 @@
+package geoip
+
+import "net/netip"
+
+// Scopes of the special-purpose ranges.
+const (
+	ScopePrivate  = "private"
+	ScopeReserved = "reserved"
+)
+
+// Range is a special-purpose address range from the IANA registries, whose
+// addresses are not routed on the internet and so have no location.
+type Range struct {
+	Prefix netip.Prefix
+	Name   string
+	// Scope is ScopePrivate for ranges networks number their own hosts from,
+	// and ScopeReserved for the rest.
+	Scope string
+}
+
+// specialRanges lists the special-purpose ranges, narrower ones before those
+// containing them.
+var specialRanges = []Range{
+	{netip.MustParsePrefix("0.0.0.0/8"), "This network", ScopeReserved},
+	{netip.MustParsePrefix("10.0.0.0/8"), "Private-Use", ScopePrivate},
+	{netip.MustParsePrefix("100.64.0.0/10"), "Shared Address Space", ScopePrivate},
+	{netip.MustParsePrefix("127.0.0.0/8"), "Loopback", ScopeReserved},
+	{netip.MustParsePrefix("169.254.0.0/16"), "Link Local", ScopeReserved},
+	{netip.MustParsePrefix("172.16.0.0/12"), "Private-Use", ScopePrivate},
+	{netip.MustParsePrefix("192.0.0.0/24"), "IETF Protocol Assignments", ScopeReserved},
+	{netip.MustParsePrefix("192.0.2.0/24"), "Documentation (TEST-NET-1)", ScopeReserved},
+	{netip.MustParsePrefix("192.168.0.0/16"), "Private-Use", ScopePrivate},
+	{netip.MustParsePrefix("198.18.0.0/15"), "Benchmarking", ScopeReserved},
+	{netip.MustParsePrefix("198.51.100.0/24"), "Documentation (TEST-NET-2)", ScopeReserved},
+	{netip.MustParsePrefix("203.0.113.0/24"), "Documentation (TEST-NET-3)", ScopeReserved},
+	{netip.MustParsePrefix("224.0.0.0/4"), "Multicast", ScopeReserved},
+	{netip.MustParsePrefix("255.255.255.255/32"), "Limited Broadcast", ScopeReserved},
+	{netip.MustParsePrefix("240.0.0.0/4"), "Reserved", ScopeReserved},
+	{netip.MustParsePrefix("::/128"), "Unspecified Address", ScopeReserved},
+	{netip.MustParsePrefix("::1/128"), "Loopback Address", ScopeReserved},
+	{netip.MustParsePrefix("64:ff9b:1::/48"), "IPv4-IPv6 Translation", ScopePrivate},
+	{netip.MustParsePrefix("100::/64"), "Discard-Only Address Block", ScopeReserved},
+	{netip.MustParsePrefix("2001:db8::/32"), "Documentation", ScopeReserved},
+	{netip.MustParsePrefix("fc00::/7"), "Unique-Local", ScopePrivate},
+	{netip.MustParsePrefix("fe80::/10"), "Link-Local Unicast", ScopeReserved},
+	{netip.MustParsePrefix("ff00::/8"), "Multicast", ScopeReserved},
+}
+
+// Special returns the special-purpose range addr is in. IPv4-mapped IPv6
+// addresses are looked up as IPv4.
+func Special(addr netip.Addr) (Range, bool) {
+	addr = addr.Unmap()
+	for _, r := range specialRanges {
+		if r.Prefix.Contains(addr) {
+			return r, true
+		}
+	}
+	return Range{}, false
+}
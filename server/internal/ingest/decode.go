@@ -0,0 +1,236 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// Decoding failures, mapped to HTTP statuses by the handler.
+var (
+	ErrUnsupportedType     = errors.New("unsupported content type")
+	ErrUnsupportedEncoding = errors.New("unsupported content encoding")
+	ErrTooLarge            = errors.New("body exceeds size limit")
+	ErrMalformed           = errors.New("malformed body")
+)
+
+// maxRejections bounds the rejection reasons reported back for one batch; the
+// count of rejected events is always exact.
+const maxRejections = 100
+
+// Batch is a decoded request body: the events to queue and what was rejected.
+type Batch struct {
+	Events   []json.RawMessage
+	Rejected int
+	Errors   []oapi.IngestRejection
+}
+
+func (b *Batch) add(i int, raw []byte) {
+	if len(raw) == 0 || raw[0] != '{' {
+		b.reject(i, "not a JSON object")
+		return
+	}
+	b.Events = append(b.Events, json.RawMessage(raw))
+}
+
+func (b *Batch) reject(i int, reason string) {
+	b.Rejected++
+	if len(b.Errors) < maxRejections {
+		b.Errors = append(b.Errors, oapi.IngestRejection{Index: i, Error: reason})
+	}
+}
+
+// Result is the acknowledgement sent for the batch.
+func (b Batch) Result() oapi.IngestResult {
+	errs := b.Errors
+	if errs == nil {
+		errs = []oapi.IngestRejection{}
+	}
+	return oapi.IngestResult{Accepted: len(b.Events), Rejected: b.Rejected, Errors: errs}
+}
+
+// Decode reads a batch of events from body. contentType selects a JSON array
+// (or a single object) or NDJSON; contentEncoding may be gzip or zstd. At most
+// limit bytes are read after decompression. Elements that are not JSON
+// objects are rejected individually; a JSON body that does not parse fails as
+// a whole with ErrMalformed, while a bad NDJSON line only rejects that line.
+func Decode(body io.Reader, contentType, contentEncoding string, limit int64) (Batch, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return Batch{}, ErrUnsupportedType
+	}
+	ndjson := false
+	switch mediaType {
+	case "application/json":
+	case "application/x-ndjson", "application/ndjson":
+		ndjson = true
+	default:
+		return Batch{}, ErrUnsupportedType
+	}
+
+	r, closeFn, err := decompress(body, contentEncoding, limit)
+	if err != nil {
+		return Batch{}, err
+	}
+	defer closeFn()
+	br := bufio.NewReader(&limitReader{r: r, n: limit})
+
+	if ndjson {
+		return decodeNDJSON(br)
+	}
+	return decodeJSON(br)
+}
+
+func decompress(body io.Reader, encoding string, limit int64) (io.Reader, func(), error) {
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return body, func() {}, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(body)
+		if err != nil {
+			return nil, nil, readErr(err)
+		}
+		return zr, func() { _ = zr.Close() }, nil
+	case "zstd":
+		zr, err := zstd.NewReader(
+			body,
+			zstd.WithDecoderConcurrency(1),
+			zstd.WithDecoderMaxMemory(uint64(limit)),
+		)
+		if err != nil {
+			return nil, nil, readErr(err)
+		}
+		return zr, zr.Close, nil
+	default:
+		return nil, nil, ErrUnsupportedEncoding
+	}
+}
+
+func decodeJSON(br *bufio.Reader) (Batch, error) {
+	var b Batch
+	first, err := peekNonSpace(br)
+	if err != nil {
+		return Batch{}, readErr(err)
+	}
+	dec := json.NewDecoder(br)
+
+	if first == '{' {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return Batch{}, readErr(err)
+		}
+		b.add(0, raw)
+		return b, expectEOF(dec)
+	}
+	if first != '[' {
+		return Batch{}, fmt.Errorf("%w: expected a JSON array or object", ErrMalformed)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return Batch{}, readErr(err)
+	}
+	for i := 0; dec.More(); i++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return Batch{}, readErr(err)
+		}
+		b.add(i, raw)
+	}
+	if _, err := dec.Token(); err != nil {
+		return Batch{}, readErr(err)
+	}
+	return b, expectEOF(dec)
+}
+
+func decodeNDJSON(br *bufio.Reader) (Batch, error) {
+	var b Batch
+	for i := 0; ; {
+		line, err := br.ReadBytes('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return Batch{}, readErr(err)
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			if json.Valid(line) {
+				b.add(i, line)
+			} else {
+				b.reject(i, "invalid JSON")
+			}
+			i++
+		}
+		if err != nil {
+			return b, nil
+		}
+	}
+}
+
+// peekNonSpace skips leading whitespace and returns the next byte unread.
+func peekNonSpace(br *bufio.Reader) (byte, error) {
+	for {
+		c, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch c {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return c, br.UnreadByte()
+	}
+}
+
+func expectEOF(dec *json.Decoder) error {
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if err == nil {
+			return fmt.Errorf("%w: trailing data after JSON value", ErrMalformed)
+		}
+		return readErr(err)
+	}
+	return nil
+}
+
+// readErr classifies an error from reading the body: size limit breaches,
+// decompressed or on the wire, become ErrTooLarge and anything else is
+// malformed input.
+func readErr(err error) error {
+	var maxBytes *http.MaxBytesError
+	if errors.Is(err, ErrTooLarge) || errors.As(err, &maxBytes) {
+		return ErrTooLarge
+	}
+	if errors.Is(err, io.EOF) {
+		err = io.ErrUnexpectedEOF
+	}
+	return fmt.Errorf("%w: %v", ErrMalformed, err)
+}
+
+// limitReader fails with ErrTooLarge once more than n bytes are read, unlike
+// io.LimitReader which silently truncates.
+type limitReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitReader) Read(p []byte) (int, error) {
+	if l.n < 0 {
+		return 0, ErrTooLarge
+	}
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrTooLarge
+	}
+	return n, err
+}
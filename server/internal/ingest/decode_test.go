@@ -0,0 +1,137 @@
+package ingest_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/luketeo/horizon/internal/ingest"
+)
+
+const limit = 1 << 20
+
+func TestDecode_JSONArrayRejectsNonObjects(t *testing.T) {
+	body := `[{"msg":"a"}, 42, {"msg":"b"}, "x"]`
+	b, err := ingest.Decode(strings.NewReader(body), "application/json", "", limit)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(b.Events) != 2 || b.Rejected != 2 {
+		t.Fatalf("want 2 accepted / 2 rejected, got %d / %d", len(b.Events), b.Rejected)
+	}
+	if string(b.Events[1]) != `{"msg":"b"}` {
+		t.Errorf("second event: got %s", b.Events[1])
+	}
+	if b.Errors[0].Index != 1 || b.Errors[1].Index != 3 {
+		t.Errorf("rejection indexes: got %+v", b.Errors)
+	}
+}
+
+func TestDecode_SingleJSONObject(t *testing.T) {
+	b, err := ingest.Decode(strings.NewReader(` {"msg":"only"} `), "application/json; charset=utf-8", "", limit)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(b.Events) != 1 {
+		t.Fatalf("want 1 event, got %d", len(b.Events))
+	}
+}
+
+func TestDecode_MalformedJSONFailsWholeBatch(t *testing.T) {
+	for _, body := range []string{`[{"msg":"a"},`, `{"a":1} {"b":2}`, `nope`, ``} {
+		_, err := ingest.Decode(strings.NewReader(body), "application/json", "", limit)
+		if !errors.Is(err, ingest.ErrMalformed) {
+			t.Errorf("body %q: want ErrMalformed, got %v", body, err)
+		}
+	}
+}
+
+func TestDecode_NDJSONRejectsBadLines(t *testing.T) {
+	body := "{\"msg\":\"a\"}\n\nnot json\n[1]\r\n{\"msg\":\"b\"}"
+	b, err := ingest.Decode(strings.NewReader(body), "application/x-ndjson", "", limit)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	if len(b.Events) != 2 || b.Rejected != 2 {
+		t.Fatalf("want 2 accepted / 2 rejected, got %d / %d", len(b.Events), b.Rejected)
+	}
+	if b.Errors[0].Index != 1 || b.Errors[0].Error != "invalid JSON" {
+		t.Errorf("first rejection: got %+v", b.Errors[0])
+	}
+	if b.Errors[1].Index != 2 || b.Errors[1].Error != "not a JSON object" {
+		t.Errorf("second rejection: got %+v", b.Errors[1])
+	}
+}
+
+func TestDecode_Compressed(t *testing.T) {
+	body := []byte(`[{"msg":"a"},{"msg":"b"}]`)
+
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write(body)
+	_ = gw.Close()
+
+	zw, err := zstd.NewWriter(nil)
+	if err != nil {
+		t.Fatalf("zstd writer: %v", err)
+	}
+	zs := zw.EncodeAll(body, nil)
+	_ = zw.Close()
+
+	for encoding, data := range map[string][]byte{"gzip": gz.Bytes(), "zstd": zs} {
+		b, err := ingest.Decode(bytes.NewReader(data), "application/json", encoding, limit)
+		if err != nil {
+			t.Fatalf("%s: Decode: %v", encoding, err)
+		}
+		if len(b.Events) != 2 {
+			t.Errorf("%s: want 2 events, got %d", encoding, len(b.Events))
+		}
+	}
+}
+
+func TestDecode_DecompressedSizeIsLimited(t *testing.T) {
+	var gz bytes.Buffer
+	gw := gzip.NewWriter(&gz)
+	_, _ = gw.Write([]byte(`[{"pad":"` + strings.Repeat("a", 4096) + `"}]`))
+	_ = gw.Close()
+
+	_, err := ingest.Decode(&gz, "application/json", "gzip", 1024)
+	if !errors.Is(err, ingest.ErrTooLarge) {
+		t.Fatalf("want ErrTooLarge, got %v", err)
+	}
+
+	_, err = ingest.Decode(strings.NewReader(strings.Repeat("{}\n", 100)), "application/x-ndjson", "", 64)
+	if !errors.Is(err, ingest.ErrTooLarge) {
+		t.Fatalf("ndjson: want ErrTooLarge, got %v", err)
+	}
+}
+
+func TestDecode_UnsupportedFormats(t *testing.T) {
+	_, err := ingest.Decode(strings.NewReader("x"), "text/plain", "", limit)
+	if !errors.Is(err, ingest.ErrUnsupportedType) {
+		t.Errorf("text/plain: want ErrUnsupportedType, got %v", err)
+	}
+	_, err = ingest.Decode(strings.NewReader("[]"), "application/json", "br", limit)
+	if !errors.Is(err, ingest.ErrUnsupportedEncoding) {
+		t.Errorf("br: want ErrUnsupportedEncoding, got %v", err)
+	}
+}
+
+func TestResult_CapsReportedRejections(t *testing.T) {
+	body := "[" + strings.TrimSuffix(strings.Repeat("1,", 150), ",") + "]"
+	b, err := ingest.Decode(strings.NewReader(body), "application/json", "", limit)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	res := b.Result()
+	if res.Accepted != 0 || res.Rejected != 150 {
+		t.Errorf("counts: want 0 / 150, got %d / %d", res.Accepted, res.Rejected)
+	}
+	if len(res.Errors) != 100 {
+		t.Errorf("errors: want 100 reported, got %d", len(res.Errors))
+	}
+}
@@ -0,0 +1,118 @@
+package ingest
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/apikey"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+// Handler serves POST /ingest/{sourceId}. It is a plain net/http handler
+// rather than part of the generated strict server: callers authenticate with
+// an org API key instead of a user session, and the body is streamed in
+// several formats.
+type Handler struct {
+	svc      *Service
+	keys     *apikey.Service
+	sources  *source.Service
+	maxBytes int64
+	logger   *slog.Logger
+}
+
+// NewHandler wires a Handler. maxBytes bounds a request body, both as sent and
+// once decompressed.
+func NewHandler(
+	svc *Service,
+	keys *apikey.Service,
+	sources *source.Service,
+	maxBytes int64,
+	logger *slog.Logger,
+) *Handler {
+	return &Handler{svc: svc, keys: keys, sources: sources, maxBytes: maxBytes, logger: logger}
+}
+
+// IngestEvents authenticates the API key, checks the source belongs to the
+// key's org and is enabled, then decodes and queues the batch, answering 202
+// with accepted and rejected counts.
+func (h *Handler) IngestEvents(w http.ResponseWriter, r *http.Request) {
+	sourceID, err := uuid.Parse(chi.URLParam(r, "sourceId"))
+	if err != nil {
+		httpx.WriteProblem(w, httpx.Prob(400, "Bad Request", "Invalid source ID"))
+		return
+	}
+
+	rawKey, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || rawKey == "" {
+		httpx.WriteProblem(w, httpx.Prob(401, "Unauthorized", "An API key bearer token is required"))
+		return
+	}
+	cred, err := h.keys.Authenticate(r.Context(), rawKey)
+	if err != nil {
+		if errors.Is(err, apikey.ErrNotFound) {
+			httpx.WriteProblem(w, httpx.Prob(401, "Unauthorized", "Invalid or revoked API key"))
+			return
+		}
+		h.fail(w, r, err)
+		return
+	}
+	if !cred.HasScope(apikey.ScopeEventsIngest) {
+		httpx.WriteProblem(w, httpx.Prob(403, "Forbidden", "API key lacks the events:ingest scope"))
+		return
+	}
+
+	ctx := tenant.WithOrg(r.Context(), cred.OrgID)
+	src, err := h.sources.Get(ctx, cred.OrgID, sourceID)
+	if err != nil {
+		if errors.Is(err, source.ErrNotFound) {
+			httpx.WriteProblem(w, httpx.Prob(404, "Not Found", "Source not found"))
+			return
+		}
+		h.fail(w, r, err)
+		return
+	}
+	if !src.Enabled {
+		httpx.WriteProblem(w, httpx.Prob(403, "Forbidden", "Source is disabled"))
+		return
+	}
+
+	body := http.MaxBytesReader(w, r.Body, h.maxBytes)
+	batch, err := Decode(body, r.Header.Get("Content-Type"), r.Header.Get("Content-Encoding"), h.maxBytes)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrUnsupportedType):
+			httpx.WriteProblem(w, httpx.Prob(415, "Unsupported Media Type",
+				"Content-Type must be application/json or application/x-ndjson"))
+		case errors.Is(err, ErrUnsupportedEncoding):
+			httpx.WriteProblem(w, httpx.Prob(415, "Unsupported Media Type",
+				"Content-Encoding must be gzip or zstd when set"))
+		case errors.Is(err, ErrTooLarge):
+			httpx.WriteProblem(w, httpx.Prob(413, "Content Too Large", "Request body exceeds the size limit"))
+		default:
+			httpx.WriteProblem(w, httpx.Prob(400, "Bad Request", err.Error()))
+		}
+		return
+	}
+
+	if err := h.svc.Submit(ctx, cred.OrgID, sourceID, batch); err != nil {
+		h.fail(w, r, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	_ = json.NewEncoder(w).Encode(batch.Result())
+}
+
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	h.logger.ErrorContext(r.Context(), "ingest request failed", slog.Any("err", err))
+	httpx.WriteProblem(w, httpx.Prob(500, "Internal Server Error", "Internal server error occurred"))
+}
@@ -0,0 +1,247 @@
+package ingest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/apikey"
+	"github.com/luketeo/horizon/internal/ingest"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+type fixture struct {
+	router  http.Handler
+	keys    *apikey.Service
+	sources *source.Service
+	orgID   uuid.UUID
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+
+	userSvc := user.NewService(user.NewRepo(db), txm, events, logger)
+	_, userID, err := userSvc.GetOrCreateUser(ctx, &clerk.User{
+		ID:                    "user_ingest_owner",
+		FirstName:             strPtr("First"),
+		PrimaryEmailAddressID: strPtr("eaddr_ingest"),
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: "eaddr_ingest", EmailAddress: "ingest@example.com"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), txm, events, logger)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), "Ingest Org", nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+
+	keys := apikey.NewService(apikey.NewRepo(db), txm, events, logger)
+	sources := source.NewService(source.NewRepo(db), txm, events, logger)
+	svc := ingest.NewService(
+		queue.NewPostgres(queue.NewRepo(db), tx.NewUnscopedManager(db), queue.Config{}, logger),
+		sources,
+		redaction.NewRedactor(redaction.NewRepo(db), txm, logger, oapi.Archive, 0),
+		txm,
+		logger,
+	)
+	h := ingest.NewHandler(svc, keys, sources, 1<<20, logger)
+
+	r := chi.NewRouter()
+	r.Post("/ingest/{sourceId}", h.IngestEvents)
+	return fixture{router: r, keys: keys, sources: sources, orgID: o.Id}
+}
+
+func (f fixture) key(t *testing.T, scopes ...string) string {
+	t.Helper()
+	k, err := f.keys.Create(tenant.WithOrg(context.Background(), f.orgID), f.orgID, "shipper", scopes)
+	if err != nil {
+		t.Fatalf("create key: %v", err)
+	}
+	return k.Key
+}
+
+func (f fixture) source(t *testing.T) uuid.UUID {
+	t.Helper()
+	s, err := f.sources.Create(
+		tenant.WithOrg(context.Background(), f.orgID),
+		f.orgID,
+		oapi.CreateSourceRequest{Name: "app", Type: oapi.HttpJson},
+	)
+	if err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+	return s.Id
+}
+
+func (f fixture) post(sourceID uuid.UUID, key, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/ingest/"+sourceID.String(), strings.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	if key != "" {
+		req.Header.Set("Authorization", "Bearer "+key)
+	}
+	rec := httptest.NewRecorder()
+	f.router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIngestEvents_RequiresIngestScope(t *testing.T) {
+	f := newFixture(t)
+	sourceID := f.source(t)
+
+	if rec := f.post(sourceID, "", "application/json", `[]`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("no key: want 401, got %d", rec.Code)
+	}
+	if rec := f.post(sourceID, "hrz_unknown", "application/json", `[]`); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: want 401, got %d", rec.Code)
+	}
+	if rec := f.post(sourceID, f.key(t, "read"), "application/json", `[]`); rec.Code != http.StatusForbidden {
+		t.Errorf("read-only key: want 403, got %d", rec.Code)
+	}
+}
+
+func TestIngestEvents_UnknownSourceReturnsNotFound(t *testing.T) {
+	f := newFixture(t)
+
+	rec := f.post(uuid.New(), f.key(t, apikey.ScopeEventsIngest), "application/json", `[]`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("want 404, got %d", rec.Code)
+	}
+}
+
+func TestIngestEvents_QueuesAcceptedAndCountsActivity(t *testing.T) {
+	f := newFixture(t)
+	sourceID := f.source(t)
+	key := f.key(t, apikey.ScopeEventsIngest)
+
+	rec := f.post(sourceID, key, "application/x-ndjson", "{\"a\":1}\n{\"a\":2}\nbroken\n")
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("want 202, got %d: %s", rec.Code, rec.Body)
+	}
+	var res oapi.IngestResult
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("decode result: %v", err)
+	}
+	if res.Accepted != 2 || res.Rejected != 1 {
+		t.Errorf("result: want 2 / 1, got %d / %d", res.Accepted, res.Rejected)
+	}
+
+	var queued int
+	if err := testhelper.DB(t).QueryRowContext(context.Background(),
+		`SELECT COUNT(*) FROM ingest_events WHERE source_id = $1`, sourceID,
+	).Scan(&queued); err != nil {
+		t.Fatalf("count queued: %v", err)
+	}
+	if queued != 2 {
+		t.Errorf("queued: want 2, got %d", queued)
+	}
+
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	health, err := f.sources.Health(ctx, f.orgID, sourceID, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if health.Events != 3 || health.ParseErrors != 1 {
+		t.Errorf("health: want 3 events / 1 parse error, got %d / %d", health.Events, health.ParseErrors)
+	}
+}
+
+func TestIngestEvents_StampsEventTime(t *testing.T) {
+	f := newFixture(t)
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	src, err := f.sources.Create(ctx, f.orgID, oapi.CreateSourceRequest{
+		Name: "edr",
+		Type: oapi.HttpJson,
+		TimestampConfig: &oapi.TimestampConfig{
+			Field:   strPtr("event.created"),
+			Layouts: &[]string{"epoch_ms"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+
+	happened := time.Now().Add(-90 * time.Second).Truncate(time.Millisecond)
+	body := fmt.Sprintf("{\"event\":{\"created\":%d}}\n{\"event\":{\"created\":\"soon\"}}\n{\"other\":1}\n",
+		happened.UnixMilli())
+	rec := f.post(src.Id, f.key(t, apikey.ScopeEventsIngest), "application/x-ndjson", body)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("want 202, got %d: %s", rec.Code, rec.Body)
+	}
+
+	rows, err := testhelper.DB(t).QueryContext(context.Background(),
+		`SELECT time, time_status, received_time FROM ingest_events WHERE source_id = $1 ORDER BY seq`, src.Id)
+	if err != nil {
+		t.Fatalf("query queued: %v", err)
+	}
+	defer rows.Close()
+	var statuses []string
+	for rows.Next() {
+		var at, received time.Time
+		var status string
+		if err := rows.Scan(&at, &status, &received); err != nil {
+			t.Fatalf("scan: %v", err)
+		}
+		statuses = append(statuses, status)
+		if status == "parsed" && !at.Equal(happened) {
+			t.Errorf("parsed time: want %v, got %v", happened, at)
+		}
+		if status != "parsed" && !at.Equal(received) {
+			t.Errorf("%s: want received time %v, got %v", status, received, at)
+		}
+	}
+	if strings.Join(statuses, ",") != "parsed,invalid,missing" {
+		t.Errorf("statuses: got %v", statuses)
+	}
+}
+
+func TestIngestEvents_DisabledSourceForbidden(t *testing.T) {
+	f := newFixture(t)
+	sourceID := f.source(t)
+	disabled := false
+	if _, err := f.sources.Update(
+		tenant.WithOrg(context.Background(), f.orgID),
+		f.orgID,
+		sourceID,
+		oapi.UpdateSourceRequest{Enabled: &disabled},
+		nil,
+	); err != nil {
+		t.Fatalf("disable source: %v", err)
+	}
+
+	rec := f.post(sourceID, f.key(t, apikey.ScopeEventsIngest), "application/json", `[{"a":1}]`)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("want 403, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,112 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/eventstore"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+// NormalizeConsumer is the name the normalizer consumes the ingest queue
+// under.
+const NormalizeConsumer = "normalizer"
+
+// Normalizer processes queued events through the pipeline as they arrive and
+// stores them. The events of a batch are handled an org and source at a
+// time, each in one transaction carrying the org's tenant scope: the source's
+// configuration is resolved, its events processed, stored and recorded, as
+// with their redactions and the entities they mention, together, so a
+// redelivered batch is stored again rather than in part, and recorded only
+// the first time, with the time extracted from each in its source's health.
+// An event the pipeline rejects is dropped, counted as a parse error in its
+// source's health; it is still in the archive, for a replay once its source
+// is fixed.
+type Normalizer struct {
+	pipeline *pipeline.Pipeline
+	sources  *source.Service
+	store    *eventstore.Store
+	tx       *tx.Manager
+}
+
+// NewNormalizer wires a Normalizer with the pipeline, the source registry, the
+// event store and the transaction manager of the application pool.
+func NewNormalizer(p *pipeline.Pipeline, sources *source.Service, store *eventstore.Store, txm *tx.Manager) *Normalizer {
+	return &Normalizer{pipeline: p, sources: sources, store: store, tx: txm}
+}
+
+// Handle normalizes a batch. It is the normalizer's queue.BatchHandler.
+func (n *Normalizer) Handle(ctx context.Context, ds []queue.Delivery) error {
+	type group struct {
+		orgID, sourceID uuid.UUID
+	}
+	var order []group
+	groups := map[group][]queue.Message{}
+	for _, d := range ds {
+		g := group{orgID: d.OrgID, sourceID: d.SourceID}
+		if _, ok := groups[g]; !ok {
+			order = append(order, g)
+		}
+		groups[g] = append(groups[g], d.Message)
+	}
+
+	for _, g := range order {
+		if err := n.normalize(ctx, g.orgID, g.sourceID, groups[g]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// normalize processes and stores the events of one source. Events of a
+// source deleted meanwhile are dropped; those of a source whose parser or
+// mapping no longer resolves fail permanently.
+func (n *Normalizer) normalize(ctx context.Context, orgID, sourceID uuid.UUID, msgs []queue.Message) error {
+	err := n.tx.RunInTx(tenant.WithOrg(ctx, orgID), nil, func(ctx context.Context) error {
+		proc, err := n.pipeline.Prepare(ctx, orgID, sourceID, pipeline.Options{})
+		if err != nil {
+			return err
+		}
+		var activity source.Activity
+		events := make([]pipeline.Event, 0, len(msgs))
+		for _, m := range msgs {
+			ev, err := proc.Process(ctx, m)
+			if errors.Is(err, redaction.ErrUnavailable) {
+				return err
+			} else if err != nil {
+				activity.ParseErrors++
+				continue
+			}
+			events = append(events, ev)
+		}
+		stored, err := n.store.Upsert(ctx, events)
+		if err != nil {
+			return err
+		}
+		if err := proc.Record(ctx, orgID, sourceID, stored); err != nil {
+			return err
+		}
+		for _, ev := range stored {
+			activity.Observe(ev.Extracted)
+		}
+		if activity == (source.Activity{}) {
+			return nil
+		}
+		return n.sources.RecordActivity(ctx, orgID, sourceID, activity)
+	})
+	switch {
+	case errors.Is(err, source.ErrNotFound):
+		return nil
+	case errors.Is(err, parser.ErrUnknownParser), errors.Is(err, pipeline.ErrMappingVersion):
+		return queue.Permanent(err)
+	}
+	return err
+}
@@ -0,0 +1,263 @@
+package ingest_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/apikey"
+	"github.com/luketeo/horizon/internal/eventstore"
+	"github.com/luketeo/horizon/internal/ingest"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+// normalizing is an ingest fixture with a normalizer storing the events of
+// its org.
+type normalizing struct {
+	fixture
+	normalizer *ingest.Normalizer
+	mappings   *normalization.Service
+}
+
+func newNormalizing(t *testing.T, enrichers ...pipeline.Enricher) normalizing {
+	t.Helper()
+	f := newFixture(t)
+	db := testhelper.DB(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+	sources := source.NewService(source.NewRepo(db), txm, events, logger)
+	patterns := pattern.NewService(pattern.NewRepo(db), txm, events, logger)
+	mappings := normalization.NewService(normalization.NewRepo(db), sources, patterns, txm, events, logger)
+	pipe := pipeline.New(sources, patterns, mappings, enrichers...).
+		WithRedactor(redaction.NewRedactor(redaction.NewRepo(db), txm, logger, oapi.Normalization, 0))
+	return normalizing{
+		fixture:    f,
+		normalizer: ingest.NewNormalizer(pipe, sources, eventstore.NewStore(db, txm), txm),
+		mappings:   mappings,
+	}
+}
+
+// message queues payload as an event of the source.
+func (f normalizing) message(sourceID uuid.UUID, payload string) queue.Delivery {
+	return queue.Delivery{
+		Message: queue.Message{
+			ID:           uuid.New(),
+			OrgID:        f.orgID,
+			SourceID:     sourceID,
+			Payload:      json.RawMessage(payload),
+			Time:         time.Now().UTC().Truncate(time.Millisecond),
+			TimeStatus:   "received",
+			ReceivedTime: time.Now().UTC(),
+		},
+		Attempt: 1,
+	}
+}
+
+// stored returns the events stored for the org, by ID.
+func (f normalizing) stored(t *testing.T) map[uuid.UUID]map[string]any {
+	t.Helper()
+	rows, err := testhelper.DB(t).QueryContext(context.Background(),
+		`SELECT id, event FROM events WHERE org_id = $1`, f.orgID)
+	if err != nil {
+		t.Fatalf("querying events: %v", err)
+	}
+	defer rows.Close()
+	got := make(map[uuid.UUID]map[string]any)
+	for rows.Next() {
+		var id uuid.UUID
+		var raw []byte
+		if err := rows.Scan(&id, &raw); err != nil {
+			t.Fatalf("scanning events: %v", err)
+		}
+		var ev map[string]any
+		if err := json.Unmarshal(raw, &ev); err != nil {
+			t.Fatalf("decoding event %s: %v", id, err)
+		}
+		got[id] = ev
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("querying events: %v", err)
+	}
+	return got
+}
+
+func TestNormalizer_StoresProcessedEvents(t *testing.T) {
+	f := newNormalizing(t)
+	sourceID := f.source(t)
+
+	ok := f.message(sourceID, `{"message":"hello","host":"web1"}`)
+	rejected := f.message(sourceID, `[1]`)
+	orphan := f.message(uuid.New(), `{"message":"from a deleted source"}`)
+	if err := f.normalizer.Handle(context.Background(), []queue.Delivery{ok, rejected, orphan}); err != nil {
+		t.Fatalf("Handle: %v", err)
+	}
+
+	got := f.stored(t)
+	if len(got) != 1 || got[ok.ID]["host"] != "web1" {
+		t.Fatalf("stored: want only the valid event, got %v", got)
+	}
+	health, err := f.sources.Health(tenant.WithOrg(context.Background(), f.orgID), f.orgID, sourceID, time.Hour)
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if health.ParseErrors != 1 || health.LastEventAt != nil {
+		t.Errorf("health: want the rejected event counted and no event seen, got %+v", health)
+	}
+
+	// A redelivered batch is stored again, not twice.
+	if err := f.normalizer.Handle(context.Background(), []queue.Delivery{ok}); err != nil {
+		t.Fatalf("Handle again: %v", err)
+	}
+	if got := f.stored(t); len(got) != 1 {
+		t.Errorf("stored after redelivery: want 1 event, got %d", len(got))
+	}
+}
+
+func TestNormalizer_ExtractsTimeFromParsedFields(t *testing.T) {
+	f := newNormalizing(t)
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	src, err := f.sources.Create(ctx, f.orgID, oapi.CreateSourceRequest{
+		Name:   "firewall",
+		Type:   oapi.HttpJson,
+		Parser: strPtr(parser.NameCEF),
+		TimestampConfig: &oapi.TimestampConfig{
+			Field:   strPtr("extensions.rt"),
+			Layouts: &[]string{"epoch_ms"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+
+	d := f.message(src.Id, `{}`)
+	happened := d.ReceivedTime.Add(-90 * time.Second).Truncate(time.Millisecond)
+	d.Payload = json.RawMessage(fmt.Sprintf(
+		`{"message":"CEF:0|Acme|Firewall|1.0|100|Blocked|5|src=10.0.0.1 rt=%d"}`, happened.UnixMilli()))
+	for range 2 {
+		// redelivered, the event's time is counted once
+		if err := f.normalizer.Handle(context.Background(), []queue.Delivery{d}); err != nil {
+			t.Fatalf("Handle: %v", err)
+		}
+	}
+
+	var at time.Time
+	if err := testhelper.DB(t).QueryRowContext(context.Background(),
+		`SELECT time FROM events WHERE id = $1`, d.ID).Scan(&at); err != nil {
+		t.Fatalf("querying event: %v", err)
+	}
+	if !at.Equal(happened) {
+		t.Errorf("time: want %v from extensions.rt, got %v", happened, at)
+	}
+	health, err := f.sources.Health(ctx, f.orgID, src.Id, 15*time.Minute)
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if health.TimedEvents != 1 || health.IngestionLagMaxSeconds == nil || *health.IngestionLagMaxSeconds < 90 {
+		t.Errorf("lag: want one timed event at least 90s late, got %d / %v",
+			health.TimedEvents, health.IngestionLagMaxSeconds)
+	}
+}
+
+func TestNormalizer_MatchesIndicatorsOfQueuedEvents(t *testing.T) {
+	db := testhelper.DB(t)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txm := tx.NewManager(db)
+	tiRepo := threatintel.NewRepo(db)
+	f := newNormalizing(t, threatintel.NewMatcher(tiRepo, txm, logger, 0))
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	sourceID := f.source(t)
+
+	m, err := f.mappings.Create(ctx, f.orgID, oapi.CreateMappingRequest{
+		Name: "firewall",
+		Definition: oapi.MappingDefinition{
+			"defaults": map[string]any{
+				"class_uid":    4001,
+				"activity_id":  6,
+				"severity_id":  1,
+				"time":         0,
+				"metadata":     map[string]any{"product": map[string]any{"name": "Firewall"}},
+				"dst_endpoint": map[string]any{"ip": "10.0.0.2"},
+			},
+			"fields": []any{map[string]any{"to": "src_endpoint.ip", "from": "src"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create mapping: %v", err)
+	}
+	if _, err := f.mappings.Activate(ctx, f.orgID, sourceID, m.Id, 1); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+	indicators := threatintel.NewService(tiRepo, txm, outbox.NewRepo(db), logger)
+	if _, err := indicators.Create(ctx, f.orgID, oapi.CreateThreatIndicatorRequest{
+		Type:  oapi.ThreatIndicatorTypeIp,
+		Value: "203.0.113.7",
+	}); err != nil {
+		t.Fatalf("Create indicator: %v", err)
+	}
+
+	rec := f.post(sourceID, f.key(t, apikey.ScopeEventsIngest), "application/json", `{"src":"203.0.113.7"}`)
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("ingest: want 202, got %d: %s", rec.Code, rec.Body)
+	}
+
+	privileged := testhelper.PrivilegedDB(t)
+	q := queue.NewPostgres(
+		queue.NewRepo(privileged),
+		tx.NewUnscopedManager(privileged),
+		queue.Config{PollInterval: 10 * time.Millisecond},
+		logger,
+	)
+	consumeCtx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- q.ConsumeBatches(consumeCtx, ingest.NormalizeConsumer, queue.Batching{}, f.normalizer.Handle)
+	}()
+	var got map[uuid.UUID]map[string]any
+	for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+		if got = f.stored(t); len(got) > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != nil && !errors.Is(err, context.Canceled) {
+		t.Errorf("ConsumeBatches: %v", err)
+	}
+	if len(got) != 1 {
+		t.Fatalf("stored: want the queued event, got %v", got)
+	}
+
+	for _, ev := range got {
+		enrichments, _ := ev["enrichments"].([]any)
+		for _, e := range enrichments {
+			e, _ := e.(map[string]any)
+			data, _ := e["data"].(map[string]any)
+			if e["type"] == threatintel.EnrichmentType && e["name"] == "src_endpoint.ip" &&
+				data["indicator"] == "203.0.113.7" {
+				return
+			}
+		}
+		t.Errorf("enrichments: want a match of src_endpoint.ip, got %v", ev["enrichments"])
+	}
+}
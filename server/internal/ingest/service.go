@@ -0,0 +1,110 @@
+// Package ingest accepts raw events from log sources and queues them for
+// normalization. Receivers (the HTTP API and the syslog listeners) only
+// decode and publish to the ingest queue, so they never wait on parsing or
+// mapping. Events are redacted by their org's archive-stage policies before
+// they are published, so the queue never holds what those remove. The
+// Normalizer consumes the queue, storing the events its pipeline makes of
+// them.
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+// Service queues decoded batches. Each batch is published, counted towards
+// its source's health and its redactions counted in one transaction carrying
+// the caller's tenant scope; with the Postgres queue the publish is part of
+// it.
+type Service struct {
+	queue    queue.Producer
+	sources  *source.Service
+	redactor *redaction.Redactor
+	tx       *tx.Manager
+	logger   *slog.Logger
+}
+
+// NewService wires a Service with the ingest queue, the source registry, the
+// redactor of archive-stage policies, transaction manager and logger.
+func NewService(
+	q queue.Producer,
+	sources *source.Service,
+	redactor *redaction.Redactor,
+	txm *tx.Manager,
+	logger *slog.Logger,
+) *Service {
+	return &Service{queue: q, sources: sources, redactor: redactor, tx: txm, logger: logger}
+}
+
+// Submit queues the batch's events for the source, each stamped with the time
+// the source's timestamp config extracts from it as received, then redacted.
+// That time files the raw event in the archive; the time of the stored event
+// is extracted again once the source's parser has run, by the pipeline,
+// whose extraction the source's health counts. Rejected events are not
+// queued but count as parse errors in the source's health. A batch whose
+// events cannot be redacted is not queued.
+func (s *Service) Submit(ctx context.Context, orgID, sourceID uuid.UUID, b Batch) error {
+	total := len(b.Events) + b.Rejected
+	if total == 0 {
+		return nil
+	}
+	received := time.Now()
+	// IDs are fixed up front so that a retried transaction publishes the same
+	// messages again, which the queue deduplicates.
+	ids := make([]uuid.UUID, len(b.Events))
+	for i := range ids {
+		ids[i] = uuid.New()
+	}
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		extractor, err := s.sources.Extractor(ctx, orgID, sourceID)
+		if err != nil {
+			return err
+		}
+		activity := source.Activity{Events: int64(total), ParseErrors: int64(b.Rejected)}
+		redactions := make(redaction.Tally)
+		msgs := make([]queue.Message, len(b.Events))
+		for i, raw := range b.Events {
+			// Decode only queues valid JSON objects, so this cannot fail.
+			fields, _ := parser.ParseJSON(raw)
+			t := extractor.Extract(fields, received)
+			payload := raw
+			redacted, err := s.redactor.Redact(ctx, orgID, sourceID, fields)
+			if err != nil {
+				return err
+			}
+			if len(redacted) > 0 {
+				if payload, err = json.Marshal(fields); err != nil {
+					return err
+				}
+				redactions.Add(redacted)
+			}
+			msgs[i] = queue.Message{
+				ID:           ids[i],
+				OrgID:        orgID,
+				SourceID:     sourceID,
+				Payload:      payload,
+				Time:         t.Time,
+				TimeStatus:   string(t.Status),
+				ReceivedTime: t.Received,
+			}
+		}
+
+		if err := s.queue.Publish(ctx, msgs); err != nil {
+			return err
+		}
+		if err := s.redactor.Record(ctx, orgID, sourceID, redactions); err != nil {
+			return err
+		}
+		return s.sources.RecordActivity(ctx, orgID, sourceID, activity)
+	})
+}
@@ -0,0 +1,74 @@
+package mapping
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"sync"
+)
+
+// Cache keeps compiled mappings by the digest of their stored definition, so
+// each definition compiles once however many sources or events use it. It
+// holds up to its size, dropping the least recently used beyond that.
+// Definitions that do not compile are not kept. It is safe for concurrent use.
+type Cache struct {
+	size int
+
+	mu      sync.Mutex
+	order   *list.List // of *entry, most recently used first
+	entries map[[sha256.Size]byte]*list.Element
+}
+
+type entry struct {
+	digest  [sha256.Size]byte
+	mapping *Mapping
+}
+
+// NewCache returns a Cache holding up to size mappings.
+func NewCache(size int) *Cache {
+	return &Cache{
+		size:    max(size, 1),
+		order:   list.New(),
+		entries: make(map[[sha256.Size]byte]*list.Element),
+	}
+}
+
+// Compile returns the compiled mapping for the stored definition raw,
+// compiling it on first use.
+func (c *Cache) Compile(raw []byte) (*Mapping, error) {
+	digest := sha256.Sum256(raw)
+	c.mu.Lock()
+	if el, ok := c.entries[digest]; ok {
+		c.order.MoveToFront(el)
+		c.mu.Unlock()
+		return el.Value.(*entry).mapping, nil
+	}
+	c.mu.Unlock()
+
+	// Compiled unlocked; a concurrent compile of the same definition is
+	// wasted, not wrong.
+	m, err := Compile(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[digest]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*entry).mapping, nil
+	}
+	c.entries[digest] = c.order.PushFront(&entry{digest: digest, mapping: m})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*entry).digest)
+	}
+	return m, nil
+}
+
+// Len reports how many mappings the cache holds.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
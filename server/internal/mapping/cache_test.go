@@ -0,0 +1,75 @@
+package mapping_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/mapping"
+)
+
+func definition(n int) []byte {
+	return fmt.Appendf(nil, `{"defaults": {"class_uid": %d}}`, n)
+}
+
+func TestCache_CompilesOnce(t *testing.T) {
+	c := mapping.NewCache(4)
+
+	first, err := c.Compile(definition(1))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	again, err := c.Compile(definition(1))
+	if err != nil || again != first {
+		t.Errorf("Compile again: want the cached mapping, got %p (%v)", again, err)
+	}
+	if other, _ := c.Compile(definition(2)); other == first {
+		t.Error("Compile of another definition: got the cached mapping")
+	}
+	if c.Len() != 2 {
+		t.Errorf("Len: want 2, got %d", c.Len())
+	}
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := mapping.NewCache(2)
+	one, _ := c.Compile(definition(1))
+	c.Compile(definition(2))
+	c.Compile(definition(1)) // 2 is now least recently used
+	c.Compile(definition(3))
+
+	if c.Len() != 2 {
+		t.Fatalf("Len: want 2, got %d", c.Len())
+	}
+	if got, _ := c.Compile(definition(1)); got != one {
+		t.Error("recently used definition was evicted")
+	}
+}
+
+func TestCache_DoesNotKeepInvalid(t *testing.T) {
+	c := mapping.NewCache(2)
+	if _, err := c.Compile([]byte(`{"rules": [{}]}`)); !errors.Is(err, mapping.ErrInvalid) {
+		t.Errorf("Compile: want ErrInvalid, got %v", err)
+	}
+	if c.Len() != 0 {
+		t.Errorf("Len: want 0, got %d", c.Len())
+	}
+}
+
+func TestCache_ConcurrentUse(t *testing.T) {
+	c := mapping.NewCache(8)
+	var wg sync.WaitGroup
+	for i := range 32 {
+		wg.Go(func() {
+			m, err := c.Compile(definition(i % 4))
+			if err != nil || m.Apply(nil)["class_uid"] != int64(i%4) {
+				t.Errorf("Compile %d: got %v", i%4, err)
+			}
+		})
+	}
+	wg.Wait()
+	if c.Len() != 4 {
+		t.Errorf("Len: want 4, got %d", c.Len())
+	}
+}
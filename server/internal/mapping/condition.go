@@ -0,0 +1,191 @@
+package mapping
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+// Condition tests an event's input fields. It either combines conditions,
+// with exactly one of All, Any or Not, or tests the input field at the dotted
+// path Field with exactly one test. Values compare by their text, so the
+// number 4624 equals "4624", and a test other than exists fails on a field
+// that is absent or not a scalar.
+type Condition struct {
+	All []Condition `json:"all,omitempty"`
+	Any []Condition `json:"any,omitempty"`
+	Not *Condition  `json:"not,omitempty"`
+
+	Field string `json:"field,omitempty"`
+	// Exists tests whether the field is present, or absent when false.
+	Exists *bool `json:"exists,omitempty"`
+	Equals any   `json:"equals,omitempty"`
+	In     []any `json:"in,omitempty"`
+	// Contains tests for a substring.
+	Contains string `json:"contains,omitempty"`
+	// Matches is an RE2 expression the text must match somewhere.
+	Matches string `json:"matches,omitempty"`
+	// Numeric bounds, exclusive and inclusive.
+	GT  *float64 `json:"gt,omitempty"`
+	GTE *float64 `json:"gte,omitempty"`
+	LT  *float64 `json:"lt,omitempty"`
+	LTE *float64 `json:"lte,omitempty"`
+	// IgnoreCase makes equals, in and contains disregard case.
+	IgnoreCase bool `json:"ignore_case,omitempty"`
+}
+
+type cond func(in parser.Fields) bool
+
+// compileCondition compiles c, returning nil for no condition.
+func compileCondition(c *Condition, at string) (cond, error) {
+	if c == nil {
+		return nil, nil
+	}
+	combinators := 0
+	for _, set := range []bool{len(c.All) > 0, len(c.Any) > 0, c.Not != nil} {
+		if set {
+			combinators++
+		}
+	}
+	if combinators > 0 {
+		if combinators > 1 || c.Field != "" {
+			return nil, invalid(at, "combines with exactly one of all, any or not, and no field")
+		}
+		return compileCombinator(c, at)
+	}
+	if c.Field == "" {
+		return nil, invalid(at, "needs a field, or one of all, any or not")
+	}
+	return compileTest(c, at)
+}
+
+func compileCombinator(c *Condition, at string) (cond, error) {
+	if c.Not != nil {
+		inner, err := compileCondition(c.Not, at+".not")
+		if err != nil {
+			return nil, err
+		}
+		return func(in parser.Fields) bool { return !inner(in) }, nil
+	}
+	list, name, all := c.Any, "any", false
+	if len(c.All) > 0 {
+		list, name, all = c.All, "all", true
+	}
+	conds := make([]cond, len(list))
+	for i := range list {
+		var err error
+		if conds[i], err = compileCondition(&list[i], fmt.Sprintf("%s.%s[%d]", at, name, i)); err != nil {
+			return nil, err
+		}
+	}
+	return func(in parser.Fields) bool {
+		for _, c := range conds {
+			if c(in) != all {
+				return !all
+			}
+		}
+		return all
+	}, nil
+}
+
+func compileTest(c *Condition, at string) (cond, error) {
+	var tests []cond
+	field := c.Field
+	fold := func(s string) string { return s }
+	if c.IgnoreCase {
+		fold = strings.ToLower
+	}
+	// onText tests the folded text of the field.
+	onText := func(f func(s string) bool) cond {
+		return func(in parser.Fields) bool {
+			v, _ := getPath(in, field)
+			s, ok := text(v)
+			return ok && f(fold(s))
+		}
+	}
+
+	if c.Exists != nil {
+		want := *c.Exists
+		tests = append(tests, func(in parser.Fields) bool {
+			_, ok := getPath(in, field)
+			return ok == want
+		})
+	}
+	if c.Equals != nil {
+		want, ok := text(literal(c.Equals))
+		if !ok {
+			return nil, invalid(at, "equals needs a scalar")
+		}
+		want = fold(want)
+		tests = append(tests, onText(func(s string) bool { return s == want }))
+	}
+	if len(c.In) > 0 {
+		set := make(map[string]bool, len(c.In))
+		for _, v := range c.In {
+			s, ok := text(literal(v))
+			if !ok {
+				return nil, invalid(at, "in needs scalars")
+			}
+			set[fold(s)] = true
+		}
+		tests = append(tests, onText(func(s string) bool { return set[s] }))
+	}
+	if c.Contains != "" {
+		sub := fold(c.Contains)
+		tests = append(tests, onText(func(s string) bool { return strings.Contains(s, sub) }))
+	}
+	if c.Matches != "" {
+		re, err := regexp.Compile(c.Matches)
+		if err != nil {
+			return nil, invalid(at, fmt.Sprintf("matches pattern %q does not compile", c.Matches))
+		}
+		tests = append(tests, func(in parser.Fields) bool {
+			v, _ := getPath(in, field)
+			s, ok := text(v)
+			return ok && re.MatchString(s)
+		})
+	}
+	if c.GT != nil || c.GTE != nil || c.LT != nil || c.LTE != nil {
+		tests = append(tests, numeric(field, c))
+	}
+
+	if len(tests) != 1 {
+		return nil, invalid(at, "needs exactly one test of exists, equals, in, contains, matches or bounds")
+	}
+	return tests[0], nil
+}
+
+// numeric tests the field's number against all the bounds c sets, which
+// count as one test so a range can be written in one condition.
+func numeric(field string, c *Condition) cond {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	loIncl, hiIncl := true, true
+	if c.GT != nil {
+		lo, loIncl = *c.GT, false
+	}
+	if c.GTE != nil && *c.GTE > lo {
+		lo, loIncl = *c.GTE, true
+	}
+	if c.LT != nil {
+		hi, hiIncl = *c.LT, false
+	}
+	if c.LTE != nil && *c.LTE < hi {
+		hi, hiIncl = *c.LTE, true
+	}
+	return func(in parser.Fields) bool {
+		v, _ := getPath(in, field)
+		s, ok := text(v)
+		if !ok {
+			return false
+		}
+		n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+		if err != nil || math.IsNaN(n) {
+			return false
+		}
+		return (n > lo || loIncl && n == lo) && (n < hi || hiIncl && n == hi)
+	}
+}
@@ -0,0 +1,89 @@
+package mapping_test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+// holds reports whether the rule condition c holds for in.
+func holds(t *testing.T, c string, in parser.Fields) bool {
+	t.Helper()
+	m := compile(t, `{"rules": [{"when": `+c+`, "set": {"matched": true}}]}`)
+	return m.Apply(in)["matched"] == true
+}
+
+func TestConditions(t *testing.T) {
+	ev := parser.Fields{
+		"action":   "Deny",
+		"event_id": json.Number("4625"),
+		"sev":      "7",
+		"proto":    "tcp",
+		"user":     map[string]any{"name": "svc_backup", "groups": []any{"admins"}},
+		"empty":    "",
+		"nothing":  nil,
+	}
+
+	for _, c := range []struct {
+		cond string
+		want bool
+	}{
+		{`{"field": "action", "equals": "Deny"}`, true},
+		{`{"field": "action", "equals": "deny"}`, false},
+		{`{"field": "action", "equals": "deny", "ignore_case": true}`, true},
+		{`{"field": "event_id", "equals": 4625}`, true},
+		{`{"field": "event_id", "equals": "4625"}`, true},
+		{`{"field": "sev", "equals": 7}`, true},
+		{`{"field": "missing", "equals": ""}`, false},
+		{`{"field": "user", "equals": "svc_backup"}`, false},
+		{`{"field": "event_id", "in": [4624, 4625]}`, true},
+		{`{"field": "proto", "in": ["UDP", "TCP"], "ignore_case": true}`, true},
+		{`{"field": "proto", "in": ["udp", "icmp"]}`, false},
+		{`{"field": "user.name", "contains": "backup"}`, true},
+		{`{"field": "user.name", "matches": "^svc_"}`, true},
+		{`{"field": "user.name", "matches": "^adm"}`, false},
+		{`{"field": "user.groups.0", "equals": "admins"}`, true},
+		{`{"field": "user.name", "exists": true}`, true},
+		{`{"field": "empty", "exists": true}`, true},
+		{`{"field": "nothing", "exists": true}`, false},
+		{`{"field": "missing", "exists": false}`, true},
+		{`{"field": "sev", "gt": 5}`, true},
+		{`{"field": "sev", "gte": 7, "lt": 7}`, false},
+		{`{"field": "sev", "gte": 7, "lte": 7}`, true},
+		{`{"field": "sev", "gt": 7}`, false},
+		{`{"field": "action", "gt": 0}`, false},
+		{`{"all": [{"field": "action", "equals": "Deny"}, {"field": "proto", "equals": "tcp"}]}`, true},
+		{`{"all": [{"field": "action", "equals": "Deny"}, {"field": "proto", "equals": "udp"}]}`, false},
+		{`{"any": [{"field": "action", "equals": "Allow"}, {"field": "proto", "equals": "tcp"}]}`, true},
+		{`{"any": [{"field": "action", "equals": "Allow"}, {"field": "proto", "equals": "udp"}]}`, false},
+		{`{"not": {"field": "action", "equals": "Allow"}}`, true},
+		{`{"not": {"any": [{"field": "sev", "lt": 3}, {"field": "missing", "exists": true}]}}`, true},
+	} {
+		if got := holds(t, c.cond, ev); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.cond, c.want, got)
+		}
+	}
+}
+
+func TestConditions_RejectInvalid(t *testing.T) {
+	for _, c := range []string{
+		`{}`,
+		`{"field": "a"}`,
+		`{"field": "a", "equals": 1, "exists": true}`,
+		`{"field": "a", "equals": {"b": 1}}`,
+		`{"field": "a", "in": [[1]]}`,
+		`{"field": "a", "matches": "("}`,
+		`{"field": "a", "all": [{"field": "b", "exists": true}]}`,
+		`{"all": [{"field": "b", "exists": true}], "not": {"field": "b", "exists": true}}`,
+		`{"any": [{"field": "b"}]}`,
+		`{"not": {}}`,
+	} {
+		_, err := mapping.Compile([]byte(`{"rules": [{"when": ` + c + `, "set": {"a": 1}}]}`))
+		if !errors.Is(err, mapping.ErrInvalid) {
+			t.Errorf("%s: want ErrInvalid, got %v", c, err)
+		}
+	}
+}
@@ -0,0 +1,370 @@
+package mapping
+
+import (
+	"fmt"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luketeo/horizon/internal/eventtime"
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+// Transform ops.
+const (
+	OpLowercase = "lowercase" // text in lower case
+	OpUppercase = "uppercase" // text in upper case
+	OpTrim      = "trim"      // text without surrounding white space
+	OpSplit     = "split"     // text split at Sep, or the part at Index
+	OpJoin      = "join"      // array elements joined with Sep
+	OpRegex     = "regex"     // the Group captured by Pattern
+	OpLookup    = "lookup"    // the Table entry for the text
+	OpCast      = "cast"      // the value as Type
+	OpDefault   = "default"   // Value when there is no value or it is empty
+)
+
+// Cast types. Timestamps are OCSF timestamp_t, Unix milliseconds, read in the
+// eventtime layouts given, RFC 3339 or epoch by default.
+const (
+	CastString    = "string"
+	CastInt       = "int"
+	CastFloat     = "float"
+	CastBool      = "bool"
+	CastIP        = "ip"
+	CastTimestamp = "timestamp"
+)
+
+// Transform is one step of an expression's transform chain. Op says which;
+// the other fields are its parameters. Every op but join and default applies
+// to each element of an array on its own. An op that cannot apply to its
+// input, such as a cast of text that is not a number or a regex that does not
+// match, yields nothing; a default after it supplies a fallback.
+type Transform struct {
+	Op string `json:"op"`
+	// Sep separates the parts of split and join.
+	Sep string `json:"sep,omitempty"`
+	// Index picks one part of split, counting from the end when negative.
+	Index *int `json:"index,omitempty"`
+	// Pattern is the regex's RE2 expression.
+	Pattern string `json:"pattern,omitempty"`
+	// Group is the regex capture kept, by name or number. It defaults to the
+	// first capture, or the whole match when there is none.
+	Group string `json:"group,omitempty"`
+	// Table maps lookup keys to their values.
+	Table map[string]any `json:"table,omitempty"`
+	// IgnoreCase matches lookup keys regardless of case.
+	IgnoreCase bool `json:"ignore_case,omitempty"`
+	// Type is the cast's target type.
+	Type string `json:"type,omitempty"`
+	// Layouts and Timezone read timestamp casts, as in an eventtime.Spec.
+	Layouts  []string `json:"layouts,omitempty"`
+	Timezone string   `json:"timezone,omitempty"`
+	// Value is default's fallback.
+	Value any `json:"value,omitempty"`
+}
+
+type expr func(in parser.Fields, now time.Time) any
+
+type transform func(v any, now time.Time) any
+
+func (m *Mapping) compileExpr(e Expr, at string) (expr, error) {
+	sources := 0
+	for _, set := range []bool{e.From != "", e.Value != nil, len(e.Concat) > 0} {
+		if set {
+			sources++
+		}
+	}
+	if sources != 1 {
+		return nil, invalid(at, "needs exactly one of from, value or concat")
+	}
+
+	var base expr
+	switch {
+	case e.From != "":
+		from := e.From
+		m.reads = append(m.reads, from)
+		base = func(in parser.Fields, _ time.Time) any {
+			v, _ := getPath(in, from)
+			return clone(v)
+		}
+	case e.Value != nil:
+		value := literal(e.Value)
+		base = func(parser.Fields, time.Time) any { return clone(value) }
+	default:
+		parts := make([]expr, len(e.Concat))
+		for i, p := range e.Concat {
+			var err error
+			if parts[i], err = m.compileExpr(p, fmt.Sprintf("%s.concat[%d]", at, i)); err != nil {
+				return nil, err
+			}
+		}
+		base = concat(parts)
+	}
+
+	chain := make([]transform, len(e.Transforms))
+	for i, t := range e.Transforms {
+		var err error
+		if chain[i], err = compileTransform(t, fmt.Sprintf("%s.transforms[%d]", at, i)); err != nil {
+			return nil, err
+		}
+	}
+	if len(chain) == 0 {
+		return base, nil
+	}
+	return func(in parser.Fields, now time.Time) any {
+		v := base(in, now)
+		for _, t := range chain {
+			v = t(v, now)
+		}
+		return v
+	}, nil
+}
+
+// concat joins the text of its parts. It yields nothing when any part does,
+// rather than text with a hole in it.
+func concat(parts []expr) expr {
+	return func(in parser.Fields, now time.Time) any {
+		var b strings.Builder
+		for _, p := range parts {
+			s, ok := text(p(in, now))
+			if !ok {
+				return nil
+			}
+			b.WriteString(s)
+		}
+		return b.String()
+	}
+}
+
+func compileTransform(t Transform, at string) (transform, error) {
+	switch t.Op {
+	case OpLowercase:
+		return onText(func(s string) any { return strings.ToLower(s) }), nil
+	case OpUppercase:
+		return onText(func(s string) any { return strings.ToUpper(s) }), nil
+	case OpTrim:
+		return onText(func(s string) any { return strings.TrimSpace(s) }), nil
+	case OpSplit:
+		return compileSplit(t, at)
+	case OpJoin:
+		return join(t.Sep), nil
+	case OpRegex:
+		return compileRegex(t, at)
+	case OpLookup:
+		return compileLookup(t, at)
+	case OpCast:
+		return compileCast(t, at)
+	case OpDefault:
+		if t.Value == nil {
+			return nil, invalid(at, "default needs a value")
+		}
+		fallback := literal(t.Value)
+		return func(v any, _ time.Time) any {
+			if v == nil || v == "" {
+				return clone(fallback)
+			}
+			return v
+		}, nil
+	case "":
+		return nil, invalid(at, "op is missing")
+	}
+	return nil, invalid(at, fmt.Sprintf("unknown op %q", t.Op))
+}
+
+// elementwise applies f to v, or to each element of v when it is an array,
+// dropping the elements f yields nothing for.
+func elementwise(f func(v any, now time.Time) any) transform {
+	return func(v any, now time.Time) any {
+		arr, ok := v.([]any)
+		if !ok {
+			if v == nil {
+				return nil
+			}
+			return f(v, now)
+		}
+		out := make([]any, 0, len(arr))
+		for _, e := range arr {
+			if r := f(e, now); r != nil {
+				out = append(out, r)
+			}
+		}
+		if len(out) == 0 {
+			return nil
+		}
+		return out
+	}
+}
+
+// onText applies f to the text of scalars.
+func onText(f func(s string) any) transform {
+	return elementwise(func(v any, _ time.Time) any {
+		s, ok := text(v)
+		if !ok {
+			return nil
+		}
+		return f(s)
+	})
+}
+
+func compileSplit(t Transform, at string) (transform, error) {
+	if t.Sep == "" {
+		return nil, invalid(at, "split needs a sep")
+	}
+	sep, index := t.Sep, t.Index
+	return onText(func(s string) any {
+		parts := strings.Split(s, sep)
+		if index == nil {
+			out := make([]any, len(parts))
+			for i, p := range parts {
+				out[i] = p
+			}
+			return out
+		}
+		i := *index
+		if i < 0 {
+			i += len(parts)
+		}
+		if i < 0 || i >= len(parts) {
+			return nil
+		}
+		return parts[i]
+	}), nil
+}
+
+func join(sep string) transform {
+	return func(v any, _ time.Time) any {
+		arr, ok := v.([]any)
+		if !ok {
+			if s, ok := text(v); ok {
+				return s
+			}
+			return nil
+		}
+		parts := make([]string, 0, len(arr))
+		for _, e := range arr {
+			if s, ok := text(e); ok {
+				parts = append(parts, s)
+			}
+		}
+		return strings.Join(parts, sep)
+	}
+}
+
+func compileRegex(t Transform, at string) (transform, error) {
+	re, err := regexp.Compile(t.Pattern)
+	if err != nil || t.Pattern == "" {
+		return nil, invalid(at, fmt.Sprintf("regex pattern %q does not compile", t.Pattern))
+	}
+	group := 0
+	switch {
+	case t.Group == "":
+		group = min(re.NumSubexp(), 1)
+	case re.SubexpIndex(t.Group) >= 0:
+		group = re.SubexpIndex(t.Group)
+	default:
+		n, err := strconv.Atoi(t.Group)
+		if err != nil || n < 0 || n > re.NumSubexp() {
+			return nil, invalid(at, fmt.Sprintf("regex has no group %q", t.Group))
+		}
+		group = n
+	}
+	return onText(func(s string) any {
+		loc := re.FindStringSubmatchIndex(s)
+		if loc == nil || loc[2*group] < 0 {
+			return nil
+		}
+		return s[loc[2*group]:loc[2*group+1]]
+	}), nil
+}
+
+func compileLookup(t Transform, at string) (transform, error) {
+	if len(t.Table) == 0 {
+		return nil, invalid(at, "lookup needs a table")
+	}
+	table := make(map[string]any, len(t.Table))
+	for k, v := range t.Table {
+		if v == nil {
+			return nil, invalid(at, fmt.Sprintf("lookup value for %q is null", k))
+		}
+		if t.IgnoreCase {
+			k = strings.ToLower(k)
+		}
+		table[k] = literal(v)
+	}
+	ignoreCase := t.IgnoreCase
+	return onText(func(s string) any {
+		if ignoreCase {
+			s = strings.ToLower(s)
+		}
+		return clone(table[s])
+	}), nil
+}
+
+func compileCast(t Transform, at string) (transform, error) {
+	switch t.Type {
+	case CastString:
+		return onText(func(s string) any { return s }), nil
+	case CastInt:
+		return elementwise(func(v any, _ time.Time) any { return castInt(v) }), nil
+	case CastFloat:
+		return onText(func(s string) any {
+			if f, err := strconv.ParseFloat(strings.TrimSpace(s), 64); err == nil {
+				return f
+			}
+			return nil
+		}), nil
+	case CastBool:
+		return onText(castBool), nil
+	case CastIP:
+		return onText(func(s string) any {
+			if ip, err := netip.ParseAddr(strings.TrimSpace(s)); err == nil {
+				return ip.Unmap().String()
+			}
+			return nil
+		}), nil
+	case CastTimestamp:
+		ex, err := eventtime.Spec{Layouts: t.Layouts, Timezone: t.Timezone}.Compile()
+		if err != nil {
+			return nil, invalid(at, err.Error())
+		}
+		return elementwise(func(v any, now time.Time) any {
+			if ts, ok := ex.Parse(v, now); ok {
+				return ts.UnixMilli()
+			}
+			return nil
+		}), nil
+	case "":
+		return nil, invalid(at, "cast needs a type")
+	}
+	return nil, invalid(at, fmt.Sprintf("unknown cast type %q", t.Type))
+}
+
+// castInt reads integers, including integral floats such as 3.0, but not
+// fractions, which it would have to round.
+func castInt(v any) any {
+	s, ok := text(v)
+	if !ok {
+		return nil
+	}
+	s = strings.TrimSpace(s)
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return n
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f != float64(int64(f)) {
+		return nil
+	}
+	return int64(f)
+}
+
+func castBool(s string) any {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "true", "t", "yes", "y", "on", "1":
+		return true
+	case "false", "f", "no", "n", "off", "0":
+		return false
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package mapping_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+func intPtr(n int) *int { return &n }
+
+// transformed applies the transforms to the input field v.
+func transformed(t *testing.T, v any, ts ...mapping.Transform) any {
+	t.Helper()
+	m, err := mapping.Definition{
+		Fields: []mapping.Field{{To: "out", Expr: mapping.Expr{From: "in", Transforms: ts}}},
+	}.Compile()
+	if err != nil {
+		t.Fatalf("Compile(%+v): %v", ts, err)
+	}
+	return m.Apply(parser.Fields{"in": v})["out"]
+}
+
+func TestTransforms(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		in   any
+		ts   []mapping.Transform
+		want any
+	}{
+		{"lowercase", "ADMIN", []mapping.Transform{{Op: mapping.OpLowercase}}, "admin"},
+		{"uppercase", "get", []mapping.Transform{{Op: mapping.OpUppercase}}, "GET"},
+		{"trim", "  fw1\t", []mapping.Transform{{Op: mapping.OpTrim}}, "fw1"},
+		{"text of number", json.Number("42"), []mapping.Transform{{Op: mapping.OpLowercase}}, "42"},
+		{"object has no text", map[string]any{"a": "b"}, []mapping.Transform{{Op: mapping.OpLowercase}}, nil},
+		{
+			"each element",
+			[]any{"A", map[string]any{}, "B"},
+			[]mapping.Transform{{Op: mapping.OpLowercase}},
+			[]any{"a", "b"},
+		},
+		{"split", "a,b,c", []mapping.Transform{{Op: mapping.OpSplit, Sep: ","}}, []any{"a", "b", "c"}},
+		{"split index", "DOMAIN\\alice", []mapping.Transform{{Op: mapping.OpSplit, Sep: "\\", Index: intPtr(1)}}, "alice"},
+		{"split from end", "a.b.c", []mapping.Transform{{Op: mapping.OpSplit, Sep: ".", Index: intPtr(-1)}}, "c"},
+		{"split out of range", "a", []mapping.Transform{{Op: mapping.OpSplit, Sep: ",", Index: intPtr(2)}}, nil},
+		{"join", []any{"a", json.Number("1"), true}, []mapping.Transform{{Op: mapping.OpJoin, Sep: " "}}, "a 1 true"},
+		{"join scalar", int64(7), []mapping.Transform{{Op: mapping.OpJoin, Sep: ","}}, "7"},
+		{
+			"split then join",
+			"a b  c",
+			[]mapping.Transform{{Op: mapping.OpSplit, Sep: " "}, {Op: mapping.OpJoin, Sep: "-"}},
+			"a-b--c",
+		},
+		{
+			"regex named group",
+			"user=alice uid=1000",
+			[]mapping.Transform{{Op: mapping.OpRegex, Pattern: `uid=(?P<uid>\d+)`, Group: "uid"}},
+			"1000",
+		},
+		{
+			"regex numbered group",
+			"GET /index.html HTTP/1.1",
+			[]mapping.Transform{{Op: mapping.OpRegex, Pattern: `^(\S+) (\S+)`, Group: "2"}},
+			"/index.html",
+		},
+		{"regex first group", "port 22", []mapping.Transform{{Op: mapping.OpRegex, Pattern: `port (\d+)`}}, "22"},
+		{"regex whole match", "id-123-x", []mapping.Transform{{Op: mapping.OpRegex, Pattern: `\d+`}}, "123"},
+		{"regex no match", "none", []mapping.Transform{{Op: mapping.OpRegex, Pattern: `\d+`}}, nil},
+		{
+			"regex group not taking part",
+			"a",
+			[]mapping.Transform{{Op: mapping.OpRegex, Pattern: `a|(b)`, Group: "1"}},
+			nil,
+		},
+		{
+			"lookup",
+			json.Number("4624"),
+			[]mapping.Transform{{Op: mapping.OpLookup, Table: map[string]any{"4624": 1, "4634": 2}}},
+			int64(1),
+		},
+		{
+			"lookup ignoring case",
+			"Deny",
+			[]mapping.Transform{{Op: mapping.OpLookup, Table: map[string]any{"DENY": "Blocked"}, IgnoreCase: true}},
+			"Blocked",
+		},
+		{"lookup miss", "x", []mapping.Transform{{Op: mapping.OpLookup, Table: map[string]any{"y": 1}}}, nil},
+		{
+			"lookup miss with default",
+			"x",
+			[]mapping.Transform{
+				{Op: mapping.OpLookup, Table: map[string]any{"y": 1}},
+				{Op: mapping.OpDefault, Value: 99},
+			},
+			int64(99),
+		},
+		{"default of empty", "", []mapping.Transform{{Op: mapping.OpDefault, Value: "unknown"}}, "unknown"},
+		{"default keeps value", "set", []mapping.Transform{{Op: mapping.OpDefault, Value: "unknown"}}, "set"},
+		{"cast string", json.Number("1.5"), []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastString}}, "1.5"},
+		{"cast int", " 443 ", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastInt}}, int64(443)},
+		{"cast int integral float", 3.0, []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastInt}}, int64(3)},
+		{"cast int fraction", "3.5", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastInt}}, nil},
+		{"cast int text", "many", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastInt}}, nil},
+		{"cast float", "0.25", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastFloat}}, 0.25},
+		{"cast bool", "Yes", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastBool}}, true},
+		{"cast bool number", json.Number("0"), []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastBool}}, false},
+		{"cast bool text", "maybe", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastBool}}, nil},
+		{"cast ipv4", "10.0.0.1", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastIP}}, "10.0.0.1"},
+		{"cast ipv6", "2001:DB8::1", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastIP}}, "2001:db8::1"},
+		{"cast ip text", "fw1", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastIP}}, nil},
+		{
+			"cast ints each",
+			[]any{"1", "x", json.Number("2")},
+			[]mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastInt}},
+			[]any{int64(1), int64(2)},
+		},
+		{
+			"cast timestamp",
+			"2026-10-19T16:00:00+02:00",
+			[]mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastTimestamp}},
+			int64(1792418400000),
+		},
+		{
+			"cast epoch timestamp",
+			json.Number("1792418400"),
+			[]mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastTimestamp}},
+			int64(1792418400000),
+		},
+		{
+			"cast timestamp in layout and zone",
+			"19/10/2026 10:00:00",
+			[]mapping.Transform{{
+				Op:       mapping.OpCast,
+				Type:     mapping.CastTimestamp,
+				Layouts:  []string{"02/01/2006 15:04:05"},
+				Timezone: "America/New_York",
+			}},
+			int64(1792418400000),
+		},
+		{"cast timestamp text", "soon", []mapping.Transform{{Op: mapping.OpCast, Type: mapping.CastTimestamp}}, nil},
+	} {
+		if got := transformed(t, c.in, c.ts...); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %#v, got %#v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestTransforms_RejectInvalid(t *testing.T) {
+	for _, tr := range []mapping.Transform{
+		{Op: mapping.OpSplit},
+		{Op: mapping.OpRegex},
+		{Op: mapping.OpRegex, Pattern: `(a`},
+		{Op: mapping.OpRegex, Pattern: `(a)`, Group: "2"},
+		{Op: mapping.OpRegex, Pattern: `(?P<x>a)`, Group: "y"},
+		{Op: mapping.OpLookup},
+		{Op: mapping.OpLookup, Table: map[string]any{"a": nil}},
+		{Op: mapping.OpCast},
+		{Op: mapping.OpCast, Type: "uuid"},
+		{Op: mapping.OpCast, Type: mapping.CastTimestamp, Layouts: []string{"nope"}},
+		{Op: mapping.OpCast, Type: mapping.CastTimestamp, Timezone: "Nowhere/Land"},
+		{Op: mapping.OpDefault},
+	} {
+		_, err := mapping.Definition{
+			Fields: []mapping.Field{{To: "out", Expr: mapping.Expr{From: "in", Transforms: []mapping.Transform{tr}}}},
+		}.Compile()
+		if !errors.Is(err, mapping.ErrInvalid) {
+			t.Errorf("%+v: want ErrInvalid, got %v", tr, err)
+		}
+	}
+}
+
+func TestConcat(t *testing.T) {
+	m := compile(t, `{"fields": [
+		{"to": "dst_endpoint.svc_name", "concat": [
+			{"from": "proto", "transforms": [{"op": "uppercase"}]},
+			{"value": "/"},
+			{"from": "port"}
+		]},
+		{"to": "user.email_addr", "concat": [{"from": "user"}, {"value": "@"}, {"from": "domain"}],
+		 "transforms": [{"op": "lowercase"}]}
+	]}`)
+
+	got := m.Apply(parser.Fields{"proto": "tcp", "port": json.Number("443"), "user": "Alice"})
+	if got["dst_endpoint"].(map[string]any)["svc_name"] != "TCP/443" {
+		t.Errorf("concat: got %v", got["dst_endpoint"])
+	}
+	if _, ok := got["user"]; ok {
+		t.Errorf("concat with a part missing: want unset, got %v", got["user"])
+	}
+	got = m.Apply(parser.Fields{"user": "Alice", "domain": "Example.COM"})
+	if got["user"].(map[string]any)["email_addr"] != "alice@example.com" {
+		t.Errorf("concat then transform: got %v", got["user"])
+	}
+}
@@ -0,0 +1,265 @@
+// Package mapping normalizes parsed events into OCSF. A mapping is a
+// declarative definition, stored as JSON, that sets OCSF attributes by dotted
+// path from the fields a parser produced: each through a chain of transforms,
+// optionally only when a condition holds. Defaults lie beneath the fields, and
+// conditional rules, typically choosing class_uid and activity_id, on top.
+// Definitions compile once into a Mapping, which a Cache keeps by content.
+package mapping
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+// ErrInvalid is returned by Compile for a definition that cannot be used. The
+// wrapping error says where in the definition the problem is.
+var ErrInvalid = errors.New("invalid mapping")
+
+// UnmappedPath is the OCSF attribute that input fields no field reads are
+// kept under when a definition asks for them.
+const UnmappedPath = "unmapped"
+
+// Definition is a mapping as stored. Apply writes, in order, the defaults, the
+// fields and then the first rule whose condition holds; later writes replace
+// earlier ones at the same path, and an expression that yields nothing writes
+// nothing, so the value beneath stays.
+type Definition struct {
+	// Defaults are literal attribute values by OCSF path.
+	Defaults map[string]any `json:"defaults,omitempty"`
+	Fields   []Field        `json:"fields,omitempty"`
+	Rules    []Rule         `json:"rules,omitempty"`
+	// Unmapped keeps the input fields no field reads under UnmappedPath.
+	Unmapped bool `json:"unmapped,omitempty"`
+}
+
+// Field sets the OCSF attribute at To to the value of its expression.
+type Field struct {
+	To   string     `json:"to"`
+	When *Condition `json:"when,omitempty"`
+	Expr
+}
+
+// Rule sets literal attributes and fields when its condition holds. A rule
+// without a condition always holds, so it serves as the last rule's else.
+type Rule struct {
+	When   *Condition     `json:"when,omitempty"`
+	Set    map[string]any `json:"set,omitempty"`
+	Fields []Field        `json:"fields,omitempty"`
+}
+
+// Expr yields a value from an event: the input field at the dotted path From,
+// the literal Value, or the text of the Concat parts joined. Its transforms
+// then apply in order.
+type Expr struct {
+	From       string      `json:"from,omitempty"`
+	Value      any         `json:"value,omitempty"`
+	Concat     []Expr      `json:"concat,omitempty"`
+	Transforms []Transform `json:"transforms,omitempty"`
+}
+
+// Mapping is a compiled Definition. It is safe for concurrent use.
+type Mapping struct {
+	defaults []assign
+	fields   []field
+	rules    []rule
+	unmapped bool
+	// reads are the input paths fields take values from.
+	reads []string
+}
+
+type assign struct {
+	to    []string
+	value any
+}
+
+type field struct {
+	to   []string
+	when cond
+	expr expr
+}
+
+type rule struct {
+	when   cond
+	set    []assign
+	fields []field
+}
+
+// Compile decodes a stored definition and compiles it. Unknown keys are
+// rejected, so a misspelt one is not silently ignored.
+func Compile(raw []byte) (*Mapping, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	dec.DisallowUnknownFields()
+	var d Definition
+	if err := dec.Decode(&d); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if dec.More() {
+		return nil, fmt.Errorf("%w: trailing data after the definition", ErrInvalid)
+	}
+	return d.Compile()
+}
+
+// Compile validates d and returns its mapping.
+func (d Definition) Compile() (*Mapping, error) {
+	m := &Mapping{unmapped: d.Unmapped}
+	var err error
+	if m.defaults, err = compileAssigns(d.Defaults, "defaults"); err != nil {
+		return nil, err
+	}
+	if m.fields, err = m.compileFields(d.Fields, "fields"); err != nil {
+		return nil, err
+	}
+	for i, r := range d.Rules {
+		at := fmt.Sprintf("rules[%d]", i)
+		if len(r.Set) == 0 && len(r.Fields) == 0 {
+			return nil, invalid(at, "sets nothing")
+		}
+		var c rule
+		if c.when, err = compileCondition(r.When, at+".when"); err != nil {
+			return nil, err
+		}
+		if c.set, err = compileAssigns(r.Set, at+".set"); err != nil {
+			return nil, err
+		}
+		if c.fields, err = m.compileFields(r.Fields, at+".fields"); err != nil {
+			return nil, err
+		}
+		m.rules = append(m.rules, c)
+	}
+	return m, nil
+}
+
+func (m *Mapping) compileFields(fs []Field, at string) ([]field, error) {
+	out := make([]field, 0, len(fs))
+	for i, f := range fs {
+		at := fmt.Sprintf("%s[%d]", at, i)
+		to, err := outputPath(f.To, at+".to")
+		if err != nil {
+			return nil, err
+		}
+		c := field{to: to}
+		if c.when, err = compileCondition(f.When, at+".when"); err != nil {
+			return nil, err
+		}
+		if c.expr, err = m.compileExpr(f.Expr, at); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// compileAssigns compiles literal values by path, in path order so a parent
+// is written before the attributes beneath it.
+func compileAssigns(values map[string]any, at string) ([]assign, error) {
+	out := make([]assign, 0, len(values))
+	for _, p := range slices.Sorted(maps.Keys(values)) {
+		to, err := outputPath(p, fmt.Sprintf("%s[%q]", at, p))
+		if err != nil {
+			return nil, err
+		}
+		if values[p] == nil {
+			return nil, invalid(fmt.Sprintf("%s[%q]", at, p), "value is null")
+		}
+		out = append(out, assign{to: to, value: literal(values[p])})
+	}
+	return out, nil
+}
+
+// attributeName is one segment of an OCSF attribute path.
+var attributeName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func outputPath(p, at string) ([]string, error) {
+	if p == "" {
+		return nil, invalid(at, "path is empty")
+	}
+	path := strings.Split(p, ".")
+	for _, seg := range path {
+		if !attributeName.MatchString(seg) {
+			return nil, invalid(at, fmt.Sprintf("%q is not an attribute path", p))
+		}
+	}
+	return path, nil
+}
+
+func invalid(at, msg string) error {
+	return fmt.Errorf("%w: %s: %s", ErrInvalid, at, msg)
+}
+
+// Apply maps the fields of one event to its OCSF attributes. Input values are
+// copied, so neither the input nor the output shares state with the other.
+func (m *Mapping) Apply(in parser.Fields) map[string]any {
+	out := make(map[string]any)
+	now := time.Now()
+	for _, a := range m.defaults {
+		setPath(out, a.to, clone(a.value))
+	}
+	applyFields(out, m.fields, in, now)
+	for _, r := range m.rules {
+		if r.when != nil && !r.when(in) {
+			continue
+		}
+		for _, a := range r.set {
+			setPath(out, a.to, clone(a.value))
+		}
+		applyFields(out, r.fields, in, now)
+		break
+	}
+	if m.unmapped {
+		m.keepUnmapped(out, in)
+	}
+	return out
+}
+
+func applyFields(out map[string]any, fs []field, in parser.Fields, now time.Time) {
+	for _, f := range fs {
+		if f.when != nil && !f.when(in) {
+			continue
+		}
+		if v := f.expr(in, now); v != nil {
+			setPath(out, f.to, v)
+		}
+	}
+}
+
+// Unmapped returns the input fields no field reads, whether or not the
+// mapping keeps them; nil when it reads them all.
+func (m *Mapping) Unmapped(in parser.Fields) map[string]any {
+	rest, _ := clone(in).(map[string]any)
+	for _, p := range m.reads {
+		removePath(rest, p)
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	return rest
+}
+
+// keepUnmapped adds the input fields no field reads under UnmappedPath,
+// beside any attributes the mapping set there itself.
+func (m *Mapping) keepUnmapped(out map[string]any, in parser.Fields) {
+	rest := m.Unmapped(in)
+	if rest == nil {
+		return
+	}
+	dst, ok := out[UnmappedPath].(map[string]any)
+	if !ok {
+		out[UnmappedPath] = rest
+		return
+	}
+	for k, v := range rest {
+		if _, taken := dst[k]; !taken {
+			dst[k] = v
+		}
+	}
+}
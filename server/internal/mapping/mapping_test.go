@@ -0,0 +1,305 @@
+package mapping_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+func compile(t *testing.T, def string) *mapping.Mapping {
+	t.Helper()
+	m, err := mapping.Compile([]byte(def))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	return m
+}
+
+// fields decodes an event the way the JSON parser does.
+func fields(t *testing.T, raw string) parser.Fields {
+	t.Helper()
+	f, err := parser.ParseJSON([]byte(raw))
+	if err != nil {
+		t.Fatalf("ParseJSON: %v", err)
+	}
+	return f
+}
+
+// sshd maps sshd authentication lines to OCSF Authentication.
+const sshd = `{
+	"defaults": {
+		"category_uid": 3,
+		"class_uid": 3002,
+		"activity_id": 99,
+		"severity_id": 1,
+		"metadata.product.name": "OpenSSH"
+	},
+	"fields": [
+		{"to": "time", "from": "timestamp", "transforms": [{"op": "cast", "type": "timestamp"}]},
+		{"to": "user.name", "from": "user", "transforms": [{"op": "lowercase"}]},
+		{"to": "src_endpoint.ip", "from": "client.ip", "transforms": [{"op": "cast", "type": "ip"}]},
+		{"to": "src_endpoint.port", "from": "client.port", "transforms": [{"op": "cast", "type": "int"}]},
+		{"to": "auth_protocol", "from": "method", "transforms": [
+			{"op": "lookup", "table": {"password": "Password", "publickey": "Public Key"}, "ignore_case": true},
+			{"op": "default", "value": "Other"}
+		]},
+		{"to": "message", "concat": [{"from": "user"}, {"value": " via "}, {"from": "method"}]}
+	],
+	"rules": [
+		{"when": {"field": "result", "equals": "Accepted"}, "set": {"activity_id": 1, "status_id": 1}},
+		{"when": {"field": "result", "in": ["Failed", "Invalid"]}, "set": {"activity_id": 1, "status_id": 2, "severity_id": 3}},
+		{"set": {"status_id": 0}}
+	],
+	"unmapped": true
+}`
+
+func TestApply_Authentication(t *testing.T) {
+	m := compile(t, sshd)
+	in := fields(t, `{
+		"timestamp": "2026-10-19T14:00:00Z",
+		"user": "Alice",
+		"client": {"ip": "::ffff:198.51.100.7", "port": "52144"},
+		"method": "PublicKey",
+		"result": "Failed",
+		"pid": 4242
+	}`)
+
+	got := m.Apply(in)
+	want := map[string]any{
+		"category_uid":       int64(3),
+		"class_uid":          int64(3002),
+		"activity_id":        int64(1),
+		"severity_id":        int64(3),
+		"status_id":          int64(2),
+		"metadata":           map[string]any{"product": map[string]any{"name": "OpenSSH"}},
+		"time":               int64(1792418400000),
+		"user":               map[string]any{"name": "alice"},
+		"src_endpoint":       map[string]any{"ip": "198.51.100.7", "port": int64(52144)},
+		"auth_protocol":      "Public Key",
+		"message":            "Alice via PublicKey",
+		mapping.UnmappedPath: map[string]any{"result": "Failed", "pid": json.Number("4242")},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Apply:\nwant %#v\n got %#v", want, got)
+	}
+	if in["user"] != "Alice" {
+		t.Errorf("input changed: %v", in)
+	}
+}
+
+func TestApply_FallsBackWhenNothingMatches(t *testing.T) {
+	m := compile(t, sshd)
+
+	got := m.Apply(fields(t, `{"user": "bob", "result": "Disconnected"}`))
+	if got["activity_id"] != int64(99) || got["status_id"] != int64(0) {
+		t.Errorf("defaults and else rule: got %v", got)
+	}
+	if got["auth_protocol"] != "Other" {
+		t.Errorf("default transform: want Other, got %v", got["auth_protocol"])
+	}
+	for _, attr := range []string{"time", "src_endpoint", "message"} {
+		if _, ok := got[attr]; ok {
+			t.Errorf("%s: want unset without input, got %v", attr, got[attr])
+		}
+	}
+}
+
+func TestApply_LaterWritesWin(t *testing.T) {
+	m := compile(t, `{
+		"defaults": {"severity_id": 1, "device": {"type_id": 0}},
+		"fields": [
+			{"to": "severity_id", "from": "sev", "transforms": [{"op": "cast", "type": "int"}]},
+			{"to": "device.hostname", "from": "host"},
+			{"to": "device", "value": "flat", "when": {"field": "flatten", "exists": true}}
+		],
+		"rules": [{"when": {"field": "sev", "gte": 9}, "set": {"severity_id": 6}}]
+	}`)
+
+	for _, c := range []struct {
+		name string
+		in   string
+		want map[string]any
+	}{
+		{
+			"field over default",
+			`{"sev": "4", "host": "fw1"}`,
+			map[string]any{"severity_id": int64(4), "device": map[string]any{"type_id": int64(0), "hostname": "fw1"}},
+		},
+		{
+			"rule over field",
+			`{"sev": 10}`,
+			map[string]any{"severity_id": int64(6), "device": map[string]any{"type_id": int64(0)}},
+		},
+		{
+			"nothing keeps default",
+			`{"sev": "high"}`,
+			map[string]any{"severity_id": int64(1), "device": map[string]any{"type_id": int64(0)}},
+		},
+		{
+			"scalar replaces object",
+			`{"flatten": true}`,
+			map[string]any{"severity_id": int64(1), "device": "flat"},
+		},
+	} {
+		if got := m.Apply(fields(t, c.in)); !reflect.DeepEqual(got, c.want) {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+}
+
+func TestApply_RuleFields(t *testing.T) {
+	m := compile(t, `{
+		"rules": [{
+			"when": {"field": "EventID", "equals": 4625},
+			"set": {"class_uid": 3002},
+			"fields": [{"to": "status_detail", "from": "FailureReason"}]
+		}]
+	}`)
+
+	got := m.Apply(fields(t, `{"EventID": "4625", "FailureReason": "Unknown user name or bad password."}`))
+	if got["class_uid"] != int64(3002) || got["status_detail"] != "Unknown user name or bad password." {
+		t.Errorf("matching rule: got %v", got)
+	}
+	if got := m.Apply(fields(t, `{"EventID": 4624, "FailureReason": "n/a"}`)); len(got) != 0 {
+		t.Errorf("no rule matches: want nothing set, got %v", got)
+	}
+}
+
+func TestApply_InputPaths(t *testing.T) {
+	m := compile(t, `{
+		"fields": [
+			{"to": "src_endpoint.ip", "from": "id.orig_h"},
+			{"to": "dst_endpoint.ip", "from": "id.resp_h"},
+			{"to": "actor.user.uid", "from": "userIdentity.arn"},
+			{"to": "resources", "from": "resources"},
+			{"to": "api.operation", "from": "requestParameters.items.1.name"},
+			{"to": "cloud.region", "from": "awsRegion"}
+		],
+		"unmapped": true
+	}`)
+	in := fields(t, `{
+		"id.orig_h": "10.0.0.1",
+		"id": {"resp_h": "10.0.0.2", "resp_p": 53},
+		"userIdentity": {"arn": "arn:aws:iam::1:user/alice", "type": "IAMUser"},
+		"resources": [{"ARN": "arn:aws:s3:::logs"}],
+		"requestParameters": {"items": [{"name": "a"}, {"name": "b"}]},
+		"awsRegion": null
+	}`)
+
+	got := m.Apply(in)
+	if got["src_endpoint"].(map[string]any)["ip"] != "10.0.0.1" {
+		t.Errorf("dotted key: got %v", got["src_endpoint"])
+	}
+	if got["dst_endpoint"].(map[string]any)["ip"] != "10.0.0.2" {
+		t.Errorf("nested object: got %v", got["dst_endpoint"])
+	}
+	if got["actor"].(map[string]any)["user"].(map[string]any)["uid"] != "arn:aws:iam::1:user/alice" {
+		t.Errorf("nested path: got %v", got["actor"])
+	}
+	if got["api"].(map[string]any)["operation"] != "b" {
+		t.Errorf("array index: got %v", got["api"])
+	}
+	if _, ok := got["cloud"]; ok {
+		t.Errorf("null input: want unset, got %v", got["cloud"])
+	}
+
+	got["resources"].([]any)[0].(map[string]any)["ARN"] = "changed"
+	if in["resources"].([]any)[0].(map[string]any)["ARN"] != "arn:aws:s3:::logs" {
+		t.Error("output shares an array with the input")
+	}
+
+	wantUnmapped := map[string]any{
+		"id":                map[string]any{"resp_p": json.Number("53")},
+		"userIdentity":      map[string]any{"type": "IAMUser"},
+		"requestParameters": map[string]any{"items": []any{map[string]any{"name": "a"}, map[string]any{"name": "b"}}},
+	}
+	if !reflect.DeepEqual(got[mapping.UnmappedPath], wantUnmapped) {
+		t.Errorf("unmapped: want %v, got %v", wantUnmapped, got[mapping.UnmappedPath])
+	}
+}
+
+func TestApply_UnmappedBesideMappedAttributes(t *testing.T) {
+	m := compile(t, `{
+		"fields": [{"to": "unmapped.raw_id", "from": "id"}],
+		"unmapped": true
+	}`)
+
+	got := m.Apply(fields(t, `{"id": 7, "raw_id": "kept by the field", "extra": "x"}`))
+	want := map[string]any{"raw_id": json.Number("7"), "extra": "x"}
+	if !reflect.DeepEqual(got[mapping.UnmappedPath], want) {
+		t.Errorf("unmapped: want %v, got %v", want, got[mapping.UnmappedPath])
+	}
+}
+
+func TestUnmapped_ListsUnreadFieldsWithoutKeepingThem(t *testing.T) {
+	m := compile(t, `{"fields": [{"to": "user.name", "from": "user.name"}]}`)
+	in := fields(t, `{"user": {"name": "alice", "uid": 1000}, "host": "web1"}`)
+
+	got := m.Unmapped(in)
+	want := map[string]any{"user": map[string]any{"uid": json.Number("1000")}, "host": "web1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Unmapped: want %v, got %v", want, got)
+	}
+	if _, kept := m.Apply(in)[mapping.UnmappedPath]; kept {
+		t.Error("Apply: want unmapped fields dropped")
+	}
+	if got := m.Unmapped(fields(t, `{"user": {"name": "bob"}}`)); got != nil {
+		t.Errorf("Unmapped: want nil when every field is read, got %v", got)
+	}
+}
+
+func TestDefinition_CompilesFromGo(t *testing.T) {
+	m, err := mapping.Definition{
+		Defaults: map[string]any{"class_uid": 4001},
+		Fields:   []mapping.Field{{To: "src_endpoint.ip", Expr: mapping.Expr{From: "src"}}},
+	}.Compile()
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	got := m.Apply(parser.Fields{"src": "10.0.0.1"})
+	if got["class_uid"] != int64(4001) || got["src_endpoint"].(map[string]any)["ip"] != "10.0.0.1" {
+		t.Errorf("Apply: got %v", got)
+	}
+}
+
+func TestCompile_RejectsInvalidDefinitions(t *testing.T) {
+	for _, c := range []struct {
+		name, def, where string
+	}{
+		{"not json", `{"fields": [`, ""},
+		{"unknown key", `{"field": []}`, "unknown field"},
+		{"trailing data", `{} {}`, "trailing"},
+		{"empty target", `{"fields": [{"to": "", "from": "a"}]}`, "fields[0].to"},
+		{"bad target", `{"fields": [{"to": "user..name", "from": "a"}]}`, "fields[0].to"},
+		{"target with space", `{"fields": [{"to": "user name", "from": "a"}]}`, "fields[0].to"},
+		{"no source", `{"fields": [{"to": "a"}]}`, "fields[0]"},
+		{"two sources", `{"fields": [{"to": "a", "from": "b", "value": 1}]}`, "fields[0]"},
+		{"null default", `{"defaults": {"a": null}}`, `defaults["a"]`},
+		{"bad default path", `{"defaults": {"a.": 1}}`, `defaults["a."]`},
+		{"empty rule", `{"rules": [{"when": {"field": "a", "exists": true}}]}`, "rules[0]"},
+		{"bad rule set", `{"rules": [{"set": {"": 1}}]}`, "rules[0].set"},
+		{
+			"bad rule field",
+			`{"rules": [{"fields": [{"to": "a", "concat": [{"from": "b"}, {}]}]}]}`,
+			"rules[0].fields[0].concat[1]",
+		},
+		{"unknown op", `{"fields": [{"to": "a", "from": "b", "transforms": [{"op": "reverse"}]}]}`, "transforms[0]"},
+		{"missing op", `{"fields": [{"to": "a", "from": "b", "transforms": [{}]}]}`, "transforms[0]"},
+		{"bad field condition", `{"fields": [{"to": "a", "from": "b", "when": {}}]}`, "fields[0].when"},
+		{"bad rule condition", `{"rules": [{"when": {"field": "a"}, "set": {"b": 1}}]}`, "rules[0].when"},
+	} {
+		_, err := mapping.Compile([]byte(c.def))
+		if !errors.Is(err, mapping.ErrInvalid) {
+			t.Errorf("%s: want ErrInvalid, got %v", c.name, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), c.where) {
+			t.Errorf("%s: want the error to point at %s, got %v", c.name, c.where, err)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package mapping
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// getPath returns the input value at the dotted path p. A key holding dots
+// itself, as Zeek's "id.orig_h" does, matches before nested objects do, and a
+// numeric segment indexes an array. Null values count as absent.
+func getPath(m map[string]any, p string) (any, bool) {
+	if v, ok := m[p]; ok {
+		return v, v != nil
+	}
+	for i := range len(p) {
+		if p[i] != '.' {
+			continue
+		}
+		if v, ok := getIn(m[p[:i]], p[i+1:]); ok {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+func getIn(v any, rest string) (any, bool) {
+	switch v := v.(type) {
+	case map[string]any:
+		return getPath(v, rest)
+	case []any:
+		idx, tail, _ := strings.Cut(rest, ".")
+		n, err := strconv.Atoi(idx)
+		if err != nil || n < 0 || n >= len(v) {
+			return nil, false
+		}
+		if tail == "" {
+			return v[n], v[n] != nil
+		}
+		return getIn(v[n], tail)
+	}
+	return nil, false
+}
+
+// removePath deletes the input value at p, as getPath finds it, along with
+// any object left empty. Values inside arrays are left in place.
+func removePath(m map[string]any, p string) bool {
+	if _, ok := m[p]; ok {
+		delete(m, p)
+		return true
+	}
+	for i := range len(p) {
+		if p[i] != '.' {
+			continue
+		}
+		next, ok := m[p[:i]].(map[string]any)
+		if !ok || !removePath(next, p[i+1:]) {
+			continue
+		}
+		if len(next) == 0 {
+			delete(m, p[:i])
+		}
+		return true
+	}
+	return false
+}
+
+// setPath sets the output value at path, creating the objects on the way and
+// replacing any other value in their place.
+func setPath(m map[string]any, path []string, v any) {
+	for _, seg := range path[:len(path)-1] {
+		next, ok := m[seg].(map[string]any)
+		if !ok {
+			next = make(map[string]any)
+			m[seg] = next
+		}
+		m = next
+	}
+	m[path[len(path)-1]] = v
+}
+
+// clone deep-copies the objects and arrays in v.
+func clone(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = clone(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = clone(e)
+		}
+		return out
+	}
+	return v
+}
+
+// text returns the text of a scalar. Numbers are written the way JSON writes
+// them, so 4624 and "4624" have the same text.
+func text(v any) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case int:
+		return strconv.Itoa(v), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(v), true
+	}
+	return "", false
+}
+
+// literal converts the numbers of a decoded definition value to int64 when
+// integral and float64 otherwise, as OCSF attributes are typed.
+func literal(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return v.String()
+	case int:
+		return int64(v)
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for k, e := range v {
+			out[k] = literal(e)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, e := range v {
+			out[i] = literal(e)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,53 @@
+package normalization
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per mapping. Versions
+// and fixtures are events of their mapping.
+const (
+	aggregateType = "mapping"
+
+	EventCreated        = "mapping.created"
+	EventUpdated        = "mapping.updated"
+	EventDeleted        = "mapping.deleted"
+	EventVersionCreated = "mapping.version_created"
+	EventFixtureCreated = "mapping.fixture_created"
+	EventFixtureDeleted = "mapping.fixture_deleted"
+)
+
+// DeletedPayload is the payload of EventDeleted.
+type DeletedPayload struct {
+	ID    uuid.UUID `json:"id"`
+	OrgID uuid.UUID `json:"org_id"`
+	Name  string    `json:"name"`
+}
+
+// FixtureDeletedPayload is the payload of EventFixtureDeleted.
+type FixtureDeletedPayload struct {
+	ID        uuid.UUID `json:"id"`
+	MappingID uuid.UUID `json:"mapping_id"`
+	OrgID     uuid.UUID `json:"org_id"`
+}
+
+// emit appends an event about mappingID to the outbox, in the transaction on
+// ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   mappingID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
@@ -0,0 +1,639 @@
+package normalization
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// maxPreviewSamples caps the samples one PreviewMapping request may normalize.
+const maxPreviewSamples = 50
+
+// Handler serves the /organizations/{orgId}/mappings/* endpoints and sets the
+// mapping of sources. Members may read, preview and test mappings; admins
+// manage them and activate their versions.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+// notFound reports whichever of the mapping, its version, its fixture or the
+// source err says is missing.
+func notFound(err error) oapi.NotFoundApplicationProblemPlusJSONResponse {
+	detail := "Mapping not found"
+	switch {
+	case errors.Is(err, ErrVersionNotFound):
+		detail = "Mapping version not found"
+	case errors.Is(err, ErrFixtureNotFound):
+		detail = "Mapping fixture not found"
+	case errors.Is(err, source.ErrNotFound):
+		detail = "Source not found"
+	}
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(httpx.Prob(404, "Not Found", detail))
+}
+
+// missing reports whether err is one notFound describes.
+func missing(err error) bool {
+	return errors.Is(err, ErrNotFound) ||
+		errors.Is(err, ErrVersionNotFound) ||
+		errors.Is(err, ErrFixtureNotFound) ||
+		errors.Is(err, source.ErrNotFound)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func conflict(detail string) oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", detail),
+	)
+}
+
+// fixturesFailed lists the failing fixtures as the problem's errors, with
+// the first reason each failed.
+func fixturesFailed(e *FixturesError) oapi.ConflictApplicationProblemPlusJSONResponse {
+	p := httpx.Prob(409, "Conflict", "Fixtures of the mapping fail against this version")
+	var errs []oapi.ValidationError
+	for _, f := range e.Result.Fixtures {
+		if f.Passed {
+			continue
+		}
+		msg := "output differs from the expected one"
+		switch {
+		case f.Error != nil:
+			msg = *f.Error
+		case len(f.Errors) > 0:
+			msg = f.Errors[0].Path + ": " + f.Errors[0].Message
+		}
+		errs = append(errs, oapi.ValidationError{Field: &f.Name, Message: &msg})
+	}
+	p.Errors = &errs
+	return oapi.ConflictApplicationProblemPlusJSONResponse(p)
+}
+
+// ── Mappings ─────────────────────────────────────────────────────────────────
+
+func (h *Handler) ListMappings(
+	ctx context.Context,
+	request oapi.ListMappingsRequestObject,
+) (oapi.ListMappingsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListMappings403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	mappings, err := h.svc.List(ctx, request.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	if mappings == nil {
+		mappings = []oapi.Mapping{}
+	}
+	return oapi.ListMappings200JSONResponse(mappings), nil
+}
+
+func (h *Handler) CreateMapping(
+	ctx context.Context,
+	request oapi.CreateMappingRequestObject,
+) (oapi.CreateMappingResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		return oapi.CreateMapping400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Name must not be empty"),
+		}, nil
+	}
+
+	m, err := h.svc.Create(ctx, request.OrgId, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrConflict):
+			return oapi.CreateMapping409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("A mapping with this name already exists"),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.CreateMapping400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateMapping201JSONResponse(m), nil
+}
+
+func (h *Handler) GetMapping(
+	ctx context.Context,
+	request oapi.GetMappingRequestObject,
+) (oapi.GetMappingResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	m, err := h.svc.Get(ctx, request.OrgId, request.MappingId)
+	if err != nil {
+		if missing(err) {
+			return oapi.GetMapping404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(m.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetMapping304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetMapping200JSONResponse{
+		Body:    m,
+		Headers: oapi.GetMapping200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
+func (h *Handler) UpdateMapping(
+	ctx context.Context,
+	request oapi.UpdateMappingRequestObject,
+) (oapi.UpdateMappingResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			return oapi.UpdateMapping400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest("Name must not be empty"),
+			}, nil
+		}
+		body.Name = &name
+	}
+
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return mappingPreconditionFailed(), nil
+	}
+
+	m, err := h.svc.Update(ctx, request.OrgId, request.MappingId, body, ifMatch)
+	if err != nil {
+		switch {
+		case missing(err):
+			return oapi.UpdateMapping404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.UpdateMapping409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("A mapping with this name already exists"),
+			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return mappingPreconditionFailed(), nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateMapping200JSONResponse{
+		Body:    m,
+		Headers: oapi.UpdateMapping200ResponseHeaders{ETag: httpx.ETag(m.Version)},
+	}, nil
+}
+
+func mappingPreconditionFailed() oapi.UpdateMapping412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateMapping412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Mapping was modified since it was last read"),
+		),
+	}
+}
+
+func (h *Handler) DeleteMapping(
+	ctx context.Context,
+	request oapi.DeleteMappingRequestObject,
+) (oapi.DeleteMappingResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Delete(ctx, request.OrgId, request.MappingId); err != nil {
+		switch {
+		case missing(err):
+			return oapi.DeleteMapping404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		case errors.Is(err, ErrInUse):
+			return oapi.DeleteMapping409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteMapping204Response{}, nil
+}
+
+func (h *Handler) PreviewMapping(
+	ctx context.Context,
+	request oapi.PreviewMappingRequestObject,
+) (oapi.PreviewMappingResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.PreviewMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	body := *request.Body
+	stored := body.MappingId != nil || body.Version != nil
+	switch {
+	case body.Definition != nil && stored:
+		return oapi.PreviewMapping400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(
+				"Give either a definition or a mapping and version, not both",
+			),
+		}, nil
+	case body.Definition == nil && (body.MappingId == nil || body.Version == nil):
+		return oapi.PreviewMapping400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(
+				"A definition, or a mapping and version, is required",
+			),
+		}, nil
+	case len(body.Samples) == 0 || len(body.Samples) > maxPreviewSamples:
+		return oapi.PreviewMapping400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(
+				"Between 1 and 50 samples may be previewed at once",
+			),
+		}, nil
+	}
+
+	result, err := h.svc.Preview(ctx, request.OrgId, body)
+	if err != nil {
+		switch {
+		case missing(err):
+			return oapi.PreviewMapping404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.PreviewMapping400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.PreviewMapping200JSONResponse(result), nil
+}
+
+// ── Versions ─────────────────────────────────────────────────────────────────
+
+func (h *Handler) ListMappingVersions(
+	ctx context.Context,
+	request oapi.ListMappingVersionsRequestObject,
+) (oapi.ListMappingVersionsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListMappingVersions403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	versions, err := h.svc.ListVersions(ctx, request.OrgId, request.MappingId)
+	if err != nil {
+		if missing(err) {
+			return oapi.ListMappingVersions404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ListMappingVersions200JSONResponse(versions), nil
+}
+
+func (h *Handler) CreateMappingVersion(
+	ctx context.Context,
+	request oapi.CreateMappingVersionRequestObject,
+) (oapi.CreateMappingVersionResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateMappingVersion403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateMappingVersion403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	v, err := h.svc.CreateVersion(ctx, request.OrgId, request.MappingId, request.Body.Definition)
+	if err != nil {
+		switch {
+		case missing(err):
+			return oapi.CreateMappingVersion404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.CreateMappingVersion400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateMappingVersion201JSONResponse(v), nil
+}
+
+func (h *Handler) GetMappingVersion(
+	ctx context.Context,
+	request oapi.GetMappingVersionRequestObject,
+) (oapi.GetMappingVersionResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetMappingVersion403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	v, err := h.svc.GetVersion(ctx, request.OrgId, request.MappingId, request.Version)
+	if err != nil {
+		if missing(err) {
+			return oapi.GetMappingVersion404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.GetMappingVersion200JSONResponse(v), nil
+}
+
+func (h *Handler) TestMappingVersion(
+	ctx context.Context,
+	request oapi.TestMappingVersionRequestObject,
+) (oapi.TestMappingVersionResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.TestMappingVersion403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	result, err := h.svc.Test(ctx, request.OrgId, request.MappingId, request.Version)
+	if err != nil {
+		if missing(err) {
+			return oapi.TestMappingVersion404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.TestMappingVersion200JSONResponse(result), nil
+}
+
+// ── Fixtures ─────────────────────────────────────────────────────────────────
+
+func (h *Handler) ListMappingFixtures(
+	ctx context.Context,
+	request oapi.ListMappingFixturesRequestObject,
+) (oapi.ListMappingFixturesResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListMappingFixtures403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	fixtures, err := h.svc.ListFixtures(ctx, request.OrgId, request.MappingId)
+	if err != nil {
+		if missing(err) {
+			return oapi.ListMappingFixtures404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ListMappingFixtures200JSONResponse(fixtures), nil
+}
+
+func (h *Handler) CreateMappingFixture(
+	ctx context.Context,
+	request oapi.CreateMappingFixtureRequestObject,
+) (oapi.CreateMappingFixtureResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateMappingFixture403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateMappingFixture403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		return oapi.CreateMappingFixture400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Name must not be empty"),
+		}, nil
+	}
+
+	f, err := h.svc.CreateFixture(ctx, request.OrgId, request.MappingId, body)
+	if err != nil {
+		switch {
+		case missing(err):
+			return oapi.CreateMappingFixture404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.CreateMappingFixture409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("The mapping has a fixture with this name"),
+			}, nil
+		case errors.Is(err, ErrInvalid), errors.Is(err, ErrTooManyFixtures):
+			return oapi.CreateMappingFixture400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateMappingFixture201JSONResponse(f), nil
+}
+
+func (h *Handler) DeleteMappingFixture(
+	ctx context.Context,
+	request oapi.DeleteMappingFixtureRequestObject,
+) (oapi.DeleteMappingFixtureResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteMappingFixture403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteMappingFixture403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.DeleteFixture(ctx, request.OrgId, request.MappingId, request.FixtureId); err != nil {
+		if missing(err) {
+			return oapi.DeleteMappingFixture404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteMappingFixture204Response{}, nil
+}
+
+// ── Source mappings ──────────────────────────────────────────────────────────
+
+func (h *Handler) SetSourceMapping(
+	ctx context.Context,
+	request oapi.SetSourceMappingRequestObject,
+) (oapi.SetSourceMappingResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.SetSourceMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.SetSourceMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	if body.Version < 1 {
+		return oapi.SetSourceMapping400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Version must be at least 1"),
+		}, nil
+	}
+
+	src, err := h.svc.Activate(ctx, request.OrgId, request.SourceId, body.MappingId, body.Version)
+	if err != nil {
+		var failed *FixturesError
+		switch {
+		case missing(err):
+			return oapi.SetSourceMapping404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		case errors.As(err, &failed):
+			return oapi.SetSourceMapping409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: fixturesFailed(failed),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.SetSourceMapping200JSONResponse{
+		Body:    src,
+		Headers: oapi.SetSourceMapping200ResponseHeaders{ETag: httpx.ETag(src.Version)},
+	}, nil
+}
+
+func (h *Handler) ClearSourceMapping(
+	ctx context.Context,
+	request oapi.ClearSourceMappingRequestObject,
+) (oapi.ClearSourceMappingResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ClearSourceMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.ClearSourceMapping403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Deactivate(ctx, request.OrgId, request.SourceId); err != nil {
+		if missing(err) {
+			return oapi.ClearSourceMapping404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(err),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ClearSourceMapping204Response{}, nil
+}
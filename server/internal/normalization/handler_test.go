@@ -0,0 +1,137 @@
+package normalization_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func newSeededHandler(t *testing.T) (*normalization.Handler, oapi.Organization) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(
+		ctx,
+		fakeClerkUser("user_mapping_handler_owner", "ownr@example.com"),
+	)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), "Mapping Handler Org", nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+
+	svc, _ := newServices(db)
+	return normalization.NewHandler(svc, userSvc, orgSvc), o
+}
+
+func ownerCtx() context.Context {
+	return middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_mapping_handler_owner", "ownr@example.com"),
+	)
+}
+
+func TestListMappings_UnauthenticatedReturnsForbidden(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	resp, err := h.ListMappings(context.Background(), oapi.ListMappingsRequestObject{OrgId: org.Id})
+	if err != nil {
+		t.Fatalf("ListMappings: %v", err)
+	}
+	if _, ok := resp.(oapi.ListMappings403ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 403, got %T", resp)
+	}
+}
+
+func TestCreateMapping_Validation(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	status := func(resp oapi.CreateMappingResponseObject) int {
+		switch resp.(type) {
+		case oapi.CreateMapping201JSONResponse:
+			return 201
+		case oapi.CreateMapping400ApplicationProblemPlusJSONResponse:
+			return 400
+		case oapi.CreateMapping409ApplicationProblemPlusJSONResponse:
+			return 409
+		}
+		return 0
+	}
+	for _, c := range []struct {
+		name string
+		def  oapi.MappingDefinition
+		want int
+	}{
+		{"  ", firewall(1), 400},
+		{"broken", oapi.MappingDefinition{"fields": "not a list"}, 400},
+		{"firewall", firewall(1), 201},
+		{"firewall", firewall(4), 409},
+	} {
+		resp, err := h.CreateMapping(ownerCtx(), oapi.CreateMappingRequestObject{
+			OrgId: org.Id,
+			Body:  &oapi.CreateMappingJSONRequestBody{Name: c.name, Definition: c.def},
+		})
+		if err != nil {
+			t.Fatalf("CreateMapping %q: %v", c.name, err)
+		}
+		if got := status(resp); got != c.want {
+			t.Errorf("%q: want %d, got %T", c.name, c.want, resp)
+		}
+	}
+}
+
+func TestPreviewMapping_RequiresOneMappingAndBoundedSamples(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	def := firewall(1)
+	version := 1
+	tooMany := make([]map[string]any, 51)
+	for _, c := range []struct {
+		name string
+		body oapi.PreviewMappingRequest
+	}{
+		{"neither", oapi.PreviewMappingRequest{Samples: []map[string]any{{}}}},
+		{"both", oapi.PreviewMappingRequest{Definition: &def, Version: &version, Samples: []map[string]any{{}}}},
+		{"no samples", oapi.PreviewMappingRequest{Definition: &def}},
+		{"too many samples", oapi.PreviewMappingRequest{Definition: &def, Samples: tooMany}},
+	} {
+		resp, err := h.PreviewMapping(ownerCtx(), oapi.PreviewMappingRequestObject{OrgId: org.Id, Body: &c.body})
+		if err != nil {
+			t.Fatalf("PreviewMapping %s: %v", c.name, err)
+		}
+		if _, ok := resp.(oapi.PreviewMapping400ApplicationProblemPlusJSONResponse); !ok {
+			t.Errorf("%s: want 400, got %T", c.name, resp)
+		}
+	}
+
+	resp, err := h.PreviewMapping(ownerCtx(), oapi.PreviewMappingRequestObject{
+		OrgId: org.Id,
+		Body:  &oapi.PreviewMappingRequest{Definition: &def, Samples: []map[string]any{{"src": "10.0.0.1"}}},
+	})
+	if err != nil {
+		t.Fatalf("PreviewMapping: %v", err)
+	}
+	ok, isOK := resp.(oapi.PreviewMapping200JSONResponse)
+	if !isOK || len(ok.Results) != 1 || !ok.Results[0].Valid {
+		t.Errorf("preview: want one valid result, got %+v", resp)
+	}
+}
@@ -0,0 +1,118 @@
+package normalization
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/pipeline"
+)
+
+// evaluate runs a queued event through proc's parser and m as processing
+// would, keeping what processing drops: the attributes of an event that is
+// not valid OCSF, its validation errors and the fields m leaves unread.
+func evaluate(proc *pipeline.Processor, m *mapping.Mapping, payload []byte) oapi.MappingSampleResult {
+	res := oapi.MappingSampleResult{Errors: []oapi.OcsfFieldError{}}
+	fields, err := proc.Parse(payload)
+	if err != nil {
+		msg := err.Error()
+		res.Error = &msg
+		return res
+	}
+	if rest := m.Unmapped(fields); rest != nil {
+		res.Unmapped = &rest
+	}
+
+	attrs := m.Apply(fields)
+	ev, err := ocsf.Normalize(attrs)
+	var invalid *ocsf.ValidationError
+	switch {
+	case err == nil:
+		res.Valid = true
+		res.Output = toObject(ev)
+	case errors.As(err, &invalid):
+		for _, fe := range invalid.Errors {
+			res.Errors = append(res.Errors, oapi.OcsfFieldError{Path: fe.Path, Message: fe.Message})
+		}
+		res.Output = toObject(attrs)
+	default:
+		msg := err.Error()
+		res.Error = &msg
+	}
+	return res
+}
+
+// toObject renders v as the JSON object clients receive, so that expected
+// attributes compare against what they would read.
+func toObject(v any) *map[string]any {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil
+	}
+	return &obj
+}
+
+// check turns a fixture's evaluation into its result: it passes when its
+// input parses into a valid OCSF event with the expected attributes.
+func check(f oapi.MappingFixture, r oapi.MappingSampleResult) oapi.MappingFixtureResult {
+	res := oapi.MappingFixtureResult{
+		FixtureId: f.Id,
+		Name:      f.Name,
+		Output:    r.Output,
+		Errors:    r.Errors,
+		Error:     r.Error,
+	}
+	if r.Output != nil {
+		res.Errors = append(res.Errors, mismatches("", f.Expected, *r.Output)...)
+	}
+	res.Passed = r.Valid && len(res.Errors) == 0
+	return res
+}
+
+// mismatches lists the attributes of got that differ from want. Objects are
+// compared by the attributes want lists, so a fixture need only name those
+// it cares about; a null in want requires the attribute to be absent.
+func mismatches(prefix string, want, got map[string]any) []oapi.OcsfFieldError {
+	var errs []oapi.OcsfFieldError
+	for _, name := range slices.Sorted(maps.Keys(want)) {
+		path := prefix + name
+		w := want[name]
+		g, present := got[name]
+		if wObj, ok := w.(map[string]any); ok {
+			if gObj, ok := g.(map[string]any); ok {
+				errs = append(errs, mismatches(path+".", wObj, gObj)...)
+				continue
+			}
+		}
+		switch {
+		case w == nil && g != nil:
+			errs = append(errs, oapi.OcsfFieldError{Path: path, Message: "want none, got " + show(g)})
+		case w != nil && !present:
+			errs = append(errs, oapi.OcsfFieldError{Path: path, Message: "want " + show(w) + ", got none"})
+		case !reflect.DeepEqual(w, g):
+			errs = append(errs, oapi.OcsfFieldError{
+				Path:    path,
+				Message: fmt.Sprintf("want %s, got %s", show(w), show(g)),
+			})
+		}
+	}
+	return errs
+}
+
+func show(v any) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
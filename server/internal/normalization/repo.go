@@ -0,0 +1,425 @@
+package normalization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns mappings, mapping_versions and mapping_fixtures SQL and row→DTO
+// mapping. The tables are protected by RLS, so callers run statements inside
+// tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.Mappings) oapi.Mapping {
+	return oapi.Mapping{
+		Id:            m.ID,
+		OrgId:         m.OrgID,
+		Name:          m.Name,
+		Description:   m.Description,
+		LatestVersion: int(m.LatestVersion),
+		Version:       m.Version,
+		CreatedAt:     m.CreatedAt,
+		UpdatedAt:     m.UpdatedAt,
+	}
+}
+
+func toOapiVersion(m model.MappingVersions) oapi.MappingVersion {
+	return oapi.MappingVersion{
+		MappingId:  m.MappingID,
+		Version:    int(m.Version),
+		Definition: object(m.Definition),
+		CreatedAt:  m.CreatedAt,
+	}
+}
+
+func toOapiFixture(m model.MappingFixtures) oapi.MappingFixture {
+	return oapi.MappingFixture{
+		Id:        m.ID,
+		MappingId: m.MappingID,
+		Name:      m.Name,
+		Parser:    m.Parser,
+		Input:     object(m.Input),
+		Expected:  object(m.Expected),
+		CreatedAt: m.CreatedAt,
+	}
+}
+
+// object decodes a stored JSON object; one that no longer decodes reads as
+// empty.
+func object(raw string) map[string]any {
+	var obj map[string]any
+	if err := json.Unmarshal([]byte(raw), &obj); err != nil || obj == nil {
+		return map[string]any{}
+	}
+	return obj
+}
+
+// nameTaken reports whether err is a unique violation, on (org_id, name) for
+// mappings and (mapping_id, name) for fixtures.
+func nameTaken(err error) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// versionIn restricts an update to the listed versions; nil means any.
+func versionIn(col postgres.ColumnInteger, versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return col.IN(exprs...)
+}
+
+// ── Mappings ─────────────────────────────────────────────────────────────────
+
+// Insert stores a new mapping without versions. Returns ErrConflict when the
+// org already has a mapping of that name.
+func (r *Repo) Insert(ctx context.Context, orgID uuid.UUID, name string, description *string) (oapi.Mapping, error) {
+	t := table.Mappings
+	stmt := t.
+		INSERT(t.OrgID, t.Name, t.Description).
+		VALUES(orgID, name, emptyToNil(description)).
+		RETURNING(t.AllColumns)
+
+	var row model.Mappings
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if nameTaken(err) {
+			return oapi.Mapping{}, ErrConflict
+		}
+		return oapi.Mapping{}, fmt.Errorf("inserting mapping: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// List returns the org's mappings ordered by name.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID) ([]oapi.Mapping, error) {
+	t := table.Mappings
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID))).
+		ORDER_BY(t.Name.ASC())
+
+	var rows []model.Mappings
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing mappings: %w", err)
+	}
+	mappings := make([]oapi.Mapping, 0, len(rows))
+	for _, row := range rows {
+		mappings = append(mappings, toOapi(row))
+	}
+	return mappings, nil
+}
+
+// Get returns a single mapping of the org. Returns ErrNotFound when it does
+// not exist in that org.
+func (r *Repo) Get(ctx context.Context, orgID, mappingID uuid.UUID) (oapi.Mapping, error) {
+	t := table.Mappings
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(mappingID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.Mappings
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.Mapping{}, ErrNotFound
+		}
+		return oapi.Mapping{}, fmt.Errorf("getting mapping: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Update patches the fields set in req and bumps the version. An empty
+// description clears it. When ifMatch is non-nil the update only applies if
+// the current version is listed, otherwise ErrPreconditionFailed is returned.
+func (r *Repo) Update(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	req oapi.UpdateMappingRequest,
+	ifMatch []int64,
+) (oapi.Mapping, error) {
+	t := table.Mappings
+	name := postgres.StringExpression(t.Name)
+	if req.Name != nil {
+		name = postgres.String(*req.Name)
+	}
+	description := postgres.StringExpression(t.Description)
+	switch {
+	case req.Description == nil:
+	case *req.Description == "":
+		description = postgres.StringExp(postgres.NULL)
+	default:
+		description = postgres.String(*req.Description)
+	}
+
+	stmt := t.
+		UPDATE(t.Name, t.Description, t.Version, t.UpdatedAt).
+		SET(name, description, t.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(mappingID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))).
+				AND(versionIn(t.Version, ifMatch)),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Mappings
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	switch {
+	case err == nil:
+		return toOapi(row), nil
+	case nameTaken(err):
+		return oapi.Mapping{}, ErrConflict
+	case !errors.Is(err, qrm.ErrNoRows):
+		return oapi.Mapping{}, fmt.Errorf("updating mapping: %w", err)
+	}
+	if _, err := r.Get(ctx, orgID, mappingID); err != nil {
+		return oapi.Mapping{}, err
+	}
+	return oapi.Mapping{}, ErrPreconditionFailed
+}
+
+// Delete removes a mapping with its versions and fixtures. Returns
+// ErrNotFound when no row matched.
+func (r *Repo) Delete(ctx context.Context, orgID, mappingID uuid.UUID) error {
+	t := table.Mappings
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(mappingID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting mapping: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SourcesUsing counts the org's sources whose active version is one of the
+// mapping's.
+func (r *Repo) SourcesUsing(ctx context.Context, orgID, mappingID uuid.UUID) (int64, error) {
+	t := table.Sources
+	stmt := postgres.
+		SELECT(postgres.COUNT(postgres.STAR).AS("count")).
+		FROM(t).
+		WHERE(
+			t.OrgID.EQ(postgres.UUID(orgID)).
+				AND(t.MappingID.EQ(postgres.UUID(mappingID))),
+		)
+
+	var dest struct{ Count int64 }
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &dest); err != nil {
+		return 0, fmt.Errorf("counting sources using mapping: %w", err)
+	}
+	return dest.Count, nil
+}
+
+// ── Versions ─────────────────────────────────────────────────────────────────
+
+// InsertVersion stores definition as the mapping's next version and bumps the
+// mapping's version with its latest_version. Numbering takes the mapping's
+// row lock, so concurrent versions of one mapping queue behind each other.
+// Returns ErrNotFound when the mapping does not exist in the org.
+func (r *Repo) InsertVersion(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	definition []byte,
+) (oapi.MappingVersion, error) {
+	m := table.Mappings
+	bump := m.
+		UPDATE(m.LatestVersion, m.Version, m.UpdatedAt).
+		SET(m.LatestVersion.ADD(postgres.Int(1)), m.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			m.ID.EQ(postgres.UUID(mappingID)).
+				AND(m.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		RETURNING(m.LatestVersion)
+
+	var mapping model.Mappings
+	if err := bump.QueryContext(ctx, tx.Executor(ctx, r.db), &mapping); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.MappingVersion{}, ErrNotFound
+		}
+		return oapi.MappingVersion{}, fmt.Errorf("numbering mapping version: %w", err)
+	}
+
+	t := table.MappingVersions
+	stmt := t.
+		INSERT(t.MappingID, t.Version, t.OrgID, t.Definition).
+		VALUES(mappingID, mapping.LatestVersion, orgID, string(definition)).
+		RETURNING(t.AllColumns)
+
+	var row model.MappingVersions
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		return oapi.MappingVersion{}, fmt.Errorf("inserting mapping version: %w", err)
+	}
+	return toOapiVersion(row), nil
+}
+
+// ListVersions returns the mapping's versions, newest first.
+func (r *Repo) ListVersions(ctx context.Context, orgID, mappingID uuid.UUID) ([]oapi.MappingVersion, error) {
+	t := table.MappingVersions
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.MappingID.EQ(postgres.UUID(mappingID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		ORDER_BY(t.Version.DESC())
+
+	var rows []model.MappingVersions
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing mapping versions: %w", err)
+	}
+	versions := make([]oapi.MappingVersion, 0, len(rows))
+	for _, row := range rows {
+		versions = append(versions, toOapiVersion(row))
+	}
+	return versions, nil
+}
+
+// GetVersion returns one version of a mapping of the org as stored. Returns
+// ErrVersionNotFound when the mapping does not have it.
+func (r *Repo) GetVersion(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	version int,
+) (model.MappingVersions, error) {
+	t := table.MappingVersions
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.MappingID.EQ(postgres.UUID(mappingID)).
+				AND(t.Version.EQ(postgres.Int32(int32(version)))).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.MappingVersions
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return model.MappingVersions{}, fmt.Errorf("%w: %d", ErrVersionNotFound, version)
+		}
+		return model.MappingVersions{}, fmt.Errorf("getting mapping version: %w", err)
+	}
+	return row, nil
+}
+
+// ── Fixtures ─────────────────────────────────────────────────────────────────
+
+// InsertFixture stores a fixture of the mapping. Returns ErrConflict when the
+// mapping already has a fixture of that name.
+func (r *Repo) InsertFixture(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	req oapi.CreateMappingFixtureRequest,
+) (oapi.MappingFixture, error) {
+	input, err := json.Marshal(req.Input)
+	if err != nil {
+		return oapi.MappingFixture{}, fmt.Errorf("encoding fixture input: %w", err)
+	}
+	expected, err := json.Marshal(req.Expected)
+	if err != nil {
+		return oapi.MappingFixture{}, fmt.Errorf("encoding fixture expectation: %w", err)
+	}
+
+	t := table.MappingFixtures
+	stmt := t.
+		INSERT(t.MappingID, t.OrgID, t.Name, t.Parser, t.Input, t.Expected).
+		VALUES(mappingID, orgID, req.Name, emptyToNil(req.Parser), string(input), string(expected)).
+		RETURNING(t.AllColumns)
+
+	var row model.MappingFixtures
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if nameTaken(err) {
+			return oapi.MappingFixture{}, ErrConflict
+		}
+		return oapi.MappingFixture{}, fmt.Errorf("inserting mapping fixture: %w", err)
+	}
+	return toOapiFixture(row), nil
+}
+
+// ListFixtures returns the mapping's fixtures ordered by name.
+func (r *Repo) ListFixtures(ctx context.Context, orgID, mappingID uuid.UUID) ([]oapi.MappingFixture, error) {
+	t := table.MappingFixtures
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.MappingID.EQ(postgres.UUID(mappingID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		ORDER_BY(t.Name.ASC())
+
+	var rows []model.MappingFixtures
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing mapping fixtures: %w", err)
+	}
+	fixtures := make([]oapi.MappingFixture, 0, len(rows))
+	for _, row := range rows {
+		fixtures = append(fixtures, toOapiFixture(row))
+	}
+	return fixtures, nil
+}
+
+// DeleteFixture removes a fixture of the mapping. Returns ErrFixtureNotFound
+// when no row matched.
+func (r *Repo) DeleteFixture(ctx context.Context, orgID, mappingID, fixtureID uuid.UUID) error {
+	t := table.MappingFixtures
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(fixtureID)).
+				AND(t.MappingID.EQ(postgres.UUID(mappingID))).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting mapping fixture: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrFixtureNotFound
+	}
+	return nil
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,520 @@
+// Package normalization owns an organisation's OCSF mappings: their
+// immutable, numbered versions, the test fixtures each version must pass
+// before a source may activate it, and previews of what a mapping makes of
+// sample events. The mapping language itself lives in package mapping; the
+// pipeline applies the version a source has active.
+package normalization
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+// ErrNotFound is returned when a mapping does not exist in the org.
+var ErrNotFound = errors.New("mapping not found")
+
+// ErrVersionNotFound is returned when a mapping does not have a version. It is
+// the pipeline's error, so that a source's pinned or active version reads the
+// same either way.
+var ErrVersionNotFound = pipeline.ErrMappingVersion
+
+// ErrFixtureNotFound is returned when a fixture does not exist on the mapping.
+var ErrFixtureNotFound = errors.New("mapping fixture not found")
+
+// ErrConflict is returned when the org already has a mapping of that name, or
+// the mapping a fixture of that name.
+var ErrConflict = errors.New("mapping name taken")
+
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("mapping version mismatch")
+
+// ErrInvalid is returned when a definition does not compile, or a fixture or
+// preview names a parser the org does not have.
+var ErrInvalid = mapping.ErrInvalid
+
+// ErrInUse is returned when deleting a mapping a source has active.
+var ErrInUse = errors.New("mapping in use")
+
+// ErrTooManyFixtures is returned when a mapping already has maxFixtures.
+var ErrTooManyFixtures = errors.New("too many mapping fixtures")
+
+// ErrFixturesFailed is returned, as a *FixturesError, when activating a
+// version some of the mapping's fixtures fail against.
+var ErrFixturesFailed = errors.New("mapping fixtures failed")
+
+// FixturesError carries the fixture results that prevented an activation.
+type FixturesError struct {
+	Result oapi.MappingTestResult
+}
+
+func (e *FixturesError) Error() string {
+	var names []string
+	for _, f := range e.Result.Fixtures {
+		if !f.Passed {
+			names = append(names, f.Name)
+		}
+	}
+	return fmt.Sprintf("%s: %s", ErrFixturesFailed, strings.Join(names, ", "))
+}
+
+func (e *FixturesError) Unwrap() error { return ErrFixturesFailed }
+
+const (
+	// maxFixtures caps the fixtures of one mapping, all of which run on every
+	// activation.
+	maxFixtures = 100
+	// compiledCacheSize caps the mapping versions kept compiled.
+	compiledCacheSize = 256
+)
+
+// Service orchestrates mapping operations. Each runs in a transaction carrying
+// the caller's tenant scope, joining one already on ctx. Mutations append
+// their domain event to the outbox in that same transaction.
+type Service struct {
+	repo     *Repo
+	sources  *source.Service
+	patterns *pattern.Service
+	tx       *tx.Manager
+	events   *outbox.Repo
+	logger   *slog.Logger
+	compiled *mapping.Cache
+}
+
+// NewService wires a Service with its repo, the source and pattern services,
+// transaction manager, outbox and logger.
+func NewService(
+	repo *Repo,
+	sources *source.Service,
+	patterns *pattern.Service,
+	txm *tx.Manager,
+	events *outbox.Repo,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		repo:     repo,
+		sources:  sources,
+		patterns: patterns,
+		tx:       txm,
+		events:   events,
+		logger:   logger,
+		compiled: mapping.NewCache(compiledCacheSize),
+	}
+}
+
+// compile checks a definition given over the API and returns it encoded for
+// storage with its compiled mapping. Returns ErrInvalid when it does not
+// compile.
+func compile(def oapi.MappingDefinition) ([]byte, *mapping.Mapping, error) {
+	raw, err := json.Marshal(def)
+	if err != nil {
+		return nil, nil, fmt.Errorf("encoding mapping definition: %w", err)
+	}
+	m, err := mapping.Compile(raw)
+	if err != nil {
+		return nil, nil, err
+	}
+	return raw, m, nil
+}
+
+// Create defines a mapping with def as its first version. Returns ErrConflict
+// when the name is taken and ErrInvalid when def does not compile.
+func (s *Service) Create(ctx context.Context, orgID uuid.UUID, req oapi.CreateMappingRequest) (oapi.Mapping, error) {
+	raw, _, err := compile(req.Definition)
+	if err != nil {
+		return oapi.Mapping{}, err
+	}
+	var m oapi.Mapping
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		created, err := s.repo.Insert(ctx, orgID, req.Name, req.Description)
+		if err != nil {
+			return err
+		}
+		if _, err := s.repo.InsertVersion(ctx, orgID, created.Id, raw); err != nil {
+			return err
+		}
+		if m, err = s.repo.Get(ctx, orgID, created.Id); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, m.Id, EventCreated, m)
+	})
+	return m, err
+}
+
+// List returns the org's mappings.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.Mapping, error) {
+	var mappings []oapi.Mapping
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		mappings, err = s.repo.List(ctx, orgID)
+		return err
+	})
+	return mappings, err
+}
+
+// Get returns a single mapping. Returns ErrNotFound when it does not exist in
+// the org.
+func (s *Service) Get(ctx context.Context, orgID, mappingID uuid.UUID) (oapi.Mapping, error) {
+	var m oapi.Mapping
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		m, err = s.repo.Get(ctx, orgID, mappingID)
+		return err
+	})
+	return m, err
+}
+
+// Update renames or describes a mapping. A non-nil ifMatch makes the update
+// conditional on the mapping's version.
+func (s *Service) Update(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	req oapi.UpdateMappingRequest,
+	ifMatch []int64,
+) (oapi.Mapping, error) {
+	var m oapi.Mapping
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if m, err = s.repo.Update(ctx, orgID, mappingID, req, ifMatch); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, mappingID, EventUpdated, m)
+	})
+	return m, err
+}
+
+// Delete removes a mapping with its versions and fixtures. Returns ErrInUse
+// while a source has one of its versions active.
+func (s *Service) Delete(ctx context.Context, orgID, mappingID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		m, err := s.repo.Get(ctx, orgID, mappingID)
+		if err != nil {
+			return err
+		}
+		n, err := s.repo.SourcesUsing(ctx, orgID, mappingID)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%w by %d source(s)", ErrInUse, n)
+		}
+
+		if err := s.repo.Delete(ctx, orgID, mappingID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, mappingID, EventDeleted, DeletedPayload{ID: mappingID, OrgID: orgID, Name: m.Name})
+	})
+}
+
+// ── Versions ─────────────────────────────────────────────────────────────────
+
+// CreateVersion adds def as the mapping's next version. Returns ErrInvalid
+// when def does not compile.
+func (s *Service) CreateVersion(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	def oapi.MappingDefinition,
+) (oapi.MappingVersion, error) {
+	raw, _, err := compile(def)
+	if err != nil {
+		return oapi.MappingVersion{}, err
+	}
+	var v oapi.MappingVersion
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if v, err = s.repo.InsertVersion(ctx, orgID, mappingID, raw); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, mappingID, EventVersionCreated, v)
+	})
+	return v, err
+}
+
+// ListVersions returns the mapping's versions, newest first. Returns
+// ErrNotFound when the mapping does not exist in the org.
+func (s *Service) ListVersions(ctx context.Context, orgID, mappingID uuid.UUID) ([]oapi.MappingVersion, error) {
+	var versions []oapi.MappingVersion
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, mappingID); err != nil {
+			return err
+		}
+		var err error
+		versions, err = s.repo.ListVersions(ctx, orgID, mappingID)
+		return err
+	})
+	return versions, err
+}
+
+// GetVersion returns one version of a mapping. Returns ErrNotFound when the
+// mapping does not exist in the org and ErrVersionNotFound when it does not
+// have the version.
+func (s *Service) GetVersion(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	version int,
+) (oapi.MappingVersion, error) {
+	var v oapi.MappingVersion
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, mappingID); err != nil {
+			return err
+		}
+		row, err := s.repo.GetVersion(ctx, orgID, mappingID, version)
+		if err != nil {
+			return err
+		}
+		v = toOapiVersion(row)
+		return nil
+	})
+	return v, err
+}
+
+// Compiled returns a version of a mapping of the org compiled, compiling each
+// stored definition once. Returns ErrVersionNotFound when the mapping does
+// not have the version.
+func (s *Service) Compiled(ctx context.Context, orgID, mappingID uuid.UUID, version int) (*mapping.Mapping, error) {
+	var definition string
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.GetVersion(ctx, orgID, mappingID, version)
+		if err != nil {
+			return err
+		}
+		definition = row.Definition
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.compiled.Compile([]byte(definition))
+}
+
+// ── Fixtures ─────────────────────────────────────────────────────────────────
+
+// CreateFixture saves a test fixture for the mapping. Returns ErrConflict when
+// the mapping has a fixture of that name, ErrInvalid when it names a parser
+// the org does not have and ErrTooManyFixtures when it has maxFixtures.
+func (s *Service) CreateFixture(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	req oapi.CreateMappingFixtureRequest,
+) (oapi.MappingFixture, error) {
+	var f oapi.MappingFixture
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, mappingID); err != nil {
+			return err
+		}
+		if _, err := s.processor(ctx, orgID, req.Parser); err != nil {
+			return err
+		}
+		fixtures, err := s.repo.ListFixtures(ctx, orgID, mappingID)
+		if err != nil {
+			return err
+		}
+		if len(fixtures) >= maxFixtures {
+			return fmt.Errorf("%w: at most %d per mapping", ErrTooManyFixtures, maxFixtures)
+		}
+		if f, err = s.repo.InsertFixture(ctx, orgID, mappingID, req); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, mappingID, EventFixtureCreated, f)
+	})
+	return f, err
+}
+
+// ListFixtures returns the mapping's fixtures. Returns ErrNotFound when the
+// mapping does not exist in the org.
+func (s *Service) ListFixtures(ctx context.Context, orgID, mappingID uuid.UUID) ([]oapi.MappingFixture, error) {
+	var fixtures []oapi.MappingFixture
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, mappingID); err != nil {
+			return err
+		}
+		var err error
+		fixtures, err = s.repo.ListFixtures(ctx, orgID, mappingID)
+		return err
+	})
+	return fixtures, err
+}
+
+// DeleteFixture removes a fixture of the mapping.
+func (s *Service) DeleteFixture(ctx context.Context, orgID, mappingID, fixtureID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if err := s.repo.DeleteFixture(ctx, orgID, mappingID, fixtureID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, mappingID, EventFixtureDeleted, FixtureDeletedPayload{
+			ID:        fixtureID,
+			MappingID: mappingID,
+			OrgID:     orgID,
+		})
+	})
+}
+
+// ── Preview and tests ────────────────────────────────────────────────────────
+
+// processor returns a Processor parsing with the named parser of the org, or
+// none. Returns ErrInvalid when the org has no such parser.
+func (s *Service) processor(ctx context.Context, orgID uuid.UUID, name *string) (*pipeline.Processor, error) {
+	if name == nil || *name == "" {
+		return pipeline.NewProcessor("", nil), nil
+	}
+	p, err := s.patterns.Parser(ctx, orgID, *name)
+	if err != nil {
+		if errors.Is(err, parser.ErrUnknownParser) {
+			return nil, fmt.Errorf("%w: unknown parser %q", ErrInvalid, *name)
+		}
+		return nil, err
+	}
+	return pipeline.NewProcessor(*name, p), nil
+}
+
+// Preview runs each sample through the parser and mapping the request names,
+// an inline definition or a stored version, storing nothing. Returns
+// ErrInvalid for a definition that does not compile or an unknown parser, and
+// ErrNotFound or ErrVersionNotFound for a stored version that does not exist.
+func (s *Service) Preview(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.PreviewMappingRequest,
+) (oapi.MappingPreviewResult, error) {
+	var m *mapping.Mapping
+	var err error
+	switch {
+	case req.Definition != nil:
+		_, m, err = compile(*req.Definition)
+	case req.MappingId != nil && req.Version != nil:
+		if _, err = s.Get(ctx, orgID, *req.MappingId); err == nil {
+			m, err = s.Compiled(ctx, orgID, *req.MappingId, *req.Version)
+		}
+	default:
+		err = fmt.Errorf("%w: a definition, or a mapping and version, is required", ErrInvalid)
+	}
+	if err != nil {
+		return oapi.MappingPreviewResult{}, err
+	}
+	proc, err := s.processor(ctx, orgID, req.Parser)
+	if err != nil {
+		return oapi.MappingPreviewResult{}, err
+	}
+
+	results := make([]oapi.MappingSampleResult, 0, len(req.Samples))
+	for _, sample := range req.Samples {
+		payload, err := json.Marshal(sample)
+		if err != nil {
+			return oapi.MappingPreviewResult{}, fmt.Errorf("encoding sample: %w", err)
+		}
+		results = append(results, evaluate(proc, m, payload))
+	}
+	return oapi.MappingPreviewResult{Results: results}, nil
+}
+
+// Test runs the mapping's fixtures against one of its versions. Returns
+// ErrNotFound when the mapping does not exist in the org and
+// ErrVersionNotFound when it does not have the version.
+func (s *Service) Test(
+	ctx context.Context,
+	orgID, mappingID uuid.UUID,
+	version int,
+) (oapi.MappingTestResult, error) {
+	result := oapi.MappingTestResult{Passed: true, Fixtures: []oapi.MappingFixtureResult{}}
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, mappingID); err != nil {
+			return err
+		}
+		m, err := s.Compiled(ctx, orgID, mappingID, version)
+		if err != nil {
+			return err
+		}
+		fixtures, err := s.repo.ListFixtures(ctx, orgID, mappingID)
+		if err != nil {
+			return err
+		}
+		for _, f := range fixtures {
+			r, err := s.runFixture(ctx, orgID, m, f)
+			if err != nil {
+				return err
+			}
+			result.Passed = result.Passed && r.Passed
+			result.Fixtures = append(result.Fixtures, r)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// runFixture runs one fixture against m. A parser the org no longer has fails
+// the fixture rather than the run.
+func (s *Service) runFixture(
+	ctx context.Context,
+	orgID uuid.UUID,
+	m *mapping.Mapping,
+	f oapi.MappingFixture,
+) (oapi.MappingFixtureResult, error) {
+	proc, err := s.processor(ctx, orgID, f.Parser)
+	if errors.Is(err, ErrInvalid) {
+		msg := err.Error()
+		return oapi.MappingFixtureResult{
+			FixtureId: f.Id,
+			Name:      f.Name,
+			Errors:    []oapi.OcsfFieldError{},
+			Error:     &msg,
+		}, nil
+	}
+	if err != nil {
+		return oapi.MappingFixtureResult{}, err
+	}
+	payload, err := json.Marshal(f.Input)
+	if err != nil {
+		return oapi.MappingFixtureResult{}, fmt.Errorf("encoding fixture input: %w", err)
+	}
+	return check(f, evaluate(proc, m, payload)), nil
+}
+
+// ── Activation ───────────────────────────────────────────────────────────────
+
+// Activate makes a version of the mapping the source's active one, once every
+// fixture of the mapping passes against it. Returns source.ErrNotFound for an
+// unknown source, ErrNotFound or ErrVersionNotFound for an unknown version
+// and a *FixturesError when fixtures fail.
+func (s *Service) Activate(
+	ctx context.Context,
+	orgID, sourceID, mappingID uuid.UUID,
+	version int,
+) (oapi.Source, error) {
+	var src oapi.Source
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.sources.Get(ctx, orgID, sourceID); err != nil {
+			return err
+		}
+		result, err := s.Test(ctx, orgID, mappingID, version)
+		if err != nil {
+			return err
+		}
+		if !result.Passed {
+			return &FixturesError{Result: result}
+		}
+		src, err = s.sources.SetMapping(ctx, orgID, sourceID, &mappingID, &version)
+		return err
+	})
+	return src, err
+}
+
+// Deactivate clears the source's mapping, so its events are no longer
+// normalized. Returns source.ErrNotFound for an unknown source.
+func (s *Service) Deactivate(ctx context.Context, orgID, sourceID uuid.UUID) error {
+	_, err := s.sources.SetMapping(ctx, orgID, sourceID, nil, nil)
+	return err
+}
@@ -0,0 +1,265 @@
+package normalization_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+func newServices(db *sql.DB) (*normalization.Service, *source.Service) {
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+	sourceSvc := source.NewService(source.NewRepo(db), txm, events, logger)
+	patternSvc := pattern.NewService(pattern.NewRepo(db), txm, events, logger)
+	return normalization.NewService(normalization.NewRepo(db), sourceSvc, patternSvc, txm, events, logger), sourceSvc
+}
+
+func newSeededService(t *testing.T) (*normalization.Service, *source.Service, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_mapping_seed", "Mapping Test Org")
+	svc, sourceSvc := newServices(db)
+	return svc, sourceSvc, orgID
+}
+
+// firewall is a network activity mapping of events with a src field, at the
+// given severity.
+func firewall(severity int) oapi.MappingDefinition {
+	return oapi.MappingDefinition{
+		"defaults": map[string]any{
+			"class_uid":        4001,
+			"activity_id":      6,
+			"severity_id":      severity,
+			"time":             0,
+			"metadata":         map[string]any{"product": map[string]any{"name": "Firewall"}},
+			"dst_endpoint":     map[string]any{"ip": "10.0.0.2"},
+			"src_endpoint.uid": "fw1",
+		},
+		"fields": []any{map[string]any{"to": "src_endpoint.ip", "from": "src"}},
+	}
+}
+
+func mustCreate(
+	t *testing.T,
+	ctx context.Context,
+	svc *normalization.Service,
+	orgID uuid.UUID,
+	name string,
+	def oapi.MappingDefinition,
+) oapi.Mapping {
+	t.Helper()
+	m, err := svc.Create(ctx, orgID, oapi.CreateMappingRequest{Name: name, Definition: def})
+	if err != nil {
+		t.Fatalf("Create %s: %v", name, err)
+	}
+	return m
+}
+
+func TestCreateMapping_NumbersImmutableVersions(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	m := mustCreate(t, ctx, svc, orgID, "firewall", firewall(1))
+	if m.LatestVersion != 1 {
+		t.Fatalf("Create: want latest version 1, got %d", m.LatestVersion)
+	}
+	v, err := svc.CreateVersion(ctx, orgID, m.Id, firewall(4))
+	if err != nil || v.Version != 2 {
+		t.Fatalf("CreateVersion: want version 2, got %+v (%v)", v, err)
+	}
+
+	versions, err := svc.ListVersions(ctx, orgID, m.Id)
+	if err != nil || len(versions) != 2 || versions[0].Version != 2 || versions[1].Version != 1 {
+		t.Fatalf("ListVersions: want 2 then 1, got %+v (%v)", versions, err)
+	}
+	first, err := svc.GetVersion(ctx, orgID, m.Id, 1)
+	if err != nil {
+		t.Fatalf("GetVersion: %v", err)
+	}
+	if defaults, _ := first.Definition["defaults"].(map[string]any); defaults["severity_id"] != float64(1) {
+		t.Errorf("version 1: want its own definition, got %v", first.Definition)
+	}
+	if _, err := svc.GetVersion(ctx, orgID, m.Id, 3); !errors.Is(err, normalization.ErrVersionNotFound) {
+		t.Errorf("GetVersion 3: want ErrVersionNotFound, got %v", err)
+	}
+
+	for _, c := range []struct {
+		name string
+		def  oapi.MappingDefinition
+		want error
+	}{
+		{"firewall", firewall(1), normalization.ErrConflict},
+		{"broken", oapi.MappingDefinition{"fields": "not a list"}, normalization.ErrInvalid},
+		{"unknown", oapi.MappingDefinition{"transforms": []any{}}, normalization.ErrInvalid},
+	} {
+		_, err := svc.Create(ctx, orgID, oapi.CreateMappingRequest{Name: c.name, Definition: c.def})
+		if !errors.Is(err, c.want) {
+			t.Errorf("Create %s: want %v, got %v", c.name, c.want, err)
+		}
+	}
+	_, err = svc.CreateVersion(ctx, orgID, m.Id, oapi.MappingDefinition{"fields": 1})
+	if !errors.Is(err, normalization.ErrInvalid) {
+		t.Errorf("CreateVersion broken: want ErrInvalid, got %v", err)
+	}
+}
+
+func TestActivate_RequiresPassingFixtures(t *testing.T) {
+	svc, sourceSvc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	m := mustCreate(t, ctx, svc, orgID, "firewall", firewall(1))
+	if _, err := svc.CreateVersion(ctx, orgID, m.Id, firewall(4)); err != nil {
+		t.Fatalf("CreateVersion: %v", err)
+	}
+	_, err := svc.CreateFixture(ctx, orgID, m.Id, oapi.CreateMappingFixtureRequest{
+		Name:     "blocked connection",
+		Input:    map[string]any{"src": "10.0.0.1"},
+		Expected: map[string]any{"severity_id": 4, "src_endpoint": map[string]any{"ip": "10.0.0.1"}},
+	})
+	if err != nil {
+		t.Fatalf("CreateFixture: %v", err)
+	}
+	_, err = svc.CreateFixture(ctx, orgID, m.Id, oapi.CreateMappingFixtureRequest{
+		Name:     "unknown parser",
+		Parser:   strPtr("grok:NOT_DEFINED"),
+		Input:    map[string]any{},
+		Expected: map[string]any{},
+	})
+	if !errors.Is(err, normalization.ErrInvalid) {
+		t.Errorf("CreateFixture with unknown parser: want ErrInvalid, got %v", err)
+	}
+
+	src, err := sourceSvc.Create(ctx, orgID, oapi.CreateSourceRequest{Name: "edge", Type: oapi.Syslog})
+	if err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+
+	_, err = svc.Activate(ctx, orgID, src.Id, m.Id, 1)
+	var failed *normalization.FixturesError
+	if !errors.As(err, &failed) || !errors.Is(err, normalization.ErrFixturesFailed) {
+		t.Fatalf("Activate 1: want a FixturesError, got %v", err)
+	}
+	if got := failed.Result.Fixtures; len(got) != 1 || got[0].Passed || len(got[0].Errors) != 1 ||
+		got[0].Errors[0].Path != "severity_id" {
+		t.Errorf("Activate 1: want severity_id mismatch, got %+v", got)
+	}
+
+	activated, err := svc.Activate(ctx, orgID, src.Id, m.Id, 2)
+	if err != nil {
+		t.Fatalf("Activate 2: %v", err)
+	}
+	if activated.MappingId == nil || *activated.MappingId != m.Id || activated.MappingVersion == nil ||
+		*activated.MappingVersion != 2 || activated.Version != src.Version+1 {
+		t.Errorf("Activate 2: got mapping %v version %v, source version %d",
+			activated.MappingId, activated.MappingVersion, activated.Version)
+	}
+
+	if err := svc.Delete(ctx, orgID, m.Id); !errors.Is(err, normalization.ErrInUse) {
+		t.Fatalf("Delete active mapping: want ErrInUse, got %v", err)
+	}
+	if err := svc.Deactivate(ctx, orgID, src.Id); err != nil {
+		t.Fatalf("Deactivate: %v", err)
+	}
+	if err := svc.Delete(ctx, orgID, m.Id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := svc.Get(ctx, orgID, m.Id); !errors.Is(err, normalization.ErrNotFound) {
+		t.Errorf("Get deleted: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestPreview_ReportsErrorsAndUnmappedFields(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	def := firewall(1)
+	result, err := svc.Preview(ctx, orgID, oapi.PreviewMappingRequest{
+		Definition: &def,
+		Samples: []map[string]any{
+			{"src": "10.0.0.1", "user": "alice"},
+			{"src": 42},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Preview: %v", err)
+	}
+	if len(result.Results) != 2 {
+		t.Fatalf("Preview: want 2 results, got %d", len(result.Results))
+	}
+
+	ok := result.Results[0]
+	if !ok.Valid || ok.Output == nil || (*ok.Output)["type_uid"] != float64(400106) {
+		t.Errorf("sample 1: want a valid event, got %+v", ok)
+	}
+	if ok.Unmapped == nil || (*ok.Unmapped)["user"] != "alice" || len(*ok.Unmapped) != 1 {
+		t.Errorf("sample 1: want user unmapped, got %v", ok.Unmapped)
+	}
+
+	bad := result.Results[1]
+	if bad.Valid || len(bad.Errors) == 0 || bad.Errors[0].Path != "src_endpoint.ip" {
+		t.Errorf("sample 2: want an src_endpoint.ip error, got %+v", bad)
+	}
+
+	m := mustCreate(t, ctx, svc, orgID, "firewall", def)
+	v := 2
+	_, err = svc.Preview(ctx, orgID, oapi.PreviewMappingRequest{
+		MappingId: &m.Id,
+		Version:   &v,
+		Samples:   []map[string]any{{}},
+	})
+	if !errors.Is(err, normalization.ErrVersionNotFound) {
+		t.Errorf("Preview unknown version: want ErrVersionNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,341 @@
+package ocsf
+
+// BaseEvent holds the attributes every event class has.
+type BaseEvent struct {
+	ActivityID     int            `json:"activity_id"`
+	ActivityName   string         `json:"activity_name,omitempty"`
+	CategoryUID    int            `json:"category_uid"`
+	CategoryName   string         `json:"category_name,omitempty"`
+	ClassUID       int            `json:"class_uid"`
+	ClassName      string         `json:"class_name,omitempty"`
+	TypeUID        int            `json:"type_uid"`
+	TypeName       string         `json:"type_name,omitempty"`
+	Time           int64          `json:"time"`
+	SeverityID     int            `json:"severity_id"`
+	Severity       string         `json:"severity,omitempty"`
+	StatusID       int            `json:"status_id,omitempty"`
+	Status         string         `json:"status,omitempty"`
+	StatusCode     string         `json:"status_code,omitempty"`
+	StatusDetail   string         `json:"status_detail,omitempty"`
+	Message        string         `json:"message,omitempty"`
+	Count          int            `json:"count,omitempty"`
+	Duration       int64          `json:"duration,omitempty"`
+	StartTime      int64          `json:"start_time,omitempty"`
+	EndTime        int64          `json:"end_time,omitempty"`
+	TimezoneOffset int            `json:"timezone_offset,omitempty"`
+	RawData        string         `json:"raw_data,omitempty"`
+	Metadata       Metadata       `json:"metadata"`
+	Device         *Device        `json:"device,omitempty"`
+	Observables    []Observable   `json:"observables,omitempty"`
+	Enrichments    []Enrichment   `json:"enrichments,omitempty"`
+	Unmapped       map[string]any `json:"unmapped,omitempty"`
+}
+
+// Common returns the attributes every class has.
+func (e *BaseEvent) Common() *BaseEvent { return e }
+
+// Network holds the attributes of the Network Activity category's classes.
+type Network struct {
+	SrcEndpoint    *Endpoint       `json:"src_endpoint,omitempty"`
+	DstEndpoint    *Endpoint       `json:"dst_endpoint,omitempty"`
+	ConnectionInfo *ConnectionInfo `json:"connection_info,omitempty"`
+	Traffic        *Traffic        `json:"traffic,omitempty"`
+}
+
+func (n *Network) captions() {
+	if c := n.ConnectionInfo; c != nil && c.DirectionID != Unknown {
+		caption(&c.Direction, c.DirectionID, directionNames)
+	}
+}
+
+// FileActivity is a File System Activity event (1001).
+type FileActivity struct {
+	BaseEvent
+	Actor      *Actor `json:"actor,omitempty"`
+	File       *File  `json:"file,omitempty"`
+	FileResult *File  `json:"file_result,omitempty"`
+	AccessMask int    `json:"access_mask,omitempty"`
+}
+
+// ProcessActivity is a Process Activity event (1007).
+type ProcessActivity struct {
+	BaseEvent
+	Actor    *Actor   `json:"actor,omitempty"`
+	Process  *Process `json:"process,omitempty"`
+	ExitCode *int     `json:"exit_code,omitempty"`
+}
+
+// DetectionFinding is a Detection Finding event (2004).
+type DetectionFinding struct {
+	BaseEvent
+	FindingInfo     *FindingInfo `json:"finding_info,omitempty"`
+	Actor           *Actor       `json:"actor,omitempty"`
+	Confidence      string       `json:"confidence,omitempty"`
+	ConfidenceID    int          `json:"confidence_id,omitempty"`
+	ConfidenceScore int          `json:"confidence_score,omitempty"`
+	RiskLevel       string       `json:"risk_level,omitempty"`
+	RiskLevelID     int          `json:"risk_level_id,omitempty"`
+	RiskScore       int          `json:"risk_score,omitempty"`
+	IsAlert         *bool        `json:"is_alert,omitempty"`
+}
+
+func (e *DetectionFinding) captions() {
+	if e.ConfidenceID != Unknown {
+		caption(&e.Confidence, e.ConfidenceID, confidenceNames)
+	}
+	if e.RiskLevelID != 0 {
+		caption(&e.RiskLevel, e.RiskLevelID, riskLevelNames)
+	}
+}
+
+// riskLevelNames run from Info, 0, to Critical, 4, unlike severities; Info is
+// the level that goes without a caption, as the ID cannot tell it from unset.
+var riskLevelNames = map[int]string{0: "Info", 1: "Low", 2: "Medium", 3: "High", 4: "Critical", Other: "Other"}
+
+// Authentication is an Authentication event (3002).
+type Authentication struct {
+	BaseEvent
+	Actor          *Actor    `json:"actor,omitempty"`
+	User           *User     `json:"user,omitempty"`
+	SrcEndpoint    *Endpoint `json:"src_endpoint,omitempty"`
+	DstEndpoint    *Endpoint `json:"dst_endpoint,omitempty"`
+	Service        *Named    `json:"service,omitempty"`
+	Session        *Session  `json:"session,omitempty"`
+	AuthProtocol   string    `json:"auth_protocol,omitempty"`
+	AuthProtocolID int       `json:"auth_protocol_id,omitempty"`
+	LogonType      string    `json:"logon_type,omitempty"`
+	LogonTypeID    int       `json:"logon_type_id,omitempty"`
+	IsMFA          *bool     `json:"is_mfa,omitempty"`
+	IsRemote       *bool     `json:"is_remote,omitempty"`
+	IsCleartext    *bool     `json:"is_cleartext,omitempty"`
+}
+
+func (e *Authentication) captions() {
+	if e.AuthProtocolID != Unknown {
+		caption(&e.AuthProtocol, e.AuthProtocolID, authProtocolNames)
+	}
+	if e.LogonTypeID != Unknown {
+		caption(&e.LogonType, e.LogonTypeID, logonTypeNames)
+	}
+}
+
+// NetworkActivity is a Network Activity event (4001).
+type NetworkActivity struct {
+	BaseEvent
+	Network
+}
+
+// HTTPActivity is an HTTP Activity event (4002).
+type HTTPActivity struct {
+	BaseEvent
+	Network
+	HTTPRequest  *HTTPRequest  `json:"http_request,omitempty"`
+	HTTPResponse *HTTPResponse `json:"http_response,omitempty"`
+	HTTPStatus   int           `json:"http_status,omitempty"`
+}
+
+// DNSActivity is a DNS Activity event (4003). Its response code is a pointer
+// because NoError is 0.
+type DNSActivity struct {
+	BaseEvent
+	Network
+	Query        *DNSQuery   `json:"query,omitempty"`
+	Answers      []DNSAnswer `json:"answers,omitempty"`
+	RCode        string      `json:"rcode,omitempty"`
+	RCodeID      *int        `json:"rcode_id,omitempty"`
+	ResponseTime int64       `json:"response_time,omitempty"`
+}
+
+func (e *DNSActivity) captions() {
+	e.Network.captions()
+	if e.RCodeID != nil {
+		caption(&e.RCode, *e.RCodeID, rcodeNames)
+	}
+}
+
+// InventoryInfo is a Device Inventory Info event (5001): what was found on a
+// device, such as the rows of an inventory query.
+type InventoryInfo struct {
+	BaseEvent
+	Device *Device `json:"device,omitempty"`
+	Actor  *Actor  `json:"actor,omitempty"`
+}
+
+// APIActivity is an API Activity event (6003), such as a call to a cloud
+// provider's API.
+type APIActivity struct {
+	BaseEvent
+	Actor       *Actor       `json:"actor,omitempty"`
+	API         *API         `json:"api,omitempty"`
+	Cloud       *Cloud       `json:"cloud,omitempty"`
+	SrcEndpoint *Endpoint    `json:"src_endpoint,omitempty"`
+	HTTPRequest *HTTPRequest `json:"http_request,omitempty"`
+	Resources   []Resource   `json:"resources,omitempty"`
+}
+
+// Class describes a supported event class.
+type Class struct {
+	UID        int
+	Name       string
+	Category   int
+	Activities map[int]string
+	// Required are the attribute paths an event of the class must carry,
+	// besides those of every class.
+	Required []string
+
+	new func() Event
+}
+
+// baseRequired are the attribute paths every event must carry. Those Derive
+// fills in, such as category_uid and type_uid, are left out.
+var baseRequired = []string{"class_uid", "activity_id", "severity_id", "time", "metadata.product"}
+
+var classes = map[int]*Class{
+	ClassBaseEvent: {
+		UID:        ClassBaseEvent,
+		Name:       "Base Event",
+		Category:   CategoryUncategorized,
+		Activities: withShared(map[int]string{}),
+		new:        func() Event { return new(BaseEvent) },
+	},
+	ClassFileActivity: {
+		UID:      ClassFileActivity,
+		Name:     "File System Activity",
+		Category: CategorySystemActivity,
+		Activities: withShared(map[int]string{
+			FileActivityCreate:        "Create",
+			FileActivityRead:          "Read",
+			FileActivityUpdate:        "Update",
+			FileActivityDelete:        "Delete",
+			FileActivityRename:        "Rename",
+			FileActivitySetAttributes: "Set Attributes",
+			FileActivitySetSecurity:   "Set Security",
+			FileActivityGetAttributes: "Get Attributes",
+			FileActivityGetSecurity:   "Get Security",
+			FileActivityEncrypt:       "Encrypt",
+			FileActivityDecrypt:       "Decrypt",
+			FileActivityMount:         "Mount",
+			FileActivityUnmount:       "Unmount",
+			FileActivityOpen:          "Open",
+		}),
+		Required: []string{"actor", "file"},
+		new:      func() Event { return new(FileActivity) },
+	},
+	ClassProcessActivity: {
+		UID:      ClassProcessActivity,
+		Name:     "Process Activity",
+		Category: CategorySystemActivity,
+		Activities: withShared(map[int]string{
+			ProcessActivityLaunch:    "Launch",
+			ProcessActivityTerminate: "Terminate",
+			ProcessActivityOpen:      "Open",
+			ProcessActivityInject:    "Inject",
+			ProcessActivitySetUserID: "Set User ID",
+		}),
+		Required: []string{"actor", "process"},
+		new:      func() Event { return new(ProcessActivity) },
+	},
+	ClassDetectionFinding: {
+		UID:      ClassDetectionFinding,
+		Name:     "Detection Finding",
+		Category: CategoryFindings,
+		Activities: withShared(map[int]string{
+			DetectionFindingCreate: "Create",
+			DetectionFindingUpdate: "Update",
+			DetectionFindingClose:  "Close",
+		}),
+		Required: []string{"finding_info", "finding_info.uid"},
+		new:      func() Event { return new(DetectionFinding) },
+	},
+	ClassAuthentication: {
+		UID:      ClassAuthentication,
+		Name:     "Authentication",
+		Category: CategoryIdentityAccess,
+		Activities: withShared(map[int]string{
+			AuthenticationLogon:                "Logon",
+			AuthenticationLogoff:               "Logoff",
+			AuthenticationTicket:               "Authentication Ticket",
+			AuthenticationServiceTicketRequest: "Service Ticket Request",
+			AuthenticationServiceTicketRenew:   "Service Ticket Renew",
+			AuthenticationPreauth:              "Preauth",
+		}),
+		Required: []string{"user"},
+		new:      func() Event { return new(Authentication) },
+	},
+	ClassNetworkActivity: {
+		UID:      ClassNetworkActivity,
+		Name:     "Network Activity",
+		Category: CategoryNetworkActivity,
+		Activities: withShared(map[int]string{
+			NetworkActivityOpen:    "Open",
+			NetworkActivityClose:   "Close",
+			NetworkActivityReset:   "Reset",
+			NetworkActivityFail:    "Fail",
+			NetworkActivityRefuse:  "Refuse",
+			NetworkActivityTraffic: "Traffic",
+			NetworkActivityListen:  "Listen",
+		}),
+		Required: []string{"src_endpoint", "dst_endpoint"},
+		new:      func() Event { return new(NetworkActivity) },
+	},
+	ClassHTTPActivity: {
+		UID:      ClassHTTPActivity,
+		Name:     "HTTP Activity",
+		Category: CategoryNetworkActivity,
+		Activities: withShared(map[int]string{
+			HTTPActivityConnect: "Connect",
+			HTTPActivityDelete:  "Delete",
+			HTTPActivityGet:     "Get",
+			HTTPActivityHead:    "Head",
+			HTTPActivityOptions: "Options",
+			HTTPActivityPost:    "Post",
+			HTTPActivityPut:     "Put",
+			HTTPActivityTrace:   "Trace",
+			HTTPActivityPatch:   "Patch",
+		}),
+		Required: []string{"http_request"},
+		new:      func() Event { return new(HTTPActivity) },
+	},
+	ClassDNSActivity: {
+		UID:      ClassDNSActivity,
+		Name:     "DNS Activity",
+		Category: CategoryNetworkActivity,
+		Activities: withShared(map[int]string{
+			DNSActivityQuery:    "Query",
+			DNSActivityResponse: "Response",
+			DNSActivityTraffic:  "Traffic",
+		}),
+		Required: []string{"query", "query.hostname"},
+		new:      func() Event { return new(DNSActivity) },
+	},
+	ClassInventoryInfo: {
+		UID:      ClassInventoryInfo,
+		Name:     "Device Inventory Info",
+		Category: CategoryDiscovery,
+		Activities: withShared(map[int]string{
+			InventoryInfoLog:     "Log",
+			InventoryInfoCollect: "Collect",
+		}),
+		Required: []string{"device"},
+		new:      func() Event { return new(InventoryInfo) },
+	},
+	ClassAPIActivity: {
+		UID:      ClassAPIActivity,
+		Name:     "API Activity",
+		Category: CategoryApplication,
+		Activities: withShared(map[int]string{
+			APIActivityCreate: "Create",
+			APIActivityRead:   "Read",
+			APIActivityUpdate: "Update",
+			APIActivityDelete: "Delete",
+		}),
+		Required: []string{"actor", "api", "api.operation"},
+		new:      func() Event { return new(APIActivity) },
+	},
+}
+
+// LookupClass returns the supported class with the given UID.
+func LookupClass(uid int) (*Class, bool) {
+	c, ok := classes[uid]
+	return c, ok
+}
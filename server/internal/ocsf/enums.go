@@ -0,0 +1,279 @@
+package ocsf
+
+// Values every OCSF enum attribute shares.
+const (
+	Unknown = 0
+	Other   = 99
+)
+
+// Category UIDs.
+const (
+	CategoryUncategorized   = 0
+	CategorySystemActivity  = 1
+	CategoryFindings        = 2
+	CategoryIdentityAccess  = 3
+	CategoryNetworkActivity = 4
+	CategoryDiscovery       = 5
+	CategoryApplication     = 6
+)
+
+var categoryNames = map[int]string{
+	CategoryUncategorized:   "Uncategorized",
+	CategorySystemActivity:  "System Activity",
+	CategoryFindings:        "Findings",
+	CategoryIdentityAccess:  "Identity & Access Management",
+	CategoryNetworkActivity: "Network Activity",
+	CategoryDiscovery:       "Discovery",
+	CategoryApplication:     "Application Activity",
+}
+
+// Class UIDs of the supported classes.
+const (
+	ClassBaseEvent        = 0
+	ClassFileActivity     = 1001
+	ClassProcessActivity  = 1007
+	ClassDetectionFinding = 2004
+	ClassAuthentication   = 3002
+	ClassNetworkActivity  = 4001
+	ClassHTTPActivity     = 4002
+	ClassDNSActivity      = 4003
+	ClassInventoryInfo    = 5001
+	ClassAPIActivity      = 6003
+)
+
+// Severity IDs.
+const (
+	SeverityInformational = 1
+	SeverityLow           = 2
+	SeverityMedium        = 3
+	SeverityHigh          = 4
+	SeverityCritical      = 5
+	SeverityFatal         = 6
+)
+
+var severityNames = withShared(map[int]string{
+	SeverityInformational: "Informational",
+	SeverityLow:           "Low",
+	SeverityMedium:        "Medium",
+	SeverityHigh:          "High",
+	SeverityCritical:      "Critical",
+	SeverityFatal:         "Fatal",
+})
+
+// Status IDs.
+const (
+	StatusSuccess = 1
+	StatusFailure = 2
+)
+
+var statusNames = withShared(map[int]string{
+	StatusSuccess: "Success",
+	StatusFailure: "Failure",
+})
+
+// File System Activity activity IDs.
+const (
+	FileActivityCreate        = 1
+	FileActivityRead          = 2
+	FileActivityUpdate        = 3
+	FileActivityDelete        = 4
+	FileActivityRename        = 5
+	FileActivitySetAttributes = 6
+	FileActivitySetSecurity   = 7
+	FileActivityGetAttributes = 8
+	FileActivityGetSecurity   = 9
+	FileActivityEncrypt       = 10
+	FileActivityDecrypt       = 11
+	FileActivityMount         = 12
+	FileActivityUnmount       = 13
+	FileActivityOpen          = 14
+)
+
+// Process Activity activity IDs.
+const (
+	ProcessActivityLaunch    = 1
+	ProcessActivityTerminate = 2
+	ProcessActivityOpen      = 3
+	ProcessActivityInject    = 4
+	ProcessActivitySetUserID = 5
+)
+
+// Detection Finding activity IDs.
+const (
+	DetectionFindingCreate = 1
+	DetectionFindingUpdate = 2
+	DetectionFindingClose  = 3
+)
+
+// Authentication activity IDs.
+const (
+	AuthenticationLogon                = 1
+	AuthenticationLogoff               = 2
+	AuthenticationTicket               = 3
+	AuthenticationServiceTicketRequest = 4
+	AuthenticationServiceTicketRenew   = 5
+	AuthenticationPreauth              = 6
+)
+
+// Network Activity activity IDs.
+const (
+	NetworkActivityOpen    = 1
+	NetworkActivityClose   = 2
+	NetworkActivityReset   = 3
+	NetworkActivityFail    = 4
+	NetworkActivityRefuse  = 5
+	NetworkActivityTraffic = 6
+	NetworkActivityListen  = 7
+)
+
+// HTTP Activity activity IDs.
+const (
+	HTTPActivityConnect = 1
+	HTTPActivityDelete  = 2
+	HTTPActivityGet     = 3
+	HTTPActivityHead    = 4
+	HTTPActivityOptions = 5
+	HTTPActivityPost    = 6
+	HTTPActivityPut     = 7
+	HTTPActivityTrace   = 8
+	HTTPActivityPatch   = 9
+)
+
+// DNS Activity activity IDs.
+const (
+	DNSActivityQuery    = 1
+	DNSActivityResponse = 2
+	DNSActivityTraffic  = 6
+)
+
+// Device Inventory Info activity IDs.
+const (
+	InventoryInfoLog     = 1
+	InventoryInfoCollect = 2
+)
+
+// API Activity activity IDs.
+const (
+	APIActivityCreate = 1
+	APIActivityRead   = 2
+	APIActivityUpdate = 3
+	APIActivityDelete = 4
+)
+
+// Authentication protocol IDs.
+const (
+	AuthProtocolNTLM     = 1
+	AuthProtocolKerberos = 2
+	AuthProtocolDigest   = 3
+	AuthProtocolOpenID   = 4
+	AuthProtocolSAML     = 5
+	AuthProtocolOAuth2   = 6
+	AuthProtocolPAP      = 7
+	AuthProtocolCHAP     = 8
+	AuthProtocolEAP      = 9
+	AuthProtocolRADIUS   = 10
+)
+
+var authProtocolNames = withShared(map[int]string{
+	AuthProtocolNTLM:     "NTLM",
+	AuthProtocolKerberos: "Kerberos",
+	AuthProtocolDigest:   "Digest",
+	AuthProtocolOpenID:   "OpenID",
+	AuthProtocolSAML:     "SAML",
+	AuthProtocolOAuth2:   "OAUTH 2.0",
+	AuthProtocolPAP:      "PAP",
+	AuthProtocolCHAP:     "CHAP",
+	AuthProtocolEAP:      "EAP",
+	AuthProtocolRADIUS:   "RADIUS",
+})
+
+// Logon type IDs.
+const (
+	LogonTypeSystem                  = 1
+	LogonTypeInteractive             = 2
+	LogonTypeNetwork                 = 3
+	LogonTypeBatch                   = 4
+	LogonTypeOSService               = 5
+	LogonTypeUnlock                  = 7
+	LogonTypeNetworkCleartext        = 8
+	LogonTypeNewCredentials          = 9
+	LogonTypeRemoteInteractive       = 10
+	LogonTypeCachedInteractive       = 11
+	LogonTypeCachedRemoteInteractive = 12
+	LogonTypeCachedUnlock            = 13
+)
+
+var logonTypeNames = withShared(map[int]string{
+	LogonTypeSystem:                  "System",
+	LogonTypeInteractive:             "Interactive",
+	LogonTypeNetwork:                 "Network",
+	LogonTypeBatch:                   "Batch",
+	LogonTypeOSService:               "OS Service",
+	LogonTypeUnlock:                  "Unlock",
+	LogonTypeNetworkCleartext:        "Network Cleartext",
+	LogonTypeNewCredentials:          "New Credentials",
+	LogonTypeRemoteInteractive:       "Remote Interactive",
+	LogonTypeCachedInteractive:       "Cached Interactive",
+	LogonTypeCachedRemoteInteractive: "Cached Remote Interactive",
+	LogonTypeCachedUnlock:            "Cached Unlock",
+})
+
+// Connection direction IDs.
+const (
+	DirectionInbound  = 1
+	DirectionOutbound = 2
+	DirectionLateral  = 3
+)
+
+var directionNames = withShared(map[int]string{
+	DirectionInbound:  "Inbound",
+	DirectionOutbound: "Outbound",
+	DirectionLateral:  "Lateral",
+})
+
+// DNS response code IDs.
+const (
+	RCodeNoError  = 0
+	RCodeFormErr  = 1
+	RCodeServFail = 2
+	RCodeNXDomain = 3
+	RCodeNotImp   = 4
+	RCodeRefused  = 5
+)
+
+var rcodeNames = map[int]string{
+	RCodeNoError:  "NoError",
+	RCodeFormErr:  "FormError",
+	RCodeServFail: "ServError",
+	RCodeNXDomain: "NXDomain",
+	RCodeNotImp:   "NotImp",
+	RCodeRefused:  "Refused",
+	Other:         "Other",
+}
+
+// Confidence IDs of a finding.
+const (
+	ConfidenceLow    = 1
+	ConfidenceMedium = 2
+	ConfidenceHigh   = 3
+)
+
+var confidenceNames = withShared(map[int]string{
+	ConfidenceLow:    "Low",
+	ConfidenceMedium: "Medium",
+	ConfidenceHigh:   "High",
+})
+
+// withShared adds the Unknown and Other captions every enum has.
+func withShared(names map[int]string) map[int]string {
+	names[Unknown] = "Unknown"
+	names[Other] = "Other"
+	return names
+}
+
+// caption sets *name to the caption of id in names unless it is set.
+func caption(name *string, id int, names map[int]string) {
+	if *name == "" {
+		*name = names[id]
+	}
+}
@@ -0,0 +1,360 @@
+package ocsf
+
+// Objects shared by the event classes. Only the attributes the supported
+// classes use are modelled; anything else an event carries belongs under its
+// unmapped attribute.
+
+// Metadata describes the event itself rather than the activity it records.
+type Metadata struct {
+	// Version is the OCSF schema version the event conforms to.
+	Version        string   `json:"version"`
+	Product        *Product `json:"product,omitempty"`
+	UID            string   `json:"uid,omitempty"`
+	CorrelationUID string   `json:"correlation_uid,omitempty"`
+	EventCode      string   `json:"event_code,omitempty"`
+	LogName        string   `json:"log_name,omitempty"`
+	LogProvider    string   `json:"log_provider,omitempty"`
+	OriginalTime   string   `json:"original_time,omitempty"`
+	LoggedTime     int64    `json:"logged_time,omitempty"`
+	ProcessedTime  int64    `json:"processed_time,omitempty"`
+	Labels         []string `json:"labels,omitempty"`
+	Profiles       []string `json:"profiles,omitempty"`
+}
+
+// Product is the product that reported the event.
+type Product struct {
+	Name       string `json:"name,omitempty"`
+	VendorName string `json:"vendor_name,omitempty"`
+	Version    string `json:"version,omitempty"`
+	UID        string `json:"uid,omitempty"`
+	Feature    *Named `json:"feature,omitempty"`
+}
+
+// Named is any object identified by just a name and uid, such as a product
+// feature or a service.
+type Named struct {
+	Name string `json:"name,omitempty"`
+	UID  string `json:"uid,omitempty"`
+}
+
+// User is a user account.
+type User struct {
+	Name          string  `json:"name,omitempty"`
+	UID           string  `json:"uid,omitempty"`
+	Domain        string  `json:"domain,omitempty"`
+	FullName      string  `json:"full_name,omitempty"`
+	EmailAddr     string  `json:"email_addr,omitempty"`
+	CredentialUID string  `json:"credential_uid,omitempty"`
+	Type          string  `json:"type,omitempty"`
+	TypeID        int     `json:"type_id,omitempty"`
+	Groups        []Group `json:"groups,omitempty"`
+}
+
+// Group is a group a user belongs to.
+type Group struct {
+	Name       string   `json:"name,omitempty"`
+	UID        string   `json:"uid,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Privileges []string `json:"privileges,omitempty"`
+}
+
+// Actor is who or what performed the activity.
+type Actor struct {
+	User      *User    `json:"user,omitempty"`
+	Process   *Process `json:"process,omitempty"`
+	Session   *Session `json:"session,omitempty"`
+	AppName   string   `json:"app_name,omitempty"`
+	AppUID    string   `json:"app_uid,omitempty"`
+	InvokedBy string   `json:"invoked_by,omitempty"`
+}
+
+// Session is a login session.
+type Session struct {
+	UID            string `json:"uid,omitempty"`
+	Issuer         string `json:"issuer,omitempty"`
+	CreatedTime    int64  `json:"created_time,omitempty"`
+	ExpirationTime int64  `json:"expiration_time,omitempty"`
+	IsRemote       *bool  `json:"is_remote,omitempty"`
+	IsMFA          *bool  `json:"is_mfa,omitempty"`
+}
+
+// Endpoint is one end of a network connection.
+type Endpoint struct {
+	IP            string    `json:"ip,omitempty"`
+	Port          int       `json:"port,omitempty"`
+	Hostname      string    `json:"hostname,omitempty"`
+	Domain        string    `json:"domain,omitempty"`
+	Name          string    `json:"name,omitempty"`
+	UID           string    `json:"uid,omitempty"`
+	MAC           string    `json:"mac,omitempty"`
+	SvcName       string    `json:"svc_name,omitempty"`
+	InterfaceName string    `json:"interface_name,omitempty"`
+	InterfaceUID  string    `json:"interface_uid,omitempty"`
+	Type          string    `json:"type,omitempty"`
+	TypeID        int       `json:"type_id,omitempty"`
+	Location      *Location `json:"location,omitempty"`
+	// AutonomousSystem is the AS the address is announced from.
+	AutonomousSystem *AutonomousSystem `json:"autonomous_system,omitempty"`
+}
+
+// Location is a geographical location.
+type Location struct {
+	City       string `json:"city,omitempty"`
+	Region     string `json:"region,omitempty"`
+	Country    string `json:"country,omitempty"`
+	Continent  string `json:"continent,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
+	// Coordinates are longitude then latitude, as in GeoJSON.
+	Coordinates  []float64 `json:"coordinates,omitempty"`
+	ISP          string    `json:"isp,omitempty"`
+	Provider     string    `json:"provider,omitempty"`
+	IsOnPremises *bool     `json:"is_on_premises,omitempty"`
+}
+
+// AutonomousSystem is a network's autonomous system.
+type AutonomousSystem struct {
+	Name   string `json:"name,omitempty"`
+	Number int    `json:"number,omitempty"`
+}
+
+// Device is the host the event was observed on.
+type Device struct {
+	Hostname string    `json:"hostname,omitempty"`
+	IP       string    `json:"ip,omitempty"`
+	Name     string    `json:"name,omitempty"`
+	UID      string    `json:"uid,omitempty"`
+	Domain   string    `json:"domain,omitempty"`
+	MAC      string    `json:"mac,omitempty"`
+	Region   string    `json:"region,omitempty"`
+	Type     string    `json:"type,omitempty"`
+	TypeID   int       `json:"type_id,omitempty"`
+	OS       *OS       `json:"os,omitempty"`
+	Location *Location `json:"location,omitempty"`
+}
+
+// OS is an operating system.
+type OS struct {
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type,omitempty"`
+	TypeID  int    `json:"type_id,omitempty"`
+	Version string `json:"version,omitempty"`
+	Build   string `json:"build,omitempty"`
+}
+
+// Process is a running or exited process.
+type Process struct {
+	Name           string   `json:"name,omitempty"`
+	PID            int      `json:"pid,omitempty"`
+	UID            string   `json:"uid,omitempty"`
+	CmdLine        string   `json:"cmd_line,omitempty"`
+	File           *File    `json:"file,omitempty"`
+	User           *User    `json:"user,omitempty"`
+	ParentProcess  *Process `json:"parent_process,omitempty"`
+	Integrity      string   `json:"integrity,omitempty"`
+	IntegrityID    int      `json:"integrity_id,omitempty"`
+	CreatedTime    int64    `json:"created_time,omitempty"`
+	TerminatedTime int64    `json:"terminated_time,omitempty"`
+}
+
+// File is a file or folder.
+type File struct {
+	Name         string        `json:"name,omitempty"`
+	Path         string        `json:"path,omitempty"`
+	ParentFolder string        `json:"parent_folder,omitempty"`
+	UID          string        `json:"uid,omitempty"`
+	Type         string        `json:"type,omitempty"`
+	TypeID       int           `json:"type_id,omitempty"`
+	Size         int64         `json:"size,omitempty"`
+	MimeType     string        `json:"mime_type,omitempty"`
+	Hashes       []Fingerprint `json:"hashes,omitempty"`
+	Owner        *User         `json:"owner,omitempty"`
+	CreatedTime  int64         `json:"created_time,omitempty"`
+	ModifiedTime int64         `json:"modified_time,omitempty"`
+	AccessedTime int64         `json:"accessed_time,omitempty"`
+}
+
+// Fingerprint is a digest of a file.
+type Fingerprint struct {
+	Algorithm   string `json:"algorithm,omitempty"`
+	AlgorithmID int    `json:"algorithm_id"`
+	Value       string `json:"value"`
+}
+
+// ConnectionInfo describes a network connection.
+type ConnectionInfo struct {
+	UID          string `json:"uid,omitempty"`
+	Direction    string `json:"direction,omitempty"`
+	DirectionID  int    `json:"direction_id,omitempty"`
+	ProtocolName string `json:"protocol_name,omitempty"`
+	ProtocolNum  int    `json:"protocol_num,omitempty"`
+	ProtocolVer  string `json:"protocol_ver,omitempty"`
+	Boundary     string `json:"boundary,omitempty"`
+	BoundaryID   int    `json:"boundary_id,omitempty"`
+	TCPFlags     int    `json:"tcp_flags,omitempty"`
+}
+
+// Traffic counts what went over a connection.
+type Traffic struct {
+	Bytes      int64 `json:"bytes,omitempty"`
+	BytesIn    int64 `json:"bytes_in,omitempty"`
+	BytesOut   int64 `json:"bytes_out,omitempty"`
+	Packets    int64 `json:"packets,omitempty"`
+	PacketsIn  int64 `json:"packets_in,omitempty"`
+	PacketsOut int64 `json:"packets_out,omitempty"`
+}
+
+// DNSQuery is the question of a DNS message.
+type DNSQuery struct {
+	Hostname  string `json:"hostname"`
+	Type      string `json:"type,omitempty"`
+	Class     string `json:"class,omitempty"`
+	Opcode    string `json:"opcode,omitempty"`
+	OpcodeID  int    `json:"opcode_id,omitempty"`
+	PacketUID int    `json:"packet_uid,omitempty"`
+}
+
+// DNSAnswer is one resource record of a DNS response.
+type DNSAnswer struct {
+	RData   string   `json:"rdata"`
+	Type    string   `json:"type,omitempty"`
+	Class   string   `json:"class,omitempty"`
+	TTL     int      `json:"ttl,omitempty"`
+	Flags   []string `json:"flags,omitempty"`
+	FlagIDs []int    `json:"flag_ids,omitempty"`
+}
+
+// HTTPRequest is an HTTP request.
+type HTTPRequest struct {
+	HTTPMethod    string       `json:"http_method,omitempty"`
+	URL           *URL         `json:"url,omitempty"`
+	Version       string       `json:"version,omitempty"`
+	UserAgent     string       `json:"user_agent,omitempty"`
+	Referrer      string       `json:"referrer,omitempty"`
+	Length        int64        `json:"length,omitempty"`
+	UID           string       `json:"uid,omitempty"`
+	XForwardedFor []string     `json:"x_forwarded_for,omitempty"`
+	HTTPHeaders   []HTTPHeader `json:"http_headers,omitempty"`
+}
+
+// HTTPResponse is an HTTP response.
+type HTTPResponse struct {
+	Code        int          `json:"code"`
+	Message     string       `json:"message,omitempty"`
+	Status      string       `json:"status,omitempty"`
+	ContentType string       `json:"content_type,omitempty"`
+	Length      int64        `json:"length,omitempty"`
+	Latency     int          `json:"latency,omitempty"`
+	HTTPHeaders []HTTPHeader `json:"http_headers,omitempty"`
+}
+
+// HTTPHeader is one HTTP header.
+type HTTPHeader struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// URL is a parsed URL.
+type URL struct {
+	URLString   string   `json:"url_string,omitempty"`
+	Scheme      string   `json:"scheme,omitempty"`
+	Hostname    string   `json:"hostname,omitempty"`
+	Port        int      `json:"port,omitempty"`
+	Path        string   `json:"path,omitempty"`
+	QueryString string   `json:"query_string,omitempty"`
+	Domain      string   `json:"domain,omitempty"`
+	Categories  []string `json:"categories,omitempty"`
+}
+
+// FindingInfo describes what a finding found.
+type FindingInfo struct {
+	UID           string    `json:"uid"`
+	Title         string    `json:"title,omitempty"`
+	Desc          string    `json:"desc,omitempty"`
+	Types         []string  `json:"types,omitempty"`
+	SrcURL        string    `json:"src_url,omitempty"`
+	CreatedTime   int64     `json:"created_time,omitempty"`
+	FirstSeenTime int64     `json:"first_seen_time,omitempty"`
+	LastSeenTime  int64     `json:"last_seen_time,omitempty"`
+	ModifiedTime  int64     `json:"modified_time,omitempty"`
+	Analytic      *Analytic `json:"analytic,omitempty"`
+	Attacks       []Attack  `json:"attacks,omitempty"`
+}
+
+// Analytic is the rule or model that produced a finding.
+type Analytic struct {
+	Name     string `json:"name,omitempty"`
+	UID      string `json:"uid,omitempty"`
+	Category string `json:"category,omitempty"`
+	Type     string `json:"type,omitempty"`
+	TypeID   int    `json:"type_id"`
+	Version  string `json:"version,omitempty"`
+}
+
+// Attack is a MITRE ATT&CK tactic and technique a finding relates to.
+type Attack struct {
+	Version      string `json:"version,omitempty"`
+	Tactic       *Named `json:"tactic,omitempty"`
+	Technique    *Named `json:"technique,omitempty"`
+	SubTechnique *Named `json:"sub_technique,omitempty"`
+}
+
+// API is an API call: the operation and the service it was made to.
+type API struct {
+	Operation string       `json:"operation"`
+	Version   string       `json:"version,omitempty"`
+	Service   *Named       `json:"service,omitempty"`
+	Request   *APIRequest  `json:"request,omitempty"`
+	Response  *APIResponse `json:"response,omitempty"`
+}
+
+// APIRequest identifies an API request.
+type APIRequest struct {
+	UID string `json:"uid,omitempty"`
+}
+
+// APIResponse is the outcome of an API request.
+type APIResponse struct {
+	Code    int    `json:"code,omitempty"`
+	Error   string `json:"error,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Cloud is the cloud environment an event happened in.
+type Cloud struct {
+	Provider string   `json:"provider"`
+	Region   string   `json:"region,omitempty"`
+	Zone     string   `json:"zone,omitempty"`
+	Account  *Account `json:"account,omitempty"`
+}
+
+// Account is a cloud or SaaS account.
+type Account struct {
+	Name string `json:"name,omitempty"`
+	UID  string `json:"uid,omitempty"`
+	Type string `json:"type,omitempty"`
+}
+
+// Resource is a cloud or API resource an activity acted on.
+type Resource struct {
+	Name   string `json:"name,omitempty"`
+	UID    string `json:"uid,omitempty"`
+	Type   string `json:"type,omitempty"`
+	Region string `json:"region,omitempty"`
+}
+
+// Observable is a value in the event worth pivoting on, with where it is.
+type Observable struct {
+	Name   string `json:"name"`
+	Type   string `json:"type,omitempty"`
+	TypeID int    `json:"type_id"`
+	Value  string `json:"value,omitempty"`
+}
+
+// Enrichment is context added to an event after it was normalized.
+type Enrichment struct {
+	Name     string         `json:"name"`
+	Value    string         `json:"value"`
+	Type     string         `json:"type,omitempty"`
+	Provider string         `json:"provider,omitempty"`
+	Data     map[string]any `json:"data"`
+}
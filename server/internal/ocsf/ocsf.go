@@ -0,0 +1,153 @@
+// Package ocsf models normalized events in the Open Cybersecurity Schema
+// Framework: typed Go events for the supported classes, with their objects
+// and enums. Normalize turns the attributes a mapping produced into the event
+// of their class, validating them against the class and deriving what OCSF
+// defines in terms of other attributes, such as type_uid and the captions of
+// enum IDs.
+package ocsf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// SchemaVersion is the OCSF version events conform to. Derive sets it as an
+// event's metadata.version unless the event names one.
+const SchemaVersion = "1.1.0"
+
+// ErrInvalid is wrapped by every ValidationError.
+var ErrInvalid = errors.New("invalid OCSF event")
+
+// Event is an event of one of the supported classes.
+type Event interface {
+	Common() *BaseEvent
+}
+
+// FieldError is a problem with one attribute of an event.
+type FieldError struct {
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// ValidationError lists every problem found with an event, by path.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		msgs[i] = fe.Path + ": " + fe.Message
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalid, strings.Join(msgs, "; "))
+}
+
+func (e *ValidationError) Unwrap() error { return ErrInvalid }
+
+// TypeUID is the type_uid of an activity of a class.
+func TypeUID(classUID, activityID int) int {
+	return classUID*100 + activityID
+}
+
+// Normalize validates attrs as an event of the class its class_uid names and
+// returns that event, derived. Attributes of the wrong type, or that the
+// class does not have, are problems like missing required ones; a
+// *ValidationError lists them all.
+func Normalize(attrs map[string]any) (Event, error) {
+	var errs []FieldError
+	fail := func(path, format string, args ...any) {
+		errs = append(errs, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	uid, ok := intAttr(attrs, "class_uid")
+	if !ok {
+		if _, present := attrs["class_uid"]; present {
+			fail("class_uid", "must be an integer")
+		} else {
+			fail("class_uid", "is required")
+		}
+		return nil, &ValidationError{Errors: errs}
+	}
+	class, ok := classes[uid]
+	if !ok {
+		fail("class_uid", "class %d is not supported", uid)
+		return nil, &ValidationError{Errors: errs}
+	}
+
+	ev := class.new()
+	errs = append(errs, checkTypes(attrs, ev)...)
+	for _, p := range slices.Concat(baseRequired, class.Required) {
+		if _, ok := lookup(attrs, p); !ok {
+			fail(p, "is required")
+		}
+	}
+	if id, ok := intAttr(attrs, "activity_id"); ok {
+		if _, known := class.Activities[id]; !known {
+			fail("activity_id", "%d is not an activity of %s", id, class.Name)
+		}
+	}
+	if id, ok := intAttr(attrs, "severity_id"); ok {
+		if _, known := severityNames[id]; !known {
+			fail("severity_id", "%d is not a severity", id)
+		}
+	}
+	if id, ok := intAttr(attrs, "status_id"); ok {
+		if _, known := statusNames[id]; !known {
+			fail("status_id", "%d is not a status", id)
+		}
+	}
+	if id, ok := intAttr(attrs, "category_uid"); ok && id != class.Category {
+		fail("category_uid", "%s is in category %d", class.Name, class.Category)
+	}
+	if id, ok := intAttr(attrs, "type_uid"); ok {
+		if activity, ok := intAttr(attrs, "activity_id"); ok && id != TypeUID(uid, activity) {
+			fail("type_uid", "must be %d", TypeUID(uid, activity))
+		}
+	}
+	if len(errs) > 0 {
+		slices.SortStableFunc(errs, func(a, b FieldError) int { return strings.Compare(a.Path, b.Path) })
+		return nil, &ValidationError{Errors: errs}
+	}
+
+	// The types were checked above, so decoding cannot fail on them.
+	raw, err := json.Marshal(attrs)
+	if err != nil {
+		return nil, fmt.Errorf("encoding attributes: %w", err)
+	}
+	if err := json.Unmarshal(raw, ev); err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", class.Name, err)
+	}
+	Derive(ev)
+	return ev, nil
+}
+
+// Derive fills in the attributes OCSF defines in terms of others: the
+// category of the class, type_uid as class_uid * 100 + activity_id, the
+// schema version and the captions of enum IDs. Captions already set are
+// kept, as a source may word them itself.
+func Derive(ev Event) {
+	b := ev.Common()
+	if class, ok := classes[b.ClassUID]; ok {
+		b.CategoryUID = class.Category
+		b.ClassName = class.Name
+		caption(&b.ActivityName, b.ActivityID, class.Activities)
+	}
+	b.CategoryName = categoryNames[b.CategoryUID]
+	b.TypeUID = TypeUID(b.ClassUID, b.ActivityID)
+	if b.ClassName != "" && b.ActivityName != "" {
+		b.TypeName = b.ClassName + ": " + b.ActivityName
+	}
+	caption(&b.Severity, b.SeverityID, severityNames)
+	if b.StatusID != Unknown {
+		caption(&b.Status, b.StatusID, statusNames)
+	}
+	if b.Metadata.Version == "" {
+		b.Metadata.Version = SchemaVersion
+	}
+	if c, ok := ev.(interface{ captions() }); ok {
+		c.captions()
+	}
+}
@@ -0,0 +1,320 @@
+package ocsf_test
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/ocsf"
+)
+
+// attrs decodes attributes the way stored events are decoded.
+func attrs(t *testing.T, raw string) map[string]any {
+	t.Helper()
+	var a map[string]any
+	if err := json.Unmarshal([]byte(raw), &a); err != nil {
+		t.Fatalf("decoding attributes: %v", err)
+	}
+	return a
+}
+
+func TestNormalize_Authentication(t *testing.T) {
+	ev, err := ocsf.Normalize(map[string]any{
+		"class_uid":        int64(3002),
+		"activity_id":      int64(1),
+		"severity_id":      json.Number("3"),
+		"status_id":        int64(2),
+		"time":             int64(1792418400000),
+		"metadata":         map[string]any{"product": map[string]any{"name": "OpenSSH"}},
+		"user":             map[string]any{"name": "alice"},
+		"src_endpoint":     map[string]any{"ip": "198.51.100.7", "port": int64(52144)},
+		"auth_protocol_id": int64(ocsf.AuthProtocolKerberos),
+		"logon_type_id":    int64(ocsf.LogonTypeRemoteInteractive),
+		"unmapped":         map[string]any{"pid": json.Number("4242")},
+	})
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	auth, ok := ev.(*ocsf.Authentication)
+	if !ok {
+		t.Fatalf("Normalize: want *Authentication, got %T", ev)
+	}
+
+	want := ocsf.BaseEvent{
+		ActivityID:   1,
+		ActivityName: "Logon",
+		CategoryUID:  ocsf.CategoryIdentityAccess,
+		CategoryName: "Identity & Access Management",
+		ClassUID:     ocsf.ClassAuthentication,
+		ClassName:    "Authentication",
+		TypeUID:      300201,
+		TypeName:     "Authentication: Logon",
+		Time:         1792418400000,
+		SeverityID:   ocsf.SeverityMedium,
+		Severity:     "Medium",
+		StatusID:     ocsf.StatusFailure,
+		Status:       "Failure",
+		Metadata:     ocsf.Metadata{Version: ocsf.SchemaVersion, Product: &ocsf.Product{Name: "OpenSSH"}},
+		Unmapped:     map[string]any{"pid": float64(4242)},
+	}
+	if !reflect.DeepEqual(auth.BaseEvent, want) {
+		t.Errorf("base:\nwant %+v\n got %+v", want, auth.BaseEvent)
+	}
+	if auth.User.Name != "alice" || auth.SrcEndpoint.Port != 52144 {
+		t.Errorf("class attributes: got %+v, %+v", auth.User, auth.SrcEndpoint)
+	}
+	if auth.AuthProtocol != "Kerberos" || auth.LogonType != "Remote Interactive" {
+		t.Errorf("captions: got %q, %q", auth.AuthProtocol, auth.LogonType)
+	}
+}
+
+func TestNormalize_Classes(t *testing.T) {
+	for _, c := range []struct {
+		name     string
+		class    string
+		wantType ocsf.Event
+		typeUID  int
+		typeName string
+	}{
+		{
+			"file activity",
+			`"class_uid": 1001, "activity_id": 5, "actor": {"user": {"name": "root"}},
+			 "file": {"name": "a.txt", "path": "/tmp/a.txt", "hashes": [{"algorithm_id": 3, "value": "ab"}]},
+			 "file_result": {"name": "b.txt"}`,
+			&ocsf.FileActivity{}, 100105, "File System Activity: Rename",
+		},
+		{
+			"process activity",
+			`"class_uid": 1007, "activity_id": 1, "actor": {"process": {"pid": 1}},
+			 "process": {"pid": 4242, "cmd_line": "sshd -D", "parent_process": {"pid": 1}}, "exit_code": 0`,
+			&ocsf.ProcessActivity{}, 100701, "Process Activity: Launch",
+		},
+		{
+			"detection finding",
+			`"class_uid": 2004, "activity_id": 1, "confidence_id": 3, "risk_level_id": 4,
+			 "finding_info": {"uid": "f-1", "title": "Brute force",
+			  "attacks": [{"technique": {"uid": "T1110", "name": "Brute Force"}}]}`,
+			&ocsf.DetectionFinding{}, 200401, "Detection Finding: Create",
+		},
+		{
+			"network activity",
+			`"class_uid": 4001, "activity_id": 6, "src_endpoint": {"ip": "10.0.0.1"},
+			 "dst_endpoint": {"ip": "10.0.0.2", "port": 443, "location": {"coordinates": [13.4, 52.5]}},
+			 "connection_info": {"protocol_num": 6, "direction_id": 2}, "traffic": {"bytes": 1024}`,
+			&ocsf.NetworkActivity{}, 400106, "Network Activity: Traffic",
+		},
+		{
+			"http activity",
+			`"class_uid": 4002, "activity_id": 3,
+			 "http_request": {"http_method": "GET", "url": {"path": "/index.html"}},
+			 "http_response": {"code": 200}`,
+			&ocsf.HTTPActivity{}, 400203, "HTTP Activity: Get",
+		},
+		{
+			"dns activity",
+			`"class_uid": 4003, "activity_id": 2, "query": {"hostname": "example.com", "type": "A"},
+			 "answers": [{"rdata": "93.184.216.34", "ttl": 300}], "rcode_id": 0`,
+			&ocsf.DNSActivity{}, 400302, "DNS Activity: Response",
+		},
+		{
+			"device inventory info",
+			`"class_uid": 5001, "activity_id": 1, "device": {"hostname": "web-1", "os": {"name": "Ubuntu"}}`,
+			&ocsf.InventoryInfo{}, 500101, "Device Inventory Info: Log",
+		},
+		{
+			"api activity",
+			`"class_uid": 6003, "activity_id": 2, "actor": {"user": {"name": "alice"}},
+			 "api": {"operation": "GetObject", "service": {"name": "s3.amazonaws.com"}},
+			 "cloud": {"provider": "AWS", "region": "eu-west-1", "account": {"uid": "123456789012"}},
+			 "resources": [{"uid": "arn:aws:s3:::logs", "type": "AWS::S3::Bucket"}]`,
+			&ocsf.APIActivity{}, 600302, "API Activity: Read",
+		},
+		{
+			"base event",
+			`"class_uid": 0, "activity_id": 99, "message": "audit record", "unmapped": {"type": "CWD"}`,
+			&ocsf.BaseEvent{}, 99, "Base Event: Other",
+		},
+		{
+			"other activity",
+			`"class_uid": 4001, "activity_id": 99, "src_endpoint": {"ip": "10.0.0.1"},
+			 "dst_endpoint": {"ip": "10.0.0.2"}`,
+			&ocsf.NetworkActivity{}, 400199, "Network Activity: Other",
+		},
+	} {
+		raw := `{"severity_id": 1, "time": 1792418400000, "metadata": {"product": {"name": "p"}}, ` + c.class + `}`
+		ev, err := ocsf.Normalize(attrs(t, raw))
+		if err != nil {
+			t.Errorf("%s: Normalize: %v", c.name, err)
+			continue
+		}
+		if reflect.TypeOf(ev) != reflect.TypeOf(c.wantType) {
+			t.Errorf("%s: want %T, got %T", c.name, c.wantType, ev)
+		}
+		if b := ev.Common(); b.TypeUID != c.typeUID || b.TypeName != c.typeName || b.CategoryName == "" {
+			t.Errorf("%s: want %d %q, got %d %q in %q", c.name, c.typeUID, c.typeName, b.TypeUID, b.TypeName,
+				b.CategoryName)
+		}
+	}
+}
+
+func TestNormalize_DerivesEnumCaptions(t *testing.T) {
+	base := `"severity_id": 1, "time": 1, "metadata": {"product": {"name": "p"}}`
+
+	ev, err := ocsf.Normalize(attrs(t, `{"class_uid": 4003, "activity_id": 1, `+base+`,
+		"query": {"hostname": "example.com"}, "rcode_id": 0, "connection_info": {"direction_id": 1}}`))
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	dns := ev.(*ocsf.DNSActivity)
+	if dns.RCode != "NoError" || dns.ConnectionInfo.Direction != "Inbound" {
+		t.Errorf("DNS captions: got %q, %q", dns.RCode, dns.ConnectionInfo.Direction)
+	}
+
+	ev, err = ocsf.Normalize(attrs(t, `{"class_uid": 2004, "activity_id": 1, `+base+`,
+		"finding_info": {"uid": "f"}, "confidence_id": 2, "risk_level_id": 3, "severity": "Low-ish"}`))
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	finding := ev.(*ocsf.DetectionFinding)
+	if finding.Confidence != "Medium" || finding.RiskLevel != "High" {
+		t.Errorf("finding captions: got %q, %q", finding.Confidence, finding.RiskLevel)
+	}
+	if finding.Severity != "Low-ish" {
+		t.Errorf("severity: want the source's caption kept, got %q", finding.Severity)
+	}
+}
+
+func TestNormalize_ValidationErrors(t *testing.T) {
+	ev, err := ocsf.Normalize(attrs(t, `{
+		"class_uid": 3002,
+		"activity_id": 42,
+		"category_uid": 4,
+		"type_uid": 300202,
+		"severity_id": 7,
+		"status_id": 5,
+		"time": "yesterday",
+		"metadata": {"product": {"name": 1}},
+		"src_endpoint": {"ip": "10.0.0.1", "port": "22", "planet": "earth"},
+		"is_mfa": "yes",
+		"observables": {"name": "x"},
+		"colour": "blue"
+	}`))
+	if ev != nil {
+		t.Errorf("Normalize: want no event, got %+v", ev)
+	}
+	var verr *ocsf.ValidationError
+	if !errors.As(err, &verr) || !errors.Is(err, ocsf.ErrInvalid) {
+		t.Fatalf("Normalize: want a ValidationError, got %v", err)
+	}
+	want := []string{
+		"activity_id",
+		"category_uid",
+		"colour",
+		"is_mfa",
+		"metadata.product.name",
+		"observables",
+		"severity_id",
+		"src_endpoint.planet",
+		"src_endpoint.port",
+		"status_id",
+		"time",
+		"type_uid",
+		"user",
+	}
+	got := make([]string, len(verr.Errors))
+	for i, fe := range verr.Errors {
+		got[i] = fe.Path
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paths:\nwant %v\n got %v\n(%v)", want, got, err)
+	}
+}
+
+func TestNormalize_RequiredAttributes(t *testing.T) {
+	for _, c := range []struct {
+		name, raw, path string
+	}{
+		{"no class", `{"activity_id": 1}`, "class_uid"},
+		{"class not an integer", `{"class_uid": "auth"}`, "class_uid"},
+		{"unsupported class", `{"class_uid": 3001}`, "class_uid"},
+		{"no time", `{"class_uid": 4002, "activity_id": 1, "severity_id": 1, "metadata": {"product": {}},
+			"http_request": {}}`, "time"},
+		{"no product", `{"class_uid": 4002, "activity_id": 1, "severity_id": 1, "time": 1, "metadata": {},
+			"http_request": {}}`, "metadata.product"},
+		{"no query hostname", `{"class_uid": 4003, "activity_id": 1, "severity_id": 1, "time": 1,
+			"metadata": {"product": {}}, "query": {"type": "A"}}`, "query.hostname"},
+		{"no finding uid", `{"class_uid": 2004, "activity_id": 1, "severity_id": 1, "time": 1,
+			"metadata": {"product": {}}, "finding_info": {"uid": null}}`, "finding_info.uid"},
+		{"no api operation", `{"class_uid": 6003, "activity_id": 2, "severity_id": 1, "time": 1,
+			"metadata": {"product": {}}, "actor": {}, "api": {"service": {"name": "s3"}}}`, "api.operation"},
+	} {
+		_, err := ocsf.Normalize(attrs(t, c.raw))
+		var verr *ocsf.ValidationError
+		if !errors.As(err, &verr) {
+			t.Errorf("%s: want a ValidationError, got %v", c.name, err)
+			continue
+		}
+		found := false
+		for _, fe := range verr.Errors {
+			found = found || fe.Path == c.path
+		}
+		if !found {
+			t.Errorf("%s: want a problem at %s, got %v", c.name, c.path, err)
+		}
+	}
+}
+
+func TestDerive_BuiltEvent(t *testing.T) {
+	ev := &ocsf.ProcessActivity{
+		BaseEvent: ocsf.BaseEvent{
+			ClassUID:   ocsf.ClassProcessActivity,
+			ActivityID: ocsf.ProcessActivityTerminate,
+			SeverityID: ocsf.SeverityInformational,
+			Metadata:   ocsf.Metadata{Version: "1.0.0"},
+		},
+	}
+	ocsf.Derive(ev)
+
+	if ev.TypeUID != 100702 || ev.CategoryUID != ocsf.CategorySystemActivity ||
+		ev.TypeName != "Process Activity: Terminate" {
+		t.Errorf("Derive: got %d in %d, %q", ev.TypeUID, ev.CategoryUID, ev.TypeName)
+	}
+	if ev.Metadata.Version != "1.0.0" || ev.Severity != "Informational" || ev.Status != "" {
+		t.Errorf("Derive: got version %q, severity %q, status %q", ev.Metadata.Version, ev.Severity, ev.Status)
+	}
+	if got := ocsf.TypeUID(ocsf.ClassDNSActivity, ocsf.DNSActivityTraffic); got != 400306 {
+		t.Errorf("TypeUID: want 400306, got %d", got)
+	}
+}
+
+func TestNormalize_RoundTripsThroughJSON(t *testing.T) {
+	in := attrs(t, `{"class_uid": 4001, "activity_id": 1, "severity_id": 1, "time": 1792418400000,
+		"metadata": {"product": {"name": "fw"}}, "src_endpoint": {"ip": "10.0.0.1", "port": 1234},
+		"dst_endpoint": {"ip": "10.0.0.2", "port": 22}}`)
+	ev, err := ocsf.Normalize(in)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	raw, err := json.Marshal(ev)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	again, err := ocsf.Normalize(attrs(t, string(raw)))
+	if err != nil {
+		t.Fatalf("Normalize of the output: %v", err)
+	}
+	if !reflect.DeepEqual(ev, again) {
+		t.Errorf("round trip:\nwant %+v\n got %+v", ev, again)
+	}
+}
+
+func TestLookupClass(t *testing.T) {
+	c, ok := ocsf.LookupClass(ocsf.ClassHTTPActivity)
+	if !ok || c.Name != "HTTP Activity" || c.Category != ocsf.CategoryNetworkActivity {
+		t.Errorf("LookupClass: got %+v", c)
+	}
+	if _, ok := ocsf.LookupClass(1); ok {
+		t.Error("LookupClass of an unsupported class: want false")
+	}
+}
@@ -0,0 +1,166 @@
+package ocsf
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// checkTypes checks attrs against the attributes and types of ev's class,
+// returning a problem per attribute the class does not have or whose value
+// is of another type.
+func checkTypes(attrs map[string]any, ev Event) []FieldError {
+	var errs []FieldError
+	checkObject(attrs, reflect.TypeOf(ev).Elem(), "", &errs)
+	return errs
+}
+
+func checkObject(obj map[string]any, t reflect.Type, prefix string, errs *[]FieldError) {
+	fields := attributesOf(t)
+	for name, v := range obj {
+		path := prefix + name
+		ft, ok := fields[name]
+		if !ok {
+			*errs = append(*errs, FieldError{Path: path, Message: "is not an attribute of the class"})
+			continue
+		}
+		checkValue(v, ft, path, errs)
+	}
+}
+
+func checkValue(v any, t reflect.Type, path string, errs *[]FieldError) {
+	if v == nil {
+		return
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	want := ""
+	switch t.Kind() {
+	case reflect.String:
+		if _, ok := v.(string); !ok {
+			want = "a string"
+		}
+	case reflect.Bool:
+		if _, ok := v.(bool); !ok {
+			want = "a boolean"
+		}
+	case reflect.Int, reflect.Int64:
+		if _, ok := integer(v); !ok {
+			want = "an integer"
+		}
+	case reflect.Float64:
+		if _, ok := number(v); !ok {
+			want = "a number"
+		}
+	case reflect.Slice:
+		arr, ok := v.([]any)
+		if !ok {
+			want = "an array"
+			break
+		}
+		for i, e := range arr {
+			checkValue(e, t.Elem(), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+	case reflect.Struct:
+		obj, ok := v.(map[string]any)
+		if !ok {
+			want = "an object"
+			break
+		}
+		checkObject(obj, t, path+".", errs)
+	case reflect.Map:
+		if _, ok := v.(map[string]any); !ok {
+			want = "an object"
+		}
+	}
+	if want != "" {
+		*errs = append(*errs, FieldError{Path: path, Message: "must be " + want})
+	}
+}
+
+// attributes caches attributesOf by struct type.
+var attributes sync.Map // reflect.Type -> map[string]reflect.Type
+
+// attributesOf returns the types of the attributes of struct type t by JSON
+// name, including those of embedded structs, as encoding/json sees them.
+func attributesOf(t reflect.Type) map[string]reflect.Type {
+	if cached, ok := attributes.Load(t); ok {
+		return cached.(map[string]reflect.Type)
+	}
+	out := make(map[string]reflect.Type)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			for name, ft := range attributesOf(f.Type) {
+				out[name] = ft
+			}
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if !f.IsExported() || name == "-" || name == "" {
+			continue
+		}
+		out[name] = f.Type
+	}
+	attributes.Store(t, out)
+	return out
+}
+
+// lookup returns the non-null value at the dotted path p.
+func lookup(attrs map[string]any, p string) (any, bool) {
+	var cur any = attrs
+	for seg := range strings.SplitSeq(p, ".") {
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		cur = obj[seg]
+	}
+	return cur, cur != nil
+}
+
+func intAttr(attrs map[string]any, name string) (int, bool) {
+	v, ok := attrs[name]
+	if !ok {
+		return 0, false
+	}
+	n, ok := integer(v)
+	return int(n), ok
+}
+
+// integer reads the integers mappings and JSON decoding produce.
+func integer(v any) (int64, bool) {
+	switch v := v.(type) {
+	case int64:
+		return v, true
+	case int:
+		return int64(v), true
+	case json.Number:
+		n, err := v.Int64()
+		return n, err == nil
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return int64(v), true
+		}
+	}
+	return 0, false
+}
+
+func number(v any) (float64, bool) {
+	switch v := v.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
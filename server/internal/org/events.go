@@ -0,0 +1,46 @@
+package org
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox. They all use the organisation as
+// their aggregate, so consumers see an org's changes in commit order.
+const (
+	aggregateType = "organization"
+
+	EventOrgCreated        = "organization.created"
+	EventOrgRenamed        = "organization.renamed"
+	EventMemberAdded       = "organization.member_added"
+	EventMemberRoleChanged = "organization.member_role_changed"
+	EventMemberRemoved     = "organization.member_removed"
+)
+
+// MemberRemovedPayload is the payload of EventMemberRemoved.
+type MemberRemovedPayload struct {
+	OrgID  uuid.UUID `json:"org_id"`
+	UserID uuid.UUID `json:"user_id"`
+}
+
+// emit appends an event about orgID to the outbox, in the transaction on ctx.
+func (s *Service) emit(ctx context.Context, orgID uuid.UUID, eventType string, payload any) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   orgID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
+
+// orgPayload drops the caller-specific fields of o.
+func orgPayload(o oapi.Organization) oapi.Organization {
+	o.MyRole = nil
+	o.MemberCount = nil
+	return o
+}
@@ -3,6 +3,7 @@ package org
 import (
 	"context"
 	"errors"
+	"strconv"
 
 	"github.com/google/uuid"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/luketeo/horizon/internal/platform/authz"
 	"github.com/luketeo/horizon/internal/platform/httpx"
 	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -26,34 +28,36 @@ func NewHandler(svc *Service, userSvc *user.Service) *Handler {
 }
 
 // requireUser resolves the authenticated Clerk user to an internal user UUID,
-// upserting the record on first access.
-func (h *Handler) requireUser(ctx context.Context) (uuid.UUID, bool) {
+// upserting the record on first access. The returned context carries the
+// user's tenant scope, which repos need to see any org-scoped rows.
+func (h *Handler) requireUser(ctx context.Context) (context.Context, uuid.UUID, bool) {
 	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
 	if !ok {
-		return uuid.Nil, false
+		return ctx, uuid.Nil, false
 	}
 	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
 	if err != nil {
-		return uuid.Nil, false
+		return ctx, uuid.Nil, false
 	}
-	return userID, true
+	return tenant.WithUser(ctx, userID), userID, true
 }
 
-// requireMembership returns (userID, role, true) for an authenticated member of
-// orgID, or zero values and false for unauthenticated/non-member callers.
+// requireMembership returns (ctx, userID, role, true) for an authenticated
+// member of orgID, or zero values and false for unauthenticated/non-member
+// callers. The returned context is scoped to the user and narrowed to orgID.
 func (h *Handler) requireMembership(
 	ctx context.Context,
 	orgID uuid.UUID,
-) (uuid.UUID, oapi.OrgRole, bool) {
-	userID, ok := h.requireUser(ctx)
+) (context.Context, uuid.UUID, oapi.OrgRole, bool) {
+	ctx, userID, ok := h.requireUser(ctx)
 	if !ok {
-		return uuid.Nil, "", false
+		return ctx, uuid.Nil, "", false
 	}
 	role, err := h.svc.GetMembership(ctx, orgID, userID)
 	if err != nil {
-		return uuid.Nil, "", false
+		return ctx, uuid.Nil, "", false
 	}
-	return userID, role, true
+	return tenant.WithOrg(ctx, orgID), userID, role, true
 }
 
 // ── Organizations ────────────────────────────────────────────────────────────
@@ -62,7 +66,7 @@ func (h *Handler) ListOrganizations(
 	ctx context.Context,
 	_ oapi.ListOrganizationsRequestObject,
 ) (oapi.ListOrganizationsResponseObject, error) {
-	userID, ok := h.requireUser(ctx)
+	ctx, userID, ok := h.requireUser(ctx)
 	if !ok {
 		return oapi.ListOrganizations401ApplicationProblemPlusJSONResponse{
 			UnauthorizedApplicationProblemPlusJSONResponse: oapi.UnauthorizedApplicationProblemPlusJSONResponse(
@@ -85,7 +89,7 @@ func (h *Handler) CreateOrganization(
 	ctx context.Context,
 	request oapi.CreateOrganizationRequestObject,
 ) (oapi.CreateOrganizationResponseObject, error) {
-	userID, ok := h.requireUser(ctx)
+	ctx, userID, ok := h.requireUser(ctx)
 	if !ok {
 		return oapi.CreateOrganization401ApplicationProblemPlusJSONResponse{
 			UnauthorizedApplicationProblemPlusJSONResponse: oapi.UnauthorizedApplicationProblemPlusJSONResponse(
@@ -112,7 +116,7 @@ func (h *Handler) GetOrganization(
 	ctx context.Context,
 	request oapi.GetOrganizationRequestObject,
 ) (oapi.GetOrganizationResponseObject, error) {
-	userID, _, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, userID, _, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.GetOrganization403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
@@ -132,14 +136,21 @@ func (h *Handler) GetOrganization(
 		}
 		return nil, err
 	}
-	return oapi.GetOrganization200JSONResponse(o), nil
+	etag := orgETag(o)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetOrganization304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetOrganization200JSONResponse{
+		Body:    o,
+		Headers: oapi.GetOrganization200ResponseHeaders{ETag: etag},
+	}, nil
 }
 
 func (h *Handler) UpdateOrganization(
 	ctx context.Context,
 	request oapi.UpdateOrganizationRequestObject,
 ) (oapi.UpdateOrganizationResponseObject, error) {
-	userID, role, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, userID, role, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.UpdateOrganization403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
@@ -155,18 +166,50 @@ func (h *Handler) UpdateOrganization(
 		}, nil
 	}
 
-	o, err := h.svc.UpdateOrg(ctx, request.OrgId, request.Body.Name, userID)
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return orgPreconditionFailed(), nil
+	}
+
+	o, err := h.svc.UpdateOrg(ctx, request.OrgId, request.Body.Name, userID, ifMatch)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
+		switch {
+		case errors.Is(err, ErrNotFound):
 			return oapi.UpdateOrganization404ApplicationProblemPlusJSONResponse{
 				NotFoundApplicationProblemPlusJSONResponse: oapi.NotFoundApplicationProblemPlusJSONResponse(
 					httpx.Prob(404, "Not Found", "Organisation not found"),
 				),
 			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return orgPreconditionFailed(), nil
 		}
 		return nil, err
 	}
-	return oapi.UpdateOrganization200JSONResponse(o), nil
+	return oapi.UpdateOrganization200JSONResponse{
+		Body:    o,
+		Headers: oapi.UpdateOrganization200ResponseHeaders{ETag: orgETag(o)},
+	}, nil
+}
+
+// orgETag tags an org as its caller sees it: besides the row, the body carries
+// the caller's role and the member count, which change without bumping it.
+func orgETag(o oapi.Organization) string {
+	var variant []string
+	if o.MyRole != nil {
+		variant = append(variant, string(*o.MyRole))
+	}
+	if o.MemberCount != nil {
+		variant = append(variant, strconv.Itoa(*o.MemberCount))
+	}
+	return httpx.ETag(o.Version, variant...)
+}
+
+func orgPreconditionFailed() oapi.UpdateOrganization412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateOrganization412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Organisation was modified since it was last read"),
+		),
+	}
 }
 
 // ── Members ──────────────────────────────────────────────────────────────────
@@ -175,7 +218,8 @@ func (h *Handler) ListOrganizationMembers(
 	ctx context.Context,
 	request oapi.ListOrganizationMembersRequestObject,
 ) (oapi.ListOrganizationMembersResponseObject, error) {
-	if _, _, ok := h.requireMembership(ctx, request.OrgId); !ok {
+	ctx, _, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
 		return oapi.ListOrganizationMembers403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
 				httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
@@ -193,11 +237,45 @@ func (h *Handler) ListOrganizationMembers(
 	return oapi.ListOrganizationMembers200JSONResponse(members), nil
 }
 
+func (h *Handler) GetOrganizationMember(
+	ctx context.Context,
+	request oapi.GetOrganizationMemberRequestObject,
+) (oapi.GetOrganizationMemberResponseObject, error) {
+	ctx, _, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetOrganizationMember403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
+				httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+			),
+		}, nil
+	}
+
+	m, err := h.svc.GetMember(ctx, request.OrgId, request.UserId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetOrganizationMember404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: oapi.NotFoundApplicationProblemPlusJSONResponse(
+					httpx.Prob(404, "Not Found", "Member not found"),
+				),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := memberETag(m)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetOrganizationMember304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetOrganizationMember200JSONResponse{
+		Body:    m,
+		Headers: oapi.GetOrganizationMember200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
 func (h *Handler) AddOrganizationMember(
 	ctx context.Context,
 	request oapi.AddOrganizationMemberRequestObject,
 ) (oapi.AddOrganizationMemberResponseObject, error) {
-	_, role, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, _, role, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.AddOrganizationMember403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
@@ -238,7 +316,7 @@ func (h *Handler) UpdateOrganizationMember(
 	ctx context.Context,
 	request oapi.UpdateOrganizationMemberRequestObject,
 ) (oapi.UpdateOrganizationMemberResponseObject, error) {
-	_, role, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, _, role, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.UpdateOrganizationMember403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
@@ -254,25 +332,53 @@ func (h *Handler) UpdateOrganizationMember(
 		}, nil
 	}
 
-	m, err := h.svc.UpdateMemberRole(ctx, request.OrgId, request.UserId, request.Body.Role)
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return memberPreconditionFailed(), nil
+	}
+
+	m, err := h.svc.UpdateMemberRole(ctx, request.OrgId, request.UserId, request.Body.Role, ifMatch)
 	if err != nil {
-		if errors.Is(err, ErrNotFound) {
+		switch {
+		case errors.Is(err, ErrNotFound):
 			return oapi.UpdateOrganizationMember404ApplicationProblemPlusJSONResponse{
 				NotFoundApplicationProblemPlusJSONResponse: oapi.NotFoundApplicationProblemPlusJSONResponse(
 					httpx.Prob(404, "Not Found", "Member not found"),
 				),
 			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return memberPreconditionFailed(), nil
 		}
 		return nil, err
 	}
-	return oapi.UpdateOrganizationMember200JSONResponse(m), nil
+	return oapi.UpdateOrganizationMember200JSONResponse{
+		Body:    m,
+		Headers: oapi.UpdateOrganizationMember200ResponseHeaders{ETag: memberETag(m)},
+	}, nil
+}
+
+// memberETag tags a membership together with the user it embeds, whose row
+// changes independently of it.
+func memberETag(m oapi.OrganizationMember) string {
+	if m.User == nil {
+		return httpx.ETag(m.Version)
+	}
+	return httpx.ETag(m.Version, strconv.FormatInt(m.User.Version, 10))
+}
+
+func memberPreconditionFailed() oapi.UpdateOrganizationMember412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateOrganizationMember412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Membership was modified since it was last read"),
+		),
+	}
 }
 
 func (h *Handler) RemoveOrganizationMember(
 	ctx context.Context,
 	request oapi.RemoveOrganizationMemberRequestObject,
 ) (oapi.RemoveOrganizationMemberResponseObject, error) {
-	_, role, ok := h.requireMembership(ctx, request.OrgId)
+	ctx, _, role, ok := h.requireMembership(ctx, request.OrgId)
 	if !ok {
 		return oapi.RemoveOrganizationMember403ApplicationProblemPlusJSONResponse{
 			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
@@ -12,7 +12,10 @@ import (
 	"github.com/luketeo/horizon/generated/oapi"
 	"github.com/luketeo/horizon/internal/org"
 	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
 	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -21,8 +24,8 @@ func newOrgHandler(t *testing.T) (*org.Handler, *user.Service, *org.Service, *sq
 	db := testhelper.DB(t)
 	testhelper.Reset(t, db)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	userSvc := user.NewService(user.NewRepo(db), logger)
-	orgSvc := org.NewService(org.NewRepo(db), logger)
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
 	return org.NewHandler(orgSvc, userSvc), userSvc, orgSvc, db
 }
 
@@ -83,7 +86,7 @@ func TestGetOrganization_NonMemberReturns403(t *testing.T) {
 	if err != nil {
 		t.Fatalf("seed owner: %v", err)
 	}
-	o, err := orgSvc.CreateOrg(ctx, "Gated", nil, ownerID)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, ownerID), "Gated", nil, ownerID)
 	if err != nil {
 		t.Fatalf("CreateOrg: %v", err)
 	}
@@ -130,3 +133,126 @@ func TestAddOrganizationMember_UnknownEmailReturns404(t *testing.T) {
 		t.Fatalf("want 404, got %T", resp)
 	}
 }
+
+func TestGetOrganization_IfNoneMatchReturns304(t *testing.T) {
+	h, _, _, _ := newOrgHandler(t)
+	ctx := middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_etag_owner", "eto@example.com"),
+	)
+
+	createResp, err := h.CreateOrganization(ctx, oapi.CreateOrganizationRequestObject{
+		Body: &oapi.CreateOrganizationJSONRequestBody{Name: "ETag Org"},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	created := createResp.(oapi.CreateOrganization201JSONResponse)
+
+	getResp, err := h.GetOrganization(ctx, oapi.GetOrganizationRequestObject{OrgId: created.Id})
+	if err != nil {
+		t.Fatalf("GetOrganization: %v", err)
+	}
+	got, ok := getResp.(oapi.GetOrganization200JSONResponse)
+	if !ok {
+		t.Fatalf("want 200, got %T", getResp)
+	}
+	etag := got.Headers.ETag
+
+	resp, err := h.GetOrganization(ctx, oapi.GetOrganizationRequestObject{
+		OrgId:  created.Id,
+		Params: oapi.GetOrganizationParams{IfNoneMatch: &etag},
+	})
+	if err != nil {
+		t.Fatalf("GetOrganization: %v", err)
+	}
+	notModified, ok := resp.(oapi.GetOrganization304Response)
+	if !ok {
+		t.Fatalf("want 304, got %T", resp)
+	}
+	if notModified.Headers.ETag != etag {
+		t.Fatalf("304 ETag = %q, want %q", notModified.Headers.ETag, etag)
+	}
+}
+
+func TestGetOrganization_MemberCountChangesETag(t *testing.T) {
+	h, userSvc, _, _ := newOrgHandler(t)
+	ctx := middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_etag_count_owner", "etco@example.com"),
+	)
+	if _, _, err := userSvc.GetOrCreateUser(
+		context.Background(),
+		fakeClerkUser("user_etag_count_member", "etcm@example.com"),
+	); err != nil {
+		t.Fatalf("seed member: %v", err)
+	}
+
+	createResp, err := h.CreateOrganization(ctx, oapi.CreateOrganizationRequestObject{
+		Body: &oapi.CreateOrganizationJSONRequestBody{Name: "ETag Count Org"},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	created := createResp.(oapi.CreateOrganization201JSONResponse)
+	getResp, err := h.GetOrganization(ctx, oapi.GetOrganizationRequestObject{OrgId: created.Id})
+	if err != nil {
+		t.Fatalf("GetOrganization: %v", err)
+	}
+	etag := getResp.(oapi.GetOrganization200JSONResponse).Headers.ETag
+
+	if _, err := h.AddOrganizationMember(ctx, oapi.AddOrganizationMemberRequestObject{
+		OrgId: created.Id,
+		Body: &oapi.AddOrganizationMemberJSONRequestBody{
+			Email: openapi_types.Email("etcm@example.com"),
+			Role:  oapi.Analyst,
+		},
+	}); err != nil {
+		t.Fatalf("AddOrganizationMember: %v", err)
+	}
+
+	resp, err := h.GetOrganization(ctx, oapi.GetOrganizationRequestObject{
+		OrgId:  created.Id,
+		Params: oapi.GetOrganizationParams{IfNoneMatch: &etag},
+	})
+	if err != nil {
+		t.Fatalf("GetOrganization: %v", err)
+	}
+	got, ok := resp.(oapi.GetOrganization200JSONResponse)
+	if !ok {
+		t.Fatalf("want 200 after a member joined, got %T", resp)
+	}
+	if got.Body.MemberCount == nil || *got.Body.MemberCount != 2 {
+		t.Fatalf("member_count = %v, want 2", got.Body.MemberCount)
+	}
+}
+
+func TestUpdateOrganization_StaleIfMatchReturns412(t *testing.T) {
+	h, _, _, _ := newOrgHandler(t)
+	ctx := middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_ifmatch_owner", "imo@example.com"),
+	)
+
+	createResp, err := h.CreateOrganization(ctx, oapi.CreateOrganizationRequestObject{
+		Body: &oapi.CreateOrganizationJSONRequestBody{Name: "If-Match Org"},
+	})
+	if err != nil {
+		t.Fatalf("CreateOrganization: %v", err)
+	}
+	created := createResp.(oapi.CreateOrganization201JSONResponse)
+	stale := `"999"`
+	name := "Renamed"
+
+	resp, err := h.UpdateOrganization(ctx, oapi.UpdateOrganizationRequestObject{
+		OrgId:  created.Id,
+		Params: oapi.UpdateOrganizationParams{IfMatch: &stale},
+		Body:   &oapi.UpdateOrganizationJSONRequestBody{Name: &name},
+	})
+	if err != nil {
+		t.Fatalf("UpdateOrganization: %v", err)
+	}
+	if _, ok := resp.(oapi.UpdateOrganization412ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 412, got %T", resp)
+	}
+}
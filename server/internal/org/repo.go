@@ -2,7 +2,6 @@ package org
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 
@@ -15,15 +14,18 @@ import (
 	"github.com/luketeo/horizon/generated/horizon/public/model"
 	"github.com/luketeo/horizon/generated/horizon/public/table"
 	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
 )
 
 // Repo owns organizations + organization_members SQL and row→DTO mapping.
+// Statements run on tx.Executor(ctx, db); both tables are protected by RLS, so
+// callers run them inside tx.Manager.RunInTx with the tenant scope on ctx.
 type Repo struct {
-	db *sql.DB
+	db qrm.DB
 }
 
 // NewRepo wires a Repo backed by the given database handle.
-func NewRepo(db *sql.DB) *Repo {
+func NewRepo(db qrm.DB) *Repo {
 	return &Repo{db: db}
 }
 
@@ -48,6 +50,7 @@ func (o orgWithMembership) toOapi() oapi.Organization {
 		Plan:        o.Plan,
 		MyRole:      &role,
 		MemberCount: &count,
+		Version:     o.Version,
 		CreatedAt:   o.CreatedAt,
 		UpdatedAt:   o.UpdatedAt,
 	}
@@ -67,6 +70,7 @@ func (m memberWithUser) toOapi() oapi.OrganizationMember {
 		LastName:    m.User.LastName,
 		AvatarUrl:   m.User.AvatarURL,
 		LastLoginAt: m.User.LastLoginAt,
+		Version:     m.User.Version,
 		CreatedAt:   m.User.CreatedAt,
 		UpdatedAt:   m.User.UpdatedAt,
 	}
@@ -76,6 +80,7 @@ func (m memberWithUser) toOapi() oapi.OrganizationMember {
 		UserId:    m.UserID,
 		Role:      oapi.OrgRole(m.Role),
 		User:      &user,
+		Version:   m.Version,
 		CreatedAt: m.CreatedAt,
 		UpdatedAt: m.UpdatedAt,
 	}
@@ -89,6 +94,7 @@ func userModelToOapi(u model.Users) oapi.User {
 		LastName:    u.LastName,
 		AvatarUrl:   u.AvatarURL,
 		LastLoginAt: u.LastLoginAt,
+		Version:     u.Version,
 		CreatedAt:   u.CreatedAt,
 		UpdatedAt:   u.UpdatedAt,
 	}
@@ -111,56 +117,66 @@ func memberCountsSubquery() (postgres.SelectTable, postgres.ColumnString, postgr
 		postgres.IntegerColumn("count").From(sub)
 }
 
+// versionIn restricts a statement to rows whose version column holds one of
+// versions. A nil slice means the caller sent no precondition.
+func versionIn(col postgres.ColumnInteger, versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return col.IN(exprs...)
+}
+
 // ── Organizations ────────────────────────────────────────────────────────────
 
 // CreateOrgWithOwner inserts an organisation and its owner membership in one
 // transaction, retrying on slug unique-violations with `<base>-2`, `<base>-3`, …
 // Unique violations inside a tx normally abort the tx; we wrap each insert in a
-// SAVEPOINT so we can roll back to a clean state and retry.
+// SAVEPOINT so we can roll back to a clean state and retry, which requires
+// the caller to run it inside tx.Manager.RunInTx.
+//
+// The org id is generated here rather than RETURNed: until the owner row
+// exists the new org is invisible to the caller under RLS, so it is read back
+// only after the membership insert.
 func (r *Repo) CreateOrgWithOwner(
 	ctx context.Context,
 	name, baseSlug string,
 	creatorID uuid.UUID,
 ) (oapi.Organization, error) {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return oapi.Organization{}, fmt.Errorf("begin tx: %w", err)
-	}
-	defer tx.Rollback() //nolint:errcheck
+	orgID := uuid.New()
 
 	insertStmtFor := func(slug string) postgres.InsertStatement {
 		return table.Organizations.
 			INSERT(
+				table.Organizations.ID,
 				table.Organizations.Name,
 				table.Organizations.Slug,
 				table.Organizations.Plan,
 				table.Organizations.Settings,
 			).
-			VALUES(name, slug, "free", "{}").
-			RETURNING(
-				table.Organizations.ID,
-				table.Organizations.CreatedAt,
-				table.Organizations.UpdatedAt,
-			)
+			VALUES(orgID, name, slug, "free", "{}")
 	}
 
-	var inserted model.Organizations
-
+	db := tx.Executor(ctx, r.db)
+	var err error
 	slug := baseSlug
 	for i := range 10 {
-		if _, err = tx.ExecContext(ctx, "SAVEPOINT insert_org"); err != nil {
+		if _, err = db.ExecContext(ctx, "SAVEPOINT insert_org"); err != nil {
 			return oapi.Organization{}, fmt.Errorf("savepoint: %w", err)
 		}
-		err = insertStmtFor(slug).QueryContext(ctx, tx, &inserted)
+		_, err = insertStmtFor(slug).ExecContext(ctx, db)
 		if err == nil {
-			if _, relErr := tx.ExecContext(ctx, "RELEASE SAVEPOINT insert_org"); relErr != nil {
+			if _, relErr := db.ExecContext(ctx, "RELEASE SAVEPOINT insert_org"); relErr != nil {
 				return oapi.Organization{}, fmt.Errorf("release savepoint: %w", relErr)
 			}
 			break
 		}
 		var pgErr *pq.Error
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
-			if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT insert_org"); rbErr != nil {
+			if _, rbErr := db.ExecContext(ctx, "ROLLBACK TO SAVEPOINT insert_org"); rbErr != nil {
 				return oapi.Organization{}, fmt.Errorf("rollback to savepoint: %w", rbErr)
 			}
 			slug = fmt.Sprintf("%s-%d", baseSlug, i+2)
@@ -178,28 +194,13 @@ func (r *Repo) CreateOrgWithOwner(
 			table.OrganizationMembers.UserID,
 			table.OrganizationMembers.Role,
 		).
-		VALUES(inserted.ID, creatorID, string(oapi.Owner))
+		VALUES(orgID, creatorID, string(oapi.Owner))
 
-	if _, err = memberInsert.ExecContext(ctx, tx); err != nil {
+	if _, err = memberInsert.ExecContext(ctx, db); err != nil {
 		return oapi.Organization{}, fmt.Errorf("adding creator as owner: %w", err)
 	}
 
-	if err = tx.Commit(); err != nil {
-		return oapi.Organization{}, fmt.Errorf("commit: %w", err)
-	}
-
-	role := oapi.Owner
-	count := 1
-	return oapi.Organization{
-		Id:          inserted.ID,
-		Name:        name,
-		Slug:        slug,
-		Plan:        "free",
-		MemberCount: &count,
-		MyRole:      &role,
-		CreatedAt:   inserted.CreatedAt,
-		UpdatedAt:   inserted.UpdatedAt,
-	}, nil
+	return r.GetForUser(ctx, orgID, creatorID)
 }
 
 // ListForUser returns orgs the user is a member of, with their role and the
@@ -228,7 +229,8 @@ func (r *Repo) ListForUser(
 		ORDER_BY(table.Organizations.CreatedAt.DESC())
 
 	var rows []orgWithMembership
-	if err := stmt.QueryContext(ctx, r.db, &rows); err != nil {
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows)
+	if err != nil {
 		return nil, fmt.Errorf("listing orgs: %w", err)
 	}
 
@@ -266,7 +268,7 @@ func (r *Repo) GetForUser(
 		LIMIT(1)
 
 	var row orgWithMembership
-	if err := stmt.QueryContext(ctx, r.db, &row); err != nil {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
 		if errors.Is(err, qrm.ErrNoRows) {
 			return oapi.Organization{}, ErrNotFound
 		}
@@ -275,24 +277,60 @@ func (r *Repo) GetForUser(
 	return row.toOapi(), nil
 }
 
-// UpdateName patches the org's name. A nil name leaves the column unchanged.
-func (r *Repo) UpdateName(ctx context.Context, orgID uuid.UUID, name *string) error {
+// UpdateName patches the org's name and bumps its version. A nil name leaves
+// the column unchanged. When ifMatch is non-nil the update only applies if the
+// current version is listed, otherwise ErrPreconditionFailed is returned.
+func (r *Repo) UpdateName(
+	ctx context.Context,
+	orgID uuid.UUID,
+	name *string,
+	ifMatch []int64,
+) error {
 	nameExpr := postgres.StringExpression(table.Organizations.Name)
 	if name != nil {
 		nameExpr = postgres.String(*name)
 	}
 
 	stmt := table.Organizations.
-		UPDATE(table.Organizations.Name, table.Organizations.UpdatedAt).
-		SET(nameExpr, postgres.NOW()).
-		WHERE(table.Organizations.ID.EQ(postgres.UUID(orgID)))
+		UPDATE(
+			table.Organizations.Name,
+			table.Organizations.Version,
+			table.Organizations.UpdatedAt,
+		).
+		SET(nameExpr, table.Organizations.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			table.Organizations.ID.EQ(postgres.UUID(orgID)).
+				AND(versionIn(table.Organizations.Version, ifMatch)),
+		)
 
-	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
 		return fmt.Errorf("updating org: %w", err)
 	}
+	if n, _ := res.RowsAffected(); n == 0 && ifMatch != nil {
+		return r.orgMissOrStale(ctx, orgID)
+	}
 	return nil
 }
 
+// orgMissOrStale explains a conditional org update that matched no row.
+func (r *Repo) orgMissOrStale(ctx context.Context, orgID uuid.UUID) error {
+	stmt := postgres.
+		SELECT(table.Organizations.ID).
+		FROM(table.Organizations).
+		WHERE(table.Organizations.ID.EQ(postgres.UUID(orgID))).
+		LIMIT(1)
+
+	var row model.Organizations
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("checking org version: %w", err)
+	}
+	return ErrPreconditionFailed
+}
+
 // GetMembership returns the user's role in the given org.
 func (r *Repo) GetMembership(
 	ctx context.Context,
@@ -308,7 +346,7 @@ func (r *Repo) GetMembership(
 		LIMIT(1)
 
 	var row model.OrganizationMembers
-	if err := stmt.QueryContext(ctx, r.db, &row); err != nil {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
 		if errors.Is(err, qrm.ErrNoRows) {
 			return "", ErrNotFound
 		}
@@ -335,7 +373,8 @@ func (r *Repo) ListMembers(
 		ORDER_BY(table.OrganizationMembers.CreatedAt.ASC())
 
 	var rows []memberWithUser
-	if err := stmt.QueryContext(ctx, r.db, &rows); err != nil {
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows)
+	if err != nil {
 		return nil, fmt.Errorf("listing members: %w", err)
 	}
 
@@ -346,7 +385,39 @@ func (r *Repo) ListMembers(
 	return members, nil
 }
 
-// FindUserIDByEmail resolves an email to its internal user id.
+// GetMember returns a single membership with its embedded user row.
+func (r *Repo) GetMember(
+	ctx context.Context,
+	orgID, userID uuid.UUID,
+) (oapi.OrganizationMember, error) {
+	stmt := postgres.
+		SELECT(
+			table.OrganizationMembers.AllColumns,
+			table.Users.AllColumns,
+		).
+		FROM(
+			table.OrganizationMembers.
+				INNER_JOIN(table.Users, table.Users.ID.EQ(table.OrganizationMembers.UserID)),
+		).
+		WHERE(
+			table.OrganizationMembers.OrgID.EQ(postgres.UUID(orgID)).
+				AND(table.OrganizationMembers.UserID.EQ(postgres.UUID(userID))),
+		).
+		LIMIT(1)
+
+	var row memberWithUser
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	if err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.OrganizationMember{}, ErrNotFound
+		}
+		return oapi.OrganizationMember{}, fmt.Errorf("getting member: %w", err)
+	}
+	return row.toOapi(), nil
+}
+
+// FindUserIDByEmail resolves an email to its internal user id. The users table
+// is not tenant-scoped.
 func (r *Repo) FindUserIDByEmail(ctx context.Context, email string) (uuid.UUID, error) {
 	stmt := postgres.
 		SELECT(table.Users.ID).
@@ -355,7 +426,7 @@ func (r *Repo) FindUserIDByEmail(ctx context.Context, email string) (uuid.UUID,
 		LIMIT(1)
 
 	var row model.Users
-	if err := stmt.QueryContext(ctx, r.db, &row); err != nil {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
 		if errors.Is(err, qrm.ErrNoRows) {
 			return uuid.Nil, ErrNotFound
 		}
@@ -381,7 +452,8 @@ func (r *Repo) InsertMember(
 		RETURNING(table.OrganizationMembers.AllColumns)
 
 	var row model.OrganizationMembers
-	if err := stmt.QueryContext(ctx, r.db, &row); err != nil {
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	if err != nil {
 		var pgErr *pq.Error
 		if errors.As(err, &pgErr) && pgErr.Code == "23505" {
 			return oapi.OrganizationMember{}, ErrConflict
@@ -393,38 +465,58 @@ func (r *Repo) InsertMember(
 		OrgId:     row.OrgID,
 		UserId:    row.UserID,
 		Role:      oapi.OrgRole(row.Role),
+		Version:   row.Version,
 		CreatedAt: row.CreatedAt,
 		UpdatedAt: row.UpdatedAt,
 	}, nil
 }
 
-// UpdateMemberRole mutates an existing membership's role.
+// UpdateMemberRole mutates an existing membership's role and bumps its
+// version. When ifMatch is non-nil the update only applies if the current
+// version is listed, otherwise ErrPreconditionFailed is returned.
 func (r *Repo) UpdateMemberRole(
 	ctx context.Context,
 	orgID, userID uuid.UUID,
 	role oapi.OrgRole,
+	ifMatch []int64,
 ) (oapi.OrganizationMember, error) {
 	stmt := table.OrganizationMembers.
-		UPDATE(table.OrganizationMembers.Role, table.OrganizationMembers.UpdatedAt).
-		SET(postgres.String(string(role)), postgres.NOW()).
+		UPDATE(
+			table.OrganizationMembers.Role,
+			table.OrganizationMembers.Version,
+			table.OrganizationMembers.UpdatedAt,
+		).
+		SET(
+			postgres.String(string(role)),
+			table.OrganizationMembers.Version.ADD(postgres.Int(1)),
+			postgres.NOW(),
+		).
 		WHERE(
 			table.OrganizationMembers.OrgID.EQ(postgres.UUID(orgID)).
-				AND(table.OrganizationMembers.UserID.EQ(postgres.UUID(userID))),
+				AND(table.OrganizationMembers.UserID.EQ(postgres.UUID(userID))).
+				AND(versionIn(table.OrganizationMembers.Version, ifMatch)),
 		).
 		RETURNING(table.OrganizationMembers.AllColumns)
 
 	var row model.OrganizationMembers
-	if err := stmt.QueryContext(ctx, r.db, &row); err != nil {
-		if errors.Is(err, qrm.ErrNoRows) {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if !errors.Is(err, qrm.ErrNoRows) {
+			return oapi.OrganizationMember{}, fmt.Errorf("updating member role: %w", err)
+		}
+		if ifMatch == nil {
 			return oapi.OrganizationMember{}, ErrNotFound
 		}
-		return oapi.OrganizationMember{}, fmt.Errorf("updating member role: %w", err)
+		if _, err := r.GetMembership(ctx, orgID, userID); err != nil {
+			return oapi.OrganizationMember{}, err
+		}
+		return oapi.OrganizationMember{}, ErrPreconditionFailed
 	}
 	return oapi.OrganizationMember{
 		Id:        row.ID,
 		OrgId:     row.OrgID,
 		UserId:    row.UserID,
 		Role:      oapi.OrgRole(row.Role),
+		Version:   row.Version,
 		CreatedAt: row.CreatedAt,
 		UpdatedAt: row.UpdatedAt,
 	}, nil
@@ -439,7 +531,7 @@ func (r *Repo) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error
 				AND(table.OrganizationMembers.UserID.EQ(postgres.UUID(userID))),
 		)
 
-	res, err := stmt.ExecContext(ctx, r.db)
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
 	if err != nil {
 		return fmt.Errorf("removing member: %w", err)
 	}
@@ -459,7 +551,7 @@ func (r *Repo) GetUser(ctx context.Context, userID uuid.UUID) (oapi.User, error)
 		LIMIT(1)
 
 	var row model.Users
-	if err := stmt.QueryContext(ctx, r.db, &row); err != nil {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
 		if errors.Is(err, qrm.ErrNoRows) {
 			return oapi.User{}, ErrNotFound
 		}
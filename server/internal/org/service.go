@@ -12,6 +12,8 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
 )
 
 // ErrNotFound is returned when an org, membership, or user cannot be found.
@@ -20,17 +22,26 @@ var ErrNotFound = errors.New("not found")
 // ErrConflict is returned on duplicate membership.
 var ErrConflict = errors.New("conflict")
 
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("precondition failed")
+
 var nonAlphaNumeric = regexp.MustCompile(`[^a-z0-9]+`)
 
-// Service coordinates organisation + membership operations.
+// Service coordinates organisation + membership operations. Every operation
+// runs in a transaction so the caller's tenant scope applies; callers already
+// inside one (e.g. another domain's service) join it. Mutations append their
+// domain event to the outbox in that same transaction.
 type Service struct {
 	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
 	logger *slog.Logger
 }
 
-// NewService wires a Service with its repo and logger.
-func NewService(repo *Repo, logger *slog.Logger) *Service {
-	return &Service{repo: repo, logger: logger}
+// NewService wires a Service with its repo, transaction manager, outbox and
+// logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{repo: repo, tx: txm, events: events, logger: logger}
 }
 
 // slugify converts a display name into a URL-safe slug.
@@ -61,7 +72,15 @@ func (s *Service) CreateOrg(
 	if slugHint != nil && *slugHint != "" {
 		base = *slugHint
 	}
-	return s.repo.CreateOrgWithOwner(ctx, name, base, creatorID)
+	var o oapi.Organization
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if o, err = s.repo.CreateOrgWithOwner(ctx, name, base, creatorID); err != nil {
+			return err
+		}
+		return s.emit(ctx, o.Id, EventOrgCreated, orgPayload(o))
+	})
+	return o, err
 }
 
 // ListOrgsForUser returns every org the given user belongs to.
@@ -69,7 +88,13 @@ func (s *Service) ListOrgsForUser(
 	ctx context.Context,
 	userID uuid.UUID,
 ) ([]oapi.Organization, error) {
-	return s.repo.ListForUser(ctx, userID)
+	var orgs []oapi.Organization
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		orgs, err = s.repo.ListForUser(ctx, userID)
+		return err
+	})
+	return orgs, err
 }
 
 // GetOrgForUser returns an org plus the user's role. Returns ErrNotFound when
@@ -78,20 +103,40 @@ func (s *Service) GetOrgForUser(
 	ctx context.Context,
 	orgID, userID uuid.UUID,
 ) (oapi.Organization, error) {
-	return s.repo.GetForUser(ctx, orgID, userID)
+	var o oapi.Organization
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		o, err = s.repo.GetForUser(ctx, orgID, userID)
+		return err
+	})
+	return o, err
 }
 
-// UpdateOrg patches the org name, then reloads the membership view.
+// UpdateOrg patches the org name, then reloads the membership view in the same
+// transaction. A non-nil ifMatch makes the update conditional on the org's
+// version.
 func (s *Service) UpdateOrg(
 	ctx context.Context,
 	orgID uuid.UUID,
 	name *string,
 	userID uuid.UUID,
+	ifMatch []int64,
 ) (oapi.Organization, error) {
-	if err := s.repo.UpdateName(ctx, orgID, name); err != nil {
-		return oapi.Organization{}, err
-	}
-	return s.repo.GetForUser(ctx, orgID, userID)
+	var o oapi.Organization
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if err := s.repo.UpdateName(ctx, orgID, name, ifMatch); err != nil {
+			return err
+		}
+		var err error
+		if o, err = s.repo.GetForUser(ctx, orgID, userID); err != nil {
+			return err
+		}
+		if name == nil {
+			return nil
+		}
+		return s.emit(ctx, orgID, EventOrgRenamed, orgPayload(o))
+	})
+	return o, err
 }
 
 // GetMembership returns the user's role for the org.
@@ -99,7 +144,13 @@ func (s *Service) GetMembership(
 	ctx context.Context,
 	orgID, userID uuid.UUID,
 ) (oapi.OrgRole, error) {
-	return s.repo.GetMembership(ctx, orgID, userID)
+	var role oapi.OrgRole
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		role, err = s.repo.GetMembership(ctx, orgID, userID)
+		return err
+	})
+	return role, err
 }
 
 // ListMembers returns the membership list with each user embedded.
@@ -107,52 +158,93 @@ func (s *Service) ListMembers(
 	ctx context.Context,
 	orgID uuid.UUID,
 ) ([]oapi.OrganizationMember, error) {
-	return s.repo.ListMembers(ctx, orgID)
+	var members []oapi.OrganizationMember
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		members, err = s.repo.ListMembers(ctx, orgID)
+		return err
+	})
+	return members, err
+}
+
+// GetMember returns a single membership with its user embedded.
+func (s *Service) GetMember(
+	ctx context.Context,
+	orgID, userID uuid.UUID,
+) (oapi.OrganizationMember, error) {
+	var m oapi.OrganizationMember
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		m, err = s.repo.GetMember(ctx, orgID, userID)
+		return err
+	})
+	return m, err
 }
 
-// AddMember adds a user (looked up by email) to an org at the given role.
-// Returns ErrNotFound if the email isn't registered; ErrConflict on duplicate.
+// AddMember adds a user (looked up by email) to an org at the given role. The
+// lookup, insert and user embed share one transaction. Returns ErrNotFound if
+// the email isn't registered; ErrConflict on duplicate.
 func (s *Service) AddMember(
 	ctx context.Context,
 	orgID uuid.UUID,
 	email string,
 	role oapi.OrgRole,
 ) (oapi.OrganizationMember, error) {
-	targetUserID, err := s.repo.FindUserIDByEmail(ctx, email)
-	if err != nil {
-		return oapi.OrganizationMember{}, err
-	}
-	m, err := s.repo.InsertMember(ctx, orgID, targetUserID, role)
+	var m oapi.OrganizationMember
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		targetUserID, err := s.repo.FindUserIDByEmail(ctx, email)
+		if err != nil {
+			return err
+		}
+		if m, err = s.repo.InsertMember(ctx, orgID, targetUserID, role); err != nil {
+			return err
+		}
+		u, err := s.repo.GetUser(ctx, targetUserID)
+		if err != nil {
+			return err
+		}
+		m.User = &u
+		return s.emit(ctx, orgID, EventMemberAdded, m)
+	})
 	if err != nil {
 		return oapi.OrganizationMember{}, err
 	}
-	if u, err := s.repo.GetUser(ctx, targetUserID); err == nil {
-		m.User = &u
-	} else if !errors.Is(err, ErrNotFound) {
-		s.logger.WarnContext(ctx, "failed to embed user on new member", "err", err)
-	}
 	return m, nil
 }
 
-// UpdateMemberRole mutates an existing membership's role.
+// UpdateMemberRole mutates an existing membership's role. A non-nil ifMatch
+// makes the update conditional on the membership's version.
 func (s *Service) UpdateMemberRole(
 	ctx context.Context,
 	orgID, userID uuid.UUID,
 	role oapi.OrgRole,
+	ifMatch []int64,
 ) (oapi.OrganizationMember, error) {
-	m, err := s.repo.UpdateMemberRole(ctx, orgID, userID, role)
+	var m oapi.OrganizationMember
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if m, err = s.repo.UpdateMemberRole(ctx, orgID, userID, role, ifMatch); err != nil {
+			return err
+		}
+		u, err := s.repo.GetUser(ctx, userID)
+		if err != nil {
+			return err
+		}
+		m.User = &u
+		return s.emit(ctx, orgID, EventMemberRoleChanged, m)
+	})
 	if err != nil {
 		return oapi.OrganizationMember{}, err
 	}
-	if u, err := s.repo.GetUser(ctx, userID); err == nil {
-		m.User = &u
-	} else if !errors.Is(err, ErrNotFound) {
-		s.logger.WarnContext(ctx, "failed to embed user on updated member", "err", err)
-	}
 	return m, nil
 }
 
 // RemoveMember deletes a membership. Returns ErrNotFound when no row matched.
 func (s *Service) RemoveMember(ctx context.Context, orgID, userID uuid.UUID) error {
-	return s.repo.RemoveMember(ctx, orgID, userID)
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if err := s.repo.RemoveMember(ctx, orgID, userID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, EventMemberRemoved, MemberRemovedPayload{OrgID: orgID, UserID: userID})
+	})
 }
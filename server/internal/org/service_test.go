@@ -13,7 +13,10 @@ import (
 
 	"github.com/luketeo/horizon/generated/oapi"
 	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
 	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -34,7 +37,12 @@ func fakeClerkUser(id, email string) *clerk.User {
 
 func seedUser(t *testing.T, db *sql.DB, clerkID, email string) uuid.UUID {
 	t.Helper()
-	userSvc := user.NewService(user.NewRepo(db), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	userSvc := user.NewService(
+		user.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
 	_, id, err := userSvc.GetOrCreateUser(context.Background(), fakeClerkUser(clerkID, email))
 	if err != nil {
 		t.Fatalf("seed user %s: %v", clerkID, err)
@@ -47,7 +55,7 @@ func newOrgService(t *testing.T) (*org.Service, *sql.DB) {
 	db := testhelper.DB(t)
 	testhelper.Reset(t, db)
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	return org.NewService(org.NewRepo(db), logger), db
+	return org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger), db
 }
 
 func TestCreateOrg_AssignsOwnerMembership(t *testing.T) {
@@ -55,6 +63,7 @@ func TestCreateOrg_AssignsOwnerMembership(t *testing.T) {
 	ctx := context.Background()
 
 	userID := seedUser(t, db, "user_create_org", "co@example.com")
+	ctx = tenant.WithUser(ctx, userID)
 	o, err := svc.CreateOrg(ctx, "Acme", nil, userID)
 	if err != nil {
 		t.Fatalf("CreateOrg: %v", err)
@@ -84,6 +93,7 @@ func TestCreateOrg_SlugConflictRetries(t *testing.T) {
 	ctx := context.Background()
 
 	userID := seedUser(t, db, "user_slug_conflict", "sc@example.com")
+	ctx = tenant.WithUser(ctx, userID)
 
 	first, err := svc.CreateOrg(ctx, "Acme", nil, userID)
 	if err != nil {
@@ -106,6 +116,7 @@ func TestCreateOrg_UsesSlugHintWhenProvided(t *testing.T) {
 	ctx := context.Background()
 
 	userID := seedUser(t, db, "user_slug_hint", "sh@example.com")
+	ctx = tenant.WithUser(ctx, userID)
 	hint := "custom-slug"
 	o, err := svc.CreateOrg(ctx, "Arbitrary Name", &hint, userID)
 	if err != nil {
@@ -123,16 +134,17 @@ func TestListOrgsForUser_ReturnsOnlyMemberOrgs(t *testing.T) {
 	alice := seedUser(t, db, "user_alice", "alice@example.com")
 	bob := seedUser(t, db, "user_bob", "bob@example.com")
 
-	aliceOrg, err := svc.CreateOrg(ctx, "AliceCo", nil, alice)
+	aliceCtx := tenant.WithUser(ctx, alice)
+	aliceOrg, err := svc.CreateOrg(aliceCtx, "AliceCo", nil, alice)
 	if err != nil {
 		t.Fatalf("alice org: %v", err)
 	}
-	_, err = svc.CreateOrg(ctx, "BobCo", nil, bob)
+	_, err = svc.CreateOrg(tenant.WithUser(ctx, bob), "BobCo", nil, bob)
 	if err != nil {
 		t.Fatalf("bob org: %v", err)
 	}
 
-	aliceList, err := svc.ListOrgsForUser(ctx, alice)
+	aliceList, err := svc.ListOrgsForUser(aliceCtx, alice)
 	if err != nil {
 		t.Fatalf("ListOrgsForUser: %v", err)
 	}
@@ -151,11 +163,11 @@ func TestGetOrgForUser_NonMemberReturnsNotFound(t *testing.T) {
 	owner := seedUser(t, db, "user_owner_get", "og@example.com")
 	outsider := seedUser(t, db, "user_outsider", "os@example.com")
 
-	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
+	o, err := svc.CreateOrg(tenant.WithUser(ctx, owner), "Org", nil, owner)
 	if err != nil {
 		t.Fatalf("CreateOrg: %v", err)
 	}
-	_, err = svc.GetOrgForUser(ctx, o.Id, outsider)
+	_, err = svc.GetOrgForUser(tenant.WithUser(ctx, outsider), o.Id, outsider)
 	if !errors.Is(err, org.ErrNotFound) {
 		t.Fatalf("want ErrNotFound, got %v", err)
 	}
@@ -166,13 +178,14 @@ func TestUpdateOrg_PatchesName(t *testing.T) {
 	ctx := context.Background()
 
 	owner := seedUser(t, db, "user_updater", "up@example.com")
+	ctx = tenant.WithUser(ctx, owner)
 	o, err := svc.CreateOrg(ctx, "Before", nil, owner)
 	if err != nil {
 		t.Fatalf("CreateOrg: %v", err)
 	}
 
 	newName := "After"
-	updated, err := svc.UpdateOrg(ctx, o.Id, &newName, owner)
+	updated, err := svc.UpdateOrg(ctx, o.Id, &newName, owner, nil)
 	if err != nil {
 		t.Fatalf("UpdateOrg: %v", err)
 	}
@@ -181,11 +194,48 @@ func TestUpdateOrg_PatchesName(t *testing.T) {
 	}
 }
 
+func TestUpdateOrg_StaleIfMatchReturnsPreconditionFailed(t *testing.T) {
+	svc, db := newOrgService(t)
+	ctx := context.Background()
+
+	owner := seedUser(t, db, "user_stale_org", "so@example.com")
+	ctx = tenant.WithUser(ctx, owner)
+	o, err := svc.CreateOrg(ctx, "Before", nil, owner)
+	if err != nil {
+		t.Fatalf("CreateOrg: %v", err)
+	}
+
+	first := "First"
+	updated, err := svc.UpdateOrg(ctx, o.Id, &first, owner, []int64{o.Version})
+	if err != nil {
+		t.Fatalf("first UpdateOrg: %v", err)
+	}
+	if updated.Version != o.Version+1 {
+		t.Errorf("version: want %d, got %d", o.Version+1, updated.Version)
+	}
+
+	// A second writer still holding the original version must lose.
+	second := "Second"
+	_, err = svc.UpdateOrg(ctx, o.Id, &second, owner, []int64{o.Version})
+	if !errors.Is(err, org.ErrPreconditionFailed) {
+		t.Fatalf("want ErrPreconditionFailed, got %v", err)
+	}
+
+	got, err := svc.GetOrgForUser(ctx, o.Id, owner)
+	if err != nil {
+		t.Fatalf("GetOrgForUser: %v", err)
+	}
+	if got.Name != "First" {
+		t.Errorf("name: want First, got %q", got.Name)
+	}
+}
+
 func TestAddMember_UnknownEmailReturnsNotFound(t *testing.T) {
 	svc, db := newOrgService(t)
 	ctx := context.Background()
 
 	owner := seedUser(t, db, "user_am_owner", "amo@example.com")
+	ctx = tenant.WithUser(ctx, owner)
 	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
 	if err != nil {
 		t.Fatalf("CreateOrg: %v", err)
@@ -202,6 +252,7 @@ func TestAddMember_DuplicateReturnsConflict(t *testing.T) {
 	ctx := context.Background()
 
 	owner := seedUser(t, db, "user_am_dup_owner", "amd@example.com")
+	ctx = tenant.WithUser(ctx, owner)
 	_ = seedUser(t, db, "user_am_dup_other", "other@example.com")
 
 	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
@@ -218,11 +269,92 @@ func TestAddMember_DuplicateReturnsConflict(t *testing.T) {
 	}
 }
 
+func TestAddMember_RollsBackWithOuterTx(t *testing.T) {
+	svc, db := newOrgService(t)
+	ctx := context.Background()
+
+	owner := seedUser(t, db, "user_am_tx_owner", "amto@example.com")
+	ctx = tenant.WithUser(ctx, owner)
+	_ = seedUser(t, db, "user_am_tx_other", "amtu@example.com")
+
+	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
+	if err != nil {
+		t.Fatalf("CreateOrg: %v", err)
+	}
+
+	boom := errors.New("boom")
+	err = tx.NewManager(db).RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := svc.AddMember(ctx, o.Id, "amtu@example.com", oapi.Analyst); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("want boom, got %v", err)
+	}
+
+	members, err := svc.ListMembers(ctx, o.Id)
+	if err != nil {
+		t.Fatalf("ListMembers: %v", err)
+	}
+	if len(members) != 1 {
+		t.Errorf("want only the owner after rollback, got %d members", len(members))
+	}
+}
+
+func TestMutations_AppendEventsWithTheirTransaction(t *testing.T) {
+	svc, db := newOrgService(t)
+	ctx := context.Background()
+
+	owner := seedUser(t, db, "user_ev_owner", "evo@example.com")
+	ctx = tenant.WithUser(ctx, owner)
+	_ = seedUser(t, db, "user_ev_other", "evu@example.com")
+
+	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
+	if err != nil {
+		t.Fatalf("CreateOrg: %v", err)
+	}
+	member, err := svc.AddMember(ctx, o.Id, "evu@example.com", oapi.Analyst)
+	if err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	// A failed mutation must not leave an event behind.
+	if _, err := svc.AddMember(ctx, o.Id, "evu@example.com", oapi.Analyst); !errors.Is(err, org.ErrConflict) {
+		t.Fatalf("want ErrConflict, got %v", err)
+	}
+	if err := svc.RemoveMember(ctx, o.Id, member.UserId); err != nil {
+		t.Fatalf("RemoveMember: %v", err)
+	}
+
+	events := outbox.NewRepo(db)
+	var got []outbox.Event
+	err = tx.NewManager(db).RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := events.Sequence(ctx, 100); err != nil {
+			return err
+		}
+		got, err = events.After(ctx, 0, 100)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("reading events: %v", err)
+	}
+	want := []string{org.EventOrgCreated, org.EventMemberAdded, org.EventMemberRemoved}
+	if len(got) != len(want) {
+		t.Fatalf("want %d events, got %d", len(want), len(got))
+	}
+	for i, e := range got {
+		if e.Type != want[i] || e.AggregateID != o.Id.String() || e.OrgID == nil || *e.OrgID != o.Id {
+			t.Errorf("event %d: want %s on org %s, got %s on %s", i, want[i], o.Id, e.Type, e.AggregateID)
+		}
+	}
+}
+
 func TestUpdateMemberRole_ChangesRole(t *testing.T) {
 	svc, db := newOrgService(t)
 	ctx := context.Background()
 
 	owner := seedUser(t, db, "user_umr_owner", "umro@example.com")
+	ctx = tenant.WithUser(ctx, owner)
 	other := seedUser(t, db, "user_umr_other", "umru@example.com")
 
 	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
@@ -232,7 +364,7 @@ func TestUpdateMemberRole_ChangesRole(t *testing.T) {
 	if _, err := svc.AddMember(ctx, o.Id, "umru@example.com", oapi.Analyst); err != nil {
 		t.Fatalf("AddMember: %v", err)
 	}
-	updated, err := svc.UpdateMemberRole(ctx, o.Id, other, oapi.Admin)
+	updated, err := svc.UpdateMemberRole(ctx, o.Id, other, oapi.Admin, nil)
 	if err != nil {
 		t.Fatalf("UpdateMemberRole: %v", err)
 	}
@@ -241,11 +373,43 @@ func TestUpdateMemberRole_ChangesRole(t *testing.T) {
 	}
 }
 
+func TestUpdateMemberRole_StaleIfMatchReturnsPreconditionFailed(t *testing.T) {
+	svc, db := newOrgService(t)
+	ctx := context.Background()
+
+	owner := seedUser(t, db, "user_umr_stale_owner", "umrso@example.com")
+	ctx = tenant.WithUser(ctx, owner)
+	other := seedUser(t, db, "user_umr_stale_other", "umrsu@example.com")
+
+	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
+	if err != nil {
+		t.Fatalf("CreateOrg: %v", err)
+	}
+	m, err := svc.AddMember(ctx, o.Id, "umrsu@example.com", oapi.Analyst)
+	if err != nil {
+		t.Fatalf("AddMember: %v", err)
+	}
+	if _, err := svc.UpdateMemberRole(ctx, o.Id, other, oapi.Admin, []int64{m.Version}); err != nil {
+		t.Fatalf("first UpdateMemberRole: %v", err)
+	}
+	_, err = svc.UpdateMemberRole(ctx, o.Id, other, oapi.Viewer, []int64{m.Version})
+	if !errors.Is(err, org.ErrPreconditionFailed) {
+		t.Fatalf("want ErrPreconditionFailed, got %v", err)
+	}
+
+	missing := uuid.MustParse("00000000-0000-0000-0000-000000000011")
+	_, err = svc.UpdateMemberRole(ctx, o.Id, missing, oapi.Viewer, []int64{1})
+	if !errors.Is(err, org.ErrNotFound) {
+		t.Fatalf("missing member: want ErrNotFound, got %v", err)
+	}
+}
+
 func TestRemoveMember_ExpectsErrNotFoundWhenMissing(t *testing.T) {
 	svc, db := newOrgService(t)
 	ctx := context.Background()
 
 	owner := seedUser(t, db, "user_rm_owner", "rmo@example.com")
+	ctx = tenant.WithUser(ctx, owner)
 	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
 	if err != nil {
 		t.Fatalf("CreateOrg: %v", err)
@@ -263,6 +427,7 @@ func TestListMembers_IncludesEmbeddedUser(t *testing.T) {
 	ctx := context.Background()
 
 	owner := seedUser(t, db, "user_lm_owner", "lmo@example.com")
+	ctx = tenant.WithUser(ctx, owner)
 	o, err := svc.CreateOrg(ctx, "Org", nil, owner)
 	if err != nil {
 		t.Fatalf("CreateOrg: %v", err)
@@ -282,3 +447,57 @@ func TestListMembers_IncludesEmbeddedUser(t *testing.T) {
 		t.Errorf("user email: want lmo@example.com, got %q", members[0].User.Email)
 	}
 }
+
+func TestOrgs_InvisibleAcrossTenants(t *testing.T) {
+	svc, db := newOrgService(t)
+	ctx := context.Background()
+
+	alice := seedUser(t, db, "user_rls_alice", "rlsa@example.com")
+	mallory := seedUser(t, db, "user_rls_mallory", "rlsm@example.com")
+
+	aliceOrg, err := svc.CreateOrg(tenant.WithUser(ctx, alice), "AliceCo", nil, alice)
+	if err != nil {
+		t.Fatalf("CreateOrg: %v", err)
+	}
+
+	// Mallory names Alice's org and even impersonates Alice in the query
+	// arguments; the scope on the context is what RLS enforces.
+	malloryCtx := tenant.WithOrg(tenant.WithUser(ctx, mallory), aliceOrg.Id)
+	if _, err := svc.GetOrgForUser(malloryCtx, aliceOrg.Id, alice); !errors.Is(err, org.ErrNotFound) {
+		t.Errorf("GetOrgForUser: want ErrNotFound, got %v", err)
+	}
+	orgs, err := svc.ListOrgsForUser(malloryCtx, alice)
+	if err != nil {
+		t.Fatalf("ListOrgsForUser: %v", err)
+	}
+	if len(orgs) != 0 {
+		t.Errorf("ListOrgsForUser: want 0 orgs, got %d", len(orgs))
+	}
+	members, err := svc.ListMembers(malloryCtx, aliceOrg.Id)
+	if err != nil {
+		t.Fatalf("ListMembers: %v", err)
+	}
+	if len(members) != 0 {
+		t.Errorf("ListMembers: want 0 members, got %d", len(members))
+	}
+	if _, err := svc.GetMembership(malloryCtx, aliceOrg.Id, alice); !errors.Is(err, org.ErrNotFound) {
+		t.Errorf("GetMembership: want ErrNotFound, got %v", err)
+	}
+
+	// Writes are filtered the same way: Mallory cannot add herself.
+	if _, err := svc.AddMember(malloryCtx, aliceOrg.Id, "rlsm@example.com", oapi.Owner); err == nil {
+		t.Error("AddMember across tenants: want error, got nil")
+	}
+	name := "Pwned"
+	if _, err := svc.UpdateOrg(malloryCtx, aliceOrg.Id, &name, alice, nil); !errors.Is(err, org.ErrNotFound) {
+		t.Errorf("UpdateOrg: want ErrNotFound, got %v", err)
+	}
+
+	got, err := svc.GetOrgForUser(tenant.WithUser(ctx, alice), aliceOrg.Id, alice)
+	if err != nil {
+		t.Fatalf("GetOrgForUser as alice: %v", err)
+	}
+	if got.Name != "AliceCo" || got.MemberCount == nil || *got.MemberCount != 1 {
+		t.Errorf("alice's org was modified: name=%q members=%v", got.Name, got.MemberCount)
+	}
+}
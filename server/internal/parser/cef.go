@@ -0,0 +1,156 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// cefHeaderFields names the pipe-delimited CEF header fields after the
+// version, in order.
+var cefHeaderFields = [...]string{
+	"device_vendor",
+	"device_product",
+	"device_version",
+	"event_id",
+	"name",
+	"severity",
+}
+
+// ParseCEF parses an ArcSight Common Event Format record:
+//
+//	CEF:Version|Device Vendor|Device Product|Device Version|Signature ID|Name|Severity|Extension
+//
+// Anything before "CEF:", such as a syslog header, is skipped. Header fields
+// may escape '|' and '\'; extension values may escape '=', '\' and newlines.
+// The result has the same shape as ParseLEEF's: format, format_version, the
+// header fields and an extensions map of strings.
+func ParseCEF(raw []byte) (Fields, error) {
+	i := bytes.Index(raw, []byte("CEF:"))
+	if i < 0 {
+		return nil, ErrUnrecognized
+	}
+	rest := string(bytes.TrimRight(raw[i+len("CEF:"):], "\r\n"))
+
+	version, rest, ok := cutHeaderField(rest)
+	if !ok {
+		return nil, errors.New("cef: truncated header")
+	}
+	f := Fields{"format": NameCEF, "format_version": version}
+	for _, name := range cefHeaderFields {
+		var value string
+		value, rest, ok = cutHeaderField(rest)
+		if !ok {
+			return nil, fmt.Errorf("cef: truncated header, missing %s", name)
+		}
+		f[name] = value
+	}
+
+	ext, err := parseCEFExtension(rest)
+	if err != nil {
+		return nil, err
+	}
+	f["extensions"] = ext
+	return f, nil
+}
+
+// cutHeaderField returns the text before the first unescaped '|', unescaped,
+// and the text after it.
+func cutHeaderField(s string) (field, rest string, ok bool) {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '|':
+			return sb.String(), s[i+1:], true
+		case c == '\\' && i+1 < len(s) && (s[i+1] == '|' || s[i+1] == '\\'):
+			sb.WriteByte(s[i+1])
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", "", false
+}
+
+// parseCEFExtension splits "k1=v1 k2=v2 with spaces k3=v3" into pairs. Values
+// may contain spaces, so a value ends at the last space before the next
+// unescaped '=' that follows a key. Senders often leave '=' unescaped in
+// values such as URLs; one not preceded by " key" stays part of the value.
+func parseCEFExtension(s string) (Fields, error) {
+	ext := Fields{}
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return ext, nil
+	}
+
+	// One pass finds the unescaped '=' and, for each, where the key before it
+	// starts: just after the last space, when only key characters follow it.
+	// keyStart is -1 when no key does.
+	type separator struct{ eq, keyStart int }
+	var seps []separator
+	space, inKey := -1, true
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == ' ':
+			space, inKey = i, true
+		case c == '\\':
+			inKey = false
+			if i++; i < len(s) && s[i] == ' ' {
+				space, inKey = i, true
+			}
+		case c == '=':
+			keyStart := -1
+			if inKey && i > space+1 {
+				keyStart = space + 1
+			}
+			seps = append(seps, separator{eq: i, keyStart: keyStart})
+			inKey = false
+		case !isExtensionKeyByte(c):
+			inKey = false
+		}
+	}
+	if len(seps) == 0 || !isExtensionKey(s[:seps[0].eq]) {
+		return nil, errors.New("cef: extension does not start with key=value")
+	}
+
+	keyStart, eq := 0, seps[0].eq
+	for _, sep := range seps[1:] {
+		if sep.keyStart <= eq+1 {
+			// no key after a space in this value: the '=' is part of it
+			continue
+		}
+		ext[s[keyStart:eq]] = unescapeCEFValue(strings.TrimRight(s[eq+1:sep.keyStart-1], " "))
+		keyStart, eq = sep.keyStart, sep.eq
+	}
+	ext[s[keyStart:eq]] = unescapeCEFValue(strings.TrimRight(s[eq+1:], " "))
+	return ext, nil
+}
+
+// isExtensionKey reports whether s can be an extension key: letters, digits
+// and the punctuation used by vendor-defined keys.
+func isExtensionKey(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isExtensionKeyByte(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isExtensionKeyByte(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '-' || c == '[' || c == ']'
+}
+
+var cefValueEscapes = strings.NewReplacer(`\\`, `\`, `\=`, `=`, `\n`, "\n", `\r`, "\r", `\|`, `|`)
+
+func unescapeCEFValue(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	return cefValueEscapes.Replace(s)
+}
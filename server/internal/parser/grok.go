@@ -0,0 +1,249 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Grok limits. Go's regexp engine (RE2) matches in time linear in the input,
+// so patterns cannot backtrack catastrophically; what is left to bound is how
+// large a pattern may grow once expanded and compiled, and how long a line
+// may be.
+const (
+	maxGrokDepth    = 16
+	maxGrokExpanded = 32 << 10
+	maxGrokProgram  = 20_000
+
+	// MaxGrokInput is the longest line a grok parser accepts.
+	MaxGrokInput = 64 << 10
+)
+
+// Grok compile and match errors.
+var (
+	ErrUnknownPattern    = errors.New("unknown grok pattern")
+	ErrPatternCycle      = errors.New("grok pattern refers to itself")
+	ErrPatternTooComplex = errors.New("grok pattern too complex")
+	ErrInputTooLong      = errors.New("input exceeds grok size limit")
+)
+
+// grokRef matches %{SYNTAX}, %{SYNTAX:semantic} and %{SYNTAX:semantic:type}.
+var grokRef = regexp.MustCompile(`%\{(\w+)(?::([\w.@\[\]-]+))?(?::(int|float|string))?\}`)
+
+// PatternName reports whether name can name a grok pattern.
+func PatternName(name string) bool {
+	return name != "" && len(name) <= 100 && strings.IndexFunc(name, func(r rune) bool {
+		return !(r == '_' || r >= '0' && r <= '9' || r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z')
+	}) < 0
+}
+
+// Library is a set of named grok patterns that expressions can refer to: the
+// built-in library plus an organisation's own patterns.
+type Library struct {
+	patterns map[string]string
+}
+
+// NewLibrary returns the built-in patterns extended with custom ones. A custom
+// pattern does not replace a built-in of the same name.
+func NewLibrary(custom map[string]string) *Library {
+	patterns := make(map[string]string, len(builtinPatterns)+len(custom))
+	maps.Copy(patterns, custom)
+	maps.Copy(patterns, builtinPatterns)
+	return &Library{patterns: patterns}
+}
+
+// IsBuiltinPattern reports whether name is in the built-in library.
+func IsBuiltinPattern(name string) bool {
+	_, ok := builtinPatterns[name]
+	return ok
+}
+
+// BuiltinPatternNames returns the names in the built-in library, sorted.
+func BuiltinPatternNames() []string {
+	return slices.Sorted(maps.Keys(builtinPatterns))
+}
+
+// Has reports whether the library defines name.
+func (l *Library) Has(name string) bool {
+	_, ok := l.patterns[name]
+	return ok
+}
+
+// References returns the names of the patterns expr refers to directly.
+func References(expr string) []string {
+	var names []string
+	for _, m := range grokRef.FindAllStringSubmatch(expr, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// Grok is a compiled grok expression. It is safe for concurrent use.
+type Grok struct {
+	re       *regexp.Regexp
+	captures []grokCapture // by subexpression index
+}
+
+type grokCapture struct {
+	path []string
+	typ  string
+}
+
+// Compile expands expr against the library and compiles it. Every
+// %{SYNTAX:semantic} becomes a field named semantic, including those inside
+// referenced patterns; a dotted or bracketed semantic such as http.status or
+// [http][status] nests the field. Named groups written as (?P<name>...) are
+// fields too.
+func (l *Library) Compile(expr string) (*Grok, error) {
+	var b strings.Builder
+	var named []grokCapture
+	if err := l.expand(&b, expr, 0, nil, &named); err != nil {
+		return nil, err
+	}
+	expanded := b.String()
+
+	parsed, err := syntax.Parse(expanded, syntax.Perl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grok expression: %w", err)
+	}
+	prog, err := syntax.Compile(parsed.Simplify())
+	if err != nil || len(prog.Inst) > maxGrokProgram {
+		return nil, ErrPatternTooComplex
+	}
+	re, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid grok expression: %w", err)
+	}
+
+	g := &Grok{re: re, captures: make([]grokCapture, len(re.SubexpNames()))}
+	for i, name := range re.SubexpNames() {
+		switch {
+		case strings.HasPrefix(name, grokGroupPrefix):
+			n, _ := strconv.Atoi(name[len(grokGroupPrefix):])
+			g.captures[i] = named[n]
+		case name != "":
+			g.captures[i] = grokCapture{path: []string{name}}
+		}
+	}
+	return g, nil
+}
+
+// grokGroupPrefix names the groups Compile generates for semantics, which
+// need not be valid group names themselves.
+const grokGroupPrefix = "grok__"
+
+func (l *Library) expand(b *strings.Builder, expr string, depth int, stack []string, named *[]grokCapture) error {
+	if depth > maxGrokDepth {
+		return ErrPatternTooComplex
+	}
+	last := 0
+	for _, m := range grokRef.FindAllStringSubmatchIndex(expr, -1) {
+		b.WriteString(expr[last:m[0]])
+		last = m[1]
+
+		name := expr[m[2]:m[3]]
+		pattern, ok := l.patterns[name]
+		if !ok {
+			return fmt.Errorf("%w %q", ErrUnknownPattern, name)
+		}
+		for _, s := range stack {
+			if s == name {
+				return fmt.Errorf("%w: %s", ErrPatternCycle, strings.Join(append(stack, name), " → "))
+			}
+		}
+
+		if m[4] >= 0 {
+			c := grokCapture{path: semanticPath(expr[m[4]:m[5]])}
+			if m[6] >= 0 {
+				c.typ = expr[m[6]:m[7]]
+			}
+			fmt.Fprintf(b, "(?P<%s%d>", grokGroupPrefix, len(*named))
+			*named = append(*named, c)
+		} else {
+			b.WriteString("(?:")
+		}
+		if err := l.expand(b, pattern, depth+1, append(stack, name), named); err != nil {
+			return err
+		}
+		b.WriteByte(')')
+
+		if b.Len() > maxGrokExpanded {
+			return ErrPatternTooComplex
+		}
+	}
+	b.WriteString(expr[last:])
+	if b.Len() > maxGrokExpanded {
+		return ErrPatternTooComplex
+	}
+	return nil
+}
+
+// semanticPath splits "http.status" and "[http][status]" into their parts.
+func semanticPath(s string) []string {
+	if strings.HasPrefix(s, "[") && strings.HasSuffix(s, "]") {
+		return strings.Split(s[1:len(s)-1], "][")
+	}
+	return strings.Split(s, ".")
+}
+
+// Parse matches the expression anywhere in raw and returns its captures.
+// Captures that did not participate or matched nothing are left out; when
+// several captures share a name the first non-empty one wins. Returns
+// ErrUnrecognized when raw does not match.
+func (g *Grok) Parse(raw []byte) (Fields, error) {
+	if len(raw) > MaxGrokInput {
+		return nil, ErrInputTooLong
+	}
+	raw = bytes.TrimRight(raw, "\r\n")
+	loc := g.re.FindSubmatchIndex(raw)
+	if loc == nil {
+		return nil, ErrUnrecognized
+	}
+	f := Fields{}
+	for i, c := range g.captures {
+		start, end := loc[2*i], loc[2*i+1]
+		if c.path == nil || start < 0 || start == end {
+			continue
+		}
+		setPath(f, c.path, convert(string(raw[start:end]), c.typ))
+	}
+	return f, nil
+}
+
+func convert(s, typ string) any {
+	switch typ {
+	case "int":
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	case "float":
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	}
+	return s
+}
+
+// setPath sets the value at path unless one is already there.
+func setPath(f Fields, path []string, v any) {
+	for _, p := range path[:len(path)-1] {
+		next, ok := f[p].(Fields)
+		if !ok {
+			if _, taken := f[p]; taken {
+				return
+			}
+			next = Fields{}
+			f[p] = next
+		}
+		f = next
+	}
+	if _, taken := f[path[len(path)-1]]; !taken {
+		f[path[len(path)-1]] = v
+	}
+}
@@ -0,0 +1,70 @@
+package parser_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+var benchLines = map[string]string{
+	"COMBINEDAPACHELOG": `203.0.113.9 - - [10/Mar/2025:13:55:36 +0000] "GET /api/v1/items?page=2 HTTP/1.1" 200 5120 ` +
+		`"https://example.com/" "Mozilla/5.0 (Macintosh; Intel Mac OS X 14_3) AppleWebKit/605.1.15"`,
+	"SSHDAUTH":   "Mar  9 08:00:01 bastion sshd[4242]: Accepted publickey for deploy from 198.51.100.7 port 52144 ssh2",
+	"SYSLOGBASE": "Mar  9 08:00:01 web-01 systemd[1]: Started Session 42 of user deploy.",
+}
+
+func BenchmarkGrokCompile(b *testing.B) {
+	lib := parser.NewLibrary(nil)
+	for b.Loop() {
+		if _, err := lib.Compile("%{COMBINEDAPACHELOG}"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGrokParse(b *testing.B) {
+	lib := parser.NewLibrary(nil)
+	for name, line := range benchLines {
+		b.Run(name, func(b *testing.B) {
+			g, err := lib.Compile("%{" + name + "}")
+			if err != nil {
+				b.Fatal(err)
+			}
+			raw := []byte(line)
+			b.SetBytes(int64(len(raw)))
+			for b.Loop() {
+				if _, err := g.Parse(raw); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkGrokNoMatch measures the worst case, a line that fails late.
+func BenchmarkGrokNoMatch(b *testing.B) {
+	g, err := parser.NewLibrary(nil).Compile("%{COMBINEDAPACHELOG}")
+	if err != nil {
+		b.Fatal(err)
+	}
+	raw := []byte(strings.Repeat("203.0.113.9 - - ", 256))
+	b.SetBytes(int64(len(raw)))
+	for b.Loop() {
+		_, _ = g.Parse(raw)
+	}
+}
+
+// BenchmarkGrokPathological runs a pattern that backtracking engines take
+// exponential time on; RE2 stays linear in the input.
+func BenchmarkGrokPathological(b *testing.B) {
+	g, err := parser.NewLibrary(nil).Compile(`^(a+)+$`)
+	if err != nil {
+		b.Fatal(err)
+	}
+	raw := []byte(strings.Repeat("a", 4096) + "!")
+	b.SetBytes(int64(len(raw)))
+	for b.Loop() {
+		_, _ = g.Parse(raw)
+	}
+}
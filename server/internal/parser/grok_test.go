@@ -0,0 +1,174 @@
+package parser_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+func TestGrok_BuiltinPatternsCompile(t *testing.T) {
+	lib := parser.NewLibrary(nil)
+	for _, name := range parser.BuiltinPatternNames() {
+		if _, err := lib.Compile("%{" + name + "}"); err != nil {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
+
+func TestGrok_CombinedAccessLog(t *testing.T) {
+	p, err := parser.Resolve("grok:COMBINEDAPACHELOG", parser.NewLibrary(nil))
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	line := `203.0.113.9 - alice [10/Mar/2025:13:55:36 +0000] "GET /index.html?q=1 HTTP/1.1" 200 2326 ` +
+		`"https://example.com/" "Mozilla/5.0 (X11; Linux x86_64)"`
+	f, err := p.Parse([]byte(line + "\n"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[string]any{
+		"clientip":    "203.0.113.9",
+		"auth":        "alice",
+		"timestamp":   "10/Mar/2025:13:55:36 +0000",
+		"verb":        "GET",
+		"request":     "/index.html?q=1",
+		"httpversion": "1.1",
+		"response":    int64(200),
+		"bytes":       int64(2326),
+		"referrer":    `"https://example.com/"`,
+		"agent":       `"Mozilla/5.0 (X11; Linux x86_64)"`,
+	}
+	for k, v := range want {
+		if f[k] != v {
+			t.Errorf("%s: want %#v, got %#v", k, v, f[k])
+		}
+	}
+	if _, ok := f["rawrequest"]; ok {
+		t.Error("rawrequest: want absent when the request parsed")
+	}
+}
+
+func TestGrok_SSHDAuth(t *testing.T) {
+	p, err := parser.Resolve("grok:SSHDAUTH", parser.NewLibrary(nil))
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	f, err := p.Parse([]byte("Mar  9 08:00:01 bastion sshd[4242]: Failed password for invalid user admin " +
+		"from 198.51.100.7 port 52144 ssh2"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f["logsource"] != "bastion" || f["program"] != "sshd" || f["pid"] != "4242" {
+		t.Errorf("syslog base: got %v", f)
+	}
+	if f["outcome"] != "Failed" || f["user"] != "admin" || f["src_ip"] != "198.51.100.7" || f["src_port"] != int64(52144) {
+		t.Errorf("auth fields: got %v", f)
+	}
+}
+
+func TestGrok_NestedSemanticsAndNamedGroups(t *testing.T) {
+	g, err := parser.NewLibrary(nil).Compile(`%{WORD:http.method} %{INT:[http][status]:int} (?P<rest>.+)`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	f, err := g.Parse([]byte("POST 201 created"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	http, ok := f["http"].(parser.Fields)
+	if !ok || http["method"] != "POST" || http["status"] != int64(201) || f["rest"] != "created" {
+		t.Errorf("got %v", f)
+	}
+}
+
+func TestGrok_CustomPatterns(t *testing.T) {
+	lib := parser.NewLibrary(map[string]string{
+		"ORDER_ID": `ORD-[0-9]{6}`,
+		"APPLINE":  `%{TIMESTAMP_ISO8601:ts} order=%{ORDER_ID:order} user=%{EMAILADDRESS:email}`,
+	})
+	p, err := parser.Resolve("grok:APPLINE", lib)
+	if err != nil {
+		t.Fatalf("Resolve: %v", err)
+	}
+	f, err := p.Parse([]byte("2025-03-10T11:00:00Z order=ORD-123456 user=ann@example.com"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if f["order"] != "ORD-123456" || f["email"] != "ann@example.com" || f["ts"] != "2025-03-10T11:00:00Z" {
+		t.Errorf("got %v", f)
+	}
+	if _, err := p.Parse([]byte("unrelated line")); !errors.Is(err, parser.ErrUnrecognized) {
+		t.Errorf("non-matching line: want ErrUnrecognized, got %v", err)
+	}
+}
+
+func TestGrok_CompileErrors(t *testing.T) {
+	// each level doubles the expansion of the one below
+	blowup := map[string]string{"L0": "x"}
+	names := []string{"L0"}
+	for i := 1; i <= 14; i++ {
+		name := "L" + strings.Repeat("X", i)
+		blowup[name] = "%{" + names[i-1] + "}%{" + names[i-1] + "}"
+		names = append(names, name)
+	}
+
+	cases := []struct {
+		name   string
+		custom map[string]string
+		expr   string
+		want   error
+	}{
+		{"unknown", nil, "%{NOPE}", parser.ErrUnknownPattern},
+		{"cycle", map[string]string{"A": "%{B}", "B": "x%{A}"}, "%{A}", parser.ErrPatternCycle},
+		{"exponential expansion", blowup, "%{" + names[14] + "}", parser.ErrPatternTooComplex},
+		{"huge repetition", nil, "(?:(?:a{1000}){1000}){1000}", nil},
+	}
+	for _, c := range cases {
+		_, err := parser.NewLibrary(c.custom).Compile(c.expr)
+		if err == nil {
+			t.Errorf("%s: want an error", c.name)
+			continue
+		}
+		if c.want != nil && !errors.Is(err, c.want) {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	// backtracking-only constructs from other grok engines are rejected
+	for _, expr := range []string{`(?<!\d)%{INT}`, `(?>a+)b`, `(a)\1`} {
+		if _, err := parser.NewLibrary(nil).Compile(expr); err == nil {
+			t.Errorf("%q: want an error", expr)
+		}
+	}
+}
+
+func TestGrok_PathologicalInputIsLinear(t *testing.T) {
+	g, err := parser.NewLibrary(nil).Compile(`^(a+)+$`)
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	line := []byte(strings.Repeat("a", parser.MaxGrokInput-1) + "!")
+	start := time.Now()
+	if _, err := g.Parse(line); !errors.Is(err, parser.ErrUnrecognized) {
+		t.Fatalf("want ErrUnrecognized, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("matching took %v", d)
+	}
+
+	if _, err := g.Parse(make([]byte, parser.MaxGrokInput+1)); !errors.Is(err, parser.ErrInputTooLong) {
+		t.Errorf("oversized input: want ErrInputTooLong, got %v", err)
+	}
+}
+
+func TestResolve_UnknownParser(t *testing.T) {
+	lib := parser.NewLibrary(nil)
+	for _, name := range []string{"yaml", "grok:", "grok:NOPE", "COMBINEDAPACHELOG"} {
+		if _, err := parser.Resolve(name, lib); !errors.Is(err, parser.ErrUnknownParser) {
+			t.Errorf("%q: want ErrUnknownParser, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// leefHeaderFields names the pipe-delimited LEEF header fields after the
+// version, in order.
+var leefHeaderFields = [...]string{
+	"device_vendor",
+	"device_product",
+	"device_version",
+	"event_id",
+}
+
+// ParseLEEF parses an IBM QRadar Log Event Extended Format record:
+//
+//	LEEF:1.0|Vendor|Product|Version|EventID|attr=value<TAB>attr=value
+//	LEEF:2.0|Vendor|Product|Version|EventID|Delimiter|attr=value^attr=value
+//
+// Anything before "LEEF:", such as a syslog header, is skipped. LEEF 1.0
+// separates attributes with tabs; LEEF 2.0 names its delimiter as a character
+// or a hex code (0x5E or x5E), defaulting to a tab when empty or left out.
+// The result has the same shape as ParseCEF's, with the attributes under
+// extensions.
+func ParseLEEF(raw []byte) (Fields, error) {
+	i := bytes.Index(raw, []byte("LEEF:"))
+	if i < 0 {
+		return nil, ErrUnrecognized
+	}
+	rest := string(bytes.TrimRight(raw[i+len("LEEF:"):], "\r\n"))
+
+	version, rest, ok := cutHeaderField(rest)
+	if !ok {
+		return nil, errors.New("leef: truncated header")
+	}
+	f := Fields{"format": NameLEEF, "format_version": version}
+	for _, name := range leefHeaderFields {
+		var value string
+		value, rest, ok = cutHeaderField(rest)
+		if !ok {
+			return nil, fmt.Errorf("leef: truncated header, missing %s", name)
+		}
+		f[name] = value
+	}
+
+	delim := "\t"
+	if strings.HasPrefix(version, "2") {
+		// some senders leave out the delimiter field altogether
+		if field, after, ok := strings.Cut(rest, "|"); ok && !strings.Contains(field, "=") {
+			d, err := leefDelimiter(field)
+			if err != nil {
+				return nil, err
+			}
+			delim, rest = d, after
+		}
+	} else if version != "1.0" && version != "1" {
+		return nil, fmt.Errorf("leef: unsupported version %q", version)
+	}
+
+	f["extensions"] = parseLEEFAttributes(rest, delim)
+	return f, nil
+}
+
+// leefDelimiter decodes the LEEF 2.0 delimiter field.
+func leefDelimiter(field string) (string, error) {
+	switch {
+	case field == "":
+		return "\t", nil
+	case len(field) == 1:
+		return field, nil
+	}
+	lower := strings.ToLower(field)
+	hex, ok := strings.CutPrefix(lower, "0x")
+	if !ok {
+		hex, ok = strings.CutPrefix(lower, "x")
+	}
+	if !ok || hex == "" || len(hex) > 4 {
+		return "", fmt.Errorf("leef: invalid delimiter %q", field)
+	}
+	code, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil || code == 0 {
+		return "", fmt.Errorf("leef: invalid delimiter %q", field)
+	}
+	return string(rune(code)), nil
+}
+
+// parseLEEFAttributes splits attributes on delim. A chunk without '=' is taken
+// to be part of the previous value, which contained the delimiter.
+func parseLEEFAttributes(s, delim string) Fields {
+	attrs := Fields{}
+	last := ""
+	for chunk := range strings.SplitSeq(s, delim) {
+		if chunk == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(chunk, "=")
+		if !ok || key == "" {
+			if last != "" {
+				attrs[last] = attrs[last].(string) + delim + chunk
+			}
+			continue
+		}
+		key = strings.TrimSpace(key)
+		attrs[key] = value
+		last = key
+	}
+	return attrs
+}
@@ -0,0 +1,87 @@
+// Package parser turns raw event text into the intermediate field map that
+// normalization consumes. Each parser understands one wire format; a source
+// names the parser its events go through.
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrUnrecognized is returned when the input is not in the parser's format at
+// all, as opposed to being in the format but malformed.
+var ErrUnrecognized = errors.New("input not in parser format")
+
+// Fields is the intermediate field map. Values are strings, numbers, bools,
+// nested Fields or slices of these, so it round-trips through JSON.
+type Fields = map[string]any
+
+// Parser parses one raw event.
+type Parser interface {
+	Parse(raw []byte) (Fields, error)
+}
+
+// Func adapts a function to Parser.
+type Func func(raw []byte) (Fields, error)
+
+// Parse calls f.
+func (f Func) Parse(raw []byte) (Fields, error) { return f(raw) }
+
+// Built-in parser names, as set on a source.
+const (
+	NameJSON = "json"
+	NameCEF  = "cef"
+	NameLEEF = "leef"
+	NameXML  = "xml"
+)
+
+// GrokPrefix marks a parser name as a grok pattern, as in
+// grok:COMBINEDAPACHELOG.
+const GrokPrefix = "grok:"
+
+// ErrUnknownParser is returned for a parser name that is neither built in nor
+// a grok pattern in the library.
+var ErrUnknownParser = errors.New("unknown parser")
+
+var builtin = map[string]Parser{
+	NameJSON: Func(ParseJSON),
+	NameCEF:  Func(ParseCEF),
+	NameLEEF: Func(ParseLEEF),
+	NameXML:  Func(ParseXML),
+}
+
+// Builtin returns the built-in parser with the given name.
+func Builtin(name string) (Parser, bool) {
+	p, ok := builtin[name]
+	return p, ok
+}
+
+// Resolve returns the parser a source names: a built-in parser, or a grok
+// pattern of lib prefixed with GrokPrefix.
+func Resolve(name string, lib *Library) (Parser, error) {
+	if p, ok := builtin[name]; ok {
+		return p, nil
+	}
+	pattern, ok := strings.CutPrefix(name, GrokPrefix)
+	if !ok || !lib.Has(pattern) {
+		return nil, ErrUnknownParser
+	}
+	return lib.Compile("%{" + pattern + "}")
+}
+
+// ParseJSON parses an event that is already a JSON object, keeping its fields
+// as they are. Numbers stay json.Number so large integers are not rounded.
+func ParseJSON(raw []byte) (Fields, error) {
+	var f Fields
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&f); err != nil || f == nil {
+		return nil, ErrUnrecognized
+	}
+	if dec.More() {
+		return nil, errors.New("json: trailing data after object")
+	}
+	return f, nil
+}
@@ -0,0 +1,148 @@
+package parser_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files from parser output")
+
+// TestGolden parses every testdata/<parser>/*.log sample with the built-in
+// parser named by its directory and compares the field map with the .golden
+// file next to it. Run with -update after an intended change.
+func TestGolden(t *testing.T) {
+	samples, err := filepath.Glob("testdata/*/*.log")
+	if err != nil || len(samples) == 0 {
+		t.Fatalf("no samples found: %v", err)
+	}
+	for _, sample := range samples {
+		name := filepath.Base(filepath.Dir(sample))
+		t.Run(name+"/"+strings.TrimSuffix(filepath.Base(sample), ".log"), func(t *testing.T) {
+			p, ok := parser.Builtin(name)
+			if !ok {
+				t.Fatalf("no built-in parser %q", name)
+			}
+			raw, err := os.ReadFile(sample)
+			if err != nil {
+				t.Fatalf("reading sample: %v", err)
+			}
+			fields, err := p.Parse(raw)
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			var buf bytes.Buffer
+			enc := json.NewEncoder(&buf)
+			enc.SetEscapeHTML(false)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(fields); err != nil {
+				t.Fatalf("encoding fields: %v", err)
+			}
+			got := buf.Bytes()
+
+			golden := strings.TrimSuffix(sample, ".log") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatalf("writing golden file: %v", err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("reading golden file: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("fields differ from %s:\ngot:\n%s\nwant:\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestParsers_SameShape(t *testing.T) {
+	cef, err := parser.ParseCEF([]byte("CEF:0|V|P|1|42|Name|5|src=10.0.0.1"))
+	if err != nil {
+		t.Fatalf("ParseCEF: %v", err)
+	}
+	leef, err := parser.ParseLEEF([]byte("LEEF:1.0|V|P|1|42|src=10.0.0.1"))
+	if err != nil {
+		t.Fatalf("ParseLEEF: %v", err)
+	}
+	for _, key := range []string{"format", "format_version", "device_vendor", "device_product", "device_version", "event_id"} {
+		if _, ok := cef[key]; !ok {
+			t.Errorf("cef: missing %s", key)
+		}
+		if _, ok := leef[key]; !ok {
+			t.Errorf("leef: missing %s", key)
+		}
+	}
+	if cef["extensions"].(parser.Fields)["src"] != "10.0.0.1" ||
+		leef["extensions"].(parser.Fields)["src"] != "10.0.0.1" {
+		t.Errorf("extensions: got %v and %v", cef["extensions"], leef["extensions"])
+	}
+}
+
+func TestParsers_Unrecognized(t *testing.T) {
+	for name, raw := range map[string]string{
+		parser.NameCEF:  "just some text",
+		parser.NameLEEF: "CEF:0|V|P|1|42|Name|5|",
+		parser.NameJSON: "[1, 2]",
+		parser.NameXML:  "<134>not a document",
+	} {
+		p, _ := parser.Builtin(name)
+		if _, err := p.Parse([]byte(raw)); !errors.Is(err, parser.ErrUnrecognized) {
+			t.Errorf("%s: want ErrUnrecognized, got %v", name, err)
+		}
+	}
+}
+
+func TestParsers_Malformed(t *testing.T) {
+	for _, c := range []struct{ name, raw string }{
+		{parser.NameCEF, "CEF:0|V|P|1|42|Name"},
+		{parser.NameCEF, "CEF:0|V|P|1|42|Name|5|no pairs here"},
+		{parser.NameLEEF, "LEEF:1.0|V|P"},
+		{parser.NameLEEF, "LEEF:3.0|V|P|1|42|src=1"},
+		{parser.NameLEEF, "LEEF:2.0|V|P|1|42|0xZZ|src=1"},
+		{parser.NameXML, "<Event><System><EventID>4624</EventID></System>"},
+		{parser.NameXML, strings.Repeat("<a>", 40) + strings.Repeat("</a>", 40)},
+	} {
+		p, _ := parser.Builtin(c.name)
+		_, err := p.Parse([]byte(c.raw))
+		if err == nil || errors.Is(err, parser.ErrUnrecognized) {
+			t.Errorf("%s %q: want a parse error, got %v", c.name, c.raw, err)
+		}
+	}
+}
+
+func TestParseCEF_LongRunsOfEquals(t *testing.T) {
+	// Unescaped '=' in values must not make splitting quadratic: a
+	// megabyte of them parses at once.
+	run := strings.Repeat("a=", 1<<19)
+	for _, c := range []struct {
+		ext  string
+		want parser.Fields
+	}{
+		{ext: "msg=" + run, want: parser.Fields{"msg": run}},
+		{ext: "msg= " + run, want: parser.Fields{"msg": "", "a": run[2:]}},
+	} {
+		f, err := parser.ParseCEF([]byte("CEF:0|V|P|1|42|Name|5|" + c.ext))
+		if err != nil {
+			t.Fatalf("ParseCEF: %v", err)
+		}
+		ext := f["extensions"].(parser.Fields)
+		if len(ext) != len(c.want) {
+			t.Fatalf("extensions: want %d keys, got %d", len(c.want), len(ext))
+		}
+		for k, v := range c.want {
+			if ext[k] != v {
+				t.Errorf("%s: want %d bytes, got %d", k, len(v.(string)), len(ext[k].(string)))
+			}
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxXMLDepth bounds the nesting ParseXML follows, so a hostile document
+// cannot exhaust the stack.
+const maxXMLDepth = 32
+
+// ParseXML parses an XML document, such as a Windows event rendered as XML.
+// The root element's attributes and children become the fields, nested by
+// element name without namespaces or their declarations:
+//
+//   - an element with text and no children is its text, without its
+//     attributes, which Windows uses only for qualifiers;
+//   - a Data element with a Name attribute is keyed by that name instead, so
+//     <Data Name="TargetUserName">alice</Data> in EventData reads as
+//     EventData.TargetUserName;
+//   - any other element is its attributes and children, and an element
+//     repeated under one parent becomes an array.
+//
+// Anything before the first tag, such as a syslog header, is skipped.
+func ParseXML(raw []byte) (Fields, error) {
+	i := xmlStart(raw)
+	if i < 0 {
+		return nil, ErrUnrecognized
+	}
+	dec := xml.NewDecoder(bytes.NewReader(raw[i:]))
+	dec.Strict = false
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, ErrUnrecognized
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			v, err := xmlElement(dec, start, 0)
+			if err != nil {
+				return nil, fmt.Errorf("xml: %w", err)
+			}
+			if f, ok := v.(Fields); ok {
+				return f, nil
+			}
+			return Fields{start.Name.Local: v}, nil
+		}
+	}
+}
+
+// xmlStart returns the offset of the first tag or declaration in raw, past a
+// syslog priority such as <134>, or -1 when there is none.
+func xmlStart(raw []byte) int {
+	for i := 0; i+1 < len(raw); i++ {
+		if raw[i] != '<' {
+			continue
+		}
+		if c := raw[i+1]; c == '?' || c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' {
+			return i
+		}
+	}
+	return -1
+}
+
+// xmlElement reads the element start opens, up to its end, into its value.
+func xmlElement(dec *xml.Decoder, start xml.StartElement, depth int) (any, error) {
+	if depth >= maxXMLDepth {
+		return nil, errors.New("nested too deeply")
+	}
+	f := Fields{}
+	for _, a := range start.Attr {
+		if a.Name.Space != "xmlns" && a.Name.Local != "xmlns" {
+			f[a.Name.Local] = a.Value
+		}
+	}
+	var text strings.Builder
+	children := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil, errors.New("unexpected end of document")
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			children = true
+			v, err := xmlElement(dec, t, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			key := t.Name.Local
+			if name := dataName(t); name != "" {
+				key = name
+			}
+			add(f, key, v)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			s := strings.TrimSpace(text.String())
+			if !children && (s != "" || len(f) == 0 || dataName(start) != "") {
+				return s, nil
+			}
+			return f, nil
+		}
+	}
+}
+
+// dataName returns the Name attribute of a Data element with no others,
+// which Windows events key their data by.
+func dataName(start xml.StartElement) string {
+	if start.Name.Local != "Data" || len(start.Attr) != 1 || start.Attr[0].Name.Local != "Name" {
+		return ""
+	}
+	return start.Attr[0].Value
+}
+
+// add sets f[key] to v, collecting the values of a repeated key in an array.
+func add(f Fields, key string, v any) {
+	prev, ok := f[key]
+	switch {
+	case !ok:
+		f[key] = v
+	case isArray(prev):
+		f[key] = append(prev.([]any), v)
+	default:
+		f[key] = []any{prev, v}
+	}
+}
+
+func isArray(v any) bool {
+	_, ok := v.([]any)
+	return ok
+}
@@ -0,0 +1,42 @@
+package pattern
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per pattern.
+const (
+	aggregateType = "grok_pattern"
+
+	EventCreated = "grok_pattern.created"
+	EventUpdated = "grok_pattern.updated"
+	EventDeleted = "grok_pattern.deleted"
+)
+
+// DeletedPayload is the payload of EventDeleted.
+type DeletedPayload struct {
+	ID    uuid.UUID `json:"id"`
+	OrgID uuid.UUID `json:"org_id"`
+	Name  string    `json:"name"`
+}
+
+// emit appends an event about patternID to the outbox, in the transaction on
+// ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, patternID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   patternID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
@@ -0,0 +1,300 @@
+package pattern
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// maxTestSamples caps the samples one TestGrokPattern request may match.
+const maxTestSamples = 50
+
+// Handler serves the /organizations/{orgId}/grok-patterns/* endpoints. Members
+// may read and test patterns; admins manage them.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound() oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", "Grok pattern not found"),
+	)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func conflict(detail string) oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", detail),
+	)
+}
+
+func (h *Handler) ListGrokPatterns(
+	ctx context.Context,
+	request oapi.ListGrokPatternsRequestObject,
+) (oapi.ListGrokPatternsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListGrokPatterns403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	patterns, err := h.svc.List(ctx, request.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	if patterns == nil {
+		patterns = []oapi.GrokPattern{}
+	}
+	return oapi.ListGrokPatterns200JSONResponse(patterns), nil
+}
+
+func (h *Handler) CreateGrokPattern(
+	ctx context.Context,
+	request oapi.CreateGrokPatternRequestObject,
+) (oapi.CreateGrokPatternResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	body.Name = strings.TrimSpace(body.Name)
+	if !parser.PatternName(body.Name) {
+		return oapi.CreateGrokPattern400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(
+				"Pattern name must be letters, digits and underscores",
+			),
+		}, nil
+	}
+
+	p, err := h.svc.Create(ctx, request.OrgId, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrConflict):
+			return oapi.CreateGrokPattern409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("A pattern with this name already exists"),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.CreateGrokPattern400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateGrokPattern201JSONResponse(p), nil
+}
+
+func (h *Handler) GetGrokPattern(
+	ctx context.Context,
+	request oapi.GetGrokPatternRequestObject,
+) (oapi.GetGrokPatternResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	p, err := h.svc.Get(ctx, request.OrgId, request.PatternId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetGrokPattern404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(p.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetGrokPattern304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetGrokPattern200JSONResponse{
+		Body:    p,
+		Headers: oapi.GetGrokPattern200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
+func (h *Handler) UpdateGrokPattern(
+	ctx context.Context,
+	request oapi.UpdateGrokPatternRequestObject,
+) (oapi.UpdateGrokPatternResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	if body.Pattern != nil && *body.Pattern == "" {
+		return oapi.UpdateGrokPattern400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Pattern must not be empty"),
+		}, nil
+	}
+
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return patternPreconditionFailed(), nil
+	}
+
+	p, err := h.svc.Update(ctx, request.OrgId, request.PatternId, body, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.UpdateGrokPattern404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.UpdateGrokPattern400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return patternPreconditionFailed(), nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateGrokPattern200JSONResponse{
+		Body:    p,
+		Headers: oapi.UpdateGrokPattern200ResponseHeaders{ETag: httpx.ETag(p.Version)},
+	}, nil
+}
+
+func patternPreconditionFailed() oapi.UpdateGrokPattern412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateGrokPattern412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Grok pattern was modified since it was last read"),
+		),
+	}
+}
+
+func (h *Handler) DeleteGrokPattern(
+	ctx context.Context,
+	request oapi.DeleteGrokPatternRequestObject,
+) (oapi.DeleteGrokPatternResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Delete(ctx, request.OrgId, request.PatternId); err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.DeleteGrokPattern404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrInUse):
+			return oapi.DeleteGrokPattern409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteGrokPattern204Response{}, nil
+}
+
+func (h *Handler) TestGrokPattern(
+	ctx context.Context,
+	request oapi.TestGrokPatternRequestObject,
+) (oapi.TestGrokPatternResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.TestGrokPattern403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	body := *request.Body
+	if len(body.Samples) > maxTestSamples {
+		return oapi.TestGrokPattern400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("At most 50 samples may be tested at once"),
+		}, nil
+	}
+
+	result, err := h.svc.Test(ctx, request.OrgId, body.Expression, body.Samples)
+	if err != nil {
+		if errors.Is(err, ErrInvalid) {
+			return oapi.TestGrokPattern400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.TestGrokPattern200JSONResponse(result), nil
+}
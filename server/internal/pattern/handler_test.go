@@ -0,0 +1,100 @@
+package pattern_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func newSeededHandler(t *testing.T) (*pattern.Handler, oapi.Organization) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(
+		ctx,
+		fakeClerkUser("user_pattern_handler_owner", "ownr@example.com"),
+	)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), "Pattern Handler Org", nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+
+	svc := pattern.NewService(pattern.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	return pattern.NewHandler(svc, userSvc, orgSvc), o
+}
+
+func ownerCtx() context.Context {
+	return middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_pattern_handler_owner", "ownr@example.com"),
+	)
+}
+
+func TestListGrokPatterns_UnauthenticatedReturnsForbidden(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	resp, err := h.ListGrokPatterns(context.Background(), oapi.ListGrokPatternsRequestObject{OrgId: org.Id})
+	if err != nil {
+		t.Fatalf("ListGrokPatterns: %v", err)
+	}
+	if _, ok := resp.(oapi.ListGrokPatterns403ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 403, got %T", resp)
+	}
+}
+
+func TestCreateGrokPattern_Validation(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	status := func(resp oapi.CreateGrokPatternResponseObject) int {
+		switch resp.(type) {
+		case oapi.CreateGrokPattern201JSONResponse:
+			return 201
+		case oapi.CreateGrokPattern400ApplicationProblemPlusJSONResponse:
+			return 400
+		case oapi.CreateGrokPattern409ApplicationProblemPlusJSONResponse:
+			return 409
+		}
+		return 0
+	}
+	for _, c := range []struct {
+		name, expr string
+		want       int
+	}{
+		{"has space", `\d+`, 400},
+		{"BROKEN", `(`, 400},
+		{"HTTPDATE", `\d+`, 409},
+		{"TICKET", `[A-Z]+-\d+`, 201},
+		{"TICKET", `\d+`, 409},
+	} {
+		resp, err := h.CreateGrokPattern(ownerCtx(), oapi.CreateGrokPatternRequestObject{
+			OrgId: org.Id,
+			Body:  &oapi.CreateGrokPatternJSONRequestBody{Name: c.name, Pattern: c.expr},
+		})
+		if err != nil {
+			t.Fatalf("CreateGrokPattern %s: %v", c.name, err)
+		}
+		if got := status(resp); got != c.want {
+			t.Errorf("%s %s: want %d, got %T", c.name, c.expr, c.want, resp)
+		}
+	}
+}
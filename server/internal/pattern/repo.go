@@ -0,0 +1,217 @@
+package pattern
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns grok_patterns SQL and row→DTO mapping. The table is protected by
+// RLS, so callers run statements inside tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.GrokPatterns) oapi.GrokPattern {
+	return oapi.GrokPattern{
+		Id:          m.ID,
+		OrgId:       m.OrgID,
+		Name:        m.Name,
+		Pattern:     m.Pattern,
+		Description: m.Description,
+		Version:     m.Version,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// nameTaken reports whether err is a unique violation on (org_id, name).
+func nameTaken(err error) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// versionIn restricts an update to the listed versions; nil means any.
+func versionIn(col postgres.ColumnInteger, versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return col.IN(exprs...)
+}
+
+// Insert stores a new pattern. Returns ErrConflict when the org already has a
+// pattern of that name.
+func (r *Repo) Insert(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateGrokPatternRequest,
+) (oapi.GrokPattern, error) {
+	t := table.GrokPatterns
+	stmt := t.
+		INSERT(t.OrgID, t.Name, t.Pattern, t.Description).
+		VALUES(orgID, req.Name, req.Pattern, emptyToNil(req.Description)).
+		RETURNING(t.AllColumns)
+
+	var row model.GrokPatterns
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if nameTaken(err) {
+			return oapi.GrokPattern{}, ErrConflict
+		}
+		return oapi.GrokPattern{}, fmt.Errorf("inserting grok pattern: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// List returns the org's patterns ordered by name.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID) ([]oapi.GrokPattern, error) {
+	t := table.GrokPatterns
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID))).
+		ORDER_BY(t.Name.ASC())
+
+	var rows []model.GrokPatterns
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing grok patterns: %w", err)
+	}
+	patterns := make([]oapi.GrokPattern, 0, len(rows))
+	for _, row := range rows {
+		patterns = append(patterns, toOapi(row))
+	}
+	return patterns, nil
+}
+
+// Get returns a single pattern of the org. Returns ErrNotFound when it does
+// not exist in that org.
+func (r *Repo) Get(ctx context.Context, orgID, patternID uuid.UUID) (oapi.GrokPattern, error) {
+	t := table.GrokPatterns
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(patternID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.GrokPatterns
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.GrokPattern{}, ErrNotFound
+		}
+		return oapi.GrokPattern{}, fmt.Errorf("getting grok pattern: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Update patches the fields set in req and bumps the version. An empty
+// description clears it. When ifMatch is non-nil the update only applies if
+// the current version is listed, otherwise ErrPreconditionFailed is returned.
+func (r *Repo) Update(
+	ctx context.Context,
+	orgID, patternID uuid.UUID,
+	req oapi.UpdateGrokPatternRequest,
+	ifMatch []int64,
+) (oapi.GrokPattern, error) {
+	t := table.GrokPatterns
+	pattern := postgres.StringExpression(t.Pattern)
+	if req.Pattern != nil {
+		pattern = postgres.String(*req.Pattern)
+	}
+	description := postgres.StringExpression(t.Description)
+	switch {
+	case req.Description == nil:
+	case *req.Description == "":
+		description = postgres.StringExp(postgres.NULL)
+	default:
+		description = postgres.String(*req.Description)
+	}
+
+	stmt := t.
+		UPDATE(t.Pattern, t.Description, t.Version, t.UpdatedAt).
+		SET(pattern, description, t.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(patternID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))).
+				AND(versionIn(t.Version, ifMatch)),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.GrokPatterns
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	switch {
+	case err == nil:
+		return toOapi(row), nil
+	case !errors.Is(err, qrm.ErrNoRows):
+		return oapi.GrokPattern{}, fmt.Errorf("updating grok pattern: %w", err)
+	}
+	if _, err := r.Get(ctx, orgID, patternID); err != nil {
+		return oapi.GrokPattern{}, err
+	}
+	return oapi.GrokPattern{}, ErrPreconditionFailed
+}
+
+// Delete removes a pattern. Returns ErrNotFound when no row matched.
+func (r *Repo) Delete(ctx context.Context, orgID, patternID uuid.UUID) error {
+	t := table.GrokPatterns
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(patternID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting grok pattern: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SourcesUsing counts the org's sources whose parser is parserName.
+func (r *Repo) SourcesUsing(ctx context.Context, orgID uuid.UUID, parserName string) (int64, error) {
+	t := table.Sources
+	stmt := postgres.
+		SELECT(postgres.COUNT(postgres.STAR).AS("count")).
+		FROM(t).
+		WHERE(
+			t.OrgID.EQ(postgres.UUID(orgID)).
+				AND(t.Parser.EQ(postgres.String(parserName))),
+		)
+
+	var dest struct{ Count int64 }
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &dest); err != nil {
+		return 0, fmt.Errorf("counting sources using parser: %w", err)
+	}
+	return dest.Count, nil
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
@@ -0,0 +1,328 @@
+// Package pattern owns an organisation's custom grok patterns, which extend
+// the built-in library in package parser, and resolves the parser a source
+// names against that library. Patterns may refer to each other; every change
+// is checked against the patterns referring to the one changed.
+package pattern
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrNotFound is returned when a pattern does not exist in the org.
+var ErrNotFound = errors.New("grok pattern not found")
+
+// ErrConflict is returned when the org already has a pattern of that name, or
+// the name is a built-in pattern's.
+var ErrConflict = errors.New("grok pattern name taken")
+
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("grok pattern version mismatch")
+
+// ErrInvalid is returned when a pattern, or one referring to it, would no
+// longer compile.
+var ErrInvalid = errors.New("invalid grok pattern")
+
+// ErrInUse is returned when deleting a pattern a source or another pattern
+// still uses.
+var ErrInUse = errors.New("grok pattern in use")
+
+// Service orchestrates pattern operations. Each runs in a transaction carrying
+// the caller's tenant scope, joining one already on ctx. Mutations append
+// their domain event to the outbox in that same transaction.
+type Service struct {
+	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
+	logger *slog.Logger
+
+	mu       sync.Mutex
+	compiled map[uuid.UUID]*compiled
+}
+
+// compiled is an org's library as of one set of its patterns, with the
+// parsers compiled from it so far.
+type compiled struct {
+	digest  [sha256.Size]byte
+	lib     *parser.Library
+	parsers map[string]parser.Parser
+}
+
+// NewService wires a Service with its repo, transaction manager, outbox and
+// logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{
+		repo:     repo,
+		tx:       txm,
+		events:   events,
+		logger:   logger,
+		compiled: make(map[uuid.UUID]*compiled),
+	}
+}
+
+// custom returns the org's patterns by name.
+func (s *Service) custom(ctx context.Context, orgID uuid.UUID) (map[string]string, error) {
+	rows, err := s.repo.List(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	patterns := make(map[string]string, len(rows))
+	for _, p := range rows {
+		patterns[p.Name] = p.Pattern
+	}
+	return patterns, nil
+}
+
+// validate compiles every custom pattern against the library they make up,
+// so that a change breaking a pattern that refers to the changed one is
+// caught along with the changed one itself.
+func validate(custom map[string]string) error {
+	lib := parser.NewLibrary(custom)
+	for _, name := range slices.Sorted(maps.Keys(custom)) {
+		if _, err := lib.Compile("%{" + name + "}"); err != nil {
+			return fmt.Errorf("%w %s: %w", ErrInvalid, name, err)
+		}
+	}
+	return nil
+}
+
+// Create defines a pattern. Returns ErrConflict when the name is taken and
+// ErrInvalid when the pattern does not compile.
+func (s *Service) Create(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateGrokPatternRequest,
+) (oapi.GrokPattern, error) {
+	if parser.IsBuiltinPattern(req.Name) {
+		return oapi.GrokPattern{}, ErrConflict
+	}
+	var p oapi.GrokPattern
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		custom, err := s.custom(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		if _, ok := custom[req.Name]; ok {
+			return ErrConflict
+		}
+		custom[req.Name] = req.Pattern
+		if err := validate(custom); err != nil {
+			return err
+		}
+		if p, err = s.repo.Insert(ctx, orgID, req); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, p.Id, EventCreated, p)
+	})
+	return p, err
+}
+
+// List returns the org's patterns.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.GrokPattern, error) {
+	var patterns []oapi.GrokPattern
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		patterns, err = s.repo.List(ctx, orgID)
+		return err
+	})
+	return patterns, err
+}
+
+// Get returns a single pattern. Returns ErrNotFound when it does not exist in
+// the org.
+func (s *Service) Get(ctx context.Context, orgID, patternID uuid.UUID) (oapi.GrokPattern, error) {
+	var p oapi.GrokPattern
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		p, err = s.repo.Get(ctx, orgID, patternID)
+		return err
+	})
+	return p, err
+}
+
+// Update patches a pattern. A non-nil ifMatch makes the update conditional on
+// the pattern's version. Returns ErrInvalid when the new pattern, or one that
+// refers to it, no longer compiles.
+func (s *Service) Update(
+	ctx context.Context,
+	orgID, patternID uuid.UUID,
+	req oapi.UpdateGrokPatternRequest,
+	ifMatch []int64,
+) (oapi.GrokPattern, error) {
+	var p oapi.GrokPattern
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		current, err := s.repo.Get(ctx, orgID, patternID)
+		if err != nil {
+			return err
+		}
+		if req.Pattern != nil {
+			custom, err := s.custom(ctx, orgID)
+			if err != nil {
+				return err
+			}
+			custom[current.Name] = *req.Pattern
+			if err := validate(custom); err != nil {
+				return err
+			}
+		}
+		if p, err = s.repo.Update(ctx, orgID, patternID, req, ifMatch); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, patternID, EventUpdated, p)
+	})
+	return p, err
+}
+
+// Delete removes a pattern. Returns ErrInUse while a source parses with it or
+// another pattern refers to it.
+func (s *Service) Delete(ctx context.Context, orgID, patternID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		p, err := s.repo.Get(ctx, orgID, patternID)
+		if err != nil {
+			return err
+		}
+		custom, err := s.custom(ctx, orgID)
+		if err != nil {
+			return err
+		}
+		for name, other := range custom {
+			if name != p.Name && slices.Contains(parser.References(other), p.Name) {
+				return fmt.Errorf("%w: %s refers to it", ErrInUse, name)
+			}
+		}
+		n, err := s.repo.SourcesUsing(ctx, orgID, parser.GrokPrefix+p.Name)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			return fmt.Errorf("%w by %d source(s)", ErrInUse, n)
+		}
+
+		if err := s.repo.Delete(ctx, orgID, patternID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, patternID, EventDeleted, DeletedPayload{ID: patternID, OrgID: orgID, Name: p.Name})
+	})
+}
+
+// Test compiles expression against the org's library and matches it against
+// each sample. Returns ErrInvalid when the expression does not compile.
+func (s *Service) Test(
+	ctx context.Context,
+	orgID uuid.UUID,
+	expression string,
+	samples []string,
+) (oapi.TestGrokPatternResult, error) {
+	lib, err := s.Library(ctx, orgID)
+	if err != nil {
+		return oapi.TestGrokPatternResult{}, err
+	}
+	g, err := lib.Compile(expression)
+	if err != nil {
+		return oapi.TestGrokPatternResult{}, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	results := make([]oapi.GrokSampleResult, 0, len(samples))
+	for _, sample := range samples {
+		fields, err := g.Parse([]byte(sample))
+		switch {
+		case err == nil:
+			results = append(results, oapi.GrokSampleResult{Matched: true, Fields: &fields})
+		case errors.Is(err, parser.ErrUnrecognized):
+			results = append(results, oapi.GrokSampleResult{})
+		default:
+			msg := err.Error()
+			results = append(results, oapi.GrokSampleResult{Error: &msg})
+		}
+	}
+	return oapi.TestGrokPatternResult{Results: results}, nil
+}
+
+// ── Resolution ───────────────────────────────────────────────────────────────
+
+// Library returns the built-in library extended with the org's patterns.
+func (s *Service) Library(ctx context.Context, orgID uuid.UUID) (*parser.Library, error) {
+	c, err := s.library(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	return c.lib, nil
+}
+
+// Parser returns the parser a source of the org names: a built-in parser or
+// grok:NAME for a pattern in the org's library. Compiled parsers are cached
+// until the org's patterns change. Returns parser.ErrUnknownParser for any
+// other name.
+func (s *Service) Parser(ctx context.Context, orgID uuid.UUID, name string) (parser.Parser, error) {
+	c, err := s.library(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	p, ok := c.parsers[name]
+	s.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	if p, err = parser.Resolve(name, c.lib); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	c.parsers[name] = p
+	s.mu.Unlock()
+	return p, nil
+}
+
+// CheckParser reports whether name is a parser sources of the org can use. An
+// empty name, meaning no parser, is accepted.
+func (s *Service) CheckParser(ctx context.Context, orgID uuid.UUID, name string) error {
+	if name == "" {
+		return nil
+	}
+	_, err := s.Parser(ctx, orgID, name)
+	return err
+}
+
+// library loads the org's patterns and returns their compiled library,
+// reusing the cached one while they are unchanged.
+func (s *Service) library(ctx context.Context, orgID uuid.UUID) (*compiled, error) {
+	var custom map[string]string
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		custom, err = s.custom(ctx, orgID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	h := sha256.New()
+	for _, name := range slices.Sorted(maps.Keys(custom)) {
+		h.Write([]byte(name + "\x00" + custom[name] + "\x00"))
+	}
+	var digest [sha256.Size]byte
+	h.Sum(digest[:0])
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if c := s.compiled[orgID]; c != nil && c.digest == digest {
+		return c, nil
+	}
+	c := &compiled{digest: digest, lib: parser.NewLibrary(custom), parsers: make(map[string]parser.Parser)}
+	s.compiled[orgID] = c
+	return c, nil
+}
@@ -0,0 +1,251 @@
+package pattern_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+func newSeededService(t *testing.T) (*pattern.Service, *sql.DB, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_pattern_seed", "Pattern Test Org")
+	svc := pattern.NewService(
+		pattern.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	return svc, db, orgID
+}
+
+func mustCreate(
+	t *testing.T,
+	ctx context.Context,
+	svc *pattern.Service,
+	orgID uuid.UUID,
+	name, expr string,
+) oapi.GrokPattern {
+	t.Helper()
+	p, err := svc.Create(ctx, orgID, oapi.CreateGrokPatternRequest{Name: name, Pattern: expr})
+	if err != nil {
+		t.Fatalf("Create %s: %v", name, err)
+	}
+	return p
+}
+
+func TestCreatePattern_RejectsTakenAndInvalid(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	mustCreate(t, ctx, svc, orgID, "TICKET", `[A-Z]+-\d+`)
+
+	for _, c := range []struct {
+		name, expr string
+		want       error
+	}{
+		{"TICKET", `\d+`, pattern.ErrConflict},
+		{"IPV4", `\d+`, pattern.ErrConflict},
+		{"BROKEN", `(unclosed`, pattern.ErrInvalid},
+		{"DANGLING", `%{NOT_DEFINED}`, pattern.ErrInvalid},
+		{"SELF", `a%{SELF}`, pattern.ErrInvalid},
+	} {
+		_, err := svc.Create(ctx, orgID, oapi.CreateGrokPatternRequest{Name: c.name, Pattern: c.expr})
+		if !errors.Is(err, c.want) {
+			t.Errorf("Create %s: want %v, got %v", c.name, c.want, err)
+		}
+	}
+}
+
+func TestUpdatePattern_ChecksDependantsAndVersion(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	ticket := mustCreate(t, ctx, svc, orgID, "TICKET", `[A-Z]+-\d+`)
+	mustCreate(t, ctx, svc, orgID, "TICKETLINE", `ticket %{TICKET:ticket}`)
+
+	// would make TICKETLINE refer to itself through TICKET
+	_, err := svc.Update(ctx, orgID, ticket.Id, oapi.UpdateGrokPatternRequest{Pattern: strPtr(`%{TICKETLINE}`)}, nil)
+	if !errors.Is(err, pattern.ErrInvalid) {
+		t.Fatalf("cyclic update: want ErrInvalid, got %v", err)
+	}
+
+	updated, err := svc.Update(
+		ctx,
+		orgID,
+		ticket.Id,
+		oapi.UpdateGrokPatternRequest{Pattern: strPtr(`[A-Z]{2,10}-\d+`), Description: strPtr("Issue keys")},
+		[]int64{ticket.Version},
+	)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Version != ticket.Version+1 || updated.Description == nil || *updated.Description != "Issue keys" {
+		t.Errorf("update: got version %d, description %v", updated.Version, updated.Description)
+	}
+
+	_, err = svc.Update(ctx, orgID, ticket.Id, oapi.UpdateGrokPatternRequest{}, []int64{ticket.Version})
+	if !errors.Is(err, pattern.ErrPreconditionFailed) {
+		t.Errorf("stale If-Match: want ErrPreconditionFailed, got %v", err)
+	}
+}
+
+func TestDeletePattern_RefusedWhileInUse(t *testing.T) {
+	svc, db, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	ticket := mustCreate(t, ctx, svc, orgID, "TICKET", `[A-Z]+-\d+`)
+	line := mustCreate(t, ctx, svc, orgID, "TICKETLINE", `ticket %{TICKET:ticket}`)
+
+	if err := svc.Delete(ctx, orgID, ticket.Id); !errors.Is(err, pattern.ErrInUse) {
+		t.Fatalf("delete referenced pattern: want ErrInUse, got %v", err)
+	}
+
+	sourceSvc := source.NewService(
+		source.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	_, err := sourceSvc.Create(ctx, orgID, oapi.CreateSourceRequest{
+		Name:   "tracker",
+		Type:   oapi.Syslog,
+		Parser: strPtr(parser.GrokPrefix + "TICKETLINE"),
+	})
+	if err != nil {
+		t.Fatalf("create source: %v", err)
+	}
+	if err := svc.Delete(ctx, orgID, line.Id); !errors.Is(err, pattern.ErrInUse) {
+		t.Fatalf("delete pattern used by source: want ErrInUse, got %v", err)
+	}
+}
+
+func TestParser_ResolvesCustomPatternsAndSeesUpdates(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	ticket := mustCreate(t, ctx, svc, orgID, "TICKET", `[A-Z]+-\d+`)
+	mustCreate(t, ctx, svc, orgID, "TICKETLINE", `ticket %{TICKET:ticket} by %{USERNAME:user}`)
+
+	parse := func() parser.Fields {
+		t.Helper()
+		p, err := svc.Parser(ctx, orgID, "grok:TICKETLINE")
+		if err != nil {
+			t.Fatalf("Parser: %v", err)
+		}
+		f, err := p.Parse([]byte("ticket OPS-42 by alice"))
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		return f
+	}
+	if f := parse(); f["ticket"] != "OPS-42" || f["user"] != "alice" {
+		t.Fatalf("fields: got %v", f)
+	}
+
+	_, err := svc.Update(ctx, orgID, ticket.Id, oapi.UpdateGrokPatternRequest{Pattern: strPtr(`[A-Z]+`)}, nil)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if f := parse(); f["ticket"] != "OPS" {
+		t.Errorf("after update: want ticket OPS, got %v", f["ticket"])
+	}
+
+	if _, err := svc.Parser(ctx, orgID, "grok:MISSING"); !errors.Is(err, parser.ErrUnknownParser) {
+		t.Errorf("unknown pattern: want ErrUnknownParser, got %v", err)
+	}
+}
+
+func TestTest_MatchesSamplesAgainstOrgLibrary(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	mustCreate(t, ctx, svc, orgID, "TICKET", `[A-Z]+-\d+`)
+
+	res, err := svc.Test(ctx, orgID, `%{TICKET:ticket} status=%{INT:status:int}`, []string{
+		"closed OPS-7 status=3",
+		"no ticket here",
+	})
+	if err != nil {
+		t.Fatalf("Test: %v", err)
+	}
+	if len(res.Results) != 2 {
+		t.Fatalf("results: want 2, got %d", len(res.Results))
+	}
+	if r := res.Results[0]; !r.Matched || (*r.Fields)["ticket"] != "OPS-7" || (*r.Fields)["status"] != int64(3) {
+		t.Errorf("first sample: got %+v", r)
+	}
+	if res.Results[1].Matched {
+		t.Error("second sample: want no match")
+	}
+
+	if _, err := svc.Test(ctx, orgID, `%{NOPE}`, nil); !errors.Is(err, pattern.ErrInvalid) {
+		t.Errorf("unknown reference: want ErrInvalid, got %v", err)
+	}
+}
+
+func TestPatterns_InvisibleAcrossTenants(t *testing.T) {
+	svc, db, orgA := newSeededService(t)
+	orgB := seedOrg(t, db, "user_pattern_other", "Other Pattern Org")
+
+	p := mustCreate(t, tenant.WithOrg(context.Background(), orgA), svc, orgA, "TICKET", `[A-Z]+-\d+`)
+
+	ctxB := tenant.WithOrg(context.Background(), orgB)
+	if _, err := svc.Get(ctxB, orgB, p.Id); !errors.Is(err, pattern.ErrNotFound) {
+		t.Errorf("Get across tenants: want ErrNotFound, got %v", err)
+	}
+	if err := svc.CheckParser(ctxB, orgB, "grok:TICKET"); !errors.Is(err, parser.ErrUnknownParser) {
+		t.Errorf("CheckParser across tenants: want ErrUnknownParser, got %v", err)
+	}
+}
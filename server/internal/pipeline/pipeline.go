@@ -0,0 +1,326 @@
+// Package pipeline processes queued raw events the way their source is
+// configured to, turning each into an Event. The ingest normalizer processes
+// events through it as they arrive, and replays of the archive again, so
+// reprocessing an event gives what processing it on arrival would give under
+// the same configuration.
+//
+// Events are queued as JSON objects. A source's parser, when it has one,
+// reads the object's "message" field, where syslog receivers put the text of
+// a message; the fields it extracts are added to the object's own. The
+// event's time is then extracted from the fields as the source's timestamp
+// config says, so it may come from a parsed field. The fields are then
+// redacted by the org's normalization-stage policies. A
+// source with a mapping then has its events normalized into OCSF by the
+// mapping's active version, and the OCSF event is enriched, as with the
+// location of its IP addresses.
+package pipeline
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/eventtime"
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+// MessageField is the field of a queued event that a source's parser reads.
+const MessageField = "message"
+
+// ErrNoMessage is returned for an event without a message for the source's
+// parser to read.
+var ErrNoMessage = errors.New("event has no message to parse")
+
+// ErrMappingVersion is returned when pinning a mapping version the source's
+// mapping does not have.
+var ErrMappingVersion = errors.New("mapping version not found")
+
+// Event is a processed event. OCSF is set when the source has a mapping.
+// Time is as extracted from its fields, and Extracted says how, for the
+// source's health. Redactions are those made in its fields, for
+// Processor.Record to count.
+type Event struct {
+	ID         uuid.UUID        `json:"id"`
+	OrgID      uuid.UUID        `json:"org_id"`
+	SourceID   uuid.UUID        `json:"source_id"`
+	Time       time.Time        `json:"time"`
+	Fields     parser.Fields    `json:"fields"`
+	OCSF       ocsf.Event       `json:"ocsf,omitempty"`
+	Extracted  eventtime.Result `json:"-"`
+	Redactions redaction.Tally  `json:"-"`
+}
+
+// Output is what processing made of the event: its OCSF event when the
+// source has a mapping, otherwise its fields.
+func (e Event) Output() any {
+	if e.OCSF != nil {
+		return e.OCSF
+	}
+	return e.Fields
+}
+
+// Options tune how a source's events are processed. The zero value uses the
+// source's current configuration.
+type Options struct {
+	// MappingVersion pins the version of the source's mapping to apply.
+	MappingVersion *int
+}
+
+// Mappings resolves the mapping versions sources normalize their events
+// with. Compiled returns an error wrapping ErrMappingVersion when the mapping
+// does not have the version.
+type Mappings interface {
+	Compiled(ctx context.Context, orgID, mappingID uuid.UUID, version int) (*mapping.Mapping, error)
+}
+
+// Enricher adds context to normalized events of an org, in place. Enrichers
+// do not fail an event: what they cannot add they leave out.
+type Enricher interface {
+	Enrich(ctx context.Context, orgID uuid.UUID, ev ocsf.Event)
+}
+
+// Redactor removes sensitive values from the fields of events of an org's
+// source, in place, before they are normalized, and returns the redactions
+// it made. Record counts redactions in the transaction on ctx, which stores
+// what was processed. Redact fails an event it cannot redact.
+type Redactor interface {
+	Redact(ctx context.Context, orgID, sourceID uuid.UUID, fields parser.Fields) (redaction.Tally, error)
+	Record(ctx context.Context, orgID, sourceID uuid.UUID, t redaction.Tally) error
+}
+
+// Recorder keeps what normalized events of an org say, such as the entities
+// they mention, once they are stored. Record joins the transaction on ctx,
+// which stores them.
+type Recorder interface {
+	Record(ctx context.Context, orgID uuid.UUID, events []ocsf.Event) error
+}
+
+// Pipeline prepares Processors from the source registry, pattern library and
+// mappings.
+type Pipeline struct {
+	sources   *source.Service
+	patterns  *pattern.Service
+	mappings  Mappings
+	redactor  Redactor
+	enrichers []Enricher
+	recorders []Recorder
+}
+
+// New wires a Pipeline with the source and pattern services, the mappings
+// and the enrichers normalized events go through, in order.
+func New(sources *source.Service, patterns *pattern.Service, mappings Mappings, enrichers ...Enricher) *Pipeline {
+	return &Pipeline{sources: sources, patterns: patterns, mappings: mappings, enrichers: enrichers}
+}
+
+// WithRedactor returns a copy of the Pipeline whose Processors redact events
+// with r.
+func (p *Pipeline) WithRedactor(r Redactor) *Pipeline {
+	c := *p
+	c.redactor = r
+	return &c
+}
+
+// WithRecorders returns a copy of the Pipeline whose Processors record the
+// normalized events stored with recorders, in order.
+func (p *Pipeline) WithRecorders(recorders ...Recorder) *Pipeline {
+	c := *p
+	c.recorders = recorders
+	return &c
+}
+
+// Processor processes the events of one source as configured when it was
+// prepared.
+type Processor struct {
+	parser parser.Parser
+	// lenient keeps events whose message is missing or not in the parser's
+	// format as they are, which suits the JSON parser: such an event is
+	// already JSON.
+	lenient   bool
+	extractor *eventtime.Extractor
+	redactor  Redactor
+	mapping   *mapping.Mapping
+	enrichers []Enricher
+	recorders []Recorder
+}
+
+// NewProcessor returns a Processor parsing with the parser of the given name,
+// nil when the source has none. It extracts times as eventtime.Default does.
+func NewProcessor(name string, p parser.Parser) *Processor {
+	return &Processor{parser: p, lenient: name == parser.NameJSON, extractor: eventtime.Default}
+}
+
+// WithExtractor returns a copy of the Processor that extracts the time of
+// events with e once they are parsed.
+func (p *Processor) WithExtractor(e *eventtime.Extractor) *Processor {
+	c := *p
+	c.extractor = e
+	return &c
+}
+
+// WithMapping returns a copy of the Processor that normalizes events with m.
+func (p *Processor) WithMapping(m *mapping.Mapping) *Processor {
+	c := *p
+	c.mapping = m
+	return &c
+}
+
+// WithRedactor returns a copy of the Processor that redacts the fields of
+// events with r before normalizing them.
+func (p *Processor) WithRedactor(r Redactor) *Processor {
+	c := *p
+	c.redactor = r
+	return &c
+}
+
+// WithEnrichers returns a copy of the Processor that enriches the events it
+// normalizes with enrichers, in order.
+func (p *Processor) WithEnrichers(enrichers ...Enricher) *Processor {
+	c := *p
+	c.enrichers = enrichers
+	return &c
+}
+
+// WithRecorders returns a copy of the Processor that records the normalized
+// events stored with recorders, in order.
+func (p *Processor) WithRecorders(recorders ...Recorder) *Processor {
+	c := *p
+	c.recorders = recorders
+	return &c
+}
+
+// Prepare resolves the configuration of a source of the org. Returns
+// source.ErrNotFound when the source does not exist in the org,
+// parser.ErrUnknownParser when its parser no longer resolves, and
+// ErrMappingVersion for a version the source's mapping does not have, or
+// any version when the source has no mapping.
+func (p *Pipeline) Prepare(ctx context.Context, orgID, sourceID uuid.UUID, opts Options) (*Processor, error) {
+	src, err := p.sources.Get(ctx, orgID, sourceID)
+	if err != nil {
+		return nil, err
+	}
+	version := src.MappingVersion
+	if opts.MappingVersion != nil {
+		if src.MappingId == nil {
+			return nil, fmt.Errorf("%w: %d, the source has no mapping", ErrMappingVersion, *opts.MappingVersion)
+		}
+		version = opts.MappingVersion
+	}
+
+	proc := NewProcessor("", nil)
+	if src.Parser != nil && *src.Parser != "" {
+		prs, err := p.patterns.Parser(ctx, orgID, *src.Parser)
+		if err != nil {
+			return nil, fmt.Errorf("resolving parser %q: %w", *src.Parser, err)
+		}
+		proc = NewProcessor(*src.Parser, prs)
+	}
+	extractor, err := source.TimestampSpec(src.TimestampConfig).Compile()
+	if err != nil {
+		return nil, fmt.Errorf("compiling timestamp config: %w", err)
+	}
+	proc = proc.WithExtractor(extractor)
+	if src.MappingId != nil && version != nil {
+		m, err := p.mappings.Compiled(ctx, orgID, *src.MappingId, *version)
+		if err != nil {
+			return nil, fmt.Errorf("resolving mapping version %d: %w", *version, err)
+		}
+		proc = proc.WithMapping(m)
+	}
+	if p.redactor != nil {
+		proc = proc.WithRedactor(p.redactor)
+	}
+	return proc.WithEnrichers(p.enrichers...).WithRecorders(p.recorders...), nil
+}
+
+// Process processes one queued event. The payload's fields are kept,
+// overwritten by those the parser extracts from the message; the event's
+// time is extracted from them, against the time it was received, and they
+// are then redacted. With a mapping, an event that does not
+// normalize into a valid OCSF event fails with an *ocsf.ValidationError; one
+// that does is then enriched.
+func (p *Processor) Process(ctx context.Context, m queue.Message) (Event, error) {
+	fields, err := p.Parse(m.Payload)
+	if err != nil {
+		return Event{}, err
+	}
+	t := p.extractor.Extract(fields, m.ReceivedTime)
+	ev := Event{ID: m.ID, OrgID: m.OrgID, SourceID: m.SourceID, Time: t.Time, Fields: fields, Extracted: t}
+	if p.redactor != nil {
+		if ev.Redactions, err = p.redactor.Redact(ctx, m.OrgID, m.SourceID, fields); err != nil {
+			return Event{}, err
+		}
+	}
+	if p.mapping != nil {
+		if ev.OCSF, err = ocsf.Normalize(p.mapping.Apply(fields)); err != nil {
+			return Event{}, err
+		}
+		for _, e := range p.enrichers {
+			e.Enrich(ctx, m.OrgID, ev.OCSF)
+		}
+	}
+	return ev, nil
+}
+
+// Record counts the redactions made in processing the events, which are of
+// the org's source, and hands those normalized to the recorders, in the
+// transaction on ctx. It is called with the events once they are stored.
+func (p *Processor) Record(ctx context.Context, orgID, sourceID uuid.UUID, events []Event) error {
+	if p.redactor != nil {
+		t := make(redaction.Tally)
+		for _, ev := range events {
+			t.Add(ev.Redactions)
+		}
+		if err := p.redactor.Record(ctx, orgID, sourceID, t); err != nil {
+			return err
+		}
+	}
+	if len(p.recorders) == 0 {
+		return nil
+	}
+	normalized := make([]ocsf.Event, 0, len(events))
+	for _, ev := range events {
+		if ev.OCSF != nil {
+			normalized = append(normalized, ev.OCSF)
+		}
+	}
+	for _, r := range p.recorders {
+		if err := r.Record(ctx, orgID, normalized); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Parse returns the fields of a queued event's payload, with those the
+// parser extracts from its message.
+func (p *Processor) Parse(payload []byte) (parser.Fields, error) {
+	fields, err := parser.ParseJSON(payload)
+	if err != nil {
+		return nil, err
+	}
+	if p.parser == nil {
+		return fields, nil
+	}
+	msg, ok := fields[MessageField].(string)
+	switch {
+	case ok:
+		parsed, err := p.parser.Parse([]byte(msg))
+		if err != nil && !(p.lenient && errors.Is(err, parser.ErrUnrecognized)) {
+			return nil, err
+		}
+		maps.Copy(fields, parsed)
+	case !p.lenient:
+		return nil, ErrNoMessage
+	}
+	return fields, nil
+}
@@ -0,0 +1,295 @@
+package pipeline_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/eventtime"
+	"github.com/luketeo/horizon/internal/mapping"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/redaction"
+)
+
+const cefMessage = `CEF:0|Acme|Firewall|1.0|100|Blocked|5|src=10.0.0.1 dst=10.0.0.2`
+
+func message(payload string) queue.Message {
+	return queue.Message{
+		ID:           uuid.New(),
+		OrgID:        uuid.New(),
+		SourceID:     uuid.New(),
+		Payload:      json.RawMessage(payload),
+		Time:         time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC),
+		ReceivedTime: time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC),
+	}
+}
+
+func processor(name string) *pipeline.Processor {
+	if name == "" {
+		return pipeline.NewProcessor("", nil)
+	}
+	p, _ := parser.Builtin(name)
+	return pipeline.NewProcessor(name, p)
+}
+
+func TestProcess_ParsesMessageOverPayload(t *testing.T) {
+	m := message(`{"message":"` + cefMessage + `","hostname":"fw1","event_id":"envelope"}`)
+
+	ev, err := processor(parser.NameCEF).Process(context.Background(), m)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if ev.ID != m.ID || ev.SourceID != m.SourceID || !ev.Time.Equal(m.Time) {
+		t.Errorf("envelope: want %+v, got %+v", m, ev)
+	}
+	if ev.Fields["hostname"] != "fw1" || ev.Fields["message"] != cefMessage {
+		t.Errorf("payload fields lost: %v", ev.Fields)
+	}
+	if ev.Fields["device_vendor"] != "Acme" || ev.Fields["event_id"] != "100" {
+		t.Errorf("parsed fields: want the CEF header over the payload, got %v", ev.Fields)
+	}
+}
+
+func TestProcess_ExtractsTimeFromParsedFields(t *testing.T) {
+	e, err := eventtime.Spec{Field: "extensions.rt", Layouts: []string{eventtime.LayoutEpochMS}}.Compile()
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	happened := time.Date(2026, 10, 19, 13, 58, 30, 0, time.UTC)
+	msg := fmt.Sprintf("%s rt=%d", cefMessage, happened.UnixMilli())
+	m := message(`{"message":"` + msg + `"}`)
+
+	ev, err := processor(parser.NameCEF).WithExtractor(e).Process(context.Background(), m)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if !ev.Time.Equal(happened) || ev.Extracted.Status != eventtime.StatusParsed ||
+		ev.Extracted.Lag() != 90*time.Second {
+		t.Errorf("time: want %v parsed from rt, 90s late, got %v (%+v)", happened, ev.Time, ev.Extracted)
+	}
+}
+
+func TestProcess_NormalizesWithMapping(t *testing.T) {
+	m, err := mapping.Compile([]byte(`{
+		"defaults": {
+			"class_uid": 4001, "activity_id": 6, "severity_id": 1, "time": 0,
+			"metadata": {"product": {"name": "Firewall"}}, "dst_endpoint.ip": "10.0.0.2"
+		},
+		"fields": [{"to": "src_endpoint.ip", "from": "extensions.src"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	proc := processor(parser.NameCEF).WithMapping(m)
+
+	ev, err := proc.Process(context.Background(), message(`{"message":"`+cefMessage+`"}`))
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if ev.OCSF == nil || ev.OCSF.Common().TypeUID != 400106 {
+		t.Fatalf("ocsf: want a network activity event, got %+v", ev.OCSF)
+	}
+	if out, _ := ev.Output().(ocsf.Event); out == nil {
+		t.Errorf("Output: want the OCSF event, got %T", ev.Output())
+	}
+
+	// without src_endpoint the event is not valid network activity
+	_, err = proc.Process(
+		context.Background(),
+		message(`{"message":"CEF:0|Acme|Firewall|1.0|100|Blocked|5|dst=10.0.0.2"}`),
+	)
+	var invalid *ocsf.ValidationError
+	if !errors.As(err, &invalid) {
+		t.Errorf("Process unmapped: want a ValidationError, got %v", err)
+	}
+}
+
+// tagger records the org of the events it enriches with an enrichment.
+type tagger string
+
+func (g tagger) Enrich(_ context.Context, orgID uuid.UUID, ev ocsf.Event) {
+	b := ev.Common()
+	b.Enrichments = append(b.Enrichments, ocsf.Enrichment{Name: string(g), Value: orgID.String()})
+}
+
+func TestProcess_EnrichesNormalizedEvents(t *testing.T) {
+	m, err := mapping.Compile([]byte(`{
+		"defaults": {
+			"class_uid": 4001, "activity_id": 6, "severity_id": 1, "time": 0,
+			"metadata": {"product": {"name": "Firewall"}}
+		},
+		"fields": [{"to": "src_endpoint.ip", "from": "extensions.src"}, {"to": "dst_endpoint.ip", "from": "extensions.dst"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	proc := processor(parser.NameCEF).WithMapping(m).WithEnrichers(tagger("first"), tagger("second"))
+	msg := message(`{"message":"` + cefMessage + `"}`)
+
+	ev, err := proc.Process(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	got := ev.OCSF.Common().Enrichments
+	if len(got) != 2 || got[0].Name != "first" || got[1].Name != "second" || got[0].Value != msg.OrgID.String() {
+		t.Errorf("Enrichments: want first then second for the org, got %+v", got)
+	}
+
+	// without a mapping there is no OCSF event to enrich
+	raw, err := processor(parser.NameCEF).WithEnrichers(tagger("first")).Process(context.Background(), msg)
+	if err != nil || raw.OCSF != nil {
+		t.Errorf("Process unmapped: want fields only, got %+v (%v)", raw.OCSF, err)
+	}
+}
+
+// keeper keeps the events recorded for each org.
+type keeper map[uuid.UUID][]ocsf.Event
+
+func (k keeper) Record(_ context.Context, orgID uuid.UUID, events []ocsf.Event) error {
+	k[orgID] = append(k[orgID], events...)
+	return nil
+}
+
+func TestRecord_HandsNormalizedEventsToRecorders(t *testing.T) {
+	m, err := mapping.Compile([]byte(`{
+		"defaults": {
+			"class_uid": 4001, "activity_id": 6, "severity_id": 1, "time": 0,
+			"metadata": {"product": {"name": "Firewall"}}, "dst_endpoint.ip": "10.0.0.2"
+		},
+		"fields": [{"to": "src_endpoint.ip", "from": "extensions.src"}]
+	}`))
+	if err != nil {
+		t.Fatalf("Compile: %v", err)
+	}
+	k := keeper{}
+	proc := processor(parser.NameCEF).WithMapping(m).WithRecorders(k)
+	msg := message(`{"message":"` + cefMessage + `"}`)
+	ev, err := proc.Process(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("Process: %v", err)
+	}
+	if len(k) != 0 {
+		t.Fatalf("Process: want nothing recorded before the events are stored, got %v", k)
+	}
+
+	unmapped := pipeline.Event{ID: uuid.New(), OrgID: msg.OrgID, Fields: parser.Fields{"msg": "hello"}}
+	if err := proc.Record(context.Background(), msg.OrgID, msg.SourceID, []pipeline.Event{ev, unmapped}); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if got := k[msg.OrgID]; len(got) != 1 || got[0] != ev.OCSF {
+		t.Errorf("recorded: want the normalized event only, got %v", got)
+	}
+}
+
+// dropper drops a field from the events it redacts, counting each drop, and
+// keeps the last tally recorded.
+type dropper struct {
+	field    string
+	recorded redaction.Tally
+}
+
+func (d *dropper) Redact(
+	_ context.Context,
+	_, _ uuid.UUID,
+	fields parser.Fields,
+) (redaction.Tally, error) {
+	if _, ok := fields[d.field]; !ok {
+		return nil, nil
+	}
+	delete(fields, d.field)
+	return redaction.Tally{{Rule: d.field}: 1}, nil
+}
+
+func (d *dropper) Record(_ context.Context, _, _ uuid.UUID, t redaction.Tally) error {
+	d.recorded = t
+	return nil
+}
+
+func TestProcess_RedactsBeforeMapping(t *testing.T) {
+	d := &dropper{field: "hostname"}
+	proc := processor(parser.NameCEF).WithRedactor(d)
+	msg := message(`{"message":"` + cefMessage + `","hostname":"fw1"}`)
+
+	var events []pipeline.Event
+	for range 2 {
+		ev, err := proc.Process(context.Background(), msg)
+		if err != nil {
+			t.Fatalf("Process: %v", err)
+		}
+		if _, ok := ev.Fields["hostname"]; ok || ev.Fields["device_vendor"] != "Acme" {
+			t.Errorf("fields: want hostname redacted, got %v", ev.Fields)
+		}
+		events = append(events, ev)
+	}
+	if string(msg.Payload) != `{"message":"`+cefMessage+`","hostname":"fw1"}` {
+		t.Errorf("payload: want it as received, got %s", msg.Payload)
+	}
+
+	if err := proc.Record(context.Background(), msg.OrgID, msg.SourceID, events); err != nil {
+		t.Fatalf("Record: %v", err)
+	}
+	if n := d.recorded[redaction.Key{Rule: "hostname"}]; n != 2 || len(d.recorded) != 1 {
+		t.Errorf("recorded: want 2 hostname redactions, got %v", d.recorded)
+	}
+}
+
+func TestProcess(t *testing.T) {
+	cases := []struct {
+		name, parser, payload string
+		wantErr               error
+		wantField             string
+	}{
+		{name: "no parser keeps payload", payload: `{"user":"alice"}`, wantField: "user"},
+		{name: "json without message", parser: parser.NameJSON, payload: `{"user":"alice"}`, wantField: "user"},
+		{
+			name:      "json with text message",
+			parser:    parser.NameJSON,
+			payload:   `{"message":"login failed"}`,
+			wantField: "message",
+		},
+		{
+			name:      "json with json message",
+			parser:    parser.NameJSON,
+			payload:   `{"message":"{\"user\":\"alice\"}"}`,
+			wantField: "user",
+		},
+		{
+			name:    "cef without message",
+			parser:  parser.NameCEF,
+			payload: `{"user":"alice"}`,
+			wantErr: pipeline.ErrNoMessage,
+		},
+		{
+			name:    "cef with text message",
+			parser:  parser.NameCEF,
+			payload: `{"message":"login failed"}`,
+			wantErr: parser.ErrUnrecognized,
+		},
+		{name: "payload not an object", payload: `[1]`, wantErr: parser.ErrUnrecognized},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ev, err := processor(c.parser).Process(context.Background(), message(c.payload))
+			if c.wantErr != nil {
+				if !errors.Is(err, c.wantErr) {
+					t.Fatalf("want %v, got %v", c.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Process: %v", err)
+			}
+			if _, ok := ev.Fields[c.wantField]; !ok {
+				t.Errorf("want field %q, got %v", c.wantField, ev.Fields)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+// Package blob stores immutable objects by key: in S3 or an S3-compatible
+// service such as MinIO, or in a local directory. Keys are slash-separated
+// relative paths, such as "raw/2026/10/19/segment.zst".
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ErrNotFound is returned when no object has the key.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a blob backend. Put replaces any object with the same key, and
+// Delete of a missing key succeeds.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// checkKey rejects keys that are not clean relative paths, which could
+// escape a local store's directory.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") ||
+		key == ".." {
+		return fmt.Errorf("invalid blob key %q", key)
+	}
+	return nil
+}
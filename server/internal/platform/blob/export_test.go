@@ -0,0 +1,12 @@
+package blob
+
+import (
+	"net/http"
+	"time"
+)
+
+// Sign exposes request signing, for checking against AWS's published
+// examples.
+func (s *S3) Sign(req *http.Request, payloadHash string, now time.Time) {
+	s.sign(req, payloadHash, now)
+}
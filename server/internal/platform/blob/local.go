@@ -0,0 +1,83 @@
+package blob
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+)
+
+// Local stores objects as files under a directory, for single-node installs
+// and development.
+type Local struct {
+	root *os.Root
+}
+
+// NewLocal opens the store in dir, creating the directory if needed.
+func NewLocal(dir string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("creating blob directory: %w", err)
+	}
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, fmt.Errorf("opening blob directory: %w", err)
+	}
+	return &Local{root: root}, nil
+}
+
+// Put writes the object to a temporary file and renames it into place, so a
+// reader never sees part of it.
+func (s *Local) Put(_ context.Context, key string, data []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := s.root.MkdirAll(path.Dir(key), 0o750); err != nil {
+		return fmt.Errorf("creating blob directory: %w", err)
+	}
+	suffix := make([]byte, 8)
+	_, _ = rand.Read(suffix)
+	tmp := key + ".tmp-" + hex.EncodeToString(suffix)
+	if err := s.root.WriteFile(tmp, data, 0o640); err != nil {
+		return fmt.Errorf("writing blob: %w", err)
+	}
+	if err := s.root.Rename(tmp, key); err != nil {
+		_ = s.root.Remove(tmp)
+		return fmt.Errorf("writing blob: %w", err)
+	}
+	return nil
+}
+
+// Get reads the object.
+func (s *Local) Get(_ context.Context, key string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	data, err := s.root.ReadFile(key)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading blob: %w", err)
+	}
+	return data, nil
+}
+
+// Delete removes the object.
+func (s *Local) Delete(_ context.Context, key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	if err := s.root.Remove(key); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("deleting blob: %w", err)
+	}
+	return nil
+}
+
+// Close releases the directory.
+func (s *Local) Close() error {
+	return s.root.Close()
+}
@@ -0,0 +1,70 @@
+package httpx
+
+import (
+	"strconv"
+	"strings"
+)
+
+// ETag formats a row version as a strong entity tag. A representation that
+// also depends on state outside its row, such as the caller's role, names that
+// state in variant so the tag changes with it; If-Match still compares only
+// the row version, which is all an update can conflict on.
+func ETag(version int64, variant ...string) string {
+	tag := strconv.FormatInt(version, 10)
+	for _, v := range variant {
+		tag += "." + v
+	}
+	return `"` + tag + `"`
+}
+
+// NoneMatch reports whether an If-None-Match header matches etag, in which
+// case a GET should answer 304. Comparison is weak, as RFC 9110 requires.
+func NoneMatch(header *string, etag string) bool {
+	if header == nil {
+		return false
+	}
+	for _, tag := range splitTags(*header) {
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatchVersions parses an If-Match header into the row versions it names.
+// A nil result means the update is unconditional (header absent or "*").
+// ok is false when no listed tag can ever match — weak or malformed tags —
+// which callers must answer with 412.
+func IfMatchVersions(header *string) ([]int64, bool) {
+	if header == nil {
+		return nil, true
+	}
+	var versions []int64
+	for _, tag := range splitTags(*header) {
+		if tag == "*" {
+			return nil, true
+		}
+		// If-Match uses strong comparison, so weak tags never match.
+		if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+			continue
+		}
+		version, _, _ := strings.Cut(tag[1:len(tag)-1], ".")
+		v, err := strconv.ParseInt(version, 10, 64)
+		if err != nil {
+			continue
+		}
+		versions = append(versions, v)
+	}
+	return versions, len(versions) > 0
+}
+
+func splitTags(header string) []string {
+	parts := strings.Split(header, ",")
+	tags := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			tags = append(tags, p)
+		}
+	}
+	return tags
+}
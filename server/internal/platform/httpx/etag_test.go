@@ -0,0 +1,70 @@
+package httpx_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/platform/httpx"
+)
+
+func TestETag(t *testing.T) {
+	if got := httpx.ETag(7); got != `"7"` {
+		t.Errorf(`ETag(7) = %s, want "7"`, got)
+	}
+}
+
+func TestNoneMatch(t *testing.T) {
+	etag := httpx.ETag(3)
+	cases := []struct {
+		header *string
+		want   bool
+	}{
+		{nil, false},
+		{strPtr(`"3"`), true},
+		{strPtr(`W/"3"`), true},
+		{strPtr(`"1", "3"`), true},
+		{strPtr(`*`), true},
+		{strPtr(`"4"`), false},
+		{strPtr(`"3.owner"`), false},
+	}
+	for _, c := range cases {
+		if got := httpx.NoneMatch(c.header, etag); got != c.want {
+			t.Errorf("NoneMatch(%v) = %v, want %v", deref(c.header), got, c.want)
+		}
+	}
+}
+
+func TestIfMatchVersions(t *testing.T) {
+	cases := []struct {
+		header *string
+		want   []int64
+		ok     bool
+	}{
+		{nil, nil, true},
+		{strPtr(`*`), nil, true},
+		{strPtr(`"5"`), []int64{5}, true},
+		{strPtr(`"5", "6"`), []int64{5, 6}, true},
+		{strPtr(`"5.owner.3"`), []int64{5}, true},
+		{strPtr(`W/"5"`), nil, false},
+		{strPtr(`"abc"`), nil, false},
+		{strPtr(`5`), nil, false},
+	}
+	for _, c := range cases {
+		got, ok := httpx.IfMatchVersions(c.header)
+		if ok != c.ok || !slices.Equal(got, c.want) {
+			t.Errorf(
+				"IfMatchVersions(%v) = (%v, %v), want (%v, %v)",
+				deref(c.header), got, ok, c.want, c.ok,
+			)
+		}
+	}
+}
+
+func strPtr(s string) *string { return &s }
+
+func deref(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
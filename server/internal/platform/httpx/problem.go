@@ -1,7 +1,12 @@
 // Package httpx contains shared HTTP helpers for domain handlers.
 package httpx
 
-import "github.com/luketeo/horizon/generated/oapi"
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
 
 // Prob constructs an RFC 9457 ProblemDetails value with the standard
 // "about:blank" type URI used throughout the API.
@@ -14,3 +19,15 @@ func Prob(status int, title, detail string) oapi.ProblemDetails {
 		Detail: &detail,
 	}
 }
+
+// WriteProblem writes p as an application/problem+json response. It is meant
+// for plain net/http middleware; strict handlers return typed responses instead.
+func WriteProblem(w http.ResponseWriter, p oapi.ProblemDetails) {
+	status := http.StatusInternalServerError
+	if p.Status != nil {
+		status = *p.Status
+	}
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(p)
+}
@@ -0,0 +1,227 @@
+// Package idempotency implements Idempotency-Key handling for mutating
+// endpoints. The first request for a (principal, key) pair runs normally and
+// its response is stored; retries with the same body replay that response,
+// and reuse of the key with a different body is rejected with 422.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/httpx"
+)
+
+const (
+	// HeaderKey is the request header carrying the client-chosen key.
+	HeaderKey = "Idempotency-Key"
+	// HeaderReplayed is set on responses served from the store.
+	HeaderReplayed = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+// Request identifies an idempotent request and fingerprints its content.
+type Request struct {
+	Principal string
+	Key       string
+	Method    string
+	Path      string
+	Hash      string
+}
+
+// Response is the captured outcome of a request, stored for replay.
+type Response struct {
+	Status      int
+	ContentType string
+	Body        []byte
+}
+
+// Record is a stored key. Status is nil while the original request is still
+// in flight.
+type Record struct {
+	ID          uuid.UUID
+	RequestHash string
+	Status      *int
+	ContentType string
+	Body        []byte
+}
+
+// Store persists idempotency records. Repo is the Postgres implementation.
+type Store interface {
+	Acquire(ctx context.Context, req Request, ttl time.Duration) (Record, bool, error)
+	Complete(ctx context.Context, id uuid.UUID, resp Response) error
+	Release(ctx context.Context, id uuid.UUID) error
+}
+
+// PrincipalFunc resolves the caller a key is scoped to. It returns false for
+// anonymous requests, which are passed through untouched.
+type PrincipalFunc func(ctx context.Context) (string, bool)
+
+// NewMiddleware returns middleware enforcing Idempotency-Key semantics on POST
+// and PATCH requests that carry the header. Records live for ttl.
+func NewMiddleware(
+	store Store,
+	principal PrincipalFunc,
+	ttl time.Duration,
+	logger *slog.Logger,
+) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fn := func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(HeaderKey)
+			if key == "" || (r.Method != http.MethodPost && r.Method != http.MethodPatch) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			who, ok := principal(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if !validKey(key) {
+				httpx.WriteProblem(w, httpx.Prob(
+					http.StatusBadRequest,
+					"Bad Request",
+					"Idempotency-Key must be 1-255 printable ASCII characters",
+				))
+				return
+			}
+
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				httpx.WriteProblem(w, httpx.Prob(
+					http.StatusBadRequest, "Bad Request", "Unable to read request body",
+				))
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			req := Request{
+				Principal: who,
+				Key:       key,
+				Method:    r.Method,
+				Path:      r.URL.RequestURI(),
+				Hash:      fingerprint(r.Method, r.URL.RequestURI(), body),
+			}
+
+			rec, acquired, err := store.Acquire(r.Context(), req, ttl)
+			if err != nil {
+				logger.ErrorContext(r.Context(), "idempotency lookup failed", slog.Any("err", err))
+				httpx.WriteProblem(w, httpx.Prob(
+					http.StatusInternalServerError, "Internal Server Error", "Idempotency store unavailable",
+				))
+				return
+			}
+
+			if !acquired {
+				respondExisting(w, rec, req)
+				return
+			}
+
+			rw := &recorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			// Detach from the request context: the client may have gone away,
+			// but the outcome must still be recorded for its retry.
+			ctx := context.WithoutCancel(r.Context())
+			if rw.status >= http.StatusInternalServerError {
+				if err := store.Release(ctx, rec.ID); err != nil {
+					logger.ErrorContext(ctx, "releasing idempotency key", slog.Any("err", err))
+				}
+				return
+			}
+			resp := Response{
+				Status:      rw.status,
+				ContentType: rw.Header().Get("Content-Type"),
+				Body:        rw.body.Bytes(),
+			}
+			if err := store.Complete(ctx, rec.ID, resp); err != nil {
+				logger.ErrorContext(ctx, "storing idempotent response", slog.Any("err", err))
+			}
+		}
+		return http.HandlerFunc(fn)
+	}
+}
+
+// respondExisting serves a request whose key is already claimed: a replay when
+// the fingerprint matches and the original finished, otherwise an error.
+func respondExisting(w http.ResponseWriter, rec Record, req Request) {
+	if rec.RequestHash != req.Hash {
+		httpx.WriteProblem(w, httpx.Prob(
+			http.StatusUnprocessableEntity,
+			"Unprocessable Entity",
+			"Idempotency-Key was already used with a different request",
+		))
+		return
+	}
+	if rec.Status == nil {
+		httpx.WriteProblem(w, httpx.Prob(
+			http.StatusConflict,
+			"Conflict",
+			"A request with this Idempotency-Key is still being processed",
+		))
+		return
+	}
+
+	if rec.ContentType != "" {
+		w.Header().Set("Content-Type", rec.ContentType)
+	}
+	w.Header().Set(HeaderReplayed, strconv.FormatBool(true))
+	w.WriteHeader(*rec.Status)
+	_, _ = w.Write(rec.Body)
+}
+
+// fingerprint hashes the parts of a request that must match for a replay.
+func fingerprint(method, uri string, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(method))
+	h.Write([]byte{'\n'})
+	h.Write([]byte(uri))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// validKey reports whether key is a non-empty run of printable ASCII no
+// longer than the column allows.
+func validKey(key string) bool {
+	if len(key) == 0 || len(key) > maxKeyLength {
+		return false
+	}
+	for i := range len(key) {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// recorder tees the response to the client while capturing it for storage.
+type recorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (r *recorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status = status
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.wroteHeader = true
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
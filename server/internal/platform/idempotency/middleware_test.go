@@ -0,0 +1,207 @@
+package idempotency_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/idempotency"
+)
+
+// memStore is an in-memory Store used to exercise the middleware without a
+// database. It mirrors Repo's claim semantics minus expiry.
+type memStore struct {
+	mu   sync.Mutex
+	recs map[string]*idempotency.Record
+}
+
+func newMemStore() *memStore {
+	return &memStore{recs: map[string]*idempotency.Record{}}
+}
+
+func (m *memStore) Acquire(
+	_ context.Context,
+	req idempotency.Request,
+	_ time.Duration,
+) (idempotency.Record, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	k := req.Principal + "|" + req.Key
+	if rec, ok := m.recs[k]; ok {
+		return *rec, false, nil
+	}
+	rec := &idempotency.Record{ID: uuid.New(), RequestHash: req.Hash}
+	m.recs[k] = rec
+	return *rec, true, nil
+}
+
+func (m *memStore) Complete(_ context.Context, id uuid.UUID, resp idempotency.Response) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, rec := range m.recs {
+		if rec.ID == id {
+			status := resp.Status
+			rec.Status = &status
+			rec.ContentType = resp.ContentType
+			rec.Body = append([]byte(nil), resp.Body...)
+		}
+	}
+	return nil
+}
+
+func (m *memStore) Release(_ context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for k, rec := range m.recs {
+		if rec.ID == id {
+			delete(m.recs, k)
+		}
+	}
+	return nil
+}
+
+func fixedPrincipal(_ context.Context) (string, bool) { return "user:test", true }
+
+// countingHandler returns 201 with an incrementing counter so replays are
+// distinguishable from fresh executions.
+func countingHandler(calls *int, status int) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		*calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_, _ = io.WriteString(w, `{"n":`+strconv.Itoa(*calls)+`}`)
+	})
+}
+
+func newTestMiddleware(store idempotency.Store) func(http.Handler) http.Handler {
+	return idempotency.NewMiddleware(
+		store,
+		fixedPrincipal,
+		time.Hour,
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
+
+func do(h http.Handler, method, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/organizations", strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(idempotency.HeaderKey, key)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestMiddleware_ReplaysStoredResponse(t *testing.T) {
+	calls := 0
+	h := newTestMiddleware(newMemStore())(countingHandler(&calls, http.StatusCreated))
+
+	first := do(h, http.MethodPost, "k1", `{"name":"Acme"}`)
+	second := do(h, http.MethodPost, "k1", `{"name":"Acme"}`)
+
+	if calls != 1 {
+		t.Fatalf("handler calls: want 1, got %d", calls)
+	}
+	if second.Code != http.StatusCreated {
+		t.Errorf("replay status: want 201, got %d", second.Code)
+	}
+	if second.Body.String() != first.Body.String() {
+		t.Errorf("replay body: want %q, got %q", first.Body.String(), second.Body.String())
+	}
+	if second.Header().Get(idempotency.HeaderReplayed) != "true" {
+		t.Errorf("replay header: want true, got %q", second.Header().Get(idempotency.HeaderReplayed))
+	}
+	if first.Header().Get(idempotency.HeaderReplayed) != "" {
+		t.Error("original response should not be marked as replayed")
+	}
+}
+
+func TestMiddleware_DifferentBodyReturns422(t *testing.T) {
+	calls := 0
+	h := newTestMiddleware(newMemStore())(countingHandler(&calls, http.StatusCreated))
+
+	do(h, http.MethodPost, "k1", `{"name":"Acme"}`)
+	rr := do(h, http.MethodPost, "k1", `{"name":"Other"}`)
+
+	if rr.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status: want 422, got %d", rr.Code)
+	}
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("content type: want application/problem+json, got %q", ct)
+	}
+	if calls != 1 {
+		t.Errorf("handler calls: want 1, got %d", calls)
+	}
+}
+
+func TestMiddleware_ServerErrorsAreNotStored(t *testing.T) {
+	calls := 0
+	h := newTestMiddleware(newMemStore())(countingHandler(&calls, http.StatusInternalServerError))
+
+	do(h, http.MethodPost, "k1", `{}`)
+	do(h, http.MethodPost, "k1", `{}`)
+
+	if calls != 2 {
+		t.Fatalf("handler calls: want 2 (5xx released the key), got %d", calls)
+	}
+}
+
+func TestMiddleware_InFlightKeyReturns409(t *testing.T) {
+	mw := newTestMiddleware(newMemStore())
+
+	// The retry is issued while the original request is still inside the
+	// handler, so its claim has no stored response yet.
+	var h http.Handler
+	var retry *httptest.ResponseRecorder
+	h = mw(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if retry == nil {
+			retry = do(h, http.MethodPost, "k1", `{}`)
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	first := do(h, http.MethodPost, "k1", `{}`)
+
+	if first.Code != http.StatusCreated {
+		t.Errorf("original status: want 201, got %d", first.Code)
+	}
+	if retry == nil || retry.Code != http.StatusConflict {
+		t.Fatalf("in-flight retry: want 409, got %v", retry)
+	}
+}
+
+func TestMiddleware_PassesThroughWithoutKeyOrOnGet(t *testing.T) {
+	calls := 0
+	h := newTestMiddleware(newMemStore())(countingHandler(&calls, http.StatusOK))
+
+	do(h, http.MethodPost, "", `{}`)
+	do(h, http.MethodPost, "", `{}`)
+	do(h, http.MethodGet, "k1", ``)
+	do(h, http.MethodGet, "k1", ``)
+
+	if calls != 4 {
+		t.Fatalf("handler calls: want 4, got %d", calls)
+	}
+}
+
+func TestMiddleware_RejectsInvalidKey(t *testing.T) {
+	calls := 0
+	h := newTestMiddleware(newMemStore())(countingHandler(&calls, http.StatusCreated))
+
+	rr := do(h, http.MethodPost, strings.Repeat("x", 256), `{}`)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("status: want 400, got %d", rr.Code)
+	}
+	if calls != 0 {
+		t.Errorf("handler calls: want 0, got %d", calls)
+	}
+}
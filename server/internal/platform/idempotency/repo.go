@@ -0,0 +1,192 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+)
+
+// lockTimeout bounds how long an in-flight claim is honoured. A claim older
+// than this is assumed to belong to a crashed request and may be taken over.
+const lockTimeout = time.Minute
+
+// Repo owns idempotency_keys SQL. It is the Postgres-backed Store.
+type Repo struct {
+	db *sql.DB
+}
+
+// Compile-time guarantee that Repo satisfies Store.
+var _ Store = (*Repo)(nil)
+
+// NewRepo wires a Repo backed by the given database handle.
+func NewRepo(db *sql.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toRecord(m model.IdempotencyKeys) Record {
+	rec := Record{
+		ID:          m.ID,
+		RequestHash: m.RequestHash,
+	}
+	if m.ResponseStatus != nil {
+		status := int(*m.ResponseStatus)
+		rec.Status = &status
+	}
+	if m.ResponseContentType != nil {
+		rec.ContentType = *m.ResponseContentType
+	}
+	if m.ResponseBody != nil {
+		rec.Body = *m.ResponseBody
+	}
+	return rec
+}
+
+// Acquire claims (principal, key) for a new request. A fresh row is inserted,
+// or an expired / abandoned row is reset in place; in both cases the caller
+// owns the claim and acquired is true. Otherwise the existing record is
+// returned so the caller can replay it or reject the request.
+func (r *Repo) Acquire(ctx context.Context, req Request, ttl time.Duration) (Record, bool, error) {
+	t := table.IdempotencyKeys
+	now := time.Now()
+
+	stmt := t.
+		INSERT(
+			t.Principal,
+			t.IdempotencyKey,
+			t.RequestMethod,
+			t.RequestPath,
+			t.RequestHash,
+			t.LockedAt,
+			t.ExpiresAt,
+		).
+		VALUES(
+			req.Principal,
+			req.Key,
+			req.Method,
+			req.Path,
+			req.Hash,
+			now,
+			now.Add(ttl),
+		).
+		ON_CONFLICT(t.Principal, t.IdempotencyKey).
+		DO_UPDATE(
+			postgres.SET(
+				t.RequestMethod.SET(t.EXCLUDED.RequestMethod),
+				t.RequestPath.SET(t.EXCLUDED.RequestPath),
+				t.RequestHash.SET(t.EXCLUDED.RequestHash),
+				t.ResponseStatus.SET(postgres.IntExp(postgres.NULL)),
+				t.ResponseContentType.SET(postgres.StringExp(postgres.NULL)),
+				t.ResponseBody.SET(postgres.ByteaExp(postgres.NULL)),
+				t.LockedAt.SET(t.EXCLUDED.LockedAt),
+				t.ExpiresAt.SET(t.EXCLUDED.ExpiresAt),
+				t.UpdatedAt.SET(postgres.NOW()),
+			).WHERE(
+				t.ExpiresAt.LT(postgres.NOW()).
+					OR(
+						t.ResponseStatus.IS_NULL().
+							AND(t.LockedAt.LT(postgres.TimestampzT(now.Add(-lockTimeout)))),
+					),
+			),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.IdempotencyKeys
+	err := stmt.QueryContext(ctx, r.db, &row)
+	if err == nil {
+		return toRecord(row), true, nil
+	}
+	if !errors.Is(err, qrm.ErrNoRows) {
+		return Record{}, false, fmt.Errorf("claiming idempotency key: %w", err)
+	}
+
+	// The key exists and is still live: load it for the caller to inspect.
+	sel := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.Principal.EQ(postgres.String(req.Principal)).
+				AND(t.IdempotencyKey.EQ(postgres.String(req.Key))),
+		).
+		LIMIT(1)
+
+	if err := sel.QueryContext(ctx, r.db, &row); err != nil {
+		return Record{}, false, fmt.Errorf("loading idempotency key: %w", err)
+	}
+	return toRecord(row), false, nil
+}
+
+// Complete stores the final response against a claimed key.
+func (r *Repo) Complete(ctx context.Context, id uuid.UUID, resp Response) error {
+	t := table.IdempotencyKeys
+	stmt := t.
+		UPDATE(
+			t.ResponseStatus,
+			t.ResponseContentType,
+			t.ResponseBody,
+			t.LockedAt,
+			t.UpdatedAt,
+		).
+		SET(
+			postgres.Int32(int32(resp.Status)), //nolint:gosec // HTTP status codes fit in int32
+			postgres.String(resp.ContentType),
+			postgres.Bytea(resp.Body),
+			postgres.TimestampzExp(postgres.NULL),
+			postgres.NOW(),
+		).
+		WHERE(t.ID.EQ(postgres.UUID(id)))
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("storing idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release drops a claim so the request can be retried with the same key. Used
+// when the handler fails in a way that should not be replayed (5xx).
+func (r *Repo) Release(ctx context.Context, id uuid.UUID) error {
+	stmt := table.IdempotencyKeys.
+		DELETE().
+		WHERE(table.IdempotencyKeys.ID.EQ(postgres.UUID(id)))
+
+	if _, err := stmt.ExecContext(ctx, r.db); err != nil {
+		return fmt.Errorf("releasing idempotency key: %w", err)
+	}
+	return nil
+}
+
+// PurgeExpired deletes keys whose TTL has passed and that are not in flight.
+// Returns the number of rows deleted.
+func (r *Repo) PurgeExpired(ctx context.Context) (int64, error) {
+	t := table.IdempotencyKeys
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ExpiresAt.LT(postgres.NOW()).
+				AND(
+					t.ResponseStatus.IS_NOT_NULL().
+						OR(t.LockedAt.LT(postgres.TimestampzT(time.Now().Add(-lockTimeout)))),
+				),
+		)
+
+	res, err := stmt.ExecContext(ctx, r.db)
+	if err != nil {
+		return 0, fmt.Errorf("purging idempotency keys: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PurgeArgs is the background job running PurgeExpired.
+type PurgeArgs struct{}
+
+// Kind implements jobs.Args.
+func (PurgeArgs) Kind() string { return "idempotency.purge" }
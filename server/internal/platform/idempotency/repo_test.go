@@ -0,0 +1,104 @@
+package idempotency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/internal/platform/idempotency"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+)
+
+func newRepo(t *testing.T) *idempotency.Repo {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	return idempotency.NewRepo(db)
+}
+
+func TestRepo_AcquireCompleteReplay(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+	req := idempotency.Request{
+		Principal: "user:repo",
+		Key:       "key-1",
+		Method:    "POST",
+		Path:      "/organizations",
+		Hash:      "abc",
+	}
+
+	rec, acquired, err := repo.Acquire(ctx, req, time.Hour)
+	if err != nil {
+		t.Fatalf("first Acquire: %v", err)
+	}
+	if !acquired {
+		t.Fatal("first Acquire: want acquired")
+	}
+	if rec.Status != nil {
+		t.Errorf("fresh claim status: want nil, got %v", *rec.Status)
+	}
+
+	if err := repo.Complete(ctx, rec.ID, idempotency.Response{
+		Status:      201,
+		ContentType: "application/json",
+		Body:        []byte(`{"ok":true}`),
+	}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	again, acquired, err := repo.Acquire(ctx, req, time.Hour)
+	if err != nil {
+		t.Fatalf("second Acquire: %v", err)
+	}
+	if acquired {
+		t.Fatal("second Acquire: want existing record, got fresh claim")
+	}
+	if again.Status == nil || *again.Status != 201 {
+		t.Errorf("stored status: want 201, got %v", again.Status)
+	}
+	if string(again.Body) != `{"ok":true}` {
+		t.Errorf("stored body: got %q", again.Body)
+	}
+}
+
+func TestRepo_ExpiredKeyIsReclaimed(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+	req := idempotency.Request{Principal: "user:repo", Key: "key-exp", Method: "POST", Path: "/x", Hash: "h1"}
+
+	rec, _, err := repo.Acquire(ctx, req, -time.Second)
+	if err != nil {
+		t.Fatalf("seed Acquire: %v", err)
+	}
+	if err := repo.Complete(ctx, rec.ID, idempotency.Response{Status: 201}); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	req.Hash = "h2"
+	fresh, acquired, err := repo.Acquire(ctx, req, time.Hour)
+	if err != nil {
+		t.Fatalf("Acquire after expiry: %v", err)
+	}
+	if !acquired {
+		t.Fatal("expired key: want reclaimed")
+	}
+	if fresh.RequestHash != "h2" || fresh.Status != nil {
+		t.Errorf("reclaimed record not reset: hash=%q status=%v", fresh.RequestHash, fresh.Status)
+	}
+}
+
+func TestRepo_KeysAreScopedPerPrincipal(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	a := idempotency.Request{Principal: "user:a", Key: "shared", Method: "POST", Path: "/x", Hash: "h"}
+	b := a
+	b.Principal = "user:b"
+
+	if _, acquired, err := repo.Acquire(ctx, a, time.Hour); err != nil || !acquired {
+		t.Fatalf("principal a: acquired=%v err=%v", acquired, err)
+	}
+	if _, acquired, err := repo.Acquire(ctx, b, time.Hour); err != nil || !acquired {
+		t.Fatalf("principal b: acquired=%v err=%v", acquired, err)
+	}
+}
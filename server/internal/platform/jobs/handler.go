@@ -0,0 +1,161 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+const defaultListLimit = 100
+
+// Handler serves the /admin/jobs/* endpoints. Jobs are not tenant-scoped, so
+// access is limited to platform admins, identified by Clerk user ID.
+type Handler struct {
+	repo   *Repo
+	tx     *tx.Manager
+	admins map[string]struct{}
+}
+
+// NewHandler wires a Handler with its repo, transaction manager and the Clerk
+// IDs of platform admins.
+func NewHandler(repo *Repo, txm *tx.Manager, adminClerkIDs []string) *Handler {
+	admins := make(map[string]struct{}, len(adminClerkIDs))
+	for _, id := range adminClerkIDs {
+		admins[id] = struct{}{}
+	}
+	return &Handler{repo: repo, tx: txm, admins: admins}
+}
+
+// requireAdmin reports (authenticated, admin) for the caller.
+func (h *Handler) requireAdmin(ctx context.Context) (bool, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return false, false
+	}
+	_, admin := h.admins[clerkUser.ID]
+	return true, admin
+}
+
+func (h *Handler) ListJobs(
+	ctx context.Context,
+	request oapi.ListJobsRequestObject,
+) (oapi.ListJobsResponseObject, error) {
+	authed, admin := h.requireAdmin(ctx)
+	if !authed {
+		return oapi.ListJobs401ApplicationProblemPlusJSONResponse{
+			UnauthorizedApplicationProblemPlusJSONResponse: oapi.UnauthorizedApplicationProblemPlusJSONResponse(
+				httpx.Prob(401, "Unauthorized", "Authentication required"),
+			),
+		}, nil
+	}
+	if !admin {
+		return oapi.ListJobs403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
+				httpx.Prob(403, "Forbidden", "Platform admin required"),
+			),
+		}, nil
+	}
+
+	limit := defaultListLimit
+	if request.Params.Limit != nil {
+		limit = *request.Params.Limit
+	}
+	var jobs []oapi.Job
+	err := h.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		jobs, err = h.repo.List(ctx, request.Params.State, request.Params.Kind, limit)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return oapi.ListJobs200JSONResponse(jobs), nil
+}
+
+func (h *Handler) GetJob(
+	ctx context.Context,
+	request oapi.GetJobRequestObject,
+) (oapi.GetJobResponseObject, error) {
+	authed, admin := h.requireAdmin(ctx)
+	if !authed {
+		return oapi.GetJob401ApplicationProblemPlusJSONResponse{
+			UnauthorizedApplicationProblemPlusJSONResponse: oapi.UnauthorizedApplicationProblemPlusJSONResponse(
+				httpx.Prob(401, "Unauthorized", "Authentication required"),
+			),
+		}, nil
+	}
+	if !admin {
+		return oapi.GetJob403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
+				httpx.Prob(403, "Forbidden", "Platform admin required"),
+			),
+		}, nil
+	}
+
+	var job oapi.Job
+	err := h.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		job, err = h.repo.Get(ctx, request.JobId)
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		return oapi.GetJob404ApplicationProblemPlusJSONResponse{
+			NotFoundApplicationProblemPlusJSONResponse: oapi.NotFoundApplicationProblemPlusJSONResponse(
+				httpx.Prob(404, "Not Found", "Job not found"),
+			),
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return oapi.GetJob200JSONResponse(job), nil
+}
+
+func (h *Handler) RetryJob(
+	ctx context.Context,
+	request oapi.RetryJobRequestObject,
+) (oapi.RetryJobResponseObject, error) {
+	authed, admin := h.requireAdmin(ctx)
+	if !authed {
+		return oapi.RetryJob401ApplicationProblemPlusJSONResponse{
+			UnauthorizedApplicationProblemPlusJSONResponse: oapi.UnauthorizedApplicationProblemPlusJSONResponse(
+				httpx.Prob(401, "Unauthorized", "Authentication required"),
+			),
+		}, nil
+	}
+	if !admin {
+		return oapi.RetryJob403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: oapi.ForbiddenApplicationProblemPlusJSONResponse(
+				httpx.Prob(403, "Forbidden", "Platform admin required"),
+			),
+		}, nil
+	}
+
+	var job oapi.Job
+	err := h.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		job, err = h.repo.Retry(ctx, request.JobId)
+		return err
+	})
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return oapi.RetryJob404ApplicationProblemPlusJSONResponse{
+			NotFoundApplicationProblemPlusJSONResponse: oapi.NotFoundApplicationProblemPlusJSONResponse(
+				httpx.Prob(404, "Not Found", "Job not found"),
+			),
+		}, nil
+	case errors.Is(err, ErrNotRetryable):
+		return oapi.RetryJob409ApplicationProblemPlusJSONResponse{
+			ConflictApplicationProblemPlusJSONResponse: oapi.ConflictApplicationProblemPlusJSONResponse(
+				httpx.Prob(409, "Conflict", "Only dead jobs can be retried"),
+			),
+		}, nil
+	case err != nil:
+		return nil, err
+	}
+	return oapi.RetryJob200JSONResponse(job), nil
+}
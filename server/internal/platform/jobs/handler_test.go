@@ -0,0 +1,96 @@
+package jobs_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+func asClerkUser(id string) context.Context {
+	return middleware.WithClerkUser(context.Background(), &clerk.User{ID: id})
+}
+
+func TestListJobs_UnauthenticatedReturns401(t *testing.T) {
+	h := jobs.NewHandler(nil, nil, []string{"user_admin"})
+
+	resp, err := h.ListJobs(context.Background(), oapi.ListJobsRequestObject{})
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	if _, ok := resp.(oapi.ListJobs401ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 401, got %T", resp)
+	}
+}
+
+func TestJobsEndpoints_NonAdminReturns403(t *testing.T) {
+	h := jobs.NewHandler(nil, nil, []string{"user_admin"})
+	ctx := asClerkUser("user_someone")
+
+	list, err := h.ListJobs(ctx, oapi.ListJobsRequestObject{})
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	if _, ok := list.(oapi.ListJobs403ApplicationProblemPlusJSONResponse); !ok {
+		t.Errorf("ListJobs: want 403, got %T", list)
+	}
+
+	get, err := h.GetJob(ctx, oapi.GetJobRequestObject{JobId: uuid.New()})
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if _, ok := get.(oapi.GetJob403ApplicationProblemPlusJSONResponse); !ok {
+		t.Errorf("GetJob: want 403, got %T", get)
+	}
+
+	retry, err := h.RetryJob(ctx, oapi.RetryJobRequestObject{JobId: uuid.New()})
+	if err != nil {
+		t.Fatalf("RetryJob: %v", err)
+	}
+	if _, ok := retry.(oapi.RetryJob403ApplicationProblemPlusJSONResponse); !ok {
+		t.Errorf("RetryJob: want 403, got %T", retry)
+	}
+}
+
+func TestJobsEndpoints_AdminCanInspectAndRetry(t *testing.T) {
+	repo := newRepo(t)
+	h := jobs.NewHandler(repo, tx.NewUnscopedManager(testhelper.DB(t)), []string{"user_admin"})
+	ctx := asClerkUser("user_admin")
+
+	id, err := repo.Enqueue(ctx, echoArgs{Message: "x"}, jobs.EnqueueOpts{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	list, err := h.ListJobs(ctx, oapi.ListJobsRequestObject{})
+	if err != nil {
+		t.Fatalf("ListJobs: %v", err)
+	}
+	ok200, ok := list.(oapi.ListJobs200JSONResponse)
+	if !ok || len(ok200) != 1 || ok200[0].Id != id {
+		t.Fatalf("ListJobs: want the enqueued job, got %#v", list)
+	}
+
+	retry, err := h.RetryJob(ctx, oapi.RetryJobRequestObject{JobId: id})
+	if err != nil {
+		t.Fatalf("RetryJob: %v", err)
+	}
+	if _, ok := retry.(oapi.RetryJob409ApplicationProblemPlusJSONResponse); !ok {
+		t.Errorf("RetryJob pending: want 409, got %T", retry)
+	}
+
+	get, err := h.GetJob(ctx, oapi.GetJobRequestObject{JobId: uuid.New()})
+	if err != nil {
+		t.Fatalf("GetJob: %v", err)
+	}
+	if _, ok := get.(oapi.GetJob404ApplicationProblemPlusJSONResponse); !ok {
+		t.Errorf("GetJob unknown: want 404, got %T", get)
+	}
+}
@@ -0,0 +1,73 @@
+// Package jobs runs background work outside the request path. Jobs are rows in
+// the jobs table, enqueued with Repo.Enqueue (inside the caller's transaction
+// when there is one) and executed by a Worker, which claims them with
+// SELECT ... FOR UPDATE SKIP LOCKED so several server instances can share the
+// queue. Failed attempts are retried with exponential backoff until the job's
+// attempt budget is used up, after which it is parked as dead.
+package jobs
+
+import (
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// Job states, mirrored by the JobState enum in the OpenAPI spec.
+const (
+	StatePending   = "pending"
+	StateRunning   = "running"
+	StateSucceeded = "succeeded"
+	StateDead      = "dead"
+)
+
+// ErrNotFound is returned when a job does not exist.
+var ErrNotFound = errors.New("job not found")
+
+// ErrDuplicate is returned by Enqueue when a job with the same kind and unique
+// key already exists.
+var ErrDuplicate = errors.New("duplicate job")
+
+// ErrNotRetryable is returned when retrying a job that is not dead.
+var ErrNotRetryable = errors.New("job is not dead")
+
+// Args is the typed payload of a job. Kind names the handler that runs it and
+// must be stable across deploys; the value itself is stored as JSON.
+type Args interface {
+	Kind() string
+}
+
+// EnqueueOpts tunes a single Enqueue call. The zero value runs the job as soon
+// as possible with the default attempt budget.
+type EnqueueOpts struct {
+	// RunAt delays the job until the given time.
+	RunAt time.Time
+	// UniqueKey deduplicates jobs: at most one job per kind and key exists
+	// until it is pruned. Include a time bucket for recurring work.
+	UniqueKey string
+	// MaxAttempts overrides the default attempt budget.
+	MaxAttempts int
+}
+
+// permanentError marks a handler failure that must not be retried.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the worker parks the job as dead instead of retrying,
+// for failures such as an undecodable payload that no retry can fix.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+const maxBackoff = time.Hour
+
+// Backoff returns the delay before the next run of a job that has failed
+// attempt times: 2^attempt seconds plus up to 10% jitter, capped at an hour.
+func Backoff(attempt int) time.Duration {
+	d := maxBackoff
+	if attempt < 12 {
+		d = min(time.Duration(1<<attempt)*time.Second, maxBackoff)
+	}
+	return d + rand.N(d/10+1) //nolint:gosec // jitter does not need a CSPRNG
+}
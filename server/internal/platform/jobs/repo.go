@@ -0,0 +1,358 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// defaultMaxAttempts matches the column default in migration 00006.
+const defaultMaxAttempts = 20
+
+// Repo owns jobs SQL and row→DTO mapping. Statements join the transaction on
+// ctx, if any, so a job can be enqueued atomically with the change causing it.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo backed by the given database handle.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.Jobs) oapi.Job {
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(m.Payload), &payload); err != nil {
+		// Enqueue only stores marshalled Args; surface anything else verbatim.
+		payload = map[string]any{"raw": m.Payload}
+	}
+	return oapi.Job{
+		Id:          m.ID,
+		Kind:        m.Kind,
+		Payload:     payload,
+		State:       oapi.JobState(m.State),
+		Attempts:    int(m.Attempts),
+		MaxAttempts: int(m.MaxAttempts),
+		RunAt:       m.RunAt,
+		UniqueKey:   m.UniqueKey,
+		LastError:   m.LastError,
+		LockedBy:    m.LockedBy,
+		LockedAt:    m.LockedAt,
+		FinishedAt:  m.FinishedAt,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+// ── Producing ────────────────────────────────────────────────────────────────
+
+// Enqueue stores a pending job for args. Returns ErrDuplicate when
+// opts.UniqueKey is set and a job with the same kind and key already exists.
+func (r *Repo) Enqueue(ctx context.Context, args Args, opts EnqueueOpts) (uuid.UUID, error) {
+	payload, err := json.Marshal(args)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("encoding %s job: %w", args.Kind(), err)
+	}
+	runAt := opts.RunAt
+	if runAt.IsZero() {
+		runAt = time.Now()
+	}
+	maxAttempts := opts.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxAttempts
+	}
+	var uniqueKey *string
+	if opts.UniqueKey != "" {
+		uniqueKey = &opts.UniqueKey
+	}
+
+	t := table.Jobs
+	stmt := t.
+		INSERT(t.Kind, t.Payload, t.RunAt, t.MaxAttempts, t.UniqueKey).
+		VALUES(args.Kind(), string(payload), runAt, maxAttempts, uniqueKey).
+		ON_CONFLICT(t.Kind, t.UniqueKey).
+		DO_NOTHING().
+		RETURNING(t.ID)
+
+	var row model.Jobs
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return uuid.Nil, ErrDuplicate
+		}
+		return uuid.Nil, fmt.Errorf("enqueueing %s job: %w", args.Kind(), err)
+	}
+	return row.ID, nil
+}
+
+// ── Consuming ────────────────────────────────────────────────────────────────
+
+// Claim locks up to limit due pending jobs of the given kinds for workerID,
+// marking them running and counting the attempt. Rows locked by concurrent
+// claimers are skipped rather than waited on.
+func (r *Repo) Claim(
+	ctx context.Context,
+	workerID string,
+	kinds []string,
+	limit int,
+) ([]model.Jobs, error) {
+	if len(kinds) == 0 || limit <= 0 {
+		return nil, nil
+	}
+	kindExprs := make([]postgres.Expression, 0, len(kinds))
+	for _, k := range kinds {
+		kindExprs = append(kindExprs, postgres.String(k))
+	}
+
+	t := table.Jobs
+	due := postgres.
+		SELECT(t.ID.AS("id")).
+		FROM(t).
+		WHERE(
+			t.State.EQ(postgres.String(StatePending)).
+				AND(t.RunAt.LT_EQ(postgres.NOW())).
+				AND(t.Kind.IN(kindExprs...)),
+		).
+		ORDER_BY(t.RunAt.ASC()).
+		LIMIT(int64(limit)).
+		FOR(postgres.UPDATE().SKIP_LOCKED()).
+		AsTable("due")
+	dueID := postgres.StringColumn("id").From(due)
+
+	stmt := t.
+		UPDATE(t.State, t.Attempts, t.LockedBy, t.LockedAt, t.UpdatedAt).
+		SET(
+			postgres.String(StateRunning),
+			t.Attempts.ADD(postgres.Int(1)),
+			postgres.String(workerID),
+			postgres.NOW(),
+			postgres.NOW(),
+		).
+		FROM(due).
+		WHERE(t.ID.EQ(dueID)).
+		RETURNING(t.AllColumns)
+
+	var rows []model.Jobs
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("claiming jobs: %w", err)
+	}
+	return rows, nil
+}
+
+// ownedBy matches a running job only while the claim that produced job is
+// still current, so a worker whose lease expired cannot overwrite the outcome
+// of a later attempt.
+func ownedBy(job model.Jobs) postgres.BoolExpression {
+	t := table.Jobs
+	return t.ID.EQ(postgres.UUID(job.ID)).
+		AND(t.State.EQ(postgres.String(StateRunning))).
+		AND(t.Attempts.EQ(postgres.Int32(job.Attempts))).
+		AND(t.LockedBy.EQ(postgres.String(*job.LockedBy)))
+}
+
+// Complete marks a claimed job as succeeded.
+func (r *Repo) Complete(ctx context.Context, job model.Jobs) error {
+	t := table.Jobs
+	stmt := t.
+		UPDATE(t.State, t.LockedBy, t.LockedAt, t.FinishedAt, t.UpdatedAt).
+		SET(
+			postgres.String(StateSucceeded),
+			postgres.StringExp(postgres.NULL),
+			postgres.TimestampzExp(postgres.NULL),
+			postgres.NOW(),
+			postgres.NOW(),
+		).
+		WHERE(ownedBy(job))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("completing job: %w", err)
+	}
+	return nil
+}
+
+// Fail records a failed attempt of a claimed job. A nil retryAt parks the job
+// as dead; otherwise it becomes pending again from retryAt.
+func (r *Repo) Fail(ctx context.Context, job model.Jobs, cause error, retryAt *time.Time) error {
+	t := table.Jobs
+	state := postgres.String(StatePending)
+	runAt := postgres.TimestampzExp(t.RunAt)
+	finishedAt := postgres.TimestampzExp(postgres.NULL)
+	if retryAt != nil {
+		runAt = postgres.TimestampzT(*retryAt)
+	} else {
+		state = postgres.String(StateDead)
+		finishedAt = postgres.NOW()
+	}
+
+	stmt := t.
+		UPDATE(t.State, t.RunAt, t.LastError, t.LockedBy, t.LockedAt, t.FinishedAt, t.UpdatedAt).
+		SET(
+			state,
+			runAt,
+			postgres.String(cause.Error()),
+			postgres.StringExp(postgres.NULL),
+			postgres.TimestampzExp(postgres.NULL),
+			finishedAt,
+			postgres.NOW(),
+		).
+		WHERE(ownedBy(job))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("recording job failure: %w", err)
+	}
+	return nil
+}
+
+// ── Maintenance ──────────────────────────────────────────────────────────────
+
+// Rescue releases jobs whose claim is older than lease, on the assumption that
+// their worker died. They run again right away, or are parked as dead when no
+// attempts remain. Returns the number of rows released.
+func (r *Repo) Rescue(ctx context.Context, lease time.Duration) (int64, error) {
+	t := table.Jobs
+	outOfAttempts := t.Attempts.GT_EQ(t.MaxAttempts)
+
+	stmt := t.
+		UPDATE(t.State, t.RunAt, t.LastError, t.LockedBy, t.LockedAt, t.FinishedAt, t.UpdatedAt).
+		SET(
+			postgres.CASE().
+				WHEN(outOfAttempts).THEN(postgres.String(StateDead)).
+				ELSE(postgres.String(StatePending)),
+			postgres.NOW(),
+			postgres.String("lease expired before the job finished"),
+			postgres.StringExp(postgres.NULL),
+			postgres.TimestampzExp(postgres.NULL),
+			postgres.CASE().
+				WHEN(outOfAttempts).THEN(postgres.NOW()).
+				ELSE(postgres.TimestampzExp(postgres.NULL)),
+			postgres.NOW(),
+		).
+		WHERE(
+			t.State.EQ(postgres.String(StateRunning)).
+				AND(t.LockedAt.LT(postgres.TimestampzT(time.Now().Add(-lease)))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return 0, fmt.Errorf("rescuing jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// Prune deletes succeeded and dead jobs that finished before cutoff, which
+// also frees their unique keys. Returns the number of rows deleted.
+func (r *Repo) Prune(ctx context.Context, cutoff time.Time) (int64, error) {
+	t := table.Jobs
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.State.IN(postgres.String(StateSucceeded), postgres.String(StateDead)).
+				AND(t.FinishedAt.LT(postgres.TimestampzT(cutoff))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return 0, fmt.Errorf("pruning jobs: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// ── Admin ────────────────────────────────────────────────────────────────────
+
+// List returns jobs newest first, optionally filtered by state and kind.
+func (r *Repo) List(
+	ctx context.Context,
+	state *oapi.JobState,
+	kind *string,
+	limit int,
+) ([]oapi.Job, error) {
+	t := table.Jobs
+	cond := postgres.Bool(true)
+	if state != nil {
+		cond = cond.AND(t.State.EQ(postgres.String(string(*state))))
+	}
+	if kind != nil {
+		cond = cond.AND(t.Kind.EQ(postgres.String(*kind)))
+	}
+
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(cond).
+		ORDER_BY(t.CreatedAt.DESC()).
+		LIMIT(int64(limit))
+
+	var rows []model.Jobs
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing jobs: %w", err)
+	}
+	jobs := make([]oapi.Job, 0, len(rows))
+	for _, row := range rows {
+		jobs = append(jobs, toOapi(row))
+	}
+	return jobs, nil
+}
+
+// Get returns a single job. Returns ErrNotFound when it does not exist.
+func (r *Repo) Get(ctx context.Context, id uuid.UUID) (oapi.Job, error) {
+	t := table.Jobs
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.ID.EQ(postgres.UUID(id))).
+		LIMIT(1)
+
+	var row model.Jobs
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.Job{}, ErrNotFound
+		}
+		return oapi.Job{}, fmt.Errorf("getting job: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Retry requeues a dead job to run now with its attempt count reset. Returns
+// ErrNotFound for an unknown job and ErrNotRetryable when it is not dead.
+func (r *Repo) Retry(ctx context.Context, id uuid.UUID) (oapi.Job, error) {
+	t := table.Jobs
+	stmt := t.
+		UPDATE(t.State, t.Attempts, t.RunAt, t.FinishedAt, t.UpdatedAt).
+		SET(
+			postgres.String(StatePending),
+			postgres.Int(0),
+			postgres.NOW(),
+			postgres.TimestampzExp(postgres.NULL),
+			postgres.NOW(),
+		).
+		WHERE(
+			t.ID.EQ(postgres.UUID(id)).
+				AND(t.State.EQ(postgres.String(StateDead))),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Jobs
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	if err == nil {
+		return toOapi(row), nil
+	}
+	if !errors.Is(err, qrm.ErrNoRows) {
+		return oapi.Job{}, fmt.Errorf("retrying job: %w", err)
+	}
+	if _, err := r.Get(ctx, id); err != nil {
+		return oapi.Job{}, err
+	}
+	return oapi.Job{}, ErrNotRetryable
+}
@@ -0,0 +1,247 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+)
+
+type echoArgs struct {
+	Message string `json:"message"`
+}
+
+func (echoArgs) Kind() string { return "test.echo" }
+
+func newRepo(t *testing.T) *jobs.Repo {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	return jobs.NewRepo(db)
+}
+
+func TestRepo_EnqueueClaimComplete(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	id, err := repo.Enqueue(ctx, echoArgs{Message: "hi"}, jobs.EnqueueOpts{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	claimed, err := repo.Claim(ctx, "w1", []string{"test.echo"}, 10)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 1 || claimed[0].ID != id {
+		t.Fatalf("want the enqueued job claimed, got %+v", claimed)
+	}
+	if claimed[0].Attempts != 1 {
+		t.Errorf("attempts: want 1, got %d", claimed[0].Attempts)
+	}
+
+	again, err := repo.Claim(ctx, "w2", []string{"test.echo"}, 10)
+	if err != nil {
+		t.Fatalf("second Claim: %v", err)
+	}
+	if len(again) != 0 {
+		t.Errorf("want running job not claimed twice, got %d", len(again))
+	}
+
+	if err := repo.Complete(ctx, claimed[0]); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	job, err := repo.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if job.State != oapi.JobState(jobs.StateSucceeded) {
+		t.Errorf("state: want succeeded, got %q", job.State)
+	}
+	if job.Payload["message"] != "hi" {
+		t.Errorf("payload: want message=hi, got %v", job.Payload)
+	}
+}
+
+func TestRepo_ClaimSkipsFutureAndUnknownKinds(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	if _, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{RunAt: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Enqueue future: %v", err)
+	}
+	if _, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{}); err != nil {
+		t.Fatalf("Enqueue due: %v", err)
+	}
+
+	claimed, err := repo.Claim(ctx, "w1", []string{"test.other"}, 10)
+	if err != nil {
+		t.Fatalf("Claim other kind: %v", err)
+	}
+	if len(claimed) != 0 {
+		t.Errorf("want no jobs of an unregistered kind, got %d", len(claimed))
+	}
+
+	claimed, err = repo.Claim(ctx, "w1", []string{"test.echo"}, 10)
+	if err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	if len(claimed) != 1 {
+		t.Errorf("want only the due job, got %d", len(claimed))
+	}
+}
+
+func TestRepo_UniqueKeyDeduplicates(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+	opts := jobs.EnqueueOpts{UniqueKey: "daily:2026-01-01"}
+
+	if _, err := repo.Enqueue(ctx, echoArgs{}, opts); err != nil {
+		t.Fatalf("first Enqueue: %v", err)
+	}
+	if _, err := repo.Enqueue(ctx, echoArgs{}, opts); !errors.Is(err, jobs.ErrDuplicate) {
+		t.Fatalf("want ErrDuplicate, got %v", err)
+	}
+	if _, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{}); err != nil {
+		t.Fatalf("Enqueue without key: %v", err)
+	}
+}
+
+func TestRepo_FailRetriesThenDies(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	id, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{MaxAttempts: 2})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	claimed, err := repo.Claim(ctx, "w1", []string{"test.echo"}, 1)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Claim: %v (%d jobs)", err, len(claimed))
+	}
+	now := time.Now()
+	if err := repo.Fail(ctx, claimed[0], errors.New("first"), &now); err != nil {
+		t.Fatalf("Fail with retry: %v", err)
+	}
+	job, err := repo.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if job.State != oapi.JobState(jobs.StatePending) || job.LastError == nil || *job.LastError != "first" {
+		t.Errorf("want pending with last error, got %q / %v", job.State, job.LastError)
+	}
+
+	claimed, err = repo.Claim(ctx, "w1", []string{"test.echo"}, 1)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("second Claim: %v (%d jobs)", err, len(claimed))
+	}
+	if err := repo.Fail(ctx, claimed[0], errors.New("second"), nil); err != nil {
+		t.Fatalf("Fail permanently: %v", err)
+	}
+	job, err = repo.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if job.State != oapi.JobState(jobs.StateDead) || job.FinishedAt == nil {
+		t.Errorf("want dead with finished_at, got %q / %v", job.State, job.FinishedAt)
+	}
+}
+
+func TestRepo_StaleClaimCannotOverwriteOutcome(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	id, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	first, err := repo.Claim(ctx, "w1", []string{"test.echo"}, 1)
+	if err != nil || len(first) != 1 {
+		t.Fatalf("Claim: %v (%d jobs)", err, len(first))
+	}
+
+	// Lease expires and another worker picks the job up.
+	if _, err := repo.Rescue(ctx, 0); err != nil {
+		t.Fatalf("Rescue: %v", err)
+	}
+	second, err := repo.Claim(ctx, "w2", []string{"test.echo"}, 1)
+	if err != nil || len(second) != 1 {
+		t.Fatalf("reclaim: %v (%d jobs)", err, len(second))
+	}
+
+	if err := repo.Complete(ctx, first[0]); err != nil {
+		t.Fatalf("stale Complete: %v", err)
+	}
+	job, err := repo.Get(ctx, id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if job.State != oapi.JobState(jobs.StateRunning) || job.Attempts != 2 {
+		t.Errorf("want job still running its second attempt, got %q / %d", job.State, job.Attempts)
+	}
+}
+
+func TestRepo_RetryRequeuesDeadJobsOnly(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	id, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := repo.Retry(ctx, id); !errors.Is(err, jobs.ErrNotRetryable) {
+		t.Fatalf("retry pending: want ErrNotRetryable, got %v", err)
+	}
+
+	claimed, err := repo.Claim(ctx, "w1", []string{"test.echo"}, 1)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Claim: %v (%d jobs)", err, len(claimed))
+	}
+	if err := repo.Fail(ctx, claimed[0], errors.New("boom"), nil); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+
+	job, err := repo.Retry(ctx, id)
+	if err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if job.State != oapi.JobState(jobs.StatePending) || job.Attempts != 0 || job.FinishedAt != nil {
+		t.Errorf("want fresh pending job, got %q / %d / %v", job.State, job.Attempts, job.FinishedAt)
+	}
+}
+
+func TestRepo_PruneDeletesFinishedJobs(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	id, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{UniqueKey: "once"})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	claimed, err := repo.Claim(ctx, "w1", []string{"test.echo"}, 1)
+	if err != nil || len(claimed) != 1 {
+		t.Fatalf("Claim: %v (%d jobs)", err, len(claimed))
+	}
+	if err := repo.Complete(ctx, claimed[0]); err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+
+	n, err := repo.Prune(ctx, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n != 1 {
+		t.Errorf("want 1 pruned, got %d", n)
+	}
+	if _, err := repo.Get(ctx, id); !errors.Is(err, jobs.ErrNotFound) {
+		t.Errorf("want ErrNotFound after prune, got %v", err)
+	}
+	if _, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{UniqueKey: "once"}); err != nil {
+		t.Errorf("want unique key free after prune, got %v", err)
+	}
+}
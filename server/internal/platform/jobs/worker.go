@@ -0,0 +1,240 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+)
+
+// WorkerConfig tunes a Worker. Zero fields fall back to the defaults below.
+type WorkerConfig struct {
+	// ID identifies the worker in locked_by. Defaults to "<hostname>:<pid>".
+	ID string
+	// Concurrency is the number of jobs run at once.
+	Concurrency int
+	// PollInterval is how often the queue is polled when idle.
+	PollInterval time.Duration
+	// Lease bounds a single attempt. Handlers get a context with this timeout,
+	// and running jobs claimed longer ago are presumed abandoned and rescued.
+	Lease time.Duration
+	// Retention is how long succeeded and dead jobs are kept before pruning.
+	Retention time.Duration
+}
+
+const (
+	defaultConcurrency  = 4
+	defaultPollInterval = time.Second
+	defaultLease        = 5 * time.Minute
+	defaultRetention    = 7 * 24 * time.Hour
+	maintenanceInterval = time.Minute
+)
+
+func (c WorkerConfig) withDefaults() WorkerConfig {
+	if c.ID == "" {
+		host, _ := os.Hostname()
+		c.ID = fmt.Sprintf("%s:%d", host, os.Getpid())
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultConcurrency
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultPollInterval
+	}
+	if c.Lease <= 0 {
+		c.Lease = defaultLease
+	}
+	if c.Retention <= 0 {
+		c.Retention = defaultRetention
+	}
+	return c
+}
+
+type handlerFunc func(ctx context.Context, payload []byte) error
+
+// Worker claims due jobs and runs them through the handlers registered for
+// their kind. Jobs of kinds it has no handler for are left for other workers.
+type Worker struct {
+	repo     *Repo
+	cfg      WorkerConfig
+	logger   *slog.Logger
+	handlers map[string]handlerFunc
+	cron     *cron.Cron
+}
+
+// NewWorker wires a Worker to its repo. Register handlers and schedules before
+// calling Run.
+func NewWorker(repo *Repo, cfg WorkerConfig, logger *slog.Logger) *Worker {
+	return &Worker{
+		repo:     repo,
+		cfg:      cfg.withDefaults(),
+		logger:   logger,
+		handlers: map[string]handlerFunc{},
+		cron:     cron.New(),
+	}
+}
+
+// Register installs fn as the handler for jobs of T's kind. A payload that does
+// not decode into T fails the job permanently.
+func Register[T Args](w *Worker, fn func(ctx context.Context, args T) error) {
+	var zero T
+	w.handlers[zero.Kind()] = func(ctx context.Context, payload []byte) error {
+		var args T
+		if err := json.Unmarshal(payload, &args); err != nil {
+			return Permanent(fmt.Errorf("decoding payload: %w", err))
+		}
+		return fn(ctx, args)
+	}
+}
+
+// Schedule enqueues args on the standard five-field cron spec (or a
+// descriptor such as "@hourly"). Each tick is keyed by its minute, so when
+// several instances run the same schedule only one job is created per tick.
+func (w *Worker) Schedule(spec string, args Args) error {
+	_, err := w.cron.AddFunc(spec, func() {
+		tick := time.Now().UTC().Truncate(time.Minute)
+		opts := EnqueueOpts{UniqueKey: "cron:" + tick.Format(time.RFC3339)}
+		_, err := w.repo.Enqueue(context.Background(), args, opts)
+		if err != nil && !errors.Is(err, ErrDuplicate) {
+			w.logger.Error("failed to enqueue scheduled job",
+				slog.String("kind", args.Kind()), slog.Any("err", err))
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("scheduling %s: %w", args.Kind(), err)
+	}
+	return nil
+}
+
+// Run processes jobs until ctx is cancelled, then waits for in-flight jobs to
+// finish. Running attempts are not cancelled with ctx; they are bounded by the
+// lease instead.
+func (w *Worker) Run(ctx context.Context) {
+	kinds := make([]string, 0, len(w.handlers))
+	for k := range w.handlers {
+		kinds = append(kinds, k)
+	}
+
+	w.cron.Start()
+	defer func() { <-w.cron.Stop().Done() }()
+
+	w.logger.Info("job worker started",
+		slog.String("worker_id", w.cfg.ID), slog.Any("kinds", kinds))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	slots := make(chan struct{}, w.cfg.Concurrency)
+	poll := time.NewTicker(w.cfg.PollInterval)
+	defer poll.Stop()
+	maintain := time.NewTicker(maintenanceInterval)
+	defer maintain.Stop()
+
+	w.maintain(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-maintain.C:
+			w.maintain(ctx)
+		case <-poll.C:
+		}
+
+		// Keep claiming while full batches come back, so a backlog drains
+		// without waiting a poll interval per batch.
+		for ctx.Err() == nil {
+			free := w.cfg.Concurrency - len(slots)
+			if free == 0 {
+				break
+			}
+			claimed, err := w.repo.Claim(ctx, w.cfg.ID, kinds, free)
+			if err != nil {
+				if ctx.Err() == nil {
+					w.logger.Error("failed to claim jobs", slog.Any("err", err))
+				}
+				break
+			}
+			for _, job := range claimed {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-slots }()
+					w.execute(context.WithoutCancel(ctx), job)
+				}()
+			}
+			if len(claimed) < free {
+				break
+			}
+		}
+	}
+}
+
+// execute runs one claimed attempt and records its outcome.
+func (w *Worker) execute(ctx context.Context, job model.Jobs) {
+	logger := w.logger.With(
+		slog.String("job_id", job.ID.String()),
+		slog.String("kind", job.Kind),
+		slog.Int("attempt", int(job.Attempts)),
+	)
+
+	runCtx, cancel := context.WithTimeout(ctx, w.cfg.Lease)
+	err := w.call(runCtx, job)
+	cancel()
+
+	if err == nil {
+		if err := w.repo.Complete(ctx, job); err != nil {
+			logger.Error("failed to mark job succeeded", slog.Any("err", err))
+		}
+		return
+	}
+
+	var retryAt *time.Time
+	var permanent permanentError
+	if !errors.As(err, &permanent) && job.Attempts < job.MaxAttempts {
+		at := time.Now().Add(Backoff(int(job.Attempts)))
+		retryAt = &at
+	}
+	if retryAt == nil {
+		logger.Error("job failed permanently", slog.Any("err", err))
+	} else {
+		logger.Warn("job failed, will retry", slog.Any("err", err), slog.Time("retry_at", *retryAt))
+	}
+	if err := w.repo.Fail(ctx, job, err, retryAt); err != nil {
+		logger.Error("failed to record job failure", slog.Any("err", err))
+	}
+}
+
+// call invokes the handler for job, turning a panic into an error.
+func (w *Worker) call(ctx context.Context, job model.Jobs) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	fn, ok := w.handlers[job.Kind]
+	if !ok {
+		return Permanent(fmt.Errorf("no handler registered for kind %q", job.Kind))
+	}
+	return fn(ctx, []byte(job.Payload))
+}
+
+// maintain rescues abandoned jobs and prunes finished ones.
+func (w *Worker) maintain(ctx context.Context) {
+	if n, err := w.repo.Rescue(ctx, w.cfg.Lease); err != nil {
+		w.logger.Error("failed to rescue jobs", slog.Any("err", err))
+	} else if n > 0 {
+		w.logger.Warn("rescued abandoned jobs", slog.Int64("count", n))
+	}
+	if _, err := w.repo.Prune(ctx, time.Now().Add(-w.cfg.Retention)); err != nil {
+		w.logger.Error("failed to prune jobs", slog.Any("err", err))
+	}
+}
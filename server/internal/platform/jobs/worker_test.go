@@ -0,0 +1,137 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+)
+
+func TestBackoff_GrowsAndIsCapped(t *testing.T) {
+	cases := []struct {
+		attempt int
+		min     time.Duration
+	}{
+		{attempt: 0, min: time.Second},
+		{attempt: 3, min: 8 * time.Second},
+		{attempt: 10, min: 1024 * time.Second},
+		{attempt: 40, min: time.Hour},
+	}
+	for _, c := range cases {
+		got := jobs.Backoff(c.attempt)
+		if got < c.min || got > c.min+c.min/10+1 {
+			t.Errorf("Backoff(%d): want within 10%% above %v, got %v", c.attempt, c.min, got)
+		}
+	}
+}
+
+// runUntil runs w until cond holds or the deadline passes, then stops it.
+func runUntil(t *testing.T, w *jobs.Worker, cond func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		w.Run(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func newWorker(repo *jobs.Repo) *jobs.Worker {
+	return jobs.NewWorker(
+		repo,
+		jobs.WorkerConfig{ID: "test", PollInterval: 10 * time.Millisecond},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+}
+
+func TestWorker_RunsRegisteredHandler(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	var got atomic.Value
+	w := newWorker(repo)
+	jobs.Register(w, func(_ context.Context, args echoArgs) error {
+		got.Store(args.Message)
+		return nil
+	})
+
+	id, err := repo.Enqueue(ctx, echoArgs{Message: "hello"}, jobs.EnqueueOpts{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	runUntil(t, w, func() bool {
+		job, err := repo.Get(ctx, id)
+		return err == nil && job.State == oapi.JobState(jobs.StateSucceeded)
+	})
+	if got.Load() != "hello" {
+		t.Errorf("handler args: want hello, got %v", got.Load())
+	}
+}
+
+func TestWorker_PermanentErrorKillsJob(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	var calls atomic.Int32
+	w := newWorker(repo)
+	jobs.Register(w, func(context.Context, echoArgs) error {
+		calls.Add(1)
+		return jobs.Permanent(errors.New("bad input"))
+	})
+
+	id, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	runUntil(t, w, func() bool {
+		job, err := repo.Get(ctx, id)
+		return err == nil && job.State == oapi.JobState(jobs.StateDead)
+	})
+	if calls.Load() != 1 {
+		t.Errorf("want a single attempt, got %d", calls.Load())
+	}
+}
+
+func TestWorker_FailureSchedulesRetry(t *testing.T) {
+	repo := newRepo(t)
+	ctx := context.Background()
+
+	w := newWorker(repo)
+	jobs.Register(w, func(context.Context, echoArgs) error {
+		panic("handler blew up")
+	})
+
+	id, err := repo.Enqueue(ctx, echoArgs{}, jobs.EnqueueOpts{})
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	var job oapi.Job
+	runUntil(t, w, func() bool {
+		job, err = repo.Get(ctx, id)
+		return err == nil && job.State == oapi.JobState(jobs.StatePending) && job.Attempts == 1
+	})
+	if !job.RunAt.After(time.Now()) {
+		t.Errorf("want retry scheduled in the future, got %v", job.RunAt)
+	}
+	if job.LastError == nil || *job.LastError != "panic: handler blew up" {
+		t.Errorf("last error: want recovered panic, got %v", job.LastError)
+	}
+}
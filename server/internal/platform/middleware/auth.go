@@ -70,3 +70,12 @@ func GetClerkUserFromContext(ctx context.Context) (*clerk.User, bool) {
 func WithClerkUser(ctx context.Context, u *clerk.User) context.Context {
 	return context.WithValue(ctx, clerkAuthUserKey, u)
 }
+
+// Principal identifies the authenticated caller as a stable string, suitable
+// for scoping per-caller state such as idempotency keys.
+func Principal(ctx context.Context) (string, bool) {
+	if u, ok := GetClerkUserFromContext(ctx); ok {
+		return "user:" + u.ID, true
+	}
+	return "", false
+}
@@ -0,0 +1,96 @@
+// Package orgcache keeps a value per org loaded from its tenant-scoped state,
+// such as the compiled policies or indicator sets applied to its events, and
+// reloads it when the state's revision has moved on.
+package orgcache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// defaultRefresh is how often a Cache checks an org's revision.
+const defaultRefresh = 30 * time.Second
+
+// Revision returns the revision of an org's state, which moves on whenever
+// the state changes.
+type Revision func(ctx context.Context, orgID uuid.UUID) (int64, error)
+
+// Loader loads the value of an org's state as of now.
+type Loader[T any] func(ctx context.Context, orgID uuid.UUID, now time.Time) (T, error)
+
+// Cache holds a value per org. It checks an org's revision at most once a
+// refresh interval, loading the value again when it has changed, both in a
+// transaction carrying the org's tenant scope. It is safe for concurrent use.
+type Cache[T any] struct {
+	tx       *tx.Manager
+	refresh  time.Duration
+	revision Revision
+	load     Loader[T]
+
+	mu   sync.Mutex
+	orgs map[uuid.UUID]*entry[T]
+}
+
+// entry is the value of one org as of revision.
+type entry[T any] struct {
+	mu       sync.Mutex // held while the value is checked or loaded
+	value    T
+	loaded   bool
+	revision int64
+	checked  time.Time
+}
+
+// New wires a Cache loading values with load when revision has changed. A
+// zero refresh means 30 seconds.
+func New[T any](txm *tx.Manager, refresh time.Duration, revision Revision, load Loader[T]) *Cache[T] {
+	if refresh <= 0 {
+		refresh = defaultRefresh
+	}
+	return &Cache[T]{
+		tx:       txm,
+		refresh:  refresh,
+		revision: revision,
+		load:     load,
+		orgs:     make(map[uuid.UUID]*entry[T]),
+	}
+}
+
+// Get returns the org's value, loading it when it has changed. When it cannot
+// be loaded, Get returns the error with the last value loaded, kept until the
+// next refresh, or with the zero value when none ever was.
+func (c *Cache[T]) Get(ctx context.Context, orgID uuid.UUID) (T, error) {
+	c.mu.Lock()
+	e := c.orgs[orgID]
+	if e == nil {
+		e = &entry[T]{}
+		c.orgs[orgID] = e
+	}
+	c.mu.Unlock()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	now := time.Now()
+	if e.loaded && now.Sub(e.checked) < c.refresh {
+		return e.value, nil
+	}
+	err := c.tx.RunInTx(tenant.WithOrg(ctx, orgID), nil, func(ctx context.Context) error {
+		rev, err := c.revision(ctx, orgID)
+		if err != nil || (e.loaded && rev == e.revision) {
+			return err
+		}
+		value, err := c.load(ctx, orgID, now)
+		if err != nil {
+			return err
+		}
+		e.value, e.loaded, e.revision = value, true, rev
+		return nil
+	})
+	e.checked = now
+	return e.value, err
+}
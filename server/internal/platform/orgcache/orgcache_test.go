@@ -0,0 +1,73 @@
+package orgcache_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/orgcache"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+func TestCache_ReloadsOnlyWhenRevisionMoves(t *testing.T) {
+	db := testhelper.DB(t)
+	ctx := context.Background()
+	orgID := uuid.New()
+
+	var rev int64 = 1
+	var loadErr error
+	loads := 0
+	cache := orgcache.New(
+		tx.NewUnscopedManager(db),
+		time.Nanosecond,
+		func(context.Context, uuid.UUID) (int64, error) { return rev, nil },
+		func(_ context.Context, id uuid.UUID, _ time.Time) (string, error) {
+			if id != orgID {
+				t.Errorf("loaded org %s, want %s", id, orgID)
+			}
+			if loadErr != nil {
+				return "", loadErr
+			}
+			loads++
+			return "v" + strconv.FormatInt(rev, 10), nil
+		},
+	)
+
+	for range 2 {
+		v, err := cache.Get(ctx, orgID)
+		if err != nil || v != "v1" {
+			t.Fatalf("Get = (%q, %v), want v1", v, err)
+		}
+	}
+	if loads != 1 {
+		t.Fatalf("loaded %d times at one revision, want 1", loads)
+	}
+
+	rev = 2
+	if v, err := cache.Get(ctx, orgID); err != nil || v != "v2" {
+		t.Fatalf("Get after revision moved = (%q, %v), want v2", v, err)
+	}
+
+	rev, loadErr = 3, errors.New("boom")
+	if v, err := cache.Get(ctx, orgID); err == nil || v != "v2" {
+		t.Fatalf("Get when loading fails = (%q, %v), want v2 with an error", v, err)
+	}
+}
+
+func TestCache_NeverLoadedReturnsZero(t *testing.T) {
+	db := testhelper.DB(t)
+	cache := orgcache.New(
+		tx.NewUnscopedManager(db),
+		0,
+		func(context.Context, uuid.UUID) (int64, error) { return 0, errors.New("boom") },
+		func(context.Context, uuid.UUID, time.Time) (*int, error) { return new(int), nil },
+	)
+	if v, err := cache.Get(context.Background(), uuid.New()); err == nil || v != nil {
+		t.Fatalf("Get = (%v, %v), want nil with an error", v, err)
+	}
+}
@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// NATSSink publishes events to a JetStream stream, one subject per event type
+// below a common prefix, e.g. horizon.events.organization.created. The event
+// ID is sent as the message ID, so JetStream drops redeliveries that fall
+// within its duplicate window.
+type NATSSink struct {
+	nc     *nats.Conn
+	js     jetstream.JetStream
+	stream string
+	prefix string
+}
+
+// NewNATSSink connects to the NATS server at url and creates or updates
+// stream to capture every subject below prefix.
+func NewNATSSink(ctx context.Context, url, stream, prefix string) (*NATSSink, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to nats: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("opening jetstream: %w", err)
+	}
+	_, err = js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     stream,
+		Subjects: []string{prefix + ".>"},
+	})
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("creating stream %s: %w", stream, err)
+	}
+	return &NATSSink{nc: nc, js: js, stream: stream, prefix: prefix}, nil
+}
+
+func (s *NATSSink) Name() string {
+	return "nats:" + s.stream
+}
+
+func (s *NATSSink) Publish(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+	msg := nats.NewMsg(s.prefix + "." + e.Type)
+	msg.Data = data
+	msg.Header.Set("Horizon-Aggregate-Type", e.AggregateType)
+	msg.Header.Set("Horizon-Aggregate-Id", e.AggregateID)
+	msg.Header.Set("Horizon-Seq", strconv.FormatInt(e.Seq, 10))
+	if _, err := s.js.PublishMsg(ctx, msg, jetstream.WithMsgID(e.ID.String())); err != nil {
+		return fmt.Errorf("publishing to jetstream: %w", err)
+	}
+	return nil
+}
+
+// Close drains and closes the NATS connection.
+func (s *NATSSink) Close() error {
+	return s.nc.Drain()
+}
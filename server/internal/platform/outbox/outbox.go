@@ -0,0 +1,88 @@
+// Package outbox implements the transactional outbox for domain events.
+// Services append events with Repo.Append inside the transaction that makes
+// the change they describe, so an event exists if and only if its change
+// committed. A Relay then assigns each visible event a global sequence number
+// and publishes it to every Sink in that order.
+//
+// Delivery is at-least-once: each sink has a checkpoint that only moves past
+// events it acknowledged, so an event may be delivered again after a failure
+// or restart. Consumers deduplicate on Event.ID.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event is a stored domain event as delivered to sinks. Its JSON form is the
+// wire format used by the Redis and NATS sinks.
+type Event struct {
+	ID            uuid.UUID       `json:"id"`
+	Seq           int64           `json:"seq"`
+	OrgID         *uuid.UUID      `json:"org_id,omitempty"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   string          `json:"aggregate_id"`
+	Type          string          `json:"type"`
+	Payload       json.RawMessage `json:"payload"`
+	OccurredAt    time.Time       `json:"occurred_at"`
+}
+
+// Draft is an event about to be appended. Payload is marshalled to JSON.
+type Draft struct {
+	OrgID         *uuid.UUID
+	AggregateType string
+	AggregateID   string
+	Type          string
+	Payload       any
+}
+
+// Sink receives events from the Relay in sequence order. Name identifies the
+// sink's checkpoint and must stay stable across restarts; a sink with a new
+// name starts from the oldest retained event.
+type Sink interface {
+	Name() string
+	Publish(ctx context.Context, e Event) error
+}
+
+// ── In-process subscribers ───────────────────────────────────────────────────
+
+// Subscriber is a Sink that hands events to a function in this process.
+type Subscriber struct {
+	name  string
+	types map[string]struct{}
+	fn    func(ctx context.Context, e Event) error
+}
+
+// NewSubscriber returns a Sink calling fn for events of the given types, or
+// for every event when no type is given. Other events are acknowledged
+// without calling fn. A failing fn is called again with the same event.
+func NewSubscriber(
+	name string,
+	fn func(ctx context.Context, e Event) error,
+	types ...string,
+) *Subscriber {
+	s := &Subscriber{name: name, fn: fn}
+	if len(types) > 0 {
+		s.types = make(map[string]struct{}, len(types))
+		for _, t := range types {
+			s.types[t] = struct{}{}
+		}
+	}
+	return s
+}
+
+func (s *Subscriber) Name() string {
+	return s.name
+}
+
+func (s *Subscriber) Publish(ctx context.Context, e Event) error {
+	if s.types != nil {
+		if _, ok := s.types[e.Type]; !ok {
+			return nil
+		}
+	}
+	return s.fn(ctx, e)
+}
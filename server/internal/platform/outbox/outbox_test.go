@@ -0,0 +1,40 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+func TestSubscriber_FiltersByType(t *testing.T) {
+	var got []string
+	s := outbox.NewSubscriber("test", func(_ context.Context, e outbox.Event) error {
+		got = append(got, e.Type)
+		return nil
+	}, "a.created", "a.deleted")
+
+	for _, typ := range []string{"a.created", "b.created", "a.deleted"} {
+		if err := s.Publish(context.Background(), outbox.Event{Type: typ}); err != nil {
+			t.Fatalf("Publish %s: %v", typ, err)
+		}
+	}
+	if len(got) != 2 || got[0] != "a.created" || got[1] != "a.deleted" {
+		t.Errorf("want only subscribed types, got %v", got)
+	}
+}
+
+func TestSubscriber_WithoutTypesReceivesAllAndReportsErrors(t *testing.T) {
+	boom := errors.New("boom")
+	s := outbox.NewSubscriber("test", func(context.Context, outbox.Event) error {
+		return boom
+	})
+
+	if err := s.Publish(context.Background(), outbox.Event{Type: "anything"}); !errors.Is(err, boom) {
+		t.Errorf("want handler error, got %v", err)
+	}
+	if s.Name() != "test" {
+		t.Errorf("name: want test, got %q", s.Name())
+	}
+}
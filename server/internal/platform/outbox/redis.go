@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisMaxLen caps a Redis stream at roughly this many entries.
+const redisMaxLen = 1_000_000
+
+// RedisSink appends events to a Redis stream. Each entry carries the routing
+// fields alongside the event's JSON form, so readers can filter without
+// decoding it.
+type RedisSink struct {
+	client *redis.Client
+	stream string
+}
+
+// NewRedisSink connects to the Redis server at url, e.g.
+// redis://localhost:6379/0, and publishes to stream.
+func NewRedisSink(ctx context.Context, url, stream string) (*RedisSink, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("parsing redis url: %w", err)
+	}
+	client := redis.NewClient(opts)
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("connecting to redis: %w", err)
+	}
+	return &RedisSink{client: client, stream: stream}, nil
+}
+
+func (s *RedisSink) Name() string {
+	return "redis:" + s.stream
+}
+
+func (s *RedisSink) Publish(ctx context.Context, e Event) error {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("encoding event: %w", err)
+	}
+	err = s.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: s.stream,
+		MaxLen: redisMaxLen,
+		Approx: true,
+		Values: map[string]any{
+			"id":             e.ID.String(),
+			"seq":            strconv.FormatInt(e.Seq, 10),
+			"type":           e.Type,
+			"aggregate_type": e.AggregateType,
+			"aggregate_id":   e.AggregateID,
+			"occurred_at":    e.OccurredAt.Format(time.RFC3339Nano),
+			"event":          string(data),
+		},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("adding to redis stream: %w", err)
+	}
+	return nil
+}
+
+// Close disconnects from Redis.
+func (s *RedisSink) Close() error {
+	return s.client.Close()
+}
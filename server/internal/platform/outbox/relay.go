@@ -0,0 +1,137 @@
+package outbox
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// RelayConfig tunes a Relay. Zero values fall back to defaults.
+type RelayConfig struct {
+	// PollInterval is how often the relay looks for new events.
+	PollInterval time.Duration
+	// BatchSize bounds the events sequenced, and delivered to each sink, per
+	// pass.
+	BatchSize int
+}
+
+// Relay publishes outbox events to sinks. Any number of relays may run; an
+// advisory lock lets one of them work at a time, which keeps each sink's
+// stream in seq order.
+type Relay struct {
+	repo   *Repo
+	txm    *tx.Manager
+	sinks  []Sink
+	cfg    RelayConfig
+	logger *slog.Logger
+}
+
+// NewRelay wires a Relay. txm must be unscoped: the relay reads every
+// tenant's events.
+func NewRelay(repo *Repo, txm *tx.Manager, sinks []Sink, cfg RelayConfig, logger *slog.Logger) *Relay {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+	return &Relay{repo: repo, txm: txm, sinks: sinks, cfg: cfg, logger: logger}
+}
+
+// Consumers returns the checkpoint names of the relay's sinks.
+func (r *Relay) Consumers() []string {
+	names := make([]string, 0, len(r.sinks))
+	for _, s := range r.sinks {
+		names = append(names, s.Name())
+	}
+	return names
+}
+
+// Run relays events until ctx is cancelled.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		more, err := r.Flush(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.ErrorContext(ctx, "outbox relay failed", slog.Any("err", err))
+		}
+		if more && err == nil {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Flush makes one relay pass: it sequences newly visible events and delivers
+// pending ones to each sink, stopping a sink at its first failure. It reports
+// whether a batch limit was hit, meaning more work is ready. Sink failures
+// are logged rather than returned, so one sink cannot hold up the others.
+// Checkpoints move in the pass's transaction; if it rolls back, the events
+// are published again on a later pass.
+func (r *Relay) Flush(ctx context.Context) (bool, error) {
+	var more bool
+	err := r.txm.RunInTx(ctx, nil, func(ctx context.Context) error {
+		locked, err := r.repo.TryLock(ctx)
+		if err != nil || !locked {
+			return err
+		}
+
+		n, err := r.repo.Sequence(ctx, r.cfg.BatchSize)
+		if err != nil {
+			return err
+		}
+		more = n == int64(r.cfg.BatchSize)
+
+		for _, sink := range r.sinks {
+			full, err := r.deliver(ctx, sink)
+			if err != nil {
+				return err
+			}
+			more = more || full
+		}
+		return nil
+	})
+	return more, err
+}
+
+// deliver publishes the events after sink's checkpoint and advances it past
+// those acknowledged. It reports whether a full batch was delivered.
+func (r *Relay) deliver(ctx context.Context, sink Sink) (bool, error) {
+	from, err := r.repo.Checkpoint(ctx, sink.Name())
+	if err != nil {
+		return false, err
+	}
+	events, err := r.repo.After(ctx, from, r.cfg.BatchSize)
+	if err != nil {
+		return false, err
+	}
+
+	last := from
+	for _, e := range events {
+		if err := sink.Publish(ctx, e); err != nil {
+			r.logger.WarnContext(ctx, "outbox sink failed",
+				slog.String("sink", sink.Name()),
+				slog.Int64("seq", e.Seq),
+				slog.String("event_type", e.Type),
+				slog.Any("err", err),
+			)
+			break
+		}
+		last = e.Seq
+	}
+	if last == from {
+		return false, nil
+	}
+	if err := r.repo.Advance(ctx, sink.Name(), last); err != nil {
+		return false, err
+	}
+	return last == events[len(events)-1].Seq && len(events) == r.cfg.BatchSize, nil
+}
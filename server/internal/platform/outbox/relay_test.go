@@ -0,0 +1,219 @@
+package outbox_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// recordingSink collects published events and fails once failAt is reached.
+type recordingSink struct {
+	name   string
+	got    []outbox.Event
+	failAt int64
+}
+
+func (s *recordingSink) Name() string { return s.name }
+
+func (s *recordingSink) Publish(_ context.Context, e outbox.Event) error {
+	if s.failAt != 0 && e.Seq >= s.failAt {
+		return errors.New("sink unavailable")
+	}
+	s.got = append(s.got, e)
+	return nil
+}
+
+func newRelay(t *testing.T, sinks ...outbox.Sink) (*outbox.Relay, *outbox.Repo) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	repo := outbox.NewRepo(db)
+	relay := outbox.NewRelay(
+		repo,
+		tx.NewUnscopedManager(db),
+		sinks,
+		outbox.RelayConfig{BatchSize: 10},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	return relay, repo
+}
+
+func appendEvents(t *testing.T, repo *outbox.Repo, aggregateID string, types ...string) {
+	t.Helper()
+	for _, typ := range types {
+		err := repo.Append(context.Background(), outbox.Draft{
+			AggregateType: "thing",
+			AggregateID:   aggregateID,
+			Type:          typ,
+			Payload:       map[string]string{"type": typ},
+		})
+		if err != nil {
+			t.Fatalf("Append %s: %v", typ, err)
+		}
+	}
+}
+
+func TestRelay_DeliversInOrderOnce(t *testing.T) {
+	sink := &recordingSink{name: "test"}
+	relay, repo := newRelay(t, sink)
+	ctx := context.Background()
+
+	appendEvents(t, repo, "a", "thing.created", "thing.renamed", "thing.deleted")
+	if _, err := relay.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if _, err := relay.Flush(ctx); err != nil {
+		t.Fatalf("second Flush: %v", err)
+	}
+
+	if len(sink.got) != 3 {
+		t.Fatalf("want 3 events delivered once, got %d", len(sink.got))
+	}
+	for i, want := range []string{"thing.created", "thing.renamed", "thing.deleted"} {
+		e := sink.got[i]
+		if e.Type != want || e.Seq != int64(i+1) || e.AggregateID != "a" {
+			t.Errorf("event %d: want %s with seq %d, got %s with seq %d", i, want, i+1, e.Type, e.Seq)
+		}
+	}
+	if string(sink.got[0].Payload) != `{"type": "thing.created"}` {
+		t.Errorf("payload: got %s", sink.got[0].Payload)
+	}
+
+	cp, err := repo.Checkpoint(ctx, "test")
+	if err != nil {
+		t.Fatalf("Checkpoint: %v", err)
+	}
+	if cp != 3 {
+		t.Errorf("checkpoint: want 3, got %d", cp)
+	}
+}
+
+func TestRelay_FailingSinkResumesFromCheckpoint(t *testing.T) {
+	failing := &recordingSink{name: "flaky", failAt: 2}
+	healthy := &recordingSink{name: "healthy"}
+	relay, repo := newRelay(t, failing, healthy)
+	ctx := context.Background()
+
+	appendEvents(t, repo, "a", "thing.created", "thing.renamed", "thing.deleted")
+	if _, err := relay.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if len(failing.got) != 1 {
+		t.Fatalf("flaky: want delivery to stop at the failure, got %d events", len(failing.got))
+	}
+	if len(healthy.got) != 3 {
+		t.Fatalf("healthy: want all events despite the other sink, got %d", len(healthy.got))
+	}
+
+	failing.failAt = 0
+	if _, err := relay.Flush(ctx); err != nil {
+		t.Fatalf("second Flush: %v", err)
+	}
+	if len(failing.got) != 3 || failing.got[1].Seq != 2 {
+		t.Errorf("flaky: want the rest delivered from seq 2, got %d events", len(failing.got))
+	}
+	if len(healthy.got) != 3 {
+		t.Errorf("healthy: want no redelivery, got %d events", len(healthy.got))
+	}
+}
+
+func TestRelay_ReportsMoreWhenBatchIsFull(t *testing.T) {
+	sink := &recordingSink{name: "test"}
+	relay, repo := newRelay(t, sink)
+	ctx := context.Background()
+
+	for range 12 {
+		appendEvents(t, repo, uuid.NewString(), "thing.created")
+	}
+	more, err := relay.Flush(ctx)
+	if err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if !more || len(sink.got) != 10 {
+		t.Fatalf("want a full batch and more pending, got more=%v with %d events", more, len(sink.got))
+	}
+	more, err = relay.Flush(ctx)
+	if err != nil {
+		t.Fatalf("second Flush: %v", err)
+	}
+	if more || len(sink.got) != 12 {
+		t.Errorf("want the remainder and nothing pending, got more=%v with %d events", more, len(sink.got))
+	}
+}
+
+func TestRepo_PruneKeepsUnacknowledgedEvents(t *testing.T) {
+	sink := &recordingSink{name: "test", failAt: 3}
+	relay, repo := newRelay(t, sink)
+	ctx := context.Background()
+
+	appendEvents(t, repo, "a", "thing.created", "thing.renamed", "thing.deleted")
+	if _, err := relay.Flush(ctx); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	n, err := repo.Prune(ctx, relay.Consumers(), time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("want the 2 acknowledged events pruned, got %d", n)
+	}
+	n, err = repo.Prune(ctx, []string{"test", "never-ran"}, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("Prune with new consumer: %v", err)
+	}
+	if n != 0 {
+		t.Errorf("want nothing pruned before every consumer caught up, got %d", n)
+	}
+}
+
+func TestRepo_TenantsOnlySeeTheirOrgsEvents(t *testing.T) {
+	relay, repo := newRelay(t)
+	db := testhelper.DB(t)
+	own, other := uuid.New(), uuid.New()
+	for _, orgID := range []*uuid.UUID{&own, nil} {
+		err := repo.Append(context.Background(), outbox.Draft{
+			OrgID:         orgID,
+			AggregateType: "thing",
+			AggregateID:   "a",
+			Type:          "thing.created",
+		})
+		if err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+	if _, err := relay.Flush(context.Background()); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+
+	visible := func(orgID uuid.UUID) int {
+		t.Helper()
+		var events []outbox.Event
+		err := tx.NewManager(db).RunInTx(tenant.WithOrg(context.Background(), orgID), nil,
+			func(ctx context.Context) error {
+				var err error
+				events, err = repo.After(ctx, 0, 10)
+				return err
+			})
+		if err != nil {
+			t.Fatalf("After: %v", err)
+		}
+		return len(events)
+	}
+	if n := visible(own); n != 1 {
+		t.Errorf("own org: got %d events, want its 1", n)
+	}
+	if n := visible(other); n != 0 {
+		t.Errorf("other org: got %d events, want none", n)
+	}
+}
@@ -0,0 +1,240 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// relayLockKey is the advisory lock serialising relays across instances.
+const relayLockKey int64 = 0x6f7574626f78 // "outbox"
+
+// sequenceQuery numbers up to $1 unsequenced events after the highest seq,
+// in insert order.
+const sequenceQuery = `
+WITH base AS (
+    SELECT COALESCE(MAX(seq), 0) AS n FROM outbox_events
+), next AS (
+    SELECT id, ROW_NUMBER() OVER (ORDER BY ordinal) AS rn
+    FROM outbox_events
+    WHERE seq IS NULL
+    ORDER BY ordinal
+    LIMIT $1
+)
+UPDATE outbox_events e
+SET seq = base.n + next.rn
+FROM base, next
+WHERE e.id = next.id`
+
+// Repo owns outbox SQL. Statements join the transaction on ctx, if any.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo backed by the given database handle.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toEvent(m model.OutboxEvents) Event {
+	var seq int64
+	if m.Seq != nil {
+		seq = *m.Seq
+	}
+	return Event{
+		ID:            m.ID,
+		Seq:           seq,
+		OrgID:         m.OrgID,
+		AggregateType: m.AggregateType,
+		AggregateID:   m.AggregateID,
+		Type:          m.EventType,
+		Payload:       json.RawMessage(m.Payload),
+		OccurredAt:    m.CreatedAt,
+	}
+}
+
+// ── Producing ────────────────────────────────────────────────────────────────
+
+// Append stores an event for the relay to publish. Call it inside the
+// transaction making the change the event describes.
+func (r *Repo) Append(ctx context.Context, d Draft) error {
+	payload, err := json.Marshal(d.Payload)
+	if err != nil {
+		return fmt.Errorf("encoding %s event: %w", d.Type, err)
+	}
+
+	t := table.OutboxEvents
+	stmt := t.
+		INSERT(t.OrgID, t.AggregateType, t.AggregateID, t.EventType, t.Payload).
+		VALUES(d.OrgID, d.AggregateType, d.AggregateID, d.Type, string(payload))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("appending %s event: %w", d.Type, err)
+	}
+	return nil
+}
+
+// ── Relaying ─────────────────────────────────────────────────────────────────
+
+// TryLock takes the relay lock for the transaction on ctx. It reports false
+// when another relay holds it.
+func (r *Repo) TryLock(ctx context.Context) (bool, error) {
+	rows, err := tx.Executor(ctx, r.db).
+		QueryContext(ctx, "SELECT pg_try_advisory_xact_lock($1)", relayLockKey)
+	if err != nil {
+		return false, fmt.Errorf("taking relay lock: %w", err)
+	}
+	defer rows.Close()
+
+	var locked bool
+	if rows.Next() {
+		if err := rows.Scan(&locked); err != nil {
+			return false, fmt.Errorf("taking relay lock: %w", err)
+		}
+	}
+	return locked, rows.Err()
+}
+
+// Sequence assigns seq numbers to up to limit events that are visible but not
+// yet sequenced. Run it under the relay lock. Returns the number sequenced.
+func (r *Repo) Sequence(ctx context.Context, limit int) (int64, error) {
+	res, err := tx.Executor(ctx, r.db).ExecContext(ctx, sequenceQuery, limit)
+	if err != nil {
+		return 0, fmt.Errorf("sequencing events: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// After returns up to limit sequenced events with seq greater than seq, in
+// seq order.
+func (r *Repo) After(ctx context.Context, seq int64, limit int) ([]Event, error) {
+	t := table.OutboxEvents
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.Seq.GT(postgres.Int(seq))).
+		ORDER_BY(t.Seq.ASC()).
+		LIMIT(int64(limit))
+
+	var rows []model.OutboxEvents
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("reading events: %w", err)
+	}
+	events := make([]Event, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, toEvent(row))
+	}
+	return events, nil
+}
+
+// Checkpoint returns the last seq acknowledged by consumer, or 0 when it has
+// not acknowledged anything yet.
+func (r *Repo) Checkpoint(ctx context.Context, consumer string) (int64, error) {
+	t := table.OutboxCheckpoints
+	stmt := postgres.
+		SELECT(t.LastSeq).
+		FROM(t).
+		WHERE(t.Consumer.EQ(postgres.String(consumer)))
+
+	var row model.OutboxCheckpoints
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("reading checkpoint: %w", err)
+	}
+	return row.LastSeq, nil
+}
+
+// Advance moves consumer's checkpoint to seq.
+func (r *Repo) Advance(ctx context.Context, consumer string, seq int64) error {
+	t := table.OutboxCheckpoints
+	stmt := t.
+		INSERT(t.Consumer, t.LastSeq).
+		VALUES(consumer, seq).
+		ON_CONFLICT(t.Consumer).
+		DO_UPDATE(postgres.SET(
+			t.LastSeq.SET(t.EXCLUDED.LastSeq),
+			t.UpdatedAt.SET(postgres.NOW()),
+		))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("advancing checkpoint: %w", err)
+	}
+	return nil
+}
+
+// ── Maintenance ──────────────────────────────────────────────────────────────
+
+// Prune deletes events created before cutoff that every one of consumers has
+// acknowledged. Returns the number of rows deleted.
+func (r *Repo) Prune(ctx context.Context, consumers []string, cutoff time.Time) (int64, error) {
+	t := table.OutboxEvents
+	cond := t.Seq.IS_NOT_NULL().AND(t.CreatedAt.LT(postgres.TimestampzT(cutoff)))
+	if len(consumers) > 0 {
+		low, err := r.lowestCheckpoint(ctx, consumers)
+		if err != nil {
+			return 0, err
+		}
+		cond = cond.AND(t.Seq.LT_EQ(postgres.Int(low)))
+	}
+
+	stmt := t.DELETE().WHERE(cond)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return 0, fmt.Errorf("pruning events: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// lowestCheckpoint returns the smallest checkpoint among consumers, counting a
+// consumer without one as 0.
+func (r *Repo) lowestCheckpoint(ctx context.Context, consumers []string) (int64, error) {
+	names := make([]postgres.Expression, 0, len(consumers))
+	for _, c := range consumers {
+		names = append(names, postgres.String(c))
+	}
+
+	t := table.OutboxCheckpoints
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.Consumer.IN(names...))
+
+	var rows []model.OutboxCheckpoints
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return 0, fmt.Errorf("reading checkpoints: %w", err)
+	}
+	seen := make(map[string]struct{}, len(rows))
+	var low int64 = -1
+	for _, row := range rows {
+		seen[row.Consumer] = struct{}{}
+		if low < 0 || row.LastSeq < low {
+			low = row.LastSeq
+		}
+	}
+	for _, c := range consumers {
+		if _, ok := seen[c]; !ok {
+			return 0, nil
+		}
+	}
+	return low, nil
+}
+
+// PruneArgs is the background job running Prune for the relay's sinks.
+type PruneArgs struct{}
+
+// Kind implements jobs.Args.
+func (PruneArgs) Kind() string { return "outbox.prune" }
@@ -0,0 +1,391 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// JetStream stream and subject names. Messages go to
+// horizon.ingest.<partition>.<org>.<source>; a consumer's dead letters to
+// horizon.dead.ingest.<consumer>.<org>.<source>.
+const (
+	jsStream     = "HORIZON_INGEST"
+	jsDeadStream = "HORIZON_INGEST_DEAD"
+	jsPrefix     = "horizon.ingest"
+	jsDeadPrefix = "horizon.dead.ingest"
+)
+
+// Dead-letter headers, next to the original message.
+const (
+	headerConsumer = "Horizon-Consumer"
+	headerError    = "Horizon-Error"
+	headerAttempts = "Horizon-Attempts"
+	headerSubject  = "Horizon-Subject"
+)
+
+// JetStream is the queue kept in a NATS JetStream stream. Sources are spread
+// over Partitions subjects by a hash of their ID; a consumer reads each
+// partition through its own durable JetStream consumer that has at most one
+// message, or one batch, outstanding, so a partition, and every source in
+// it, is handled in order. A failed message is redelivered after a backoff
+// before anything behind it.
+//
+// The stream keeps messages for MaxAge whether or not they were consumed, so
+// a consumer that falls further behind than that loses messages.
+type JetStream struct {
+	nc     *nats.Conn
+	js     jetstream.JetStream
+	cfg    Config
+	logger *slog.Logger
+}
+
+// NewJetStream connects to the NATS server at url and creates or updates the
+// ingest and dead-letter streams.
+func NewJetStream(
+	ctx context.Context,
+	url string,
+	cfg Config,
+	maxAge time.Duration,
+	logger *slog.Logger,
+) (*JetStream, error) {
+	nc, err := nats.Connect(url)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to nats: %w", err)
+	}
+	js, err := jetstream.New(nc)
+	if err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("opening jetstream: %w", err)
+	}
+	for _, sc := range []jetstream.StreamConfig{
+		{Name: jsStream, Subjects: []string{jsPrefix + ".>"}, MaxAge: maxAge},
+		{Name: jsDeadStream, Subjects: []string{jsDeadPrefix + ".>"}},
+	} {
+		if _, err := js.CreateOrUpdateStream(ctx, sc); err != nil {
+			nc.Close()
+			return nil, fmt.Errorf("creating stream %s: %w", sc.Name, err)
+		}
+	}
+	return &JetStream{nc: nc, js: js, cfg: cfg.withDefaults(), logger: logger}, nil
+}
+
+// partition returns the partition of a source.
+func (q *JetStream) partition(sourceID uuid.UUID) int {
+	h := fnv.New32a()
+	_, _ = h.Write(sourceID[:])
+	return int(h.Sum32() % uint32(q.cfg.Partitions)) //nolint:gosec // Partitions is positive
+}
+
+// Publish sends msgs and waits for the stream to store them. The message ID
+// is sent as the JetStream message ID, so publishing again within the
+// stream's duplicate window, as a retried transaction does, stores nothing
+// new.
+func (q *JetStream) Publish(ctx context.Context, msgs []Message) error {
+	futures := make([]jetstream.PubAckFuture, 0, len(msgs))
+	for _, m := range msgs {
+		data, err := json.Marshal(m)
+		if err != nil {
+			return fmt.Errorf("encoding message: %w", err)
+		}
+		subject := fmt.Sprintf("%s.%d.%s.%s", jsPrefix, q.partition(m.SourceID), m.OrgID, m.SourceID)
+		f, err := q.js.PublishAsync(subject, data, jetstream.WithMsgID(m.ID.String()))
+		if err != nil {
+			return fmt.Errorf("publishing to jetstream: %w", err)
+		}
+		futures = append(futures, f)
+	}
+	for _, f := range futures {
+		select {
+		case <-f.Ok():
+		case err := <-f.Err():
+			return fmt.Errorf("publishing to jetstream: %w", err)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Consume delivers messages to h until ctx is cancelled, reading every
+// partition at once. Handler calls are not cancelled with ctx; they are
+// bounded by AckWait instead.
+func (q *JetStream) Consume(ctx context.Context, name string, h Handler) error {
+	logger := q.logger.With(slog.String("consumer", name))
+
+	iters := make([]jetstream.MessagesContext, 0, q.cfg.Partitions)
+	defer func() {
+		for _, it := range iters {
+			it.Stop()
+		}
+	}()
+	consumers, err := q.consumers(ctx, name, 1, 0)
+	if err != nil {
+		return err
+	}
+	for _, cons := range consumers {
+		it, err := cons.Messages(jetstream.PullMaxMessages(1))
+		if err != nil {
+			return fmt.Errorf("reading consumer %s: %w", name, err)
+		}
+		iters = append(iters, it)
+	}
+	logger.Info("queue consumer started", slog.String("backend", "jetstream"))
+
+	var wg sync.WaitGroup
+	for _, it := range iters {
+		wg.Go(func() {
+			for {
+				msg, err := it.Next()
+				if err != nil {
+					if !errors.Is(err, jetstream.ErrMsgIteratorClosed) {
+						logger.Error("failed to read from jetstream", slog.Any("err", err))
+					}
+					return
+				}
+				q.handle(context.WithoutCancel(ctx), name, h, msg, logger)
+			}
+		})
+	}
+	<-ctx.Done()
+	for _, it := range iters {
+		it.Stop()
+	}
+	wg.Wait()
+	return nil
+}
+
+// ConsumeBatches delivers messages to h in batches of up to b.Size messages
+// of one partition until ctx is cancelled. A fetch waits up to b.Linger for
+// its batch to fill. Each partition has a whole batch outstanding at most; a
+// failed batch is redelivered after a backoff, still before anything behind
+// it.
+func (q *JetStream) ConsumeBatches(ctx context.Context, name string, b Batching, h BatchHandler) error {
+	logger := q.logger.With(slog.String("consumer", name))
+	if b.Size <= 0 {
+		b.Size = q.cfg.BatchSize
+	}
+	wait := max(b.Linger, q.cfg.PollInterval)
+
+	consumers, err := q.consumers(ctx, name, b.Size, wait)
+	if err != nil {
+		return err
+	}
+	logger.Info("queue consumer started", slog.String("backend", "jetstream"))
+
+	var wg sync.WaitGroup
+	for _, cons := range consumers {
+		wg.Go(func() {
+			for ctx.Err() == nil {
+				msgs, err := fetch(ctx, cons, b.Size, wait)
+				if err != nil {
+					if ctx.Err() == nil {
+						logger.Error("failed to read from jetstream", slog.Any("err", err))
+						time.Sleep(q.cfg.PollInterval)
+					}
+					continue
+				}
+				if len(msgs) > 0 {
+					q.handleBatch(context.WithoutCancel(ctx), name, h, msgs, logger)
+				}
+			}
+		})
+	}
+	wg.Wait()
+	return nil
+}
+
+// consumers creates or updates the durable consumer of every partition for
+// name, allowing pending unacknowledged messages each. A message may wait
+// up to linger in a filling batch before its handler call starts.
+func (q *JetStream) consumers(
+	ctx context.Context,
+	name string,
+	pending int,
+	linger time.Duration,
+) ([]jetstream.Consumer, error) {
+	consumers := make([]jetstream.Consumer, 0, q.cfg.Partitions)
+	for p := range q.cfg.Partitions {
+		cons, err := q.js.CreateOrUpdateConsumer(ctx, jsStream, jetstream.ConsumerConfig{
+			Durable:       fmt.Sprintf("%s-%d", name, p),
+			FilterSubject: fmt.Sprintf("%s.%d.>", jsPrefix, p),
+			DeliverPolicy: jetstream.DeliverAllPolicy,
+			AckPolicy:     jetstream.AckExplicitPolicy,
+			// A handler call is bounded by AckWait; the margin lets its
+			// failure be settled before the server redelivers.
+			AckWait: linger + 2*q.cfg.AckWait,
+			// Dead letters are settled here, so the server never gives up.
+			MaxDeliver:    -1,
+			MaxAckPending: pending,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("creating consumer %s: %w", name, err)
+		}
+		consumers = append(consumers, cons)
+	}
+	return consumers, nil
+}
+
+// fetch pulls up to size messages, waiting up to wait for that many.
+func fetch(ctx context.Context, cons jetstream.Consumer, size int, wait time.Duration) ([]jetstream.Msg, error) {
+	ctx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+	batch, err := cons.Fetch(size, jetstream.FetchContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	var msgs []jetstream.Msg
+	for msg := range batch.Messages() {
+		msgs = append(msgs, msg)
+	}
+	if err := batch.Error(); err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return msgs, err
+	}
+	return msgs, nil
+}
+
+// handle delivers one message and settles it: acknowledged, redelivered after
+// a backoff, or sent to the dead letters.
+func (q *JetStream) handle(ctx context.Context, name string, h Handler, msg jetstream.Msg, logger *slog.Logger) {
+	meta, err := msg.Metadata()
+	if err != nil {
+		logger.Error("failed to read message metadata", slog.Any("err", err))
+		_ = msg.Term()
+		return
+	}
+	d := Delivery{Seq: int64(meta.Sequence.Stream), Attempt: int(meta.NumDelivered)} //nolint:gosec // counters fit
+
+	var failure error
+	switch {
+	case json.Unmarshal(msg.Data(), &d.Message) != nil:
+		failure = Permanent(errors.New("undecodable message"))
+	case d.Attempt > q.cfg.MaxDeliver:
+		failure = ErrDeliveryLimit
+	default:
+		failure = call(ctx, h, d, q.cfg.AckWait)
+	}
+
+	logger = logger.With(slog.String("event_id", d.ID.String()), slog.Int("attempt", d.Attempt))
+	switch {
+	case failure == nil:
+		err = msg.Ack()
+	case errors.Is(failure, ErrDeliveryLimit) || DeadLetter(failure, d.Attempt, q.cfg.MaxDeliver):
+		logger.Error("queued event dead-lettered", slog.Any("err", failure))
+		if err = q.bury(ctx, name, msg, d, failure); err != nil {
+			logger.Error("failed to store dead letter", slog.Any("err", err))
+			err = msg.NakWithDelay(Backoff(d.Attempt))
+		} else {
+			err = msg.Term()
+		}
+	default:
+		delay := Backoff(d.Attempt)
+		logger.Warn("queued event failed, will retry", slog.Any("err", failure), slog.Duration("delay", delay))
+		err = msg.NakWithDelay(delay)
+	}
+	if err != nil {
+		logger.Error("failed to settle message", slog.Any("err", err))
+	}
+}
+
+// handleBatch delivers msgs as one batch and settles every one of them the
+// same way. A message that cannot be decoded is dead-lettered on its own.
+func (q *JetStream) handleBatch(
+	ctx context.Context,
+	name string,
+	h BatchHandler,
+	msgs []jetstream.Msg,
+	logger *slog.Logger,
+) {
+	ds := make([]Delivery, 0, len(msgs))
+	kept := msgs[:0]
+	attempt := 1
+	for _, msg := range msgs {
+		var d Delivery
+		meta, err := msg.Metadata()
+		if err == nil {
+			err = json.Unmarshal(msg.Data(), &d.Message)
+		}
+		if err != nil {
+			logger.Error("queued event dead-lettered", slog.Any("err", err))
+			if err := q.bury(ctx, name, msg, d, Permanent(errors.New("undecodable message"))); err != nil {
+				logger.Error("failed to store dead letter", slog.Any("err", err))
+			}
+			_ = msg.Term()
+			continue
+		}
+		d.Seq = int64(meta.Sequence.Stream)            //nolint:gosec // counters fit
+		attempt = max(attempt, int(meta.NumDelivered)) //nolint:gosec // counters fit
+		ds = append(ds, d)
+		kept = append(kept, msg)
+	}
+	if len(ds) == 0 {
+		return
+	}
+	for i := range ds {
+		ds[i].Attempt = attempt
+	}
+
+	var failure error
+	if attempt > q.cfg.MaxDeliver {
+		failure = ErrDeliveryLimit
+	} else {
+		failure = call(ctx, h, ds, q.cfg.AckWait)
+	}
+
+	logger = logger.With(slog.Int("events", len(ds)), slog.Int("attempt", attempt))
+	dead := errors.Is(failure, ErrDeliveryLimit) || DeadLetter(failure, attempt, q.cfg.MaxDeliver)
+	switch {
+	case failure == nil:
+	case dead:
+		logger.Error("queued batch dead-lettered", slog.Any("err", failure))
+	default:
+		logger.Warn("queued batch failed, will retry", slog.Any("err", failure))
+	}
+	delay := Backoff(attempt)
+	for i, msg := range kept {
+		var err error
+		switch {
+		case failure == nil:
+			err = msg.Ack()
+		case dead:
+			if err = q.bury(ctx, name, msg, ds[i], failure); err != nil {
+				logger.Error("failed to store dead letter", slog.Any("err", err))
+				err = msg.NakWithDelay(delay)
+			} else {
+				err = msg.Term()
+			}
+		default:
+			err = msg.NakWithDelay(delay)
+		}
+		if err != nil {
+			logger.Error("failed to settle message", slog.Any("err", err))
+		}
+	}
+}
+
+// bury publishes msg to the consumer's dead letters.
+func (q *JetStream) bury(ctx context.Context, name string, msg jetstream.Msg, d Delivery, cause error) error {
+	dead := nats.NewMsg(fmt.Sprintf("%s.%s.%s.%s", jsDeadPrefix, name, d.OrgID, d.SourceID))
+	dead.Data = msg.Data()
+	dead.Header.Set(headerConsumer, name)
+	dead.Header.Set(headerError, cause.Error())
+	dead.Header.Set(headerAttempts, strconv.Itoa(d.Attempt))
+	dead.Header.Set(headerSubject, msg.Subject())
+	_, err := q.js.PublishMsg(ctx, dead, jetstream.WithMsgID(name+":"+d.ID.String()))
+	return err
+}
+
+// Close drains and closes the NATS connection.
+func (q *JetStream) Close() error {
+	return q.nc.Drain()
+}
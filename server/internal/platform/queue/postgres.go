@@ -0,0 +1,272 @@
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Postgres is the queue kept in ingest_events. Publishing joins the
+// caller's transaction, so events are queued atomically with whatever else
+// it writes. Each consumer has a cursor per source; a worker leases a
+// source's cursor and works through its events in seq order, so any number
+// of instances can share a consumer name.
+type Postgres struct {
+	repo   *Repo
+	txm    *tx.Manager
+	cfg    Config
+	logger *slog.Logger
+}
+
+// NewPostgres wires the Postgres queue. txm must be unscoped: consumers read
+// every tenant's events. Publishing uses the transaction on the caller's
+// context instead, under its tenant scope.
+func NewPostgres(repo *Repo, txm *tx.Manager, cfg Config, logger *slog.Logger) *Postgres {
+	return &Postgres{repo: repo, txm: txm, cfg: cfg.withDefaults(), logger: logger}
+}
+
+// Publish stores msgs. Run it inside the caller's transaction.
+func (q *Postgres) Publish(ctx context.Context, msgs []Message) error {
+	return q.repo.Insert(ctx, msgs)
+}
+
+// Consume delivers events to h until ctx is cancelled, then waits for the
+// sources in hand to be released. Handler calls are not cancelled with ctx;
+// they are bounded by AckWait instead.
+func (q *Postgres) Consume(ctx context.Context, name string, h Handler) error {
+	return q.consume(ctx, name, Batching{}, func(ctx context.Context, cur model.IngestCursors, logger *slog.Logger) {
+		q.drain(ctx, h, cur, logger)
+	})
+}
+
+// ConsumeBatches delivers events to h in batches of up to b.Size events of
+// one source until ctx is cancelled. A source's events wait for its batch to
+// fill for up to b.Linger.
+func (q *Postgres) ConsumeBatches(ctx context.Context, name string, b Batching, h BatchHandler) error {
+	if b.Size <= 0 {
+		b.Size = q.cfg.BatchSize
+	}
+	return q.consume(ctx, name, b, func(ctx context.Context, cur model.IngestCursors, logger *slog.Logger) {
+		q.drainBatch(ctx, h, b.Size, cur, logger)
+	})
+}
+
+// consume claims sources for drain until ctx is cancelled, then waits for the
+// sources in hand to be released.
+func (q *Postgres) consume(
+	ctx context.Context,
+	name string,
+	fill Batching,
+	drain func(ctx context.Context, cur model.IngestCursors, logger *slog.Logger),
+) error {
+	logger := q.logger.With(slog.String("consumer", name))
+	logger.Info("queue consumer started", slog.String("backend", "postgres"))
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	slots := make(chan struct{}, q.cfg.Concurrency)
+	poll := time.NewTicker(q.cfg.PollInterval)
+	defer poll.Stop()
+
+	for {
+		if err := q.repo.EnsureCursors(ctx, name); err != nil && ctx.Err() == nil {
+			logger.Error("failed to create cursors", slog.Any("err", err))
+		}
+
+		// Keep claiming while sources come back, so a backlog drains without
+		// waiting a poll interval per batch.
+		for ctx.Err() == nil {
+			free := q.cfg.Concurrency - len(slots)
+			if free == 0 {
+				break
+			}
+			var claimed []model.IngestCursors
+			err := q.txm.RunInTx(ctx, nil, func(ctx context.Context) error {
+				var err error
+				claimed, err = q.repo.Claim(ctx, name, free, q.lease(), fill)
+				return err
+			})
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Error("failed to claim sources", slog.Any("err", err))
+				}
+				break
+			}
+			for _, cur := range claimed {
+				slots <- struct{}{}
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					defer func() { <-slots }()
+					drain(context.WithoutCancel(ctx), cur, logger)
+				}()
+			}
+			if len(claimed) < free {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-poll.C:
+		}
+	}
+}
+
+// lease is how long a claimed cursor is held without progress: long enough
+// for a handler call to time out and its failure to be recorded.
+func (q *Postgres) lease() time.Duration {
+	return 2 * q.cfg.AckWait
+}
+
+// drain delivers a batch of the source's events in order, stopping at the
+// first one to be retried, then releases the cursor.
+func (q *Postgres) drain(ctx context.Context, h Handler, cur model.IngestCursors, logger *slog.Logger) {
+	logger = logger.With(slog.String("source_id", cur.SourceID.String()))
+
+	events, err := q.repo.After(ctx, cur, q.cfg.BatchSize)
+	if err != nil {
+		logger.Error("failed to read queued events", slog.Any("err", err))
+		q.release(ctx, cur, logger)
+		return
+	}
+
+	// The claim counted a delivery of the first event only.
+	attempt := int(cur.Attempts)
+	for _, e := range events {
+		d := delivery(e, attempt)
+		attempt = 1
+
+		var failure error
+		if d.Attempt > q.cfg.MaxDeliver {
+			failure = ErrDeliveryLimit
+		} else {
+			failure = call(ctx, h, d, q.cfg.AckWait)
+		}
+
+		switch {
+		case failure == nil:
+			err = q.repo.Ack(ctx, cur, e.Seq, q.lease())
+		case errors.Is(failure, ErrDeliveryLimit) || DeadLetter(failure, d.Attempt, q.cfg.MaxDeliver):
+			logger.Error("queued event dead-lettered",
+				slog.String("event_id", e.ID.String()), slog.Int("attempt", d.Attempt), slog.Any("err", failure))
+			err = q.txm.RunInTx(ctx, nil, func(ctx context.Context) error {
+				if err := q.repo.Bury(ctx, cur, e, d.Attempt, failure); err != nil {
+					return err
+				}
+				return q.repo.Ack(ctx, cur, e.Seq, q.lease())
+			})
+		default:
+			retryAt := time.Now().Add(Backoff(d.Attempt))
+			logger.Warn("queued event failed, will retry",
+				slog.String("event_id", e.ID.String()), slog.Int("attempt", d.Attempt),
+				slog.Any("err", failure), slog.Time("retry_at", retryAt))
+			if err := q.repo.Retry(ctx, cur, d.Attempt, failure, retryAt); err != nil {
+				logger.Error("failed to record failed delivery", slog.Any("err", err))
+			}
+			return
+		}
+		if err != nil {
+			if !errors.Is(err, errLeaseLost) {
+				logger.Error("failed to acknowledge queued event", slog.Any("err", err))
+			}
+			return
+		}
+	}
+	q.release(ctx, cur, logger)
+}
+
+// drainBatch delivers the source's next batch of events and settles it as a
+// whole, then releases the cursor.
+func (q *Postgres) drainBatch(
+	ctx context.Context,
+	h BatchHandler,
+	size int,
+	cur model.IngestCursors,
+	logger *slog.Logger,
+) {
+	logger = logger.With(slog.String("source_id", cur.SourceID.String()))
+
+	events, err := q.repo.After(ctx, cur, size)
+	if err != nil || len(events) == 0 {
+		if err != nil {
+			logger.Error("failed to read queued events", slog.Any("err", err))
+		}
+		q.release(ctx, cur, logger)
+		return
+	}
+
+	attempt := int(cur.Attempts)
+	ds := make([]Delivery, len(events))
+	for i, e := range events {
+		ds[i] = delivery(e, attempt)
+	}
+	var failure error
+	if attempt > q.cfg.MaxDeliver {
+		failure = ErrDeliveryLimit
+	} else {
+		failure = call(ctx, h, ds, q.cfg.AckWait)
+	}
+
+	last := events[len(events)-1].Seq
+	logger = logger.With(slog.Int("events", len(events)), slog.Int("attempt", attempt))
+	switch {
+	case failure == nil:
+		err = q.repo.Ack(ctx, cur, last, q.lease())
+	case errors.Is(failure, ErrDeliveryLimit) || DeadLetter(failure, attempt, q.cfg.MaxDeliver):
+		logger.Error("queued batch dead-lettered", slog.Any("err", failure))
+		err = q.txm.RunInTx(ctx, nil, func(ctx context.Context) error {
+			for _, e := range events {
+				if err := q.repo.Bury(ctx, cur, e, attempt, failure); err != nil {
+					return err
+				}
+			}
+			return q.repo.Ack(ctx, cur, last, q.lease())
+		})
+	default:
+		retryAt := time.Now().Add(Backoff(attempt))
+		logger.Warn("queued batch failed, will retry", slog.Any("err", failure), slog.Time("retry_at", retryAt))
+		if err := q.repo.Retry(ctx, cur, attempt, failure, retryAt); err != nil {
+			logger.Error("failed to record failed delivery", slog.Any("err", err))
+		}
+		return
+	}
+	if err != nil {
+		if !errors.Is(err, errLeaseLost) {
+			logger.Error("failed to acknowledge queued batch", slog.Any("err", err))
+		}
+		return
+	}
+	q.release(ctx, cur, logger)
+}
+
+// delivery turns a queued event into its delivery.
+func delivery(e model.IngestEvents, attempt int) Delivery {
+	return Delivery{
+		Message: Message{
+			ID:           e.ID,
+			OrgID:        e.OrgID,
+			SourceID:     e.SourceID,
+			Payload:      json.RawMessage(e.Payload),
+			Time:         e.Time,
+			TimeStatus:   e.TimeStatus,
+			ReceivedTime: e.ReceivedTime,
+		},
+		Seq:     e.Seq,
+		Attempt: attempt,
+	}
+}
+
+func (q *Postgres) release(ctx context.Context, cur model.IngestCursors, logger *slog.Logger) {
+	if err := q.repo.Release(ctx, cur); err != nil && !errors.Is(err, errLeaseLost) {
+		logger.Error("failed to release cursor", slog.Any("err", err))
+	}
+}
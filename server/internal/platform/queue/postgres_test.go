@@ -0,0 +1,343 @@
+package queue_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+type fixture struct {
+	db    *sql.DB
+	q     *queue.Postgres
+	repo  *queue.Repo
+	orgID uuid.UUID
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	var orgID uuid.UUID
+	if err := db.QueryRowContext(context.Background(),
+		`INSERT INTO organizations (name, slug) VALUES ('Queue Org', 'queue-org') RETURNING id`,
+	).Scan(&orgID); err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	repo := queue.NewRepo(db)
+	q := queue.NewPostgres(
+		repo,
+		tx.NewUnscopedManager(db),
+		queue.Config{MaxDeliver: 3, PollInterval: 10 * time.Millisecond, AckWait: time.Second},
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	return fixture{db: db, q: q, repo: repo, orgID: orgID}
+}
+
+func (f fixture) source(t *testing.T, name string) uuid.UUID {
+	t.Helper()
+	var id uuid.UUID
+	if err := f.db.QueryRowContext(context.Background(),
+		`INSERT INTO sources (org_id, name, type, token_hash, token_prefix)
+		 VALUES ($1, $2, 'http-json', $2, 'hzn_src_') RETURNING id`,
+		f.orgID, name,
+	).Scan(&id); err != nil {
+		t.Fatalf("seed source: %v", err)
+	}
+	return id
+}
+
+// publish queues n messages for the source whose payloads number them from 0.
+func (f fixture) publish(t *testing.T, sourceID uuid.UUID, n int) {
+	t.Helper()
+	now := time.Now()
+	msgs := make([]queue.Message, n)
+	for i := range msgs {
+		msgs[i] = queue.Message{
+			ID:           uuid.New(),
+			OrgID:        f.orgID,
+			SourceID:     sourceID,
+			Payload:      json.RawMessage(fmt.Sprintf(`{"n":%d}`, i)),
+			Time:         now,
+			TimeStatus:   "missing",
+			ReceivedTime: now,
+		}
+	}
+	if err := f.q.Publish(context.Background(), msgs); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+}
+
+func number(d queue.Delivery) int {
+	var p struct{ N int }
+	_ = json.Unmarshal(d.Payload, &p)
+	return p.N
+}
+
+// consumeUntil runs a consumer until cond holds or the deadline passes.
+func (f fixture) consumeUntil(t *testing.T, name string, h queue.Handler, cond func() bool) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = f.q.Consume(ctx, name, h)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// recorder collects what a handler saw, per source.
+type recorder struct {
+	mu   sync.Mutex
+	seen map[uuid.UUID][]int
+}
+
+func (r *recorder) add(d queue.Delivery) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen == nil {
+		r.seen = map[uuid.UUID][]int{}
+	}
+	r.seen[d.SourceID] = append(r.seen[d.SourceID], number(d))
+}
+
+func (r *recorder) get(source uuid.UUID) []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.seen[source])
+}
+
+func TestPostgres_DeliversEachSourceInOrder(t *testing.T) {
+	f := newFixture(t)
+	a, b := f.source(t, "a"), f.source(t, "b")
+	f.publish(t, a, 250)
+	f.publish(t, b, 40)
+
+	var rec recorder
+	f.consumeUntil(t, "normalizer", func(_ context.Context, d queue.Delivery) error {
+		rec.add(d)
+		return nil
+	}, func() bool { return len(rec.get(a)) == 250 && len(rec.get(b)) == 40 })
+
+	for _, src := range []uuid.UUID{a, b} {
+		got := rec.get(src)
+		for i, n := range got {
+			if n != i {
+				t.Fatalf("source %s: out of order at %d: %v", src, i, got[max(i-3, 0):i+1])
+			}
+		}
+	}
+}
+
+func TestPostgres_RetriesBeforeLaterEvents(t *testing.T) {
+	f := newFixture(t)
+	src := f.source(t, "a")
+	f.publish(t, src, 3)
+
+	var rec recorder
+	var mu sync.Mutex
+	attempts := map[int][]int{}
+	f.consumeUntil(t, "normalizer", func(_ context.Context, d queue.Delivery) error {
+		mu.Lock()
+		attempts[number(d)] = append(attempts[number(d)], d.Attempt)
+		mu.Unlock()
+		if number(d) == 1 && d.Attempt == 1 {
+			return errors.New("transient")
+		}
+		rec.add(d)
+		return nil
+	}, func() bool { return len(rec.get(src)) == 3 })
+
+	if got := rec.get(src); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("order: want [0 1 2], got %v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(attempts[1], []int{1, 2}) || !slices.Equal(attempts[2], []int{1}) {
+		t.Errorf("attempts: got %v", attempts)
+	}
+}
+
+func TestPostgres_DeadLettersPoisonEvents(t *testing.T) {
+	f := newFixture(t)
+	src := f.source(t, "a")
+	f.publish(t, src, 3)
+
+	var rec recorder
+	f.consumeUntil(t, "normalizer", func(_ context.Context, d queue.Delivery) error {
+		if number(d) == 0 {
+			return queue.Permanent(errors.New("unmappable"))
+		}
+		rec.add(d)
+		return nil
+	}, func() bool { return len(rec.get(src)) == 2 })
+
+	var consumer, reason string
+	var attempts int
+	if err := f.db.QueryRowContext(context.Background(),
+		`SELECT consumer, attempts, error FROM ingest_dead_letters WHERE source_id = $1`, src,
+	).Scan(&consumer, &attempts, &reason); err != nil {
+		t.Fatalf("reading dead letter: %v", err)
+	}
+	if consumer != "normalizer" || attempts != 1 || reason != "unmappable" {
+		t.Errorf("dead letter: got %s / %d / %s", consumer, attempts, reason)
+	}
+}
+
+func TestPostgres_ConsumersProgressIndependently(t *testing.T) {
+	f := newFixture(t)
+	src := f.source(t, "a")
+	f.publish(t, src, 5)
+
+	var first, second recorder
+	f.consumeUntil(t, "normalizer", func(_ context.Context, d queue.Delivery) error {
+		first.add(d)
+		return nil
+	}, func() bool { return len(first.get(src)) == 5 })
+	f.consumeUntil(t, "archive", func(_ context.Context, d queue.Delivery) error {
+		second.add(d)
+		return nil
+	}, func() bool { return len(second.get(src)) == 5 })
+
+	if len(first.get(src)) != 5 {
+		t.Errorf("normalizer: want no redelivery, got %v", first.get(src))
+	}
+}
+
+// consumeBatchesUntil runs a batch consumer until cond holds or the deadline
+// passes.
+func (f fixture) consumeBatchesUntil(
+	t *testing.T,
+	name string,
+	b queue.Batching,
+	h queue.BatchHandler,
+	cond func() bool,
+) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = f.q.ConsumeBatches(ctx, name, b, h)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met before deadline")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPostgres_BatchesWaitToFill(t *testing.T) {
+	f := newFixture(t)
+	src := f.source(t, "a")
+	f.publish(t, src, 7)
+
+	var mu sync.Mutex
+	var sizes []int
+	var rec recorder
+	f.consumeBatchesUntil(t, "archive", queue.Batching{Size: 5, Linger: time.Hour},
+		func(_ context.Context, ds []queue.Delivery) error {
+			mu.Lock()
+			sizes = append(sizes, len(ds))
+			mu.Unlock()
+			for _, d := range ds {
+				rec.add(d)
+			}
+			return nil
+		}, func() bool { return len(rec.get(src)) == 5 })
+
+	// Wait a few polls: the two events left must keep lingering.
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(sizes, []int{5}) || !slices.Equal(rec.get(src), []int{0, 1, 2, 3, 4}) {
+		t.Errorf("want one full batch of 0-4, got sizes %v, events %v", sizes, rec.get(src))
+	}
+}
+
+func TestPostgres_RetriesWholeBatch(t *testing.T) {
+	f := newFixture(t)
+	src := f.source(t, "a")
+	f.publish(t, src, 3)
+
+	var rec recorder
+	var mu sync.Mutex
+	var attempts []int
+	f.consumeBatchesUntil(t, "archive", queue.Batching{Size: 10},
+		func(_ context.Context, ds []queue.Delivery) error {
+			mu.Lock()
+			attempts = append(attempts, ds[0].Attempt)
+			mu.Unlock()
+			if ds[0].Attempt == 1 {
+				return errors.New("store unavailable")
+			}
+			for _, d := range ds {
+				rec.add(d)
+			}
+			return nil
+		}, func() bool { return len(rec.get(src)) == 3 })
+
+	if got := rec.get(src); !slices.Equal(got, []int{0, 1, 2}) {
+		t.Errorf("order: want [0 1 2], got %v", got)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if !slices.Equal(attempts, []int{1, 2}) {
+		t.Errorf("attempts: want [1 2], got %v", attempts)
+	}
+}
+
+func TestRepo_PruneKeepsUnacknowledgedEvents(t *testing.T) {
+	f := newFixture(t)
+	src := f.source(t, "a")
+	f.publish(t, src, 4)
+
+	var rec recorder
+	f.consumeUntil(t, "normalizer", func(_ context.Context, d queue.Delivery) error {
+		rec.add(d)
+		return nil
+	}, func() bool { return len(rec.get(src)) == 4 })
+
+	ctx := context.Background()
+	future := time.Now().Add(time.Hour)
+	n, err := f.repo.Prune(ctx, []string{"normalizer", "archive"}, future)
+	if err != nil || n != 0 {
+		t.Fatalf("Prune with a consumer behind: want 0, got %d (%v)", n, err)
+	}
+	n, err = f.repo.Prune(ctx, []string{"normalizer"}, future)
+	if err != nil || n != 4 {
+		t.Fatalf("Prune: want 4, got %d (%v)", n, err)
+	}
+}
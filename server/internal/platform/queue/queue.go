@@ -0,0 +1,199 @@
+// Package queue carries raw events from ingestion to the consumers that
+// process them, such as normalization and the archive. Receivers publish
+// with a Producer; each consumer reads every event under its own name, so
+// consumers progress independently.
+//
+// Delivery is at-least-once and ordered per source: a consumer sees the
+// events of one source in publish order, and a failed event is retried,
+// with backoff, before any later event of its source. An event that fails
+// permanently or on every allowed delivery goes to the consumer's dead
+// letters and the source moves on. Handlers deduplicate on Message.ID.
+//
+// A consumer takes messages one at a time with Consume, or in batches with
+// ConsumeBatches for work that pays per call, such as writing files.
+//
+// Two backends implement the queue: Postgres, the ingest_events table, for
+// single-node installs, and NATS JetStream.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Message is a raw event as queued.
+type Message struct {
+	ID       uuid.UUID `json:"id"`
+	OrgID    uuid.UUID `json:"org_id"`
+	SourceID uuid.UUID `json:"source_id"`
+	// Payload is the event as received, a JSON object.
+	Payload json.RawMessage `json:"payload"`
+	// Time is when the event happened as extracted at ingest, and TimeStatus
+	// where it came from; see package eventtime.
+	Time         time.Time `json:"time"`
+	TimeStatus   string    `json:"time_status"`
+	ReceivedTime time.Time `json:"received_time"`
+}
+
+// Delivery is a message handed to a consumer.
+type Delivery struct {
+	Message
+	// Seq orders the source's messages; later messages have higher values.
+	Seq int64
+	// Attempt is 1 on the first delivery and counts up with each retry.
+	Attempt int
+}
+
+// Handler processes one delivery. Returning nil acknowledges it; an error
+// has it retried, unless wrapped with Permanent.
+type Handler func(ctx context.Context, d Delivery) error
+
+// BatchHandler processes consecutive deliveries in order: of one source with
+// Postgres, of the sources sharing a partition with JetStream. Returning nil
+// acknowledges all of them; an error has the whole batch retried, or
+// dead-lettered when wrapped with Permanent or on its last delivery.
+type BatchHandler func(ctx context.Context, ds []Delivery) error
+
+// Batching tunes a batch consumer.
+type Batching struct {
+	// Size bounds the deliveries in one batch.
+	Size int
+	// Linger is how long a consumer waits for a batch to fill before taking
+	// what there is.
+	Linger time.Duration
+}
+
+// Producer publishes messages. Messages of one source keep the order they
+// are published in.
+type Producer interface {
+	Publish(ctx context.Context, msgs []Message) error
+}
+
+// Consumer delivers every message to h under the durable consumer name,
+// until ctx is cancelled. A consumer with a new name starts from the oldest
+// retained message. Names are letters, digits, '-' and '_', and must stay
+// stable across restarts.
+type Consumer interface {
+	Consume(ctx context.Context, name string, h Handler) error
+}
+
+// BatchConsumer is a Consumer that hands messages over in batches. Attempt
+// counts the deliveries of the batch, the same for each of its messages.
+type BatchConsumer interface {
+	ConsumeBatches(ctx context.Context, name string, b Batching, h BatchHandler) error
+}
+
+// Queue is a backend: a Producer and consumers sharing one stream.
+type Queue interface {
+	Producer
+	Consumer
+	BatchConsumer
+}
+
+// Config tunes a backend's consumers. Zero fields fall back to the defaults
+// below.
+type Config struct {
+	// MaxDeliver bounds the deliveries of one message before it goes to the
+	// dead letters.
+	MaxDeliver int
+	// AckWait bounds a handler call. A message not acknowledged in time is
+	// delivered again, which is how a crashed consumer's work is picked up.
+	AckWait time.Duration
+	// Concurrency is the number of sources handled at once (Postgres; with
+	// JetStream every partition is handled at once).
+	Concurrency int
+	// BatchSize bounds the messages of one source taken per claim (Postgres).
+	BatchSize int
+	// PollInterval is how often an idle consumer looks for messages
+	// (Postgres).
+	PollInterval time.Duration
+	// Partitions is the number of ordered lanes sources are spread over
+	// (JetStream). Changing it reorders sources that move lane, so set it
+	// once.
+	Partitions int
+}
+
+const (
+	defaultMaxDeliver   = 5
+	defaultAckWait      = time.Minute
+	defaultConcurrency  = 4
+	defaultBatchSize    = 100
+	defaultPollInterval = time.Second
+	defaultPartitions   = 8
+)
+
+func (c Config) withDefaults() Config {
+	if c.MaxDeliver <= 0 {
+		c.MaxDeliver = defaultMaxDeliver
+	}
+	if c.AckWait <= 0 {
+		c.AckWait = defaultAckWait
+	}
+	if c.Concurrency <= 0 {
+		c.Concurrency = defaultConcurrency
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultBatchSize
+	}
+	if c.PollInterval <= 0 {
+		c.PollInterval = defaultPollInterval
+	}
+	if c.Partitions <= 0 {
+		c.Partitions = defaultPartitions
+	}
+	return c
+}
+
+// ErrDeliveryLimit is the dead-letter reason for a message whose earlier
+// deliveries never completed, such as one that crashes its consumer.
+var ErrDeliveryLimit = errors.New("delivery limit exceeded")
+
+// permanentError marks a handler failure that must not be retried.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent wraps err so the message goes straight to the dead letters, for
+// failures such as a payload no retry can fix.
+func Permanent(err error) error {
+	return permanentError{err: err}
+}
+
+// DeadLetter reports whether a delivery that failed with err on the given
+// attempt is given up on rather than retried.
+func DeadLetter(err error, attempt, maxDeliver int) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent) || attempt >= maxDeliver
+}
+
+const maxBackoff = 5 * time.Minute
+
+// Backoff returns the delay before redelivering a message that has failed
+// attempt times: 2^(attempt-1) seconds plus up to 10% jitter, capped at five
+// minutes. Retries hold up the rest of the source, so they stay short.
+func Backoff(attempt int) time.Duration {
+	d := maxBackoff
+	if attempt < 10 {
+		d = min(time.Duration(1<<max(attempt-1, 0))*time.Second, maxBackoff)
+	}
+	return d + rand.N(d/10+1) //nolint:gosec // jitter does not need a CSPRNG
+}
+
+// call invokes h, bounded by timeout and turning a panic into an error.
+func call[T any](ctx context.Context, h func(context.Context, T) error, v T, timeout time.Duration) (err error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	return h(ctx, v)
+}
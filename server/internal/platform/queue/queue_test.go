@@ -0,0 +1,51 @@
+package queue_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/internal/platform/queue"
+)
+
+func TestBackoff_GrowsAndIsCapped(t *testing.T) {
+	cases := []struct {
+		attempt int
+		min     time.Duration
+	}{
+		{attempt: 1, min: time.Second},
+		{attempt: 4, min: 8 * time.Second},
+		{attempt: 9, min: 256 * time.Second},
+		{attempt: 40, min: 5 * time.Minute},
+	}
+	for _, c := range cases {
+		got := queue.Backoff(c.attempt)
+		if got < c.min || got > c.min+c.min/10+1 {
+			t.Errorf("Backoff(%d): want within 10%% above %v, got %v", c.attempt, c.min, got)
+		}
+	}
+}
+
+func TestDeadLetter(t *testing.T) {
+	transient := errors.New("store unavailable")
+	cases := []struct {
+		name    string
+		err     error
+		attempt int
+		want    bool
+	}{
+		{"retried", transient, 1, false},
+		{"last delivery", transient, 5, true},
+		{"permanent", queue.Permanent(transient), 1, true},
+		{"wrapped permanent", fmt.Errorf("mapping: %w", queue.Permanent(transient)), 1, true},
+	}
+	for _, c := range cases {
+		if got := queue.DeadLetter(c.err, c.attempt, 5); got != c.want {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, got)
+		}
+	}
+	if !errors.Is(queue.Permanent(transient), transient) {
+		t.Error("Permanent: want the cause to unwrap")
+	}
+}
@@ -0,0 +1,320 @@
+package queue
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// insertChunk bounds the rows per INSERT, keeping each statement well under
+// Postgres' 65535 bind parameters.
+const insertChunk = 1000
+
+// errLeaseLost is returned when a cursor's lease expired and another worker
+// claimed it, so this worker must stop working on the source.
+var errLeaseLost = errors.New("cursor lease lost")
+
+// Repo owns the SQL of the Postgres queue: ingest_events, the consumers'
+// ingest_cursors and their ingest_dead_letters. Statements join the
+// transaction on ctx, if any.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+// ── Producing ────────────────────────────────────────────────────────────────
+
+// Insert stores msgs in order. It takes a per-source lock for the rest of the
+// transaction on ctx first, so concurrent publishers to one source commit in
+// seq order and a consumer never acknowledges past an event still to commit.
+func (r *Repo) Insert(ctx context.Context, msgs []Message) error {
+	if len(msgs) == 0 {
+		return nil
+	}
+	sources := make([]uuid.UUID, 0, 1)
+	for _, m := range msgs {
+		if !slices.Contains(sources, m.SourceID) {
+			sources = append(sources, m.SourceID)
+		}
+	}
+	// a fixed order keeps publishers to the same sources from deadlocking
+	slices.SortFunc(sources, func(a, b uuid.UUID) int { return bytes.Compare(a[:], b[:]) })
+	for _, id := range sources {
+		_, err := tx.Executor(ctx, r.db).ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", sourceLockKey(id))
+		if err != nil {
+			return fmt.Errorf("locking source queue: %w", err)
+		}
+	}
+
+	t := table.IngestEvents
+	for start := 0; start < len(msgs); start += insertChunk {
+		chunk := msgs[start:min(start+insertChunk, len(msgs))]
+
+		stmt := t.INSERT(t.ID, t.OrgID, t.SourceID, t.Payload, t.Time, t.TimeStatus, t.ReceivedTime)
+		for _, m := range chunk {
+			stmt = stmt.VALUES(m.ID, m.OrgID, m.SourceID, string(m.Payload), m.Time, m.TimeStatus, m.ReceivedTime)
+		}
+		if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+			return fmt.Errorf("enqueueing events: %w", err)
+		}
+	}
+	return nil
+}
+
+// sourceLockKey derives the advisory lock key of a source's queue.
+func sourceLockKey(id uuid.UUID) int64 {
+	return int64(binary.BigEndian.Uint64(id[:8])) //nolint:gosec // any 64 bits will do
+}
+
+// ── Consuming ────────────────────────────────────────────────────────────────
+
+// EnsureCursors gives consumer a cursor, at the start, for every source that
+// has none yet.
+func (r *Repo) EnsureCursors(ctx context.Context, consumer string) error {
+	c := table.IngestCursors
+	s := table.Sources
+	stmt := c.
+		INSERT(c.Consumer, c.SourceID).
+		QUERY(postgres.SELECT(postgres.String(consumer), s.ID).FROM(s)).
+		ON_CONFLICT(c.Consumer, c.SourceID).
+		DO_NOTHING()
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("creating cursors: %w", err)
+	}
+	return nil
+}
+
+// Claim leases up to limit of consumer's cursors that are due and have events
+// after them, counting a delivery of each one's next event. Cursors leased by
+// concurrent claimers are skipped rather than waited on. With fill.Linger
+// set, a cursor is only due once fill.Size events are waiting or the oldest
+// has waited fill.Linger.
+func (r *Repo) Claim(
+	ctx context.Context,
+	consumer string,
+	limit int,
+	lease time.Duration,
+	fill Batching,
+) ([]model.IngestCursors, error) {
+	c := table.IngestCursors
+	e := table.IngestEvents
+	after := e.SourceID.EQ(c.SourceID).AND(e.Seq.GT(c.AckedSeq))
+	var pending postgres.BoolExpression
+	if fill.Linger <= 0 {
+		pending = postgres.EXISTS(postgres.SELECT(postgres.Int(1)).FROM(e).WHERE(after))
+	} else {
+		waited := e.ReceivedTime.LT_EQ(postgres.NOW().SUB(postgres.INTERVALd(fill.Linger)))
+		pending = postgres.EXISTS(postgres.SELECT(postgres.Int(1)).FROM(e).WHERE(after.AND(waited))).
+			OR(postgres.EXISTS(
+				postgres.
+					SELECT(postgres.Int(1)).
+					FROM(e).
+					WHERE(after).
+					ORDER_BY(e.Seq.ASC()).
+					OFFSET(int64(max(fill.Size-1, 0))).
+					LIMIT(1),
+			))
+	}
+	due := postgres.
+		SELECT(c.SourceID.AS("source_id")).
+		FROM(c).
+		WHERE(
+			c.Consumer.EQ(postgres.String(consumer)).
+				AND(c.AvailableAt.LT_EQ(postgres.NOW())).
+				AND(c.LockedUntil.IS_NULL().OR(c.LockedUntil.LT(postgres.NOW()))).
+				AND(pending),
+		).
+		ORDER_BY(c.AvailableAt.ASC()).
+		LIMIT(int64(limit)).
+		FOR(postgres.UPDATE().SKIP_LOCKED()).
+		AsTable("due")
+	dueSource := postgres.StringColumn("source_id").From(due)
+
+	stmt := c.
+		UPDATE(c.Attempts, c.LeaseID, c.LockedUntil, c.UpdatedAt).
+		SET(
+			c.Attempts.ADD(postgres.Int(1)),
+			postgres.UUID(uuid.New()),
+			postgres.NOW().ADD(postgres.INTERVALd(lease)),
+			postgres.NOW(),
+		).
+		FROM(due).
+		WHERE(c.Consumer.EQ(postgres.String(consumer)).AND(c.SourceID.EQ(dueSource))).
+		RETURNING(c.AllColumns)
+
+	var rows []model.IngestCursors
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("claiming cursors: %w", err)
+	}
+	return rows, nil
+}
+
+// After returns up to limit of the source's events following the cursor, in
+// order.
+func (r *Repo) After(ctx context.Context, cur model.IngestCursors, limit int) ([]model.IngestEvents, error) {
+	e := table.IngestEvents
+	stmt := postgres.
+		SELECT(e.AllColumns).
+		FROM(e).
+		WHERE(e.SourceID.EQ(postgres.UUID(cur.SourceID)).AND(e.Seq.GT(postgres.Int(cur.AckedSeq)))).
+		ORDER_BY(e.Seq.ASC()).
+		LIMIT(int64(limit))
+
+	var rows []model.IngestEvents
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("reading queued events: %w", err)
+	}
+	return rows, nil
+}
+
+// leased matches the cursor only while the lease it was claimed with holds.
+func leased(cur model.IngestCursors) postgres.BoolExpression {
+	c := table.IngestCursors
+	return c.Consumer.EQ(postgres.String(cur.Consumer)).
+		AND(c.SourceID.EQ(postgres.UUID(cur.SourceID))).
+		AND(c.LeaseID.EQ(postgres.UUID(*cur.LeaseID)))
+}
+
+// exec runs stmt against the leased cursor, returning errLeaseLost when the
+// lease no longer holds.
+func (r *Repo) exec(ctx context.Context, stmt postgres.Statement, what string) error {
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("%s: %w", what, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return errLeaseLost
+	}
+	return nil
+}
+
+// Ack moves the cursor past seq and extends its lease for the next event.
+func (r *Repo) Ack(ctx context.Context, cur model.IngestCursors, seq int64, lease time.Duration) error {
+	c := table.IngestCursors
+	stmt := c.
+		UPDATE(c.AckedSeq, c.Attempts, c.LockedUntil, c.LastError, c.UpdatedAt).
+		SET(
+			postgres.Int(seq),
+			postgres.Int(0),
+			postgres.NOW().ADD(postgres.INTERVALd(lease)),
+			postgres.StringExp(postgres.NULL),
+			postgres.NOW(),
+		).
+		WHERE(leased(cur))
+	return r.exec(ctx, stmt, "acknowledging event")
+}
+
+// Retry records a failed delivery of the cursor's next event and releases the
+// cursor until retryAt.
+func (r *Repo) Retry(
+	ctx context.Context,
+	cur model.IngestCursors,
+	attempts int,
+	cause error,
+	retryAt time.Time,
+) error {
+	c := table.IngestCursors
+	stmt := c.
+		UPDATE(c.Attempts, c.AvailableAt, c.LastError, c.LeaseID, c.LockedUntil, c.UpdatedAt).
+		SET(
+			postgres.Int(int64(attempts)),
+			postgres.TimestampzT(retryAt),
+			postgres.String(cause.Error()),
+			postgres.StringExp(postgres.NULL),
+			postgres.TimestampzExp(postgres.NULL),
+			postgres.NOW(),
+		).
+		WHERE(leased(cur))
+	return r.exec(ctx, stmt, "recording failed delivery")
+}
+
+// Release gives up the cursor's lease, leaving it due.
+func (r *Repo) Release(ctx context.Context, cur model.IngestCursors) error {
+	c := table.IngestCursors
+	stmt := c.
+		UPDATE(c.LeaseID, c.LockedUntil, c.UpdatedAt).
+		SET(postgres.StringExp(postgres.NULL), postgres.TimestampzExp(postgres.NULL), postgres.NOW()).
+		WHERE(leased(cur))
+	return r.exec(ctx, stmt, "releasing cursor")
+}
+
+// Bury stores the event as a dead letter of the cursor's consumer. Run it in
+// the transaction that acknowledges the event.
+func (r *Repo) Bury(
+	ctx context.Context,
+	cur model.IngestCursors,
+	e model.IngestEvents,
+	attempts int,
+	cause error,
+) error {
+	d := table.IngestDeadLetters
+	stmt := d.
+		INSERT(
+			d.Consumer, d.EventID, d.OrgID, d.SourceID, d.Payload,
+			d.Time, d.TimeStatus, d.ReceivedTime, d.Attempts, d.Error,
+		).
+		VALUES(
+			cur.Consumer, e.ID, e.OrgID, e.SourceID, e.Payload,
+			e.Time, e.TimeStatus, e.ReceivedTime, attempts, cause.Error(),
+		)
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("storing dead letter: %w", err)
+	}
+	return nil
+}
+
+// ── Retention ────────────────────────────────────────────────────────────────
+
+// Prune deletes events received before cutoff that every one of consumers has
+// acknowledged; with no consumers, every event received before cutoff.
+// Returns the number of events deleted.
+func (r *Repo) Prune(ctx context.Context, consumers []string, cutoff time.Time) (int64, error) {
+	e := table.IngestEvents
+	cond := e.ReceivedTime.LT(postgres.TimestampzT(cutoff))
+	if len(consumers) > 0 {
+		names := make([]postgres.Expression, 0, len(consumers))
+		for _, name := range consumers {
+			if err := r.EnsureCursors(ctx, name); err != nil {
+				return 0, err
+			}
+			names = append(names, postgres.String(name))
+		}
+		c := table.IngestCursors
+		acked := postgres.
+			SELECT(postgres.MINi(c.AckedSeq)).
+			FROM(c).
+			WHERE(c.SourceID.EQ(e.SourceID).AND(c.Consumer.IN(names...)))
+		cond = cond.AND(e.Seq.LT_EQ(postgres.IntExp(acked)))
+	}
+
+	res, err := e.DELETE().WHERE(cond).ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return 0, fmt.Errorf("pruning queued events: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PruneArgs is the job that prunes acknowledged events from the Postgres
+// queue.
+type PruneArgs struct{}
+
+// Kind implements jobs.Args.
+func (PruneArgs) Kind() string { return "queue.prune" }
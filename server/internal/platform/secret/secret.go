@@ -0,0 +1,106 @@
+// Package secret encrypts the credentials organisations store for the
+// services Horizon connects to on their behalf, such as threat feeds. A Box
+// holds the deployment's master key and derives a key per organisation from
+// it with HKDF-SHA256; values are sealed with AES-256-GCM under that key and
+// bound to the organisation, so a value copied to another organisation's row
+// does not open.
+package secret
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// KeySize is the length of a master key in bytes.
+const KeySize = 32
+
+// version prefixes sealed values, so the scheme can change without losing
+// what was sealed before.
+const version byte = 1
+
+// ErrCorrupt is returned when a sealed value does not open: it was tampered
+// with, sealed for another organisation or under another master key.
+var ErrCorrupt = errors.New("sealed value does not open")
+
+// Box seals and opens values with keys derived from a master key.
+type Box struct {
+	master []byte
+}
+
+// NewBox returns a Box over a KeySize-byte master key.
+func NewBox(master []byte) (*Box, error) {
+	if len(master) != KeySize {
+		return nil, fmt.Errorf("master key is %d bytes, want %d", len(master), KeySize)
+	}
+	return &Box{master: master}, nil
+}
+
+// ParseKey decodes a base64-encoded master key, as kept in the environment.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("decoding master key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("master key is %d bytes, want %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// Seal encrypts plaintext for the organisation.
+func (b *Box) Seal(orgID uuid.UUID, plaintext []byte) ([]byte, error) {
+	aead, err := b.aead(orgID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]byte, 1+aead.NonceSize(), 1+aead.NonceSize()+len(plaintext)+aead.Overhead())
+	out[0] = version
+	nonce := out[1:]
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("generating nonce: %w", err)
+	}
+	return aead.Seal(out, nonce, plaintext, additionalData(orgID)), nil
+}
+
+// Open decrypts a value Seal returned for the organisation. Returns ErrCorrupt
+// when it does not open.
+func (b *Box) Open(orgID uuid.UUID, sealed []byte) ([]byte, error) {
+	aead, err := b.aead(orgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < 1+aead.NonceSize()+aead.Overhead() || sealed[0] != version {
+		return nil, ErrCorrupt
+	}
+	nonce, ciphertext := sealed[1:1+aead.NonceSize()], sealed[1+aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, additionalData(orgID))
+	if err != nil {
+		return nil, ErrCorrupt
+	}
+	return plaintext, nil
+}
+
+// aead derives the organisation's key.
+func (b *Box) aead(orgID uuid.UUID) (cipher.AEAD, error) {
+	key, err := hkdf.Key(sha256.New, b.master, nil, "horizon credentials "+orgID.String(), KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving key: %w", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("creating cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func additionalData(orgID uuid.UUID) []byte {
+	return append([]byte{version}, orgID[:]...)
+}
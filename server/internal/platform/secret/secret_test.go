@@ -0,0 +1,89 @@
+package secret_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/secret"
+)
+
+func newBox(t *testing.T, fill byte) *secret.Box {
+	t.Helper()
+	box, err := secret.NewBox(bytes.Repeat([]byte{fill}, secret.KeySize))
+	if err != nil {
+		t.Fatalf("NewBox: %v", err)
+	}
+	return box
+}
+
+func TestSealOpen_RoundTrips(t *testing.T) {
+	box := newBox(t, 1)
+	orgID := uuid.New()
+
+	sealed, err := box.Seal(orgID, []byte("s3cret"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Contains(sealed, []byte("s3cret")) {
+		t.Errorf("sealed value contains the plaintext: %x", sealed)
+	}
+	again, err := box.Seal(orgID, []byte("s3cret"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	if bytes.Equal(sealed, again) {
+		t.Error("sealing twice gave the same value")
+	}
+
+	got, err := box.Open(orgID, sealed)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if string(got) != "s3cret" {
+		t.Errorf("Open: got %q, want %q", got, "s3cret")
+	}
+}
+
+func TestOpen_RejectsValuesNotSealedForTheOrg(t *testing.T) {
+	box := newBox(t, 1)
+	orgID := uuid.New()
+	sealed, err := box.Seal(orgID, []byte("s3cret"))
+	if err != nil {
+		t.Fatalf("Seal: %v", err)
+	}
+	tampered := bytes.Clone(sealed)
+	tampered[len(tampered)-1] ^= 1
+
+	tests := map[string]struct {
+		box    *secret.Box
+		orgID  uuid.UUID
+		sealed []byte
+	}{
+		"other org":        {box, uuid.New(), sealed},
+		"other master key": {newBox(t, 2), orgID, sealed},
+		"tampered":         {box, orgID, tampered},
+		"truncated":        {box, orgID, sealed[:10]},
+		"unknown version":  {box, orgID, append([]byte{9}, sealed[1:]...)},
+	}
+	for name, tt := range tests {
+		if _, err := tt.box.Open(tt.orgID, tt.sealed); !errors.Is(err, secret.ErrCorrupt) {
+			t.Errorf("%s: got %v, want ErrCorrupt", name, err)
+		}
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	valid := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, secret.KeySize))
+	if key, err := secret.ParseKey(valid); err != nil || len(key) != secret.KeySize {
+		t.Errorf("ParseKey(valid): got %d bytes, %v", len(key), err)
+	}
+	for _, s := range []string{"not base64!", base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := secret.ParseKey(s); err == nil {
+			t.Errorf("ParseKey(%q): want an error", s)
+		}
+	}
+}
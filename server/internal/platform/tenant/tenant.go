@@ -0,0 +1,67 @@
+// Package tenant carries the caller's tenant scope through a request and
+// applies it to Postgres transactions, where row-level security policies use
+// it to hide rows of other organisations. tx.Manager applies the scope to every
+// transaction it starts; a context without a scope sees no rows.
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// AppRole is the database role request transactions switch to. It is subject
+// to the RLS policies created in migration 00005.
+const AppRole = "horizon_app"
+
+// Scope identifies whose data a transaction may see. Either field may be
+// uuid.Nil; at least one must be set for any org-scoped row to be visible.
+type Scope struct {
+	UserID uuid.UUID
+	OrgID  uuid.UUID
+}
+
+type scopeKey struct{}
+
+// WithUser returns a copy of ctx scoped to the given user, keeping any org.
+func WithUser(ctx context.Context, userID uuid.UUID) context.Context {
+	s := FromContext(ctx)
+	s.UserID = userID
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// WithOrg returns a copy of ctx narrowed to the given org, keeping any user.
+func WithOrg(ctx context.Context, orgID uuid.UUID) context.Context {
+	s := FromContext(ctx)
+	s.OrgID = orgID
+	return context.WithValue(ctx, scopeKey{}, s)
+}
+
+// FromContext returns the scope stored on ctx, or the zero Scope.
+func FromContext(ctx context.Context) Scope {
+	s, _ := ctx.Value(scopeKey{}).(Scope)
+	return s
+}
+
+// Apply switches tx to AppRole and publishes the scope from ctx via
+// transaction-local settings, so both revert when tx ends.
+func Apply(ctx context.Context, tx *sql.Tx) error {
+	s := FromContext(ctx)
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+AppRole); err != nil {
+		return fmt.Errorf("setting tenant role: %w", err)
+	}
+	const q = `SELECT set_config('app.user_id', $1, true), set_config('app.org_id', $2, true)`
+	if _, err := tx.ExecContext(ctx, q, settingValue(s.UserID), settingValue(s.OrgID)); err != nil {
+		return fmt.Errorf("setting tenant scope: %w", err)
+	}
+	return nil
+}
+
+func settingValue(id uuid.UUID) string {
+	if id == uuid.Nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,27 @@
+package tenant_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/tenant"
+)
+
+func TestScopeComposes(t *testing.T) {
+	userID, orgID := uuid.New(), uuid.New()
+
+	if s := tenant.FromContext(context.Background()); s != (tenant.Scope{}) {
+		t.Fatalf("empty context: want zero scope, got %+v", s)
+	}
+
+	ctx := tenant.WithOrg(tenant.WithUser(context.Background(), userID), orgID)
+	s := tenant.FromContext(ctx)
+	if s.UserID != userID {
+		t.Errorf("UserID: want %s, got %s", userID, s.UserID)
+	}
+	if s.OrgID != orgID {
+		t.Errorf("OrgID: want %s, got %s", orgID, s.OrgID)
+	}
+}
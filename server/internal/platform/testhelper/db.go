@@ -17,6 +17,10 @@ var (
 	testDBOnce sync.Once
 	testDB     *sql.DB
 	testDBErr  error
+
+	privDBOnce sync.Once
+	privDB     *sql.DB
+	privDBErr  error
 )
 
 // DB returns a shared *sql.DB connected to the test database specified by
@@ -40,11 +44,34 @@ func DB(t *testing.T) *sql.DB {
 	return testDB
 }
 
+// PrivilegedDB returns a second shared *sql.DB on the test database, standing
+// in for the privileged pool. Code that runs statements outside transactions,
+// as background work on that pool does, needs a pool no tenant-scoped
+// tx.Manager is built on: tx.Executor refuses such statements on DB's once
+// one is.
+func PrivilegedDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set; skipping DB-backed test")
+	}
+	privDBOnce.Do(func() {
+		privDB, privDBErr = sql.Open("postgres", dsn)
+		if privDBErr == nil {
+			privDBErr = privDB.Ping()
+		}
+	})
+	if privDBErr != nil {
+		t.Fatalf("opening privileged test database: %v", privDBErr)
+	}
+	return privDB
+}
+
 // Reset truncates every domain table, restoring a blank slate. Tests are not
 // parallel-safe against this harness: rely on sequential execution.
 func Reset(t *testing.T, db *sql.DB) {
 	t.Helper()
-	const q = `TRUNCATE api_keys, organization_members, organizations, users RESTART IDENTITY CASCADE`
+	const q = `TRUNCATE api_keys, organization_members, organizations, users, idempotency_keys, jobs, outbox_events, outbox_checkpoints, sources, source_stats, ingest_events, ingest_cursors, ingest_dead_letters, grok_patterns, archive_segments, replays, replay_shadow_events, mappings, mapping_versions, mapping_fixtures, content_pack_installs, content_pack_items, threat_indicators, threat_indicator_revisions, taxii_feeds, taxii_collections, entities, entity_sightings, entity_revisions, redaction_policies, redaction_salts, redaction_revisions, redaction_stats, events RESTART IDENTITY CASCADE`
 	if _, err := db.ExecContext(context.Background(), q); err != nil {
 		t.Fatalf("truncating tables: %v", err)
 	}
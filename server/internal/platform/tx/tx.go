@@ -0,0 +1,188 @@
+// Package tx provides the unit of work shared by all repos. Services wrap
+// multi-step operations in Manager.RunInTx; repos run their statements against
+// Executor(ctx, db), which resolves to the transaction carried on ctx when
+// there is one. Outside a transaction, statements on the pool of a tenant
+// scoped Manager are refused, since they would run unscoped; other handles are
+// used as they are. Nested RunInTx calls join the outer transaction, so
+// operations from different domains compose atomically.
+package tx
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/internal/platform/tenant"
+)
+
+const (
+	// maxAttempts bounds how often a transaction is run when Postgres reports
+	// a serialization failure or deadlock.
+	maxAttempts = 3
+	baseBackoff = 10 * time.Millisecond
+)
+
+type txKey struct{}
+
+// ErrNoTx is returned for statements run outside a transaction on the pool of
+// a tenant-scoped Manager. They would run as the pool's role without a tenant
+// scope, which for the table owner means bypassing row-level security.
+var ErrNoTx = errors.New("statement outside a transaction on a tenant-scoped pool")
+
+// scopedPools and unscopedPools hold the pools Managers were built on, by
+// kind. Executor refuses statements outside a transaction on a pool only
+// tenant-scoped Managers use.
+var scopedPools, unscopedPools sync.Map
+
+// Manager starts transactions on a database handle.
+type Manager struct {
+	db       *sql.DB
+	unscoped bool
+}
+
+// NewManager wires a Manager to the given database handle. Its transactions
+// run under the caller's tenant scope.
+func NewManager(db *sql.DB) *Manager {
+	scopedPools.Store(db, struct{}{})
+	return &Manager{db: db}
+}
+
+// NewUnscopedManager wires a Manager whose transactions run as the connecting
+// role without a tenant scope. It is meant for background work on the
+// privileged pool, which acts across tenants.
+func NewUnscopedManager(db *sql.DB) *Manager {
+	unscopedPools.Store(db, struct{}{})
+	return &Manager{db: db, unscoped: true}
+}
+
+// RunInTx runs fn inside a transaction carried on the context passed to fn.
+// Unless the Manager is unscoped, the caller's tenant scope is applied when
+// the transaction starts. The transaction commits when fn returns nil and
+// rolls back otherwise.
+//
+// If ctx already carries a transaction, fn joins it and opts are ignored;
+// commit, rollback and retries are left to the outermost call. At the
+// outermost level, serialization failures and deadlocks rerun fn from the
+// start, so fn must not have side effects outside the database.
+func (m *Manager) RunInTx(
+	ctx context.Context,
+	opts *sql.TxOptions,
+	fn func(ctx context.Context) error,
+) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+
+	var err error
+	for attempt := range maxAttempts {
+		if attempt > 0 {
+			if err := backoff(ctx, attempt); err != nil {
+				return err
+			}
+		}
+		err = m.runOnce(ctx, opts, fn)
+		if !retryable(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("transaction retries exhausted: %w", err)
+}
+
+func (m *Manager) runOnce(
+	ctx context.Context,
+	opts *sql.TxOptions,
+	fn func(ctx context.Context) error,
+) error {
+	tx, err := m.db.BeginTx(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	if !m.unscoped {
+		if err := tenant.Apply(ctx, tx); err != nil {
+			return errors.Join(err, rollback(tx))
+		}
+	}
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		return errors.Join(err, rollback(tx))
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// Executor returns the transaction carried on ctx, or fallback when there is
+// none. Repos use it so the same method works inside and outside RunInTx.
+// When fallback is the pool of a tenant-scoped Manager, and of no unscoped
+// one, statements outside a transaction fail with ErrNoTx instead, so a
+// forgotten RunInTx fails closed.
+func Executor(ctx context.Context, fallback qrm.DB) qrm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	if db, ok := fallback.(*sql.DB); ok && scopedOnly(db) {
+		return refused{}
+	}
+	return fallback
+}
+
+// scopedOnly reports whether db is the pool of a tenant-scoped Manager and of
+// no unscoped one.
+func scopedOnly(db *sql.DB) bool {
+	if _, ok := scopedPools.Load(db); !ok {
+		return false
+	}
+	_, unscoped := unscopedPools.Load(db)
+	return !unscoped
+}
+
+// refused is the executor of statements that must not run: each fails with
+// ErrNoTx.
+type refused struct{}
+
+func (refused) Exec(string, ...any) (sql.Result, error) { return nil, ErrNoTx }
+
+func (refused) ExecContext(context.Context, string, ...any) (sql.Result, error) { return nil, ErrNoTx }
+
+func (refused) Query(string, ...any) (*sql.Rows, error) { return nil, ErrNoTx }
+
+func (refused) QueryContext(context.Context, string, ...any) (*sql.Rows, error) { return nil, ErrNoTx }
+
+// retryable reports whether err is a Postgres serialization failure or
+// deadlock, after which rerunning the whole transaction may succeed.
+func retryable(err error) bool {
+	var pgErr *pq.Error
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == "40001" || pgErr.Code == "40P01"
+}
+
+// backoff sleeps for an exponentially growing, jittered interval.
+func backoff(ctx context.Context, attempt int) error {
+	d := baseBackoff << attempt
+	d += rand.N(d) //nolint:gosec // jitter does not need a CSPRNG
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// rollback aborts tx, ignoring the error from a tx that already finished.
+func rollback(tx *sql.Tx) error {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		return fmt.Errorf("rollback: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package tx_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+func countUsers(t *testing.T, db *sql.DB) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRowContext(context.Background(), `SELECT COUNT(*) FROM users`).Scan(&n); err != nil {
+		t.Fatalf("counting users: %v", err)
+	}
+	return n
+}
+
+func insertUser(ctx context.Context, clerkID string) error {
+	_, err := tx.Executor(ctx, nil).ExecContext(ctx,
+		`INSERT INTO users (clerk_id, email) VALUES ($1, $1 || '@example.com')`, clerkID)
+	return err
+}
+
+func TestRunInTx_CommitsOnSuccess(t *testing.T) {
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	m := tx.NewManager(db)
+
+	err := m.RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		return insertUser(ctx, "user_commit")
+	})
+	if err != nil {
+		t.Fatalf("RunInTx: %v", err)
+	}
+	if n := countUsers(t, db); n != 1 {
+		t.Errorf("want 1 user, got %d", n)
+	}
+}
+
+func TestRunInTx_RollsBackOnError(t *testing.T) {
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	m := tx.NewManager(db)
+	boom := errors.New("boom")
+
+	err := m.RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		if err := insertUser(ctx, "user_rollback"); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("want boom, got %v", err)
+	}
+	if n := countUsers(t, db); n != 0 {
+		t.Errorf("want 0 users after rollback, got %d", n)
+	}
+}
+
+func TestRunInTx_NestedCallJoinsOuter(t *testing.T) {
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	m := tx.NewManager(db)
+	boom := errors.New("boom")
+
+	err := m.RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		outer := tx.Executor(ctx, db)
+		err := m.RunInTx(ctx, nil, func(ctx context.Context) error {
+			if tx.Executor(ctx, db) != outer {
+				t.Error("nested RunInTx: want the outer transaction")
+			}
+			return insertUser(ctx, "user_nested")
+		})
+		if err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("want boom, got %v", err)
+	}
+	if n := countUsers(t, db); n != 0 {
+		t.Errorf("want nested insert rolled back with outer tx, got %d users", n)
+	}
+}
+
+func TestRunInTx_RetriesSerializationFailure(t *testing.T) {
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	m := tx.NewManager(db)
+
+	attempts := 0
+	err := m.RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		attempts++
+		if err := insertUser(ctx, "user_retry"); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RunInTx: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("want 2 attempts, got %d", attempts)
+	}
+	if n := countUsers(t, db); n != 1 {
+		t.Errorf("want 1 user, got %d", n)
+	}
+}
+
+func TestRunInTx_GivesUpAfterRetries(t *testing.T) {
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	m := tx.NewManager(db)
+
+	attempts := 0
+	err := m.RunInTx(context.Background(), nil, func(ctx context.Context) error {
+		attempts++
+		return &pq.Error{Code: "40P01"}
+	})
+	var pgErr *pq.Error
+	if !errors.As(err, &pgErr) || pgErr.Code != "40P01" {
+		t.Fatalf("want wrapped deadlock error, got %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("want 3 attempts, got %d", attempts)
+	}
+}
+
+// openPool returns a pool that is never connected to: sql.Open does not dial.
+func openPool(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("postgres", "host=unused.invalid")
+	if err != nil {
+		t.Fatalf("opening pool: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	return db
+}
+
+func TestExecutor_FallsBackOutsideTxOnUnscopedPools(t *testing.T) {
+	ctx := context.Background()
+	plain := openPool(t)
+	if tx.Executor(ctx, plain) != plain {
+		t.Error("pool without a manager: want the fallback handle")
+	}
+	privileged := openPool(t)
+	tx.NewUnscopedManager(privileged)
+	if tx.Executor(ctx, privileged) != privileged {
+		t.Error("pool of an unscoped manager: want the fallback handle")
+	}
+}
+
+func TestExecutor_RefusesScopedPoolOutsideTx(t *testing.T) {
+	ctx := context.Background()
+	db := openPool(t)
+	tx.NewManager(db)
+
+	if _, err := tx.Executor(ctx, db).ExecContext(ctx, "DELETE FROM users"); !errors.Is(err, tx.ErrNoTx) {
+		t.Errorf("ExecContext: want ErrNoTx, got %v", err)
+	}
+	rows, err := tx.Executor(ctx, db).QueryContext(ctx, "SELECT id FROM users")
+	if !errors.Is(err, tx.ErrNoTx) {
+		t.Errorf("QueryContext: want ErrNoTx, got %v", err)
+	}
+	if rows != nil {
+		_ = rows.Close()
+	}
+}
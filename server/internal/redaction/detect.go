@@ -0,0 +1,90 @@
+package redaction
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// matcher finds what a rule redacts in text: the matches of re, or of its
+// group when it has one, that valid accepts when set.
+type matcher struct {
+	re    *regexp.Regexp
+	group int
+	valid func(string) bool
+}
+
+// detectors are the built-in matchers, by detector.
+var detectors = map[oapi.RedactionDetector][]matcher{
+	oapi.Email: {{
+		re: regexp.MustCompile(
+			`[A-Za-z0-9._%+-]+@[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?(?:\.[A-Za-z0-9](?:[A-Za-z0-9-]*[A-Za-z0-9])?)*\.[A-Za-z]{2,}`,
+		),
+	}},
+	// Card numbers are 13 to 19 digits, which may be grouped by spaces or
+	// dashes, starting with the 2 to 6 of the major networks.
+	oapi.CreditCard: {{
+		re:    regexp.MustCompile(`\b[2-6](?:[ -]?\d){12,18}\b`),
+		valid: luhn,
+	}},
+	// Access key IDs stand out by their prefix; secret access keys only by
+	// the name they are given.
+	oapi.AwsKey: {
+		{re: regexp.MustCompile(`\b(?:AKIA|ASIA|ABIA|ACCA)[0-9A-Z]{16}\b`)},
+		{
+			re: regexp.MustCompile(
+				`(?i)secret_?access_?key["']?\s*[:=]\s*["']?([A-Za-z0-9/+]{40})(?:[^A-Za-z0-9/+]|$)`,
+			),
+			group: 1,
+		},
+	},
+	// A JWT is three base64url segments, of which the header and claims are
+	// JSON objects, so start with "eyJ". The signature is empty when unsigned.
+	oapi.Jwt: {{
+		re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`),
+	}},
+}
+
+// luhn reports whether the digits of s pass the Luhn check.
+func luhn(s string) bool {
+	sum, n := 0, 0
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if n%2 == 1 {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		n++
+	}
+	return n >= 13 && n <= 19 && sum%10 == 0
+}
+
+// replace replaces what m matches in s with what fn makes of it. Returns the
+// text and the number of matches replaced.
+func (m matcher) replace(s string, fn func(string) string) (string, int) {
+	locs := m.re.FindAllStringSubmatchIndex(s, -1)
+	var b strings.Builder
+	last, n := 0, 0
+	for _, loc := range locs {
+		start, end := loc[2*m.group], loc[2*m.group+1]
+		if start < 0 || (m.valid != nil && !m.valid(s[start:end])) {
+			continue
+		}
+		b.WriteString(s[last:start])
+		b.WriteString(fn(s[start:end]))
+		last = end
+		n++
+	}
+	if n == 0 {
+		return s, 0
+	}
+	b.WriteString(s[last:])
+	return b.String(), n
+}
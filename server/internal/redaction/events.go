@@ -0,0 +1,43 @@
+package redaction
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per policy. Redactions
+// raise none; they are counted instead.
+const (
+	aggregateType = "redaction_policy"
+
+	EventCreated = "redaction_policy.created"
+	EventUpdated = "redaction_policy.updated"
+	EventDeleted = "redaction_policy.deleted"
+)
+
+// DeletedPayload is the payload of EventDeleted.
+type DeletedPayload struct {
+	ID    uuid.UUID `json:"id"`
+	OrgID uuid.UUID `json:"org_id"`
+	Name  string    `json:"name"`
+}
+
+// emit appends an event about aggregateID to the outbox, in the transaction
+// on ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, aggregateID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
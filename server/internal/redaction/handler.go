@@ -0,0 +1,274 @@
+package redaction
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// Handler serves the /organizations/{orgId}/redaction-policies/* endpoints
+// and the redaction counts of sources. Members may read policies and counts;
+// admins manage policies.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound(detail string) oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", detail),
+	)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func conflict() oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", "The organisation already has a redaction policy of this name"),
+	)
+}
+
+func (h *Handler) ListRedactionPolicies(
+	ctx context.Context,
+	request oapi.ListRedactionPoliciesRequestObject,
+) (oapi.ListRedactionPoliciesResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListRedactionPolicies403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	policies, err := h.svc.List(ctx, request.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	return oapi.ListRedactionPolicies200JSONResponse(policies), nil
+}
+
+func (h *Handler) CreateRedactionPolicy(
+	ctx context.Context,
+	request oapi.CreateRedactionPolicyRequestObject,
+) (oapi.CreateRedactionPolicyResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateRedactionPolicy403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateRedactionPolicy403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	p, err := h.svc.Create(ctx, request.OrgId, *request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalid):
+			return oapi.CreateRedactionPolicy400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.CreateRedactionPolicy409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateRedactionPolicy201JSONResponse(p), nil
+}
+
+func (h *Handler) GetRedactionPolicy(
+	ctx context.Context,
+	request oapi.GetRedactionPolicyRequestObject,
+) (oapi.GetRedactionPolicyResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetRedactionPolicy403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	p, err := h.svc.Get(ctx, request.OrgId, request.PolicyId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetRedactionPolicy404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Redaction policy not found"),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(p.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetRedactionPolicy304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetRedactionPolicy200JSONResponse{
+		Body:    p,
+		Headers: oapi.GetRedactionPolicy200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
+func (h *Handler) UpdateRedactionPolicy(
+	ctx context.Context,
+	request oapi.UpdateRedactionPolicyRequestObject,
+) (oapi.UpdateRedactionPolicyResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateRedactionPolicy403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateRedactionPolicy403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return policyPreconditionFailed(), nil
+	}
+
+	p, err := h.svc.Update(ctx, request.OrgId, request.PolicyId, *request.Body, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.UpdateRedactionPolicy404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Redaction policy not found"),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.UpdateRedactionPolicy400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.UpdateRedactionPolicy409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict(),
+			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return policyPreconditionFailed(), nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateRedactionPolicy200JSONResponse{
+		Body:    p,
+		Headers: oapi.UpdateRedactionPolicy200ResponseHeaders{ETag: httpx.ETag(p.Version)},
+	}, nil
+}
+
+func policyPreconditionFailed() oapi.UpdateRedactionPolicy412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateRedactionPolicy412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Redaction policy was modified since it was last read"),
+		),
+	}
+}
+
+func (h *Handler) DeleteRedactionPolicy(
+	ctx context.Context,
+	request oapi.DeleteRedactionPolicyRequestObject,
+) (oapi.DeleteRedactionPolicyResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteRedactionPolicy403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteRedactionPolicy403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Delete(ctx, request.OrgId, request.PolicyId); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.DeleteRedactionPolicy404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Redaction policy not found"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteRedactionPolicy204Response{}, nil
+}
+
+func (h *Handler) ListSourceRedactions(
+	ctx context.Context,
+	request oapi.ListSourceRedactionsRequestObject,
+) (oapi.ListSourceRedactionsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListSourceRedactions403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	params := request.Params
+	counts, err := h.svc.Counts(ctx, request.OrgId, request.SourceId, params.From, params.To)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.ListSourceRedactions404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Source not found"),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.ListSourceRedactions400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ListSourceRedactions200JSONResponse(counts), nil
+}
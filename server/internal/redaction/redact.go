@@ -0,0 +1,392 @@
+package redaction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/parser"
+)
+
+// Limits of a rule.
+const (
+	// maxRuleNameLen caps the length of a rule's name.
+	maxRuleNameLen = 100
+	// maxFields caps the fields a rule names.
+	maxFields = 50
+	// maxFieldLen caps the length of a field's path.
+	maxFieldLen = 255
+	// maxPatternLen caps the length of a rule's pattern.
+	maxPatternLen = 1000
+	// maxReplacementLen caps the length of a rule's replacement.
+	maxReplacementLen = 100
+	// maxKeep caps the letters and digits a mask leaves.
+	maxKeep = 32
+)
+
+// defaultReplacement is what scrub replaces matches with by default.
+const defaultReplacement = "[REDACTED]"
+
+// Key names a rule of a policy.
+type Key struct {
+	PolicyID uuid.UUID
+	Rule     string
+}
+
+// Tally counts the values redacted, by the rule that redacted them.
+type Tally map[Key]int64
+
+// Add adds o's counts to t's.
+func (t Tally) Add(o Tally) {
+	for k, n := range o {
+		t[k] += n
+	}
+}
+
+// rule is a rule compiled for applying. Without matchers, it acts on the
+// whole value of the fields at its paths.
+type rule struct {
+	key         Key
+	action      oapi.RedactionAction
+	paths       []string
+	matchers    []matcher
+	replacement string
+	keepFirst   int
+	keepLast    int
+}
+
+// compileRule checks r and compiles it as a rule of the policy. Returns an
+// error wrapping ErrInvalid when r is not a valid rule.
+func compileRule(policyID uuid.UUID, r oapi.RedactionRule) (rule, error) {
+	name := strings.TrimSpace(r.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxRuleNameLen {
+		return rule{}, fmt.Errorf("%w: rule names must be 1 to %d characters", ErrInvalid, maxRuleNameLen)
+	}
+	c := rule{
+		key:         Key{PolicyID: policyID, Rule: name},
+		action:      r.Action,
+		replacement: defaultReplacement,
+	}
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: rule %s: %s", ErrInvalid, name, fmt.Sprintf(format, args...))
+	}
+
+	switch r.Action {
+	case oapi.Drop, oapi.Hash, oapi.Mask, oapi.Scrub:
+	default:
+		return rule{}, invalid("unknown action %q", r.Action)
+	}
+
+	if r.Fields != nil {
+		if len(*r.Fields) > maxFields {
+			return rule{}, invalid("more than %d fields", maxFields)
+		}
+		for _, f := range *r.Fields {
+			f = strings.TrimSpace(f)
+			if f == "" || utf8.RuneCountInString(f) > maxFieldLen {
+				return rule{}, invalid("field paths must be 1 to %d characters", maxFieldLen)
+			}
+			if !slices.Contains(c.paths, f) {
+				c.paths = append(c.paths, f)
+			}
+		}
+	}
+
+	switch {
+	case r.Detector != nil && r.Pattern != nil:
+		return rule{}, invalid("detector and pattern are exclusive")
+	case r.Detector != nil:
+		ms, ok := detectors[*r.Detector]
+		if !ok {
+			return rule{}, invalid("unknown detector %q", *r.Detector)
+		}
+		c.matchers = ms
+	case r.Pattern != nil:
+		if utf8.RuneCountInString(*r.Pattern) > maxPatternLen {
+			return rule{}, invalid("pattern longer than %d characters", maxPatternLen)
+		}
+		re, err := regexp.Compile(*r.Pattern)
+		if err != nil {
+			return rule{}, invalid("pattern: %v", err)
+		}
+		if re.MatchString("") {
+			return rule{}, invalid("pattern matches empty text")
+		}
+		c.matchers = []matcher{{re: re}}
+	case r.Action == oapi.Scrub:
+		return rule{}, invalid("scrub requires a detector or pattern")
+	case len(c.paths) == 0:
+		return rule{}, invalid("a rule without a detector or pattern must name its fields")
+	}
+
+	if r.Replacement != nil {
+		if utf8.RuneCountInString(*r.Replacement) > maxReplacementLen {
+			return rule{}, invalid("replacement longer than %d characters", maxReplacementLen)
+		}
+		c.replacement = *r.Replacement
+	}
+	if r.KeepFirst != nil {
+		if *r.KeepFirst < 0 || *r.KeepFirst > maxKeep {
+			return rule{}, invalid("keep_first must be 0 to %d", maxKeep)
+		}
+		c.keepFirst = *r.KeepFirst
+	}
+	if r.KeepLast != nil {
+		if *r.KeepLast < 0 || *r.KeepLast > maxKeep {
+			return rule{}, invalid("keep_last must be 0 to %d", maxKeep)
+		}
+		c.keepLast = *r.KeepLast
+	}
+	return c, nil
+}
+
+// apply applies the rule to fields, in place. Returns the number of values
+// redacted.
+func (r *rule) apply(fields parser.Fields, hash func(string) string) int {
+	if len(r.paths) == 0 {
+		_, _, n := r.redactValue(map[string]any(fields), hash)
+		return n
+	}
+	n := 0
+	for _, p := range r.paths {
+		n += resolve(fields, p, func(m map[string]any, key string) int {
+			return r.applyAt(m, key, hash)
+		})
+	}
+	return n
+}
+
+// applyAt applies the rule to the field key of m.
+func (r *rule) applyAt(m map[string]any, key string, hash func(string) string) int {
+	v := m[key]
+	if r.matchers != nil {
+		nv, keep, n := r.redactValue(v, hash)
+		switch {
+		case !keep:
+			delete(m, key)
+		case n > 0:
+			m[key] = nv
+		}
+		return n
+	}
+	if v == nil {
+		return 0
+	}
+	switch r.action {
+	case oapi.Drop:
+		delete(m, key)
+	case oapi.Hash:
+		m[key] = hash(text(v))
+	case oapi.Mask:
+		m[key] = mask(text(v), r.keepFirst, r.keepLast)
+	}
+	return 1
+}
+
+// redactValue applies the rule's matchers to the text in v, nested objects
+// and arrays included. Returns what v becomes, false when it is to be
+// dropped, and the number of matches.
+func (r *rule) redactValue(v any, hash func(string) string) (any, bool, int) {
+	switch v := v.(type) {
+	case string:
+		s, n := r.redactText(v, hash)
+		return s, n == 0 || r.action != oapi.Drop, n
+	case json.Number:
+		s, n := r.redactText(v.String(), hash)
+		if n == 0 {
+			return v, true, 0
+		}
+		return s, r.action != oapi.Drop, n
+	case map[string]any:
+		total := 0
+		for k, child := range v {
+			nv, keep, n := r.redactValue(child, hash)
+			switch {
+			case !keep:
+				delete(v, k)
+			case n > 0:
+				v[k] = nv
+			}
+			total += n
+		}
+		return v, true, total
+	case []any:
+		kept, total := v[:0], 0
+		for _, child := range v {
+			nv, keep, n := r.redactValue(child, hash)
+			if keep {
+				kept = append(kept, nv)
+			}
+			total += n
+		}
+		return kept, true, total
+	}
+	return v, true, 0
+}
+
+// redactText applies the rule's action to each match in s. Returns the text
+// and the number of matches.
+func (r *rule) redactText(s string, hash func(string) string) (string, int) {
+	total := 0
+	for _, m := range r.matchers {
+		var n int
+		s, n = m.replace(s, func(match string) string {
+			switch r.action {
+			case oapi.Hash:
+				return hash(match)
+			case oapi.Mask:
+				return mask(match, r.keepFirst, r.keepLast)
+			case oapi.Scrub:
+				return r.replacement
+			}
+			return match
+		})
+		total += n
+	}
+	return s, total
+}
+
+// resolve calls fn with each object holding the field at path in m and the
+// field's key in it, returning the sum of what fn returns. A field is found
+// under the path itself as a key, and under the path's leading segments as
+// nested objects, or arrays of them.
+func resolve(m map[string]any, path string, fn func(m map[string]any, key string) int) int {
+	n := 0
+	if _, ok := m[path]; ok {
+		n += fn(m, path)
+	}
+	for i := range len(path) {
+		if path[i] != '.' {
+			continue
+		}
+		switch child := m[path[:i]].(type) {
+		case map[string]any:
+			n += resolve(child, path[i+1:], fn)
+		case []any:
+			for _, elem := range child {
+				if obj, ok := elem.(map[string]any); ok {
+					n += resolve(obj, path[i+1:], fn)
+				}
+			}
+		}
+	}
+	return n
+}
+
+// text returns a field's value as text: itself for a string or number, and
+// its JSON otherwise.
+func text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+// mask replaces the letters and digits of s with asterisks, but for the
+// first keepFirst and last keepLast of them. All are replaced when s has no
+// more than are kept.
+func mask(s string, keepFirst, keepLast int) string {
+	alnum := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }
+	total := 0
+	for _, r := range s {
+		if alnum(r) {
+			total++
+		}
+	}
+	if keepFirst+keepLast >= total {
+		keepFirst, keepLast = 0, 0
+	}
+	var b strings.Builder
+	i := 0
+	for _, r := range s {
+		if alnum(r) {
+			if i >= keepFirst && i < total-keepLast {
+				r = '*'
+			}
+			i++
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// ── Set ──────────────────────────────────────────────────────────────────────
+
+// Set holds an org's policies compiled for redacting its events, in the
+// order they apply. It is safe for concurrent use once built.
+type Set struct {
+	salt     []byte
+	policies []policy
+}
+
+// policy is a compiled policy; no sources means every source.
+type policy struct {
+	sources []uuid.UUID
+	rules   []rule
+}
+
+// NewSet compiles the policies, in order, hashing with the org's salt.
+// Returns an error wrapping ErrInvalid when a rule does not compile.
+func NewSet(salt []byte, policies []oapi.RedactionPolicy) (*Set, error) {
+	s := &Set{salt: salt}
+	for _, p := range policies {
+		c := policy{sources: p.SourceIds}
+		for _, r := range p.Rules {
+			compiled, err := compileRule(p.Id, r)
+			if err != nil {
+				return nil, fmt.Errorf("policy %s: %w", p.Name, err)
+			}
+			c.rules = append(c.rules, compiled)
+		}
+		s.policies = append(s.policies, c)
+	}
+	return s, nil
+}
+
+// Len returns the number of policies in the set.
+func (s *Set) Len() int {
+	return len(s.policies)
+}
+
+// Redact redacts the fields of an event of the source, in place, with the
+// policies that apply to the source. Returns the redactions made; nil when
+// none were.
+func (s *Set) Redact(sourceID uuid.UUID, fields parser.Fields) Tally {
+	var t Tally
+	for _, p := range s.policies {
+		if len(p.sources) > 0 && !slices.Contains(p.sources, sourceID) {
+			continue
+		}
+		for i := range p.rules {
+			r := &p.rules[i]
+			if n := r.apply(fields, s.hash); n > 0 {
+				if t == nil {
+					t = make(Tally)
+				}
+				t[r.key] += int64(n)
+			}
+		}
+	}
+	return t
+}
+
+// hash returns the HMAC-SHA256 of v under the salt, in hex.
+func (s *Set) hash(v string) string {
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(v))
+	return hex.EncodeToString(mac.Sum(nil))
+}
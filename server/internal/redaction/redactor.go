@@ -0,0 +1,95 @@
+package redaction
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/platform/orgcache"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrUnavailable is returned when an org's policies could not be loaded, and
+// never have been, so its events cannot be redacted.
+var ErrUnavailable = errors.New("redaction policies unavailable")
+
+// Redactor redacts the events of each org with its enabled policies of one
+// stage. It keeps each org's policies in a Set, reloading it when they have
+// changed, which it checks for at most once a refresh interval. It is safe
+// for concurrent use.
+type Redactor struct {
+	repo   *Repo
+	tx     *tx.Manager
+	logger *slog.Logger
+	stage  oapi.RedactionStage
+	sets   *orgcache.Cache[*Set]
+}
+
+// NewRedactor wires a Redactor of the stage's policies with the repo,
+// transaction manager and logger. A zero refresh means 30 seconds.
+func NewRedactor(
+	repo *Repo,
+	txm *tx.Manager,
+	logger *slog.Logger,
+	stage oapi.RedactionStage,
+	refresh time.Duration,
+) *Redactor {
+	r := &Redactor{repo: repo, tx: txm, logger: logger, stage: stage}
+	r.sets = orgcache.New(txm, refresh, repo.Revision, r.load)
+	return r
+}
+
+// Redact redacts the fields of an event of the org's source, in place, and
+// returns the redactions made. When the org's policies cannot be loaded, the
+// last ones loaded are used; when none ever were, Redact returns
+// ErrUnavailable rather than let the event through unredacted.
+func (r *Redactor) Redact(ctx context.Context, orgID, sourceID uuid.UUID, fields parser.Fields) (Tally, error) {
+	set := r.set(ctx, orgID)
+	if set == nil {
+		return nil, ErrUnavailable
+	}
+	return set.Redact(sourceID, fields), nil
+}
+
+// Record adds t to the source's redaction counts for the current hour, in a
+// transaction carrying the org's tenant scope, joining one already on ctx.
+func (r *Redactor) Record(ctx context.Context, orgID, sourceID uuid.UUID, t Tally) error {
+	if len(t) == 0 {
+		return nil
+	}
+	return r.tx.RunInTx(tenant.WithOrg(ctx, orgID), nil, func(ctx context.Context) error {
+		return r.repo.Record(ctx, orgID, sourceID, r.stage, time.Now(), t)
+	})
+}
+
+// set returns the org's policies, reloading them when they have changed.
+func (r *Redactor) set(ctx context.Context, orgID uuid.UUID) *Set {
+	set, err := r.sets.Get(ctx, orgID)
+	if err != nil {
+		r.logger.WarnContext(ctx, "loading redaction policies failed",
+			slog.String("org_id", orgID.String()),
+			slog.String("stage", string(r.stage)),
+			slog.Any("err", err),
+		)
+	}
+	return set
+}
+
+// load loads the org's enabled policies of the stage.
+func (r *Redactor) load(ctx context.Context, orgID uuid.UUID, _ time.Time) (*Set, error) {
+	salt, err := r.repo.Salt(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	policies, err := r.repo.ListEnabled(ctx, orgID, r.stage)
+	if err != nil {
+		return nil, err
+	}
+	return NewSet(salt, policies)
+}
@@ -0,0 +1,461 @@
+package redaction
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns redaction_policies, redaction_salts, redaction_revisions and
+// redaction_stats SQL and row→DTO mapping. The tables are protected by RLS,
+// so callers run statements inside tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.RedactionPolicies) oapi.RedactionPolicy {
+	sources := make([]uuid.UUID, 0, len(m.SourceIds))
+	for _, s := range m.SourceIds {
+		if id, err := uuid.Parse(s); err == nil {
+			sources = append(sources, id)
+		}
+	}
+	var rules []oapi.RedactionRule
+	if err := json.Unmarshal([]byte(m.Rules), &rules); err != nil || rules == nil {
+		rules = []oapi.RedactionRule{}
+	}
+	return oapi.RedactionPolicy{
+		Id:          m.ID,
+		OrgId:       m.OrgID,
+		Name:        m.Name,
+		Description: m.Description,
+		Enabled:     m.Enabled,
+		Stage:       oapi.RedactionStage(m.Stage),
+		SourceIds:   sources,
+		Rules:       rules,
+		Version:     m.Version,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+func toOapiList(rows []model.RedactionPolicies) []oapi.RedactionPolicy {
+	policies := make([]oapi.RedactionPolicy, 0, len(rows))
+	for _, row := range rows {
+		policies = append(policies, toOapi(row))
+	}
+	return policies
+}
+
+// nameTaken reports whether err is a unique violation on (org_id, name).
+func nameTaken(err error) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// versionIn restricts an update to the listed versions; nil means any.
+func versionIn(col postgres.ColumnInteger, versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return col.IN(exprs...)
+}
+
+// uuidArray is a uuid[] of ids.
+func uuidArray(ids []uuid.UUID) postgres.Expression {
+	values := make([]string, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, id.String())
+	}
+	return postgres.CAST(postgres.StringArray(values...)).AS("uuid[]")
+}
+
+// rulesJSON renders rules for storage.
+func rulesJSON(rules []oapi.RedactionRule) (postgres.Expression, error) {
+	b, err := json.Marshal(rules)
+	if err != nil {
+		return nil, fmt.Errorf("encoding redaction rules: %w", err)
+	}
+	return postgres.CAST(postgres.String(string(b))).AS("jsonb"), nil
+}
+
+// Insert stores a new policy from a complete draft. Returns ErrConflict when
+// the org already has a policy of that name.
+func (r *Repo) Insert(ctx context.Context, orgID uuid.UUID, d Draft) (oapi.RedactionPolicy, error) {
+	rules, err := rulesJSON(d.Rules)
+	if err != nil {
+		return oapi.RedactionPolicy{}, err
+	}
+	t := table.RedactionPolicies
+	stmt := t.
+		INSERT(t.OrgID, t.Name, t.Description, t.Enabled, t.Stage, t.SourceIds, t.Rules).
+		VALUES(orgID, d.Name, d.Description, d.Enabled, string(d.Stage), uuidArray(d.SourceIDs), rules).
+		RETURNING(t.AllColumns)
+
+	var row model.RedactionPolicies
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if nameTaken(err) {
+			return oapi.RedactionPolicy{}, ErrConflict
+		}
+		return oapi.RedactionPolicy{}, fmt.Errorf("inserting redaction policy: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// List returns the org's policies by name.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID) ([]oapi.RedactionPolicy, error) {
+	t := table.RedactionPolicies
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID))).
+		ORDER_BY(t.Name.ASC())
+
+	var rows []model.RedactionPolicies
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing redaction policies: %w", err)
+	}
+	return toOapiList(rows), nil
+}
+
+// ListEnabled returns the org's enabled policies of the stage, by name.
+func (r *Repo) ListEnabled(
+	ctx context.Context,
+	orgID uuid.UUID,
+	stage oapi.RedactionStage,
+) ([]oapi.RedactionPolicy, error) {
+	t := table.RedactionPolicies
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.OrgID.EQ(postgres.UUID(orgID)).
+				AND(t.Enabled.IS_TRUE()).
+				AND(t.Stage.EQ(postgres.String(string(stage)))),
+		).
+		ORDER_BY(t.Name.ASC())
+
+	var rows []model.RedactionPolicies
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing enabled redaction policies: %w", err)
+	}
+	return toOapiList(rows), nil
+}
+
+// Get returns a single policy of the org. Returns ErrNotFound when it does
+// not exist in that org.
+func (r *Repo) Get(ctx context.Context, orgID, policyID uuid.UUID) (oapi.RedactionPolicy, error) {
+	t := table.RedactionPolicies
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(policyID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.RedactionPolicies
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.RedactionPolicy{}, ErrNotFound
+		}
+		return oapi.RedactionPolicy{}, fmt.Errorf("getting redaction policy: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Update replaces the policy's settings with those of a complete draft and
+// bumps the version. When ifMatch is non-nil the update only applies if the
+// current version is listed, otherwise ErrPreconditionFailed is returned.
+// Returns ErrConflict when another policy of the org has the name.
+func (r *Repo) Update(
+	ctx context.Context,
+	orgID, policyID uuid.UUID,
+	d Draft,
+	ifMatch []int64,
+) (oapi.RedactionPolicy, error) {
+	rules, err := rulesJSON(d.Rules)
+	if err != nil {
+		return oapi.RedactionPolicy{}, err
+	}
+	description := postgres.StringExp(postgres.NULL)
+	if d.Description != nil {
+		description = postgres.String(*d.Description)
+	}
+	t := table.RedactionPolicies
+	stmt := t.
+		UPDATE(t.Name, t.Description, t.Enabled, t.Stage, t.SourceIds, t.Rules, t.Version, t.UpdatedAt).
+		SET(
+			d.Name, description, d.Enabled, string(d.Stage), uuidArray(d.SourceIDs), rules,
+			t.Version.ADD(postgres.Int(1)), postgres.NOW(),
+		).
+		WHERE(
+			t.ID.EQ(postgres.UUID(policyID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))).
+				AND(versionIn(t.Version, ifMatch)),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.RedactionPolicies
+	err = stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	switch {
+	case err == nil:
+		return toOapi(row), nil
+	case nameTaken(err):
+		return oapi.RedactionPolicy{}, ErrConflict
+	case !errors.Is(err, qrm.ErrNoRows):
+		return oapi.RedactionPolicy{}, fmt.Errorf("updating redaction policy: %w", err)
+	}
+	if _, err := r.Get(ctx, orgID, policyID); err != nil {
+		return oapi.RedactionPolicy{}, err
+	}
+	return oapi.RedactionPolicy{}, ErrPreconditionFailed
+}
+
+// Delete removes a policy, keeping the counts of its redactions. Returns
+// ErrNotFound when no row matched.
+func (r *Repo) Delete(ctx context.Context, orgID, policyID uuid.UUID) error {
+	t := table.RedactionPolicies
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(policyID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting redaction policy: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// UnknownSources returns those of ids that are not sources of the org.
+func (r *Repo) UnknownSources(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) ([]uuid.UUID, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	t := table.Sources
+	exprs := make([]postgres.Expression, 0, len(ids))
+	for _, id := range ids {
+		exprs = append(exprs, postgres.UUID(id))
+	}
+	stmt := postgres.
+		SELECT(t.ID).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID)).AND(t.ID.IN(exprs...)))
+
+	var rows []model.Sources
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("looking up sources: %w", err)
+	}
+	var unknown []uuid.UUID
+	for _, id := range ids {
+		if !slices.ContainsFunc(rows, func(row model.Sources) bool { return row.ID == id }) {
+			unknown = append(unknown, id)
+		}
+	}
+	return unknown, nil
+}
+
+// ── Salts ────────────────────────────────────────────────────────────────────
+
+// Salt returns the org's salt; nil when it has none yet.
+func (r *Repo) Salt(ctx context.Context, orgID uuid.UUID) ([]byte, error) {
+	t := table.RedactionSalts
+	stmt := postgres.
+		SELECT(t.Salt).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID)))
+
+	var row model.RedactionSalts
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("getting redaction salt: %w", err)
+	}
+	return row.Salt, nil
+}
+
+// EnsureSalt stores salt as the org's, unless it already has one.
+func (r *Repo) EnsureSalt(ctx context.Context, orgID uuid.UUID, salt []byte) error {
+	t := table.RedactionSalts
+	stmt := t.
+		INSERT(t.OrgID, t.Salt).
+		VALUES(orgID, salt).
+		ON_CONFLICT(t.OrgID).
+		DO_NOTHING()
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("storing redaction salt: %w", err)
+	}
+	return nil
+}
+
+// ── Stats ────────────────────────────────────────────────────────────────────
+
+// Record adds the tally to the source's counts for the hour of at.
+func (r *Repo) Record(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	stage oapi.RedactionStage,
+	at time.Time,
+	t Tally,
+) error {
+	if len(t) == 0 {
+		return nil
+	}
+	// Rows are written in a fixed order, so that concurrent writers do not
+	// deadlock.
+	keys := make([]Key, 0, len(t))
+	for k := range t {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b Key) int {
+		return cmp.Or(slices.Compare(a.PolicyID[:], b.PolicyID[:]), cmp.Compare(a.Rule, b.Rule))
+	})
+
+	s := table.RedactionStats
+	bucket := at.UTC().Truncate(time.Hour)
+	stmt := s.INSERT(s.SourceID, s.OrgID, s.PolicyID, s.Rule, s.Stage, s.Bucket, s.Redactions)
+	for _, k := range keys {
+		stmt = stmt.VALUES(sourceID, orgID, k.PolicyID, k.Rule, string(stage), bucket, t[k])
+	}
+	stmt = stmt.
+		ON_CONFLICT(s.SourceID, s.Bucket, s.PolicyID, s.Rule, s.Stage).
+		DO_UPDATE(postgres.SET(s.Redactions.SET(s.Redactions.ADD(s.EXCLUDED.Redactions))))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("recording redactions: %w", err)
+	}
+	return nil
+}
+
+// countRow is a row of Counts.
+type countRow struct {
+	model.RedactionStats
+	Name *string `alias:"redaction_policies.name"`
+}
+
+// Counts returns the source's hourly counts for hours starting in [from,
+// to), by hour, policy name and rule, with the name of the policy when it
+// still exists.
+func (r *Repo) Counts(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	from, to time.Time,
+) ([]oapi.RedactionCount, error) {
+	s := table.RedactionStats
+	p := table.RedactionPolicies
+	stmt := postgres.
+		SELECT(s.AllColumns, p.Name).
+		FROM(s.LEFT_JOIN(p, p.ID.EQ(s.PolicyID))).
+		WHERE(
+			s.SourceID.EQ(postgres.UUID(sourceID)).
+				AND(s.OrgID.EQ(postgres.UUID(orgID))).
+				AND(s.Bucket.GT_EQ(postgres.TimestampzT(from))).
+				AND(s.Bucket.LT(postgres.TimestampzT(to))),
+		).
+		ORDER_BY(s.Bucket.ASC(), p.Name.ASC().NULLS_LAST(), s.PolicyID.ASC(), s.Rule.ASC())
+
+	var rows []countRow
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing redaction counts: %w", err)
+	}
+	counts := make([]oapi.RedactionCount, 0, len(rows))
+	for _, row := range rows {
+		counts = append(counts, oapi.RedactionCount{
+			Bucket:     row.Bucket,
+			PolicyId:   row.PolicyID,
+			PolicyName: row.Name,
+			Rule:       row.Rule,
+			Stage:      oapi.RedactionStage(row.Stage),
+			Redactions: row.Redactions,
+		})
+	}
+	return counts, nil
+}
+
+// PruneStats deletes counts for hours before cutoff. Returns the number of
+// rows deleted.
+func (r *Repo) PruneStats(ctx context.Context, cutoff time.Time) (int64, error) {
+	s := table.RedactionStats
+	stmt := s.DELETE().WHERE(s.Bucket.LT(postgres.TimestampzT(cutoff)))
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return 0, fmt.Errorf("pruning redaction stats: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PruneStatsArgs is the background job running PruneStats.
+type PruneStatsArgs struct{}
+
+// Kind implements jobs.Args.
+func (PruneStatsArgs) Kind() string { return "redaction.prune_stats" }
+
+// ── Revisions ────────────────────────────────────────────────────────────────
+
+// Revision returns the org's redaction revision, which changes whenever its
+// policies do; zero when they never have.
+func (r *Repo) Revision(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	t := table.RedactionRevisions
+	stmt := postgres.
+		SELECT(t.Revision).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID)))
+
+	var row model.RedactionRevisions
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("getting redaction revision: %w", err)
+	}
+	return row.Revision, nil
+}
+
+// Bump advances the org's redaction revision, telling redactors to reload.
+func (r *Repo) Bump(ctx context.Context, orgID uuid.UUID) error {
+	t := table.RedactionRevisions
+	stmt := t.
+		INSERT(t.OrgID).
+		VALUES(orgID).
+		ON_CONFLICT(t.OrgID).
+		DO_UPDATE(postgres.SET(t.Revision.SET(t.Revision.ADD(postgres.Int(1)))))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("bumping redaction revision: %w", err)
+	}
+	return nil
+}
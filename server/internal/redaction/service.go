@@ -0,0 +1,373 @@
+// Package redaction owns an organisation's redaction policies, which keep
+// personal data and secrets out of what Horizon stores of its events. A
+// policy's rules drop, hash, mask or scrub values: whole fields, or what a
+// built-in detector (email addresses, payment card numbers, AWS keys and
+// JWTs) or a regular expression finds in their text. Archive-stage policies
+// apply at ingest, before events are queued, so neither the archive nor
+// anything processed from it holds the values; normalization-stage policies
+// apply to the fields parsed from an event before it is mapped, leaving the
+// archive as received. Hashes are keyed with a salt kept for the
+// organisation, so the same value hashes alike across its events, and
+// unlike across organisations. Every redaction is counted, hourly, by
+// source, policy and rule.
+package redaction
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrNotFound is returned when a policy, or the source whose redactions are
+// asked for, does not exist in the org.
+var ErrNotFound = errors.New("redaction policy not found")
+
+// ErrConflict is returned when the org already has a policy of that name.
+var ErrConflict = errors.New("redaction policy already exists")
+
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("redaction policy version mismatch")
+
+// ErrInvalid is returned when a policy or one of its rules is not valid, and
+// when a range of counts is not.
+var ErrInvalid = errors.New("invalid redaction policy")
+
+// Limits of a policy and of a range of counts.
+const (
+	// maxNameLen caps the length of a policy's name.
+	maxNameLen = 255
+	// maxDescriptionLen caps the length of a policy's description.
+	maxDescriptionLen = 1000
+	// maxSources caps the sources a policy names.
+	maxSources = 100
+	// maxRules caps the rules of a policy.
+	maxRules = 50
+	// saltSize is the length of an org's salt in bytes.
+	saltSize = 32
+	// maxCountsRange caps the range of counts asked for.
+	maxCountsRange = 31 * 24 * time.Hour
+	// defaultCountsRange is the range of counts not given a start.
+	defaultCountsRange = 7 * 24 * time.Hour
+)
+
+// Service orchestrates redaction policy operations. Each runs in a
+// transaction carrying the caller's tenant scope, joining one already on
+// ctx. Mutations append their domain event to the outbox and advance the
+// org's redaction revision in that same transaction.
+type Service struct {
+	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
+	logger *slog.Logger
+}
+
+// NewService wires a Service with its repo, transaction manager, outbox and
+// logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{repo: repo, tx: txm, events: events, logger: logger}
+}
+
+// Draft is a policy to be stored, with all its settings.
+type Draft struct {
+	Name        string
+	Description *string
+	Enabled     bool
+	Stage       oapi.RedactionStage
+	SourceIDs   []uuid.UUID
+	Rules       []oapi.RedactionRule
+}
+
+// complete trims d's name, description and rules and checks its settings.
+// Returns an error wrapping ErrInvalid when d is not a valid policy.
+func complete(d *Draft) error {
+	d.Name = strings.TrimSpace(d.Name)
+	if d.Description != nil {
+		if desc := strings.TrimSpace(*d.Description); desc != "" {
+			d.Description = &desc
+		} else {
+			d.Description = nil
+		}
+	}
+	d.SourceIDs = uniqueIDs(d.SourceIDs)
+	switch {
+	case d.Name == "" || utf8.RuneCountInString(d.Name) > maxNameLen:
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalid, maxNameLen)
+	case d.Description != nil && utf8.RuneCountInString(*d.Description) > maxDescriptionLen:
+		return fmt.Errorf("%w: description longer than %d characters", ErrInvalid, maxDescriptionLen)
+	case d.Stage != oapi.Archive && d.Stage != oapi.Normalization:
+		return fmt.Errorf("%w: unknown stage %q", ErrInvalid, d.Stage)
+	case len(d.SourceIDs) > maxSources:
+		return fmt.Errorf("%w: more than %d sources", ErrInvalid, maxSources)
+	case len(d.Rules) == 0:
+		return fmt.Errorf("%w: a policy needs a rule", ErrInvalid)
+	case len(d.Rules) > maxRules:
+		return fmt.Errorf("%w: more than %d rules", ErrInvalid, maxRules)
+	}
+
+	names := make([]string, 0, len(d.Rules))
+	for i, r := range d.Rules {
+		r = cleanRule(r)
+		if _, err := compileRule(uuid.Nil, r); err != nil {
+			return err
+		}
+		if slices.Contains(names, r.Name) {
+			return fmt.Errorf("%w: rule %s given twice", ErrInvalid, r.Name)
+		}
+		names = append(names, r.Name)
+		d.Rules[i] = r
+	}
+	return nil
+}
+
+// cleanRule trims the name and field paths of r and drops the settings its
+// action does not use.
+func cleanRule(r oapi.RedactionRule) oapi.RedactionRule {
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Fields != nil {
+		fields := make([]string, 0, len(*r.Fields))
+		for _, f := range *r.Fields {
+			fields = append(fields, strings.TrimSpace(f))
+		}
+		r.Fields = &fields
+		if len(fields) == 0 {
+			r.Fields = nil
+		}
+	}
+	if r.Action != oapi.Scrub {
+		r.Replacement = nil
+	}
+	if r.Action != oapi.Mask {
+		r.KeepFirst, r.KeepLast = nil, nil
+	}
+	return r
+}
+
+// uniqueIDs returns ids without repeats, in order.
+func uniqueIDs(ids []uuid.UUID) []uuid.UUID {
+	out := make([]uuid.UUID, 0, len(ids))
+	for _, id := range ids {
+		if !slices.Contains(out, id) {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// checkSources returns an error wrapping ErrInvalid when one of ids is not a
+// source of the org.
+func (s *Service) checkSources(ctx context.Context, orgID uuid.UUID, ids []uuid.UUID) error {
+	unknown, err := s.repo.UnknownSources(ctx, orgID, ids)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: unknown source %s", ErrInvalid, unknown[0])
+	}
+	return nil
+}
+
+// Create stores a new policy, generating the org's salt with its first.
+// Returns ErrInvalid when the policy is not valid and ErrConflict when the
+// org already has a policy of that name.
+func (s *Service) Create(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateRedactionPolicyRequest,
+) (oapi.RedactionPolicy, error) {
+	d := Draft{
+		Name:        req.Name,
+		Description: req.Description,
+		Enabled:     req.Enabled == nil || *req.Enabled,
+		Stage:       req.Stage,
+		Rules:       slices.Clone(req.Rules),
+	}
+	if req.SourceIds != nil {
+		d.SourceIDs = *req.SourceIds
+	}
+	if err := complete(&d); err != nil {
+		return oapi.RedactionPolicy{}, err
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return oapi.RedactionPolicy{}, fmt.Errorf("generating redaction salt: %w", err)
+	}
+
+	var p oapi.RedactionPolicy
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if err := s.checkSources(ctx, orgID, d.SourceIDs); err != nil {
+			return err
+		}
+		if err := s.repo.EnsureSalt(ctx, orgID, salt); err != nil {
+			return err
+		}
+		var err error
+		if p, err = s.repo.Insert(ctx, orgID, d); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, p.Id, EventCreated, p)
+	})
+	return p, err
+}
+
+// List returns the org's policies by name.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.RedactionPolicy, error) {
+	var policies []oapi.RedactionPolicy
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		policies, err = s.repo.List(ctx, orgID)
+		return err
+	})
+	return policies, err
+}
+
+// Get returns a single policy. Returns ErrNotFound when it does not exist in
+// the org.
+func (s *Service) Get(ctx context.Context, orgID, policyID uuid.UUID) (oapi.RedactionPolicy, error) {
+	var p oapi.RedactionPolicy
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		p, err = s.repo.Get(ctx, orgID, policyID)
+		return err
+	})
+	return p, err
+}
+
+// Update changes the settings req sets. When ifMatch is non-nil the update
+// only applies if the current version is listed. Returns ErrNotFound,
+// ErrInvalid, ErrConflict when another policy has the name, or
+// ErrPreconditionFailed.
+func (s *Service) Update(
+	ctx context.Context,
+	orgID, policyID uuid.UUID,
+	req oapi.UpdateRedactionPolicyRequest,
+	ifMatch []int64,
+) (oapi.RedactionPolicy, error) {
+	var p oapi.RedactionPolicy
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		cur, err := s.repo.Get(ctx, orgID, policyID)
+		if err != nil {
+			return err
+		}
+		if ifMatch != nil && !slices.Contains(ifMatch, cur.Version) {
+			return ErrPreconditionFailed
+		}
+		d := Draft{
+			Name:        cur.Name,
+			Description: cur.Description,
+			Enabled:     cur.Enabled,
+			Stage:       cur.Stage,
+			SourceIDs:   cur.SourceIds,
+			Rules:       cur.Rules,
+		}
+		if req.Name != nil {
+			d.Name = *req.Name
+		}
+		if req.Description != nil {
+			d.Description = req.Description
+		}
+		if req.Enabled != nil {
+			d.Enabled = *req.Enabled
+		}
+		if req.Stage != nil {
+			d.Stage = *req.Stage
+		}
+		if req.SourceIds != nil {
+			d.SourceIDs = *req.SourceIds
+		}
+		if req.Rules != nil {
+			d.Rules = slices.Clone(*req.Rules)
+		}
+		if err := complete(&d); err != nil {
+			return err
+		}
+		if req.SourceIds != nil {
+			if err := s.checkSources(ctx, orgID, d.SourceIDs); err != nil {
+				return err
+			}
+		}
+		if p, err = s.repo.Update(ctx, orgID, policyID, d, ifMatch); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, policyID, EventUpdated, p)
+	})
+	return p, err
+}
+
+// Delete removes a policy; the counts of its redactions are kept. Returns
+// ErrNotFound when it does not exist in the org.
+func (s *Service) Delete(ctx context.Context, orgID, policyID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		p, err := s.repo.Get(ctx, orgID, policyID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, orgID, policyID); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, policyID, EventDeleted, DeletedPayload{
+			ID:    policyID,
+			OrgID: orgID,
+			Name:  p.Name,
+		})
+	})
+}
+
+// Counts returns the source's hourly redaction counts by policy rule for
+// hours starting in [from, to). A nil to means now, and a nil from a week
+// before to. Returns ErrInvalid when the range is empty or longer than 31
+// days, and ErrNotFound when the source does not exist in the org.
+func (s *Service) Counts(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	from, to *time.Time,
+) ([]oapi.RedactionCount, error) {
+	end := time.Now()
+	if to != nil {
+		end = *to
+	}
+	start := end.Add(-defaultCountsRange)
+	if from != nil {
+		start = *from
+	}
+	switch {
+	case !start.Before(end):
+		return nil, fmt.Errorf("%w: range must start before it ends", ErrInvalid)
+	case end.Sub(start) > maxCountsRange:
+		return nil, fmt.Errorf("%w: range longer than 31 days", ErrInvalid)
+	}
+
+	var counts []oapi.RedactionCount
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		unknown, err := s.repo.UnknownSources(ctx, orgID, []uuid.UUID{sourceID})
+		if err != nil {
+			return err
+		}
+		if len(unknown) > 0 {
+			return ErrNotFound
+		}
+		counts, err = s.repo.Counts(ctx, orgID, sourceID, start, end)
+		return err
+	})
+	return counts, err
+}
@@ -0,0 +1,222 @@
+package redaction_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func boolPtr(b bool) *bool { return &b }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+// seedSource creates an HTTP JSON source in the org. Returns its ID.
+func seedSource(t *testing.T, db *sql.DB, orgID uuid.UUID, name string) uuid.UUID {
+	t.Helper()
+	svc := source.NewService(source.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	src, err := svc.Create(tenant.WithOrg(context.Background(), orgID), orgID, oapi.CreateSourceRequest{
+		Name: name,
+		Type: oapi.HttpJson,
+	})
+	if err != nil {
+		t.Fatalf("seed source: %v", err)
+	}
+	return src.Id
+}
+
+func newSeededService(t *testing.T) (*redaction.Service, *sql.DB, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_redaction_seed", "Redaction Test Org")
+	svc := redaction.NewService(redaction.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	return svc, db, orgID
+}
+
+func TestCreateRedactionPolicy_CleansAndRejectsInvalid(t *testing.T) {
+	svc, db, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	srcID := seedSource(t, db, orgID, "web")
+
+	p, err := svc.Create(ctx, orgID, oapi.CreateRedactionPolicyRequest{
+		Name:      " PII ",
+		Stage:     oapi.Archive,
+		SourceIds: &[]uuid.UUID{srcID, srcID},
+		Rules: []oapi.RedactionRule{{
+			Name:        " user ",
+			Action:      oapi.Hash,
+			Fields:      &[]string{" user.name "},
+			Replacement: strPtr("unused"),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if p.Name != "PII" || !p.Enabled || len(p.SourceIds) != 1 || len(p.Rules) != 1 || p.Version != 1 {
+		t.Fatalf("created: got %+v", p)
+	}
+	if r := p.Rules[0]; r.Name != "user" || (*r.Fields)[0] != "user.name" || r.Replacement != nil {
+		t.Errorf("created rule: got %+v", r)
+	}
+
+	rules := []oapi.RedactionRule{{Name: "r", Action: oapi.Drop, Fields: &[]string{"password"}}}
+	_, err = svc.Create(ctx, orgID, oapi.CreateRedactionPolicyRequest{Name: "pii", Stage: oapi.Archive, Rules: rules})
+	if !errors.Is(err, redaction.ErrConflict) {
+		t.Errorf("duplicate: got %v, want ErrConflict", err)
+	}
+	for _, req := range []oapi.CreateRedactionPolicyRequest{
+		{Name: "a", Stage: "storage", Rules: rules},
+		{Name: "b", Stage: oapi.Archive},
+		{Name: "c", Stage: oapi.Archive, Rules: append(rules, rules[0])},
+		{Name: "d", Stage: oapi.Archive, Rules: rules, SourceIds: &[]uuid.UUID{uuid.New()}},
+		{Name: "e", Stage: oapi.Archive, Rules: []oapi.RedactionRule{{Name: "r", Action: oapi.Scrub}}},
+	} {
+		if _, err := svc.Create(ctx, orgID, req); !errors.Is(err, redaction.ErrInvalid) {
+			t.Errorf("Create(%+v): got %v, want ErrInvalid", req, err)
+		}
+	}
+}
+
+func TestUpdateRedactionPolicy(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	p, err := svc.Create(ctx, orgID, oapi.CreateRedactionPolicyRequest{
+		Name:  "secrets",
+		Stage: oapi.Archive,
+		Rules: []oapi.RedactionRule{{Name: "r", Action: oapi.Drop, Fields: &[]string{"password"}}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	stage := oapi.Normalization
+	updated, err := svc.Update(ctx, orgID, p.Id, oapi.UpdateRedactionPolicyRequest{
+		Stage:   &stage,
+		Enabled: boolPtr(false),
+		Rules: &[]oapi.RedactionRule{
+			{Name: "r", Action: oapi.Scrub, Pattern: strPtr(`token=\S+`), Replacement: strPtr("token=*")},
+		},
+	}, []int64{p.Version})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Stage != oapi.Normalization || updated.Enabled || updated.Rules[0].Action != oapi.Scrub ||
+		updated.Version != p.Version+1 {
+		t.Errorf("updated: got %+v", updated)
+	}
+
+	_, err = svc.Update(ctx, orgID, p.Id, oapi.UpdateRedactionPolicyRequest{}, []int64{p.Version})
+	if !errors.Is(err, redaction.ErrPreconditionFailed) {
+		t.Errorf("stale If-Match: got %v, want ErrPreconditionFailed", err)
+	}
+	_, err = svc.Update(ctx, orgID, p.Id, oapi.UpdateRedactionPolicyRequest{Rules: &[]oapi.RedactionRule{}}, nil)
+	if !errors.Is(err, redaction.ErrInvalid) {
+		t.Errorf("no rules: got %v, want ErrInvalid", err)
+	}
+	_, err = svc.Update(ctx, orgID, uuid.New(), oapi.UpdateRedactionPolicyRequest{}, nil)
+	if !errors.Is(err, redaction.ErrNotFound) {
+		t.Errorf("unknown policy: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestRedactor_RedactsAndCountsBySource(t *testing.T) {
+	svc, db, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	srcID := seedSource(t, db, orgID, "web")
+	r := redaction.NewRedactor(redaction.NewRepo(db), tx.NewManager(db), discard(), oapi.Archive, time.Nanosecond)
+
+	fields := parser.Fields{"msg": "mail alice@example.com"}
+	if tally, err := r.Redact(context.Background(), orgID, srcID, fields); err != nil || tally != nil {
+		t.Fatalf("no policies: got %v, %v", tally, err)
+	}
+
+	p, err := svc.Create(ctx, orgID, oapi.CreateRedactionPolicyRequest{
+		Name:  "emails",
+		Stage: oapi.Archive,
+		Rules: []oapi.RedactionRule{{Name: "email", Action: oapi.Hash, Detector: detectorPtr(oapi.Email)}},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	tally, err := r.Redact(context.Background(), orgID, srcID, fields)
+	if err != nil || fields["msg"] == "mail alice@example.com" {
+		t.Fatalf("after create: got %v, %v", fields, err)
+	}
+	for range 2 {
+		if err := r.Record(context.Background(), orgID, srcID, tally); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+
+	if err := svc.Delete(ctx, orgID, p.Id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	counts, err := svc.Counts(ctx, orgID, srcID, nil, nil)
+	if err != nil {
+		t.Fatalf("Counts: %v", err)
+	}
+	if len(counts) != 1 || counts[0].PolicyId != p.Id || counts[0].PolicyName != nil ||
+		counts[0].Rule != "email" || counts[0].Stage != oapi.Archive || counts[0].Redactions != 2 {
+		t.Errorf("counts: got %+v", counts)
+	}
+
+	if _, err := svc.Counts(ctx, orgID, uuid.New(), nil, nil); !errors.Is(err, redaction.ErrNotFound) {
+		t.Errorf("unknown source: got %v, want ErrNotFound", err)
+	}
+	from := time.Now().Add(-40 * 24 * time.Hour)
+	if _, err := svc.Counts(ctx, orgID, srcID, &from, nil); !errors.Is(err, redaction.ErrInvalid) {
+		t.Errorf("long range: got %v, want ErrInvalid", err)
+	}
+}
@@ -0,0 +1,36 @@
+package replay
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per replay. Each carries
+// the replay as of the event.
+const (
+	aggregateType = "replay"
+
+	EventCreated   = "replay.created"
+	EventCancelled = "replay.cancelled"
+	EventFinished  = "replay.finished"
+)
+
+// emit appends an event about replayID to the outbox, in the transaction on
+// ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, replayID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   replayID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
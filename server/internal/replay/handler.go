@@ -0,0 +1,237 @@
+package replay
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+const defaultShadowLimit = 100
+
+// Handler serves the /organizations/{orgId}/replays/* endpoints. Members may
+// follow replays and read their results; admins start and cancel them.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound(detail string) oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", detail),
+	)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func conflict(detail string) oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", detail),
+	)
+}
+
+func (h *Handler) ListReplays(
+	ctx context.Context,
+	request oapi.ListReplaysRequestObject,
+) (oapi.ListReplaysResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListReplays403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	replays, err := h.svc.List(ctx, request.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	if replays == nil {
+		replays = []oapi.Replay{}
+	}
+	return oapi.ListReplays200JSONResponse(replays), nil
+}
+
+func (h *Handler) CreateReplay(
+	ctx context.Context,
+	request oapi.CreateReplayRequestObject,
+) (oapi.CreateReplayResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateReplay403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateReplay403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	r, err := h.svc.Create(ctx, request.OrgId, *request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalid), errors.Is(err, pipeline.ErrMappingVersion):
+			return oapi.CreateReplay400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, source.ErrNotFound):
+			return oapi.CreateReplay404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Source not found"),
+			}, nil
+		case errors.Is(err, archive.ErrDisabled):
+			return oapi.CreateReplay409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("The raw event archive is disabled"),
+			}, nil
+		case errors.Is(err, ErrNoStore):
+			return oapi.CreateReplay409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("There is no event store to upsert into"),
+			}, nil
+		case errors.Is(err, parser.ErrUnknownParser):
+			return oapi.CreateReplay409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("The source's parser no longer resolves"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateReplay202JSONResponse(r), nil
+}
+
+func (h *Handler) GetReplay(
+	ctx context.Context,
+	request oapi.GetReplayRequestObject,
+) (oapi.GetReplayResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetReplay403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	r, err := h.svc.Get(ctx, request.OrgId, request.ReplayId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetReplay404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Replay not found"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.GetReplay200JSONResponse(r), nil
+}
+
+func (h *Handler) CancelReplay(
+	ctx context.Context,
+	request oapi.CancelReplayRequestObject,
+) (oapi.CancelReplayResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CancelReplay403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CancelReplay403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	r, err := h.svc.Cancel(ctx, request.OrgId, request.ReplayId)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.CancelReplay404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Replay not found"),
+			}, nil
+		case errors.Is(err, ErrFinished):
+			return oapi.CancelReplay409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("The replay has already finished"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CancelReplay200JSONResponse(r), nil
+}
+
+func (h *Handler) ListReplayShadowEvents(
+	ctx context.Context,
+	request oapi.ListReplayShadowEventsRequestObject,
+) (oapi.ListReplayShadowEventsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListReplayShadowEvents403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	limit := defaultShadowLimit
+	if request.Params.Limit != nil {
+		limit = *request.Params.Limit
+	}
+	failed := request.Params.Failed != nil && *request.Params.Failed
+	events, err := h.svc.ShadowEvents(ctx, request.OrgId, request.ReplayId, request.Params.After, failed, limit)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.ListReplayShadowEvents404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound("Replay not found"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ListReplayShadowEvents200JSONResponse(events), nil
+}
@@ -0,0 +1,133 @@
+package replay_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/replay"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+// newSeededHandler wires a Handler without an archive, as when none is
+// configured.
+func newSeededHandler(t *testing.T) (*replay.Handler, oapi.Organization) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+
+	userSvc := user.NewService(user.NewRepo(db), txm, events, logger)
+	orgSvc := org.NewService(org.NewRepo(db), txm, events, logger)
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser("user_replay_handler_owner", "ownr@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), "Replay Handler Org", nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+
+	sourceSvc := source.NewService(source.NewRepo(db), txm, events, logger)
+	patternSvc := pattern.NewService(pattern.NewRepo(db), txm, events, logger)
+	svc := replay.NewService(
+		replay.NewRepo(db),
+		archive.NewService(nil, archive.NewRepo(db), txm),
+		pipeline.New(sourceSvc, patternSvc, normalization.NewService(
+			normalization.NewRepo(db), sourceSvc, patternSvc, txm, events, logger,
+		)),
+		nil,
+		jobs.NewRepo(db),
+		txm,
+		events,
+		logger,
+	)
+	return replay.NewHandler(svc, userSvc, orgSvc), o
+}
+
+func ownerCtx() context.Context {
+	return middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_replay_handler_owner", "ownr@example.com"),
+	)
+}
+
+func TestListReplays_UnauthenticatedReturnsForbidden(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	resp, err := h.ListReplays(context.Background(), oapi.ListReplaysRequestObject{OrgId: org.Id})
+	if err != nil {
+		t.Fatalf("ListReplays: %v", err)
+	}
+	if _, ok := resp.(oapi.ListReplays403ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 403, got %T", resp)
+	}
+}
+
+func TestCreateReplay_Validation(t *testing.T) {
+	h, org := newSeededHandler(t)
+	from := time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+
+	for _, c := range []struct {
+		name string
+		to   time.Time
+		want int
+	}{
+		{"backwards range", from.Add(-time.Hour), 400},
+		{"archive disabled", from.Add(time.Hour), 409},
+	} {
+		resp, err := h.CreateReplay(ownerCtx(), oapi.CreateReplayRequestObject{
+			OrgId: org.Id,
+			Body:  &oapi.CreateReplayJSONRequestBody{From: from, To: c.to, Target: oapi.Shadow},
+		})
+		if err != nil {
+			t.Fatalf("CreateReplay %s: %v", c.name, err)
+		}
+		got := 0
+		switch resp.(type) {
+		case oapi.CreateReplay400ApplicationProblemPlusJSONResponse:
+			got = 400
+		case oapi.CreateReplay409ApplicationProblemPlusJSONResponse:
+			got = 409
+		}
+		if got != c.want {
+			t.Errorf("%s: want %d, got %T", c.name, c.want, resp)
+		}
+	}
+}
@@ -0,0 +1,327 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns replays and replay_shadow_events SQL and row→DTO mapping. Both
+// tables are protected by RLS, so callers run statements inside
+// tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.Replays) oapi.Replay {
+	return oapi.Replay{
+		Id:              m.ID,
+		OrgId:           m.OrgID,
+		SourceId:        m.SourceID,
+		From:            m.FromTime,
+		To:              m.ToTime,
+		MappingVersion:  mappingVersion(m),
+		Target:          oapi.ReplayTarget(m.Target),
+		Status:          oapi.ReplayStatus(m.Status),
+		SegmentsTotal:   int(m.SegmentsTotal),
+		SegmentsDone:    int(m.SegmentsDone),
+		EventsRead:      m.EventsRead,
+		EventsProcessed: m.EventsProcessed,
+		EventsFailed:    m.EventsFailed,
+		Error:           m.Error,
+		StartedAt:       m.StartedAt,
+		FinishedAt:      m.FinishedAt,
+		CreatedAt:       m.CreatedAt,
+		UpdatedAt:       m.UpdatedAt,
+	}
+}
+
+func toShadowOapi(m model.ReplayShadowEvents) oapi.ReplayShadowEvent {
+	e := oapi.ReplayShadowEvent{EventId: m.EventID, SourceId: m.SourceID, Time: m.Time, Error: m.Error}
+	if m.Output != nil {
+		var output map[string]any
+		if err := json.Unmarshal([]byte(*m.Output), &output); err == nil {
+			e.Output = &output
+		}
+	}
+	return e
+}
+
+// unfinished matches replays that have not reached a final status.
+func unfinished(t *table.ReplaysTable) postgres.BoolExpression {
+	return t.Status.IN(postgres.String(StatusPending), postgres.String(StatusRunning))
+}
+
+// Insert stores a new pending replay.
+func (r *Repo) Insert(ctx context.Context, orgID uuid.UUID, req oapi.CreateReplayRequest) (model.Replays, error) {
+	var mappingVersion *int32
+	if req.MappingVersion != nil {
+		v := int32(*req.MappingVersion) //nolint:gosec // bounded by the API schema
+		mappingVersion = &v
+	}
+	t := table.Replays
+	stmt := t.
+		INSERT(t.OrgID, t.SourceID, t.FromTime, t.ToTime, t.MappingVersion, t.Target).
+		VALUES(orgID, req.SourceId, req.From, req.To, mappingVersion, string(req.Target)).
+		RETURNING(t.AllColumns)
+
+	var row model.Replays
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		return model.Replays{}, fmt.Errorf("inserting replay: %w", err)
+	}
+	return row, nil
+}
+
+// List returns the org's replays, newest first.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID) ([]oapi.Replay, error) {
+	t := table.Replays
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID))).
+		ORDER_BY(t.CreatedAt.DESC())
+
+	var rows []model.Replays
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing replays: %w", err)
+	}
+	replays := make([]oapi.Replay, 0, len(rows))
+	for _, row := range rows {
+		replays = append(replays, toOapi(row))
+	}
+	return replays, nil
+}
+
+// Get returns a single replay of the org. Returns ErrNotFound when it does
+// not exist in that org.
+func (r *Repo) Get(ctx context.Context, orgID, replayID uuid.UUID) (model.Replays, error) {
+	t := table.Replays
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(replayID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.Replays
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return model.Replays{}, ErrNotFound
+		}
+		return model.Replays{}, fmt.Errorf("getting replay: %w", err)
+	}
+	return row, nil
+}
+
+// Start marks a pending replay running with the number of segments it will
+// read. Returns false when the replay is no longer pending.
+func (r *Repo) Start(ctx context.Context, replayID uuid.UUID, segments int) (bool, error) {
+	t := table.Replays
+	stmt := t.
+		UPDATE().
+		SET(
+			t.Status.SET(postgres.String(StatusRunning)),
+			t.SegmentsTotal.SET(postgres.Int(int64(segments))),
+			t.StartedAt.SET(postgres.NOW()),
+			t.UpdatedAt.SET(postgres.NOW()),
+		).
+		WHERE(t.ID.EQ(postgres.UUID(replayID)).AND(t.Status.EQ(postgres.String(StatusPending))))
+
+	return r.affected(ctx, stmt, "starting replay")
+}
+
+// Checkpoint records a finished segment: it becomes the cursor and p is added
+// to the replay's progress. Returns false when the replay is no longer
+// running, as after a cancellation; the row stays locked until the
+// transaction ends, so a concurrent cancellation waits for the checkpoint.
+func (r *Repo) Checkpoint(ctx context.Context, replayID uuid.UUID, seg archive.Segment, p Progress) (bool, error) {
+	t := table.Replays
+	done := t.SegmentsDone.ADD(postgres.Int(1))
+	stmt := t.
+		UPDATE().
+		SET(
+			t.SegmentsDone.SET(done),
+			t.SegmentsTotal.SET(postgres.IntExp(postgres.GREATEST(t.SegmentsTotal, done))),
+			t.EventsRead.SET(t.EventsRead.ADD(postgres.Int(p.Read))),
+			t.EventsProcessed.SET(t.EventsProcessed.ADD(postgres.Int(p.Processed))),
+			t.EventsFailed.SET(t.EventsFailed.ADD(postgres.Int(p.Failed))),
+			t.CursorTime.SET(postgres.TimestampzT(seg.MinTime)),
+			t.CursorKey.SET(postgres.String(seg.Key)),
+			t.UpdatedAt.SET(postgres.NOW()),
+		).
+		WHERE(t.ID.EQ(postgres.UUID(replayID)).AND(t.Status.EQ(postgres.String(StatusRunning))))
+
+	return r.affected(ctx, stmt, "checkpointing replay")
+}
+
+// Finish moves an unfinished replay to a final status, recording why it
+// failed if it did. Returns false when it had already finished.
+func (r *Repo) Finish(ctx context.Context, replayID uuid.UUID, status string, reason *string) (bool, error) {
+	t := table.Replays
+	stmt := t.
+		UPDATE().
+		SET(
+			t.Status.SET(postgres.String(status)),
+			t.Error.SET(stringOrNull(reason)),
+			t.FinishedAt.SET(postgres.NOW()),
+			t.UpdatedAt.SET(postgres.NOW()),
+		).
+		WHERE(t.ID.EQ(postgres.UUID(replayID)).AND(unfinished(t)))
+
+	return r.affected(ctx, stmt, "finishing replay")
+}
+
+// Cancel marks an unfinished replay of the org cancelled. Returns ErrNotFound
+// when it does not exist in that org and ErrFinished when it has already
+// finished.
+func (r *Repo) Cancel(ctx context.Context, orgID, replayID uuid.UUID) (oapi.Replay, error) {
+	t := table.Replays
+	stmt := t.
+		UPDATE().
+		SET(
+			t.Status.SET(postgres.String(StatusCancelled)),
+			t.FinishedAt.SET(postgres.NOW()),
+			t.UpdatedAt.SET(postgres.NOW()),
+		).
+		WHERE(
+			t.ID.EQ(postgres.UUID(replayID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))).
+				AND(unfinished(t)),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Replays
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if !errors.Is(err, qrm.ErrNoRows) {
+			return oapi.Replay{}, fmt.Errorf("cancelling replay: %w", err)
+		}
+		if _, err := r.Get(ctx, orgID, replayID); err != nil {
+			return oapi.Replay{}, err
+		}
+		return oapi.Replay{}, ErrFinished
+	}
+	return toOapi(row), nil
+}
+
+// affected runs an update and reports whether it changed a row.
+func (r *Repo) affected(ctx context.Context, stmt postgres.UpdateStatement, doing string) (bool, error) {
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", doing, err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", doing, err)
+	}
+	return n > 0, nil
+}
+
+// ── Shadow events ────────────────────────────────────────────────────────────
+
+// shadowBatch bounds the rows of one shadow insert, keeping it under the
+// protocol's limit on bind parameters.
+const shadowBatch = 1000
+
+// SaveShadow stores what a shadow replay produced, replacing an earlier
+// result for the same event: an event archived twice is reported once.
+func (r *Repo) SaveShadow(ctx context.Context, rows []model.ReplayShadowEvents) error {
+	t := table.ReplayShadowEvents
+	for batch := range slices.Chunk(rows, shadowBatch) {
+		stmt := t.INSERT(t.ReplayID, t.EventID, t.OrgID, t.SourceID, t.Time, t.Output, t.Error)
+		for _, row := range batch {
+			stmt = stmt.VALUES(row.ReplayID, row.EventID, row.OrgID, row.SourceID, row.Time, row.Output, row.Error)
+		}
+		stmt = stmt.
+			ON_CONFLICT(t.ReplayID, t.EventID).
+			DO_UPDATE(postgres.SET(
+				t.SourceID.SET(t.EXCLUDED.SourceID),
+				t.Time.SET(t.EXCLUDED.Time),
+				t.Output.SET(t.EXCLUDED.Output),
+				t.Error.SET(t.EXCLUDED.Error),
+			))
+
+		if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+			return fmt.Errorf("saving replay shadow events: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListShadow returns up to limit of a replay's shadow events in event ID
+// order, after the given event if any, and only failed ones if failed is
+// set.
+func (r *Repo) ListShadow(
+	ctx context.Context,
+	orgID, replayID uuid.UUID,
+	after *uuid.UUID,
+	failed bool,
+	limit int,
+) ([]oapi.ReplayShadowEvent, error) {
+	t := table.ReplayShadowEvents
+	cond := t.ReplayID.EQ(postgres.UUID(replayID)).AND(t.OrgID.EQ(postgres.UUID(orgID)))
+	if after != nil {
+		cond = cond.AND(t.EventID.GT(postgres.UUID(*after)))
+	}
+	if failed {
+		cond = cond.AND(t.Error.IS_NOT_NULL())
+	}
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(cond).
+		ORDER_BY(t.EventID.ASC()).
+		LIMIT(int64(limit))
+
+	var rows []model.ReplayShadowEvents
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing replay shadow events: %w", err)
+	}
+	events := make([]oapi.ReplayShadowEvent, 0, len(rows))
+	for _, row := range rows {
+		events = append(events, toShadowOapi(row))
+	}
+	return events, nil
+}
+
+// ── Helpers ──────────────────────────────────────────────────────────────────
+
+func stringOrNull(s *string) postgres.StringExpression {
+	if s == nil {
+		return postgres.StringExp(postgres.NULL)
+	}
+	return postgres.String(*s)
+}
+
+// RunArgs is the job that advances a replay. Each run carries on from the
+// replay's cursor for a while, then enqueues the next run.
+type RunArgs struct {
+	OrgID    uuid.UUID `json:"org_id"`
+	ReplayID uuid.UUID `json:"replay_id"`
+}
+
+// Kind implements jobs.Args.
+func (RunArgs) Kind() string { return "replay.run" }
+
+// runKey deduplicates the run that follows done segments.
+func runKey(replayID uuid.UUID, done int32) string {
+	return fmt.Sprintf("%s:%d", replayID, done)
+}
@@ -0,0 +1,250 @@
+package replay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/blob"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+// runBudget bounds how long one run reads segments before handing over to
+// the next, well inside the job worker's lease.
+const runBudget = time.Minute
+
+// Run is the RunArgs job handler. It carries the replay on from its cursor
+// for up to runBudget, then enqueues the next run, or finishes the replay
+// once no segment is left. A failure no retry can fix, such as a corrupt
+// segment or a source whose parser no longer resolves, fails the replay;
+// any other fails the run, which the job worker retries from the cursor.
+func (s *Service) Run(ctx context.Context, args RunArgs) error {
+	ctx = tenant.WithOrg(ctx, args.OrgID)
+	var row model.Replays
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		row, err = s.repo.Get(ctx, args.OrgID, args.ReplayID)
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		// Deleted along with its org or source.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if row.Status != StatusPending && row.Status != StatusRunning {
+		return nil
+	}
+	if row.Target == TargetUpsert && s.store == nil {
+		return s.fail(ctx, row, ErrNoStore)
+	}
+
+	segments, err := s.archive.Segments(ctx, row.OrgID, row.SourceID, row.FromTime, row.ToTime)
+	if err != nil {
+		return s.fail(ctx, row, err)
+	}
+	if row.Status == StatusPending {
+		var started bool
+		err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+			var err error
+			started, err = s.repo.Start(ctx, row.ID, len(segments))
+			return err
+		})
+		if err != nil || !started {
+			return err
+		}
+	}
+	segments = afterCursor(segments, row)
+
+	deadline := time.Now().Add(runBudget)
+	processors := make(map[uuid.UUID]*pipeline.Processor)
+	for i, seg := range segments {
+		if i > 0 && time.Now().After(deadline) {
+			return s.handOver(ctx, row, row.SegmentsDone+int32(i)) //nolint:gosec // segment counts fit
+		}
+		proc, ok := processors[seg.SourceID]
+		if !ok {
+			opts := pipeline.Options{MappingVersion: mappingVersion(row)}
+			if proc, err = s.pipeline.Prepare(ctx, row.OrgID, seg.SourceID, opts); err != nil {
+				return s.fail(ctx, row, err)
+			}
+			processors[seg.SourceID] = proc
+		}
+		msgs, err := s.archive.Read(ctx, seg, row.FromTime, row.ToTime)
+		if err != nil {
+			return s.fail(ctx, row, err)
+		}
+		running, err := s.replaySegment(ctx, row, seg, proc, msgs)
+		if err != nil || !running {
+			return err
+		}
+	}
+	return s.finish(ctx, row, StatusSucceeded, nil)
+}
+
+// replaySegment processes a segment's events and checkpoints them, writing
+// them to the replay's target in the same transaction. Upserting replays
+// record the events they store for the first time, counting their
+// redactions and sightings of the entities they mention; those stored before
+// were recorded then, and shadow output is not stored.
+// Returns false when the replay is no longer running, in which case nothing
+// is written. An event in the segment twice is processed once; one in two
+// segments is written twice, the second write replacing the first.
+func (s *Service) replaySegment(
+	ctx context.Context,
+	row model.Replays,
+	seg archive.Segment,
+	proc *pipeline.Processor,
+	msgs []queue.Message,
+) (bool, error) {
+	var p Progress
+	var events []pipeline.Event
+	var shadow []model.ReplayShadowEvents
+	seen := make(map[uuid.UUID]bool, len(msgs))
+	for _, m := range msgs {
+		if seen[m.ID] {
+			continue
+		}
+		seen[m.ID] = true
+		p.Read++
+		ev, err := proc.Process(ctx, m)
+		var output []byte
+		if err == nil {
+			output, err = json.Marshal(ev.Output())
+		}
+		if err != nil {
+			p.Failed++
+		} else {
+			p.Processed++
+			events = append(events, ev)
+		}
+		if row.Target == TargetShadow {
+			shadow = append(shadow, shadowRow(row, m, output, err))
+		}
+	}
+
+	var running bool
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if running, err = s.repo.Checkpoint(ctx, row.ID, seg, p); err != nil || !running {
+			return err
+		}
+		if row.Target == TargetShadow {
+			return s.repo.SaveShadow(ctx, shadow)
+		}
+		stored, err := s.store.Upsert(ctx, events)
+		if err != nil {
+			return err
+		}
+		return proc.Record(ctx, row.OrgID, seg.SourceID, stored)
+	})
+	return running, err
+}
+
+// handOver enqueues the run that carries on after done segments. The key
+// makes enqueueing it again, from a retried run, a no-op.
+func (s *Service) handOver(ctx context.Context, row model.Replays, done int32) error {
+	args := RunArgs{OrgID: row.OrgID, ReplayID: row.ID}
+	_, err := s.jobs.Enqueue(ctx, args, jobs.EnqueueOpts{UniqueKey: runKey(row.ID, done)})
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// fail finishes the replay as failed when err is one no retry can fix, and
+// returns err otherwise so the run is retried.
+func (s *Service) fail(ctx context.Context, row model.Replays, err error) error {
+	if !permanent(err) {
+		return err
+	}
+	s.logger.WarnContext(ctx, "replay failed",
+		slog.String("replay_id", row.ID.String()), slog.Any("err", err))
+	reason := err.Error()
+	return s.finish(ctx, row, StatusFailed, &reason)
+}
+
+// finish moves the replay to a final status unless it already has one, as
+// when it was cancelled meanwhile.
+func (s *Service) finish(ctx context.Context, row model.Replays, status string, reason *string) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		finished, err := s.repo.Finish(ctx, row.ID, status, reason)
+		if err != nil || !finished {
+			return err
+		}
+		if row, err = s.repo.Get(ctx, row.OrgID, row.ID); err != nil {
+			return err
+		}
+		return s.emit(ctx, row.OrgID, row.ID, EventFinished, toOapi(row))
+	})
+}
+
+// permanent reports whether err is a failure no retry of the run can fix.
+func permanent(err error) bool {
+	for _, target := range []error{
+		archive.ErrDisabled,
+		archive.ErrCorrupt,
+		blob.ErrNotFound,
+		source.ErrNotFound,
+		parser.ErrUnknownParser,
+		pipeline.ErrMappingVersion,
+		ErrNoStore,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// afterCursor drops the segments up to and including the replay's cursor,
+// which are done.
+func afterCursor(segments []archive.Segment, row model.Replays) []archive.Segment {
+	if row.CursorTime == nil || row.CursorKey == nil {
+		return segments
+	}
+	for i, seg := range segments {
+		if seg.MinTime.After(*row.CursorTime) ||
+			seg.MinTime.Equal(*row.CursorTime) && seg.Key > *row.CursorKey {
+			return segments[i:]
+		}
+	}
+	return nil
+}
+
+func mappingVersion(row model.Replays) *int {
+	if row.MappingVersion == nil {
+		return nil
+	}
+	v := int(*row.MappingVersion)
+	return &v
+}
+
+func shadowRow(row model.Replays, m queue.Message, output []byte, err error) model.ReplayShadowEvents {
+	e := model.ReplayShadowEvents{
+		ReplayID: row.ID,
+		EventID:  m.ID,
+		OrgID:    row.OrgID,
+		SourceID: m.SourceID,
+		Time:     m.Time,
+	}
+	if err != nil {
+		msg := err.Error()
+		e.Error = &msg
+	} else {
+		out := string(output)
+		e.Output = &out
+	}
+	return e
+}
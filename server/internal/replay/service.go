@@ -0,0 +1,215 @@
+// Package replay runs archived raw events through the pipeline again, for
+// instance after fixing a parser or to try out a new mapping version. A
+// replay selects an org's events by source and time range; a background job
+// reads the matching archive segments oldest first and either upserts the
+// processed events into the event store or keeps them aside in a shadow
+// table, per replay, for comparison with what is stored.
+//
+// Runs of the job checkpoint after every segment, recording the segment as
+// the replay's cursor along with its progress, so a run that stops is picked
+// up where it left off. A run only lasts a while before handing over to the
+// next one; cancelling a replay takes effect at its next checkpoint.
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Replay statuses, mirrored by the ReplayStatus enum in the OpenAPI spec.
+const (
+	StatusPending   = "pending"
+	StatusRunning   = "running"
+	StatusSucceeded = "succeeded"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled"
+)
+
+// Replay targets, mirrored by the ReplayTarget enum in the OpenAPI spec.
+const (
+	TargetUpsert = "upsert"
+	TargetShadow = "shadow"
+)
+
+// ErrNotFound is returned when a replay does not exist in the org.
+var ErrNotFound = errors.New("replay not found")
+
+// ErrInvalid is returned for a replay request that cannot be run as asked.
+var ErrInvalid = errors.New("invalid replay")
+
+// ErrFinished is returned when cancelling a replay that has already finished.
+var ErrFinished = errors.New("replay already finished")
+
+// ErrNoStore is returned when upserting replayed events without an event
+// store to upsert them into.
+var ErrNoStore = errors.New("no event store to upsert into")
+
+// Store is where upserting replays put processed events. Upsert replaces the
+// stored events with the same IDs, joins the transaction on ctx and returns
+// the events it stored for the first time.
+type Store interface {
+	Upsert(ctx context.Context, events []pipeline.Event) ([]pipeline.Event, error)
+}
+
+// Progress counts the events of a replay.
+type Progress struct {
+	// Read counts the archived events in the replay's range.
+	Read int64
+	// Processed and Failed split them by whether they could be processed.
+	Processed int64
+	Failed    int64
+}
+
+// Service orchestrates replays. Each operation runs in a transaction carrying
+// the caller's tenant scope, joining one already on ctx. Mutations append
+// their domain event to the outbox in that same transaction.
+type Service struct {
+	repo     *Repo
+	archive  *archive.Service
+	pipeline *pipeline.Pipeline
+	store    Store
+	jobs     *jobs.Repo
+	tx       *tx.Manager
+	events   *outbox.Repo
+	logger   *slog.Logger
+}
+
+// NewService wires a Service. store is nil when there is no event store, in
+// which case replays can only target the shadow table.
+func NewService(
+	repo *Repo,
+	archiveSvc *archive.Service,
+	pipe *pipeline.Pipeline,
+	store Store,
+	jobsRepo *jobs.Repo,
+	txm *tx.Manager,
+	events *outbox.Repo,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		repo:     repo,
+		archive:  archiveSvc,
+		pipeline: pipe,
+		store:    store,
+		jobs:     jobsRepo,
+		tx:       txm,
+		events:   events,
+		logger:   logger,
+	}
+}
+
+// Create starts a replay, enqueueing its first run. Returns ErrInvalid for a
+// bad request, archive.ErrDisabled without an archive, ErrNoStore when
+// upserting without an event store, and the errors of pipeline.Prepare for a
+// source that cannot be processed as asked.
+func (s *Service) Create(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateReplayRequest,
+) (oapi.Replay, error) {
+	switch {
+	case !req.To.After(req.From):
+		return oapi.Replay{}, fmt.Errorf("%w: to must be after from", ErrInvalid)
+	case req.Target != TargetUpsert && req.Target != TargetShadow:
+		return oapi.Replay{}, fmt.Errorf("%w: unknown target %q", ErrInvalid, req.Target)
+	case req.MappingVersion != nil && req.SourceId == nil:
+		return oapi.Replay{}, fmt.Errorf("%w: pinning a mapping version requires a source", ErrInvalid)
+	case !s.archive.Enabled():
+		return oapi.Replay{}, archive.ErrDisabled
+	case req.Target == TargetUpsert && s.store == nil:
+		return oapi.Replay{}, ErrNoStore
+	}
+
+	var r oapi.Replay
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if req.SourceId != nil {
+			opts := pipeline.Options{MappingVersion: req.MappingVersion}
+			if _, err := s.pipeline.Prepare(ctx, orgID, *req.SourceId, opts); err != nil {
+				return err
+			}
+		}
+		row, err := s.repo.Insert(ctx, orgID, req)
+		if err != nil {
+			return err
+		}
+		args := RunArgs{OrgID: orgID, ReplayID: row.ID}
+		if _, err := s.jobs.Enqueue(ctx, args, jobs.EnqueueOpts{UniqueKey: runKey(row.ID, 0)}); err != nil {
+			return err
+		}
+		r = toOapi(row)
+		return s.emit(ctx, orgID, r.Id, EventCreated, r)
+	})
+	return r, err
+}
+
+// List returns the org's replays, newest first.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.Replay, error) {
+	var replays []oapi.Replay
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		replays, err = s.repo.List(ctx, orgID)
+		return err
+	})
+	return replays, err
+}
+
+// Get returns a single replay with its progress. Returns ErrNotFound when it
+// does not exist in the org.
+func (s *Service) Get(ctx context.Context, orgID, replayID uuid.UUID) (oapi.Replay, error) {
+	var r oapi.Replay
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, replayID)
+		if err != nil {
+			return err
+		}
+		r = toOapi(row)
+		return nil
+	})
+	return r, err
+}
+
+// Cancel stops a replay at its next checkpoint. Returns ErrFinished when it
+// has already finished.
+func (s *Service) Cancel(ctx context.Context, orgID, replayID uuid.UUID) (oapi.Replay, error) {
+	var r oapi.Replay
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if r, err = s.repo.Cancel(ctx, orgID, replayID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, replayID, EventCancelled, r)
+	})
+	return r, err
+}
+
+// ShadowEvents pages through what a replay kept in the shadow table, by event
+// ID. Returns ErrNotFound when the replay does not exist in the org.
+func (s *Service) ShadowEvents(
+	ctx context.Context,
+	orgID, replayID uuid.UUID,
+	after *uuid.UUID,
+	failed bool,
+	limit int,
+) ([]oapi.ReplayShadowEvent, error) {
+	var events []oapi.ReplayShadowEvent
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, replayID); err != nil {
+			return err
+		}
+		var err error
+		events, err = s.repo.ListShadow(ctx, orgID, replayID, after, failed, limit)
+		return err
+	})
+	return events, err
+}
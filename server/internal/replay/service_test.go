@@ -0,0 +1,306 @@
+package replay_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/archive"
+	"github.com/luketeo/horizon/internal/normalization"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/blob"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/queue"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/replay"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+const cefMessage = `CEF:0|Acme|Firewall|1.0|100|Blocked|5|src=10.0.0.1`
+
+var base = time.Date(2026, 10, 19, 14, 0, 0, 0, time.UTC)
+
+type fixture struct {
+	archiver *archive.Archiver
+	svc      *replay.Service
+	mappings *normalization.Service
+	orgID    uuid.UUID
+	sourceID uuid.UUID
+}
+
+func newFixture(t *testing.T) fixture {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	var orgID, sourceID uuid.UUID
+	if err := db.QueryRowContext(ctx,
+		`INSERT INTO organizations (name, slug) VALUES ('Replay Org', 'replay-org') RETURNING id`,
+	).Scan(&orgID); err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	if err := db.QueryRowContext(ctx,
+		`INSERT INTO sources (org_id, name, type, token_hash, token_prefix, parser)
+		 VALUES ($1, 'firewall', 'syslog', 'hash', 'hzn_src_', 'cef') RETURNING id`,
+		orgID,
+	).Scan(&sourceID); err != nil {
+		t.Fatalf("seed source: %v", err)
+	}
+
+	store, err := blob.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewLocal: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+	privileged := testhelper.PrivilegedDB(t)
+	archiveRepo := archive.NewRepo(db)
+	sourceSvc := source.NewService(source.NewRepo(db), txm, events, logger)
+	patternSvc := pattern.NewService(pattern.NewRepo(db), txm, events, logger)
+	mappingSvc := normalization.NewService(normalization.NewRepo(db), sourceSvc, patternSvc, txm, events, logger)
+	pipe := pipeline.New(sourceSvc, patternSvc, mappingSvc)
+	return fixture{
+		archiver: archive.NewArchiver(store, archive.NewRepo(privileged), logger),
+		svc: replay.NewService(
+			replay.NewRepo(db),
+			archive.NewService(store, archiveRepo, txm),
+			pipe,
+			nil,
+			jobs.NewRepo(privileged),
+			txm,
+			events,
+			logger,
+		),
+		mappings: mappingSvc,
+		orgID:    orgID,
+		sourceID: sourceID,
+	}
+}
+
+// archiveEvents archives a syslog event per message, a minute apart from
+// base.
+func (f fixture) archiveEvents(t *testing.T, messages ...string) []queue.Message {
+	t.Helper()
+	ds := make([]queue.Delivery, len(messages))
+	msgs := make([]queue.Message, len(messages))
+	for i, text := range messages {
+		payload, _ := json.Marshal(map[string]any{"hostname": "fw1", "message": text})
+		msgs[i] = queue.Message{
+			ID:           uuid.New(),
+			OrgID:        f.orgID,
+			SourceID:     f.sourceID,
+			Payload:      payload,
+			Time:         base.Add(time.Duration(i) * time.Minute),
+			TimeStatus:   "parsed",
+			ReceivedTime: base.Add(time.Duration(i)*time.Minute + time.Second),
+		}
+		ds[i] = queue.Delivery{Message: msgs[i], Seq: int64(i + 1), Attempt: 1}
+	}
+	if err := f.archiver.Handle(context.Background(), ds); err != nil {
+		t.Fatalf("archiving: %v", err)
+	}
+	return msgs
+}
+
+func (f fixture) request(target oapi.ReplayTarget) oapi.CreateReplayRequest {
+	return oapi.CreateReplayRequest{From: base, To: base.Add(time.Hour), Target: target}
+}
+
+func TestRun_ShadowsProcessedEvents(t *testing.T) {
+	f := newFixture(t)
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	msgs := f.archiveEvents(t, cefMessage, "not cef", cefMessage)
+
+	r, err := f.svc.Create(ctx, f.orgID, f.request(oapi.Shadow))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if r.Status != replay.StatusPending {
+		t.Errorf("Create: want pending, got %s", r.Status)
+	}
+	if err := f.svc.Run(context.Background(), replay.RunArgs{OrgID: f.orgID, ReplayID: r.Id}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got, err := f.svc.Get(ctx, f.orgID, r.Id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Status != replay.StatusSucceeded || got.FinishedAt == nil {
+		t.Errorf("status: want succeeded, got %s", got.Status)
+	}
+	if got.SegmentsTotal != 1 || got.SegmentsDone != 1 ||
+		got.EventsRead != 3 || got.EventsProcessed != 2 || got.EventsFailed != 1 {
+		t.Errorf("progress: got %+v", got)
+	}
+
+	shadow, err := f.svc.ShadowEvents(ctx, f.orgID, r.Id, nil, false, 100)
+	if err != nil || len(shadow) != 3 {
+		t.Fatalf("ShadowEvents: want 3, got %d (%v)", len(shadow), err)
+	}
+	failed, err := f.svc.ShadowEvents(ctx, f.orgID, r.Id, nil, true, 100)
+	if err != nil || len(failed) != 1 || failed[0].EventId != msgs[1].ID || failed[0].Error == nil {
+		t.Fatalf("ShadowEvents failed: want the second event, got %+v (%v)", failed, err)
+	}
+	for _, e := range shadow {
+		if e.EventId == msgs[1].ID {
+			continue
+		}
+		if e.Output == nil || (*e.Output)["device_vendor"] != "Acme" || (*e.Output)["hostname"] != "fw1" {
+			t.Errorf("output of %s: got %v", e.EventId, e.Output)
+		}
+	}
+
+	page, err := f.svc.ShadowEvents(ctx, f.orgID, r.Id, &shadow[0].EventId, false, 1)
+	if err != nil || len(page) != 1 || page[0].EventId != shadow[1].EventId {
+		t.Errorf("ShadowEvents after the first: want the second, got %+v (%v)", page, err)
+	}
+}
+
+func TestRun_ShadowsPinnedMappingVersion(t *testing.T) {
+	f := newFixture(t)
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	f.archiveEvents(t, cefMessage)
+
+	define := func(severity int) oapi.MappingDefinition {
+		return oapi.MappingDefinition{
+			"defaults": map[string]any{
+				"class_uid":        4001,
+				"activity_id":      6,
+				"severity_id":      severity,
+				"time":             0,
+				"metadata":         map[string]any{"product": map[string]any{"name": "Firewall"}},
+				"dst_endpoint":     map[string]any{"ip": "10.0.0.2"},
+				"src_endpoint.uid": "fw1",
+			},
+			"fields": []any{map[string]any{"to": "src_endpoint.ip", "from": "extensions.src"}},
+		}
+	}
+	m, err := f.mappings.Create(ctx, f.orgID, oapi.CreateMappingRequest{Name: "firewall", Definition: define(1)})
+	if err != nil {
+		t.Fatalf("Create mapping: %v", err)
+	}
+	if _, err := f.mappings.CreateVersion(ctx, f.orgID, m.Id, define(4)); err != nil {
+		t.Fatalf("CreateVersion: %v", err)
+	}
+	if _, err := f.mappings.Activate(ctx, f.orgID, f.sourceID, m.Id, 2); err != nil {
+		t.Fatalf("Activate: %v", err)
+	}
+
+	req := f.request(oapi.Shadow)
+	pinned := 1
+	req.SourceId, req.MappingVersion = &f.sourceID, &pinned
+	r, err := f.svc.Create(ctx, f.orgID, req)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := f.svc.Run(context.Background(), replay.RunArgs{OrgID: f.orgID, ReplayID: r.Id}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	shadow, err := f.svc.ShadowEvents(ctx, f.orgID, r.Id, nil, false, 100)
+	if err != nil || len(shadow) != 1 || shadow[0].Output == nil {
+		t.Fatalf("ShadowEvents: want one output, got %+v (%v)", shadow, err)
+	}
+	out := *shadow[0].Output
+	if out["type_uid"] != float64(400106) || out["severity"] != "Informational" {
+		t.Errorf("output: want version 1's OCSF event, got %v", out)
+	}
+	if src, _ := out["src_endpoint"].(map[string]any); src["ip"] != "10.0.0.1" {
+		t.Errorf("src_endpoint: got %v", out["src_endpoint"])
+	}
+}
+
+func TestRun_CancelledReplayStops(t *testing.T) {
+	f := newFixture(t)
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	f.archiveEvents(t, cefMessage)
+
+	r, err := f.svc.Create(ctx, f.orgID, f.request(oapi.Shadow))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := f.svc.Cancel(ctx, f.orgID, r.Id); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if err := f.svc.Run(context.Background(), replay.RunArgs{OrgID: f.orgID, ReplayID: r.Id}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got, err := f.svc.Get(ctx, f.orgID, r.Id)
+	if err != nil || got.Status != replay.StatusCancelled || got.EventsRead != 0 {
+		t.Errorf("Get: want cancelled before reading, got %+v (%v)", got, err)
+	}
+	if _, err := f.svc.Cancel(ctx, f.orgID, r.Id); !errors.Is(err, replay.ErrFinished) {
+		t.Errorf("Cancel again: want ErrFinished, got %v", err)
+	}
+}
+
+func TestCreate_Rejections(t *testing.T) {
+	f := newFixture(t)
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	version := 2
+
+	backwards := f.request(oapi.Shadow)
+	backwards.From, backwards.To = backwards.To, backwards.From
+	unpinnable := f.request(oapi.Shadow)
+	unpinnable.MappingVersion = &version
+	pinned := unpinnable
+	pinned.SourceId = &f.sourceID
+	missing := f.request(oapi.Shadow)
+	other := uuid.New()
+	missing.SourceId = &other
+
+	for _, c := range []struct {
+		name string
+		req  oapi.CreateReplayRequest
+		want error
+	}{
+		{"backwards range", backwards, replay.ErrInvalid},
+		{"version without source", unpinnable, replay.ErrInvalid},
+		{"unknown version", pinned, pipeline.ErrMappingVersion},
+		{"unknown source", missing, source.ErrNotFound},
+		{"upsert without store", f.request(oapi.Upsert), replay.ErrNoStore},
+	} {
+		if _, err := f.svc.Create(ctx, f.orgID, c.req); !errors.Is(err, c.want) {
+			t.Errorf("%s: want %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	replays, err := f.svc.List(ctx, f.orgID)
+	if err != nil || len(replays) != 0 {
+		t.Errorf("List: want none created, got %+v (%v)", replays, err)
+	}
+}
+
+func TestReplays_InvisibleAcrossTenants(t *testing.T) {
+	f := newFixture(t)
+	ctx := tenant.WithOrg(context.Background(), f.orgID)
+	r, err := f.svc.Create(ctx, f.orgID, f.request(oapi.Shadow))
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	other := tenant.WithOrg(context.Background(), uuid.New())
+	if _, err := f.svc.Get(other, f.orgID, r.Id); !errors.Is(err, replay.ErrNotFound) {
+		t.Errorf("Get from another org: want ErrNotFound, got %v", err)
+	}
+	if _, err := f.svc.Cancel(other, f.orgID, r.Id); !errors.Is(err, replay.ErrNotFound) {
+		t.Errorf("Cancel from another org: want ErrNotFound, got %v", err)
+	}
+}
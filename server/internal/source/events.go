@@ -0,0 +1,43 @@
+package source
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per source. Payloads
+// never include token material.
+const (
+	aggregateType = "source"
+
+	EventCreated      = "source.created"
+	EventUpdated      = "source.updated"
+	EventDeleted      = "source.deleted"
+	EventTokenRotated = "source.token_rotated"
+)
+
+// DeletedPayload is the payload of EventDeleted.
+type DeletedPayload struct {
+	ID    uuid.UUID `json:"id"`
+	OrgID uuid.UUID `json:"org_id"`
+}
+
+// emit appends an event about sourceID to the outbox, in the transaction on
+// ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   sourceID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
@@ -0,0 +1,407 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/parser"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// Health windows accepted by GetSourceHealth, in minutes.
+const (
+	defaultHealthWindow = 15
+	maxHealthWindow     = 1440
+)
+
+// ParserChecker reports whether a source of the org may name a parser,
+// returning parser.ErrUnknownParser when it names none the org has.
+type ParserChecker interface {
+	CheckParser(ctx context.Context, orgID uuid.UUID, name string) error
+}
+
+// Handler serves the /organizations/{orgId}/sources/* endpoints. Members may
+// read sources and their health; admins manage them.
+type Handler struct {
+	svc     *Service
+	parsers ParserChecker
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, parsers ParserChecker, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, parsers: parsers, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// checkParser returns the problem detail for a parser name sources of the org
+// cannot use, or "" when it is fine.
+func (h *Handler) checkParser(ctx context.Context, orgID uuid.UUID, name *string) (string, error) {
+	if name == nil {
+		return "", nil
+	}
+	err := h.parsers.CheckParser(ctx, orgID, *name)
+	switch {
+	case err == nil:
+		return "", nil
+	case errors.Is(err, parser.ErrUnknownParser):
+		return fmt.Sprintf("Unknown parser %q", *name), nil
+	}
+	return "", err
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound() oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", "Source not found"),
+	)
+}
+
+func nameTakenProblem() oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", "A source with this name already exists"),
+	)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func (h *Handler) ListSources(
+	ctx context.Context,
+	request oapi.ListSourcesRequestObject,
+) (oapi.ListSourcesResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListSources403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	sources, err := h.svc.List(ctx, request.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	if sources == nil {
+		sources = []oapi.Source{}
+	}
+	return oapi.ListSources200JSONResponse(sources), nil
+}
+
+func (h *Handler) CreateSource(
+	ctx context.Context,
+	request oapi.CreateSourceRequestObject,
+) (oapi.CreateSourceResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateSource403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateSource403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		return oapi.CreateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Source name is required"),
+		}, nil
+	}
+	if !ValidType(body.Type) {
+		return oapi.CreateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Unsupported source type"),
+		}, nil
+	}
+	if body.SyslogListener != nil && !ValidListener(*body.SyslogListener) {
+		return oapi.CreateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Unsupported syslog listener"),
+		}, nil
+	}
+	if body.SyslogSenders != nil {
+		senders, err := NormalizeSenders(*body.SyslogSenders)
+		if err != nil {
+			return oapi.CreateSource400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		body.SyslogSenders = &senders
+	}
+	if _, err := TimestampSpec(body.TimestampConfig).Compile(); err != nil {
+		return oapi.CreateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+		}, nil
+	}
+	detail, err := h.checkParser(ctx, request.OrgId, body.Parser)
+	if err != nil {
+		return nil, err
+	}
+	if detail != "" {
+		return oapi.CreateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(detail),
+		}, nil
+	}
+
+	src, err := h.svc.Create(ctx, request.OrgId, body)
+	if err != nil {
+		if errors.Is(err, ErrConflict) {
+			return oapi.CreateSource409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: nameTakenProblem(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateSource201JSONResponse(src), nil
+}
+
+func (h *Handler) GetSource(
+	ctx context.Context,
+	request oapi.GetSourceRequestObject,
+) (oapi.GetSourceResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetSource403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	src, err := h.svc.Get(ctx, request.OrgId, request.SourceId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetSource404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(src.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetSource304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetSource200JSONResponse{
+		Body:    src,
+		Headers: oapi.GetSource200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
+func (h *Handler) UpdateSource(
+	ctx context.Context,
+	request oapi.UpdateSourceRequestObject,
+) (oapi.UpdateSourceResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateSource403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateSource403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	if body.Name != nil {
+		name := strings.TrimSpace(*body.Name)
+		if name == "" {
+			return oapi.UpdateSource400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest("Source name must not be empty"),
+			}, nil
+		}
+		body.Name = &name
+	}
+	if l := body.SyslogListener; l != nil && *l != "" && !ValidListener(oapi.SyslogListener(*l)) {
+		return oapi.UpdateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("Unsupported syslog listener"),
+		}, nil
+	}
+	if body.SyslogSenders != nil {
+		senders, err := NormalizeSenders(*body.SyslogSenders)
+		if err != nil {
+			return oapi.UpdateSource400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		body.SyslogSenders = &senders
+	}
+	if _, err := TimestampSpec(body.TimestampConfig).Compile(); err != nil {
+		return oapi.UpdateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+		}, nil
+	}
+	detail, err := h.checkParser(ctx, request.OrgId, body.Parser)
+	if err != nil {
+		return nil, err
+	}
+	if detail != "" {
+		return oapi.UpdateSource400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest(detail),
+		}, nil
+	}
+
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return sourcePreconditionFailed(), nil
+	}
+
+	src, err := h.svc.Update(ctx, request.OrgId, request.SourceId, body, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.UpdateSource404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.UpdateSource409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: nameTakenProblem(),
+			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return sourcePreconditionFailed(), nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateSource200JSONResponse{
+		Body:    src,
+		Headers: oapi.UpdateSource200ResponseHeaders{ETag: httpx.ETag(src.Version)},
+	}, nil
+}
+
+func sourcePreconditionFailed() oapi.UpdateSource412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateSource412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Source was modified since it was last read"),
+		),
+	}
+}
+
+func (h *Handler) DeleteSource(
+	ctx context.Context,
+	request oapi.DeleteSourceRequestObject,
+) (oapi.DeleteSourceResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteSource403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteSource403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Delete(ctx, request.OrgId, request.SourceId); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.DeleteSource404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteSource204Response{}, nil
+}
+
+func (h *Handler) RotateSourceToken(
+	ctx context.Context,
+	request oapi.RotateSourceTokenRequestObject,
+) (oapi.RotateSourceTokenResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.RotateSourceToken403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.RotateSourceToken403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	src, err := h.svc.RotateToken(ctx, request.OrgId, request.SourceId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.RotateSourceToken404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.RotateSourceToken200JSONResponse(src), nil
+}
+
+func (h *Handler) GetSourceHealth(
+	ctx context.Context,
+	request oapi.GetSourceHealthRequestObject,
+) (oapi.GetSourceHealthResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetSourceHealth403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	minutes := defaultHealthWindow
+	if w := request.Params.WindowMinutes; w != nil {
+		minutes = min(max(*w, 1), maxHealthWindow)
+	}
+
+	health, err := h.svc.Health(ctx, request.OrgId, request.SourceId, time.Duration(minutes)*time.Minute)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetSourceHealth404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.GetSourceHealth200JSONResponse(health), nil
+}
@@ -0,0 +1,206 @@
+package source_test
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func newSeededHandler(t *testing.T) (*source.Handler, oapi.Organization) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(
+		ctx,
+		fakeClerkUser("user_source_handler_owner", "ownr@example.com"),
+	)
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), "Source Handler Org", nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+
+	svc := source.NewService(source.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	patternSvc := pattern.NewService(pattern.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	return source.NewHandler(svc, patternSvc, userSvc, orgSvc), o
+}
+
+func ownerCtx() context.Context {
+	return middleware.WithClerkUser(
+		context.Background(),
+		fakeClerkUser("user_source_handler_owner", "ownr@example.com"),
+	)
+}
+
+func TestListSources_UnauthenticatedReturnsForbidden(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	resp, err := h.ListSources(context.Background(), oapi.ListSourcesRequestObject{OrgId: org.Id})
+	if err != nil {
+		t.Fatalf("ListSources: %v", err)
+	}
+	if _, ok := resp.(oapi.ListSources403ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 403, got %T", resp)
+	}
+}
+
+func TestCreateSource_RejectsUnknownType(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	resp, err := h.CreateSource(ownerCtx(), oapi.CreateSourceRequestObject{
+		OrgId: org.Id,
+		Body:  &oapi.CreateSourceJSONRequestBody{Name: "odd", Type: "carrier-pigeon"},
+	})
+	if err != nil {
+		t.Fatalf("CreateSource: %v", err)
+	}
+	if _, ok := resp.(oapi.CreateSource400ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 400, got %T", resp)
+	}
+}
+
+func TestCreateSource_RejectsInvalidSyslogSender(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	senders := []string{"10.0.0.0/8", "not-an-address"}
+	resp, err := h.CreateSource(ownerCtx(), oapi.CreateSourceRequestObject{
+		OrgId: org.Id,
+		Body:  &oapi.CreateSourceJSONRequestBody{Name: "fw", Type: oapi.Syslog, SyslogSenders: &senders},
+	})
+	if err != nil {
+		t.Fatalf("CreateSource: %v", err)
+	}
+	if _, ok := resp.(oapi.CreateSource400ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 400, got %T", resp)
+	}
+}
+
+func TestCreateSource_ValidatesParser(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	for parserName, want := range map[string]bool{
+		"cef":                    true,
+		"grok:COMBINEDAPACHELOG": true,
+		"grok:NO_SUCH_PATTERN":   false,
+		"xml":                    true,
+		"yaml":                   false,
+	} {
+		resp, err := h.CreateSource(ownerCtx(), oapi.CreateSourceRequestObject{
+			OrgId: org.Id,
+			Body:  &oapi.CreateSourceJSONRequestBody{Name: parserName, Type: oapi.Syslog, Parser: &parserName},
+		})
+		if err != nil {
+			t.Fatalf("CreateSource(%s): %v", parserName, err)
+		}
+		if _, ok := resp.(oapi.CreateSource201JSONResponse); ok != want {
+			t.Errorf("parser %s: want accepted %v, got %T", parserName, want, resp)
+		}
+	}
+}
+
+func TestCreateSource_ValidatesTimestampConfig(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	for name, cfg := range map[string]oapi.TimestampConfig{
+		"bad-layout":   {Layouts: &[]string{"yyyy-mm-dd"}},
+		"bad-timezone": {Timezone: strPtr("Mars/Olympus_Mons")},
+		"bad-field":    {Field: strPtr("event..created")},
+	} {
+		resp, err := h.CreateSource(ownerCtx(), oapi.CreateSourceRequestObject{
+			OrgId: org.Id,
+			Body:  &oapi.CreateSourceJSONRequestBody{Name: name, Type: oapi.HttpJson, TimestampConfig: &cfg},
+		})
+		if err != nil {
+			t.Fatalf("CreateSource(%s): %v", name, err)
+		}
+		if _, ok := resp.(oapi.CreateSource400ApplicationProblemPlusJSONResponse); !ok {
+			t.Errorf("%s: want 400, got %T", name, resp)
+		}
+	}
+}
+
+func TestCreateGetAndHealth_AdminHappyPath(t *testing.T) {
+	h, org := newSeededHandler(t)
+	ctx := ownerCtx()
+
+	createResp, err := h.CreateSource(ctx, oapi.CreateSourceRequestObject{
+		OrgId: org.Id,
+		Body:  &oapi.CreateSourceJSONRequestBody{Name: "firewall", Type: oapi.Syslog},
+	})
+	if err != nil {
+		t.Fatalf("CreateSource: %v", err)
+	}
+	created, ok := createResp.(oapi.CreateSource201JSONResponse)
+	if !ok {
+		t.Fatalf("want 201, got %T", createResp)
+	}
+	if created.IngestToken == "" {
+		t.Error("ingest token: want set, got empty")
+	}
+
+	getResp, err := h.GetSource(ctx, oapi.GetSourceRequestObject{OrgId: org.Id, SourceId: created.Id})
+	if err != nil {
+		t.Fatalf("GetSource: %v", err)
+	}
+	got, ok := getResp.(oapi.GetSource200JSONResponse)
+	if !ok {
+		t.Fatalf("want 200, got %T", getResp)
+	}
+	if got.Body.TokenPrefix != created.TokenPrefix {
+		t.Errorf("token_prefix: want %q, got %q", created.TokenPrefix, got.Body.TokenPrefix)
+	}
+
+	window := 5000
+	healthResp, err := h.GetSourceHealth(ctx, oapi.GetSourceHealthRequestObject{
+		OrgId:    org.Id,
+		SourceId: created.Id,
+		Params:   oapi.GetSourceHealthParams{WindowMinutes: &window},
+	})
+	if err != nil {
+		t.Fatalf("GetSourceHealth: %v", err)
+	}
+	health, ok := healthResp.(oapi.GetSourceHealth200JSONResponse)
+	if !ok {
+		t.Fatalf("want 200, got %T", healthResp)
+	}
+	if health.WindowMinutes != 1440 {
+		t.Errorf("window_minutes: want clamped to 1440, got %d", health.WindowMinutes)
+	}
+}
+
+func TestDeleteSource_UnknownReturnsNotFound(t *testing.T) {
+	h, org := newSeededHandler(t)
+
+	resp, err := h.DeleteSource(ownerCtx(), oapi.DeleteSourceRequestObject{
+		OrgId:    org.Id,
+		SourceId: uuid.MustParse("00000000-0000-0000-0000-000000000099"),
+	})
+	if err != nil {
+		t.Fatalf("DeleteSource: %v", err)
+	}
+	if _, ok := resp.(oapi.DeleteSource404ApplicationProblemPlusJSONResponse); !ok {
+		t.Fatalf("want 404, got %T", resp)
+	}
+}
@@ -0,0 +1,549 @@
+package source
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns sources and source_stats SQL and row→DTO mapping. Both tables are
+// protected by RLS, so callers run statements inside tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.Sources) oapi.Source {
+	return oapi.Source{
+		Id:          m.ID,
+		OrgId:       m.OrgID,
+		Name:        m.Name,
+		Type:        oapi.SourceType(m.Type),
+		Parser:      m.Parser,
+		Enabled:     m.Enabled,
+		TokenPrefix: m.TokenPrefix,
+		Version:     m.Version,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+
+		MappingId:       m.MappingID,
+		MappingVersion:  intPtr(m.MappingVersion),
+		SyslogListener:  (*oapi.SyslogListener)(m.SyslogListener),
+		SyslogSenders:   senders(m.SyslogSenders),
+		TimestampConfig: timestampConfig(m.TimestampConfig),
+	}
+}
+
+func intPtr(v *int32) *int {
+	if v == nil {
+		return nil
+	}
+	n := int(*v)
+	return &n
+}
+
+// timestampConfig decodes the stored config; NULL, or a config that no longer
+// decodes, reads as none.
+func timestampConfig(raw *string) *oapi.TimestampConfig {
+	if raw == nil {
+		return nil
+	}
+	var c oapi.TimestampConfig
+	if err := json.Unmarshal([]byte(*raw), &c); err != nil {
+		return nil
+	}
+	return &c
+}
+
+// timestampConfigJSON renders c for storage, as NULL when it sets nothing.
+// Empty values are dropped on the way.
+func timestampConfigJSON(c *oapi.TimestampConfig) postgres.Expression {
+	b, _ := json.Marshal(TimestampSpec(c))
+	if string(b) == "{}" {
+		return postgres.NULL
+	}
+	return postgres.CAST(postgres.String(string(b))).AS("jsonb")
+}
+
+func senders(a pq.StringArray) []string {
+	if a == nil {
+		return []string{}
+	}
+	return []string(a)
+}
+
+// nameTaken maps a unique violation on (org_id, name) to ErrConflict.
+func nameTaken(err error) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// versionIn restricts an update to the listed versions; nil means any.
+func versionIn(col postgres.ColumnInteger, versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return col.IN(exprs...)
+}
+
+// ── Sources ──────────────────────────────────────────────────────────────────
+
+// Insert stores a new source with the hash of its ingest token. Returns
+// ErrConflict when the org already has a source of that name.
+func (r *Repo) Insert(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateSourceRequest,
+	tokenHash, tokenPrefix string,
+) (oapi.Source, error) {
+	enabled := true
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	var senders pq.StringArray
+	if req.SyslogSenders != nil {
+		senders = *req.SyslogSenders
+	}
+
+	t := table.Sources
+	stmt := t.
+		INSERT(
+			t.OrgID, t.Name, t.Type, t.Parser, t.Enabled, t.TokenHash, t.TokenPrefix,
+			t.SyslogListener, t.SyslogSenders, t.TimestampConfig,
+		).
+		VALUES(
+			orgID,
+			req.Name,
+			string(req.Type),
+			emptyToNil(req.Parser),
+			enabled,
+			tokenHash,
+			tokenPrefix,
+			(*string)(req.SyslogListener),
+			cidrs(senders),
+			timestampConfigJSON(req.TimestampConfig),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Sources
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if nameTaken(err) {
+			return oapi.Source{}, ErrConflict
+		}
+		return oapi.Source{}, fmt.Errorf("inserting source: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// List returns the org's sources ordered by name.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID) ([]oapi.Source, error) {
+	t := table.Sources
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID))).
+		ORDER_BY(t.Name.ASC())
+
+	var rows []model.Sources
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing sources: %w", err)
+	}
+	sources := make([]oapi.Source, 0, len(rows))
+	for _, row := range rows {
+		sources = append(sources, toOapi(row))
+	}
+	return sources, nil
+}
+
+// Get returns a single source of the org. Returns ErrNotFound when it does not
+// exist in that org.
+func (r *Repo) Get(ctx context.Context, orgID, sourceID uuid.UUID) (model.Sources, error) {
+	t := table.Sources
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(sourceID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.Sources
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return model.Sources{}, ErrNotFound
+		}
+		return model.Sources{}, fmt.Errorf("getting source: %w", err)
+	}
+	return row, nil
+}
+
+// Update patches the fields set in req and bumps the version. An empty parser
+// clears it. When ifMatch is non-nil the update only applies if the
+// current version is listed, otherwise ErrPreconditionFailed is returned.
+func (r *Repo) Update(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	req oapi.UpdateSourceRequest,
+	ifMatch []int64,
+) (oapi.Source, error) {
+	t := table.Sources
+	name := postgres.StringExpression(t.Name)
+	if req.Name != nil {
+		name = postgres.String(*req.Name)
+	}
+	enabled := postgres.BoolExpression(t.Enabled)
+	if req.Enabled != nil {
+		enabled = postgres.Bool(*req.Enabled)
+	}
+
+	senders := postgres.Expression(t.SyslogSenders)
+	if req.SyslogSenders != nil {
+		senders = cidrs(*req.SyslogSenders)
+	}
+	timestamps := postgres.Expression(t.TimestampConfig)
+	if req.TimestampConfig != nil {
+		timestamps = timestampConfigJSON(req.TimestampConfig)
+	}
+
+	stmt := t.
+		UPDATE(
+			t.Name, t.Parser, t.Enabled, t.SyslogListener, t.SyslogSenders, t.TimestampConfig,
+			t.Version, t.UpdatedAt,
+		).
+		SET(
+			name,
+			optionalString(t.Parser, req.Parser),
+			enabled,
+			optionalString(t.SyslogListener, req.SyslogListener),
+			senders,
+			timestamps,
+			t.Version.ADD(postgres.Int(1)),
+			postgres.NOW(),
+		).
+		WHERE(
+			t.ID.EQ(postgres.UUID(sourceID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))).
+				AND(versionIn(t.Version, ifMatch)),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Sources
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	switch {
+	case err == nil:
+		return toOapi(row), nil
+	case nameTaken(err):
+		return oapi.Source{}, ErrConflict
+	case !errors.Is(err, qrm.ErrNoRows):
+		return oapi.Source{}, fmt.Errorf("updating source: %w", err)
+	}
+	if _, err := r.Get(ctx, orgID, sourceID); err != nil {
+		return oapi.Source{}, err
+	}
+	return oapi.Source{}, ErrPreconditionFailed
+}
+
+// RotateToken replaces the source's token hash and prefix, bumping the
+// version. Returns ErrNotFound when the source does not exist in the org.
+func (r *Repo) RotateToken(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	tokenHash, tokenPrefix string,
+) (oapi.Source, error) {
+	t := table.Sources
+	stmt := t.
+		UPDATE(t.TokenHash, t.TokenPrefix, t.Version, t.UpdatedAt).
+		SET(
+			postgres.String(tokenHash),
+			postgres.String(tokenPrefix),
+			t.Version.ADD(postgres.Int(1)),
+			postgres.NOW(),
+		).
+		WHERE(
+			t.ID.EQ(postgres.UUID(sourceID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Sources
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.Source{}, ErrNotFound
+		}
+		return oapi.Source{}, fmt.Errorf("rotating source token: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// SetMapping makes version of mappingID the source's active mapping version,
+// or clears it when mappingID is nil, bumping the version. Returns
+// ErrNotFound when the source does not exist in the org.
+func (r *Repo) SetMapping(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	mappingID *uuid.UUID,
+	version *int,
+) (oapi.Source, error) {
+	t := table.Sources
+	id, v := postgres.Expression(postgres.NULL), postgres.Expression(postgres.NULL)
+	if mappingID != nil && version != nil {
+		id, v = postgres.UUID(*mappingID), postgres.Int32(int32(*version))
+	}
+	stmt := t.
+		UPDATE(t.MappingID, t.MappingVersion, t.Version, t.UpdatedAt).
+		SET(id, v, t.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(sourceID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.Sources
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.Source{}, ErrNotFound
+		}
+		return oapi.Source{}, fmt.Errorf("setting source mapping: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Delete removes a source and its stats. Returns ErrNotFound when no row
+// matched.
+func (r *Repo) Delete(ctx context.Context, orgID, sourceID uuid.UUID) error {
+	t := table.Sources
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(sourceID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting source: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResolveToken looks up the source owning tokenHash. It runs before any
+// tenant scope exists and goes through a SECURITY DEFINER function, which
+// returns only the identifiers needed to establish one. Returns ErrNotFound
+// for an unknown token.
+func (r *Repo) ResolveToken(ctx context.Context, tokenHash string) (Credential, error) {
+	rows, err := tx.Executor(ctx, r.db).QueryContext(
+		ctx,
+		"SELECT id, org_id, enabled FROM app_source_for_token($1)",
+		tokenHash,
+	)
+	if err != nil {
+		return Credential{}, fmt.Errorf("resolving source token: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return Credential{}, fmt.Errorf("resolving source token: %w", err)
+		}
+		return Credential{}, ErrNotFound
+	}
+	var c Credential
+	if err := rows.Scan(&c.SourceID, &c.OrgID, &c.Enabled); err != nil {
+		return Credential{}, fmt.Errorf("resolving source token: %w", err)
+	}
+	return c, nil
+}
+
+// ResolveSyslog finds the syslog source claiming sender on listener. Like
+// ResolveToken it runs before any tenant scope exists, through a SECURITY
+// DEFINER function. Returns ErrNotFound when no source claims the sender.
+func (r *Repo) ResolveSyslog(ctx context.Context, listener string, sender netip.Addr) (Credential, error) {
+	rows, err := tx.Executor(ctx, r.db).QueryContext(
+		ctx,
+		"SELECT id, org_id, enabled FROM app_syslog_source_for($1, $2)",
+		listener,
+		sender.String(),
+	)
+	if err != nil {
+		return Credential{}, fmt.Errorf("resolving syslog sender: %w", err)
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		if err := rows.Err(); err != nil {
+			return Credential{}, fmt.Errorf("resolving syslog sender: %w", err)
+		}
+		return Credential{}, ErrNotFound
+	}
+	var c Credential
+	if err := rows.Scan(&c.SourceID, &c.OrgID, &c.Enabled); err != nil {
+		return Credential{}, fmt.Errorf("resolving syslog sender: %w", err)
+	}
+	return c, nil
+}
+
+// ── Activity ─────────────────────────────────────────────────────────────────
+
+// RecordActivity adds the activity of events received at the given time to
+// the source's per-minute counters and, when it has events, advances its
+// last_event_at.
+func (r *Repo) RecordActivity(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	a Activity,
+	at time.Time,
+) error {
+	s := table.SourceStats
+	upsert := s.
+		INSERT(
+			s.SourceID, s.OrgID, s.Bucket, s.Events, s.ParseErrors,
+			s.TimedEvents, s.LagMsSum, s.LagMsMax, s.OutOfRange,
+		).
+		VALUES(
+			sourceID, orgID, at.UTC().Truncate(time.Minute), a.Events, a.ParseErrors,
+			a.Timed, a.LagSum.Milliseconds(), a.LagMax.Milliseconds(), a.OutOfRange,
+		).
+		ON_CONFLICT(s.SourceID, s.Bucket).
+		DO_UPDATE(postgres.SET(
+			s.Events.SET(s.Events.ADD(s.EXCLUDED.Events)),
+			s.ParseErrors.SET(s.ParseErrors.ADD(s.EXCLUDED.ParseErrors)),
+			s.TimedEvents.SET(s.TimedEvents.ADD(s.EXCLUDED.TimedEvents)),
+			s.LagMsSum.SET(s.LagMsSum.ADD(s.EXCLUDED.LagMsSum)),
+			s.LagMsMax.SET(postgres.IntExp(postgres.GREATEST(s.LagMsMax, s.EXCLUDED.LagMsMax))),
+			s.OutOfRange.SET(s.OutOfRange.ADD(s.EXCLUDED.OutOfRange)),
+		))
+	if _, err := upsert.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("recording source activity: %w", err)
+	}
+	if a.Events == 0 {
+		return nil
+	}
+
+	t := table.Sources
+	touch := t.
+		UPDATE(t.LastEventAt).
+		SET(postgres.TimestampzT(at)).
+		WHERE(
+			t.ID.EQ(postgres.UUID(sourceID)).
+				AND(t.LastEventAt.IS_NULL().OR(t.LastEventAt.LT(postgres.TimestampzT(at)))),
+		)
+	if _, err := touch.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("recording source activity: %w", err)
+	}
+	return nil
+}
+
+// totals are a source's counters summed over a window.
+type totals struct {
+	Events      int64
+	ParseErrors int64
+	TimedEvents int64
+	LagMsSum    int64
+	LagMsMax    int64
+	OutOfRange  int64
+}
+
+// Totals sums the source's counters over buckets starting at or after since.
+func (r *Repo) Totals(ctx context.Context, sourceID uuid.UUID, since time.Time) (totals, error) {
+	s := table.SourceStats
+	stmt := postgres.
+		SELECT(
+			total(s.Events).AS("events"),
+			total(s.ParseErrors).AS("parse_errors"),
+			total(s.TimedEvents).AS("timed_events"),
+			total(s.LagMsSum).AS("lag_ms_sum"),
+			postgres.COALESCE(postgres.MAXi(s.LagMsMax), postgres.Int(0)).AS("lag_ms_max"),
+			total(s.OutOfRange).AS("out_of_range"),
+		).
+		FROM(s).
+		WHERE(
+			s.SourceID.EQ(postgres.UUID(sourceID)).
+				AND(s.Bucket.GT_EQ(postgres.TimestampzT(since))),
+		)
+
+	var dest totals
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &dest); err != nil {
+		return totals{}, fmt.Errorf("summing source activity: %w", err)
+	}
+	return dest, nil
+}
+
+// PruneStats deletes counters for buckets before cutoff. Returns the number of
+// rows deleted.
+func (r *Repo) PruneStats(ctx context.Context, cutoff time.Time) (int64, error) {
+	s := table.SourceStats
+	stmt := s.DELETE().WHERE(s.Bucket.LT(postgres.TimestampzT(cutoff)))
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return 0, fmt.Errorf("pruning source stats: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n, nil
+}
+
+// PruneStatsArgs is the background job running PruneStats.
+type PruneStatsArgs struct{}
+
+// Kind implements jobs.Args.
+func (PruneStatsArgs) Kind() string { return "source.prune_stats" }
+
+// ── Helpers ──────────────────────────────────────────────────────────────────
+
+// total sums col as a bigint, 0 over no rows. A bare SUM of a bigint column is
+// numeric, which does not scan into int64.
+func total(col postgres.ColumnInteger) postgres.Expression {
+	return postgres.CAST(postgres.COALESCE(postgres.SUMi(col), postgres.Int(0))).AS_BIGINT()
+}
+
+func emptyToNil(s *string) *string {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return s
+}
+
+// cidrs renders blocks as a cidr[] value; text[] does not assign to it
+// implicitly.
+func cidrs(blocks []string) postgres.Expression {
+	return postgres.CAST(postgres.StringArray(blocks...)).AS("cidr[]")
+}
+
+// optionalString leaves col unchanged for nil, clears it for "" and sets it
+// otherwise.
+func optionalString(col postgres.ColumnString, v *string) postgres.StringExpression {
+	switch {
+	case v == nil:
+		return col
+	case *v == "":
+		return postgres.StringExp(postgres.NULL)
+	default:
+		return postgres.String(*v)
+	}
+}
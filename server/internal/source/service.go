@@ -0,0 +1,428 @@
+// Package source owns the log source registry: the sources an organisation
+// sends events from, their parser and mapping bindings, per-source ingest
+// tokens and the activity counters behind source health. Raw tokens are
+// generated here, hashed once, and returned to the caller once only.
+package source
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/eventtime"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrNotFound is returned when a source does not exist in the org.
+var ErrNotFound = errors.New("source not found")
+
+// ErrConflict is returned when the org already has a source of that name.
+var ErrConflict = errors.New("source name taken")
+
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("source version mismatch")
+
+// ErrDisabled is returned when a token belongs to a disabled source.
+var ErrDisabled = errors.New("source disabled")
+
+// tokenPrefixLen is how much of a raw token is kept for display.
+const tokenPrefixLen = 12
+
+// Credential identifies the source an ingest token belongs to.
+type Credential struct {
+	SourceID uuid.UUID
+	OrgID    uuid.UUID
+	Enabled  bool
+}
+
+// Service orchestrates source operations. Each runs in a transaction carrying
+// the caller's tenant scope, joining one already on ctx. Mutations append
+// their domain event to the outbox in that same transaction.
+type Service struct {
+	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
+	logger *slog.Logger
+}
+
+// NewService wires a Service with its repo, transaction manager, outbox and
+// logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{repo: repo, tx: txm, events: events, logger: logger}
+}
+
+// ValidType reports whether t is a supported source type.
+func ValidType(t oapi.SourceType) bool {
+	switch t {
+	case oapi.Syslog, oapi.HttpJson, oapi.Cloudtrail, oapi.WindowsEvtx:
+		return true
+	}
+	return false
+}
+
+// ValidListener reports whether l names a syslog listener.
+func ValidListener(l oapi.SyslogListener) bool {
+	switch l {
+	case oapi.Udp, oapi.Tcp, oapi.Tls:
+		return true
+	}
+	return false
+}
+
+// NormalizeSenders parses syslog sender blocks, accepting single addresses as
+// host blocks, and returns them in canonical form.
+func NormalizeSenders(blocks []string) ([]string, error) {
+	out := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		b = strings.TrimSpace(b)
+		p, err := netip.ParsePrefix(b)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(b)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid syslog sender %q", b)
+			}
+			p = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		out = append(out, p.Masked().String())
+	}
+	return out, nil
+}
+
+// TimestampSpec converts a timestamp config to the spec package eventtime
+// compiles; nil is the default spec.
+func TimestampSpec(c *oapi.TimestampConfig) eventtime.Spec {
+	if c == nil {
+		return eventtime.Spec{}
+	}
+	var s eventtime.Spec
+	if c.Field != nil {
+		s.Field = strings.TrimSpace(*c.Field)
+	}
+	if c.Layouts != nil {
+		s.Layouts = *c.Layouts
+	}
+	if c.Timezone != nil {
+		s.Timezone = strings.TrimSpace(*c.Timezone)
+	}
+	if c.MaxFutureMinutes != nil {
+		s.MaxFutureMinutes = *c.MaxFutureMinutes
+	}
+	if c.MaxPastDays != nil {
+		s.MaxPastDays = *c.MaxPastDays
+	}
+	if c.OutOfRange != nil {
+		s.OutOfRange = string(*c.OutOfRange)
+	}
+	return s
+}
+
+// HashToken returns the stored form of a raw ingest token.
+func HashToken(raw string) string {
+	h := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(h[:])
+}
+
+// generateToken returns a freshly generated ingest token (with the "hzs_"
+// prefix), its SHA-256 hash and its display prefix.
+func generateToken() (raw, hash, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", fmt.Errorf("generating random bytes: %w", err)
+	}
+	raw = "hzs_" + hex.EncodeToString(b)
+	return raw, HashToken(raw), raw[:tokenPrefixLen], nil
+}
+
+func withToken(src oapi.Source, raw string) oapi.CreatedSource {
+	return oapi.CreatedSource{
+		Id:          src.Id,
+		OrgId:       src.OrgId,
+		Name:        src.Name,
+		Type:        src.Type,
+		Parser:      src.Parser,
+		Enabled:     src.Enabled,
+		TokenPrefix: src.TokenPrefix,
+		IngestToken: raw,
+		Version:     src.Version,
+		CreatedAt:   src.CreatedAt,
+		UpdatedAt:   src.UpdatedAt,
+
+		MappingId:       src.MappingId,
+		MappingVersion:  src.MappingVersion,
+		SyslogListener:  src.SyslogListener,
+		SyslogSenders:   src.SyslogSenders,
+		TimestampConfig: src.TimestampConfig,
+	}
+}
+
+// Create registers a source and returns it with its raw ingest token, one
+// time only. Returns ErrConflict when the name is taken.
+func (s *Service) Create(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateSourceRequest,
+) (oapi.CreatedSource, error) {
+	raw, hash, prefix, err := generateToken()
+	if err != nil {
+		return oapi.CreatedSource{}, err
+	}
+	var src oapi.Source
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if src, err = s.repo.Insert(ctx, orgID, req, hash, prefix); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, src.Id, EventCreated, src)
+	})
+	if err != nil {
+		return oapi.CreatedSource{}, err
+	}
+	return withToken(src, raw), nil
+}
+
+// List returns the org's sources.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.Source, error) {
+	var sources []oapi.Source
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		sources, err = s.repo.List(ctx, orgID)
+		return err
+	})
+	return sources, err
+}
+
+// Get returns a single source. Returns ErrNotFound when it does not exist in
+// the org.
+func (s *Service) Get(ctx context.Context, orgID, sourceID uuid.UUID) (oapi.Source, error) {
+	var src oapi.Source
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, sourceID)
+		if err != nil {
+			return err
+		}
+		src = toOapi(row)
+		return nil
+	})
+	return src, err
+}
+
+// Update patches a source. A non-nil ifMatch makes the update conditional on
+// the source's version.
+func (s *Service) Update(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	req oapi.UpdateSourceRequest,
+	ifMatch []int64,
+) (oapi.Source, error) {
+	var src oapi.Source
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if src, err = s.repo.Update(ctx, orgID, sourceID, req, ifMatch); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, sourceID, EventUpdated, src)
+	})
+	return src, err
+}
+
+// RotateToken replaces the source's ingest token, invalidating the old one,
+// and returns the source with the new raw token, one time only.
+func (s *Service) RotateToken(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+) (oapi.CreatedSource, error) {
+	raw, hash, prefix, err := generateToken()
+	if err != nil {
+		return oapi.CreatedSource{}, err
+	}
+	var src oapi.Source
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if src, err = s.repo.RotateToken(ctx, orgID, sourceID, hash, prefix); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, sourceID, EventTokenRotated, src)
+	})
+	if err != nil {
+		return oapi.CreatedSource{}, err
+	}
+	return withToken(src, raw), nil
+}
+
+// SetMapping makes version of mappingID the source's active mapping version,
+// or clears it when mappingID is nil. Callers check that the version may be
+// activated, in the transaction on ctx this joins.
+func (s *Service) SetMapping(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	mappingID *uuid.UUID,
+	version *int,
+) (oapi.Source, error) {
+	var src oapi.Source
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if src, err = s.repo.SetMapping(ctx, orgID, sourceID, mappingID, version); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, sourceID, EventUpdated, src)
+	})
+	return src, err
+}
+
+// Delete removes a source along with its activity counters.
+func (s *Service) Delete(ctx context.Context, orgID, sourceID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, orgID, sourceID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, sourceID, EventDeleted, DeletedPayload{ID: sourceID, OrgID: orgID})
+	})
+}
+
+// Health summarises the source's activity over the trailing window: when it
+// last received an event, its event and parse error rates, and how late its
+// events arrive.
+func (s *Service) Health(
+	ctx context.Context,
+	orgID, sourceID uuid.UUID,
+	window time.Duration,
+) (oapi.SourceHealth, error) {
+	minutes := int(window / time.Minute)
+	h := oapi.SourceHealth{SourceId: sourceID, WindowMinutes: minutes}
+	var t totals
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, sourceID)
+		if err != nil {
+			return err
+		}
+		h.LastEventAt = row.LastEventAt
+
+		since := time.Now().UTC().Truncate(time.Minute).Add(-window)
+		t, err = s.repo.Totals(ctx, sourceID, since)
+		return err
+	})
+	if err != nil {
+		return oapi.SourceHealth{}, err
+	}
+	h.Events, h.ParseErrors = t.Events, t.ParseErrors
+	h.TimedEvents, h.OutOfRangeEvents = t.TimedEvents, t.OutOfRange
+	if minutes > 0 {
+		h.EventsPerMinute = float64(h.Events) / float64(minutes)
+	}
+	if h.Events > 0 {
+		h.ParseErrorRate = float64(h.ParseErrors) / float64(h.Events)
+	}
+	if t.TimedEvents > 0 {
+		avg := float64(t.LagMsSum) / float64(t.TimedEvents) / 1000
+		peak := float64(t.LagMsMax) / 1000
+		h.IngestionLagAvgSeconds, h.IngestionLagMaxSeconds = &avg, &peak
+	}
+	return h, nil
+}
+
+// Extractor returns the timestamp extractor configured for the source.
+// Returns ErrNotFound when it does not exist in the org.
+func (s *Service) Extractor(ctx context.Context, orgID, sourceID uuid.UUID) (*eventtime.Extractor, error) {
+	var cfg *oapi.TimestampConfig
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, sourceID)
+		if err != nil {
+			return err
+		}
+		cfg = timestampConfig(row.TimestampConfig)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg == nil {
+		return eventtime.Default, nil
+	}
+	return TimestampSpec(cfg).Compile()
+}
+
+// Authenticate resolves a raw ingest token to its source. Returns ErrNotFound
+// for an unknown token and ErrDisabled when the source is disabled.
+func (s *Service) Authenticate(ctx context.Context, raw string) (Credential, error) {
+	var c Credential
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		c, err = s.repo.ResolveToken(ctx, HashToken(raw))
+		return err
+	})
+	if err != nil {
+		return Credential{}, err
+	}
+	if !c.Enabled {
+		return Credential{}, ErrDisabled
+	}
+	return c, nil
+}
+
+// ResolveSyslog finds the source a syslog message from sender on listener
+// belongs to. Returns ErrNotFound when no source claims the sender and
+// ErrDisabled when the source is disabled.
+func (s *Service) ResolveSyslog(ctx context.Context, listener string, sender netip.Addr) (Credential, error) {
+	var c Credential
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		c, err = s.repo.ResolveSyslog(ctx, listener, sender.Unmap())
+		return err
+	})
+	if err != nil {
+		return Credential{}, err
+	}
+	if !c.Enabled {
+		return Credential{}, ErrDisabled
+	}
+	return c, nil
+}
+
+// Activity is what a batch of events adds to a source's health counters.
+type Activity struct {
+	// Events received, including those that failed to parse.
+	Events int64
+	// ParseErrors are events rejected at ingest, or by the pipeline when
+	// normalized; the normalizer counts those, and the times below, with no
+	// events of their own.
+	ParseErrors int64
+	// Timed events had an in-range time of their own, as the pipeline
+	// extracts it; LagSum and LagMax are over those.
+	Timed      int64
+	LagSum     time.Duration
+	LagMax     time.Duration
+	OutOfRange int64
+}
+
+// Observe counts an event's extracted time.
+func (a *Activity) Observe(r eventtime.Result) {
+	switch r.Status {
+	case eventtime.StatusParsed:
+		lag := max(r.Lag(), 0)
+		a.Timed++
+		a.LagSum += lag
+		a.LagMax = max(a.LagMax, lag)
+	case eventtime.StatusFuture, eventtime.StatusPast:
+		a.OutOfRange++
+	}
+}
+
+// RecordActivity adds a batch's activity to the source's counters, in a
+// transaction joining one already on ctx. ctx must carry a tenant scope
+// covering orgID.
+func (s *Service) RecordActivity(ctx context.Context, orgID, sourceID uuid.UUID, a Activity) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		return s.repo.RecordActivity(ctx, orgID, sourceID, a, time.Now())
+	})
+}
@@ -0,0 +1,400 @@
+package source_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/eventtime"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), logger)
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+func newSeededService(t *testing.T) (*source.Service, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_source_seed", "Source Test Org")
+	svc := source.NewService(
+		source.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
+	return svc, orgID
+}
+
+func TestCreateSource_ReturnsTokenAndStoresHash(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	created, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{
+		Name:   "firewall",
+		Type:   oapi.Syslog,
+		Parser: strPtr("cef"),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !strings.HasPrefix(created.IngestToken, "hzs_") {
+		t.Errorf("token prefix: want hzs_, got %q", created.IngestToken)
+	}
+	if !strings.HasPrefix(created.IngestToken, created.TokenPrefix) {
+		t.Errorf("token_prefix %q is not a prefix of the token", created.TokenPrefix)
+	}
+	if !created.Enabled {
+		t.Error("enabled: want true by default")
+	}
+	if created.Parser == nil || *created.Parser != "cef" {
+		t.Errorf("parser: want cef, got %v", created.Parser)
+	}
+
+	var dbHash string
+	if err := testhelper.DB(t).QueryRowContext(ctx,
+		`SELECT token_hash FROM sources WHERE id = $1`, created.Id,
+	).Scan(&dbHash); err != nil {
+		t.Fatalf("select token_hash: %v", err)
+	}
+	if dbHash != source.HashToken(created.IngestToken) {
+		t.Errorf("stored token_hash mismatch: got %s", dbHash)
+	}
+}
+
+func TestCreateSource_DuplicateNameConflicts(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	req := oapi.CreateSourceRequest{Name: "cloudtrail", Type: oapi.Cloudtrail}
+	if _, err := svc.Create(ctx, orgID, req); err != nil {
+		t.Fatalf("first create: %v", err)
+	}
+	if _, err := svc.Create(ctx, orgID, req); !errors.Is(err, source.ErrConflict) {
+		t.Fatalf("second create: want ErrConflict, got %v", err)
+	}
+}
+
+func TestUpdateSource_PatchesAndChecksVersion(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	created, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{
+		Name:   "edr",
+		Type:   oapi.HttpJson,
+		Parser: strPtr("json"),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	disabled := false
+	updated, err := svc.Update(ctx, orgID, created.Id, oapi.UpdateSourceRequest{
+		Enabled: &disabled,
+		Parser:  strPtr(""),
+	}, []int64{created.Version})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Enabled {
+		t.Error("enabled: want false")
+	}
+	if updated.Parser != nil {
+		t.Errorf("parser: want cleared, got %q", *updated.Parser)
+	}
+	if updated.Name != "edr" {
+		t.Errorf("name: want unchanged, got %q", updated.Name)
+	}
+	if updated.Version != created.Version+1 {
+		t.Errorf("version: want %d, got %d", created.Version+1, updated.Version)
+	}
+
+	_, err = svc.Update(
+		ctx,
+		orgID,
+		created.Id,
+		oapi.UpdateSourceRequest{Name: strPtr("stale")},
+		[]int64{created.Version},
+	)
+	if !errors.Is(err, source.ErrPreconditionFailed) {
+		t.Fatalf("stale update: want ErrPreconditionFailed, got %v", err)
+	}
+}
+
+func TestRotateToken_InvalidatesOldToken(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	created, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{Name: "dc01", Type: oapi.WindowsEvtx})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	rotated, err := svc.RotateToken(ctx, orgID, created.Id)
+	if err != nil {
+		t.Fatalf("RotateToken: %v", err)
+	}
+	if rotated.IngestToken == created.IngestToken {
+		t.Fatal("rotated token equals the old one")
+	}
+
+	if _, err := svc.Authenticate(context.Background(), created.IngestToken); !errors.Is(err, source.ErrNotFound) {
+		t.Errorf("old token: want ErrNotFound, got %v", err)
+	}
+	cred, err := svc.Authenticate(context.Background(), rotated.IngestToken)
+	if err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	if cred.SourceID != created.Id || cred.OrgID != orgID {
+		t.Errorf("credential: got %+v", cred)
+	}
+}
+
+func TestAuthenticate_DisabledSourceRejected(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	disabled := false
+	created, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{
+		Name:    "paused",
+		Type:    oapi.Syslog,
+		Enabled: &disabled,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := svc.Authenticate(context.Background(), created.IngestToken); !errors.Is(err, source.ErrDisabled) {
+		t.Fatalf("want ErrDisabled, got %v", err)
+	}
+}
+
+func TestNormalizeSenders(t *testing.T) {
+	got, err := source.NormalizeSenders([]string{" 10.1.2.3/8", "192.0.2.7", "2001:db8::1/32", "::ffff:192.0.2.9"})
+	if err != nil {
+		t.Fatalf("NormalizeSenders: %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.0.2.7/32", "2001:db8::/32", "192.0.2.9/32"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("want %v, got %v", want, got)
+	}
+	if _, err := source.NormalizeSenders([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("want error for an invalid prefix")
+	}
+}
+
+func TestResolveSyslog_MostSpecificSenderWins(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	wide := []string{"10.0.0.0/8"}
+	narrow := []string{"10.1.0.0/16"}
+	udp := oapi.Udp
+	network, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{
+		Name: "network", Type: oapi.Syslog, SyslogSenders: &wide,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	firewall, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{
+		Name: "firewall", Type: oapi.Syslog, SyslogListener: &udp, SyslogSenders: &narrow,
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	cases := []struct {
+		listener, sender string
+		want             uuid.UUID
+	}{
+		{"udp", "10.1.2.3", firewall.Id},
+		{"tcp", "10.1.2.3", network.Id},
+		{"udp", "10.9.9.9", network.Id},
+	}
+	for _, c := range cases {
+		cred, err := svc.ResolveSyslog(context.Background(), c.listener, netip.MustParseAddr(c.sender))
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.listener, c.sender, err)
+		}
+		if cred.SourceID != c.want || cred.OrgID != orgID {
+			t.Errorf("%s %s: want source %s, got %+v", c.listener, c.sender, c.want, cred)
+		}
+	}
+	_, err = svc.ResolveSyslog(context.Background(), "udp", netip.MustParseAddr("192.0.2.1"))
+	if !errors.Is(err, source.ErrNotFound) {
+		t.Errorf("unclaimed sender: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestHealth_SummarisesRecentActivity(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	created, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{Name: "proxy", Type: oapi.HttpJson})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	h, err := svc.Health(ctx, orgID, created.Id, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Health before activity: %v", err)
+	}
+	if h.LastEventAt != nil || h.Events != 0 || h.ParseErrorRate != 0 {
+		t.Errorf("idle source: got %+v", h)
+	}
+
+	if err := svc.RecordActivity(ctx, orgID, created.Id, source.Activity{Events: 80}); err != nil {
+		t.Fatalf("RecordActivity: %v", err)
+	}
+	if err := svc.RecordActivity(ctx, orgID, created.Id, source.Activity{
+		Events:      20,
+		ParseErrors: 5,
+		Timed:       10,
+		LagSum:      20 * time.Second,
+		LagMax:      4 * time.Second,
+		OutOfRange:  3,
+	}); err != nil {
+		t.Fatalf("RecordActivity: %v", err)
+	}
+
+	h, err = svc.Health(ctx, orgID, created.Id, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("Health: %v", err)
+	}
+	if h.LastEventAt == nil {
+		t.Error("last_event_at: want set")
+	}
+	if h.Events != 100 || h.ParseErrors != 5 {
+		t.Errorf("totals: want 100/5, got %d/%d", h.Events, h.ParseErrors)
+	}
+	if h.EventsPerMinute != 10 {
+		t.Errorf("events_per_minute: want 10, got %v", h.EventsPerMinute)
+	}
+	if h.ParseErrorRate != 0.05 {
+		t.Errorf("parse_error_rate: want 0.05, got %v", h.ParseErrorRate)
+	}
+	if h.TimedEvents != 10 || h.OutOfRangeEvents != 3 {
+		t.Errorf("timed/out of range: want 10/3, got %d/%d", h.TimedEvents, h.OutOfRangeEvents)
+	}
+	if h.IngestionLagAvgSeconds == nil || *h.IngestionLagAvgSeconds != 2 ||
+		h.IngestionLagMaxSeconds == nil || *h.IngestionLagMaxSeconds != 4 {
+		t.Errorf("ingestion lag: want avg 2s max 4s, got %v/%v", h.IngestionLagAvgSeconds, h.IngestionLagMaxSeconds)
+	}
+}
+
+func TestTimestampConfig_StoredAndCompiled(t *testing.T) {
+	svc, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	created, err := svc.Create(ctx, orgID, oapi.CreateSourceRequest{
+		Name: "dc",
+		Type: oapi.Syslog,
+		TimestampConfig: &oapi.TimestampConfig{
+			Field:    strPtr(" raw_timestamp "),
+			Layouts:  &[]string{"syslog"},
+			Timezone: strPtr("Europe/Berlin"),
+		},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if c := created.TimestampConfig; c == nil || c.Field == nil || *c.Field != "raw_timestamp" {
+		t.Fatalf("timestamp_config: got %+v", c)
+	}
+
+	e, err := svc.Extractor(ctx, orgID, created.Id)
+	if err != nil {
+		t.Fatalf("Extractor: %v", err)
+	}
+	received := time.Date(2025, time.March, 9, 12, 0, 0, 0, time.UTC)
+	res := e.Extract(map[string]any{"raw_timestamp": "Mar  9 12:58:30"}, received)
+	if want := received.Add(-90 * time.Second); !res.Time.Equal(want) {
+		t.Errorf("extracted: want %v, got %v (%s)", want, res.Time, res.Status)
+	}
+
+	updated, err := svc.Update(ctx, orgID, created.Id, oapi.UpdateSourceRequest{
+		TimestampConfig: &oapi.TimestampConfig{},
+	}, nil)
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.TimestampConfig != nil {
+		t.Errorf("empty config: want defaults restored, got %+v", updated.TimestampConfig)
+	}
+	if e, err = svc.Extractor(ctx, orgID, created.Id); err != nil || e != eventtime.Default {
+		t.Errorf("Extractor after reset: want default, got %v", err)
+	}
+}
+
+func TestSources_InvisibleAcrossTenants(t *testing.T) {
+	svc, orgA := newSeededService(t)
+	orgB := seedOrg(t, testhelper.DB(t), "user_source_other", "Other Org")
+
+	created, err := svc.Create(
+		tenant.WithOrg(context.Background(), orgA),
+		orgA,
+		oapi.CreateSourceRequest{Name: "a-source", Type: oapi.Syslog},
+	)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	ctxB := tenant.WithOrg(context.Background(), orgB)
+	sources, err := svc.List(ctxB, orgA)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(sources) != 0 {
+		t.Errorf("cross-tenant list: want 0 sources, got %d", len(sources))
+	}
+	if _, err := svc.Get(ctxB, orgA, created.Id); !errors.Is(err, source.ErrNotFound) {
+		t.Errorf("cross-tenant get: want ErrNotFound, got %v", err)
+	}
+	if err := svc.Delete(ctxB, orgA, created.Id); !errors.Is(err, source.ErrNotFound) {
+		t.Errorf("cross-tenant delete: want ErrNotFound, got %v", err)
+	}
+}
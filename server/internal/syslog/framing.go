@@ -0,0 +1,89 @@
+package syslog
+
+import (
+	"bufio"
+	"errors"
+	"io"
+)
+
+// ErrFrameTooLarge is returned for a stream frame longer than the maximum
+// message size. The stream cannot be resynchronised after it.
+var ErrFrameTooLarge = errors.New("syslog frame exceeds size limit")
+
+// frameReader splits a TCP or TLS stream into messages (RFC 6587). Each frame
+// is either octet-counted, "LEN SP MSG", or terminated by a newline; senders
+// are detected per frame, as octet-counted frames start with a digit and
+// newline-terminated ones with '<'.
+type frameReader struct {
+	r   *bufio.Reader
+	max int
+}
+
+func newFrameReader(r io.Reader, maxSize int) *frameReader {
+	return &frameReader{r: bufio.NewReaderSize(r, maxSize+1), max: maxSize}
+}
+
+// Next returns the next frame. The slice is only valid until the following
+// call. A final newline-framed message without its newline is returned before
+// io.EOF.
+func (f *frameReader) Next() ([]byte, error) {
+	for {
+		c, err := f.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case c == '\n' || c == '\r' || c == ' ' || c == 0:
+			// padding between frames
+		case c >= '1' && c <= '9':
+			_ = f.r.UnreadByte()
+			return f.octetCounted()
+		default:
+			_ = f.r.UnreadByte()
+			return f.newlineTerminated()
+		}
+	}
+}
+
+func (f *frameReader) octetCounted() ([]byte, error) {
+	n := 0
+	for digits := 0; ; digits++ {
+		c, err := f.r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		if c == ' ' {
+			break
+		}
+		if c < '0' || c > '9' || digits == 10 {
+			return nil, errors.New("invalid octet count")
+		}
+		n = n*10 + int(c-'0')
+	}
+	if n > f.max {
+		return nil, ErrFrameTooLarge
+	}
+
+	frame := make([]byte, n)
+	if _, err := io.ReadFull(f.r, frame); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
+
+func (f *frameReader) newlineTerminated() ([]byte, error) {
+	line, err := f.r.ReadSlice('\n')
+	switch {
+	case err == nil:
+		return line[:len(line)-1], nil
+	case errors.Is(err, bufio.ErrBufferFull):
+		return nil, ErrFrameTooLarge
+	case errors.Is(err, io.EOF) && len(line) > 0:
+		return line, nil
+	default:
+		return nil, err
+	}
+}
@@ -0,0 +1,295 @@
+package syslog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Message formats.
+const (
+	FormatRFC5424 = "rfc5424"
+	FormatRFC3164 = "rfc3164"
+)
+
+// ErrNoPriority is returned for a message that does not start with a valid
+// <PRI> part; without one it is not syslog.
+var ErrNoPriority = errors.New("missing or invalid priority")
+
+// nilValue stands for an absent header field in RFC 5424.
+const nilValue = "-"
+
+// Message is a parsed syslog message as queued for normalization. Fields a
+// format or sender does not provide are left empty. SenderIP and Listener are
+// set by the receiver, not the parser. RawTimestamp keeps a BSD timestamp as
+// sent, since Timestamp had to guess its year and zone; a source can extract
+// its time from there instead, in the sender's zone.
+type Message struct {
+	Format         string                       `json:"format"`
+	Facility       int                          `json:"facility"`
+	Severity       int                          `json:"severity"`
+	Version        int                          `json:"version,omitempty"`
+	Timestamp      *time.Time                   `json:"timestamp,omitempty"`
+	RawTimestamp   string                       `json:"raw_timestamp,omitempty"`
+	Hostname       string                       `json:"hostname,omitempty"`
+	AppName        string                       `json:"app_name,omitempty"`
+	ProcID         string                       `json:"proc_id,omitempty"`
+	MsgID          string                       `json:"msg_id,omitempty"`
+	StructuredData map[string]map[string]string `json:"structured_data,omitempty"`
+	Message        string                       `json:"message"`
+	SenderIP       string                       `json:"sender_ip,omitempty"`
+	Listener       string                       `json:"listener,omitempty"`
+}
+
+// Parse parses one syslog message. A PRI followed by a version digit and a
+// space is RFC 5424; anything else is treated as BSD syslog (RFC 3164), whose
+// timestamp has no year or zone and is read relative to now, in now's location.
+func Parse(b []byte, now time.Time) (Message, error) {
+	b = bytes.TrimRight(b, "\r\n\x00")
+	pri, rest, err := parsePriority(b)
+	if err != nil {
+		return Message{}, err
+	}
+	m := Message{Facility: pri / 8, Severity: pri % 8}
+
+	if len(rest) >= 2 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+		m.Format = FormatRFC5424
+		m.Version = int(rest[0] - '0')
+		if err := parse5424(&m, rest[2:]); err != nil {
+			return Message{}, err
+		}
+		return m, nil
+	}
+	m.Format = FormatRFC3164
+	parse3164(&m, rest, now)
+	return m, nil
+}
+
+func parsePriority(b []byte) (int, []byte, error) {
+	if len(b) < 3 || b[0] != '<' {
+		return 0, nil, ErrNoPriority
+	}
+	end := bytes.IndexByte(b[:min(len(b), 5)], '>')
+	if end < 2 {
+		return 0, nil, ErrNoPriority
+	}
+	digits := b[1:end]
+	if len(digits) > 1 && digits[0] == '0' {
+		return 0, nil, ErrNoPriority
+	}
+	pri, err := strconv.Atoi(string(digits))
+	if err != nil || pri < 0 || pri > 191 {
+		return 0, nil, ErrNoPriority
+	}
+	return pri, b[end+1:], nil
+}
+
+// ── RFC 5424 ─────────────────────────────────────────────────────────────────
+
+func parse5424(m *Message, b []byte) error {
+	fields := make([]string, 5)
+	for i := range fields {
+		var ok bool
+		var field []byte
+		field, b, ok = bytes.Cut(b, []byte{' '})
+		if !ok || len(field) == 0 {
+			return errors.New("truncated RFC 5424 header")
+		}
+		if string(field) != nilValue {
+			fields[i] = string(field)
+		}
+	}
+	if fields[0] != "" {
+		ts, err := time.Parse(time.RFC3339Nano, fields[0])
+		if err != nil {
+			return fmt.Errorf("invalid RFC 5424 timestamp %q", fields[0])
+		}
+		m.Timestamp = &ts
+	}
+	m.Hostname, m.AppName, m.ProcID, m.MsgID = fields[1], fields[2], fields[3], fields[4]
+
+	sd, rest, err := parseStructuredData(b)
+	if err != nil {
+		return err
+	}
+	m.StructuredData = sd
+	if len(rest) > 0 {
+		if rest[0] != ' ' {
+			return errors.New("missing space after structured data")
+		}
+		rest = bytes.TrimPrefix(rest[1:], []byte("\xef\xbb\xbf"))
+	}
+	m.Message = validUTF8(string(rest))
+	return nil
+}
+
+// parseStructuredData reads the STRUCTURED-DATA part, either the nil value or
+// one or more [id name="value" ...] elements, returning what follows it.
+func parseStructuredData(b []byte) (map[string]map[string]string, []byte, error) {
+	if len(b) == 0 {
+		return nil, nil, errors.New("missing structured data")
+	}
+	if b[0] == '-' {
+		return nil, b[1:], nil
+	}
+	sd := map[string]map[string]string{}
+	for len(b) > 0 && b[0] == '[' {
+		b = b[1:]
+		id, rest := sdName(b)
+		if id == "" {
+			return nil, nil, errors.New("invalid structured data ID")
+		}
+		b = rest
+		params := map[string]string{}
+		for len(b) > 0 && b[0] == ' ' {
+			name, rest := sdName(b[1:])
+			if name == "" || len(rest) < 2 || rest[0] != '=' || rest[1] != '"' {
+				return nil, nil, fmt.Errorf("invalid parameter in structured data element %q", id)
+			}
+			value, rest, err := sdValue(rest[2:])
+			if err != nil {
+				return nil, nil, err
+			}
+			params[name] = value
+			b = rest
+		}
+		if len(b) == 0 || b[0] != ']' {
+			return nil, nil, fmt.Errorf("unterminated structured data element %q", id)
+		}
+		b = b[1:]
+		sd[id] = params
+	}
+	if len(sd) == 0 {
+		return nil, nil, errors.New("invalid structured data")
+	}
+	return sd, b, nil
+}
+
+// sdName reads an SD-ID or PARAM-NAME: printable ASCII other than '=', ' ',
+// ']' and '"'.
+func sdName(b []byte) (string, []byte) {
+	i := 0
+	for i < len(b) && b[i] > ' ' && b[i] < 0x7f && b[i] != '=' && b[i] != ']' && b[i] != '"' {
+		i++
+	}
+	return string(b[:i]), b[i:]
+}
+
+// sdValue reads a PARAM-VALUE up to its closing quote, undoing the \", \\ and
+// \] escapes. A backslash before any other character is kept.
+func sdValue(b []byte) (string, []byte, error) {
+	var sb strings.Builder
+	for i := 0; i < len(b); i++ {
+		switch c := b[i]; {
+		case c == '"':
+			return validUTF8(sb.String()), b[i+1:], nil
+		case c == '\\' && i+1 < len(b) && (b[i+1] == '"' || b[i+1] == '\\' || b[i+1] == ']'):
+			sb.WriteByte(b[i+1])
+			i++
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", nil, errors.New("unterminated structured data value")
+}
+
+// ── RFC 3164 ─────────────────────────────────────────────────────────────────
+
+// bsdStamp is the RFC 3164 timestamp layout; the day is space-padded.
+const bsdStamp = "Jan _2 15:04:05"
+
+// parse3164 reads whatever of TIMESTAMP HOSTNAME TAG: is present; BSD syslog
+// is loosely specified, so it never fails once the PRI is valid. When the
+// timestamp is missing the hostname is assumed missing too, as relays do.
+func parse3164(m *Message, b []byte, now time.Time) {
+	ts, raw, rest := bsdTimestamp(b, now)
+	if ts != nil {
+		m.Timestamp, m.RawTimestamp = ts, raw
+		// The next word is the hostname unless it is already the tag.
+		if word, after, ok := bytes.Cut(rest, []byte{' '}); ok && isHostname(word) {
+			m.Hostname = string(word)
+			rest = after
+		}
+	}
+	m.AppName, m.ProcID, rest = parseTag(rest)
+	m.Message = validUTF8(string(rest))
+}
+
+// bsdTimestamp reads the timestamp and the space after it. Besides the RFC
+// 3164 form, some senders put an RFC 3339 timestamp in an otherwise BSD
+// message. raw is the timestamp as sent, in the RFC 3164 form only. Returns
+// nil and b unchanged when there is neither.
+func bsdTimestamp(b []byte, now time.Time) (ts *time.Time, raw string, rest []byte) {
+	if n := len(bsdStamp); len(b) > n && b[n] == ' ' {
+		if t, err := time.ParseInLocation(bsdStamp, string(b[:n]), now.Location()); err == nil {
+			t = withYear(t, now)
+			return &t, string(b[:n]), b[n+1:]
+		}
+	}
+	if field, after, ok := bytes.Cut(b, []byte{' '}); ok {
+		if t, err := time.Parse(time.RFC3339Nano, string(field)); err == nil {
+			return &t, "", after
+		}
+	}
+	return nil, "", b
+}
+
+// withYear places a year-less timestamp in the year that puts it closest to
+// now: a December message received in early January belongs to last year.
+func withYear(ts, now time.Time) time.Time {
+	ts = ts.AddDate(now.Year(), 0, 0)
+	switch {
+	case ts.Sub(now) > 30*24*time.Hour:
+		ts = ts.AddDate(-1, 0, 0)
+	case now.Sub(ts) > 335*24*time.Hour:
+		ts = ts.AddDate(1, 0, 0)
+	}
+	return ts
+}
+
+func isHostname(word []byte) bool {
+	if len(word) == 0 || word[len(word)-1] == ':' {
+		return false
+	}
+	return bytes.IndexAny(word, "[]") < 0
+}
+
+// maxTag bounds the tag length; a longer first word is taken as message text.
+const maxTag = 48
+
+// parseTag splits "app[pid]: message" or "app: message". Without the colon
+// there is no tag and the whole remainder is the message.
+func parseTag(b []byte) (app, pid string, rest []byte) {
+	i := 0
+	for i < len(b) && i < maxTag && b[i] > ' ' && b[i] != ':' && b[i] != '[' {
+		i++
+	}
+	if i == 0 || i == len(b) {
+		return "", "", b
+	}
+	name, after := b[:i], b[i:]
+	if after[0] == '[' {
+		end := bytes.IndexByte(after, ']')
+		if end < 0 {
+			return "", "", b
+		}
+		pid = string(after[1:end])
+		after = after[end+1:]
+	}
+	if len(after) == 0 || after[0] != ':' {
+		return "", "", b
+	}
+	return string(name), pid, bytes.TrimPrefix(after[1:], []byte{' '})
+}
+
+// validUTF8 replaces invalid UTF-8 so the event stays valid JSON text.
+func validUTF8(s string) string {
+	if utf8.ValidString(s) {
+		return s
+	}
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
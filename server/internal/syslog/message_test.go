@@ -0,0 +1,130 @@
+package syslog_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/internal/syslog"
+)
+
+var now = time.Date(2025, time.March, 10, 12, 0, 0, 0, time.UTC)
+
+func TestParse_RFC5424(t *testing.T) {
+	raw := `<165>1 2025-03-10T11:59:58.123456Z mymachine.example.com evntslog 1234 ID47 ` +
+		`[exampleSDID@32473 iut="3" eventSource="App\"lication\\" eventID="1011"][origin ip="192.0.2.1"] ` +
+		"\xef\xbb\xbfAn application event log entry..."
+	m, err := syslog.Parse([]byte(raw), now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if m.Format != syslog.FormatRFC5424 || m.Version != 1 || m.Facility != 20 || m.Severity != 5 {
+		t.Errorf("header: got %+v", m)
+	}
+	want := time.Date(2025, time.March, 10, 11, 59, 58, 123456000, time.UTC)
+	if m.Timestamp == nil || !m.Timestamp.Equal(want) {
+		t.Errorf("timestamp: got %v", m.Timestamp)
+	}
+	if m.Hostname != "mymachine.example.com" || m.AppName != "evntslog" || m.ProcID != "1234" || m.MsgID != "ID47" {
+		t.Errorf("fields: got %+v", m)
+	}
+	if got := m.StructuredData["exampleSDID@32473"]["eventSource"]; got != `App"lication\` {
+		t.Errorf("escaped param: got %q", got)
+	}
+	if got := m.StructuredData["origin"]["ip"]; got != "192.0.2.1" {
+		t.Errorf("second element: got %q", got)
+	}
+	if m.Message != "An application event log entry..." {
+		t.Errorf("message: got %q", m.Message)
+	}
+}
+
+func TestParse_RFC5424NilValues(t *testing.T) {
+	m, err := syslog.Parse([]byte("<34>1 - - - - - -"), now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if m.Timestamp != nil || m.Hostname != "" || m.AppName != "" || m.StructuredData != nil || m.Message != "" {
+		t.Errorf("want empty fields, got %+v", m)
+	}
+}
+
+func TestParse_RFC5424Malformed(t *testing.T) {
+	for _, raw := range []string{
+		"<34>1 2025-03-10T11:59:58Z host app",
+		"<34>1 yesterday host app - - -",
+		`<34>1 - host app - - [id a="unterminated]`,
+		"<34>1 - host app - - [id]message",
+		"<34>1 - host app - - nonsense",
+	} {
+		if _, err := syslog.Parse([]byte(raw), now); err == nil {
+			t.Errorf("%q: want error", raw)
+		}
+	}
+}
+
+func TestParse_RFC3164(t *testing.T) {
+	m, err := syslog.Parse(
+		[]byte("<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8\n"),
+		now,
+	)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if m.Format != syslog.FormatRFC3164 || m.Facility != 4 || m.Severity != 2 {
+		t.Errorf("header: got %+v", m)
+	}
+	// October is months ahead of March, so it is last October.
+	want := time.Date(2024, time.October, 11, 22, 14, 15, 0, time.UTC)
+	if m.Timestamp == nil || !m.Timestamp.Equal(want) {
+		t.Errorf("timestamp: got %v", m.Timestamp)
+	}
+	if m.RawTimestamp != "Oct 11 22:14:15" {
+		t.Errorf("raw_timestamp: got %q", m.RawTimestamp)
+	}
+	if m.Hostname != "mymachine" || m.AppName != "su" || m.ProcID != "230" {
+		t.Errorf("fields: got %+v", m)
+	}
+	if m.Message != "'su root' failed for lonvick on /dev/pts/8" {
+		t.Errorf("message: got %q", m.Message)
+	}
+}
+
+func TestParse_RFC3164WithoutHostname(t *testing.T) {
+	m, err := syslog.Parse([]byte("<13>Mar  9 08:00:01 CRON[99]: job done"), now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if m.Hostname != "" || m.AppName != "CRON" || m.ProcID != "99" || m.Message != "job done" {
+		t.Errorf("got %+v", m)
+	}
+	if m.Timestamp == nil || m.Timestamp.Year() != 2025 || m.Timestamp.Day() != 9 {
+		t.Errorf("timestamp: got %v", m.Timestamp)
+	}
+}
+
+func TestParse_RFC3164Loose(t *testing.T) {
+	m, err := syslog.Parse([]byte("<13>no header at all"), now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if m.Timestamp != nil || m.Hostname != "" || m.AppName != "" || m.Message != "no header at all" {
+		t.Errorf("got %+v", m)
+	}
+
+	m, err = syslog.Parse([]byte("<13>2025-03-10T11:00:00+01:00 fw01 kernel: DROP"), now)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if m.Timestamp == nil || m.Timestamp.UTC().Hour() != 10 || m.Hostname != "fw01" || m.AppName != "kernel" {
+		t.Errorf("RFC 3339 timestamp: got %+v", m)
+	}
+}
+
+func TestParse_RejectsMissingPriority(t *testing.T) {
+	for _, raw := range []string{"", "hello", "<>1 -", "<192>x", "<013>x", "<1a>x"} {
+		if _, err := syslog.Parse([]byte(raw), now); !errors.Is(err, syslog.ErrNoPriority) {
+			t.Errorf("%q: want ErrNoPriority, got %v", raw, err)
+		}
+	}
+}
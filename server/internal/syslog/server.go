@@ -0,0 +1,425 @@
+// Package syslog receives syslog messages over UDP, TCP and TLS and feeds them
+// into the same raw-event pipeline as HTTP ingestion. A message belongs to the
+// source that claims its sender's address on the listener it arrived on;
+// messages from unclaimed senders are dropped.
+package syslog
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/ingest"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/source"
+)
+
+// Listener names, as claimed by sources.
+const (
+	ListenerUDP = "udp"
+	ListenerTCP = "tcp"
+	ListenerTLS = "tls"
+)
+
+// Resolver maps a sender on a listener to its source; source.Service
+// satisfies it.
+type Resolver interface {
+	ResolveSyslog(ctx context.Context, listener string, sender netip.Addr) (source.Credential, error)
+}
+
+// Submitter queues a batch of raw events; ingest.Service satisfies it.
+type Submitter interface {
+	Submit(ctx context.Context, orgID, sourceID uuid.UUID, b ingest.Batch) error
+}
+
+// Config selects the listeners to open and tunes batching. An empty address
+// disables its listener; TLSConfig is required when TLSAddr is set. Zero
+// durations and sizes take the defaults below.
+type Config struct {
+	UDPAddr   string
+	TCPAddr   string
+	TLSAddr   string
+	TLSConfig *tls.Config
+
+	MaxMessageSize int           // default 64 KiB
+	BatchSize      int           // events per source per submit; default 500
+	FlushInterval  time.Duration // longest an event waits to be submitted; default 1s
+	ResolveTTL     time.Duration // how long sender lookups are cached; default 30s
+	IdleTimeout    time.Duration // closes silent stream connections; default 10m
+}
+
+func (c *Config) defaults() {
+	if c.MaxMessageSize <= 0 {
+		c.MaxMessageSize = 64 << 10
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 500
+	}
+	if c.FlushInterval <= 0 {
+		c.FlushInterval = time.Second
+	}
+	if c.ResolveTTL <= 0 {
+		c.ResolveTTL = 30 * time.Second
+	}
+	if c.IdleTimeout <= 0 {
+		c.IdleTimeout = 10 * time.Minute
+	}
+}
+
+// Server runs the configured listeners. Received messages are batched per
+// source and submitted in arrival order from a single goroutine, so events of
+// one source are queued in the order they were received.
+type Server struct {
+	cfg       Config
+	resolver  Resolver
+	submitter Submitter
+	logger    *slog.Logger
+	cache     *resolveCache
+
+	in      chan inbound
+	packet  net.PacketConn
+	streams map[string]net.Listener
+}
+
+// inbound is one received message: its event, or a rejection counted as a
+// parse error.
+type inbound struct {
+	cred  source.Credential
+	event json.RawMessage
+}
+
+// NewServer wires a Server. Call Listen, then Serve.
+func NewServer(resolver Resolver, submitter Submitter, cfg Config, logger *slog.Logger) *Server {
+	cfg.defaults()
+	return &Server{
+		cfg:       cfg,
+		resolver:  resolver,
+		submitter: submitter,
+		logger:    logger,
+		cache:     newResolveCache(cfg.ResolveTTL),
+		in:        make(chan inbound, cfg.BatchSize),
+		streams:   map[string]net.Listener{},
+	}
+}
+
+// Listen binds the configured listeners.
+func (s *Server) Listen() error {
+	if s.cfg.UDPAddr != "" {
+		pc, err := net.ListenPacket("udp", s.cfg.UDPAddr)
+		if err != nil {
+			return err
+		}
+		s.packet = pc
+	}
+	if s.cfg.TCPAddr != "" {
+		ln, err := net.Listen("tcp", s.cfg.TCPAddr)
+		if err != nil {
+			s.close()
+			return err
+		}
+		s.streams[ListenerTCP] = ln
+	}
+	if s.cfg.TLSAddr != "" {
+		if s.cfg.TLSConfig == nil {
+			s.close()
+			return errors.New("syslog TLS listener requires a TLS config")
+		}
+		ln, err := tls.Listen("tcp", s.cfg.TLSAddr, s.cfg.TLSConfig)
+		if err != nil {
+			s.close()
+			return err
+		}
+		s.streams[ListenerTLS] = ln
+	}
+	return nil
+}
+
+// Addr returns the bound address of a listener, or nil if it is not open.
+func (s *Server) Addr(listener string) net.Addr {
+	if listener == ListenerUDP {
+		if s.packet == nil {
+			return nil
+		}
+		return s.packet.LocalAddr()
+	}
+	if ln, ok := s.streams[listener]; ok {
+		return ln.Addr()
+	}
+	return nil
+}
+
+// Serve receives messages until ctx is cancelled, then closes the listeners
+// and connections and submits what is still pending before returning.
+func (s *Server) Serve(ctx context.Context) {
+	for name, ln := range s.streams {
+		s.logger.InfoContext(ctx, "syslog listening", slog.String("listener", name), slog.Any("addr", ln.Addr()))
+	}
+	if s.packet != nil {
+		s.logger.InfoContext(ctx, "syslog listening",
+			slog.String("listener", ListenerUDP), slog.Any("addr", s.packet.LocalAddr()))
+	}
+
+	batched := make(chan struct{})
+	go func() {
+		s.batch(context.WithoutCancel(ctx))
+		close(batched)
+	}()
+
+	var wg sync.WaitGroup
+	if s.packet != nil {
+		wg.Go(func() { s.servePacket(ctx) })
+	}
+	for name, ln := range s.streams {
+		wg.Go(func() { s.serveStream(ctx, &wg, name, ln) })
+	}
+	<-ctx.Done()
+	wg.Wait()
+	close(s.in)
+	<-batched
+}
+
+func (s *Server) close() {
+	if s.packet != nil {
+		_ = s.packet.Close()
+	}
+	for _, ln := range s.streams {
+		_ = ln.Close()
+	}
+}
+
+// ── Receiving ────────────────────────────────────────────────────────────────
+
+// servePacket reads one message per datagram.
+func (s *Server) servePacket(ctx context.Context) {
+	stop := context.AfterFunc(ctx, func() { _ = s.packet.Close() })
+	defer stop()
+
+	buf := make([]byte, s.cfg.MaxMessageSize)
+	for {
+		n, addr, err := s.packet.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logger.WarnContext(ctx, "syslog udp read failed", slog.Any("err", err))
+			continue
+		}
+		sender, ok := addrOf(addr)
+		if !ok {
+			continue
+		}
+		s.receive(ctx, ListenerUDP, sender, buf[:n])
+	}
+}
+
+// serveStream accepts TCP or TLS connections, serving each on its own
+// goroutine tracked by wg.
+func (s *Server) serveStream(ctx context.Context, wg *sync.WaitGroup, name string, ln net.Listener) {
+	stop := context.AfterFunc(ctx, func() { _ = ln.Close() })
+	defer stop()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logger.WarnContext(ctx, "syslog accept failed", slog.String("listener", name), slog.Any("err", err))
+			time.Sleep(50 * time.Millisecond)
+			continue
+		}
+		wg.Go(func() { s.serveConn(ctx, name, conn) })
+	}
+}
+
+func (s *Server) serveConn(ctx context.Context, listener string, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	sender, ok := addrOf(conn.RemoteAddr())
+	if !ok {
+		return
+	}
+	frames := newFrameReader(conn, s.cfg.MaxMessageSize)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(s.cfg.IdleTimeout))
+		frame, err := frames.Next()
+		if err != nil {
+			if ctx.Err() == nil && !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				s.logger.DebugContext(ctx, "syslog connection closed",
+					slog.String("listener", listener), slog.String("sender", sender.String()), slog.Any("err", err))
+			}
+			return
+		}
+		s.receive(ctx, listener, sender, frame)
+	}
+}
+
+// receive parses a message from sender and hands it to the batcher. Messages
+// from senders no enabled source claims are dropped; unparseable ones count
+// as parse errors of their source.
+func (s *Server) receive(ctx context.Context, listener string, sender netip.Addr, frame []byte) {
+	cred, ok := s.resolve(ctx, listener, sender)
+	if !ok {
+		return
+	}
+	msg, err := Parse(frame, time.Now())
+	if err != nil {
+		s.in <- inbound{cred: cred}
+		return
+	}
+	msg.SenderIP = sender.String()
+	msg.Listener = listener
+	event, err := json.Marshal(msg)
+	if err != nil {
+		s.in <- inbound{cred: cred}
+		return
+	}
+	s.in <- inbound{cred: cred, event: event}
+}
+
+func (s *Server) resolve(ctx context.Context, listener string, sender netip.Addr) (source.Credential, bool) {
+	key := resolveKey{listener: listener, sender: sender}
+	if cred, ok, hit := s.cache.get(key); hit {
+		return cred, ok
+	}
+	cred, err := s.resolver.ResolveSyslog(ctx, listener, sender)
+	switch {
+	case err == nil:
+		s.cache.put(key, cred, true)
+		return cred, true
+	case errors.Is(err, source.ErrNotFound), errors.Is(err, source.ErrDisabled):
+		s.logger.DebugContext(ctx, "dropping syslog from unclaimed sender",
+			slog.String("listener", listener), slog.String("sender", sender.String()))
+		s.cache.put(key, source.Credential{}, false)
+		return source.Credential{}, false
+	default:
+		s.logger.ErrorContext(ctx, "resolving syslog sender failed", slog.Any("err", err))
+		return source.Credential{}, false
+	}
+}
+
+func addrOf(a net.Addr) (netip.Addr, bool) {
+	ap, err := netip.ParseAddrPort(a.String())
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return ap.Addr().Unmap(), true
+}
+
+// ── Batching ─────────────────────────────────────────────────────────────────
+
+type pending struct {
+	orgID uuid.UUID
+	batch ingest.Batch
+}
+
+// batch collects received messages per source and submits a source's batch
+// when it is full or the flush interval passes, until s.in is closed.
+func (s *Server) batch(ctx context.Context) {
+	bySource := map[uuid.UUID]*pending{}
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	flush := func(sourceID uuid.UUID, p *pending) {
+		delete(bySource, sourceID)
+		err := s.submitter.Submit(tenant.WithOrg(ctx, p.orgID), p.orgID, sourceID, p.batch)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "submitting syslog batch failed",
+				slog.String("source_id", sourceID.String()),
+				slog.Int("events", len(p.batch.Events)),
+				slog.Any("err", err))
+		}
+	}
+
+	for {
+		select {
+		case in, ok := <-s.in:
+			if !ok {
+				for id, p := range bySource {
+					flush(id, p)
+				}
+				return
+			}
+			p := bySource[in.cred.SourceID]
+			if p == nil {
+				p = &pending{orgID: in.cred.OrgID}
+				bySource[in.cred.SourceID] = p
+			}
+			if in.event == nil {
+				p.batch.Rejected++
+			} else {
+				p.batch.Events = append(p.batch.Events, in.event)
+			}
+			if len(p.batch.Events)+p.batch.Rejected >= s.cfg.BatchSize {
+				flush(in.cred.SourceID, p)
+			}
+		case <-ticker.C:
+			for id, p := range bySource {
+				flush(id, p)
+			}
+		}
+	}
+}
+
+// ── Sender cache ─────────────────────────────────────────────────────────────
+
+type resolveKey struct {
+	listener string
+	sender   netip.Addr
+}
+
+type resolveEntry struct {
+	cred    source.Credential
+	ok      bool
+	expires time.Time
+}
+
+// resolveCache remembers sender lookups, including misses, so a busy or
+// unclaimed sender does not query the database for every message. Changes to
+// a source's senders take effect once its entries expire.
+type resolveCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[resolveKey]resolveEntry
+}
+
+func newResolveCache(ttl time.Duration) *resolveCache {
+	return &resolveCache{ttl: ttl, entries: map[resolveKey]resolveEntry{}}
+}
+
+func (c *resolveCache) get(key resolveKey) (cred source.Credential, ok, hit bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, found := c.entries[key]
+	if !found || time.Now().After(e.expires) {
+		return source.Credential{}, false, false
+	}
+	return e.cred, e.ok, true
+}
+
+func (c *resolveCache) put(key resolveKey, cred source.Credential, ok bool) {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// drop expired entries now and then so scans from many addresses do not
+	// grow the cache without bound
+	if len(c.entries) >= 4096 {
+		for k, e := range c.entries {
+			if now.After(e.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	c.entries[key] = resolveEntry{cred: cred, ok: ok, expires: now.Add(c.ttl)}
+}
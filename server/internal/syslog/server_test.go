@@ -0,0 +1,136 @@
+package syslog_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/ingest"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/syslog"
+)
+
+// fakeResolver claims loopback senders on every listener.
+type fakeResolver struct {
+	cred source.Credential
+}
+
+func (f fakeResolver) ResolveSyslog(_ context.Context, _ string, sender netip.Addr) (source.Credential, error) {
+	if !sender.IsLoopback() {
+		return source.Credential{}, source.ErrNotFound
+	}
+	return f.cred, nil
+}
+
+type fakeSubmitter struct {
+	mu       sync.Mutex
+	events   []syslog.Message
+	rejected int
+}
+
+func (f *fakeSubmitter) Submit(_ context.Context, _, _ uuid.UUID, b ingest.Batch) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, raw := range b.Events {
+		var m syslog.Message
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return err
+		}
+		f.events = append(f.events, m)
+	}
+	f.rejected += b.Rejected
+	return nil
+}
+
+func (f *fakeSubmitter) snapshot() ([]syslog.Message, int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]syslog.Message(nil), f.events...), f.rejected
+}
+
+func startServer(t *testing.T, cfg syslog.Config) (*syslog.Server, *fakeSubmitter, func()) {
+	t.Helper()
+	sub := &fakeSubmitter{}
+	cred := source.Credential{SourceID: uuid.New(), OrgID: uuid.New(), Enabled: true}
+	srv := syslog.NewServer(fakeResolver{cred: cred}, sub, cfg, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err := srv.Listen(); err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		srv.Serve(ctx)
+		close(done)
+	}()
+	stop := func() {
+		cancel()
+		<-done
+	}
+	t.Cleanup(stop)
+	return srv, sub, stop
+}
+
+func TestServer_TCPFraming(t *testing.T) {
+	srv, sub, stop := startServer(t, syslog.Config{TCPAddr: "127.0.0.1:0"})
+
+	conn, err := net.Dial("tcp", srv.Addr(syslog.ListenerTCP).String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	first := "<34>1 - host app - - - octet\ncounted"
+	fmt.Fprintf(conn, "%d %s", len(first), first)
+	fmt.Fprint(conn, "<13>Mar 10 11:00:00 host app: newline framed\n")
+	fmt.Fprint(conn, "not syslog\n")
+	fmt.Fprint(conn, "<13>unterminated")
+	_ = conn.Close()
+
+	// shutting down submits what is pending
+	time.Sleep(100 * time.Millisecond)
+	stop()
+
+	events, rejected := sub.snapshot()
+	if len(events) != 3 || rejected != 1 {
+		t.Fatalf("want 3 events / 1 rejected, got %d / %d", len(events), rejected)
+	}
+	if events[0].Message != "octet\ncounted" || events[0].Listener != syslog.ListenerTCP {
+		t.Errorf("first event: got %+v", events[0])
+	}
+	if events[1].Message != "newline framed" || events[1].SenderIP != "127.0.0.1" {
+		t.Errorf("second event: got %+v", events[1])
+	}
+	if events[2].Message != "unterminated" {
+		t.Errorf("third event: got %+v", events[2])
+	}
+}
+
+func TestServer_UDPFlushesOnInterval(t *testing.T) {
+	srv, sub, _ := startServer(t, syslog.Config{UDPAddr: "127.0.0.1:0", FlushInterval: 20 * time.Millisecond})
+
+	conn, err := net.Dial("udp", srv.Addr(syslog.ListenerUDP).String())
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer func() { _ = conn.Close() }()
+	_, _ = conn.Write([]byte("<14>1 2025-03-10T11:00:00Z host app - - [a b=\"c\"] datagram"))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if events, _ := sub.snapshot(); len(events) == 1 {
+			if events[0].StructuredData["a"]["b"] != "c" || events[0].Listener != syslog.ListenerUDP {
+				t.Errorf("got %+v", events[0])
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("datagram was not submitted")
+}
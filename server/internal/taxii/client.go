@@ -0,0 +1,336 @@
+package taxii
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// mediaType is the TAXII 2.1 media type, sent as Accept with every request.
+const mediaType = "application/taxii+json;version=2.1"
+
+// dateAddedLast is the response header carrying when the server added the
+// last object of a page.
+const dateAddedLast = "X-TAXII-Date-Added-Last"
+
+const (
+	// requestTimeout bounds a single request to a server.
+	requestTimeout = 30 * time.Second
+	// dialTimeout bounds connecting to a server.
+	dialTimeout = 10 * time.Second
+	// maxResponseBytes bounds a response body read.
+	maxResponseBytes = 32 << 20
+	// pageLimit is the number of objects asked for per page; servers may
+	// return fewer.
+	pageLimit = 1000
+)
+
+// objectTypes are the STIX object types asked for: indicators, and the
+// identities and markings they refer to.
+const objectTypes = "indicator,identity,marking-definition"
+
+// ErrUnauthorized is returned when a server rejects the feed's credentials.
+var ErrUnauthorized = errors.New("TAXII server rejected the credentials")
+
+// ErrResponse is returned when a server answers with something other than
+// what TAXII 2.1 specifies, or with an error no retry can fix.
+var ErrResponse = errors.New("unexpected TAXII response")
+
+// ErrForbiddenAddress is returned when a server resolves to an address feeds
+// may not reach: one in a reserved or special-purpose range, such as
+// loopback, private, shared (CGNAT), link-local, multicast or NAT64.
+var ErrForbiddenAddress = errors.New("TAXII server address not allowed")
+
+// forbiddenPrefixes are the reserved and special-purpose ranges of the IANA
+// registries, whose addresses are not public hosts, together with those
+// reaching IPv4 addresses through IPv6 translation or tunnels, which may
+// embed a private one.
+var forbiddenPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),       // This network
+	netip.MustParsePrefix("10.0.0.0/8"),      // Private-Use
+	netip.MustParsePrefix("100.64.0.0/10"),   // Shared Address Space (CGNAT)
+	netip.MustParsePrefix("127.0.0.0/8"),     // Loopback
+	netip.MustParsePrefix("169.254.0.0/16"),  // Link Local
+	netip.MustParsePrefix("172.16.0.0/12"),   // Private-Use
+	netip.MustParsePrefix("192.0.0.0/24"),    // IETF Protocol Assignments
+	netip.MustParsePrefix("192.0.2.0/24"),    // Documentation (TEST-NET-1)
+	netip.MustParsePrefix("192.88.99.0/24"),  // 6to4 Relay Anycast
+	netip.MustParsePrefix("192.168.0.0/16"),  // Private-Use
+	netip.MustParsePrefix("198.18.0.0/15"),   // Benchmarking
+	netip.MustParsePrefix("198.51.100.0/24"), // Documentation (TEST-NET-2)
+	netip.MustParsePrefix("203.0.113.0/24"),  // Documentation (TEST-NET-3)
+	netip.MustParsePrefix("224.0.0.0/4"),     // Multicast
+	netip.MustParsePrefix("240.0.0.0/4"),     // Reserved, and Limited Broadcast
+	netip.MustParsePrefix("::/96"),           // Unspecified, Loopback and IPv4-compatible
+	netip.MustParsePrefix("::ffff:0:0/96"),   // IPv4-mapped
+	netip.MustParsePrefix("64:ff9b::/96"),    // IPv4-IPv6 Translation (NAT64)
+	netip.MustParsePrefix("64:ff9b:1::/48"),  // IPv4-IPv6 Translation, local-use
+	netip.MustParsePrefix("100::/64"),        // Discard-Only Address Block
+	netip.MustParsePrefix("2001::/23"),       // IETF Protocol Assignments, Teredo included
+	netip.MustParsePrefix("2001:db8::/32"),   // Documentation
+	netip.MustParsePrefix("2002::/16"),       // 6to4
+	netip.MustParsePrefix("fc00::/7"),        // Unique-Local
+	netip.MustParsePrefix("fe80::/10"),       // Link-Local Unicast
+	netip.MustParsePrefix("fec0::/10"),       // Site-Local, deprecated
+	netip.MustParsePrefix("ff00::/8"),        // Multicast
+}
+
+// Auth is how requests to a server authenticate.
+type Auth struct {
+	// Type is AuthNone, AuthBasic or AuthToken.
+	Type string
+	// Username is the basic auth username.
+	Username string
+	// Secret is the basic auth password or the bearer token.
+	Secret string
+}
+
+// Client talks to a TAXII 2.1 server.
+type Client struct {
+	http *http.Client
+	auth Auth
+}
+
+// NewClient returns a Client authenticating with auth. httpClient is nil for
+// the default one, which only connects to public addresses.
+func NewClient(httpClient *http.Client, auth Auth) *Client {
+	if httpClient == nil {
+		httpClient = publicClient()
+	}
+	return &Client{http: httpClient, auth: auth}
+}
+
+// publicClient returns an HTTP client that only connects to public
+// addresses, checked once a server's name is resolved, so that feeds cannot
+// reach the deployment's own network. It goes through no proxy, which would
+// connect on its behalf, and follows no redirects.
+func publicClient() *http.Client {
+	dialer := &net.Dialer{Timeout: dialTimeout, Control: allowDial}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{
+		Timeout:   requestTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// allowDial is the dialer's Control hook, refusing to connect to addresses
+// that are not public.
+func allowDial(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !publicAddr(addr) {
+		return fmt.Errorf("%w: %s", ErrForbiddenAddress, addr)
+	}
+	return nil
+}
+
+// publicAddr reports whether addr is one feeds may reach: in none of
+// forbiddenPrefixes. IPv4-mapped IPv6 addresses are checked as IPv4.
+func publicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, p := range forbiddenPrefixes {
+		if p.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// validURL reports whether u is one a Client may request: http or https,
+// with a host and without credentials.
+func validURL(u *url.URL) bool {
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil
+}
+
+// Discovery is a server's discovery resource.
+type Discovery struct {
+	Title    string   `json:"title"`
+	Default  string   `json:"default"`
+	APIRoots []string `json:"api_roots"`
+}
+
+// Collection is a collection on an API root.
+type Collection struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	CanRead     bool   `json:"can_read"`
+	CanWrite    bool   `json:"can_write"`
+}
+
+// Page is a page of a collection's objects.
+type Page struct {
+	Objects []json.RawMessage
+	// More reports whether objects are left after this page.
+	More bool
+	// Next is the server's token for the next page, when it gives one.
+	Next string
+	// DateAddedLast is when the server added the last object of the page;
+	// zero when the page is empty or the server did not say.
+	DateAddedLast time.Time
+}
+
+// Discover reads the discovery resource at discoveryURL. API roots are
+// returned as absolute URLs ending with a slash.
+func (c *Client) Discover(ctx context.Context, discoveryURL string) (Discovery, error) {
+	var d Discovery
+	base, err := url.Parse(discoveryURL)
+	if err != nil {
+		return d, fmt.Errorf("%w: discovery URL: %w", ErrResponse, err)
+	}
+	if _, err := c.get(ctx, discoveryURL, &d); err != nil {
+		return d, err
+	}
+	roots := make([]string, 0, len(d.APIRoots))
+	for _, r := range d.APIRoots {
+		ref, err := url.Parse(r)
+		if err != nil {
+			return d, fmt.Errorf("%w: API root %q: %w", ErrResponse, r, err)
+		}
+		root := base.ResolveReference(ref)
+		if !validURL(root) {
+			return d, fmt.Errorf("%w: API root %q is not an http or https URL without credentials", ErrResponse, r)
+		}
+		roots = append(roots, withSlash(root.String()))
+	}
+	d.APIRoots = roots
+	return d, nil
+}
+
+// Collections lists the collections on an API root.
+func (c *Client) Collections(ctx context.Context, apiRoot string) ([]Collection, error) {
+	var body struct {
+		Collections []Collection `json:"collections"`
+	}
+	if _, err := c.get(ctx, withSlash(apiRoot)+"collections/", &body); err != nil {
+		return nil, err
+	}
+	for _, col := range body.Collections {
+		if col.ID == "" {
+			return nil, fmt.Errorf("%w: collection without an ID", ErrResponse)
+		}
+	}
+	return body.Collections, nil
+}
+
+// Objects reads a page of the indicators, identities and markings the server
+// added to a collection after addedAfter, or from the start when it is zero.
+// next continues from a previous page with the same addedAfter.
+func (c *Client) Objects(
+	ctx context.Context,
+	apiRoot, collectionID string,
+	addedAfter time.Time,
+	next string,
+) (Page, error) {
+	q := url.Values{}
+	q.Set("match[type]", objectTypes)
+	q.Set("limit", strconv.Itoa(pageLimit))
+	if !addedAfter.IsZero() {
+		q.Set("added_after", addedAfter.UTC().Format(time.RFC3339Nano))
+	}
+	if next != "" {
+		q.Set("next", next)
+	}
+	u := withSlash(apiRoot) + "collections/" + url.PathEscape(collectionID) + "/objects/?" + q.Encode()
+
+	var p Page
+	var body struct {
+		More    bool              `json:"more"`
+		Next    string            `json:"next"`
+		Objects []json.RawMessage `json:"objects"`
+	}
+	header, err := c.get(ctx, u, &body)
+	if err != nil {
+		return p, err
+	}
+	p.Objects, p.More, p.Next = body.Objects, body.More, body.Next
+	if v := header.Get(dateAddedLast); v != "" && len(p.Objects) > 0 {
+		if p.DateAddedLast, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			return p, fmt.Errorf("%w: %s %q: %w", ErrResponse, dateAddedLast, v, err)
+		}
+	}
+	return p, nil
+}
+
+// get requests a TAXII resource and decodes it into v. An empty response, as
+// servers may send for an empty page, leaves v as it is.
+func (c *Client) get(ctx context.Context, u string, v any) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrResponse, err)
+	}
+	// URLs are derived from what servers send, such as API roots, so each is
+	// checked again.
+	if !validURL(req.URL) {
+		return nil, fmt.Errorf("%w: %s is not an http or https URL without credentials", ErrResponse, req.URL.Redacted())
+	}
+	req.Header.Set("Accept", mediaType)
+	switch c.auth.Type {
+	case AuthBasic:
+		req.SetBasicAuth(c.auth.Username, c.auth.Secret)
+	case AuthToken:
+		req.Header.Set("Authorization", "Bearer "+c.auth.Secret)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("requesting %s: %w", req.URL.Redacted(), err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", req.URL.Redacted(), err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return nil, ErrUnauthorized
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		// Worth retrying.
+		return nil, fmt.Errorf("requesting %s: %s", req.URL.Redacted(), resp.Status)
+	case resp.StatusCode == http.StatusNoContent:
+		return resp.Header, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%w: %s from %s", ErrResponse, resp.Status, req.URL.Redacted())
+	case len(body) > maxResponseBytes:
+		return nil, fmt.Errorf("%w: %s sent more than %d bytes", ErrResponse, req.URL.Redacted(), maxResponseBytes)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "json") {
+		return nil, fmt.Errorf("%w: %s sent %s", ErrResponse, req.URL.Redacted(), ct)
+	}
+	if len(strings.TrimSpace(string(body))) == 0 {
+		return resp.Header, nil
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return nil, fmt.Errorf("%w: decoding %s: %w", ErrResponse, req.URL.Redacted(), err)
+	}
+	return resp.Header, nil
+}
+
+func withSlash(u string) string {
+	if strings.HasSuffix(u, "/") {
+		return u
+	}
+	return u + "/"
+}
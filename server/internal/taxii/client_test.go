@@ -0,0 +1,307 @@
+package taxii_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/internal/taxii"
+)
+
+// fakeObject is an object on the fake server's collection, with when the
+// server added it.
+type fakeObject struct {
+	added time.Time
+	raw   string
+}
+
+// fakeServer is an in-process TAXII 2.1 server with one API root holding a
+// readable collection, "indicators", and one that cannot be read, "private".
+// It pages the readable collection perPage objects at a time with next
+// tokens.
+type fakeServer struct {
+	*httptest.Server
+
+	// auth is the Authorization header every request must carry, if set.
+	auth    string
+	perPage int
+
+	mu      sync.Mutex
+	objects []fakeObject
+	// queries records the query of each objects request.
+	queries []map[string]string
+}
+
+func newFakeServer(t *testing.T, auth string) *fakeServer {
+	t.Helper()
+	f := &fakeServer{auth: auth, perPage: 2}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /taxii2/", func(w http.ResponseWriter, r *http.Request) {
+		// One root relative to the discovery URL and one absolute.
+		f.write(w, nil, map[string]any{
+			"title":     "Fake TAXII",
+			"api_roots": []string{"../intel", f.URL + "/empty/"},
+		})
+	})
+	mux.HandleFunc("GET /intel/collections/", func(w http.ResponseWriter, r *http.Request) {
+		f.write(w, nil, map[string]any{"collections": []map[string]any{
+			{"id": "indicators", "title": "Indicators", "can_read": true, "can_write": false},
+			{"id": "private", "title": "Private", "can_read": false, "can_write": false},
+		}})
+	})
+	mux.HandleFunc("GET /empty/collections/", func(w http.ResponseWriter, r *http.Request) {
+		f.write(w, nil, map[string]any{})
+	})
+	mux.HandleFunc("GET /intel/collections/indicators/objects/", f.serveObjects)
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.auth != "" && r.Header.Get("Authorization") != f.auth {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if !strings.HasPrefix(r.Header.Get("Accept"), "application/taxii+json") {
+			http.Error(w, "not acceptable", http.StatusNotAcceptable)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(f.Close)
+	return f
+}
+
+// add puts an indicator for ip on the collection, added at added.
+func (f *fakeServer) add(ip string, added time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	raw := fmt.Sprintf(`{"type":"indicator","spec_version":"2.1","id":"indicator--%d","name":"%s",`+
+		`"pattern":"[ipv4-addr:value = '%s']","pattern_type":"stix","valid_from":"2024-01-01T00:00:00Z"}`,
+		len(f.objects), ip, ip)
+	f.objects = append(f.objects, fakeObject{added: added, raw: raw})
+}
+
+func (f *fakeServer) serveObjects(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := map[string]string{}
+	for k := range r.URL.Query() {
+		q[k] = r.URL.Query().Get(k)
+	}
+	f.queries = append(f.queries, q)
+
+	var after time.Time
+	if v := q["added_after"]; v != "" {
+		var err error
+		if after, err = time.Parse(time.RFC3339Nano, v); err != nil {
+			http.Error(w, "bad added_after", http.StatusBadRequest)
+			return
+		}
+	}
+	var matched []fakeObject
+	for _, o := range f.objects {
+		if o.added.After(after) {
+			matched = append(matched, o)
+		}
+	}
+	start, _ := strconv.Atoi(q["next"])
+	end := min(start+f.perPage, len(matched))
+	if start >= end {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	page := matched[start:end]
+	objects := make([]json.RawMessage, len(page))
+	for i, o := range page {
+		objects[i] = json.RawMessage(o.raw)
+	}
+	body := map[string]any{"more": end < len(matched), "objects": objects}
+	if end < len(matched) {
+		body["next"] = strconv.Itoa(end)
+	}
+	f.write(w, http.Header{
+		"X-TAXII-Date-Added-Last": {page[len(page)-1].added.Format(time.RFC3339Nano)},
+	}, body)
+}
+
+func (f *fakeServer) write(w http.ResponseWriter, header http.Header, body any) {
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Type", "application/taxii+json;version=2.1")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestClient_DiscoversRootsAndCollections(t *testing.T) {
+	srv := newFakeServer(t, "")
+	c := taxii.NewClient(http.DefaultClient, taxii.Auth{Type: taxii.AuthNone})
+	ctx := context.Background()
+
+	d, err := c.Discover(ctx, srv.URL+"/taxii2/")
+	if err != nil {
+		t.Fatalf("Discover: %v", err)
+	}
+	want := []string{srv.URL + "/intel/", srv.URL + "/empty/"}
+	if len(d.APIRoots) != 2 || d.APIRoots[0] != want[0] || d.APIRoots[1] != want[1] {
+		t.Fatalf("api roots = %v, want %v", d.APIRoots, want)
+	}
+
+	cols, err := c.Collections(ctx, d.APIRoots[0])
+	if err != nil {
+		t.Fatalf("Collections: %v", err)
+	}
+	if len(cols) != 2 || cols[0].ID != "indicators" || !cols[0].CanRead || cols[1].CanRead {
+		t.Fatalf("collections = %+v", cols)
+	}
+	if cols, err := c.Collections(ctx, d.APIRoots[1]); err != nil || len(cols) != 0 {
+		t.Fatalf("empty root collections = %+v, %v", cols, err)
+	}
+}
+
+func TestClient_RefusesLoopbackDiscoveryURL(t *testing.T) {
+	srv := newFakeServer(t, "")
+	_, err := taxii.NewClient(nil, taxii.Auth{Type: taxii.AuthNone}).
+		Discover(context.Background(), srv.URL+"/taxii2/")
+	if !errors.Is(err, taxii.ErrForbiddenAddress) {
+		t.Fatalf("loopback err = %v, want ErrForbiddenAddress", err)
+	}
+}
+
+func TestClient_RefusesReservedAddresses(t *testing.T) {
+	client := taxii.NewClient(nil, taxii.Auth{Type: taxii.AuthNone})
+	for prefix, addr := range map[string]string{
+		"0.0.0.0/8":       "0.1.2.3",
+		"10.0.0.0/8":      "10.1.2.3",
+		"100.64.0.0/10":   "100.100.100.200",
+		"127.0.0.0/8":     "127.0.0.2",
+		"169.254.0.0/16":  "169.254.169.254",
+		"172.16.0.0/12":   "172.31.0.1",
+		"192.0.0.0/24":    "192.0.0.170",
+		"192.0.2.0/24":    "192.0.2.1",
+		"192.88.99.0/24":  "192.88.99.1",
+		"192.168.0.0/16":  "192.168.1.1",
+		"198.18.0.0/15":   "198.19.0.1",
+		"198.51.100.0/24": "198.51.100.1",
+		"203.0.113.0/24":  "203.0.113.1",
+		"224.0.0.0/4":     "239.255.255.250",
+		"240.0.0.0/4":     "255.255.255.255",
+		"::/96":           "::1",
+		"::ffff:0:0/96":   "::ffff:10.0.0.1",
+		"64:ff9b::/96":    "64:ff9b::a9fe:a9fe",
+		"64:ff9b:1::/48":  "64:ff9b:1::a00:1",
+		"100::/64":        "100::1",
+		"2001::/23":       "2001::a00:1",
+		"2001:db8::/32":   "2001:db8::1",
+		"2002::/16":       "2002:a00:1::1",
+		"fc00::/7":        "fd00::1",
+		"fe80::/10":       "fe80::1",
+		"fec0::/10":       "fec0::1",
+		"ff00::/8":        "ff02::1",
+	} {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		_, err := client.Discover(ctx, "http://"+net.JoinHostPort(addr, "80")+"/taxii2/")
+		cancel()
+		if !errors.Is(err, taxii.ErrForbiddenAddress) {
+			t.Errorf("%s (%s): err = %v, want ErrForbiddenAddress", prefix, addr, err)
+		}
+	}
+}
+
+func TestClient_RejectsAPIRootsThatAreNotHTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/taxii+json;version=2.1")
+		_, _ = w.Write([]byte(`{"title":"Evil","api_roots":["file:///etc/"]}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	_, err := taxii.NewClient(http.DefaultClient, taxii.Auth{Type: taxii.AuthNone}).
+		Discover(context.Background(), srv.URL+"/taxii2/")
+	if !errors.Is(err, taxii.ErrResponse) {
+		t.Fatalf("file api root err = %v, want ErrResponse", err)
+	}
+}
+
+func TestClient_Authenticates(t *testing.T) {
+	ctx := context.Background()
+
+	token := newFakeServer(t, "Bearer s3cret")
+	if _, err := taxii.NewClient(http.DefaultClient, taxii.Auth{Type: taxii.AuthToken, Secret: "s3cret"}).
+		Discover(ctx, token.URL+"/taxii2/"); err != nil {
+		t.Fatalf("token auth: %v", err)
+	}
+
+	basic := newFakeServer(t, "Basic YWxpY2U6czNjcmV0") // alice:s3cret
+	if _, err := taxii.NewClient(http.DefaultClient, taxii.Auth{Type: taxii.AuthBasic, Username: "alice", Secret: "s3cret"}).
+		Discover(ctx, basic.URL+"/taxii2/"); err != nil {
+		t.Fatalf("basic auth: %v", err)
+	}
+
+	_, err := taxii.NewClient(http.DefaultClient, taxii.Auth{Type: taxii.AuthToken, Secret: "wrong"}).
+		Discover(ctx, token.URL+"/taxii2/")
+	if !errors.Is(err, taxii.ErrUnauthorized) {
+		t.Fatalf("wrong token err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestClient_RejectsNonTAXIIResponses(t *testing.T) {
+	srv := newFakeServer(t, "")
+	c := taxii.NewClient(http.DefaultClient, taxii.Auth{Type: taxii.AuthNone})
+
+	if _, err := c.Collections(context.Background(), srv.URL+"/missing/"); !errors.Is(err, taxii.ErrResponse) {
+		t.Fatalf("404 err = %v, want ErrResponse", err)
+	}
+}
+
+func TestClient_PagesObjects(t *testing.T) {
+	srv := newFakeServer(t, "")
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := range 3 {
+		srv.add(fmt.Sprintf("203.0.113.%d", i+1), base.Add(time.Duration(i)*time.Second))
+	}
+	c := taxii.NewClient(http.DefaultClient, taxii.Auth{Type: taxii.AuthNone})
+	ctx := context.Background()
+	root := srv.URL + "/intel"
+
+	first, err := c.Objects(ctx, root, "indicators", time.Time{}, "")
+	if err != nil {
+		t.Fatalf("first page: %v", err)
+	}
+	if len(first.Objects) != 2 || !first.More || first.Next != "2" {
+		t.Fatalf("first page = %d objects, more %v, next %q", len(first.Objects), first.More, first.Next)
+	}
+	if !first.DateAddedLast.Equal(base.Add(time.Second)) {
+		t.Fatalf("first date added last = %v", first.DateAddedLast)
+	}
+
+	second, err := c.Objects(ctx, root, "indicators", time.Time{}, first.Next)
+	if err != nil {
+		t.Fatalf("second page: %v", err)
+	}
+	if len(second.Objects) != 1 || second.More {
+		t.Fatalf("second page = %d objects, more %v", len(second.Objects), second.More)
+	}
+
+	// Nothing added since the last object: an empty 204 page.
+	last, err := c.Objects(ctx, root, "indicators", second.DateAddedLast, "")
+	if err != nil {
+		t.Fatalf("caught up page: %v", err)
+	}
+	if len(last.Objects) != 0 || last.More || !last.DateAddedLast.IsZero() {
+		t.Fatalf("caught up page = %+v", last)
+	}
+
+	q := srv.queries[0]
+	if q["match[type]"] != "indicator,identity,marking-definition" || q["limit"] == "" || q["added_after"] != "" {
+		t.Fatalf("first query = %v", q)
+	}
+	if got := srv.queries[2]["added_after"]; got != base.Add(2*time.Second).Format(time.RFC3339Nano) {
+		t.Fatalf("added_after = %q", got)
+	}
+}
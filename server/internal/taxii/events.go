@@ -0,0 +1,44 @@
+package taxii
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per feed. What a sync
+// imports is announced by threatintel's import events.
+const (
+	aggregateType = "taxii_feed"
+
+	EventCreated           = "taxii_feed.created"
+	EventUpdated           = "taxii_feed.updated"
+	EventDeleted           = "taxii_feed.deleted"
+	EventCollectionUpdated = "taxii_feed.collection_updated"
+)
+
+// DeletedPayload is the payload of EventDeleted.
+type DeletedPayload struct {
+	ID    uuid.UUID `json:"id"`
+	OrgID uuid.UUID `json:"org_id"`
+	Name  string    `json:"name"`
+}
+
+// emit appends an event about aggregateID to the outbox, in the transaction
+// on ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, aggregateID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
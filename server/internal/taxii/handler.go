@@ -0,0 +1,382 @@
+package taxii
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// Handler serves the /organizations/{orgId}/taxii-feeds/* endpoints. Members
+// may read feeds and their collections; admins manage and poll them.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound() oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", "TAXII feed not found"),
+	)
+}
+
+func collectionNotFound() oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", "TAXII collection not found"),
+	)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func conflict(detail string) oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", detail),
+	)
+}
+
+// saveConflict is the 409 detail for a feed that cannot be saved as given.
+func saveConflict(err error) string {
+	if errors.Is(err, ErrNoKey) {
+		return "No key to encrypt the feed's secret with is configured"
+	}
+	return "The organisation already has a TAXII feed of this name"
+}
+
+func (h *Handler) ListTaxiiFeeds(
+	ctx context.Context,
+	request oapi.ListTaxiiFeedsRequestObject,
+) (oapi.ListTaxiiFeedsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListTaxiiFeeds403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	feeds, err := h.svc.List(ctx, request.OrgId)
+	if err != nil {
+		return nil, err
+	}
+	return oapi.ListTaxiiFeeds200JSONResponse(feeds), nil
+}
+
+func (h *Handler) CreateTaxiiFeed(
+	ctx context.Context,
+	request oapi.CreateTaxiiFeedRequestObject,
+) (oapi.CreateTaxiiFeedResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	feed, err := h.svc.Create(ctx, request.OrgId, *request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalid):
+			return oapi.CreateTaxiiFeed400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrConflict), errors.Is(err, ErrNoKey):
+			return oapi.CreateTaxiiFeed409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict(saveConflict(err)),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateTaxiiFeed201JSONResponse(feed), nil
+}
+
+func (h *Handler) GetTaxiiFeed(
+	ctx context.Context,
+	request oapi.GetTaxiiFeedRequestObject,
+) (oapi.GetTaxiiFeedResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	feed, err := h.svc.Get(ctx, request.OrgId, request.FeedId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetTaxiiFeed404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(feed.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetTaxiiFeed304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetTaxiiFeed200JSONResponse{
+		Body:    feed,
+		Headers: oapi.GetTaxiiFeed200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
+func (h *Handler) UpdateTaxiiFeed(
+	ctx context.Context,
+	request oapi.UpdateTaxiiFeedRequestObject,
+) (oapi.UpdateTaxiiFeedResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return feedPreconditionFailed(), nil
+	}
+
+	feed, err := h.svc.Update(ctx, request.OrgId, request.FeedId, *request.Body, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.UpdateTaxiiFeed404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.UpdateTaxiiFeed400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrConflict), errors.Is(err, ErrNoKey):
+			return oapi.UpdateTaxiiFeed409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict(saveConflict(err)),
+			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return feedPreconditionFailed(), nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateTaxiiFeed200JSONResponse{
+		Body:    feed,
+		Headers: oapi.UpdateTaxiiFeed200ResponseHeaders{ETag: httpx.ETag(feed.Version)},
+	}, nil
+}
+
+func feedPreconditionFailed() oapi.UpdateTaxiiFeed412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateTaxiiFeed412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "TAXII feed was modified since it was last read"),
+		),
+	}
+}
+
+func (h *Handler) DeleteTaxiiFeed(
+	ctx context.Context,
+	request oapi.DeleteTaxiiFeedRequestObject,
+) (oapi.DeleteTaxiiFeedResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Delete(ctx, request.OrgId, request.FeedId); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.DeleteTaxiiFeed404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteTaxiiFeed204Response{}, nil
+}
+
+func (h *Handler) DiscoverTaxiiCollections(
+	ctx context.Context,
+	request oapi.DiscoverTaxiiCollectionsRequestObject,
+) (oapi.DiscoverTaxiiCollectionsResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DiscoverTaxiiCollections403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DiscoverTaxiiCollections403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	cols, err := h.svc.Discover(ctx, request.OrgId, request.FeedId)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.DiscoverTaxiiCollections404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrUnreachable):
+			return oapi.DiscoverTaxiiCollections502ApplicationProblemPlusJSONResponse{
+				BadGatewayApplicationProblemPlusJSONResponse: oapi.BadGatewayApplicationProblemPlusJSONResponse(
+					httpx.Prob(502, "Bad Gateway", err.Error()),
+				),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DiscoverTaxiiCollections200JSONResponse(cols), nil
+}
+
+func (h *Handler) SyncTaxiiFeed(
+	ctx context.Context,
+	request oapi.SyncTaxiiFeedRequestObject,
+) (oapi.SyncTaxiiFeedResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.SyncTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.SyncTaxiiFeed403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	feed, err := h.svc.Sync(ctx, request.OrgId, request.FeedId)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.SyncTaxiiFeed404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrDisabled):
+			return oapi.SyncTaxiiFeed409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("The TAXII feed is disabled"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.SyncTaxiiFeed202JSONResponse(feed), nil
+}
+
+func (h *Handler) ListTaxiiCollections(
+	ctx context.Context,
+	request oapi.ListTaxiiCollectionsRequestObject,
+) (oapi.ListTaxiiCollectionsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListTaxiiCollections403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	cols, err := h.svc.Collections(ctx, request.OrgId, request.FeedId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.ListTaxiiCollections404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ListTaxiiCollections200JSONResponse(cols), nil
+}
+
+func (h *Handler) UpdateTaxiiCollection(
+	ctx context.Context,
+	request oapi.UpdateTaxiiCollectionRequestObject,
+) (oapi.UpdateTaxiiCollectionResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateTaxiiCollection403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateTaxiiCollection403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	col, err := h.svc.UpdateCollection(ctx, request.OrgId, request.FeedId, request.CollectionId, *request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.UpdateTaxiiCollection404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrCollectionNotFound):
+			return oapi.UpdateTaxiiCollection404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: collectionNotFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateTaxiiCollection200JSONResponse(col), nil
+}
@@ -0,0 +1,520 @@
+package taxii
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns taxii_feeds and taxii_collections SQL and row→DTO mapping. The
+// tables are protected by RLS, so callers run statements inside
+// tx.Manager.RunInTx; the scheduler runs Due on the privileged pool.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.TaxiiFeeds) oapi.TaxiiFeed {
+	tags := []string(m.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	f := oapi.TaxiiFeed{
+		Id:                  m.ID,
+		OrgId:               m.OrgID,
+		Name:                m.Name,
+		DiscoveryUrl:        m.DiscoveryURL,
+		AuthType:            oapi.TaxiiAuthType(m.AuthType),
+		Username:            m.Username,
+		HasSecret:           m.Secret != nil,
+		PollIntervalMinutes: int(m.PollIntervalMinutes),
+		Enabled:             m.Enabled,
+		Tags:                tags,
+		NextPollAt:          m.NextPollAt,
+		LastPolledAt:        m.LastPolledAt,
+		Error:               m.Error,
+		Version:             m.Version,
+		CreatedAt:           m.CreatedAt,
+		UpdatedAt:           m.UpdatedAt,
+	}
+	if m.Confidence != nil {
+		c := int(*m.Confidence)
+		f.Confidence = &c
+	}
+	if m.Tlp != nil {
+		tlp := oapi.Tlp(*m.Tlp)
+		f.Tlp = &tlp
+	}
+	return f
+}
+
+func toOapiList(rows []model.TaxiiFeeds) []oapi.TaxiiFeed {
+	feeds := make([]oapi.TaxiiFeed, 0, len(rows))
+	for _, row := range rows {
+		feeds = append(feeds, toOapi(row))
+	}
+	return feeds
+}
+
+// settingsOf returns a feed's stored settings.
+func settingsOf(m model.TaxiiFeeds) settings {
+	f := settings{
+		name:         m.Name,
+		discoveryURL: m.DiscoveryURL,
+		authType:     m.AuthType,
+		username:     m.Username,
+		secret:       m.Secret,
+		pollInterval: int(m.PollIntervalMinutes),
+		enabled:      m.Enabled,
+		tags:         m.Tags,
+	}
+	if m.Confidence != nil {
+		c := int(*m.Confidence)
+		f.confidence = &c
+	}
+	if m.Tlp != nil {
+		tlp := oapi.Tlp(*m.Tlp)
+		f.tlp = &tlp
+	}
+	return f
+}
+
+func toCollection(m model.TaxiiCollections) oapi.TaxiiCollection {
+	return oapi.TaxiiCollection{
+		Id:                m.ID,
+		OrgId:             m.OrgID,
+		FeedId:            m.FeedID,
+		ApiRoot:           m.APIRoot,
+		CollectionId:      m.CollectionID,
+		Title:             m.Title,
+		Description:       m.Description,
+		CanRead:           m.CanRead,
+		Enabled:           m.Enabled,
+		AddedAfter:        m.AddedAfter,
+		LastSyncedAt:      m.LastSyncedAt,
+		Error:             m.Error,
+		IndicatorsCreated: m.IndicatorsCreated,
+		IndicatorsUpdated: m.IndicatorsUpdated,
+		CreatedAt:         m.CreatedAt,
+		UpdatedAt:         m.UpdatedAt,
+	}
+}
+
+func toCollectionList(rows []model.TaxiiCollections) []oapi.TaxiiCollection {
+	cols := make([]oapi.TaxiiCollection, 0, len(rows))
+	for _, row := range rows {
+		cols = append(cols, toCollection(row))
+	}
+	return cols
+}
+
+// nameTaken reports whether err is a unique violation on (org_id, name).
+func nameTaken(err error) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func intOrNull(i *int) postgres.IntegerExpression {
+	if i == nil {
+		return postgres.IntExp(postgres.NULL)
+	}
+	return postgres.Int32(int32(*i)) //nolint:gosec // confidence is checked to be 0-100
+}
+
+func tlpOrNull(t *oapi.Tlp) postgres.StringExpression {
+	if t == nil {
+		return postgres.StringExp(postgres.NULL)
+	}
+	return postgres.String(string(*t))
+}
+
+func stringOrNull(s *string) postgres.StringExpression {
+	if s == nil {
+		return postgres.StringExp(postgres.NULL)
+	}
+	return postgres.String(*s)
+}
+
+func bytesOrNull(b *[]byte) postgres.Expression {
+	if b == nil {
+		return postgres.NULL
+	}
+	return postgres.Bytea(*b)
+}
+
+func tagsArray(tags []string) postgres.Expression {
+	if len(tags) == 0 {
+		return postgres.Raw("'{}'::text[]")
+	}
+	return postgres.StringArray(tags...)
+}
+
+// ── Feeds ────────────────────────────────────────────────────────────────────
+
+// Insert stores a new feed from checked settings. Returns ErrConflict when
+// the org already has a feed of that name.
+func (r *Repo) Insert(ctx context.Context, orgID uuid.UUID, f settings) (model.TaxiiFeeds, error) {
+	t := table.TaxiiFeeds
+	stmt := t.
+		INSERT(
+			t.OrgID, t.Name, t.DiscoveryURL, t.AuthType, t.Username, t.Secret,
+			t.PollIntervalMinutes, t.Enabled, t.Confidence, t.Tlp, t.Tags,
+		).
+		VALUES(
+			orgID, f.name, f.discoveryURL, f.authType, stringOrNull(f.username), bytesOrNull(f.secret),
+			int32(f.pollInterval), f.enabled, intOrNull(f.confidence), tlpOrNull(f.tlp), tagsArray(f.tags),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.TaxiiFeeds
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if nameTaken(err) {
+			return row, ErrConflict
+		}
+		return row, fmt.Errorf("inserting TAXII feed: %w", err)
+	}
+	return row, nil
+}
+
+// List returns the org's feeds, by name.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID) ([]model.TaxiiFeeds, error) {
+	t := table.TaxiiFeeds
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID))).
+		ORDER_BY(t.Name.ASC())
+
+	var rows []model.TaxiiFeeds
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing TAXII feeds: %w", err)
+	}
+	return rows, nil
+}
+
+// Get returns a single feed. Returns ErrNotFound when it does not exist in
+// the org.
+func (r *Repo) Get(ctx context.Context, orgID, feedID uuid.UUID) (model.TaxiiFeeds, error) {
+	t := table.TaxiiFeeds
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(feedID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	var row model.TaxiiFeeds
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return row, ErrNotFound
+		}
+		return row, fmt.Errorf("getting TAXII feed: %w", err)
+	}
+	return row, nil
+}
+
+// Update replaces the settings of the feed read as current, bumping its
+// version, and schedules its next poll. Returns ErrPreconditionFailed when
+// the feed changed since, and ErrConflict when the new name is taken.
+func (r *Repo) Update(
+	ctx context.Context,
+	current model.TaxiiFeeds,
+	f settings,
+	nextPoll time.Time,
+) (model.TaxiiFeeds, error) {
+	t := table.TaxiiFeeds
+	stmt := t.
+		UPDATE(
+			t.Name, t.DiscoveryURL, t.AuthType, t.Username, t.Secret, t.PollIntervalMinutes, t.Enabled,
+			t.Confidence, t.Tlp, t.Tags, t.NextPollAt, t.Version, t.UpdatedAt,
+		).
+		SET(
+			postgres.String(f.name),
+			postgres.String(f.discoveryURL),
+			postgres.String(f.authType),
+			stringOrNull(f.username),
+			bytesOrNull(f.secret),
+			postgres.Int32(int32(f.pollInterval)), //nolint:gosec // checked to be at most a week
+			postgres.Bool(f.enabled),
+			intOrNull(f.confidence),
+			tlpOrNull(f.tlp),
+			tagsArray(f.tags),
+			postgres.TimestampzT(nextPoll), t.Version.ADD(postgres.Int(1)), postgres.NOW(),
+		).
+		WHERE(
+			t.ID.EQ(postgres.UUID(current.ID)).
+				AND(t.OrgID.EQ(postgres.UUID(current.OrgID))).
+				AND(t.Version.EQ(postgres.Int64(current.Version))),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.TaxiiFeeds
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	switch {
+	case err == nil:
+		return row, nil
+	case nameTaken(err):
+		return row, ErrConflict
+	case errors.Is(err, qrm.ErrNoRows):
+		return row, ErrPreconditionFailed
+	}
+	return row, fmt.Errorf("updating TAXII feed: %w", err)
+}
+
+// Delete removes a feed and, by cascade, its collections. Returns ErrNotFound
+// when no row matched.
+func (r *Repo) Delete(ctx context.Context, orgID, feedID uuid.UUID) error {
+	t := table.TaxiiFeeds
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(feedID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting TAXII feed: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Polled records the outcome of discovering a feed's collections: the error,
+// or nil when discovery succeeded. The feed's version is left alone; its
+// sync state is not part of what If-Match guards.
+func (r *Repo) Polled(ctx context.Context, orgID, feedID uuid.UUID, failure *string) error {
+	t := table.TaxiiFeeds
+	stmt := t.
+		UPDATE(t.LastPolledAt, t.Error).
+		SET(postgres.NOW(), stringOrNull(failure)).
+		WHERE(
+			t.ID.EQ(postgres.UUID(feedID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("recording TAXII feed poll: %w", err)
+	}
+	return nil
+}
+
+// Due locks up to limit enabled feeds, of any org, whose next poll is due at
+// now, and moves their next poll an interval on. Rows locked by a concurrent
+// scheduler are skipped. Run on the privileged pool.
+func (r *Repo) Due(ctx context.Context, now time.Time, limit int) ([]model.TaxiiFeeds, error) {
+	t := table.TaxiiFeeds
+	due := postgres.
+		SELECT(t.ID.AS("id")).
+		FROM(t).
+		WHERE(
+			t.Enabled.IS_TRUE().
+				AND(t.NextPollAt.LT_EQ(postgres.TimestampzT(now))),
+		).
+		ORDER_BY(t.NextPollAt.ASC()).
+		LIMIT(int64(limit)).
+		FOR(postgres.UPDATE().SKIP_LOCKED()).
+		AsTable("due")
+	dueID := postgres.StringColumn("id").From(due)
+
+	stmt := t.
+		UPDATE(t.NextPollAt).
+		SET(postgres.TimestampzT(now).ADD(postgres.INTERVAL(1, postgres.MINUTE).MUL(t.PollIntervalMinutes))).
+		FROM(due).
+		WHERE(t.ID.EQ(dueID)).
+		RETURNING(t.AllColumns)
+
+	var rows []model.TaxiiFeeds
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("claiming due TAXII feeds: %w", err)
+	}
+	return rows, nil
+}
+
+// ── Collections ──────────────────────────────────────────────────────────────
+
+// SaveCollections records the collections found on a feed's server. Ones
+// seen before keep their sync state and whether they are enabled; new ones
+// are enabled when they can be read. Returns every collection of the feed,
+// including ones the server no longer lists.
+func (r *Repo) SaveCollections(
+	ctx context.Context,
+	orgID, feedID uuid.UUID,
+	found map[string][]Collection,
+) ([]model.TaxiiCollections, error) {
+	t := table.TaxiiCollections
+	stmt := t.INSERT(
+		t.OrgID, t.FeedID, t.APIRoot, t.CollectionID, t.Title, t.Description, t.CanRead, t.Enabled,
+	)
+	n := 0
+	for root, cols := range found {
+		for _, c := range cols {
+			var description *string
+			if c.Description != "" {
+				description = &c.Description
+			}
+			stmt = stmt.VALUES(orgID, feedID, root, c.ID, c.Title, description, c.CanRead, c.CanRead)
+			n++
+		}
+	}
+	if n > 0 {
+		stmt = stmt.
+			ON_CONFLICT(t.FeedID, t.APIRoot, t.CollectionID).
+			DO_UPDATE(postgres.SET(
+				t.Title.SET(t.EXCLUDED.Title),
+				t.Description.SET(t.EXCLUDED.Description),
+				t.CanRead.SET(t.EXCLUDED.CanRead),
+				t.UpdatedAt.SET(postgres.NOW()),
+			))
+		if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+			return nil, fmt.Errorf("saving TAXII collections: %w", err)
+		}
+	}
+	return r.ListCollections(ctx, orgID, feedID)
+}
+
+// ListCollections returns a feed's collections, by API root and title.
+func (r *Repo) ListCollections(ctx context.Context, orgID, feedID uuid.UUID) ([]model.TaxiiCollections, error) {
+	t := table.TaxiiCollections
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.FeedID.EQ(postgres.UUID(feedID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		ORDER_BY(t.APIRoot.ASC(), t.Title.ASC(), t.CollectionID.ASC())
+
+	var rows []model.TaxiiCollections
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing TAXII collections: %w", err)
+	}
+	return rows, nil
+}
+
+// UpdateCollection sets whether a collection is synced, when enabled is
+// non-nil, and with resync forgets its sync state. Returns
+// ErrCollectionNotFound when it is not one of the feed's.
+func (r *Repo) UpdateCollection(
+	ctx context.Context,
+	orgID, feedID, collectionID uuid.UUID,
+	enabled *bool,
+	resync bool,
+) (model.TaxiiCollections, error) {
+	t := table.TaxiiCollections
+	enabledExpr := postgres.BoolExpression(t.Enabled)
+	if enabled != nil {
+		enabledExpr = postgres.Bool(*enabled)
+	}
+	addedAfter := postgres.TimestampzExpression(t.AddedAfter)
+	failure := postgres.StringExpression(t.Error)
+	if resync {
+		addedAfter = postgres.TimestampzExp(postgres.NULL)
+		failure = postgres.StringExp(postgres.NULL)
+	}
+
+	stmt := t.
+		UPDATE(t.Enabled, t.AddedAfter, t.Error, t.UpdatedAt).
+		SET(enabledExpr, addedAfter, failure, postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(collectionID)).
+				AND(t.FeedID.EQ(postgres.UUID(feedID))).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.TaxiiCollections
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return row, ErrCollectionNotFound
+		}
+		return row, fmt.Errorf("updating TAXII collection: %w", err)
+	}
+	return row, nil
+}
+
+// DeleteCollections forgets a feed's collections and their sync state.
+func (r *Repo) DeleteCollections(ctx context.Context, orgID, feedID uuid.UUID) error {
+	t := table.TaxiiCollections
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.FeedID.EQ(postgres.UUID(feedID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("deleting TAXII collections: %w", err)
+	}
+	return nil
+}
+
+// Checkpoint records a page of a collection synced: the date the server
+// added its last object, when it said, and the indicators it created and
+// updated. Clears the collection's error.
+func (r *Repo) Checkpoint(
+	ctx context.Context,
+	orgID, collectionID uuid.UUID,
+	addedAfter time.Time,
+	created, updated int,
+) error {
+	t := table.TaxiiCollections
+	cursor := postgres.TimestampzExpression(t.AddedAfter)
+	if !addedAfter.IsZero() {
+		cursor = postgres.TimestampzT(addedAfter)
+	}
+	stmt := t.
+		UPDATE(t.AddedAfter, t.LastSyncedAt, t.Error, t.IndicatorsCreated, t.IndicatorsUpdated, t.UpdatedAt).
+		SET(
+			cursor,
+			postgres.NOW(),
+			postgres.StringExp(postgres.NULL),
+			t.IndicatorsCreated.ADD(postgres.Int(int64(created))),
+			t.IndicatorsUpdated.ADD(postgres.Int(int64(updated))),
+			postgres.NOW(),
+		).
+		WHERE(
+			t.ID.EQ(postgres.UUID(collectionID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("checkpointing TAXII collection: %w", err)
+	}
+	return nil
+}
+
+// CollectionFailed records why syncing a collection failed.
+func (r *Repo) CollectionFailed(ctx context.Context, orgID, collectionID uuid.UUID, failure string) error {
+	t := table.TaxiiCollections
+	stmt := t.
+		UPDATE(t.Error, t.UpdatedAt).
+		SET(postgres.String(failure), postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(collectionID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("recording TAXII collection failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,436 @@
+// Package taxii polls TAXII 2.1 servers for threat intelligence. An org adds
+// a feed naming a server's discovery URL and how to authenticate with it; the
+// password or token is sealed with the org's credentials key before it is
+// stored. A scheduled job enqueues a sync of every feed due a poll, which
+// discovers the server's API roots and their collections, then reads the
+// objects added to each enabled collection since it was last synced, page by
+// page, importing the STIX indicators among them into the org's indicator
+// store through threatintel.
+//
+// Each page is imported in the transaction that advances its collection's
+// added_after, so a sync that stops, or reaches its page budget, is carried
+// on by the next from the last page imported.
+package taxii
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/secret"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+// Auth types, mirrored by the TaxiiAuthType enum in the OpenAPI spec.
+const (
+	AuthNone  = "none"
+	AuthBasic = "basic"
+	AuthToken = "token"
+)
+
+// Limits and defaults of a feed.
+const (
+	maxNameLen          = 255
+	defaultPollInterval = 60
+	minPollInterval     = 5
+	maxPollInterval     = 7 * 24 * 60
+)
+
+// ErrNotFound is returned when a feed does not exist in the org.
+var ErrNotFound = errors.New("TAXII feed not found")
+
+// ErrCollectionNotFound is returned when a collection does not exist on the
+// feed.
+var ErrCollectionNotFound = errors.New("TAXII collection not found")
+
+// ErrConflict is returned when the org already has a feed of that name.
+var ErrConflict = errors.New("TAXII feed name taken")
+
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("TAXII feed version mismatch")
+
+// ErrInvalid is returned for a feed whose settings are out of range or do not
+// go together.
+var ErrInvalid = errors.New("invalid TAXII feed")
+
+// ErrNoKey is returned when a feed's secret is to be stored or used and no
+// key to seal it with is configured.
+var ErrNoKey = errors.New("no key configured to encrypt credentials with")
+
+// ErrDisabled is returned when syncing a disabled feed.
+var ErrDisabled = errors.New("TAXII feed disabled")
+
+// ErrUnreachable wraps the error of a server that could not be discovered.
+var ErrUnreachable = errors.New("TAXII server unavailable")
+
+// Service orchestrates feed operations and syncs. Each runs in a transaction
+// carrying the caller's tenant scope, joining one already on ctx; requests to
+// servers are made outside of them. Mutations append their domain event to
+// the outbox in the transaction they commit in.
+type Service struct {
+	repo   *Repo
+	intel  *threatintel.Service
+	box    *secret.Box
+	http   *http.Client
+	jobs   *jobs.Repo
+	tx     *tx.Manager
+	events *outbox.Repo
+	logger *slog.Logger
+}
+
+// NewService wires a Service. box is nil when no credentials key is
+// configured, in which case only feeds without a secret can be used.
+// httpClient is nil for the default client.
+func NewService(
+	repo *Repo,
+	intel *threatintel.Service,
+	box *secret.Box,
+	httpClient *http.Client,
+	jobsRepo *jobs.Repo,
+	txm *tx.Manager,
+	events *outbox.Repo,
+	logger *slog.Logger,
+) *Service {
+	return &Service{
+		repo:   repo,
+		intel:  intel,
+		box:    box,
+		http:   httpClient,
+		jobs:   jobsRepo,
+		tx:     txm,
+		events: events,
+		logger: logger,
+	}
+}
+
+// settings are a feed's configuration, checked and with its secret sealed.
+type settings struct {
+	name         string
+	discoveryURL string
+	authType     string
+	username     *string
+	secret       *[]byte
+	pollInterval int
+	enabled      bool
+	confidence   *int
+	tlp          *oapi.Tlp
+	tags         []string
+}
+
+// check checks s, given plain as its new secret, if any, and seals that for
+// the org. Returns an error wrapping ErrInvalid when s is not a usable feed,
+// and ErrNoKey when there is a secret to seal without a key.
+func (s *Service) check(orgID uuid.UUID, f *settings, plain *string) error {
+	f.name = strings.TrimSpace(f.name)
+	if f.name == "" || utf8.RuneCountInString(f.name) > maxNameLen {
+		return fmt.Errorf("%w: name must be 1 to %d characters", ErrInvalid, maxNameLen)
+	}
+	u, err := url.Parse(f.discoveryURL)
+	if err != nil || !validURL(u) {
+		return fmt.Errorf("%w: discovery_url must be an http or https URL without credentials", ErrInvalid)
+	}
+	if f.pollInterval < minPollInterval || f.pollInterval > maxPollInterval {
+		return fmt.Errorf("%w: poll_interval_minutes must be between %d and %d",
+			ErrInvalid, minPollInterval, maxPollInterval)
+	}
+	f.tags = threatintel.CleanTags(f.tags)
+	if err := threatintel.CheckAttributes(nil, f.confidence, f.tlp, &f.tags, nil); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if f.username != nil && *f.username == "" {
+		f.username = nil
+	}
+
+	switch f.authType {
+	case AuthNone:
+		if f.username != nil || plain != nil {
+			return fmt.Errorf("%w: auth_type none takes no username or secret", ErrInvalid)
+		}
+		f.secret = nil
+		return nil
+	case AuthBasic:
+		if f.username == nil {
+			return fmt.Errorf("%w: auth_type basic requires a username", ErrInvalid)
+		}
+	case AuthToken:
+		if f.username != nil {
+			return fmt.Errorf("%w: auth_type token takes no username", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: unknown auth_type %q", ErrInvalid, f.authType)
+	}
+	if plain == nil {
+		if f.secret == nil {
+			return fmt.Errorf("%w: auth_type %s requires a secret", ErrInvalid, f.authType)
+		}
+		return nil
+	}
+	if s.box == nil {
+		return ErrNoKey
+	}
+	sealed, err := s.box.Seal(orgID, []byte(*plain))
+	if err != nil {
+		return err
+	}
+	f.secret = &sealed
+	return nil
+}
+
+// Create adds a feed, first polled as soon as the scheduler next runs.
+// Returns ErrInvalid for a feed that cannot be used as given, ErrNoKey when
+// it has a secret and no key is configured, and ErrConflict when the name is
+// taken.
+func (s *Service) Create(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateTaxiiFeedRequest,
+) (oapi.TaxiiFeed, error) {
+	f := settings{
+		name:         req.Name,
+		discoveryURL: req.DiscoveryUrl,
+		authType:     AuthNone,
+		username:     req.Username,
+		pollInterval: defaultPollInterval,
+		enabled:      true,
+		confidence:   req.Confidence,
+		tlp:          req.Tlp,
+	}
+	if req.AuthType != nil {
+		f.authType = string(*req.AuthType)
+	}
+	if req.PollIntervalMinutes != nil {
+		f.pollInterval = *req.PollIntervalMinutes
+	}
+	if req.Enabled != nil {
+		f.enabled = *req.Enabled
+	}
+	if req.Tags != nil {
+		f.tags = *req.Tags
+	}
+	if err := s.check(orgID, &f, req.Secret); err != nil {
+		return oapi.TaxiiFeed{}, err
+	}
+
+	var feed oapi.TaxiiFeed
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Insert(ctx, orgID, f)
+		if err != nil {
+			return err
+		}
+		feed = toOapi(row)
+		return s.emit(ctx, orgID, feed.Id, EventCreated, feed)
+	})
+	return feed, err
+}
+
+// List returns the org's feeds, by name.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID) ([]oapi.TaxiiFeed, error) {
+	var feeds []oapi.TaxiiFeed
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		rows, err := s.repo.List(ctx, orgID)
+		feeds = toOapiList(rows)
+		return err
+	})
+	return feeds, err
+}
+
+// Get returns a single feed. Returns ErrNotFound when it does not exist in
+// the org.
+func (s *Service) Get(ctx context.Context, orgID, feedID uuid.UUID) (oapi.TaxiiFeed, error) {
+	var feed oapi.TaxiiFeed
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, feedID)
+		feed = toOapi(row)
+		return err
+	})
+	return feed, err
+}
+
+// Update changes the settings set in req and bumps the feed's version.
+// Changing the auth type discards the stored credentials, so a secret must
+// come with a change to basic or token auth. Changing the discovery URL
+// forgets the collections of the previous server, and changing the poll
+// interval reschedules the next poll. When ifMatch is non-nil the update only
+// applies if the current version is listed, otherwise ErrPreconditionFailed
+// is returned.
+func (s *Service) Update(
+	ctx context.Context,
+	orgID, feedID uuid.UUID,
+	req oapi.UpdateTaxiiFeedRequest,
+	ifMatch []int64,
+) (oapi.TaxiiFeed, error) {
+	var feed oapi.TaxiiFeed
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, feedID)
+		if err != nil {
+			return err
+		}
+		if ifMatch != nil && !slices.Contains(ifMatch, row.Version) {
+			return ErrPreconditionFailed
+		}
+
+		f := settingsOf(row)
+		if req.Name != nil {
+			f.name = *req.Name
+		}
+		if req.DiscoveryUrl != nil {
+			f.discoveryURL = *req.DiscoveryUrl
+		}
+		if req.AuthType != nil && string(*req.AuthType) != f.authType {
+			f.authType = string(*req.AuthType)
+			f.username, f.secret = nil, nil
+		}
+		if req.Username != nil {
+			f.username = req.Username
+		}
+		if req.PollIntervalMinutes != nil {
+			f.pollInterval = *req.PollIntervalMinutes
+		}
+		if req.Enabled != nil {
+			f.enabled = *req.Enabled
+		}
+		if req.Confidence != nil {
+			f.confidence = req.Confidence
+		}
+		if req.Tlp != nil {
+			f.tlp = req.Tlp
+		}
+		if req.Tags != nil {
+			f.tags = *req.Tags
+		}
+		if err := s.check(orgID, &f, req.Secret); err != nil {
+			return err
+		}
+
+		nextPoll := row.NextPollAt
+		if f.pollInterval != int(row.PollIntervalMinutes) {
+			nextPoll = time.Now()
+			if row.LastPolledAt != nil {
+				nextPoll = row.LastPolledAt.Add(time.Duration(f.pollInterval) * time.Minute)
+			}
+		}
+		if f.discoveryURL != row.DiscoveryURL {
+			if err := s.repo.DeleteCollections(ctx, orgID, feedID); err != nil {
+				return err
+			}
+		}
+		if row, err = s.repo.Update(ctx, row, f, nextPoll); err != nil {
+			return err
+		}
+		feed = toOapi(row)
+		return s.emit(ctx, orgID, feedID, EventUpdated, feed)
+	})
+	return feed, err
+}
+
+// Delete removes a feed and its collections. Indicators imported from it are
+// kept. Returns ErrNotFound when it does not exist in the org.
+func (s *Service) Delete(ctx context.Context, orgID, feedID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, feedID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, orgID, feedID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, feedID, EventDeleted, DeletedPayload{
+			ID:    feedID,
+			OrgID: orgID,
+			Name:  row.Name,
+		})
+	})
+}
+
+// Collections returns the collections discovered on a feed, with their sync
+// state. Returns ErrNotFound when the feed does not exist in the org.
+func (s *Service) Collections(ctx context.Context, orgID, feedID uuid.UUID) ([]oapi.TaxiiCollection, error) {
+	var cols []oapi.TaxiiCollection
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, feedID); err != nil {
+			return err
+		}
+		rows, err := s.repo.ListCollections(ctx, orgID, feedID)
+		cols = toCollectionList(rows)
+		return err
+	})
+	return cols, err
+}
+
+// UpdateCollection enables or disables syncing a collection, or with Resync
+// forgets its sync state so the next sync reads it from the start. Returns
+// ErrNotFound when the feed does not exist in the org, and
+// ErrCollectionNotFound when the collection is not one of its.
+func (s *Service) UpdateCollection(
+	ctx context.Context,
+	orgID, feedID, collectionID uuid.UUID,
+	req oapi.UpdateTaxiiCollectionRequest,
+) (oapi.TaxiiCollection, error) {
+	var col oapi.TaxiiCollection
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		if _, err := s.repo.Get(ctx, orgID, feedID); err != nil {
+			return err
+		}
+		resync := req.Resync != nil && *req.Resync
+		row, err := s.repo.UpdateCollection(ctx, orgID, feedID, collectionID, req.Enabled, resync)
+		if err != nil {
+			return err
+		}
+		col = toCollection(row)
+		return s.emit(ctx, orgID, feedID, EventCollectionUpdated, col)
+	})
+	return col, err
+}
+
+// Discover reads the feed's API roots and their collections from its server
+// now, and records them. Returns ErrNotFound when the feed does not exist in
+// the org, and an error wrapping ErrUnreachable when the server cannot be
+// discovered.
+func (s *Service) Discover(ctx context.Context, orgID, feedID uuid.UUID) ([]oapi.TaxiiCollection, error) {
+	var row model.TaxiiFeeds
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		row, err = s.repo.Get(ctx, orgID, feedID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	rows, err := s.discover(ctx, row)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrUnreachable, err)
+	}
+	return toCollectionList(rows), nil
+}
+
+// Sync enqueues a sync of the feed now. Returns ErrNotFound when it does not
+// exist in the org, and ErrDisabled when it is disabled.
+func (s *Service) Sync(ctx context.Context, orgID, feedID uuid.UUID) (oapi.TaxiiFeed, error) {
+	var feed oapi.TaxiiFeed
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		row, err := s.repo.Get(ctx, orgID, feedID)
+		if err != nil {
+			return err
+		}
+		if !row.Enabled {
+			return ErrDisabled
+		}
+		feed = toOapi(row)
+		return enqueueSync(ctx, s.jobs, orgID, feedID, "now", time.Now())
+	})
+	return feed, err
+}
@@ -0,0 +1,300 @@
+package taxii_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/secret"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/taxii"
+	"github.com/luketeo/horizon/internal/threatintel"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func authPtr(a oapi.TaxiiAuthType) *oapi.TaxiiAuthType { return &a }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+// newSeededService builds a Service whose secrets are sealed with box, which
+// may be nil, along with the indicator service it imports into.
+func newSeededService(
+	t *testing.T,
+	box *secret.Box,
+) (*taxii.Service, *threatintel.Service, *sql.DB, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_taxii_seed", "TAXII Test Org")
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
+	intel := threatintel.NewService(threatintel.NewRepo(db), txm, events, discard())
+	svc := taxii.NewService(taxii.NewRepo(db), intel, box, http.DefaultClient, jobs.NewRepo(db), txm, events, discard())
+	return svc, intel, db, orgID
+}
+
+func newBox(t *testing.T) *secret.Box {
+	t.Helper()
+	box, err := secret.NewBox(bytes.Repeat([]byte{7}, secret.KeySize))
+	if err != nil {
+		t.Fatalf("NewBox: %v", err)
+	}
+	return box
+}
+
+func TestCreateFeed_ValidatesAndSealsSecret(t *testing.T) {
+	box := newBox(t)
+	svc, _, db, orgID := newSeededService(t, box)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	for name, req := range map[string]oapi.CreateTaxiiFeedRequest{
+		"bad url":         {Name: "a", DiscoveryUrl: "ftp://taxii.example/"},
+		"url credentials": {Name: "a", DiscoveryUrl: "https://u:p@taxii.example/"},
+		"basic no user":   {Name: "a", DiscoveryUrl: "https://taxii.example/", AuthType: authPtr(oapi.Basic)},
+		"token no secret": {Name: "a", DiscoveryUrl: "https://taxii.example/", AuthType: authPtr(oapi.Token)},
+		"none with secret": {
+			Name: "a", DiscoveryUrl: "https://taxii.example/", Secret: strPtr("s3cret"),
+		},
+	} {
+		if _, err := svc.Create(ctx, orgID, req); !errors.Is(err, taxii.ErrInvalid) {
+			t.Errorf("%s: err = %v, want ErrInvalid", name, err)
+		}
+	}
+
+	feed, err := svc.Create(ctx, orgID, oapi.CreateTaxiiFeedRequest{
+		Name:         "Partner feed",
+		DiscoveryUrl: "https://taxii.example/taxii2/",
+		AuthType:     authPtr(oapi.Token),
+		Secret:       strPtr("s3cret"),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if !feed.HasSecret || feed.PollIntervalMinutes != 60 || !feed.Enabled {
+		t.Fatalf("feed = %+v", feed)
+	}
+	var row model.TaxiiFeeds
+	err = tx.NewManager(db).RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		row, err = taxii.NewRepo(db).Get(ctx, orgID, feed.Id)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("Get row: %v", err)
+	}
+	if row.Secret == nil || bytes.Contains(*row.Secret, []byte("s3cret")) {
+		t.Fatalf("stored secret is not sealed: %q", row.Secret)
+	}
+	if plain, err := box.Open(orgID, *row.Secret); err != nil || string(plain) != "s3cret" {
+		t.Fatalf("Open = %q, %v", plain, err)
+	}
+
+	_, err = svc.Create(ctx, orgID, oapi.CreateTaxiiFeedRequest{
+		Name: "Partner feed", DiscoveryUrl: "https://other.example/taxii2/",
+	})
+	if !errors.Is(err, taxii.ErrConflict) {
+		t.Fatalf("duplicate name err = %v, want ErrConflict", err)
+	}
+}
+
+func TestCreateFeed_SecretWithoutKey(t *testing.T) {
+	svc, _, _, orgID := newSeededService(t, nil)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	_, err := svc.Create(ctx, orgID, oapi.CreateTaxiiFeedRequest{
+		Name:         "Partner feed",
+		DiscoveryUrl: "https://taxii.example/taxii2/",
+		AuthType:     authPtr(oapi.Token),
+		Secret:       strPtr("s3cret"),
+	})
+	if !errors.Is(err, taxii.ErrNoKey) {
+		t.Fatalf("err = %v, want ErrNoKey", err)
+	}
+}
+
+func TestUpdateFeed_AuthChangeDiscardsSecret(t *testing.T) {
+	svc, _, _, orgID := newSeededService(t, newBox(t))
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	feed, err := svc.Create(ctx, orgID, oapi.CreateTaxiiFeedRequest{
+		Name:         "Partner feed",
+		DiscoveryUrl: "https://taxii.example/taxii2/",
+		AuthType:     authPtr(oapi.Token),
+		Secret:       strPtr("s3cret"),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	_, err = svc.Update(ctx, orgID, feed.Id, oapi.UpdateTaxiiFeedRequest{
+		AuthType: authPtr(oapi.Basic),
+		Username: strPtr("alice"),
+	}, nil)
+	if !errors.Is(err, taxii.ErrInvalid) {
+		t.Fatalf("basic without secret err = %v, want ErrInvalid", err)
+	}
+
+	_, err = svc.Update(ctx, orgID, feed.Id, oapi.UpdateTaxiiFeedRequest{
+		Name: strPtr("Renamed"),
+	}, []int64{feed.Version + 1})
+	if !errors.Is(err, taxii.ErrPreconditionFailed) {
+		t.Fatalf("stale If-Match err = %v, want ErrPreconditionFailed", err)
+	}
+
+	updated, err := svc.Update(ctx, orgID, feed.Id, oapi.UpdateTaxiiFeedRequest{
+		AuthType: authPtr(oapi.None),
+	}, []int64{feed.Version})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.HasSecret || updated.AuthType != oapi.None || updated.Version != feed.Version+1 {
+		t.Fatalf("updated = %+v", updated)
+	}
+}
+
+func TestRun_ImportsIndicatorsAndCheckpoints(t *testing.T) {
+	svc, intel, _, orgID := newSeededService(t, newBox(t))
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	srv := newFakeServer(t, "Bearer s3cret")
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, ip := range []string{"203.0.113.1", "203.0.113.2", "203.0.113.3"} {
+		srv.add(ip, base.Add(time.Duration(i)*time.Second))
+	}
+
+	feed, err := svc.Create(ctx, orgID, oapi.CreateTaxiiFeedRequest{
+		Name:         "Partner feed",
+		DiscoveryUrl: srv.URL + "/taxii2/",
+		AuthType:     authPtr(oapi.Token),
+		Secret:       strPtr("s3cret"),
+		Tags:         &[]string{"partner"},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	args := taxii.SyncArgs{OrgID: orgID, FeedID: feed.Id}
+	if err := svc.Run(context.Background(), args); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	inds, err := intel.List(ctx, orgID, threatintel.Filter{Limit: 100})
+	if err != nil {
+		t.Fatalf("List indicators: %v", err)
+	}
+	if len(inds) != 3 {
+		t.Fatalf("indicators = %d, want 3", len(inds))
+	}
+	for _, ind := range inds {
+		if ind.Source != "Partner feed" || len(ind.Tags) != 1 || ind.Tags[0] != "partner" {
+			t.Fatalf("indicator = %+v", ind)
+		}
+	}
+
+	cols, err := svc.Collections(ctx, orgID, feed.Id)
+	if err != nil {
+		t.Fatalf("Collections: %v", err)
+	}
+	byID := map[string]oapi.TaxiiCollection{}
+	for _, c := range cols {
+		byID[c.CollectionId] = c
+	}
+	synced := byID["indicators"]
+	if synced.AddedAfter == nil || !synced.AddedAfter.Equal(base.Add(2*time.Second)) {
+		t.Fatalf("added_after = %v", synced.AddedAfter)
+	}
+	if synced.IndicatorsCreated != 3 || synced.LastSyncedAt == nil || synced.Error != nil {
+		t.Fatalf("synced collection = %+v", synced)
+	}
+	if private := byID["private"]; private.Enabled || private.CanRead {
+		t.Fatalf("unreadable collection = %+v", private)
+	}
+
+	// The next sync asks only for what was added since.
+	srv.add("203.0.113.4", base.Add(time.Minute))
+	if err := svc.Run(context.Background(), args); err != nil {
+		t.Fatalf("second Run: %v", err)
+	}
+	if got := srv.queries[len(srv.queries)-1]["added_after"]; got != base.Add(2*time.Second).Format(time.RFC3339Nano) {
+		t.Fatalf("second sync added_after = %q", got)
+	}
+	if inds, _ := intel.List(ctx, orgID, threatintel.Filter{Limit: 100}); len(inds) != 4 {
+		t.Fatalf("indicators after second sync = %d, want 4", len(inds))
+	}
+}
+
+func TestRun_RecordsRejectedCredentials(t *testing.T) {
+	svc, _, _, orgID := newSeededService(t, newBox(t))
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	srv := newFakeServer(t, "Bearer s3cret")
+
+	feed, err := svc.Create(ctx, orgID, oapi.CreateTaxiiFeedRequest{
+		Name:         "Partner feed",
+		DiscoveryUrl: srv.URL + "/taxii2/",
+		AuthType:     authPtr(oapi.Token),
+		Secret:       strPtr("wrong"),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	// Not worth retrying: the run succeeds with the failure recorded.
+	if err := svc.Run(context.Background(), taxii.SyncArgs{OrgID: orgID, FeedID: feed.Id}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	got, err := svc.Get(ctx, orgID, feed.Id)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Error == nil || got.LastPolledAt == nil {
+		t.Fatalf("feed = %+v, want the failure recorded", got)
+	}
+}
@@ -0,0 +1,316 @@
+package taxii
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/secret"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+const (
+	// maxPages bounds the pages of a collection one sync reads, well inside
+	// the job worker's lease; the next sync carries on from the last.
+	maxPages = 50
+	// dueBatch bounds the feeds one scheduler run enqueues syncs of.
+	dueBatch = 100
+	// syncAttempts is the attempt budget of a sync; a feed that keeps failing
+	// is tried again at its next poll.
+	syncAttempts = 3
+)
+
+// ScheduleArgs is the recurring job that enqueues a sync of every feed due a
+// poll.
+type ScheduleArgs struct{}
+
+// Kind implements jobs.Args.
+func (ScheduleArgs) Kind() string { return "taxii.schedule" }
+
+// SyncArgs is the job that polls a feed: discovering its collections and
+// importing the indicators added to the enabled ones.
+type SyncArgs struct {
+	OrgID  uuid.UUID `json:"org_id"`
+	FeedID uuid.UUID `json:"feed_id"`
+}
+
+// Kind implements jobs.Args.
+func (SyncArgs) Kind() string { return "taxii.sync" }
+
+// enqueueSync enqueues a sync of a feed, at most one per feed, reason and
+// minute.
+func enqueueSync(ctx context.Context, jobsRepo *jobs.Repo, orgID, feedID uuid.UUID, reason string, at time.Time) error {
+	key := fmt.Sprintf("%s:%s:%s", feedID, reason, at.UTC().Truncate(time.Minute).Format(time.RFC3339))
+	opts := jobs.EnqueueOpts{UniqueKey: key, MaxAttempts: syncAttempts}
+	_, err := jobsRepo.Enqueue(ctx, SyncArgs{OrgID: orgID, FeedID: feedID}, opts)
+	if errors.Is(err, jobs.ErrDuplicate) {
+		return nil
+	}
+	return err
+}
+
+// EnqueueDue enqueues a sync of every enabled feed, of any org, due a poll at
+// now, moving each feed's next poll an interval on in the same transaction.
+// repo, jobsRepo and txm are on the privileged pool. Returns the number of
+// syncs enqueued.
+func EnqueueDue(ctx context.Context, repo *Repo, jobsRepo *jobs.Repo, txm *tx.Manager, now time.Time) (int, error) {
+	total := 0
+	for {
+		var n int
+		err := txm.RunInTx(ctx, nil, func(ctx context.Context) error {
+			feeds, err := repo.Due(ctx, now, dueBatch)
+			if err != nil {
+				return err
+			}
+			for _, f := range feeds {
+				if err := enqueueSync(ctx, jobsRepo, f.OrgID, f.ID, "poll", now); err != nil {
+					return err
+				}
+			}
+			n = len(feeds)
+			return nil
+		})
+		total += n
+		if err != nil || n < dueBatch {
+			return total, err
+		}
+	}
+}
+
+// Run is the SyncArgs job handler. It discovers the feed's collections, then
+// syncs each enabled collection it can read in turn. A failure no retry can
+// fix, such as rejected credentials or a response that is not TAXII, is
+// recorded on the feed or collection and ends the run; any other is recorded
+// too and fails the run, which the job worker retries.
+func (s *Service) Run(ctx context.Context, args SyncArgs) error {
+	ctx = tenant.WithOrg(ctx, args.OrgID)
+	var feed model.TaxiiFeeds
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		feed, err = s.repo.Get(ctx, args.OrgID, args.FeedID)
+		return err
+	})
+	if errors.Is(err, ErrNotFound) {
+		// Deleted since the sync was enqueued.
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !feed.Enabled {
+		return nil
+	}
+
+	cols, err := s.discover(ctx, feed)
+	if err != nil {
+		return retryable(err)
+	}
+	client, err := s.client(feed)
+	if err != nil {
+		return retryable(err)
+	}
+	var errs []error
+	for _, col := range cols {
+		if !col.Enabled || !col.CanRead {
+			continue
+		}
+		if err := s.syncCollection(ctx, feed, client, col); err != nil {
+			s.logger.WarnContext(ctx, "TAXII collection sync failed",
+				slog.String("feed_id", feed.ID.String()),
+				slog.String("collection_id", col.CollectionID),
+				slog.Any("err", err))
+			errs = append(errs, retryable(err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// client returns a client authenticating as the feed says, opening its
+// secret.
+func (s *Service) client(feed model.TaxiiFeeds) (*Client, error) {
+	auth := Auth{Type: feed.AuthType}
+	if feed.Username != nil {
+		auth.Username = *feed.Username
+	}
+	if feed.Secret != nil {
+		if s.box == nil {
+			return nil, ErrNoKey
+		}
+		plain, err := s.box.Open(feed.OrgID, *feed.Secret)
+		if err != nil {
+			return nil, fmt.Errorf("opening feed secret: %w", err)
+		}
+		auth.Secret = string(plain)
+	}
+	return NewClient(s.http, auth), nil
+}
+
+// discover reads the feed's API roots and their collections from its server
+// and records them, along with the outcome on the feed. Returns every
+// collection of the feed.
+func (s *Service) discover(ctx context.Context, feed model.TaxiiFeeds) ([]model.TaxiiCollections, error) {
+	found, err := s.fetchCollections(ctx, feed)
+	if err != nil {
+		msg := err.Error()
+		if rerr := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+			return s.repo.Polled(ctx, feed.OrgID, feed.ID, &msg)
+		}); rerr != nil {
+			return nil, errors.Join(err, rerr)
+		}
+		return nil, err
+	}
+
+	var cols []model.TaxiiCollections
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if cols, err = s.repo.SaveCollections(ctx, feed.OrgID, feed.ID, found); err != nil {
+			return err
+		}
+		return s.repo.Polled(ctx, feed.OrgID, feed.ID, nil)
+	})
+	return cols, err
+}
+
+// fetchCollections reads the collections of each of the feed's API roots.
+func (s *Service) fetchCollections(ctx context.Context, feed model.TaxiiFeeds) (map[string][]Collection, error) {
+	client, err := s.client(feed)
+	if err != nil {
+		return nil, err
+	}
+	d, err := client.Discover(ctx, feed.DiscoveryURL)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string][]Collection, len(d.APIRoots))
+	for _, root := range d.APIRoots {
+		cols, err := client.Collections(ctx, root)
+		if err != nil {
+			return nil, err
+		}
+		found[root] = cols
+	}
+	return found, nil
+}
+
+// syncCollection imports the indicators the server added to a collection
+// since its added_after, a page at a time, for up to maxPages. Each page is
+// imported in the transaction that checkpoints it. A failure is recorded on
+// the collection.
+func (s *Service) syncCollection(
+	ctx context.Context,
+	feed model.TaxiiFeeds,
+	client *Client,
+	col model.TaxiiCollections,
+) error {
+	err := s.readPages(ctx, feed, client, col)
+	if err != nil {
+		msg := err.Error()
+		if rerr := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+			return s.repo.CollectionFailed(ctx, col.OrgID, col.ID, msg)
+		}); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+	}
+	return err
+}
+
+func (s *Service) readPages(
+	ctx context.Context,
+	feed model.TaxiiFeeds,
+	client *Client,
+	col model.TaxiiCollections,
+) error {
+	var after time.Time
+	if col.AddedAfter != nil {
+		after = *col.AddedAfter
+	}
+	next := ""
+	for range maxPages {
+		page, err := client.Objects(ctx, col.APIRoot, col.CollectionID, after, next)
+		if err != nil {
+			return err
+		}
+		err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+			var res oapi.ThreatIndicatorImportResult
+			if len(page.Objects) > 0 {
+				req, err := importRequest(feed, page.Objects)
+				if err != nil {
+					return err
+				}
+				if res, err = s.intel.Import(ctx, feed.OrgID, req); err != nil {
+					return err
+				}
+			}
+			return s.repo.Checkpoint(ctx, col.OrgID, col.ID, page.DateAddedLast, res.Created, res.Updated)
+		})
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case !page.More:
+			return nil
+		case page.Next != "":
+			// Carry on with the same added_after.
+			next = page.Next
+		case page.DateAddedLast.After(after):
+			after, next = page.DateAddedLast, ""
+		default:
+			return fmt.Errorf("%w: more objects were reported without a way to read them", ErrResponse)
+		}
+	}
+	return nil
+}
+
+// importRequest wraps a page of objects in a STIX bundle, imported with the
+// feed's name as the source of indicators whose creator it does not include.
+func importRequest(feed model.TaxiiFeeds, objects []json.RawMessage) (oapi.ImportThreatIndicatorsRequest, error) {
+	bundle, err := json.Marshal(struct {
+		Type    string            `json:"type"`
+		ID      string            `json:"id"`
+		Objects []json.RawMessage `json:"objects"`
+	}{"bundle", "bundle--" + uuid.NewString(), objects})
+	if err != nil {
+		return oapi.ImportThreatIndicatorsRequest{}, fmt.Errorf("%w: encoding bundle: %w", ErrResponse, err)
+	}
+	f := settingsOf(feed)
+	req := oapi.ImportThreatIndicatorsRequest{
+		Format:     oapi.Stix,
+		Content:    string(bundle),
+		Source:     &feed.Name,
+		Confidence: f.confidence,
+		Tlp:        f.tlp,
+	}
+	if len(f.tags) > 0 {
+		req.Tags = &f.tags
+	}
+	return req, nil
+}
+
+// retryable returns err when a retry of the sync may fix it, and nil for
+// failures that only a change to the feed or its server can, which are
+// already recorded.
+func retryable(err error) error {
+	for _, target := range []error{
+		ErrUnauthorized,
+		ErrForbiddenAddress,
+		ErrResponse,
+		ErrNoKey,
+		secret.ErrCorrupt,
+		threatintel.ErrInvalid,
+	} {
+		if errors.Is(err, target) {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,116 @@
+package threatintel
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// ── CSV ──────────────────────────────────────────────────────────────────────
+
+// csvColumns are the columns a CSV import reads, by their header.
+var csvColumns = []string{"type", "value", "source", "confidence", "tlp", "tags", "description", "expires_at"}
+
+// parseCSV reads a CSV file whose header row names its columns: value, and
+// optionally type, source, confidence, tlp, tags separated by semicolons,
+// description and expires_at in RFC 3339. Other columns are ignored. An
+// empty type is inferred from the value.
+func parseCSV(content string) (Parsed, error) {
+	var p Parsed
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(content, "\ufeff")))
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	header, err := r.Read()
+	if errors.Is(err, io.EOF) {
+		return p, fmt.Errorf("%w: empty CSV file", ErrInvalid)
+	}
+	if err != nil {
+		return p, fmt.Errorf("%w: reading CSV header: %w", ErrInvalid, err)
+	}
+	cols := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for _, c := range csvColumns {
+			if name == c {
+				if _, dup := cols[name]; dup {
+					return p, fmt.Errorf("%w: CSV column %s given twice", ErrInvalid, name)
+				}
+				cols[name] = i
+			}
+		}
+	}
+	if _, ok := cols["value"]; !ok {
+		return p, fmt.Errorf("%w: CSV header has no value column", ErrInvalid)
+	}
+
+	for {
+		record, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return p, nil
+		}
+		if err != nil {
+			return p, fmt.Errorf("%w: reading CSV: %w", ErrInvalid, err)
+		}
+		line, _ := r.FieldPos(0)
+		ref := "line " + strconv.Itoa(line)
+		field := func(name string) string {
+			if i, ok := cols[name]; ok && i < len(record) {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		d, err := csvDraft(field)
+		if err != nil {
+			p.fail(ref, err)
+			continue
+		}
+		p.add(ref, d)
+	}
+}
+
+// csvDraft reads an indicator from the fields of a CSV record.
+func csvDraft(field func(name string) string) (Draft, error) {
+	d := Draft{
+		Type:   oapi.ThreatIndicatorType(strings.ToLower(field("type"))),
+		Value:  field("value"),
+		Source: field("source"),
+	}
+	if d.Value == "" {
+		return d, fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+	if s := field("confidence"); s != "" {
+		c, err := strconv.Atoi(s)
+		if err != nil {
+			return d, fmt.Errorf("%w: confidence %q is not a number", ErrInvalid, s)
+		}
+		d.Confidence = &c
+	}
+	if s := field("tlp"); s != "" {
+		tlp, ok := parseTLP(s)
+		if !ok {
+			return d, fmt.Errorf("%w: unknown TLP level %q", ErrInvalid, s)
+		}
+		d.TLP = tlp
+	}
+	if s := field("tags"); s != "" {
+		d.Tags = strings.Split(s, ";")
+	}
+	if s := field("description"); s != "" {
+		d.Description = &s
+	}
+	if s := field("expires_at"); s != "" {
+		at, err := time.Parse(time.RFC3339, s)
+		if err != nil {
+			return d, fmt.Errorf("%w: expires_at %q is not an RFC 3339 time", ErrInvalid, s)
+		}
+		d.ExpiresAt = &at
+	}
+	return d, nil
+}
@@ -0,0 +1,55 @@
+package threatintel
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+)
+
+// Domain events appended to the outbox, one aggregate per indicator. An
+// import is one event, whose aggregate is the org.
+const (
+	aggregateType = "threat_indicator"
+
+	EventCreated  = "threat_indicator.created"
+	EventUpdated  = "threat_indicator.updated"
+	EventDeleted  = "threat_indicator.deleted"
+	EventImported = "threat_indicator.imported"
+)
+
+// DeletedPayload is the payload of EventDeleted.
+type DeletedPayload struct {
+	ID    uuid.UUID                `json:"id"`
+	OrgID uuid.UUID                `json:"org_id"`
+	Type  oapi.ThreatIndicatorType `json:"type"`
+	Value string                   `json:"value"`
+}
+
+// ImportedPayload is the payload of EventImported.
+type ImportedPayload struct {
+	OrgID   uuid.UUID                        `json:"org_id"`
+	Format  oapi.ThreatIndicatorImportFormat `json:"format"`
+	Source  string                           `json:"source"`
+	Created int                              `json:"created"`
+	Updated int                              `json:"updated"`
+}
+
+// emit appends an event about aggregateID to the outbox, in the transaction
+// on ctx.
+func (s *Service) emit(
+	ctx context.Context,
+	orgID, aggregateID uuid.UUID,
+	eventType string,
+	payload any,
+) error {
+	return s.events.Append(ctx, outbox.Draft{
+		OrgID:         &orgID,
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID.String(),
+		Type:          eventType,
+		Payload:       payload,
+	})
+}
@@ -0,0 +1,292 @@
+package threatintel
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/authz"
+	"github.com/luketeo/horizon/internal/platform/httpx"
+	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+// Page sizes of ListThreatIndicators.
+const (
+	defaultListLimit = 100
+	maxListLimit     = 500
+)
+
+// Handler serves the /organizations/{orgId}/threat-indicators/* endpoints.
+// Members may read indicators; admins manage and import them.
+type Handler struct {
+	svc     *Service
+	userSvc *user.Service
+	orgSvc  *org.Service
+}
+
+// NewHandler wires a Handler with the services it needs.
+func NewHandler(svc *Service, userSvc *user.Service, orgSvc *org.Service) *Handler {
+	return &Handler{svc: svc, userSvc: userSvc, orgSvc: orgSvc}
+}
+
+// requireMembership resolves identity and org membership in one step. The
+// returned context carries the caller's tenant scope, narrowed to orgID.
+func (h *Handler) requireMembership(
+	ctx context.Context,
+	orgID uuid.UUID,
+) (context.Context, oapi.OrgRole, bool) {
+	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
+	if !ok {
+		return ctx, "", false
+	}
+	_, userID, err := h.userSvc.GetOrCreateUser(ctx, clerkUser)
+	if err != nil {
+		return ctx, "", false
+	}
+	ctx = tenant.WithUser(ctx, userID)
+	role, err := h.orgSvc.GetMembership(ctx, orgID, userID)
+	if err != nil {
+		return ctx, "", false
+	}
+	return tenant.WithOrg(ctx, orgID), role, true
+}
+
+func notMember() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "You are not a member of this organisation"),
+	)
+}
+
+func notAdmin() oapi.ForbiddenApplicationProblemPlusJSONResponse {
+	return oapi.ForbiddenApplicationProblemPlusJSONResponse(
+		httpx.Prob(403, "Forbidden", "Admin or owner role required"),
+	)
+}
+
+func notFound() oapi.NotFoundApplicationProblemPlusJSONResponse {
+	return oapi.NotFoundApplicationProblemPlusJSONResponse(
+		httpx.Prob(404, "Not Found", "Threat indicator not found"),
+	)
+}
+
+func badRequest(detail string) oapi.BadRequestApplicationProblemPlusJSONResponse {
+	return oapi.BadRequestApplicationProblemPlusJSONResponse(
+		httpx.Prob(400, "Bad Request", detail),
+	)
+}
+
+func conflict(detail string) oapi.ConflictApplicationProblemPlusJSONResponse {
+	return oapi.ConflictApplicationProblemPlusJSONResponse(
+		httpx.Prob(409, "Conflict", detail),
+	)
+}
+
+func (h *Handler) ListThreatIndicators(
+	ctx context.Context,
+	request oapi.ListThreatIndicatorsRequestObject,
+) (oapi.ListThreatIndicatorsResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ListThreatIndicators403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	params := request.Params
+	f := Filter{
+		Type:   params.Type,
+		Tag:    params.Tag,
+		Active: params.Active != nil && *params.Active,
+		After:  params.After,
+		Limit:  defaultListLimit,
+	}
+	if params.Limit != nil {
+		f.Limit = min(max(*params.Limit, 1), maxListLimit)
+	}
+	indicators, err := h.svc.List(ctx, request.OrgId, f)
+	if err != nil {
+		return nil, err
+	}
+	return oapi.ListThreatIndicators200JSONResponse(indicators), nil
+}
+
+func (h *Handler) CreateThreatIndicator(
+	ctx context.Context,
+	request oapi.CreateThreatIndicatorRequestObject,
+) (oapi.CreateThreatIndicatorResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.CreateThreatIndicator403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.CreateThreatIndicator403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	ind, err := h.svc.Create(ctx, request.OrgId, *request.Body)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrInvalid):
+			return oapi.CreateThreatIndicator400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrConflict):
+			return oapi.CreateThreatIndicator409ApplicationProblemPlusJSONResponse{
+				ConflictApplicationProblemPlusJSONResponse: conflict("The organisation already has this indicator"),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.CreateThreatIndicator201JSONResponse(ind), nil
+}
+
+func (h *Handler) ImportThreatIndicators(
+	ctx context.Context,
+	request oapi.ImportThreatIndicatorsRequestObject,
+) (oapi.ImportThreatIndicatorsResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.ImportThreatIndicators403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.ImportThreatIndicators403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	res, err := h.svc.Import(ctx, request.OrgId, *request.Body)
+	if err != nil {
+		if errors.Is(err, ErrInvalid) {
+			return oapi.ImportThreatIndicators400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.ImportThreatIndicators200JSONResponse(res), nil
+}
+
+func (h *Handler) GetThreatIndicator(
+	ctx context.Context,
+	request oapi.GetThreatIndicatorRequestObject,
+) (oapi.GetThreatIndicatorResponseObject, error) {
+	ctx, _, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.GetThreatIndicator403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+
+	ind, err := h.svc.Get(ctx, request.OrgId, request.IndicatorId)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.GetThreatIndicator404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	etag := httpx.ETag(ind.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetThreatIndicator304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetThreatIndicator200JSONResponse{
+		Body:    ind,
+		Headers: oapi.GetThreatIndicator200ResponseHeaders{ETag: etag},
+	}, nil
+}
+
+func (h *Handler) UpdateThreatIndicator(
+	ctx context.Context,
+	request oapi.UpdateThreatIndicatorRequestObject,
+) (oapi.UpdateThreatIndicatorResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.UpdateThreatIndicator403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.UpdateThreatIndicator403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	body := *request.Body
+	if body.ExpiresAt != nil && body.ClearExpiry != nil && *body.ClearExpiry {
+		return oapi.UpdateThreatIndicator400ApplicationProblemPlusJSONResponse{
+			BadRequestApplicationProblemPlusJSONResponse: badRequest("expires_at and clear_expiry are exclusive"),
+		}, nil
+	}
+
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return indicatorPreconditionFailed(), nil
+	}
+
+	ind, err := h.svc.Update(ctx, request.OrgId, request.IndicatorId, body, ifMatch)
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrNotFound):
+			return oapi.UpdateThreatIndicator404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		case errors.Is(err, ErrInvalid):
+			return oapi.UpdateThreatIndicator400ApplicationProblemPlusJSONResponse{
+				BadRequestApplicationProblemPlusJSONResponse: badRequest(err.Error()),
+			}, nil
+		case errors.Is(err, ErrPreconditionFailed):
+			return indicatorPreconditionFailed(), nil
+		}
+		return nil, err
+	}
+	return oapi.UpdateThreatIndicator200JSONResponse{
+		Body:    ind,
+		Headers: oapi.UpdateThreatIndicator200ResponseHeaders{ETag: httpx.ETag(ind.Version)},
+	}, nil
+}
+
+func indicatorPreconditionFailed() oapi.UpdateThreatIndicator412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateThreatIndicator412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Threat indicator was modified since it was last read"),
+		),
+	}
+}
+
+func (h *Handler) DeleteThreatIndicator(
+	ctx context.Context,
+	request oapi.DeleteThreatIndicatorRequestObject,
+) (oapi.DeleteThreatIndicatorResponseObject, error) {
+	ctx, role, ok := h.requireMembership(ctx, request.OrgId)
+	if !ok {
+		return oapi.DeleteThreatIndicator403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notMember(),
+		}, nil
+	}
+	if !authz.HasRole(role, oapi.Admin) {
+		return oapi.DeleteThreatIndicator403ApplicationProblemPlusJSONResponse{
+			ForbiddenApplicationProblemPlusJSONResponse: notAdmin(),
+		}, nil
+	}
+
+	if err := h.svc.Delete(ctx, request.OrgId, request.IndicatorId); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return oapi.DeleteThreatIndicator404ApplicationProblemPlusJSONResponse{
+				NotFoundApplicationProblemPlusJSONResponse: notFound(),
+			}, nil
+		}
+		return nil, err
+	}
+	return oapi.DeleteThreatIndicator204Response{}, nil
+}
@@ -0,0 +1,50 @@
+package threatintel
+
+import (
+	"fmt"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// Entry is an indicator read from an import, with where it is in the file.
+type Entry struct {
+	Ref   string
+	Draft Draft
+}
+
+// EntryError is an entry of an import that could not be read.
+type EntryError struct {
+	Ref string
+	Err error
+}
+
+// Parsed is what was read from an import.
+type Parsed struct {
+	Entries []Entry
+	// Skipped counts the entries that are not indicators of a supported type.
+	Skipped int
+	Errors  []EntryError
+}
+
+func (p *Parsed) add(ref string, d Draft) {
+	p.Entries = append(p.Entries, Entry{Ref: ref, Draft: d})
+}
+
+func (p *Parsed) fail(ref string, err error) {
+	p.Errors = append(p.Errors, EntryError{Ref: ref, Err: err})
+}
+
+// Parse reads the indicators of an import in format. The drafts read are not
+// yet normalized. Returns an error wrapping ErrInvalid when the content
+// cannot be read at all.
+func Parse(format oapi.ThreatIndicatorImportFormat, content string) (Parsed, error) {
+	switch format {
+	case oapi.Csv:
+		return parseCSV(content)
+	case oapi.Stix:
+		return parseSTIX(content)
+	case oapi.Misp:
+		return parseMISP(content)
+	}
+	return Parsed{}, fmt.Errorf("%w: unknown import format %q", ErrInvalid, format)
+}
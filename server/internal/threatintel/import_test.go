@@ -0,0 +1,258 @@
+package threatintel_test
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+// drafts lists the type and value of the entries read, as "type:value".
+func drafts(p threatintel.Parsed) []string {
+	out := make([]string, 0, len(p.Entries))
+	for _, e := range p.Entries {
+		out = append(out, string(e.Draft.Type)+":"+e.Draft.Value)
+	}
+	return out
+}
+
+func TestParseCSV(t *testing.T) {
+	content := "\ufeffValue,Type,Confidence,TLP,Tags,Expires_At,Notes\n" +
+		"203.0.113.7,ip,90,TLP:RED,c2;botnet,2030-01-02T03:04:05Z,ignored\n" +
+		"evil.example,,,,,,\n" +
+		"198.51.100.0/24,cidr,high,,,,\n" +
+		",ip,,,,,\n" +
+		"phish@evil.example,email,,white,,soon,\n"
+
+	p, err := threatintel.Parse(oapi.Csv, content)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, want := drafts(p), []string{"ip:203.0.113.7", ":evil.example"}; !slices.Equal(got, want) {
+		t.Fatalf("entries: got %v, want %v", got, want)
+	}
+	first := p.Entries[0]
+	if first.Ref != "line 2" || *first.Draft.Confidence != 90 || first.Draft.TLP != oapi.Red ||
+		!slices.Equal(first.Draft.Tags, []string{"c2", "botnet"}) ||
+		!first.Draft.ExpiresAt.Equal(time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("first entry: got %+v", first)
+	}
+	var refs []string
+	for _, e := range p.Errors {
+		if !errors.Is(e.Err, threatintel.ErrInvalid) {
+			t.Errorf("%s: got %v, want ErrInvalid", e.Ref, e.Err)
+		}
+		refs = append(refs, e.Ref)
+	}
+	if want := []string{"line 4", "line 5", "line 6"}; !slices.Equal(refs, want) {
+		t.Errorf("errors: got %v, want %v", refs, want)
+	}
+}
+
+func TestParseCSV_RejectsFileWithoutValues(t *testing.T) {
+	for _, content := range []string{"", "type,indicator\nip,203.0.113.7\n", "value,\"unterminated\n"} {
+		if _, err := threatintel.Parse(oapi.Csv, content); !errors.Is(err, threatintel.ErrInvalid) {
+			t.Errorf("Parse(%q): got %v, want ErrInvalid", content, err)
+		}
+	}
+}
+
+const stixBundle = `{
+  "type": "bundle",
+  "id": "bundle--5d0092c5-5f74-4287-9642-33f4c354e56d",
+  "objects": [
+    {
+      "type": "identity",
+      "spec_version": "2.1",
+      "id": "identity--f431f809-377b-45e0-aa1c-6a4751cae5ff",
+      "name": "ACME CERT",
+      "identity_class": "organization"
+    },
+    {
+      "type": "marking-definition",
+      "spec_version": "2.1",
+      "id": "marking-definition--a7e3a9d8-7f85-4f0a-9b2e-1d7c58e2a7a1",
+      "name": "TLP:AMBER+STRICT",
+      "extensions": {"extension-definition--60a3c5c5-0d10-413e-aab3-9e08dde9e88d": {"extension_type": "property-extension", "tlp_2_0": "amber+strict"}}
+    },
+    {
+      "type": "indicator",
+      "spec_version": "2.1",
+      "id": "indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f",
+      "created_by_ref": "identity--f431f809-377b-45e0-aa1c-6a4751cae5ff",
+      "name": "Emotet C2",
+      "pattern": "[ipv4-addr:value = '203.0.113.7'] OR [ipv4-addr:value = '198.51.100.0/24'] OR [domain-name:value = 'evil.example']",
+      "pattern_type": "stix",
+      "valid_from": "2024-01-01T00:00:00Z",
+      "valid_until": "2030-01-01T00:00:00.000Z",
+      "confidence": 85,
+      "labels": ["malicious-activity"],
+      "object_marking_refs": ["marking-definition--34098fce-860f-48ae-8e50-ebd3cc5e41da"]
+    },
+    {
+      "type": "indicator",
+      "spec_version": "2.1",
+      "id": "indicator--1ed8caa7-a708-4706-b651-f1186ede6ca1",
+      "pattern": "[file:hashes.'SHA-256' = 'E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855']",
+      "pattern_type": "stix",
+      "valid_from": "2024-01-01T00:00:00Z",
+      "object_marking_refs": ["marking-definition--a7e3a9d8-7f85-4f0a-9b2e-1d7c58e2a7a1"]
+    },
+    {
+      "type": "indicator",
+      "spec_version": "2.1",
+      "id": "indicator--b8f5c1e4-97a2-4a8a-a4c9-2e33f8f1a3d0",
+      "pattern": "[ipv4-addr:value = '203.0.113.9' AND network-traffic:dst_port = 443]",
+      "pattern_type": "stix",
+      "valid_from": "2024-01-01T00:00:00Z"
+    },
+    {
+      "type": "indicator",
+      "spec_version": "2.1",
+      "id": "indicator--d1f5b7f0-86e3-4a2c-9b0f-3d6f3c7e8a11",
+      "pattern": "alert tcp any any -> any any",
+      "pattern_type": "snort",
+      "valid_from": "2024-01-01T00:00:00Z"
+    },
+    {
+      "type": "indicator",
+      "spec_version": "2.1",
+      "id": "indicator--0f5a3c2d-1b4e-4d6f-8a9b-7c0d1e2f3a4b",
+      "pattern": "[url:value = 'http://evil.example/it\\'s']",
+      "pattern_type": "stix",
+      "valid_from": "2024-01-01T00:00:00Z",
+      "valid_until": "tomorrow"
+    },
+    {
+      "type": "malware",
+      "spec_version": "2.1",
+      "id": "malware--31b940d4-6f7f-459a-80ea-9c1f17b5891b",
+      "name": "Emotet",
+      "is_family": true
+    }
+  ]
+}`
+
+func TestParseSTIX(t *testing.T) {
+	p, err := threatintel.Parse(oapi.Stix, stixBundle)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, want := drafts(p), []string{
+		"ip:203.0.113.7",
+		"cidr:198.51.100.0/24",
+		"domain:evil.example",
+		"file_hash:E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855",
+	}; !slices.Equal(got, want) {
+		t.Fatalf("entries: got %v, want %v", got, want)
+	}
+	first := p.Entries[0].Draft
+	if first.Source != "ACME CERT" || *first.Confidence != 85 || first.TLP != oapi.Green ||
+		*first.ExternalID != "indicator--8e2e2d2b-17d4-4cbf-938f-98ee46b3cd3f" ||
+		*first.Description != "Emotet C2" || !slices.Equal(first.Tags, []string{"malicious-activity"}) ||
+		!first.ExpiresAt.Equal(time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("first entry: got %+v", first)
+	}
+	if hash := p.Entries[3].Draft; hash.TLP != oapi.AmberStrict || hash.Source != "" || hash.Confidence != nil {
+		t.Errorf("hash entry: got %+v", hash)
+	}
+	// The AND and snort patterns, and the malware.
+	if p.Skipped != 3 {
+		t.Errorf("skipped: got %d, want 3", p.Skipped)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Ref != "indicator--0f5a3c2d-1b4e-4d6f-8a9b-7c0d1e2f3a4b" {
+		t.Errorf("errors: got %+v", p.Errors)
+	}
+}
+
+func TestParseSTIX_RejectsOtherDocuments(t *testing.T) {
+	for _, content := range []string{"", "[]", `{"type": "indicator"}`, `{"type": "bundle", "objects": [1]}`} {
+		if _, err := threatintel.Parse(oapi.Stix, content); !errors.Is(err, threatintel.ErrInvalid) {
+			t.Errorf("Parse(%q): got %v, want ErrInvalid", content, err)
+		}
+	}
+}
+
+const mispEvent = `{
+  "Event": {
+    "info": "Phishing campaign",
+    "Orgc": {"name": "CIRCL"},
+    "Tag": [{"name": "tlp:green"}, {"name": "phishing"}],
+    "Attribute": [
+      {"uuid": "5c6a1b2e-0001-4f1a-9a7e-000000000001", "type": "ip-dst", "value": "203.0.113.7", "to_ids": true,
+       "comment": "Landing page host"},
+      {"uuid": "5c6a1b2e-0001-4f1a-9a7e-000000000002", "type": "domain|ip", "value": "evil.example|198.51.100.7",
+       "to_ids": "1", "Tag": [{"name": "tlp:red"}]},
+      {"uuid": "5c6a1b2e-0001-4f1a-9a7e-000000000003", "type": "filename|sha256",
+       "value": "invoice.exe|e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", "to_ids": true},
+      {"uuid": "5c6a1b2e-0001-4f1a-9a7e-000000000004", "type": "url", "value": "https://evil.example/login",
+       "to_ids": false},
+      {"uuid": "5c6a1b2e-0001-4f1a-9a7e-000000000005", "type": "text", "value": "Campaign notes", "to_ids": false},
+      {"uuid": "5c6a1b2e-0001-4f1a-9a7e-000000000006", "type": "ip-src|port", "value": "203.0.113.9", "to_ids": true}
+    ],
+    "Object": [
+      {"name": "email", "Attribute": [
+        {"uuid": "5c6a1b2e-0001-4f1a-9a7e-000000000007", "type": "email-src", "value": "phish@evil.example",
+         "to_ids": true, "Tag": [{"name": "spoofed"}]}
+      ]}
+    ]
+  }
+}`
+
+func TestParseMISP(t *testing.T) {
+	p, err := threatintel.Parse(oapi.Misp, mispEvent)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got, want := drafts(p), []string{
+		"ip:203.0.113.7",
+		"domain:evil.example",
+		"ip:198.51.100.7",
+		"file_hash:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855",
+		"email:phish@evil.example",
+	}; !slices.Equal(got, want) {
+		t.Fatalf("entries: got %v, want %v", got, want)
+	}
+	first := p.Entries[0].Draft
+	if first.Source != "CIRCL" || first.TLP != oapi.Green || *first.Description != "Landing page host" ||
+		*first.ExternalID != "5c6a1b2e-0001-4f1a-9a7e-000000000001" || !slices.Equal(first.Tags, []string{"phishing"}) {
+		t.Errorf("first entry: got %+v", first)
+	}
+	if d := p.Entries[2].Draft; d.TLP != oapi.Red || p.Entries[2].Ref != "5c6a1b2e-0001-4f1a-9a7e-000000000002" {
+		t.Errorf("domain|ip entry: got %+v", p.Entries[2])
+	}
+	if d := p.Entries[4].Draft; !slices.Equal(d.Tags, []string{"phishing", "spoofed"}) {
+		t.Errorf("object attribute tags: got %v", d.Tags)
+	}
+	if p.Skipped != 2 {
+		t.Errorf("skipped: got %d, want 2", p.Skipped)
+	}
+	if len(p.Errors) != 1 || p.Errors[0].Ref != "5c6a1b2e-0001-4f1a-9a7e-000000000006" {
+		t.Errorf("errors: got %+v", p.Errors)
+	}
+}
+
+func TestParseMISP_ReadsEventLists(t *testing.T) {
+	for _, content := range []string{
+		`{"response": [{"Event": {"Attribute": [{"type": "md5", "value": "d41d8cd98f00b204e9800998ecf8427e", "to_ids": true}]}}]}`,
+		`[{"Event": {"Attribute": [{"type": "md5", "value": "d41d8cd98f00b204e9800998ecf8427e", "to_ids": true}]}}]`,
+		`[{"Attribute": [{"type": "md5", "value": "d41d8cd98f00b204e9800998ecf8427e", "to_ids": true}]}]`,
+	} {
+		p, err := threatintel.Parse(oapi.Misp, content)
+		if err != nil {
+			t.Errorf("Parse(%s): %v", content, err)
+			continue
+		}
+		if got := drafts(p); !slices.Equal(got, []string{"file_hash:d41d8cd98f00b204e9800998ecf8427e"}) {
+			t.Errorf("Parse(%s): got %v", content, got)
+		}
+	}
+	if _, err := threatintel.Parse(oapi.Misp, `{"Event": {"Attribute": [{"to_ids": "maybe"}]}}`); !errors.Is(
+		err, threatintel.ErrInvalid,
+	) {
+		t.Errorf("bad to_ids: got %v, want ErrInvalid", err)
+	}
+}
@@ -0,0 +1,222 @@
+package threatintel
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// Defaults of what an indicator does not say.
+const (
+	defaultConfidence = 50
+	defaultTLP        = oapi.Amber
+	// manualSource is the source of indicators added over the API.
+	manualSource = "manual"
+)
+
+// Limits of an indicator's attributes.
+const (
+	maxValueLen       = 2048
+	maxSourceLen      = 255
+	maxTags           = 50
+	maxTagLen         = 100
+	maxDescriptionLen = 1000
+)
+
+// Types lists the indicator types.
+var Types = []oapi.ThreatIndicatorType{
+	oapi.ThreatIndicatorTypeIp,
+	oapi.ThreatIndicatorTypeCidr,
+	oapi.ThreatIndicatorTypeDomain,
+	oapi.ThreatIndicatorTypeUrl,
+	oapi.ThreatIndicatorTypeFileHash,
+	oapi.ThreatIndicatorTypeEmail,
+}
+
+// tlps lists the TLP levels.
+var tlps = []oapi.Tlp{oapi.Clear, oapi.Green, oapi.Amber, oapi.AmberStrict, oapi.Red}
+
+// validType reports whether t is an indicator type.
+func validType(t oapi.ThreatIndicatorType) bool {
+	return slices.Contains(Types, t)
+}
+
+// validTLP reports whether t is a TLP level.
+func validTLP(t oapi.Tlp) bool {
+	return slices.Contains(tlps, t)
+}
+
+// parseTLP reads a TLP level as written in feeds: tlp:green, TLP:AMBER+STRICT
+// or amber. White is TLP 1.0's name for clear.
+func parseTLP(s string) (oapi.Tlp, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.TrimPrefix(s, "tlp:")
+	if s == "white" {
+		return oapi.Clear, true
+	}
+	t := oapi.Tlp(s)
+	return t, validTLP(t)
+}
+
+// Normalize returns value in the form indicators of type t are stored and
+// matched in: canonical addresses and networks, lower-case domains, emails
+// and hashes, and URLs with a lower-case scheme and host and no fragment.
+// Returns an error wrapping ErrInvalid when value is not of type t.
+func Normalize(t oapi.ThreatIndicatorType, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "", fmt.Errorf("%w: empty value", ErrInvalid)
+	}
+	if len(value) > maxValueLen {
+		return "", fmt.Errorf("%w: value longer than %d characters", ErrInvalid, maxValueLen)
+	}
+	var (
+		v  string
+		ok bool
+	)
+	switch t {
+	case oapi.ThreatIndicatorTypeIp:
+		v, ok = normalizeIP(value)
+	case oapi.ThreatIndicatorTypeCidr:
+		v, ok = normalizeCIDR(value)
+	case oapi.ThreatIndicatorTypeDomain:
+		v, ok = normalizeDomain(value)
+	case oapi.ThreatIndicatorTypeUrl:
+		v, ok = normalizeURL(value)
+	case oapi.ThreatIndicatorTypeFileHash:
+		v, ok = normalizeHash(value)
+	case oapi.ThreatIndicatorTypeEmail:
+		v, ok = normalizeEmail(value)
+	default:
+		return "", fmt.Errorf("%w: unknown indicator type %q", ErrInvalid, t)
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %q is not a valid %s", ErrInvalid, value, t)
+	}
+	return v, nil
+}
+
+// Infer returns the type value reads as, for imports that do not say.
+func Infer(value string) oapi.ThreatIndicatorType {
+	value = strings.TrimSpace(value)
+	switch {
+	case strings.Contains(value, "://"):
+		return oapi.ThreatIndicatorTypeUrl
+	case strings.Contains(value, "/"):
+		return oapi.ThreatIndicatorTypeCidr
+	case strings.Contains(value, "@"):
+		return oapi.ThreatIndicatorTypeEmail
+	}
+	if _, ok := normalizeIP(value); ok {
+		return oapi.ThreatIndicatorTypeIp
+	}
+	if _, ok := normalizeHash(value); ok {
+		return oapi.ThreatIndicatorTypeFileHash
+	}
+	return oapi.ThreatIndicatorTypeDomain
+}
+
+func normalizeIP(s string) (string, bool) {
+	addr, err := netip.ParseAddr(s)
+	if err != nil || addr.Zone() != "" {
+		return "", false
+	}
+	return addr.Unmap().String(), true
+}
+
+// normalizeCIDR masks the network, and reads an IPv4-mapped network as IPv4.
+func normalizeCIDR(s string) (string, bool) {
+	p, err := netip.ParsePrefix(s)
+	if err != nil {
+		return "", false
+	}
+	if p.Addr().Is4In6() {
+		if p.Bits() < 96 {
+			return "", false
+		}
+		p = netip.PrefixFrom(p.Addr().Unmap(), p.Bits()-96)
+	}
+	return p.Masked().String(), true
+}
+
+// normalizeDomain lower-cases a domain name and drops a trailing dot or a
+// leading wildcard label, since a domain indicator covers its subdomains.
+func normalizeDomain(s string) (string, bool) {
+	s = strings.TrimPrefix(strings.TrimSuffix(strings.ToLower(s), "."), "*.")
+	if s == "" || len(s) > 253 {
+		return "", false
+	}
+	if _, err := netip.ParseAddr(s); err == nil {
+		return "", false
+	}
+	for label := range strings.SplitSeq(s, ".") {
+		if label == "" || len(label) > 63 {
+			return "", false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return "", false
+			}
+		}
+	}
+	return s, true
+}
+
+func normalizeURL(s string) (string, bool) {
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return "", false
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Fragment, u.RawFragment = "", ""
+	return u.String(), true
+}
+
+// normalizeHash accepts the hex digests of MD5, SHA-1, SHA-256 and SHA-512.
+func normalizeHash(s string) (string, bool) {
+	switch len(s) {
+	case 32, 40, 64, 128:
+	default:
+		return "", false
+	}
+	if _, err := hex.DecodeString(s); err != nil {
+		return "", false
+	}
+	return strings.ToLower(s), true
+}
+
+func normalizeEmail(s string) (string, bool) {
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Address != s {
+		return "", false
+	}
+	local, domain, _ := strings.Cut(strings.ToLower(s), "@")
+	if domain, ok := normalizeDomain(domain); ok && local != "" {
+		return local + "@" + domain, true
+	}
+	return "", false
+}
+
+// active reports whether an indicator is still matched at now.
+func active(ind *oapi.ThreatIndicator, now time.Time) bool {
+	return ind.ExpiresAt == nil || now.Before(*ind.ExpiresAt)
+}
+
+// CleanTags trims tags and drops empty and repeated ones, keeping their order.
+func CleanTags(tags []string) []string {
+	out := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		if tag = strings.TrimSpace(tag); tag != "" && !slices.Contains(out, tag) {
+			out = append(out, tag)
+		}
+	}
+	return out
+}
@@ -0,0 +1,81 @@
+package threatintel_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		typ   oapi.ThreatIndicatorType
+		value string
+		want  string
+	}{
+		{oapi.ThreatIndicatorTypeIp, " 203.0.113.7 ", "203.0.113.7"},
+		{oapi.ThreatIndicatorTypeIp, "::ffff:203.0.113.7", "203.0.113.7"},
+		{oapi.ThreatIndicatorTypeIp, "2001:DB8::1", "2001:db8::1"},
+		{oapi.ThreatIndicatorTypeCidr, "198.51.100.77/24", "198.51.100.0/24"},
+		{oapi.ThreatIndicatorTypeCidr, "::ffff:198.51.100.0/120", "198.51.100.0/24"},
+		{oapi.ThreatIndicatorTypeCidr, "2001:db8:abcd::/32", "2001:db8::/32"},
+		{oapi.ThreatIndicatorTypeDomain, "Evil.Example.COM.", "evil.example.com"},
+		{oapi.ThreatIndicatorTypeDomain, "*.evil.example", "evil.example"},
+		{oapi.ThreatIndicatorTypeUrl, "HTTPS://Evil.Example/Path?q=1#frag", "https://evil.example/Path?q=1"},
+		{oapi.ThreatIndicatorTypeFileHash, "D41D8CD98F00B204E9800998ECF8427E", "d41d8cd98f00b204e9800998ecf8427e"},
+		{oapi.ThreatIndicatorTypeEmail, "Phish@Evil.Example", "phish@evil.example"},
+	}
+	for _, tt := range tests {
+		got, err := threatintel.Normalize(tt.typ, tt.value)
+		if err != nil {
+			t.Errorf("Normalize(%s, %q): %v", tt.typ, tt.value, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Normalize(%s, %q) = %q, want %q", tt.typ, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestNormalize_RejectsValuesNotOfType(t *testing.T) {
+	tests := []struct {
+		typ   oapi.ThreatIndicatorType
+		value string
+	}{
+		{oapi.ThreatIndicatorTypeIp, ""},
+		{oapi.ThreatIndicatorTypeIp, "evil.example"},
+		{oapi.ThreatIndicatorTypeIp, "fe80::1%eth0"},
+		{oapi.ThreatIndicatorTypeCidr, "203.0.113.7"},
+		{oapi.ThreatIndicatorTypeDomain, "203.0.113.7"},
+		{oapi.ThreatIndicatorTypeDomain, "evil..example"},
+		{oapi.ThreatIndicatorTypeDomain, "evil example"},
+		{oapi.ThreatIndicatorTypeUrl, "evil.example/path"},
+		{oapi.ThreatIndicatorTypeFileHash, "d41d8cd98f00b204"},
+		{oapi.ThreatIndicatorTypeFileHash, "z41d8cd98f00b204e9800998ecf8427e"},
+		{oapi.ThreatIndicatorTypeEmail, "Phish <phish@evil.example>"},
+		{"registry_key", "HKLM"},
+	}
+	for _, tt := range tests {
+		if _, err := threatintel.Normalize(tt.typ, tt.value); !errors.Is(err, threatintel.ErrInvalid) {
+			t.Errorf("Normalize(%s, %q): got %v, want ErrInvalid", tt.typ, tt.value, err)
+		}
+	}
+}
+
+func TestInfer(t *testing.T) {
+	tests := map[string]oapi.ThreatIndicatorType{
+		"203.0.113.7":                      oapi.ThreatIndicatorTypeIp,
+		"2001:db8::1":                      oapi.ThreatIndicatorTypeIp,
+		"198.51.100.0/24":                  oapi.ThreatIndicatorTypeCidr,
+		"evil.example":                     oapi.ThreatIndicatorTypeDomain,
+		"http://evil.example/a/b":          oapi.ThreatIndicatorTypeUrl,
+		"phish@evil.example":               oapi.ThreatIndicatorTypeEmail,
+		"d41d8cd98f00b204e9800998ecf8427e": oapi.ThreatIndicatorTypeFileHash,
+	}
+	for value, want := range tests {
+		if got := threatintel.Infer(value); got != want {
+			t.Errorf("Infer(%q) = %s, want %s", value, got, want)
+		}
+	}
+}
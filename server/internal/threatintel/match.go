@@ -0,0 +1,302 @@
+package threatintel
+
+import (
+	"context"
+	"log/slog"
+	"net/netip"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/platform/orgcache"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Provider names the matcher in the enrichments it adds to events.
+const Provider = "horizon-threatintel"
+
+// EnrichmentType is the type of the enrichments recording a match.
+const EnrichmentType = "threat_indicator"
+
+// ── Set ──────────────────────────────────────────────────────────────────────
+
+// Set holds indicators for matching: addresses, domains, URLs, hashes and
+// emails in hash sets, and networks in a radix tree. It is safe for
+// concurrent use once built.
+type Set struct {
+	ips    map[netip.Addr][]*oapi.ThreatIndicator
+	cidrs  radix[*oapi.ThreatIndicator]
+	values map[oapi.ThreatIndicatorType]map[string][]*oapi.ThreatIndicator
+	size   int
+}
+
+// NewSet builds a Set of the indicators, whose values must be normalized.
+func NewSet(indicators []oapi.ThreatIndicator) *Set {
+	s := &Set{
+		ips:    make(map[netip.Addr][]*oapi.ThreatIndicator),
+		values: make(map[oapi.ThreatIndicatorType]map[string][]*oapi.ThreatIndicator),
+	}
+	for i := range indicators {
+		ind := &indicators[i]
+		switch ind.Type {
+		case oapi.ThreatIndicatorTypeIp:
+			addr, err := netip.ParseAddr(ind.Value)
+			if err != nil {
+				continue
+			}
+			s.ips[addr] = append(s.ips[addr], ind)
+		case oapi.ThreatIndicatorTypeCidr:
+			p, err := netip.ParsePrefix(ind.Value)
+			if err != nil {
+				continue
+			}
+			s.cidrs.insert(p.Masked(), ind)
+		default:
+			m := s.values[ind.Type]
+			if m == nil {
+				m = make(map[string][]*oapi.ThreatIndicator)
+				s.values[ind.Type] = m
+			}
+			m[ind.Value] = append(m[ind.Value], ind)
+		}
+		s.size++
+	}
+	return s
+}
+
+// Len returns the number of indicators in the set.
+func (s *Set) Len() int {
+	return s.size
+}
+
+// observed is what kind of value an attribute of an event holds.
+type observed int
+
+const (
+	observedIP observed = iota
+	observedDomain
+	observedURL
+	observedHash
+	observedEmail
+	// observedRData is a DNS answer's data: an address or a name.
+	observedRData
+)
+
+// attribute is an attribute of an OCSF object matched against indicators.
+type attribute struct {
+	field string
+	kind  observed
+}
+
+// attributes lists the attributes matched, by the object they are in.
+var attributes = map[reflect.Type][]attribute{
+	reflect.TypeFor[ocsf.Endpoint](): {
+		{"IP", observedIP}, {"Hostname", observedDomain}, {"Domain", observedDomain},
+	},
+	reflect.TypeFor[ocsf.Device](): {
+		{"IP", observedIP}, {"Hostname", observedDomain}, {"Domain", observedDomain},
+	},
+	reflect.TypeFor[ocsf.URL]():         {{"URLString", observedURL}, {"Hostname", observedDomain}},
+	reflect.TypeFor[ocsf.DNSQuery]():    {{"Hostname", observedDomain}},
+	reflect.TypeFor[ocsf.DNSAnswer]():   {{"RData", observedRData}},
+	reflect.TypeFor[ocsf.HTTPRequest](): {{"XForwardedFor", observedIP}},
+	reflect.TypeFor[ocsf.User]():        {{"EmailAddr", observedEmail}},
+	reflect.TypeFor[ocsf.Fingerprint](): {{"Value", observedHash}},
+}
+
+// match returns the indicators active at now that value, of the kind
+// observed, matches.
+func (s *Set) match(kind observed, value string, now time.Time) []*oapi.ThreatIndicator {
+	var found []*oapi.ThreatIndicator
+	switch kind {
+	case observedIP:
+		addr, err := netip.ParseAddr(strings.TrimSpace(value))
+		if err != nil {
+			return nil
+		}
+		addr = addr.Unmap().WithZone("")
+		found = append(found, s.ips[addr]...)
+		found = append(found, s.cidrs.lookup(addr)...)
+	case observedRData:
+		if _, err := netip.ParseAddr(strings.TrimSpace(value)); err == nil {
+			return s.match(observedIP, value, now)
+		}
+		return s.match(observedDomain, value, now)
+	case observedDomain:
+		d, ok := normalizeDomain(strings.TrimSpace(value))
+		if !ok {
+			return nil
+		}
+		// A domain indicator covers its subdomains.
+		domains := s.values[oapi.ThreatIndicatorTypeDomain]
+		for {
+			found = append(found, domains[d]...)
+			_, parent, ok := strings.Cut(d, ".")
+			if !ok {
+				break
+			}
+			d = parent
+		}
+	case observedURL:
+		if u, ok := normalizeURL(strings.TrimSpace(value)); ok {
+			found = s.values[oapi.ThreatIndicatorTypeUrl][u]
+		}
+	case observedHash:
+		found = s.values[oapi.ThreatIndicatorTypeFileHash][strings.ToLower(strings.TrimSpace(value))]
+	case observedEmail:
+		found = s.values[oapi.ThreatIndicatorTypeEmail][strings.ToLower(strings.TrimSpace(value))]
+	}
+	out := found[:0:0]
+	for _, ind := range found {
+		if active(ind, now) {
+			out = append(out, ind)
+		}
+	}
+	return out
+}
+
+// Enrich adds an enrichment to ev for every indicator active at now that an
+// attribute of ev matches, naming the attribute by its path in the event.
+func (s *Set) Enrich(ev ocsf.Event, now time.Time) {
+	if s.size == 0 {
+		return
+	}
+	base := ev.Common()
+	seen := make(map[string]bool)
+	walk(reflect.ValueOf(ev), "", func(path string, kind observed, value string) {
+		for _, ind := range s.match(kind, value, now) {
+			key := path + "\x00" + value + "\x00" + ind.Id.String()
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			base.Enrichments = append(base.Enrichments, enrichment(path, value, ind))
+		}
+	})
+}
+
+func enrichment(path, value string, ind *oapi.ThreatIndicator) ocsf.Enrichment {
+	data := map[string]any{
+		"indicator_id":   ind.Id.String(),
+		"indicator_type": string(ind.Type),
+		"indicator":      ind.Value,
+		"source":         ind.Source,
+		"confidence":     ind.Confidence,
+		"tlp":            string(ind.Tlp),
+		"tags":           ind.Tags,
+	}
+	if ind.Description != nil {
+		data["description"] = *ind.Description
+	}
+	return ocsf.Enrichment{Name: path, Value: value, Type: EnrichmentType, Provider: Provider, Data: data}
+}
+
+// walk calls fn with every non-empty attribute of v matched against
+// indicators, its path in the event and the kind of value it holds.
+// Unmapped attributes are not looked in.
+func walk(v reflect.Value, path string, fn func(path string, kind observed, value string)) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			walk(v.Elem(), path, fn)
+		}
+	case reflect.Slice:
+		for i := range v.Len() {
+			walk(v.Index(i), path, fn)
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for _, attr := range attributes[t] {
+			f, _ := t.FieldByName(attr.field)
+			at := join(path, jsonName(f))
+			switch fv := v.FieldByIndex(f.Index); fv.Kind() {
+			case reflect.String:
+				if s := fv.String(); s != "" {
+					fn(at, attr.kind, s)
+				}
+			case reflect.Slice:
+				for i := range fv.Len() {
+					if s := fv.Index(i).String(); s != "" {
+						fn(at, attr.kind, s)
+					}
+				}
+			}
+		}
+		for i := range t.NumField() {
+			f := t.Field(i)
+			if !f.IsExported() || f.Type.Kind() == reflect.String {
+				continue
+			}
+			child := path
+			if !f.Anonymous {
+				child = join(path, jsonName(f))
+			}
+			walk(v.Field(i), child, fn)
+		}
+	}
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	return name
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// ── Matcher ──────────────────────────────────────────────────────────────────
+
+// Matcher matches normalized events against the indicators of their org as
+// they are processed. It keeps each org's indicators in a Set, reloading it
+// when the org's indicators have changed, which it checks for at most once a
+// refresh interval. It is safe for concurrent use.
+type Matcher struct {
+	repo   *Repo
+	logger *slog.Logger
+	sets   *orgcache.Cache[*Set]
+}
+
+// NewMatcher wires a Matcher with the repo, transaction manager and logger.
+// A zero refresh means 30 seconds.
+func NewMatcher(repo *Repo, txm *tx.Manager, logger *slog.Logger, refresh time.Duration) *Matcher {
+	m := &Matcher{repo: repo, logger: logger}
+	m.sets = orgcache.New(txm, refresh, repo.Revision, m.load)
+	return m
+}
+
+// Enrich adds an enrichment to ev for every indicator of the org it matches.
+// When the org's indicators cannot be loaded, the last ones loaded are used.
+func (m *Matcher) Enrich(ctx context.Context, orgID uuid.UUID, ev ocsf.Event) {
+	if set := m.set(ctx, orgID); set != nil {
+		set.Enrich(ev, time.Now())
+	}
+}
+
+// set returns the org's indicators, reloading them when they have changed.
+func (m *Matcher) set(ctx context.Context, orgID uuid.UUID) *Set {
+	set, err := m.sets.Get(ctx, orgID)
+	if err != nil {
+		m.logger.WarnContext(ctx, "loading threat indicators failed",
+			slog.String("org_id", orgID.String()),
+			slog.Any("err", err),
+		)
+	}
+	return set
+}
+
+// load loads the org's indicators active at now.
+func (m *Matcher) load(ctx context.Context, orgID uuid.UUID, now time.Time) (*Set, error) {
+	indicators, err := m.repo.ListActive(ctx, orgID, now)
+	if err != nil {
+		return nil, err
+	}
+	return NewSet(indicators), nil
+}
@@ -0,0 +1,167 @@
+package threatintel_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/threatintel"
+)
+
+func indicator(typ oapi.ThreatIndicatorType, value string) oapi.ThreatIndicator {
+	return oapi.ThreatIndicator{
+		Id:         uuid.New(),
+		Type:       typ,
+		Value:      value,
+		Source:     "feed",
+		Confidence: 80,
+		Tlp:        oapi.Amber,
+		Tags:       []string{"c2"},
+	}
+}
+
+// matched lists the attributes of ev's enrichments and the indicators they
+// name, as "path=indicator".
+func matched(ev ocsf.Event) []string {
+	var out []string
+	for _, e := range ev.Common().Enrichments {
+		if e.Type == threatintel.EnrichmentType {
+			out = append(out, e.Name+"="+e.Data["indicator"].(string))
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func TestSetEnrich_MatchesAttributesOfEvents(t *testing.T) {
+	set := threatintel.NewSet([]oapi.ThreatIndicator{
+		indicator(oapi.ThreatIndicatorTypeIp, "203.0.113.7"),
+		indicator(oapi.ThreatIndicatorTypeCidr, "198.51.100.0/24"),
+		indicator(oapi.ThreatIndicatorTypeCidr, "198.51.100.128/25"),
+		indicator(oapi.ThreatIndicatorTypeCidr, "2001:db8::/32"),
+		indicator(oapi.ThreatIndicatorTypeDomain, "evil.example"),
+		indicator(oapi.ThreatIndicatorTypeUrl, "https://cdn.example/payload.bin"),
+		indicator(oapi.ThreatIndicatorTypeFileHash, "d41d8cd98f00b204e9800998ecf8427e"),
+		indicator(oapi.ThreatIndicatorTypeEmail, "phish@evil.example"),
+	})
+	if set.Len() != 8 {
+		t.Fatalf("Len: got %d, want 8", set.Len())
+	}
+
+	net := &ocsf.NetworkActivity{
+		BaseEvent: ocsf.BaseEvent{Device: &ocsf.Device{IP: "10.0.0.5"}},
+		Network: ocsf.Network{
+			SrcEndpoint: &ocsf.Endpoint{IP: "::ffff:203.0.113.7"},
+			DstEndpoint: &ocsf.Endpoint{IP: "198.51.100.200", Hostname: "c2.EVIL.example"},
+		},
+	}
+	set.Enrich(net, time.Now())
+	if got, want := matched(net), []string{
+		"dst_endpoint.hostname=evil.example",
+		"dst_endpoint.ip=198.51.100.0/24",
+		"dst_endpoint.ip=198.51.100.128/25",
+		"src_endpoint.ip=203.0.113.7",
+	}; !slices.Equal(got, want) {
+		t.Errorf("network activity: got %v, want %v", got, want)
+	}
+
+	dns := &ocsf.DNSActivity{
+		Query:   &ocsf.DNSQuery{Hostname: "www.evil.example."},
+		Answers: []ocsf.DNSAnswer{{RData: "2001:db8::53"}, {RData: "benign.example"}},
+	}
+	set.Enrich(dns, time.Now())
+	if got, want := matched(dns), []string{
+		"answers.rdata=2001:db8::/32",
+		"query.hostname=evil.example",
+	}; !slices.Equal(got, want) {
+		t.Errorf("dns activity: got %v, want %v", got, want)
+	}
+
+	web := &ocsf.HTTPActivity{
+		HTTPRequest: &ocsf.HTTPRequest{
+			URL:           &ocsf.URL{URLString: "HTTPS://cdn.example/payload.bin#x", Hostname: "cdn.example"},
+			XForwardedFor: []string{"192.0.2.1", "203.0.113.7"},
+		},
+	}
+	set.Enrich(web, time.Now())
+	if got, want := matched(web), []string{
+		"http_request.url.url_string=https://cdn.example/payload.bin",
+		"http_request.x_forwarded_for=203.0.113.7",
+	}; !slices.Equal(got, want) {
+		t.Errorf("http activity: got %v, want %v", got, want)
+	}
+}
+
+func TestSetEnrich_RecordsIndicatorAttributes(t *testing.T) {
+	ind := indicator(oapi.ThreatIndicatorTypeIp, "203.0.113.7")
+	desc := "Known C2"
+	ind.Description = &desc
+	set := threatintel.NewSet([]oapi.ThreatIndicator{ind})
+	ev := &ocsf.NetworkActivity{Network: ocsf.Network{SrcEndpoint: &ocsf.Endpoint{IP: "203.0.113.7"}}}
+
+	set.Enrich(ev, time.Now())
+
+	if len(ev.Enrichments) != 1 {
+		t.Fatalf("enrichments: got %+v", ev.Enrichments)
+	}
+	e := ev.Enrichments[0]
+	if e.Name != "src_endpoint.ip" || e.Value != "203.0.113.7" || e.Provider != threatintel.Provider {
+		t.Errorf("enrichment: got %+v", e)
+	}
+	if e.Data["indicator_id"] != ind.Id.String() || e.Data["source"] != "feed" || e.Data["confidence"] != 80 ||
+		e.Data["tlp"] != "amber" || e.Data["description"] != "Known C2" ||
+		!slices.Equal(e.Data["tags"].([]string), []string{"c2"}) {
+		t.Errorf("enrichment data: got %+v", e.Data)
+	}
+}
+
+func TestSetEnrich_SkipsExpiredAndRepeats(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	expired := indicator(oapi.ThreatIndicatorTypeIp, "203.0.113.7")
+	expired.ExpiresAt = &past
+	live := indicator(oapi.ThreatIndicatorTypeIp, "203.0.113.8")
+	live.ExpiresAt = &future
+	set := threatintel.NewSet([]oapi.ThreatIndicator{expired, live})
+	ev := &ocsf.HTTPActivity{
+		Network:     ocsf.Network{SrcEndpoint: &ocsf.Endpoint{IP: "203.0.113.7"}},
+		HTTPRequest: &ocsf.HTTPRequest{XForwardedFor: []string{"203.0.113.8", "203.0.113.8"}},
+	}
+
+	set.Enrich(ev, now)
+
+	if got, want := matched(ev), []string{"http_request.x_forwarded_for=203.0.113.8"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSetEnrich_MatchesFilesAndUsers(t *testing.T) {
+	const sha256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+	set := threatintel.NewSet([]oapi.ThreatIndicator{
+		indicator(oapi.ThreatIndicatorTypeFileHash, sha256),
+		indicator(oapi.ThreatIndicatorTypeEmail, "phish@evil.example"),
+	})
+	ev := &ocsf.FileActivity{
+		BaseEvent: ocsf.BaseEvent{Unmapped: map[string]any{"sha256": sha256}},
+		Actor: &ocsf.Actor{
+			User:    &ocsf.User{EmailAddr: "Phish@Evil.Example"},
+			Process: &ocsf.Process{File: &ocsf.File{Hashes: []ocsf.Fingerprint{{Value: strings.ToUpper(sha256)}}}},
+		},
+		File: &ocsf.File{Name: sha256, Hashes: []ocsf.Fingerprint{{Algorithm: "SHA-256", Value: sha256}}},
+	}
+
+	set.Enrich(ev, time.Now())
+
+	if got, want := matched(ev), []string{
+		"actor.process.file.hashes.value=" + sha256,
+		"actor.user.email_addr=phish@evil.example",
+		"file.hashes.value=" + sha256,
+	}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,215 @@
+package threatintel
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// mispEvent holds the properties read of a MISP event.
+type mispEvent struct {
+	Orgc struct {
+		Name string `json:"name"`
+	} `json:"Orgc"`
+	Tag       []mispTag       `json:"Tag"`
+	Attribute []mispAttribute `json:"Attribute"`
+	Object    []struct {
+		Attribute []mispAttribute `json:"Attribute"`
+	} `json:"Object"`
+}
+
+type mispAttribute struct {
+	UUID    string    `json:"uuid"`
+	Type    string    `json:"type"`
+	Value   string    `json:"value"`
+	Comment string    `json:"comment"`
+	ToIDS   mispBool  `json:"to_ids"`
+	Deleted mispBool  `json:"deleted"`
+	Tag     []mispTag `json:"Tag"`
+}
+
+type mispTag struct {
+	Name string `json:"name"`
+}
+
+// mispBool reads a MISP flag, which exports write as a boolean or as "0" or
+// "1".
+type mispBool bool
+
+func (b *mispBool) UnmarshalJSON(data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case bool:
+		*b = mispBool(v)
+	case string:
+		parsed, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("reading MISP flag %q: %w", v, err)
+		}
+		*b = mispBool(parsed)
+	case float64:
+		*b = v != 0
+	case nil:
+		*b = false
+	default:
+		return fmt.Errorf("reading MISP flag: unexpected %s", data)
+	}
+	return nil
+}
+
+// mispTypes maps the MISP attribute types read to indicator types. Composite
+// types, whose values join two with a |, list a type per part, empty for a
+// part that is not read.
+var mispTypes = map[string][]oapi.ThreatIndicatorType{
+	"ip-src":          {oapi.ThreatIndicatorTypeIp},
+	"ip-dst":          {oapi.ThreatIndicatorTypeIp},
+	"ip-src|port":     {oapi.ThreatIndicatorTypeIp, ""},
+	"ip-dst|port":     {oapi.ThreatIndicatorTypeIp, ""},
+	"domain":          {oapi.ThreatIndicatorTypeDomain},
+	"hostname":        {oapi.ThreatIndicatorTypeDomain},
+	"hostname|port":   {oapi.ThreatIndicatorTypeDomain, ""},
+	"domain|ip":       {oapi.ThreatIndicatorTypeDomain, oapi.ThreatIndicatorTypeIp},
+	"url":             {oapi.ThreatIndicatorTypeUrl},
+	"md5":             {oapi.ThreatIndicatorTypeFileHash},
+	"sha1":            {oapi.ThreatIndicatorTypeFileHash},
+	"sha256":          {oapi.ThreatIndicatorTypeFileHash},
+	"sha512":          {oapi.ThreatIndicatorTypeFileHash},
+	"filename|md5":    {"", oapi.ThreatIndicatorTypeFileHash},
+	"filename|sha1":   {"", oapi.ThreatIndicatorTypeFileHash},
+	"filename|sha256": {"", oapi.ThreatIndicatorTypeFileHash},
+	"filename|sha512": {"", oapi.ThreatIndicatorTypeFileHash},
+	"email":           {oapi.ThreatIndicatorTypeEmail},
+	"email-src":       {oapi.ThreatIndicatorTypeEmail},
+	"email-dst":       {oapi.ThreatIndicatorTypeEmail},
+}
+
+// parseMISP reads a MISP event export: an event, a list of events, or a
+// search response, each event optionally wrapped in an object under "Event".
+// Attributes marked for IDS of the types in mispTypes are read, including
+// those of the event's objects; others are skipped. An attribute's source is
+// the event's creator org, and its TLP level that of its tlp: tag, or else
+// the event's.
+func parseMISP(content string) (Parsed, error) {
+	var p Parsed
+	events, err := mispEvents([]byte(content))
+	if err != nil {
+		return p, fmt.Errorf("%w: reading MISP export: %w", ErrInvalid, err)
+	}
+	n := 0
+	for _, ev := range events {
+		eventTLP, eventTags := mispTags(ev.Tag)
+		attrs := ev.Attribute
+		for _, obj := range ev.Object {
+			attrs = append(attrs, obj.Attribute...)
+		}
+		for _, a := range attrs {
+			n++
+			ref := a.UUID
+			if ref == "" {
+				ref = "attribute " + strconv.Itoa(n)
+			}
+			types, ok := mispTypes[a.Type]
+			if !ok || !bool(a.ToIDS) || bool(a.Deleted) {
+				p.Skipped++
+				continue
+			}
+			parts := []string{a.Value}
+			if len(types) > 1 {
+				parts = strings.SplitN(a.Value, "|", len(types))
+				if len(parts) != len(types) {
+					p.fail(ref, fmt.Errorf("%w: %s value %q is not composite", ErrInvalid, a.Type, a.Value))
+					continue
+				}
+			}
+
+			tlp, tags := mispTags(a.Tag)
+			if tlp == "" {
+				tlp = eventTLP
+			}
+			base := Draft{Source: ev.Orgc.Name, TLP: tlp, Tags: slices.Concat(eventTags, tags)}
+			if a.UUID != "" {
+				base.ExternalID = &a.UUID
+			}
+			if a.Comment != "" {
+				base.Description = &a.Comment
+			}
+			for i, t := range types {
+				if t == "" {
+					continue
+				}
+				d := base
+				d.Type, d.Value = t, parts[i]
+				if t == oapi.ThreatIndicatorTypeIp && strings.Contains(d.Value, "/") {
+					d.Type = oapi.ThreatIndicatorTypeCidr
+				}
+				p.add(ref, d)
+			}
+		}
+	}
+	return p, nil
+}
+
+// mispEvents reads the events of an export.
+func mispEvents(data []byte) ([]mispEvent, error) {
+	data = bytes.TrimSpace(data)
+	var items []json.RawMessage
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &items); err != nil {
+			return nil, err
+		}
+	} else {
+		var search struct {
+			Response []json.RawMessage `json:"response"`
+		}
+		if err := json.Unmarshal(data, &search); err != nil {
+			return nil, err
+		}
+		items = search.Response
+		if items == nil {
+			items = []json.RawMessage{data}
+		}
+	}
+	events := make([]mispEvent, 0, len(items))
+	for _, item := range items {
+		var wrapped struct {
+			Event *mispEvent `json:"Event"`
+		}
+		if err := json.Unmarshal(item, &wrapped); err != nil {
+			return nil, err
+		}
+		if wrapped.Event != nil {
+			events = append(events, *wrapped.Event)
+			continue
+		}
+		var ev mispEvent
+		if err := json.Unmarshal(item, &ev); err != nil {
+			return nil, err
+		}
+		events = append(events, ev)
+	}
+	return events, nil
+}
+
+// mispTags splits tags into a TLP level, from a tlp: tag, and the rest.
+func mispTags(tags []mispTag) (oapi.Tlp, []string) {
+	var tlp oapi.Tlp
+	var rest []string
+	for _, tag := range tags {
+		if strings.HasPrefix(strings.ToLower(tag.Name), "tlp:") {
+			if t, ok := parseTLP(tag.Name); ok {
+				tlp = t
+				continue
+			}
+		}
+		rest = append(rest, tag.Name)
+	}
+	return tlp, rest
+}
@@ -0,0 +1,102 @@
+package threatintel
+
+import "net/netip"
+
+// radix is a path-compressed binary trie of networks, finding every network
+// an address is in with one walk down from the root. IPv4 and IPv6 networks
+// are kept in separate trees.
+type radix[V any] struct {
+	v4, v6 *radixNode[V]
+}
+
+type radixNode[V any] struct {
+	prefix netip.Prefix
+	// values are those of the network prefix itself; a node that only joins
+	// two longer networks has none.
+	values   []V
+	children [2]*radixNode[V]
+}
+
+// insert adds v under the network p, which must be masked.
+func (t *radix[V]) insert(p netip.Prefix, v V) {
+	n := &t.v6
+	if p.Addr().Is4() {
+		n = &t.v4
+	}
+	for {
+		cur := *n
+		if cur == nil {
+			*n = &radixNode[V]{prefix: p, values: []V{v}}
+			return
+		}
+		common := commonBits(cur.prefix, p)
+		switch {
+		case common == cur.prefix.Bits() && common == p.Bits():
+			cur.values = append(cur.values, v)
+			return
+		case common == cur.prefix.Bits():
+			// p is inside cur.
+			n = &cur.children[bitAt(p.Addr(), common)]
+		case common == p.Bits():
+			// cur is inside p.
+			leaf := &radixNode[V]{prefix: p, values: []V{v}}
+			leaf.children[bitAt(cur.prefix.Addr(), common)] = cur
+			*n = leaf
+			return
+		default:
+			// p and cur part ways below their common network.
+			join := &radixNode[V]{prefix: netip.PrefixFrom(p.Addr(), common).Masked()}
+			join.children[bitAt(cur.prefix.Addr(), common)] = cur
+			join.children[bitAt(p.Addr(), common)] = &radixNode[V]{prefix: p, values: []V{v}}
+			*n = join
+			return
+		}
+	}
+}
+
+// lookup returns the values of every network addr is in, the most specific
+// network's first.
+func (t *radix[V]) lookup(addr netip.Addr) []V {
+	addr = addr.Unmap()
+	n := t.v6
+	if addr.Is4() {
+		n = t.v4
+	}
+	var found [][]V
+	for n != nil && n.prefix.Contains(addr) {
+		if len(n.values) > 0 {
+			found = append(found, n.values)
+		}
+		if n.prefix.Bits() == addr.BitLen() {
+			break
+		}
+		n = n.children[bitAt(addr, n.prefix.Bits())]
+	}
+	var out []V
+	for i := len(found) - 1; i >= 0; i-- {
+		out = append(out, found[i]...)
+	}
+	return out
+}
+
+// commonBits returns the length of the longest network containing both a
+// and b.
+func commonBits(a, b netip.Prefix) int {
+	limit := min(a.Bits(), b.Bits())
+	x, y := a.Addr().AsSlice(), b.Addr().AsSlice()
+	for i := range limit {
+		if bit(x, i) != bit(y, i) {
+			return i
+		}
+	}
+	return limit
+}
+
+// bitAt returns bit i of addr, counting from the most significant.
+func bitAt(addr netip.Addr, i int) int {
+	return bit(addr.AsSlice(), i)
+}
+
+func bit(b []byte, i int) int {
+	return int(b[i/8]>>(7-i%8)) & 1
+}
@@ -0,0 +1,389 @@
+package threatintel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/go-jet/jet/v2/postgres"
+	"github.com/go-jet/jet/v2/qrm"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/luketeo/horizon/generated/horizon/public/model"
+	"github.com/luketeo/horizon/generated/horizon/public/table"
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// Repo owns threat_indicators and threat_indicator_revisions SQL and row→DTO
+// mapping. The tables are protected by RLS, so callers run statements inside
+// tx.Manager.RunInTx.
+type Repo struct {
+	db qrm.DB
+}
+
+// NewRepo wires a Repo to the given database.
+func NewRepo(db qrm.DB) *Repo {
+	return &Repo{db: db}
+}
+
+func toOapi(m model.ThreatIndicators) oapi.ThreatIndicator {
+	tags := []string(m.Tags)
+	if tags == nil {
+		tags = []string{}
+	}
+	return oapi.ThreatIndicator{
+		Id:          m.ID,
+		OrgId:       m.OrgID,
+		Type:        oapi.ThreatIndicatorType(m.Type),
+		Value:       m.Value,
+		Source:      m.Source,
+		Confidence:  int(m.Confidence),
+		Tlp:         oapi.Tlp(m.Tlp),
+		Tags:        tags,
+		Description: m.Description,
+		ExternalId:  m.ExternalID,
+		ExpiresAt:   m.ExpiresAt,
+		Version:     m.Version,
+		CreatedAt:   m.CreatedAt,
+		UpdatedAt:   m.UpdatedAt,
+	}
+}
+
+func toOapiList(rows []model.ThreatIndicators) []oapi.ThreatIndicator {
+	indicators := make([]oapi.ThreatIndicator, 0, len(rows))
+	for _, row := range rows {
+		indicators = append(indicators, toOapi(row))
+	}
+	return indicators
+}
+
+// valueTaken reports whether err is a unique violation on (org_id, type,
+// value).
+func valueTaken(err error) bool {
+	var pgErr *pq.Error
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+// versionIn restricts an update to the listed versions; nil means any.
+func versionIn(col postgres.ColumnInteger, versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return col.IN(exprs...)
+}
+
+// Insert stores a new indicator from a complete draft. Returns ErrConflict
+// when the org already has an indicator of that type and value.
+func (r *Repo) Insert(ctx context.Context, orgID uuid.UUID, d Draft) (oapi.ThreatIndicator, error) {
+	t := table.ThreatIndicators
+	stmt := t.
+		INSERT(
+			t.OrgID, t.Type, t.Value, t.Source, t.Confidence, t.Tlp, t.Tags,
+			t.Description, t.ExternalID, t.ExpiresAt,
+		).
+		VALUES(
+			orgID, string(d.Type), d.Value, d.Source, int32(*d.Confidence), string(d.TLP), pq.StringArray(d.Tags),
+			d.Description, d.ExternalID, d.ExpiresAt,
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.ThreatIndicators
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if valueTaken(err) {
+			return oapi.ThreatIndicator{}, ErrConflict
+		}
+		return oapi.ThreatIndicator{}, fmt.Errorf("inserting threat indicator: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Filter narrows a listing of indicators.
+type Filter struct {
+	// Type and Tag, when set, keep indicators of that type and with that tag.
+	Type *oapi.ThreatIndicatorType
+	Tag  *string
+	// Active keeps only indicators that have not expired.
+	Active bool
+	// After continues a listing after the indicator with that ID.
+	After *uuid.UUID
+	Limit int
+}
+
+// List returns up to f.Limit of the org's indicators passing f, in ID order.
+func (r *Repo) List(ctx context.Context, orgID uuid.UUID, f Filter) ([]oapi.ThreatIndicator, error) {
+	t := table.ThreatIndicators
+	cond := t.OrgID.EQ(postgres.UUID(orgID))
+	if f.Type != nil {
+		cond = cond.AND(t.Type.EQ(postgres.String(string(*f.Type))))
+	}
+	if f.Tag != nil {
+		cond = cond.AND(t.Tags.CONTAINS(postgres.StringArray(*f.Tag)))
+	}
+	if f.Active {
+		cond = cond.AND(t.ExpiresAt.IS_NULL().OR(t.ExpiresAt.GT(postgres.NOW())))
+	}
+	if f.After != nil {
+		cond = cond.AND(t.ID.GT(postgres.UUID(*f.After)))
+	}
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(cond).
+		ORDER_BY(t.ID.ASC()).
+		LIMIT(int64(f.Limit))
+
+	var rows []model.ThreatIndicators
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing threat indicators: %w", err)
+	}
+	return toOapiList(rows), nil
+}
+
+// ListActive returns every indicator of the org that has not expired at now.
+func (r *Repo) ListActive(ctx context.Context, orgID uuid.UUID, now time.Time) ([]oapi.ThreatIndicator, error) {
+	t := table.ThreatIndicators
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.OrgID.EQ(postgres.UUID(orgID)).
+				AND(t.ExpiresAt.IS_NULL().OR(t.ExpiresAt.GT(postgres.TimestampzT(now)))),
+		)
+
+	var rows []model.ThreatIndicators
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+		return nil, fmt.Errorf("listing active threat indicators: %w", err)
+	}
+	return toOapiList(rows), nil
+}
+
+// Get returns a single indicator of the org. Returns ErrNotFound when it
+// does not exist in that org.
+func (r *Repo) Get(ctx context.Context, orgID, indicatorID uuid.UUID) (oapi.ThreatIndicator, error) {
+	t := table.ThreatIndicators
+	stmt := postgres.
+		SELECT(t.AllColumns).
+		FROM(t).
+		WHERE(
+			t.ID.EQ(postgres.UUID(indicatorID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		).
+		LIMIT(1)
+
+	var row model.ThreatIndicators
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return oapi.ThreatIndicator{}, ErrNotFound
+		}
+		return oapi.ThreatIndicator{}, fmt.Errorf("getting threat indicator: %w", err)
+	}
+	return toOapi(row), nil
+}
+
+// Update patches the fields set in req, whose source and tags must be
+// cleaned, and bumps the version. An empty description clears it, as does
+// ClearExpiry the expiry. When ifMatch is non-nil the update only applies if
+// the current version is listed, otherwise ErrPreconditionFailed is returned.
+func (r *Repo) Update(
+	ctx context.Context,
+	orgID, indicatorID uuid.UUID,
+	req oapi.UpdateThreatIndicatorRequest,
+	ifMatch []int64,
+) (oapi.ThreatIndicator, error) {
+	t := table.ThreatIndicators
+	source := postgres.StringExpression(t.Source)
+	if req.Source != nil {
+		source = postgres.String(*req.Source)
+	}
+	confidence := postgres.IntegerExpression(t.Confidence)
+	if req.Confidence != nil {
+		confidence = postgres.Int32(int32(*req.Confidence))
+	}
+	tlp := postgres.StringExpression(t.Tlp)
+	if req.Tlp != nil {
+		tlp = postgres.String(string(*req.Tlp))
+	}
+	tags := postgres.Expression(t.Tags)
+	if req.Tags != nil {
+		tags = postgres.StringArray(*req.Tags...)
+		if len(*req.Tags) == 0 {
+			tags = postgres.Raw("'{}'::text[]")
+		}
+	}
+	description := postgres.StringExpression(t.Description)
+	switch {
+	case req.Description == nil:
+	case *req.Description == "":
+		description = postgres.StringExp(postgres.NULL)
+	default:
+		description = postgres.String(*req.Description)
+	}
+	expiresAt := postgres.TimestampzExpression(t.ExpiresAt)
+	switch {
+	case req.ClearExpiry != nil && *req.ClearExpiry:
+		expiresAt = postgres.TimestampzExp(postgres.NULL)
+	case req.ExpiresAt != nil:
+		expiresAt = postgres.TimestampzT(*req.ExpiresAt)
+	}
+
+	stmt := t.
+		UPDATE(t.Source, t.Confidence, t.Tlp, t.Tags, t.Description, t.ExpiresAt, t.Version, t.UpdatedAt).
+		SET(source, confidence, tlp, tags, description, expiresAt, t.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			t.ID.EQ(postgres.UUID(indicatorID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))).
+				AND(versionIn(t.Version, ifMatch)),
+		).
+		RETURNING(t.AllColumns)
+
+	var row model.ThreatIndicators
+	err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row)
+	switch {
+	case err == nil:
+		return toOapi(row), nil
+	case !errors.Is(err, qrm.ErrNoRows):
+		return oapi.ThreatIndicator{}, fmt.Errorf("updating threat indicator: %w", err)
+	}
+	if _, err := r.Get(ctx, orgID, indicatorID); err != nil {
+		return oapi.ThreatIndicator{}, err
+	}
+	return oapi.ThreatIndicator{}, ErrPreconditionFailed
+}
+
+// Delete removes an indicator. Returns ErrNotFound when no row matched.
+func (r *Repo) Delete(ctx context.Context, orgID, indicatorID uuid.UUID) error {
+	t := table.ThreatIndicators
+	stmt := t.
+		DELETE().
+		WHERE(
+			t.ID.EQ(postgres.UUID(indicatorID)).
+				AND(t.OrgID.EQ(postgres.UUID(orgID))),
+		)
+
+	res, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db))
+	if err != nil {
+		return fmt.Errorf("deleting threat indicator: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ── Imports ──────────────────────────────────────────────────────────────────
+
+// upsertBatch bounds the rows of one upsert, keeping it under the protocol's
+// limit on bind parameters.
+const upsertBatch = 500
+
+// key identifies an indicator within an org.
+type key struct {
+	typ   oapi.ThreatIndicatorType
+	value string
+}
+
+// Existing returns which of the drafts the org already has an indicator of.
+func (r *Repo) Existing(ctx context.Context, orgID uuid.UUID, drafts []Draft) (map[key]bool, error) {
+	t := table.ThreatIndicators
+	existing := make(map[key]bool)
+	for batch := range slices.Chunk(drafts, upsertBatch) {
+		values := make([]postgres.Expression, 0, len(batch))
+		for _, d := range batch {
+			values = append(values, postgres.String(d.Value))
+		}
+		stmt := postgres.
+			SELECT(t.Type, t.Value).
+			FROM(t).
+			WHERE(t.OrgID.EQ(postgres.UUID(orgID)).AND(t.Value.IN(values...)))
+
+		var rows []model.ThreatIndicators
+		if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &rows); err != nil {
+			return nil, fmt.Errorf("finding existing threat indicators: %w", err)
+		}
+		for _, row := range rows {
+			existing[key{oapi.ThreatIndicatorType(row.Type), row.Value}] = true
+		}
+	}
+	return existing, nil
+}
+
+// Upsert stores complete drafts, none of the same type and value, replacing
+// the attributes of the org's indicators they match and bumping their
+// versions.
+func (r *Repo) Upsert(ctx context.Context, orgID uuid.UUID, drafts []Draft) error {
+	t := table.ThreatIndicators
+	for batch := range slices.Chunk(drafts, upsertBatch) {
+		stmt := t.INSERT(
+			t.OrgID, t.Type, t.Value, t.Source, t.Confidence, t.Tlp, t.Tags,
+			t.Description, t.ExternalID, t.ExpiresAt,
+		)
+		for _, d := range batch {
+			stmt = stmt.VALUES(
+				orgID, string(d.Type), d.Value, d.Source, int32(*d.Confidence), string(d.TLP), pq.StringArray(d.Tags),
+				d.Description, d.ExternalID, d.ExpiresAt,
+			)
+		}
+		stmt = stmt.
+			ON_CONFLICT(t.OrgID, t.Type, t.Value).
+			DO_UPDATE(postgres.SET(
+				t.Source.SET(t.EXCLUDED.Source),
+				t.Confidence.SET(t.EXCLUDED.Confidence),
+				t.Tlp.SET(t.EXCLUDED.Tlp),
+				t.Tags.SET(t.EXCLUDED.Tags),
+				t.Description.SET(t.EXCLUDED.Description),
+				t.ExternalID.SET(t.EXCLUDED.ExternalID),
+				t.ExpiresAt.SET(t.EXCLUDED.ExpiresAt),
+				t.Version.SET(t.Version.ADD(postgres.Int(1))),
+				t.UpdatedAt.SET(postgres.NOW()),
+			))
+
+		if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+			return fmt.Errorf("upserting threat indicators: %w", err)
+		}
+	}
+	return nil
+}
+
+// ── Revisions ────────────────────────────────────────────────────────────────
+
+// Revision returns the org's indicator revision, which changes whenever its
+// indicators do; zero when they never have.
+func (r *Repo) Revision(ctx context.Context, orgID uuid.UUID) (int64, error) {
+	t := table.ThreatIndicatorRevisions
+	stmt := postgres.
+		SELECT(t.Revision).
+		FROM(t).
+		WHERE(t.OrgID.EQ(postgres.UUID(orgID)))
+
+	var row model.ThreatIndicatorRevisions
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &row); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("getting threat indicator revision: %w", err)
+	}
+	return row.Revision, nil
+}
+
+// Bump advances the org's indicator revision, telling matchers to reload.
+func (r *Repo) Bump(ctx context.Context, orgID uuid.UUID) error {
+	t := table.ThreatIndicatorRevisions
+	stmt := t.
+		INSERT(t.OrgID).
+		VALUES(orgID).
+		ON_CONFLICT(t.OrgID).
+		DO_UPDATE(postgres.SET(t.Revision.SET(t.Revision.ADD(postgres.Int(1)))))
+
+	if _, err := stmt.ExecContext(ctx, tx.Executor(ctx, r.db)); err != nil {
+		return fmt.Errorf("bumping threat indicator revision: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,378 @@
+// Package threatintel owns an organisation's threat indicators: addresses,
+// networks, domains, URLs, file hashes and emails known to be malicious,
+// each with the source it came from, a confidence, a TLP level, tags and an
+// expiry. Indicators are added over the API or imported from CSV files, STIX
+// 2.1 bundles and MISP event exports, and every normalized event is matched
+// against the org's active indicators as it is processed, each match added to
+// the event as an enrichment.
+package threatintel
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+)
+
+// ErrNotFound is returned when an indicator does not exist in the org.
+var ErrNotFound = errors.New("threat indicator not found")
+
+// ErrConflict is returned when the org already has an indicator of that type
+// and value.
+var ErrConflict = errors.New("threat indicator already exists")
+
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("threat indicator version mismatch")
+
+// ErrInvalid is returned when an indicator's value is not of its type or an
+// attribute is out of range, and when an import cannot be read at all.
+var ErrInvalid = errors.New("invalid threat indicator")
+
+// Limits of an import.
+const (
+	// maxImportEntries caps the entries of one import.
+	maxImportEntries = 50000
+	// maxImportErrors caps the errors an import reports.
+	maxImportErrors = 100
+)
+
+// Service orchestrates indicator operations. Each runs in a transaction
+// carrying the caller's tenant scope, joining one already on ctx. Mutations
+// append their domain event to the outbox and advance the org's indicator
+// revision in that same transaction.
+type Service struct {
+	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
+	logger *slog.Logger
+}
+
+// NewService wires a Service with its repo, transaction manager, outbox and
+// logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{repo: repo, tx: txm, events: events, logger: logger}
+}
+
+// Draft is an indicator to be stored. A draft is complete once normalized
+// and given its defaults, when Source, Confidence and TLP are set.
+type Draft struct {
+	// Type is inferred from Value when empty.
+	Type        oapi.ThreatIndicatorType
+	Value       string
+	Source      string
+	Confidence  *int
+	TLP         oapi.Tlp
+	Tags        []string
+	Description *string
+	ExternalID  *string
+	ExpiresAt   *time.Time
+}
+
+// defaults fill in what a draft does not say.
+type defaults struct {
+	source     string
+	confidence int
+	tlp        oapi.Tlp
+	tags       []string
+	expiresAt  *time.Time
+}
+
+// complete normalizes d's value and checks its attributes, filling in what it
+// does not say from def. Returns an error wrapping ErrInvalid when d is not a
+// valid indicator.
+func complete(d *Draft, def defaults) error {
+	if d.Type == "" {
+		d.Type = Infer(d.Value)
+	}
+	if !validType(d.Type) {
+		return fmt.Errorf("%w: unknown indicator type %q", ErrInvalid, d.Type)
+	}
+	value, err := Normalize(d.Type, d.Value)
+	if err != nil {
+		return err
+	}
+	d.Value = value
+	if d.Source == "" {
+		d.Source = def.source
+	}
+	if d.Confidence == nil {
+		d.Confidence = &def.confidence
+	}
+	if d.TLP == "" {
+		d.TLP = def.tlp
+	}
+	d.Tags = CleanTags(slices.Concat(d.Tags, def.tags))
+	if d.ExpiresAt == nil {
+		d.ExpiresAt = def.expiresAt
+	}
+	if d.Description != nil && *d.Description == "" {
+		d.Description = nil
+	}
+	return CheckAttributes(&d.Source, d.Confidence, &d.TLP, &d.Tags, d.Description)
+}
+
+// CheckAttributes checks those of an indicator's attributes that are set,
+// as they are given explicitly or as the defaults of an import.
+func CheckAttributes(source *string, confidence *int, tlp *oapi.Tlp, tags *[]string, description *string) error {
+	switch {
+	case source != nil && (*source == "" || utf8.RuneCountInString(*source) > maxSourceLen):
+		return fmt.Errorf("%w: source must be 1 to %d characters", ErrInvalid, maxSourceLen)
+	case confidence != nil && (*confidence < 0 || *confidence > 100):
+		return fmt.Errorf("%w: confidence must be between 0 and 100", ErrInvalid)
+	case tlp != nil && !validTLP(*tlp):
+		return fmt.Errorf("%w: unknown TLP level %q", ErrInvalid, *tlp)
+	case tags != nil && len(*tags) > maxTags:
+		return fmt.Errorf("%w: more than %d tags", ErrInvalid, maxTags)
+	case description != nil && utf8.RuneCountInString(*description) > maxDescriptionLen:
+		return fmt.Errorf("%w: description longer than %d characters", ErrInvalid, maxDescriptionLen)
+	}
+	if tags != nil {
+		for _, tag := range *tags {
+			if utf8.RuneCountInString(tag) > maxTagLen {
+				return fmt.Errorf("%w: tag longer than %d characters", ErrInvalid, maxTagLen)
+			}
+		}
+	}
+	return nil
+}
+
+// Create adds an indicator. Returns ErrInvalid when the value is not of the
+// type or an attribute is out of range, and ErrConflict when the org already
+// has the indicator.
+func (s *Service) Create(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.CreateThreatIndicatorRequest,
+) (oapi.ThreatIndicator, error) {
+	d := Draft{
+		Type:        req.Type,
+		Value:       req.Value,
+		Confidence:  req.Confidence,
+		Description: req.Description,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	if req.Source != nil {
+		if *req.Source == "" {
+			return oapi.ThreatIndicator{}, fmt.Errorf("%w: empty source", ErrInvalid)
+		}
+		d.Source = *req.Source
+	}
+	if req.Tlp != nil {
+		d.TLP = *req.Tlp
+	}
+	if req.Tags != nil {
+		d.Tags = *req.Tags
+	}
+	if d.Type == "" {
+		return oapi.ThreatIndicator{}, fmt.Errorf("%w: missing type", ErrInvalid)
+	}
+	def := defaults{source: manualSource, confidence: defaultConfidence, tlp: defaultTLP}
+	if err := complete(&d, def); err != nil {
+		return oapi.ThreatIndicator{}, err
+	}
+
+	var ind oapi.ThreatIndicator
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if ind, err = s.repo.Insert(ctx, orgID, d); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, ind.Id, EventCreated, ind)
+	})
+	return ind, err
+}
+
+// List returns a page of the org's indicators passing f.
+func (s *Service) List(ctx context.Context, orgID uuid.UUID, f Filter) ([]oapi.ThreatIndicator, error) {
+	var indicators []oapi.ThreatIndicator
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		indicators, err = s.repo.List(ctx, orgID, f)
+		return err
+	})
+	return indicators, err
+}
+
+// Get returns a single indicator. Returns ErrNotFound when it does not exist
+// in the org.
+func (s *Service) Get(ctx context.Context, orgID, indicatorID uuid.UUID) (oapi.ThreatIndicator, error) {
+	var ind oapi.ThreatIndicator
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		ind, err = s.repo.Get(ctx, orgID, indicatorID)
+		return err
+	})
+	return ind, err
+}
+
+// Update patches an indicator's attributes; its type and value are fixed. A
+// non-nil ifMatch makes the update conditional on the indicator's version.
+// Returns ErrInvalid when an attribute is out of range.
+func (s *Service) Update(
+	ctx context.Context,
+	orgID, indicatorID uuid.UUID,
+	req oapi.UpdateThreatIndicatorRequest,
+	ifMatch []int64,
+) (oapi.ThreatIndicator, error) {
+	if req.Tags != nil {
+		tags := CleanTags(*req.Tags)
+		req.Tags = &tags
+	}
+	if err := CheckAttributes(req.Source, req.Confidence, req.Tlp, req.Tags, req.Description); err != nil {
+		return oapi.ThreatIndicator{}, err
+	}
+
+	var ind oapi.ThreatIndicator
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if ind, err = s.repo.Update(ctx, orgID, indicatorID, req, ifMatch); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, indicatorID, EventUpdated, ind)
+	})
+	return ind, err
+}
+
+// Delete removes an indicator. Returns ErrNotFound when it does not exist in
+// the org.
+func (s *Service) Delete(ctx context.Context, orgID, indicatorID uuid.UUID) error {
+	return s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		ind, err := s.repo.Get(ctx, orgID, indicatorID)
+		if err != nil {
+			return err
+		}
+		if err := s.repo.Delete(ctx, orgID, indicatorID); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, indicatorID, EventDeleted, DeletedPayload{
+			ID:    indicatorID,
+			OrgID: orgID,
+			Type:  ind.Type,
+			Value: ind.Value,
+		})
+	})
+}
+
+// ── Imports ──────────────────────────────────────────────────────────────────
+
+// Import reads indicators from a CSV file, STIX 2.1 bundle or MISP event
+// export and stores them, replacing the attributes of indicators the org
+// already has. Entries that are not indicators of a supported type are
+// skipped; entries that are invalid are reported and the rest imported.
+// Returns ErrInvalid when the content cannot be read at all.
+func (s *Service) Import(
+	ctx context.Context,
+	orgID uuid.UUID,
+	req oapi.ImportThreatIndicatorsRequest,
+) (oapi.ThreatIndicatorImportResult, error) {
+	res := oapi.ThreatIndicatorImportResult{Errors: []oapi.ThreatIndicatorImportError{}}
+	def := defaults{
+		source:     string(req.Format),
+		confidence: defaultConfidence,
+		tlp:        defaultTLP,
+		expiresAt:  req.ExpiresAt,
+	}
+	if req.Source != nil {
+		def.source = *req.Source
+	}
+	if req.Confidence != nil {
+		def.confidence = *req.Confidence
+	}
+	if req.Tlp != nil {
+		def.tlp = *req.Tlp
+	}
+	if req.Tags != nil {
+		def.tags = CleanTags(*req.Tags)
+	}
+	if err := CheckAttributes(&def.source, &def.confidence, &def.tlp, &def.tags, nil); err != nil {
+		return res, err
+	}
+
+	p, err := Parse(req.Format, req.Content)
+	if err != nil {
+		return res, err
+	}
+	if len(p.Entries) > maxImportEntries {
+		return res, fmt.Errorf("%w: more than %d entries", ErrInvalid, maxImportEntries)
+	}
+
+	res.Skipped = p.Skipped
+	report := func(ref string, err error) {
+		if len(res.Errors) < maxImportErrors {
+			res.Errors = append(res.Errors, oapi.ThreatIndicatorImportError{Entry: ref, Message: err.Error()})
+		}
+	}
+	for _, e := range p.Errors {
+		report(e.Ref, e.Err)
+	}
+
+	// An indicator given twice is imported as last given.
+	drafts := make([]Draft, 0, len(p.Entries))
+	index := make(map[key]int, len(p.Entries))
+	for _, e := range p.Entries {
+		d := e.Draft
+		if err := complete(&d, def); err != nil {
+			report(e.Ref, err)
+			continue
+		}
+		k := key{d.Type, d.Value}
+		if i, ok := index[k]; ok {
+			drafts[i] = d
+			continue
+		}
+		index[k] = len(drafts)
+		drafts = append(drafts, d)
+	}
+	if len(drafts) == 0 {
+		return res, nil
+	}
+
+	err = s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		existing, err := s.repo.Existing(ctx, orgID, drafts)
+		if err != nil {
+			return err
+		}
+		for _, d := range drafts {
+			if existing[key{d.Type, d.Value}] {
+				res.Updated++
+			} else {
+				res.Created++
+			}
+		}
+		if err := s.repo.Upsert(ctx, orgID, drafts); err != nil {
+			return err
+		}
+		if err := s.repo.Bump(ctx, orgID); err != nil {
+			return err
+		}
+		return s.emit(ctx, orgID, orgID, EventImported, ImportedPayload{
+			OrgID:   orgID,
+			Format:  req.Format,
+			Source:  def.source,
+			Created: res.Created,
+			Updated: res.Updated,
+		})
+	})
+	if err != nil {
+		return oapi.ThreatIndicatorImportResult{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,229 @@
+package threatintel_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/clerk/clerk-sdk-go/v2"
+	"github.com/google/uuid"
+
+	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/ocsf"
+	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tenant"
+	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/threatintel"
+	"github.com/luketeo/horizon/internal/user"
+)
+
+func strPtr(s string) *string { return &s }
+
+func intPtr(i int) *int { return &i }
+
+func fakeClerkUser(id, primaryEmail string) *clerk.User {
+	emailID := "eaddr_" + id
+	return &clerk.User{
+		ID:                    id,
+		FirstName:             strPtr("First"),
+		LastName:              strPtr("Last"),
+		PrimaryEmailAddressID: &emailID,
+		EmailAddresses: []*clerk.EmailAddress{
+			{ID: emailID, EmailAddress: primaryEmail},
+		},
+	}
+}
+
+func discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// seedOrg builds a user and an org owned by that user. Returns orgID.
+func seedOrg(t *testing.T, db *sql.DB, clerkID, orgName string) uuid.UUID {
+	t.Helper()
+	ctx := context.Background()
+	userSvc := user.NewService(user.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	_, userID, err := userSvc.GetOrCreateUser(ctx, fakeClerkUser(clerkID, clerkID+"@example.com"))
+	if err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	orgSvc := org.NewService(org.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	o, err := orgSvc.CreateOrg(tenant.WithUser(ctx, userID), orgName, nil, userID)
+	if err != nil {
+		t.Fatalf("seed org: %v", err)
+	}
+	return o.Id
+}
+
+func newSeededService(t *testing.T) (*threatintel.Service, *sql.DB, uuid.UUID) {
+	t.Helper()
+	db := testhelper.DB(t)
+	testhelper.Reset(t, db)
+	orgID := seedOrg(t, db, "user_threatintel_seed", "Threat Intel Test Org")
+	svc := threatintel.NewService(threatintel.NewRepo(db), tx.NewManager(db), outbox.NewRepo(db), discard())
+	return svc, db, orgID
+}
+
+func TestCreateIndicator_NormalizesAndRejectsDuplicates(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+
+	ind, err := svc.Create(ctx, orgID, oapi.CreateThreatIndicatorRequest{
+		Type:  oapi.ThreatIndicatorTypeDomain,
+		Value: "Evil.Example.",
+		Tags:  &[]string{"c2", " c2 ", ""},
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if ind.Value != "evil.example" || ind.Source != "manual" || ind.Confidence != 50 || ind.Tlp != oapi.Amber ||
+		len(ind.Tags) != 1 || ind.Version != 1 {
+		t.Errorf("created: got %+v", ind)
+	}
+
+	_, err = svc.Create(
+		ctx,
+		orgID,
+		oapi.CreateThreatIndicatorRequest{Type: oapi.ThreatIndicatorTypeDomain, Value: "evil.example"},
+	)
+	if !errors.Is(err, threatintel.ErrConflict) {
+		t.Errorf("duplicate: got %v, want ErrConflict", err)
+	}
+	for _, req := range []oapi.CreateThreatIndicatorRequest{
+		{Type: oapi.ThreatIndicatorTypeIp, Value: "evil.example"},
+		{Type: oapi.ThreatIndicatorTypeIp, Value: "203.0.113.7", Confidence: intPtr(101)},
+		{Type: oapi.ThreatIndicatorTypeIp, Value: "203.0.113.7", Source: strPtr("")},
+	} {
+		if _, err := svc.Create(ctx, orgID, req); !errors.Is(err, threatintel.ErrInvalid) {
+			t.Errorf("Create(%+v): got %v, want ErrInvalid", req, err)
+		}
+	}
+}
+
+func TestUpdateIndicator(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	ind, err := svc.Create(ctx, orgID, oapi.CreateThreatIndicatorRequest{
+		Type: oapi.ThreatIndicatorTypeIp, Value: "203.0.113.7", ExpiresAt: &expiry, Description: strPtr("C2"),
+	})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	tlp := oapi.Red
+	updated, err := svc.Update(ctx, orgID, ind.Id, oapi.UpdateThreatIndicatorRequest{
+		Confidence:  intPtr(90),
+		Tlp:         &tlp,
+		Tags:        &[]string{},
+		Description: strPtr(""),
+		ClearExpiry: func() *bool { b := true; return &b }(),
+	}, []int64{ind.Version})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if updated.Confidence != 90 || updated.Tlp != oapi.Red || updated.Description != nil ||
+		updated.ExpiresAt != nil || updated.Version != ind.Version+1 {
+		t.Errorf("updated: got %+v", updated)
+	}
+
+	_, err = svc.Update(ctx, orgID, ind.Id, oapi.UpdateThreatIndicatorRequest{}, []int64{ind.Version})
+	if !errors.Is(err, threatintel.ErrPreconditionFailed) {
+		t.Errorf("stale If-Match: got %v, want ErrPreconditionFailed", err)
+	}
+	_, err = svc.Update(ctx, orgID, uuid.New(), oapi.UpdateThreatIndicatorRequest{}, nil)
+	if !errors.Is(err, threatintel.ErrNotFound) {
+		t.Errorf("unknown indicator: got %v, want ErrNotFound", err)
+	}
+}
+
+func TestImportIndicators_UpsertsAndReports(t *testing.T) {
+	svc, _, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	if _, err := svc.Create(ctx, orgID, oapi.CreateThreatIndicatorRequest{Type: oapi.ThreatIndicatorTypeIp, Value: "203.0.113.7"}); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	res, err := svc.Import(ctx, orgID, oapi.ImportThreatIndicatorsRequest{
+		Format:  oapi.Csv,
+		Content: "value,confidence\n203.0.113.7,90\nevil.example,\nEVIL.example,70\nnot a value,\n",
+		Source:  strPtr("feed"),
+		Tags:    &[]string{"imported"},
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if res.Created != 1 || res.Updated != 1 || len(res.Errors) != 1 || res.Errors[0].Entry != "line 5" {
+		t.Errorf("result: got %+v", res)
+	}
+
+	list, err := svc.List(ctx, orgID, threatintel.Filter{Tag: strPtr("imported"), Limit: 10})
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("listed: got %+v", list)
+	}
+	for _, ind := range list {
+		switch ind.Value {
+		case "203.0.113.7":
+			if ind.Confidence != 90 || ind.Source != "feed" || ind.Version != 2 {
+				t.Errorf("updated indicator: got %+v", ind)
+			}
+		case "evil.example":
+			if ind.Confidence != 70 {
+				t.Errorf("imported twice: got %+v", ind)
+			}
+		}
+	}
+
+	if _, err := svc.Import(ctx, orgID, oapi.ImportThreatIndicatorsRequest{
+		Format: oapi.Stix, Content: "{}",
+	}); !errors.Is(err, threatintel.ErrInvalid) {
+		t.Errorf("unreadable import: got %v, want ErrInvalid", err)
+	}
+}
+
+func TestMatcher_ReloadsChangedIndicators(t *testing.T) {
+	svc, db, orgID := newSeededService(t)
+	ctx := tenant.WithOrg(context.Background(), orgID)
+	m := threatintel.NewMatcher(threatintel.NewRepo(db), tx.NewManager(db), discard(), time.Nanosecond)
+	event := func() *ocsf.NetworkActivity {
+		return &ocsf.NetworkActivity{Network: ocsf.Network{DstEndpoint: &ocsf.Endpoint{IP: "198.51.100.9"}}}
+	}
+
+	ev := event()
+	m.Enrich(context.Background(), orgID, ev)
+	if len(ev.Enrichments) != 0 {
+		t.Fatalf("no indicators: got %+v", ev.Enrichments)
+	}
+
+	ind, err := svc.Create(
+		ctx,
+		orgID,
+		oapi.CreateThreatIndicatorRequest{Type: oapi.ThreatIndicatorTypeCidr, Value: "198.51.100.0/24"},
+	)
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	ev = event()
+	m.Enrich(context.Background(), orgID, ev)
+	if len(ev.Enrichments) != 1 || ev.Enrichments[0].Data["indicator_id"] != ind.Id.String() {
+		t.Fatalf("after create: got %+v", ev.Enrichments)
+	}
+
+	if err := svc.Delete(ctx, orgID, ind.Id); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	ev = event()
+	m.Enrich(context.Background(), orgID, ev)
+	if len(ev.Enrichments) != 0 {
+		t.Errorf("after delete: got %+v", ev.Enrichments)
+	}
+}
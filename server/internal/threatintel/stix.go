@@ -0,0 +1,215 @@
+package threatintel
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/luketeo/horizon/generated/oapi"
+)
+
+// stixTLP maps the IDs of the TLP marking definitions the STIX 2.1 standard
+// and its TLP 2.0 extension predefine to their levels, so bundles need not
+// include them.
+var stixTLP = map[string]oapi.Tlp{
+	// TLP 1.0, defined in STIX 2.1 itself.
+	"marking-definition--613f2e26-407d-48c7-9eca-b8e91df99dc9": oapi.Clear,
+	"marking-definition--34098fce-860f-48ae-8e50-ebd3cc5e41da": oapi.Green,
+	"marking-definition--f88d31f6-486f-44da-b317-01333bde0b82": oapi.Amber,
+	"marking-definition--5e57c739-391a-4eb3-b6be-7d15ca92d5ed": oapi.Red,
+	// TLP 2.0.
+	"marking-definition--94868c89-83c2-464b-929b-a1a8aa3c8487": oapi.Clear,
+	"marking-definition--bab4a63c-aed9-4cf5-a766-dfca5abac2bb": oapi.Green,
+	"marking-definition--55d920b0-5e8b-4f79-9ee9-91f868d9b421": oapi.Amber,
+	"marking-definition--939a9414-2ddd-4d32-a0cd-375ea402b003": oapi.AmberStrict,
+	"marking-definition--e828b379-4e03-4974-9ac4-e53a884c97c1": oapi.Red,
+}
+
+// stixObject holds the properties read of the objects in a bundle.
+type stixObject struct {
+	Type              string   `json:"type"`
+	ID                string   `json:"id"`
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Pattern           string   `json:"pattern"`
+	PatternType       string   `json:"pattern_type"`
+	Confidence        *int     `json:"confidence"`
+	Labels            []string `json:"labels"`
+	ValidUntil        string   `json:"valid_until"`
+	Revoked           bool     `json:"revoked"`
+	CreatedByRef      string   `json:"created_by_ref"`
+	ObjectMarkingRefs []string `json:"object_marking_refs"`
+	Definition        struct {
+		TLP string `json:"tlp"`
+	} `json:"definition"`
+}
+
+// parseSTIX reads a STIX 2.1 bundle. Its indicators with STIX patterns
+// comparing supported observables for equality, alone or joined by OR, are
+// read, each observable compared as an indicator; other indicators and
+// objects are skipped. An indicator's source is the identity that created it,
+// and its TLP level that of its marking.
+func parseSTIX(content string) (Parsed, error) {
+	var p Parsed
+	var bundle struct {
+		Type    string            `json:"type"`
+		Objects []json.RawMessage `json:"objects"`
+	}
+	if err := json.Unmarshal([]byte(content), &bundle); err != nil {
+		return p, fmt.Errorf("%w: reading STIX bundle: %w", ErrInvalid, err)
+	}
+	if bundle.Type != "bundle" {
+		return p, fmt.Errorf("%w: not a STIX bundle", ErrInvalid)
+	}
+
+	objects := make([]stixObject, len(bundle.Objects))
+	identities := make(map[string]string)
+	markings := make(map[string]oapi.Tlp)
+	for i, raw := range bundle.Objects {
+		if err := json.Unmarshal(raw, &objects[i]); err != nil {
+			return p, fmt.Errorf("%w: reading STIX object %d: %w", ErrInvalid, i, err)
+		}
+		switch o := objects[i]; o.Type {
+		case "identity":
+			identities[o.ID] = o.Name
+		case "marking-definition":
+			if tlp, ok := parseTLP(o.Definition.TLP); ok {
+				markings[o.ID] = tlp
+			} else if tlp, ok := parseTLP(o.Name); ok && strings.HasPrefix(strings.ToLower(o.Name), "tlp:") {
+				markings[o.ID] = tlp
+			}
+		}
+	}
+
+	for _, o := range objects {
+		switch o.Type {
+		case "indicator":
+		case "identity", "marking-definition":
+			continue
+		default:
+			p.Skipped++
+			continue
+		}
+		if o.Revoked || o.PatternType != "stix" {
+			p.Skipped++
+			continue
+		}
+		observables, ok := stixObservables(o.Pattern)
+		if !ok {
+			p.Skipped++
+			continue
+		}
+
+		base := Draft{
+			Source:     identities[o.CreatedByRef],
+			Confidence: o.Confidence,
+			Tags:       o.Labels,
+		}
+		if o.ID != "" {
+			base.ExternalID = &o.ID
+		}
+		switch {
+		case o.Description != "":
+			base.Description = &o.Description
+		case o.Name != "":
+			base.Description = &o.Name
+		}
+		for _, ref := range o.ObjectMarkingRefs {
+			if tlp, ok := stixTLP[ref]; ok {
+				base.TLP = tlp
+				break
+			}
+			if tlp, ok := markings[ref]; ok {
+				base.TLP = tlp
+				break
+			}
+		}
+		if o.ValidUntil != "" {
+			at, err := time.Parse(time.RFC3339, o.ValidUntil)
+			if err != nil {
+				p.fail(o.ID, fmt.Errorf("%w: valid_until %q is not a STIX timestamp", ErrInvalid, o.ValidUntil))
+				continue
+			}
+			base.ExpiresAt = &at
+		}
+		for _, obs := range observables {
+			d := base
+			d.Type, d.Value = obs.typ, obs.value
+			p.add(o.ID, d)
+		}
+	}
+	return p, nil
+}
+
+// stixComparison matches a comparison of an object path for equality with a
+// string, capturing the object type, property and string.
+var stixComparison = regexp.MustCompile(`([a-z0-9-]+):([A-Za-z0-9_.'-]+)\s*=\s*'((?:[^'\\]|\\.)*)'`)
+
+// stixJoins matches what may join the comparisons of a pattern that is a set
+// of alternatives.
+var stixJoins = regexp.MustCompile(`^[\s\[\]()]*(?:OR[\s\[\]()]+)*$`)
+
+// stixHashes lists the file hash properties read, as written in patterns.
+var stixHashes = []string{
+	"hashes.md5", "hashes.'md5'",
+	"hashes.'sha-1'", "hashes.sha1", "hashes.'sha1'",
+	"hashes.'sha-256'", "hashes.sha256", "hashes.'sha256'",
+	"hashes.'sha-512'", "hashes.sha512", "hashes.'sha512'",
+}
+
+// stixObservables reads the observables a pattern compares for equality,
+// each of which is sufficient for the pattern to match. Reports false when
+// the pattern is anything else, or compares none of the supported
+// observables.
+func stixObservables(pattern string) ([]key, bool) {
+	matches := stixComparison.FindAllStringSubmatchIndex(pattern, -1)
+	if len(matches) == 0 {
+		return nil, false
+	}
+	var rest strings.Builder
+	last := 0
+	var found []key
+	for _, m := range matches {
+		rest.WriteString(pattern[last:m[0]])
+		rest.WriteString(" ")
+		last = m[1]
+		object, property := pattern[m[2]:m[3]], strings.ToLower(pattern[m[4]:m[5]])
+		value := strings.NewReplacer(`\'`, `'`, `\\`, `\`).Replace(pattern[m[6]:m[7]])
+		if t, ok := stixType(object, property, value); ok {
+			found = append(found, key{t, value})
+		}
+	}
+	rest.WriteString(pattern[last:])
+	if !stixJoins.MatchString(rest.String()) || len(found) == 0 {
+		return nil, false
+	}
+	return found, true
+}
+
+// stixType returns the indicator type of an observable's property, when it is
+// one indicators are kept of.
+func stixType(object, property, value string) (oapi.ThreatIndicatorType, bool) {
+	switch {
+	case (object == "ipv4-addr" || object == "ipv6-addr") && property == "value":
+		if strings.Contains(value, "/") {
+			return oapi.ThreatIndicatorTypeCidr, true
+		}
+		return oapi.ThreatIndicatorTypeIp, true
+	case object == "domain-name" && property == "value":
+		return oapi.ThreatIndicatorTypeDomain, true
+	case object == "url" && property == "value":
+		return oapi.ThreatIndicatorTypeUrl, true
+	case object == "email-addr" && property == "value",
+		object == "email-message" && property == "from_ref.value":
+		return oapi.ThreatIndicatorTypeEmail, true
+	case object == "file":
+		for _, h := range stixHashes {
+			if property == h {
+				return oapi.ThreatIndicatorTypeFileHash, true
+			}
+		}
+	}
+	return "", false
+}
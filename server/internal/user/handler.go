@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"errors"
 
 	"github.com/luketeo/horizon/generated/oapi"
 	"github.com/luketeo/horizon/internal/platform/httpx"
@@ -19,9 +20,10 @@ func NewHandler(svc *Service) *Handler {
 }
 
 // GetUsersMe returns the authenticated user's profile, upserting on first access.
+// The ETag tracks the profile version; last_login_at is not covered by it.
 func (h *Handler) GetUsersMe(
 	ctx context.Context,
-	_ oapi.GetUsersMeRequestObject,
+	request oapi.GetUsersMeRequestObject,
 ) (oapi.GetUsersMeResponseObject, error) {
 	clerkUser, ok := middleware.GetClerkUserFromContext(ctx)
 	if !ok {
@@ -36,7 +38,14 @@ func (h *Handler) GetUsersMe(
 	if err != nil {
 		return nil, err
 	}
-	return oapi.GetUsersMe200JSONResponse(u), nil
+	etag := httpx.ETag(u.Version)
+	if httpx.NoneMatch(request.Params.IfNoneMatch, etag) {
+		return oapi.GetUsersMe304Response{Headers: oapi.NotModifiedResponseHeaders{ETag: etag}}, nil
+	}
+	return oapi.GetUsersMe200JSONResponse{
+		Body:    u,
+		Headers: oapi.GetUsersMe200ResponseHeaders{ETag: etag},
+	}, nil
 }
 
 // UpdateUsersMe updates the authenticated user's mutable profile fields.
@@ -53,14 +62,39 @@ func (h *Handler) UpdateUsersMe(
 		}, nil
 	}
 
+	ifMatch, ok := httpx.IfMatchVersions(request.Params.IfMatch)
+	if !ok {
+		return preconditionFailed(), nil
+	}
+
 	_, userID, err := h.svc.GetOrCreateUser(ctx, clerkUser)
 	if err != nil {
 		return nil, err
 	}
 
-	u, err := h.svc.UpdateUser(ctx, userID, request.Body.FirstName, request.Body.LastName)
+	u, err := h.svc.UpdateUser(
+		ctx,
+		userID,
+		request.Body.FirstName,
+		request.Body.LastName,
+		ifMatch,
+	)
 	if err != nil {
+		if errors.Is(err, ErrPreconditionFailed) {
+			return preconditionFailed(), nil
+		}
 		return nil, err
 	}
-	return oapi.UpdateUsersMe200JSONResponse(u), nil
+	return oapi.UpdateUsersMe200JSONResponse{
+		Body:    u,
+		Headers: oapi.UpdateUsersMe200ResponseHeaders{ETag: httpx.ETag(u.Version)},
+	}, nil
+}
+
+func preconditionFailed() oapi.UpdateUsersMe412ApplicationProblemPlusJSONResponse {
+	return oapi.UpdateUsersMe412ApplicationProblemPlusJSONResponse{
+		PreconditionFailedApplicationProblemPlusJSONResponse: oapi.PreconditionFailedApplicationProblemPlusJSONResponse(
+			httpx.Prob(412, "Precondition Failed", "Profile was modified since it was last read"),
+		),
+	}
 }
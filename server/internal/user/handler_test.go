@@ -8,7 +8,9 @@ import (
 
 	"github.com/luketeo/horizon/generated/oapi"
 	"github.com/luketeo/horizon/internal/platform/middleware"
+	"github.com/luketeo/horizon/internal/platform/outbox"
 	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -16,7 +18,12 @@ func newUserHandler(t *testing.T) *user.Handler {
 	t.Helper()
 	db := testhelper.DB(t)
 	testhelper.Reset(t, db)
-	svc := user.NewService(user.NewRepo(db), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	svc := user.NewService(
+		user.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
 	return user.NewHandler(svc)
 }
 
@@ -51,8 +58,8 @@ func TestGetUsersMe_ReturnsUpsertedUser(t *testing.T) {
 	if !is {
 		t.Fatalf("want 200 response, got %T", resp)
 	}
-	if ok200.Email != "gina@example.com" {
-		t.Errorf("email: want gina@example.com, got %q", ok200.Email)
+	if ok200.Body.Email != "gina@example.com" {
+		t.Errorf("email: want gina@example.com, got %q", ok200.Body.Email)
 	}
 }
 
@@ -94,10 +101,10 @@ func TestUpdateUsersMe_AppliesPatch(t *testing.T) {
 	if !is {
 		t.Fatalf("want 200 response, got %T", resp)
 	}
-	if ok200.FirstName == nil || *ok200.FirstName != "Hank" {
-		t.Errorf("first_name: want Hank, got %v", ok200.FirstName)
+	if ok200.Body.FirstName == nil || *ok200.Body.FirstName != "Hank" {
+		t.Errorf("first_name: want Hank, got %v", ok200.Body.FirstName)
 	}
-	if ok200.LastName == nil || *ok200.LastName != "Hopkins" {
-		t.Errorf("last_name: want Hopkins, got %v", ok200.LastName)
+	if ok200.Body.LastName == nil || *ok200.Body.LastName != "Hopkins" {
+		t.Errorf("last_name: want Hopkins, got %v", ok200.Body.LastName)
 	}
 }
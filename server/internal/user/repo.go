@@ -2,7 +2,6 @@ package user
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 
@@ -14,15 +13,17 @@ import (
 	"github.com/luketeo/horizon/generated/horizon/public/model"
 	"github.com/luketeo/horizon/generated/horizon/public/table"
 	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/tx"
 )
 
-// Repo owns the user-table SQL and row→DTO mapping.
+// Repo owns the user-table SQL and row→DTO mapping. Statements join the
+// transaction on ctx, if any.
 type Repo struct {
-	db *sql.DB
+	db qrm.DB
 }
 
 // NewRepo builds a Repo backed by the given database handle.
-func NewRepo(db *sql.DB) *Repo {
+func NewRepo(db qrm.DB) *Repo {
 	return &Repo{db: db}
 }
 
@@ -35,12 +36,28 @@ func toOapi(m model.Users) oapi.User {
 		LastName:    m.LastName,
 		AvatarUrl:   m.AvatarURL,
 		LastLoginAt: m.LastLoginAt,
+		Version:     m.Version,
 		CreatedAt:   m.CreatedAt,
 		UpdatedAt:   m.UpdatedAt,
 	}
 }
 
+// versionIn restricts a statement to rows whose version is one of versions.
+// A nil slice means the caller sent no precondition.
+func versionIn(versions []int64) postgres.BoolExpression {
+	if versions == nil {
+		return postgres.Bool(true)
+	}
+	exprs := make([]postgres.Expression, 0, len(versions))
+	for _, v := range versions {
+		exprs = append(exprs, postgres.Int64(v))
+	}
+	return table.Users.Version.IN(exprs...)
+}
+
 // Upsert inserts or updates a user keyed on clerk_id and returns the resulting row.
+// The version is bumped only when a synced profile field actually changes, so
+// routine logins do not invalidate ETags held by clients.
 func (r *Repo) Upsert(
 	ctx context.Context,
 	clerkID, email string,
@@ -66,6 +83,12 @@ func (r *Repo) Upsert(
 		ON_CONFLICT(table.Users.ClerkID).
 		DO_UPDATE(
 			postgres.SET(
+				table.Users.Version.SET(postgres.IntExp(
+					postgres.CASE().
+						WHEN(profileChanged()).
+						THEN(table.Users.Version.ADD(postgres.Int(1))).
+						ELSE(table.Users.Version),
+				)),
 				table.Users.Email.SET(table.Users.EXCLUDED.Email),
 				table.Users.FirstName.SET(postgres.StringExp(postgres.COALESCE(
 					table.Users.EXCLUDED.FirstName, table.Users.FirstName,
@@ -83,17 +106,31 @@ func (r *Repo) Upsert(
 		RETURNING(table.Users.AllColumns)
 
 	var out model.Users
-	if err := stmt.QueryContext(ctx, r.db, &out); err != nil {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &out); err != nil {
 		return oapi.User{}, fmt.Errorf("upserting user: %w", err)
 	}
 	return toOapi(out), nil
 }
 
-// Update sets mutable profile fields on the user. Returns ErrNotFound if no row matches.
+// profileChanged reports, inside an upsert, whether the incoming Clerk profile
+// differs from the stored row once nil fields are coalesced.
+func profileChanged() postgres.BoolExpression {
+	u, ex := table.Users, table.Users.EXCLUDED
+	return ex.Email.IS_DISTINCT_FROM(u.Email).
+		OR(postgres.StringExp(postgres.COALESCE(ex.FirstName, u.FirstName)).IS_DISTINCT_FROM(u.FirstName)).
+		OR(postgres.StringExp(postgres.COALESCE(ex.LastName, u.LastName)).IS_DISTINCT_FROM(u.LastName)).
+		OR(postgres.StringExp(postgres.COALESCE(ex.AvatarURL, u.AvatarURL)).IS_DISTINCT_FROM(u.AvatarURL))
+}
+
+// Update sets mutable profile fields on the user and bumps its version. When
+// ifMatch is non-nil the update only applies if the current version is listed;
+// otherwise ErrPreconditionFailed is returned. Returns ErrNotFound if no row
+// matches the id.
 func (r *Repo) Update(
 	ctx context.Context,
 	userID uuid.UUID,
 	firstName, lastName *string,
+	ifMatch []int64,
 ) (oapi.User, error) {
 	var (
 		firstExp postgres.StringExpression = table.Users.FirstName
@@ -107,14 +144,25 @@ func (r *Repo) Update(
 	}
 
 	stmt := table.Users.
-		UPDATE(table.Users.FirstName, table.Users.LastName, table.Users.UpdatedAt).
-		SET(firstExp, lastExp, postgres.NOW()).
-		WHERE(table.Users.ID.EQ(postgres.UUID(userID))).
+		UPDATE(
+			table.Users.FirstName,
+			table.Users.LastName,
+			table.Users.Version,
+			table.Users.UpdatedAt,
+		).
+		SET(firstExp, lastExp, table.Users.Version.ADD(postgres.Int(1)), postgres.NOW()).
+		WHERE(
+			table.Users.ID.EQ(postgres.UUID(userID)).
+				AND(versionIn(ifMatch)),
+		).
 		RETURNING(table.Users.AllColumns)
 
 	var out model.Users
-	if err := stmt.QueryContext(ctx, r.db, &out); err != nil {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &out); err != nil {
 		if errors.Is(err, qrm.ErrNoRows) {
+			if ifMatch != nil {
+				return oapi.User{}, r.missOrStale(ctx, userID)
+			}
 			return oapi.User{}, ErrNotFound
 		}
 		return oapi.User{}, fmt.Errorf("updating user: %w", err)
@@ -122,6 +170,25 @@ func (r *Repo) Update(
 	return toOapi(out), nil
 }
 
+// missOrStale explains a conditional update that matched no row: the user is
+// either gone (ErrNotFound) or has moved past the caller's version.
+func (r *Repo) missOrStale(ctx context.Context, userID uuid.UUID) error {
+	stmt := postgres.
+		SELECT(table.Users.ID).
+		FROM(table.Users).
+		WHERE(table.Users.ID.EQ(postgres.UUID(userID))).
+		LIMIT(1)
+
+	var out model.Users
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &out); err != nil {
+		if errors.Is(err, qrm.ErrNoRows) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("checking user version: %w", err)
+	}
+	return ErrPreconditionFailed
+}
+
 // GetIDByClerkID resolves the internal UUID for a Clerk user id.
 func (r *Repo) GetIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error) {
 	stmt := postgres.
@@ -131,7 +198,7 @@ func (r *Repo) GetIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, e
 		LIMIT(1)
 
 	var out model.Users
-	if err := stmt.QueryContext(ctx, r.db, &out); err != nil {
+	if err := stmt.QueryContext(ctx, tx.Executor(ctx, r.db), &out); err != nil {
 		if errors.Is(err, qrm.ErrNoRows) {
 			return uuid.Nil, ErrNotFound
 		}
@@ -10,20 +10,32 @@ import (
 	"github.com/google/uuid"
 
 	"github.com/luketeo/horizon/generated/oapi"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
 )
 
 // ErrNotFound is returned when a user record does not exist.
 var ErrNotFound = errors.New("user not found")
 
+// ErrPreconditionFailed is returned when an If-Match version no longer matches.
+var ErrPreconditionFailed = errors.New("user version mismatch")
+
+// EventUpdated is appended to the outbox when a user edits their profile. The
+// user is the aggregate and the payload is the updated oapi.User.
+const EventUpdated = "user.updated"
+
 // Service coordinates user identity against Clerk and the local users table.
 type Service struct {
 	repo   *Repo
+	tx     *tx.Manager
+	events *outbox.Repo
 	logger *slog.Logger
 }
 
-// NewService wires a Service with its repo and structured logger.
-func NewService(repo *Repo, logger *slog.Logger) *Service {
-	return &Service{repo: repo, logger: logger}
+// NewService wires a Service with its repo, transaction manager, outbox and
+// structured logger.
+func NewService(repo *Repo, txm *tx.Manager, events *outbox.Repo, logger *slog.Logger) *Service {
+	return &Service{repo: repo, tx: txm, events: events, logger: logger}
 }
 
 // primaryEmail selects the Clerk user's primary email, falling back to the first listed.
@@ -52,30 +64,58 @@ func (s *Service) GetOrCreateUser(
 		imgURL = clerkUser.ImageURL
 	}
 
-	u, err := s.repo.Upsert(
-		ctx,
-		clerkUser.ID,
-		email,
-		clerkUser.FirstName,
-		clerkUser.LastName,
-		imgURL,
-	)
+	var u oapi.User
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		u, err = s.repo.Upsert(
+			ctx,
+			clerkUser.ID,
+			email,
+			clerkUser.FirstName,
+			clerkUser.LastName,
+			imgURL,
+		)
+		return err
+	})
 	if err != nil {
 		return oapi.User{}, uuid.Nil, err
 	}
 	return u, u.Id, nil
 }
 
-// UpdateUser updates mutable profile fields on the authenticated user.
+// UpdateUser updates mutable profile fields on the authenticated user. A
+// non-nil ifMatch makes the update conditional on the row version (see
+// httpx.IfMatchVersions); a stale version yields ErrPreconditionFailed. The
+// update and its EventUpdated share one transaction.
 func (s *Service) UpdateUser(
 	ctx context.Context,
 	userID uuid.UUID,
 	firstName, lastName *string,
+	ifMatch []int64,
 ) (oapi.User, error) {
-	return s.repo.Update(ctx, userID, firstName, lastName)
+	var u oapi.User
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		if u, err = s.repo.Update(ctx, userID, firstName, lastName, ifMatch); err != nil {
+			return err
+		}
+		return s.events.Append(ctx, outbox.Draft{
+			AggregateType: "user",
+			AggregateID:   userID.String(),
+			Type:          EventUpdated,
+			Payload:       u,
+		})
+	})
+	return u, err
 }
 
 // GetUserIDByClerkID returns the internal UUID for a given Clerk id.
 func (s *Service) GetUserIDByClerkID(ctx context.Context, clerkID string) (uuid.UUID, error) {
-	return s.repo.GetIDByClerkID(ctx, clerkID)
+	var id uuid.UUID
+	err := s.tx.RunInTx(ctx, nil, func(ctx context.Context) error {
+		var err error
+		id, err = s.repo.GetIDByClerkID(ctx, clerkID)
+		return err
+	})
+	return id, err
 }
@@ -11,7 +11,9 @@ import (
 	"github.com/clerk/clerk-sdk-go/v2"
 	"github.com/google/uuid"
 
+	"github.com/luketeo/horizon/internal/platform/outbox"
 	"github.com/luketeo/horizon/internal/platform/testhelper"
+	"github.com/luketeo/horizon/internal/platform/tx"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -46,7 +48,12 @@ func newUserService(t *testing.T) *user.Service {
 	t.Helper()
 	db := testhelper.DB(t)
 	testhelper.Reset(t, db)
-	return user.NewService(user.NewRepo(db), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	return user.NewService(
+		user.NewRepo(db),
+		tx.NewManager(db),
+		outbox.NewRepo(db),
+		slog.New(slog.NewTextHandler(io.Discard, nil)),
+	)
 }
 
 func TestGetOrCreateUser_InsertsNewUser(t *testing.T) {
@@ -188,7 +195,7 @@ func TestUpdateUser_SetsProvidedFields(t *testing.T) {
 		t.Fatalf("GetOrCreateUser: %v", err)
 	}
 
-	updated, err := svc.UpdateUser(ctx, id, strPtr("Daniel"), strPtr("Dobson"))
+	updated, err := svc.UpdateUser(ctx, id, strPtr("Daniel"), strPtr("Dobson"), nil)
 	if err != nil {
 		t.Fatalf("UpdateUser: %v", err)
 	}
@@ -216,7 +223,7 @@ func TestUpdateUser_CoalescesNilFields(t *testing.T) {
 		t.Fatalf("GetOrCreateUser: %v", err)
 	}
 
-	updated, err := svc.UpdateUser(ctx, id, nil, strPtr("Ellis"))
+	updated, err := svc.UpdateUser(ctx, id, nil, strPtr("Ellis"), nil)
 	if err != nil {
 		t.Fatalf("UpdateUser: %v", err)
 	}
@@ -233,12 +240,58 @@ func TestUpdateUser_NotFound(t *testing.T) {
 	ctx := context.Background()
 
 	missingID := mustParseUUID(t, "00000000-0000-0000-0000-000000000001")
-	_, err := svc.UpdateUser(ctx, missingID, strPtr("Ghost"), nil)
+	_, err := svc.UpdateUser(ctx, missingID, strPtr("Ghost"), nil, nil)
 	if !errors.Is(err, user.ErrNotFound) {
 		t.Fatalf("UpdateUser missing row: want ErrNotFound, got %v", err)
 	}
 }
 
+func TestUpdateUser_StaleIfMatchReturnsPreconditionFailed(t *testing.T) {
+	svc := newUserService(t)
+	ctx := context.Background()
+
+	clerkU := fakeClerkUser("user_stale_1", "ivy@example.com", strPtr("Ivy"), nil, nil)
+	u, id, err := svc.GetOrCreateUser(ctx, clerkU)
+	if err != nil {
+		t.Fatalf("GetOrCreateUser: %v", err)
+	}
+
+	if _, err := svc.UpdateUser(ctx, id, strPtr("Iris"), nil, []int64{u.Version}); err != nil {
+		t.Fatalf("first UpdateUser: %v", err)
+	}
+	_, err = svc.UpdateUser(ctx, id, strPtr("Isla"), nil, []int64{u.Version})
+	if !errors.Is(err, user.ErrPreconditionFailed) {
+		t.Fatalf("want ErrPreconditionFailed, got %v", err)
+	}
+}
+
+func TestGetOrCreateUser_VersionOnlyBumpsOnProfileChange(t *testing.T) {
+	svc := newUserService(t)
+	ctx := context.Background()
+
+	clerkU := fakeClerkUser("user_version_1", "jo@example.com", strPtr("Jo"), nil, nil)
+	first, _, err := svc.GetOrCreateUser(ctx, clerkU)
+	if err != nil {
+		t.Fatalf("first GetOrCreateUser: %v", err)
+	}
+	second, _, err := svc.GetOrCreateUser(ctx, clerkU)
+	if err != nil {
+		t.Fatalf("second GetOrCreateUser: %v", err)
+	}
+	if second.Version != first.Version {
+		t.Errorf("repeat login bumped version: %d -> %d", first.Version, second.Version)
+	}
+
+	renamed := fakeClerkUser("user_version_1", "jo@example.com", strPtr("Joanna"), nil, nil)
+	third, _, err := svc.GetOrCreateUser(ctx, renamed)
+	if err != nil {
+		t.Fatalf("third GetOrCreateUser: %v", err)
+	}
+	if third.Version != first.Version+1 {
+		t.Errorf("profile change: want version %d, got %d", first.Version+1, third.Version)
+	}
+}
+
 func TestGetUserIDByClerkID_Roundtrip(t *testing.T) {
 	svc := newUserService(t)
 	ctx := context.Background()
@@ -1,6 +1,8 @@
 // Package web aggregates the domain-owned HTTP handlers into a single value
-// that satisfies oapi.StrictServerInterface. Each domain (user, org, apikey)
-// owns its own handler under internal/<domain>; this type forwards to them.
+// that satisfies oapi.StrictServerInterface. Each domain (user, org, apikey,
+// source, pattern, normalization, contentpack, replay, threatintel, taxii,
+// entity, redaction) owns its own handler under internal/<domain>, and platform admin
+// endpoints live with their subsystem (jobs); this type forwards to them.
 package web
 
 import (
@@ -8,8 +10,24 @@ import (
 
 	"github.com/luketeo/horizon/generated/oapi"
 	"github.com/luketeo/horizon/internal/apikey"
+	"github.com/luketeo/horizon/internal/archive"
 	"github.com/luketeo/horizon/internal/config"
+	"github.com/luketeo/horizon/internal/contentpack"
+	"github.com/luketeo/horizon/internal/entity"
+	"github.com/luketeo/horizon/internal/eventstore"
+	"github.com/luketeo/horizon/internal/ingest"
+	"github.com/luketeo/horizon/internal/normalization"
 	"github.com/luketeo/horizon/internal/org"
+	"github.com/luketeo/horizon/internal/pattern"
+	"github.com/luketeo/horizon/internal/pipeline"
+	"github.com/luketeo/horizon/internal/platform/jobs"
+	"github.com/luketeo/horizon/internal/platform/outbox"
+	"github.com/luketeo/horizon/internal/platform/tx"
+	"github.com/luketeo/horizon/internal/redaction"
+	"github.com/luketeo/horizon/internal/replay"
+	"github.com/luketeo/horizon/internal/source"
+	"github.com/luketeo/horizon/internal/taxii"
+	"github.com/luketeo/horizon/internal/threatintel"
 	"github.com/luketeo/horizon/internal/user"
 )
 
@@ -19,29 +37,77 @@ import (
 type Handler struct {
 	config *config.Config
 
-	userH   *user.Handler
-	orgH    *org.Handler
-	apikeyH *apikey.Handler
+	userH    *user.Handler
+	orgH     *org.Handler
+	apikeyH  *apikey.Handler
+	sourceH  *source.Handler
+	patternH *pattern.Handler
+	mappingH *normalization.Handler
+	packH    *contentpack.Handler
+	replayH  *replay.Handler
+	tiH      *threatintel.Handler
+	taxiiH   *taxii.Handler
+	entityH  *entity.Handler
+	redactH  *redaction.Handler
+	ingestH  *ingest.Handler
+	jobsH    *jobs.Handler
 }
 
 // Compile-time guarantee that every oapi route has a concrete implementation.
 var _ oapi.StrictServerInterface = (*Handler)(nil)
 
 // NewHandler wires up each domain package with its own repo, service, and
-// handler, then composes them into a single aggregator.
-func NewHandler(cfg *config.Config) *Handler {
+// handler, then composes them into a single aggregator. Replays are checked
+// against pipe, the pipeline they run through.
+func NewHandler(cfg *config.Config, pipe *pipeline.Pipeline) *Handler {
 	db := cfg.DB()
 	logger := cfg.Logger()
+	txm := tx.NewManager(db)
+	events := outbox.NewRepo(db)
 
-	userSvc := user.NewService(user.NewRepo(db), logger)
-	orgSvc := org.NewService(org.NewRepo(db), logger)
-	apikeySvc := apikey.NewService(apikey.NewRepo(db), logger)
+	userSvc := user.NewService(user.NewRepo(db), txm, events, logger)
+	orgSvc := org.NewService(org.NewRepo(db), txm, events, logger)
+	apikeySvc := apikey.NewService(apikey.NewRepo(db), txm, events, logger)
+	sourceSvc := source.NewService(source.NewRepo(db), txm, events, logger)
+	patternSvc := pattern.NewService(pattern.NewRepo(db), txm, events, logger)
+	mappingSvc := normalization.NewService(normalization.NewRepo(db), sourceSvc, patternSvc, txm, events, logger)
+	packSvc := contentpack.NewService(contentpack.NewRepo(db), patternSvc, mappingSvc, txm, events, logger)
+	redactionRepo := redaction.NewRepo(db)
+	redactionSvc := redaction.NewService(redactionRepo, txm, events, logger)
+	redactor := redaction.NewRedactor(redactionRepo, txm, logger, oapi.Archive, 0)
+	ingestSvc := ingest.NewService(cfg.IngestQueue(), sourceSvc, redactor, txm, logger)
+	archiveSvc := archive.NewService(cfg.ArchiveStore(), archive.NewRepo(db), txm)
+	tiRepo := threatintel.NewRepo(db)
+	tiSvc := threatintel.NewService(tiRepo, txm, events, logger)
+	taxiiSvc := taxii.NewService(taxii.NewRepo(db), tiSvc, cfg.Secrets(), nil, jobs.NewRepo(db), txm, events, logger)
+	entitySvc := entity.NewService(entity.NewRepo(db), txm, events, logger)
+	replaySvc := replay.NewService(
+		replay.NewRepo(db),
+		archiveSvc,
+		pipe,
+		eventstore.NewStore(db, txm),
+		jobs.NewRepo(db),
+		txm,
+		events,
+		logger,
+	)
 
 	return &Handler{
-		config:  cfg,
-		userH:   user.NewHandler(userSvc),
-		orgH:    org.NewHandler(orgSvc, userSvc),
-		apikeyH: apikey.NewHandler(apikeySvc, userSvc, orgSvc),
+		config:   cfg,
+		userH:    user.NewHandler(userSvc),
+		orgH:     org.NewHandler(orgSvc, userSvc),
+		apikeyH:  apikey.NewHandler(apikeySvc, userSvc, orgSvc),
+		sourceH:  source.NewHandler(sourceSvc, patternSvc, userSvc, orgSvc),
+		patternH: pattern.NewHandler(patternSvc, userSvc, orgSvc),
+		mappingH: normalization.NewHandler(mappingSvc, userSvc, orgSvc),
+		packH:    contentpack.NewHandler(packSvc, userSvc, orgSvc),
+		replayH:  replay.NewHandler(replaySvc, userSvc, orgSvc),
+		tiH:      threatintel.NewHandler(tiSvc, userSvc, orgSvc),
+		taxiiH:   taxii.NewHandler(taxiiSvc, userSvc, orgSvc),
+		entityH:  entity.NewHandler(entitySvc, userSvc, orgSvc),
+		redactH:  redaction.NewHandler(redactionSvc, userSvc, orgSvc),
+		ingestH:  ingest.NewHandler(ingestSvc, apikeySvc, sourceSvc, cfg.Env().IngestMaxBodyBytes(), logger),
+		jobsH:    jobs.NewHandler(jobs.NewRepo(db), txm, cfg.Env().PlatformAdminIDs()),
 	}
 }
 
@@ -98,6 +164,13 @@ func (h *Handler) ListOrganizationMembers(
 	return h.orgH.ListOrganizationMembers(ctx, req)
 }
 
+func (h *Handler) GetOrganizationMember(
+	ctx context.Context,
+	req oapi.GetOrganizationMemberRequestObject,
+) (oapi.GetOrganizationMemberResponseObject, error) {
+	return h.orgH.GetOrganizationMember(ctx, req)
+}
+
 func (h *Handler) AddOrganizationMember(
 	ctx context.Context,
 	req oapi.AddOrganizationMemberRequestObject,
@@ -135,9 +208,505 @@ func (h *Handler) CreateApiKey(
 	return h.apikeyH.CreateApiKey(ctx, req)
 }
 
+func (h *Handler) GetApiKey(
+	ctx context.Context,
+	req oapi.GetApiKeyRequestObject,
+) (oapi.GetApiKeyResponseObject, error) {
+	return h.apikeyH.GetApiKey(ctx, req)
+}
+
 func (h *Handler) RevokeApiKey(
 	ctx context.Context,
 	req oapi.RevokeApiKeyRequestObject,
 ) (oapi.RevokeApiKeyResponseObject, error) {
 	return h.apikeyH.RevokeApiKey(ctx, req)
 }
+
+// ── Source endpoint forwarders ───────────────────────────────────────────────
+
+func (h *Handler) ListSources(
+	ctx context.Context,
+	req oapi.ListSourcesRequestObject,
+) (oapi.ListSourcesResponseObject, error) {
+	return h.sourceH.ListSources(ctx, req)
+}
+
+func (h *Handler) CreateSource(
+	ctx context.Context,
+	req oapi.CreateSourceRequestObject,
+) (oapi.CreateSourceResponseObject, error) {
+	return h.sourceH.CreateSource(ctx, req)
+}
+
+func (h *Handler) GetSource(
+	ctx context.Context,
+	req oapi.GetSourceRequestObject,
+) (oapi.GetSourceResponseObject, error) {
+	return h.sourceH.GetSource(ctx, req)
+}
+
+func (h *Handler) UpdateSource(
+	ctx context.Context,
+	req oapi.UpdateSourceRequestObject,
+) (oapi.UpdateSourceResponseObject, error) {
+	return h.sourceH.UpdateSource(ctx, req)
+}
+
+func (h *Handler) DeleteSource(
+	ctx context.Context,
+	req oapi.DeleteSourceRequestObject,
+) (oapi.DeleteSourceResponseObject, error) {
+	return h.sourceH.DeleteSource(ctx, req)
+}
+
+func (h *Handler) RotateSourceToken(
+	ctx context.Context,
+	req oapi.RotateSourceTokenRequestObject,
+) (oapi.RotateSourceTokenResponseObject, error) {
+	return h.sourceH.RotateSourceToken(ctx, req)
+}
+
+func (h *Handler) GetSourceHealth(
+	ctx context.Context,
+	req oapi.GetSourceHealthRequestObject,
+) (oapi.GetSourceHealthResponseObject, error) {
+	return h.sourceH.GetSourceHealth(ctx, req)
+}
+
+// ── Grok pattern endpoint forwarders ─────────────────────────────────────────
+
+func (h *Handler) ListGrokPatterns(
+	ctx context.Context,
+	req oapi.ListGrokPatternsRequestObject,
+) (oapi.ListGrokPatternsResponseObject, error) {
+	return h.patternH.ListGrokPatterns(ctx, req)
+}
+
+func (h *Handler) CreateGrokPattern(
+	ctx context.Context,
+	req oapi.CreateGrokPatternRequestObject,
+) (oapi.CreateGrokPatternResponseObject, error) {
+	return h.patternH.CreateGrokPattern(ctx, req)
+}
+
+func (h *Handler) GetGrokPattern(
+	ctx context.Context,
+	req oapi.GetGrokPatternRequestObject,
+) (oapi.GetGrokPatternResponseObject, error) {
+	return h.patternH.GetGrokPattern(ctx, req)
+}
+
+func (h *Handler) UpdateGrokPattern(
+	ctx context.Context,
+	req oapi.UpdateGrokPatternRequestObject,
+) (oapi.UpdateGrokPatternResponseObject, error) {
+	return h.patternH.UpdateGrokPattern(ctx, req)
+}
+
+func (h *Handler) DeleteGrokPattern(
+	ctx context.Context,
+	req oapi.DeleteGrokPatternRequestObject,
+) (oapi.DeleteGrokPatternResponseObject, error) {
+	return h.patternH.DeleteGrokPattern(ctx, req)
+}
+
+func (h *Handler) TestGrokPattern(
+	ctx context.Context,
+	req oapi.TestGrokPatternRequestObject,
+) (oapi.TestGrokPatternResponseObject, error) {
+	return h.patternH.TestGrokPattern(ctx, req)
+}
+
+// ── Mapping endpoint forwarders ──────────────────────────────────────────────
+
+func (h *Handler) SetSourceMapping(
+	ctx context.Context,
+	req oapi.SetSourceMappingRequestObject,
+) (oapi.SetSourceMappingResponseObject, error) {
+	return h.mappingH.SetSourceMapping(ctx, req)
+}
+
+func (h *Handler) ClearSourceMapping(
+	ctx context.Context,
+	req oapi.ClearSourceMappingRequestObject,
+) (oapi.ClearSourceMappingResponseObject, error) {
+	return h.mappingH.ClearSourceMapping(ctx, req)
+}
+
+func (h *Handler) ListMappings(
+	ctx context.Context,
+	req oapi.ListMappingsRequestObject,
+) (oapi.ListMappingsResponseObject, error) {
+	return h.mappingH.ListMappings(ctx, req)
+}
+
+func (h *Handler) CreateMapping(
+	ctx context.Context,
+	req oapi.CreateMappingRequestObject,
+) (oapi.CreateMappingResponseObject, error) {
+	return h.mappingH.CreateMapping(ctx, req)
+}
+
+func (h *Handler) PreviewMapping(
+	ctx context.Context,
+	req oapi.PreviewMappingRequestObject,
+) (oapi.PreviewMappingResponseObject, error) {
+	return h.mappingH.PreviewMapping(ctx, req)
+}
+
+func (h *Handler) GetMapping(
+	ctx context.Context,
+	req oapi.GetMappingRequestObject,
+) (oapi.GetMappingResponseObject, error) {
+	return h.mappingH.GetMapping(ctx, req)
+}
+
+func (h *Handler) UpdateMapping(
+	ctx context.Context,
+	req oapi.UpdateMappingRequestObject,
+) (oapi.UpdateMappingResponseObject, error) {
+	return h.mappingH.UpdateMapping(ctx, req)
+}
+
+func (h *Handler) DeleteMapping(
+	ctx context.Context,
+	req oapi.DeleteMappingRequestObject,
+) (oapi.DeleteMappingResponseObject, error) {
+	return h.mappingH.DeleteMapping(ctx, req)
+}
+
+func (h *Handler) ListMappingVersions(
+	ctx context.Context,
+	req oapi.ListMappingVersionsRequestObject,
+) (oapi.ListMappingVersionsResponseObject, error) {
+	return h.mappingH.ListMappingVersions(ctx, req)
+}
+
+func (h *Handler) CreateMappingVersion(
+	ctx context.Context,
+	req oapi.CreateMappingVersionRequestObject,
+) (oapi.CreateMappingVersionResponseObject, error) {
+	return h.mappingH.CreateMappingVersion(ctx, req)
+}
+
+func (h *Handler) GetMappingVersion(
+	ctx context.Context,
+	req oapi.GetMappingVersionRequestObject,
+) (oapi.GetMappingVersionResponseObject, error) {
+	return h.mappingH.GetMappingVersion(ctx, req)
+}
+
+func (h *Handler) TestMappingVersion(
+	ctx context.Context,
+	req oapi.TestMappingVersionRequestObject,
+) (oapi.TestMappingVersionResponseObject, error) {
+	return h.mappingH.TestMappingVersion(ctx, req)
+}
+
+func (h *Handler) ListMappingFixtures(
+	ctx context.Context,
+	req oapi.ListMappingFixturesRequestObject,
+) (oapi.ListMappingFixturesResponseObject, error) {
+	return h.mappingH.ListMappingFixtures(ctx, req)
+}
+
+func (h *Handler) CreateMappingFixture(
+	ctx context.Context,
+	req oapi.CreateMappingFixtureRequestObject,
+) (oapi.CreateMappingFixtureResponseObject, error) {
+	return h.mappingH.CreateMappingFixture(ctx, req)
+}
+
+func (h *Handler) DeleteMappingFixture(
+	ctx context.Context,
+	req oapi.DeleteMappingFixtureRequestObject,
+) (oapi.DeleteMappingFixtureResponseObject, error) {
+	return h.mappingH.DeleteMappingFixture(ctx, req)
+}
+
+// ── Content pack endpoint forwarders ─────────────────────────────────────────
+
+func (h *Handler) ListContentPacks(
+	ctx context.Context,
+	req oapi.ListContentPacksRequestObject,
+) (oapi.ListContentPacksResponseObject, error) {
+	return h.packH.ListContentPacks(ctx, req)
+}
+
+func (h *Handler) GetContentPack(
+	ctx context.Context,
+	req oapi.GetContentPackRequestObject,
+) (oapi.GetContentPackResponseObject, error) {
+	return h.packH.GetContentPack(ctx, req)
+}
+
+func (h *Handler) InstallContentPack(
+	ctx context.Context,
+	req oapi.InstallContentPackRequestObject,
+) (oapi.InstallContentPackResponseObject, error) {
+	return h.packH.InstallContentPack(ctx, req)
+}
+
+// ── Replay endpoint forwarders ───────────────────────────────────────────────
+
+func (h *Handler) ListReplays(
+	ctx context.Context,
+	req oapi.ListReplaysRequestObject,
+) (oapi.ListReplaysResponseObject, error) {
+	return h.replayH.ListReplays(ctx, req)
+}
+
+func (h *Handler) CreateReplay(
+	ctx context.Context,
+	req oapi.CreateReplayRequestObject,
+) (oapi.CreateReplayResponseObject, error) {
+	return h.replayH.CreateReplay(ctx, req)
+}
+
+func (h *Handler) GetReplay(
+	ctx context.Context,
+	req oapi.GetReplayRequestObject,
+) (oapi.GetReplayResponseObject, error) {
+	return h.replayH.GetReplay(ctx, req)
+}
+
+func (h *Handler) CancelReplay(
+	ctx context.Context,
+	req oapi.CancelReplayRequestObject,
+) (oapi.CancelReplayResponseObject, error) {
+	return h.replayH.CancelReplay(ctx, req)
+}
+
+func (h *Handler) ListReplayShadowEvents(
+	ctx context.Context,
+	req oapi.ListReplayShadowEventsRequestObject,
+) (oapi.ListReplayShadowEventsResponseObject, error) {
+	return h.replayH.ListReplayShadowEvents(ctx, req)
+}
+
+// ── Threat intelligence endpoint forwarders ──────────────────────────────────
+
+func (h *Handler) ListThreatIndicators(
+	ctx context.Context,
+	req oapi.ListThreatIndicatorsRequestObject,
+) (oapi.ListThreatIndicatorsResponseObject, error) {
+	return h.tiH.ListThreatIndicators(ctx, req)
+}
+
+func (h *Handler) CreateThreatIndicator(
+	ctx context.Context,
+	req oapi.CreateThreatIndicatorRequestObject,
+) (oapi.CreateThreatIndicatorResponseObject, error) {
+	return h.tiH.CreateThreatIndicator(ctx, req)
+}
+
+func (h *Handler) ImportThreatIndicators(
+	ctx context.Context,
+	req oapi.ImportThreatIndicatorsRequestObject,
+) (oapi.ImportThreatIndicatorsResponseObject, error) {
+	return h.tiH.ImportThreatIndicators(ctx, req)
+}
+
+func (h *Handler) GetThreatIndicator(
+	ctx context.Context,
+	req oapi.GetThreatIndicatorRequestObject,
+) (oapi.GetThreatIndicatorResponseObject, error) {
+	return h.tiH.GetThreatIndicator(ctx, req)
+}
+
+func (h *Handler) UpdateThreatIndicator(
+	ctx context.Context,
+	req oapi.UpdateThreatIndicatorRequestObject,
+) (oapi.UpdateThreatIndicatorResponseObject, error) {
+	return h.tiH.UpdateThreatIndicator(ctx, req)
+}
+
+func (h *Handler) DeleteThreatIndicator(
+	ctx context.Context,
+	req oapi.DeleteThreatIndicatorRequestObject,
+) (oapi.DeleteThreatIndicatorResponseObject, error) {
+	return h.tiH.DeleteThreatIndicator(ctx, req)
+}
+
+// ── TAXII feed endpoint forwarders ───────────────────────────────────────────
+
+func (h *Handler) ListTaxiiFeeds(
+	ctx context.Context,
+	req oapi.ListTaxiiFeedsRequestObject,
+) (oapi.ListTaxiiFeedsResponseObject, error) {
+	return h.taxiiH.ListTaxiiFeeds(ctx, req)
+}
+
+func (h *Handler) CreateTaxiiFeed(
+	ctx context.Context,
+	req oapi.CreateTaxiiFeedRequestObject,
+) (oapi.CreateTaxiiFeedResponseObject, error) {
+	return h.taxiiH.CreateTaxiiFeed(ctx, req)
+}
+
+func (h *Handler) GetTaxiiFeed(
+	ctx context.Context,
+	req oapi.GetTaxiiFeedRequestObject,
+) (oapi.GetTaxiiFeedResponseObject, error) {
+	return h.taxiiH.GetTaxiiFeed(ctx, req)
+}
+
+func (h *Handler) UpdateTaxiiFeed(
+	ctx context.Context,
+	req oapi.UpdateTaxiiFeedRequestObject,
+) (oapi.UpdateTaxiiFeedResponseObject, error) {
+	return h.taxiiH.UpdateTaxiiFeed(ctx, req)
+}
+
+func (h *Handler) DeleteTaxiiFeed(
+	ctx context.Context,
+	req oapi.DeleteTaxiiFeedRequestObject,
+) (oapi.DeleteTaxiiFeedResponseObject, error) {
+	return h.taxiiH.DeleteTaxiiFeed(ctx, req)
+}
+
+func (h *Handler) DiscoverTaxiiCollections(
+	ctx context.Context,
+	req oapi.DiscoverTaxiiCollectionsRequestObject,
+) (oapi.DiscoverTaxiiCollectionsResponseObject, error) {
+	return h.taxiiH.DiscoverTaxiiCollections(ctx, req)
+}
+
+func (h *Handler) SyncTaxiiFeed(
+	ctx context.Context,
+	req oapi.SyncTaxiiFeedRequestObject,
+) (oapi.SyncTaxiiFeedResponseObject, error) {
+	return h.taxiiH.SyncTaxiiFeed(ctx, req)
+}
+
+func (h *Handler) ListTaxiiCollections(
+	ctx context.Context,
+	req oapi.ListTaxiiCollectionsRequestObject,
+) (oapi.ListTaxiiCollectionsResponseObject, error) {
+	return h.taxiiH.ListTaxiiCollections(ctx, req)
+}
+
+func (h *Handler) UpdateTaxiiCollection(
+	ctx context.Context,
+	req oapi.UpdateTaxiiCollectionRequestObject,
+) (oapi.UpdateTaxiiCollectionResponseObject, error) {
+	return h.taxiiH.UpdateTaxiiCollection(ctx, req)
+}
+
+// ── Entity endpoint forwarders ───────────────────────────────────────────────
+
+func (h *Handler) ListEntities(
+	ctx context.Context,
+	req oapi.ListEntitiesRequestObject,
+) (oapi.ListEntitiesResponseObject, error) {
+	return h.entityH.ListEntities(ctx, req)
+}
+
+func (h *Handler) CreateEntity(
+	ctx context.Context,
+	req oapi.CreateEntityRequestObject,
+) (oapi.CreateEntityResponseObject, error) {
+	return h.entityH.CreateEntity(ctx, req)
+}
+
+func (h *Handler) ImportEntities(
+	ctx context.Context,
+	req oapi.ImportEntitiesRequestObject,
+) (oapi.ImportEntitiesResponseObject, error) {
+	return h.entityH.ImportEntities(ctx, req)
+}
+
+func (h *Handler) GetEntity(
+	ctx context.Context,
+	req oapi.GetEntityRequestObject,
+) (oapi.GetEntityResponseObject, error) {
+	return h.entityH.GetEntity(ctx, req)
+}
+
+func (h *Handler) UpdateEntity(
+	ctx context.Context,
+	req oapi.UpdateEntityRequestObject,
+) (oapi.UpdateEntityResponseObject, error) {
+	return h.entityH.UpdateEntity(ctx, req)
+}
+
+func (h *Handler) DeleteEntity(
+	ctx context.Context,
+	req oapi.DeleteEntityRequestObject,
+) (oapi.DeleteEntityResponseObject, error) {
+	return h.entityH.DeleteEntity(ctx, req)
+}
+
+func (h *Handler) GetEntityTimeline(
+	ctx context.Context,
+	req oapi.GetEntityTimelineRequestObject,
+) (oapi.GetEntityTimelineResponseObject, error) {
+	return h.entityH.GetEntityTimeline(ctx, req)
+}
+
+// ── Redaction endpoint forwarders ────────────────────────────────────────────
+
+func (h *Handler) ListRedactionPolicies(
+	ctx context.Context,
+	req oapi.ListRedactionPoliciesRequestObject,
+) (oapi.ListRedactionPoliciesResponseObject, error) {
+	return h.redactH.ListRedactionPolicies(ctx, req)
+}
+
+func (h *Handler) CreateRedactionPolicy(
+	ctx context.Context,
+	req oapi.CreateRedactionPolicyRequestObject,
+) (oapi.CreateRedactionPolicyResponseObject, error) {
+	return h.redactH.CreateRedactionPolicy(ctx, req)
+}
+
+func (h *Handler) GetRedactionPolicy(
+	ctx context.Context,
+	req oapi.GetRedactionPolicyRequestObject,
+) (oapi.GetRedactionPolicyResponseObject, error) {
+	return h.redactH.GetRedactionPolicy(ctx, req)
+}
+
+func (h *Handler) UpdateRedactionPolicy(
+	ctx context.Context,
+	req oapi.UpdateRedactionPolicyRequestObject,
+) (oapi.UpdateRedactionPolicyResponseObject, error) {
+	return h.redactH.UpdateRedactionPolicy(ctx, req)
+}
+
+func (h *Handler) DeleteRedactionPolicy(
+	ctx context.Context,
+	req oapi.DeleteRedactionPolicyRequestObject,
+) (oapi.DeleteRedactionPolicyResponseObject, error) {
+	return h.redactH.DeleteRedactionPolicy(ctx, req)
+}
+
+func (h *Handler) ListSourceRedactions(
+	ctx context.Context,
+	req oapi.ListSourceRedactionsRequestObject,
+) (oapi.ListSourceRedactionsResponseObject, error) {
+	return h.redactH.ListSourceRedactions(ctx, req)
+}
+
+// ── Admin endpoint forwarders ────────────────────────────────────────────────
+
+func (h *Handler) ListJobs(
+	ctx context.Context,
+	req oapi.ListJobsRequestObject,
+) (oapi.ListJobsResponseObject, error) {
+	return h.jobsH.ListJobs(ctx, req)
+}
+
+func (h *Handler) GetJob(
+	ctx context.Context,
+	req oapi.GetJobRequestObject,
+) (oapi.GetJobResponseObject, error) {
+	return h.jobsH.GetJob(ctx, req)
+}
+
+func (h *Handler) RetryJob(
+	ctx context.Context,
+	req oapi.RetryJobRequestObject,
+) (oapi.RetryJobResponseObject, error) {
+	return h.jobsH.RetryJob(ctx, req)
+}
@@ -0,0 +1,9 @@
+package web
+
+import "net/http"
+
+// IngestEvents forwards to the ingest handler. It is mounted outside the
+// Clerk-authenticated group; the ingest handler authenticates API keys itself.
+func (h *Handler) IngestEvents(w http.ResponseWriter, r *http.Request) {
+	h.ingestH.IngestEvents(w, r)
+}
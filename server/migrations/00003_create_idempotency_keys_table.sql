@@ -0,0 +1,31 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Stored responses for requests carrying an Idempotency-Key header. Rows are
+-- scoped to the authenticated principal so two callers can reuse the same key
+-- without colliding. A NULL response_status marks a request still in flight.
+CREATE TABLE idempotency_keys (
+    id                    UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    principal             VARCHAR(255) NOT NULL,
+    idempotency_key       VARCHAR(255) NOT NULL,
+    request_method        VARCHAR(10)  NOT NULL,
+    request_path          TEXT         NOT NULL,
+    request_hash          VARCHAR(64)  NOT NULL,
+    response_status       INTEGER,
+    response_content_type VARCHAR(255),
+    response_body         BYTEA,
+    locked_at             TIMESTAMP WITH TIME ZONE,
+    expires_at            TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at            TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (principal, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS idempotency_keys;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Row versions back the strong ETags served on GET and checked by If-Match.
+-- updated_at is not usable for users: every authenticated request re-syncs the
+-- Clerk profile and bumps it.
+ALTER TABLE users                ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE organizations        ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE organization_members ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE api_keys             ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE api_keys             DROP COLUMN IF EXISTS version;
+ALTER TABLE organization_members DROP COLUMN IF EXISTS version;
+ALTER TABLE organizations        DROP COLUMN IF EXISTS version;
+ALTER TABLE users                DROP COLUMN IF EXISTS version;
+-- +goose StatementEnd
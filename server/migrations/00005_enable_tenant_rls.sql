@@ -0,0 +1,143 @@
+-- +goose Up
+
+-- Row-level security for org-scoped tables.
+--
+-- Request transactions run as horizon_app (via SET LOCAL ROLE) and publish the
+-- caller's scope in the app.user_id / app.org_id settings. Policies then only
+-- expose rows of organisations the user belongs to, narrowed to app.org_id
+-- when set. Without any scope nothing is visible, so a forgotten scope fails
+-- closed. The migrating role owns the tables and is not subject to RLS; it is
+-- the privileged role used for migrations and background jobs.
+
+-- +goose StatementBegin
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_roles WHERE rolname = 'horizon_app') THEN
+        CREATE ROLE horizon_app NOLOGIN;
+    END IF;
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+GRANT horizon_app TO CURRENT_USER;
+GRANT USAGE ON SCHEMA public TO horizon_app;
+GRANT SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public TO horizon_app;
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    GRANT SELECT, INSERT, UPDATE, DELETE ON TABLES TO horizon_app;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION app_current_user_id() RETURNS UUID
+    LANGUAGE sql STABLE
+AS $$ SELECT NULLIF(current_setting('app.user_id', true), '')::UUID $$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION app_current_org_id() RETURNS UUID
+    LANGUAGE sql STABLE
+AS $$ SELECT NULLIF(current_setting('app.org_id', true), '')::UUID $$;
+-- +goose StatementEnd
+
+-- Membership lookups run as the owner so policies on organization_members do
+-- not recurse into themselves.
+-- +goose StatementBegin
+CREATE FUNCTION app_member_org_ids() RETURNS SETOF UUID
+    LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public
+AS $$ SELECT org_id FROM organization_members WHERE user_id = app_current_user_id() $$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION app_org_has_members(p_org_id UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public
+AS $$ SELECT EXISTS (SELECT 1 FROM organization_members WHERE org_id = p_org_id) $$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE FUNCTION app_can_access_org(p_org_id UUID) RETURNS BOOLEAN
+    LANGUAGE sql STABLE
+AS $$
+    SELECT (app_current_user_id() IS NOT NULL OR app_current_org_id() IS NOT NULL)
+       AND (app_current_org_id() IS NULL OR p_org_id = app_current_org_id())
+       AND (app_current_user_id() IS NULL OR p_org_id IN (SELECT app_member_org_ids()))
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE organizations ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY organizations_select ON organizations FOR SELECT
+    USING (app_can_access_org(id));
+-- Any signed-in user may create an organisation; it becomes visible to them
+-- once their owner membership exists.
+CREATE POLICY organizations_insert ON organizations FOR INSERT
+    WITH CHECK (app_current_user_id() IS NOT NULL);
+CREATE POLICY organizations_update ON organizations FOR UPDATE
+    USING (app_can_access_org(id))
+    WITH CHECK (app_can_access_org(id));
+CREATE POLICY organizations_delete ON organizations FOR DELETE
+    USING (app_can_access_org(id));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE organization_members ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY organization_members_select ON organization_members FOR SELECT
+    USING (app_can_access_org(org_id));
+-- Besides members adding others, a user may add themselves as the first
+-- member of an organisation that has none (the creator becoming owner).
+CREATE POLICY organization_members_insert ON organization_members FOR INSERT
+    WITH CHECK (
+        app_can_access_org(org_id)
+        OR (user_id = app_current_user_id() AND NOT app_org_has_members(org_id))
+    );
+CREATE POLICY organization_members_update ON organization_members FOR UPDATE
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+CREATE POLICY organization_members_delete ON organization_members FOR DELETE
+    USING (app_can_access_org(org_id));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE api_keys ENABLE ROW LEVEL SECURITY;
+
+CREATE POLICY api_keys_tenant ON api_keys
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+-- +goose StatementEnd
+
+-- +goose Down
+
+-- +goose StatementBegin
+DROP POLICY IF EXISTS api_keys_tenant ON api_keys;
+ALTER TABLE api_keys DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS organization_members_delete ON organization_members;
+DROP POLICY IF EXISTS organization_members_update ON organization_members;
+DROP POLICY IF EXISTS organization_members_insert ON organization_members;
+DROP POLICY IF EXISTS organization_members_select ON organization_members;
+ALTER TABLE organization_members DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS organizations_delete ON organizations;
+DROP POLICY IF EXISTS organizations_update ON organizations;
+DROP POLICY IF EXISTS organizations_insert ON organizations;
+DROP POLICY IF EXISTS organizations_select ON organizations;
+ALTER TABLE organizations DISABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS app_can_access_org(UUID);
+DROP FUNCTION IF EXISTS app_org_has_members(UUID);
+DROP FUNCTION IF EXISTS app_member_org_ids();
+DROP FUNCTION IF EXISTS app_current_org_id();
+DROP FUNCTION IF EXISTS app_current_user_id();
+-- +goose StatementEnd
+
+-- The role is cluster-wide and may still be used by other databases (the test
+-- database shares it), so only this database's grants are revoked.
+-- +goose StatementBegin
+ALTER DEFAULT PRIVILEGES IN SCHEMA public
+    REVOKE SELECT, INSERT, UPDATE, DELETE ON TABLES FROM horizon_app;
+REVOKE SELECT, INSERT, UPDATE, DELETE ON ALL TABLES IN SCHEMA public FROM horizon_app;
+REVOKE USAGE ON SCHEMA public FROM horizon_app;
+-- +goose StatementEnd
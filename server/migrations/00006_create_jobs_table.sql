@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Background jobs. Workers claim pending rows whose run_at has passed with
+-- SELECT ... FOR UPDATE SKIP LOCKED, so any number of server instances can
+-- share the queue. A failed attempt returns the row to pending with a later
+-- run_at; once max_attempts is used up it is parked as dead for inspection.
+CREATE TABLE jobs (
+    id           UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    kind         VARCHAR(100) NOT NULL,
+    payload      JSONB        NOT NULL DEFAULT '{}',
+    state        VARCHAR(20)  NOT NULL DEFAULT 'pending'
+        CHECK (state IN ('pending', 'running', 'succeeded', 'dead')),
+    attempts     INTEGER      NOT NULL DEFAULT 0,
+    max_attempts INTEGER      NOT NULL DEFAULT 20,
+    run_at       TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    -- At most one job per (kind, unique_key) exists until it is pruned.
+    unique_key   VARCHAR(255),
+    last_error   TEXT,
+    locked_by    VARCHAR(255),
+    locked_at    TIMESTAMP WITH TIME ZONE,
+    finished_at  TIMESTAMP WITH TIME ZONE,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (kind, unique_key)
+);
+
+CREATE INDEX idx_jobs_pending_run_at ON jobs(run_at) WHERE state = 'pending';
+CREATE INDEX idx_jobs_running_locked_at ON jobs(locked_at) WHERE state = 'running';
+CREATE INDEX idx_jobs_state_created_at ON jobs(state, created_at DESC);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS jobs;
+-- +goose StatementEnd
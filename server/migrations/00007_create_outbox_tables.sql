@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Domain events written in the same transaction as the state change they
+-- describe. seq is assigned by the relay, not at insert: a transaction that
+-- commits late would otherwise surface an event below a consumer's
+-- checkpoint. Sequencing in visibility order means a checkpoint never skips
+-- an event. ordinal records insert order, which the relay uses to order
+-- events that become visible together; writes to one aggregate serialize on
+-- its row lock, so its events keep their commit order.
+CREATE TABLE outbox_events (
+    id             UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    ordinal        BIGINT       GENERATED ALWAYS AS IDENTITY,
+    seq            BIGINT       UNIQUE,
+    org_id         UUID,
+    aggregate_type VARCHAR(100) NOT NULL,
+    aggregate_id   VARCHAR(255) NOT NULL,
+    event_type     VARCHAR(100) NOT NULL,
+    payload        JSONB        NOT NULL DEFAULT '{}',
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_outbox_events_unsequenced ON outbox_events(ordinal) WHERE seq IS NULL;
+
+-- Position of each consumer (sink) in the seq order. Delivery is
+-- at-least-once: a consumer's checkpoint only moves after it acknowledged
+-- the events up to it.
+CREATE TABLE outbox_checkpoints (
+    consumer   VARCHAR(100) PRIMARY KEY,
+    last_seq   BIGINT       NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS outbox_checkpoints;
+DROP TABLE IF EXISTS outbox_events;
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Log sources an organisation sends events from. The ingest token is stored
+-- as a SHA-256 hash like API keys; token_prefix identifies it in listings.
+-- last_event_at is activity rather than configuration, so it does not bump
+-- version.
+CREATE TABLE sources (
+    id            UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id        UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name          VARCHAR(255) NOT NULL,
+    type          VARCHAR(50)  NOT NULL
+        CHECK (type IN ('syslog', 'http-json', 'cloudtrail', 'windows-evtx')),
+    parser        VARCHAR(100),
+    mapping       VARCHAR(255),
+    enabled       BOOLEAN      NOT NULL DEFAULT TRUE,
+    token_hash    VARCHAR(255) UNIQUE NOT NULL,
+    token_prefix  VARCHAR(20)  NOT NULL,
+    last_event_at TIMESTAMP WITH TIME ZONE,
+    version       BIGINT       NOT NULL DEFAULT 1,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+
+CREATE INDEX idx_sources_org_id ON sources(org_id);
+
+-- Per-minute activity counters backing source health.
+CREATE TABLE source_stats (
+    source_id    UUID   NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    org_id       UUID   NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    bucket       TIMESTAMP WITH TIME ZONE NOT NULL,
+    events       BIGINT NOT NULL DEFAULT 0,
+    parse_errors BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (source_id, bucket)
+);
+
+CREATE INDEX idx_source_stats_bucket ON source_stats(bucket);
+
+ALTER TABLE sources ENABLE ROW LEVEL SECURITY;
+CREATE POLICY sources_tenant ON sources
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+ALTER TABLE source_stats ENABLE ROW LEVEL SECURITY;
+CREATE POLICY source_stats_tenant ON source_stats
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- Resolving an ingest token happens before any tenant scope exists, so it
+-- runs as the owner and returns only what is needed to establish the scope.
+-- +goose StatementBegin
+CREATE FUNCTION app_source_for_token(p_token_hash VARCHAR)
+    RETURNS TABLE (id UUID, org_id UUID, enabled BOOLEAN)
+    LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public
+AS $$ SELECT id, org_id, enabled FROM sources WHERE token_hash = p_token_hash $$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS app_source_for_token(VARCHAR);
+DROP TABLE IF EXISTS source_stats;
+DROP TABLE IF EXISTS sources;
+-- +goose StatementEnd
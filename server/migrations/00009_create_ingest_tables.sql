@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Raw events accepted by ingestion and waiting for normalization. Inserting
+-- here is all the request path does, so it never waits on parsing. seq keeps
+-- insert order, within a source as well as overall.
+CREATE TABLE ingest_events (
+    id          UUID   PRIMARY KEY DEFAULT gen_random_uuid(),
+    seq         BIGINT GENERATED ALWAYS AS IDENTITY,
+    org_id      UUID   NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    source_id   UUID   NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    payload     JSONB  NOT NULL,
+    received_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ingest_events_seq ON ingest_events(seq);
+CREATE INDEX idx_ingest_events_source_id ON ingest_events(source_id, seq);
+
+ALTER TABLE ingest_events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY ingest_events_tenant ON ingest_events
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- API-key authentication happens before any tenant scope exists, so the
+-- lookup runs as the owner and returns only what is needed to establish the
+-- scope and check the key's scopes. Revoked keys are not returned.
+-- +goose StatementBegin
+CREATE FUNCTION app_api_key_for_hash(p_key_hash VARCHAR)
+    RETURNS TABLE (id UUID, org_id UUID, scopes TEXT[])
+    LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public
+AS $$ SELECT id, org_id, scopes FROM api_keys WHERE key_hash = p_key_hash AND revoked_at IS NULL $$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS app_api_key_for_hash(VARCHAR);
+DROP TABLE IF EXISTS ingest_events;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Syslog carries no credentials, so a syslog source claims the senders it
+-- receives from: the listener they connect to (NULL for any) and their
+-- addresses as CIDR blocks.
+ALTER TABLE sources
+    ADD COLUMN syslog_listener VARCHAR(10) CHECK (syslog_listener IN ('udp', 'tcp', 'tls')),
+    ADD COLUMN syslog_senders  CIDR[]      NOT NULL DEFAULT '{}';
+
+-- +goose StatementEnd
+
+-- Routing a syslog message happens before any tenant scope exists, so it runs
+-- as the owner. The most specific block wins, then a source bound to the
+-- listener over one accepting any, then the oldest source.
+-- +goose StatementBegin
+CREATE FUNCTION app_syslog_source_for(p_listener VARCHAR, p_sender INET)
+    RETURNS TABLE (id UUID, org_id UUID, enabled BOOLEAN)
+    LANGUAGE sql STABLE SECURITY DEFINER SET search_path = public
+AS $$
+    SELECT s.id, s.org_id, s.enabled
+    FROM sources s, unnest(s.syslog_senders) AS sender
+    WHERE s.type = 'syslog'
+      AND (s.syslog_listener IS NULL OR s.syslog_listener = p_listener)
+      AND p_sender <<= sender
+    ORDER BY masklen(sender) DESC, s.syslog_listener IS NULL, s.created_at
+    LIMIT 1
+$$;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS app_syslog_source_for(VARCHAR, INET);
+ALTER TABLE sources
+    DROP COLUMN IF EXISTS syslog_senders,
+    DROP COLUMN IF EXISTS syslog_listener;
+-- +goose StatementEnd
@@ -0,0 +1,29 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An organisation's own grok patterns, extending the built-in library. Names
+-- are what expressions and source parsers (grok:NAME) refer to, so they do
+-- not change once created.
+CREATE TABLE grok_patterns (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name        VARCHAR(100) NOT NULL CHECK (name ~ '^[A-Za-z0-9_]+$'),
+    pattern     TEXT         NOT NULL,
+    description TEXT,
+    version     BIGINT       NOT NULL DEFAULT 1,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+
+ALTER TABLE grok_patterns ENABLE ROW LEVEL SECURITY;
+CREATE POLICY grok_patterns_tenant ON grok_patterns
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS grok_patterns;
+-- +goose StatementEnd
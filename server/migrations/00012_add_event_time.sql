@@ -0,0 +1,41 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- How a source's events carry their time; NULL uses the defaults. See
+-- package eventtime for the shape.
+ALTER TABLE sources ADD COLUMN timestamp_config JSONB;
+
+-- Queued events keep both when they happened and when they arrived.
+-- time_status says whether time came from the event or fell back to, or was
+-- flagged against, received_time.
+ALTER TABLE ingest_events RENAME COLUMN received_at TO received_time;
+ALTER TABLE ingest_events
+    ADD COLUMN time        TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN time_status VARCHAR(10) NOT NULL DEFAULT 'missing'
+        CHECK (time_status IN ('parsed', 'missing', 'invalid', 'future', 'past'));
+UPDATE ingest_events SET time = received_time;
+ALTER TABLE ingest_events ALTER COLUMN time SET NOT NULL;
+
+-- Ingestion lag over the events whose time came from the event, and how
+-- many fell outside the accepted range.
+ALTER TABLE source_stats
+    ADD COLUMN timed_events BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN lag_ms_sum   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN lag_ms_max   BIGINT NOT NULL DEFAULT 0,
+    ADD COLUMN out_of_range BIGINT NOT NULL DEFAULT 0;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE source_stats
+    DROP COLUMN IF EXISTS out_of_range,
+    DROP COLUMN IF EXISTS lag_ms_max,
+    DROP COLUMN IF EXISTS lag_ms_sum,
+    DROP COLUMN IF EXISTS timed_events;
+ALTER TABLE ingest_events
+    DROP COLUMN IF EXISTS time_status,
+    DROP COLUMN IF EXISTS time;
+ALTER TABLE ingest_events RENAME COLUMN received_time TO received_at;
+ALTER TABLE sources DROP COLUMN IF EXISTS timestamp_config;
+-- +goose StatementEnd
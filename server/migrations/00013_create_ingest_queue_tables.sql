@@ -0,0 +1,54 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Position of each queue consumer in each source's ingest_events, for the
+-- Postgres queue. A consumer works through a source in seq order and only
+-- one worker holds a source's cursor at a time, so events of one source are
+-- handled in order. attempts counts failed deliveries of the event after
+-- acked_seq; the cursor is not claimable again before available_at.
+CREATE TABLE ingest_cursors (
+    consumer     VARCHAR(100) NOT NULL,
+    source_id    UUID         NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    acked_seq    BIGINT       NOT NULL DEFAULT 0,
+    attempts     INTEGER      NOT NULL DEFAULT 0,
+    available_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    lease_id     UUID,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    last_error   TEXT,
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (consumer, source_id)
+);
+
+CREATE INDEX idx_ingest_cursors_available ON ingest_cursors(consumer, available_at);
+
+-- Events a consumer gave up on: failed permanently or on every allowed
+-- delivery. They are kept for inspection and replay.
+CREATE TABLE ingest_dead_letters (
+    id            UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    consumer      VARCHAR(100) NOT NULL,
+    event_id      UUID         NOT NULL,
+    org_id        UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    source_id     UUID         NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    payload       JSONB        NOT NULL,
+    time          TIMESTAMP WITH TIME ZONE NOT NULL,
+    time_status   VARCHAR(10)  NOT NULL,
+    received_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    attempts      INTEGER      NOT NULL,
+    error         TEXT         NOT NULL,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_ingest_dead_letters_source ON ingest_dead_letters(source_id, created_at DESC);
+
+ALTER TABLE ingest_dead_letters ENABLE ROW LEVEL SECURITY;
+CREATE POLICY ingest_dead_letters_tenant ON ingest_dead_letters
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS ingest_dead_letters;
+DROP TABLE IF EXISTS ingest_cursors;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Manifest of the raw event archive: one row per segment file in the blob
+-- store, a compressed batch of one source's events from one hour of event
+-- time. The time ranges locate the segments to read for a replay. A copy of
+-- each row is stored next to its segment, so the archive describes itself
+-- without the database.
+CREATE TABLE archive_segments (
+    id                UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id            UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    source_id         UUID         NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    key               TEXT         NOT NULL UNIQUE,
+    events            INTEGER      NOT NULL,
+    bytes             BIGINT       NOT NULL,
+    sha256            VARCHAR(64)  NOT NULL,
+    min_time          TIMESTAMP WITH TIME ZONE NOT NULL,
+    max_time          TIMESTAMP WITH TIME ZONE NOT NULL,
+    min_received_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    max_received_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at        TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_archive_segments_org_time ON archive_segments(org_id, min_time);
+CREATE INDEX idx_archive_segments_source_time ON archive_segments(source_id, min_time);
+
+ALTER TABLE archive_segments ENABLE ROW LEVEL SECURITY;
+CREATE POLICY archive_segments_tenant ON archive_segments
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS archive_segments;
+-- +goose StatementEnd
@@ -0,0 +1,83 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An organisation's mappings, which turn parsed events into OCSF events. The
+-- definition lives in the mapping's versions; version here is the row version
+-- of the name and description, as elsewhere.
+CREATE TABLE mappings (
+    id             UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id         UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name           VARCHAR(255) NOT NULL,
+    description    TEXT,
+    latest_version INTEGER      NOT NULL DEFAULT 0,
+    version        BIGINT       NOT NULL DEFAULT 1,
+    created_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+
+ALTER TABLE mappings ENABLE ROW LEVEL SECURITY;
+CREATE POLICY mappings_tenant ON mappings
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- The definitions of a mapping, numbered from 1. A version never changes once
+-- created, so replaying events with a version gives what it gave before.
+CREATE TABLE mapping_versions (
+    mapping_id UUID         NOT NULL REFERENCES mappings(id) ON DELETE CASCADE,
+    version    INTEGER      NOT NULL CHECK (version > 0),
+    org_id     UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    definition JSONB        NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (mapping_id, version)
+);
+
+ALTER TABLE mapping_versions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY mapping_versions_tenant ON mapping_versions
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- Saved test events of a mapping: a queued event, the parser to read its
+-- message with, and attributes its OCSF event must have. Every fixture must
+-- pass before a version is made a source's active one.
+CREATE TABLE mapping_fixtures (
+    id         UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    mapping_id UUID         NOT NULL REFERENCES mappings(id) ON DELETE CASCADE,
+    org_id     UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name       VARCHAR(255) NOT NULL,
+    parser     VARCHAR(100),
+    input      JSONB        NOT NULL,
+    expected   JSONB        NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (mapping_id, name)
+);
+
+ALTER TABLE mapping_fixtures ENABLE ROW LEVEL SECURITY;
+CREATE POLICY mapping_fixtures_tenant ON mapping_fixtures
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- A source's active mapping version replaces the free-text mapping name,
+-- which nothing resolved. Versions in use cannot go away with their mapping.
+ALTER TABLE sources
+    DROP COLUMN mapping,
+    ADD COLUMN mapping_id      UUID,
+    ADD COLUMN mapping_version INTEGER,
+    ADD CONSTRAINT sources_mapping_fkey FOREIGN KEY (mapping_id, mapping_version)
+        REFERENCES mapping_versions(mapping_id, version) ON DELETE RESTRICT,
+    ADD CONSTRAINT sources_mapping_check CHECK ((mapping_id IS NULL) = (mapping_version IS NULL));
+
+CREATE INDEX idx_sources_mapping_id ON sources(mapping_id) WHERE mapping_id IS NOT NULL;
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE sources
+    DROP COLUMN IF EXISTS mapping_version,
+    DROP COLUMN IF EXISTS mapping_id,
+    ADD COLUMN mapping VARCHAR(255);
+DROP TABLE IF EXISTS mapping_fixtures;
+DROP TABLE IF EXISTS mapping_versions;
+DROP TABLE IF EXISTS mappings;
+-- +goose StatementEnd
@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- The bundled content packs an organisation has installed, and the pack
+-- version last installed. The packs themselves ship in the binary.
+CREATE TABLE content_pack_installs (
+    id           UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id       UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    pack         VARCHAR(100) NOT NULL,
+    pack_version INTEGER      NOT NULL CHECK (pack_version > 0),
+    version      BIGINT       NOT NULL DEFAULT 1,
+    created_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at   TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, pack)
+);
+
+ALTER TABLE content_pack_installs ENABLE ROW LEVEL SECURITY;
+CREATE POLICY content_pack_installs_tenant ON content_pack_installs
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- The grok patterns and mappings an install created, with the version the
+-- pack last wrote. An item whose version has moved on since was changed by
+-- the organisation, and upgrades leave it alone; one whose pattern or mapping
+-- is gone was deleted by the organisation, and upgrades do not bring it back.
+CREATE TABLE content_pack_items (
+    install_id   UUID         NOT NULL REFERENCES content_pack_installs(id) ON DELETE CASCADE,
+    kind         VARCHAR(20)  NOT NULL CHECK (kind IN ('pattern', 'mapping')),
+    name         VARCHAR(255) NOT NULL,
+    org_id       UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    item_id      UUID         NOT NULL,
+    item_version BIGINT       NOT NULL,
+    PRIMARY KEY (install_id, kind, name)
+);
+
+ALTER TABLE content_pack_items ENABLE ROW LEVEL SECURITY;
+CREATE POLICY content_pack_items_tenant ON content_pack_items
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS content_pack_items;
+DROP TABLE IF EXISTS content_pack_installs;
+-- +goose StatementEnd
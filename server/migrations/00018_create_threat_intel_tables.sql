@@ -0,0 +1,52 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An organisation's threat intelligence indicators: values that, seen in an
+-- event, point at known malicious activity. Values are stored normalized for
+-- their type, so an import of an indicator the org already has updates it.
+CREATE TABLE threat_indicators (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    type        VARCHAR(20)  NOT NULL
+        CHECK (type IN ('ip', 'cidr', 'domain', 'url', 'file_hash', 'email')),
+    value       TEXT         NOT NULL,
+    source      VARCHAR(255) NOT NULL,
+    confidence  INTEGER      NOT NULL CHECK (confidence BETWEEN 0 AND 100),
+    tlp         VARCHAR(20)  NOT NULL
+        CHECK (tlp IN ('clear', 'green', 'amber', 'amber+strict', 'red')),
+    tags        TEXT[]       NOT NULL DEFAULT '{}',
+    description TEXT,
+    external_id VARCHAR(255),
+    expires_at  TIMESTAMP WITH TIME ZONE,
+    version     BIGINT       NOT NULL DEFAULT 1,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, type, value)
+);
+
+CREATE INDEX idx_threat_indicators_tags ON threat_indicators USING GIN (tags);
+
+ALTER TABLE threat_indicators ENABLE ROW LEVEL SECURITY;
+CREATE POLICY threat_indicators_tenant ON threat_indicators
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- A counter raised with every change to an organisation's indicators, which
+-- processes matching events against them poll to know when to reload.
+CREATE TABLE threat_indicator_revisions (
+    org_id   UUID   PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    revision BIGINT NOT NULL DEFAULT 1
+);
+
+ALTER TABLE threat_indicator_revisions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY threat_indicator_revisions_tenant ON threat_indicator_revisions
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS threat_indicator_revisions;
+DROP TABLE IF EXISTS threat_indicators;
+-- +goose StatementEnd
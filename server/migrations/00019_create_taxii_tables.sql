@@ -0,0 +1,74 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An organisation's TAXII 2.1 feeds: servers whose collections are polled for
+-- STIX indicators on a schedule. The secret, a password or token, is stored
+-- encrypted with a key derived for the organisation.
+CREATE TABLE taxii_feeds (
+    id                    UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id                UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name                  VARCHAR(255) NOT NULL,
+    discovery_url         TEXT         NOT NULL,
+    auth_type             VARCHAR(10)  NOT NULL CHECK (auth_type IN ('none', 'basic', 'token')),
+    username              VARCHAR(255),
+    secret                BYTEA,
+    poll_interval_minutes INTEGER      NOT NULL CHECK (poll_interval_minutes BETWEEN 5 AND 10080),
+    enabled               BOOLEAN      NOT NULL DEFAULT TRUE,
+    confidence            INTEGER      CHECK (confidence BETWEEN 0 AND 100),
+    tlp                   VARCHAR(20)
+        CHECK (tlp IN ('clear', 'green', 'amber', 'amber+strict', 'red')),
+    tags                  TEXT[]       NOT NULL DEFAULT '{}',
+    next_poll_at          TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    last_polled_at        TIMESTAMP WITH TIME ZONE,
+    error                 TEXT,
+    version               BIGINT       NOT NULL DEFAULT 1,
+    created_at            TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at            TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+
+-- The scheduler looks for enabled feeds due a poll across organisations.
+CREATE INDEX idx_taxii_feeds_due ON taxii_feeds (next_poll_at) WHERE enabled;
+
+ALTER TABLE taxii_feeds ENABLE ROW LEVEL SECURITY;
+CREATE POLICY taxii_feeds_tenant ON taxii_feeds
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- The collections discovered on a feed's API roots, with their sync state:
+-- added_after is the date the server last reported adding an object synced,
+-- from which the next sync carries on.
+CREATE TABLE taxii_collections (
+    id                 UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id             UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    feed_id            UUID         NOT NULL REFERENCES taxii_feeds(id) ON DELETE CASCADE,
+    api_root           TEXT         NOT NULL,
+    collection_id      VARCHAR(255) NOT NULL,
+    title              TEXT         NOT NULL,
+    description        TEXT,
+    can_read           BOOLEAN      NOT NULL,
+    enabled            BOOLEAN      NOT NULL,
+    added_after        TIMESTAMP WITH TIME ZONE,
+    last_synced_at     TIMESTAMP WITH TIME ZONE,
+    error              TEXT,
+    indicators_created BIGINT       NOT NULL DEFAULT 0,
+    indicators_updated BIGINT       NOT NULL DEFAULT 0,
+    created_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at         TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (feed_id, api_root, collection_id)
+);
+
+CREATE INDEX idx_taxii_collections_org_id ON taxii_collections (org_id);
+
+ALTER TABLE taxii_collections ENABLE ROW LEVEL SECURITY;
+CREATE POLICY taxii_collections_tenant ON taxii_collections
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS taxii_collections;
+DROP TABLE IF EXISTS taxii_feeds;
+-- +goose StatementEnd
@@ -0,0 +1,74 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An organisation's inventory of the hosts, IP addresses, users and processes
+-- its events mention. Entities are added as they are observed in normalized
+-- events, or by hand; values are stored normalized for their type. The
+-- criticality, owner, business unit and tags are set by hand or by import,
+-- and are added to the events that mention the entity.
+CREATE TABLE entities (
+    id            UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id        UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    type          VARCHAR(20)  NOT NULL CHECK (type IN ('host', 'ip', 'user', 'process')),
+    value         TEXT         NOT NULL,
+    aliases       TEXT[]       NOT NULL DEFAULT '{}',
+    criticality   VARCHAR(20)
+        CHECK (criticality IN ('low', 'medium', 'high', 'critical')),
+    owner         VARCHAR(255),
+    business_unit VARCHAR(255),
+    tags          TEXT[]       NOT NULL DEFAULT '{}',
+    first_seen_at TIMESTAMP WITH TIME ZONE,
+    last_seen_at  TIMESTAMP WITH TIME ZONE,
+    version       BIGINT       NOT NULL DEFAULT 1,
+    created_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at    TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, type, value)
+);
+
+CREATE INDEX idx_entities_aliases ON entities USING GIN (aliases);
+
+ALTER TABLE entities ENABLE ROW LEVEL SECURITY;
+CREATE POLICY entities_tenant ON entities
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- Hourly counts of the events that mentioned an entity, by OCSF class,
+-- backing its timeline.
+CREATE TABLE entity_sightings (
+    entity_id  UUID         NOT NULL REFERENCES entities(id) ON DELETE CASCADE,
+    org_id     UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    bucket     TIMESTAMP WITH TIME ZONE NOT NULL,
+    class_uid  INTEGER      NOT NULL,
+    class_name VARCHAR(255) NOT NULL DEFAULT '',
+    events     BIGINT       NOT NULL DEFAULT 0,
+    PRIMARY KEY (entity_id, bucket, class_uid)
+);
+
+CREATE INDEX idx_entity_sightings_bucket ON entity_sightings(bucket);
+
+ALTER TABLE entity_sightings ENABLE ROW LEVEL SECURITY;
+CREATE POLICY entity_sightings_tenant ON entity_sightings
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- A counter raised with every change to the context of an organisation's
+-- entities, which processes enriching events with it poll to know when to
+-- reload.
+CREATE TABLE entity_revisions (
+    org_id   UUID   PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    revision BIGINT NOT NULL DEFAULT 1
+);
+
+ALTER TABLE entity_revisions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY entity_revisions_tenant ON entity_revisions
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS entity_revisions;
+DROP TABLE IF EXISTS entity_sightings;
+DROP TABLE IF EXISTS entities;
+-- +goose StatementEnd
@@ -0,0 +1,81 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- An organisation's redaction policies: rules dropping, hashing, masking or
+-- scrubbing sensitive values in the events of its sources, applied at
+-- ingest, before events are queued and archived, or before they are
+-- normalized. No source IDs means every source.
+CREATE TABLE redaction_policies (
+    id          UUID         PRIMARY KEY DEFAULT gen_random_uuid(),
+    org_id      UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    name        VARCHAR(255) NOT NULL,
+    description TEXT,
+    enabled     BOOLEAN      NOT NULL DEFAULT TRUE,
+    stage       VARCHAR(20)  NOT NULL CHECK (stage IN ('archive', 'normalization')),
+    source_ids  UUID[]       NOT NULL DEFAULT '{}',
+    rules       JSONB        NOT NULL,
+    version     BIGINT       NOT NULL DEFAULT 1,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    UNIQUE (org_id, name)
+);
+
+ALTER TABLE redaction_policies ENABLE ROW LEVEL SECURITY;
+CREATE POLICY redaction_policies_tenant ON redaction_policies
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- The key an organisation's hash rules are keyed with, generated with its
+-- first policy. It is never returned.
+CREATE TABLE redaction_salts (
+    org_id     UUID  PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    salt       BYTEA NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE redaction_salts ENABLE ROW LEVEL SECURITY;
+CREATE POLICY redaction_salts_tenant ON redaction_salts
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- A counter raised with every change to an organisation's redaction
+-- policies, which processes redacting events poll to know when to reload.
+CREATE TABLE redaction_revisions (
+    org_id   UUID   PRIMARY KEY REFERENCES organizations(id) ON DELETE CASCADE,
+    revision BIGINT NOT NULL DEFAULT 1
+);
+
+ALTER TABLE redaction_revisions ENABLE ROW LEVEL SECURITY;
+CREATE POLICY redaction_revisions_tenant ON redaction_revisions
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- Hourly counts of the values each rule redacted in a source's events, for
+-- audit. They outlive the policy, so policy_id is not a reference.
+CREATE TABLE redaction_stats (
+    source_id  UUID         NOT NULL REFERENCES sources(id) ON DELETE CASCADE,
+    org_id     UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    policy_id  UUID         NOT NULL,
+    rule       VARCHAR(100) NOT NULL,
+    stage      VARCHAR(20)  NOT NULL,
+    bucket     TIMESTAMP WITH TIME ZONE NOT NULL,
+    redactions BIGINT       NOT NULL DEFAULT 0,
+    PRIMARY KEY (source_id, bucket, policy_id, rule, stage)
+);
+
+CREATE INDEX idx_redaction_stats_bucket ON redaction_stats(bucket);
+
+ALTER TABLE redaction_stats ENABLE ROW LEVEL SECURITY;
+CREATE POLICY redaction_stats_tenant ON redaction_stats
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS redaction_stats;
+DROP TABLE IF EXISTS redaction_revisions;
+DROP TABLE IF EXISTS redaction_salts;
+DROP TABLE IF EXISTS redaction_policies;
+-- +goose StatementEnd
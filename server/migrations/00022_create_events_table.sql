@@ -0,0 +1,78 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Normalized events: what processing made of each ingested event, its OCSF
+-- event when the source has a mapping and its parsed fields otherwise. The
+-- table is range-partitioned by day of event time, in UTC, so a day's events
+-- are dropped with their partition once past retention. The attributes
+-- searches filter on most are promoted to columns, NULL when the event does
+-- not have them; everything else is queried in the JSONB.
+CREATE TABLE events (
+    org_id      UUID         NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+    id          UUID         NOT NULL,
+    source_id   UUID         NOT NULL,
+    time        TIMESTAMP WITH TIME ZONE NOT NULL,
+    class_uid   INTEGER,
+    severity_id INTEGER,
+    src_ip      INET,
+    dst_ip      INET,
+    user_name   TEXT,
+    hostname    TEXT,
+    event       JSONB        NOT NULL,
+    created_at  TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (org_id, id, time)
+) PARTITION BY RANGE (time);
+
+CREATE INDEX idx_events_org_time ON events(org_id, time DESC);
+CREATE INDEX idx_events_org_class_time ON events(org_id, class_uid, time DESC);
+CREATE INDEX idx_events_org_src_ip ON events(org_id, src_ip) WHERE src_ip IS NOT NULL;
+CREATE INDEX idx_events_org_dst_ip ON events(org_id, dst_ip) WHERE dst_ip IS NOT NULL;
+CREATE INDEX idx_events_org_user_name ON events(org_id, user_name) WHERE user_name IS NOT NULL;
+CREATE INDEX idx_events_org_hostname ON events(org_id, hostname) WHERE hostname IS NOT NULL;
+CREATE INDEX idx_events_event ON events USING GIN (event jsonb_path_ops);
+
+ALTER TABLE events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY events_tenant ON events
+    USING (app_can_access_org(org_id))
+    WITH CHECK (app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- Creates the partition holding the events of a day, unless it exists. It
+-- runs as the owner, since only the owner of events may add partitions to
+-- it, so writers under a tenant scope can store events of any day. Creators
+-- queue on an advisory lock rather than race to create the same partition.
+-- +goose StatementBegin
+CREATE FUNCTION events_create_partition(p_day DATE) RETURNS VOID
+    LANGUAGE plpgsql SECURITY DEFINER SET search_path = public
+AS $$
+DECLARE
+    partition TEXT := 'events_p' || to_char(p_day, 'YYYYMMDD');
+BEGIN
+    IF to_regclass(partition) IS NOT NULL THEN
+        RETURN;
+    END IF;
+    PERFORM pg_advisory_xact_lock(hashtext('events_create_partition'));
+    IF to_regclass(partition) IS NOT NULL THEN
+        RETURN;
+    END IF;
+    EXECUTE format(
+        'CREATE TABLE %I PARTITION OF events FOR VALUES FROM (%L) TO (%L)',
+        partition,
+        p_day::TIMESTAMP AT TIME ZONE 'UTC',
+        (p_day + 1)::TIMESTAMP AT TIME ZONE 'UTC'
+    );
+END
+$$;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+REVOKE ALL ON FUNCTION events_create_partition(DATE) FROM PUBLIC;
+GRANT EXECUTE ON FUNCTION events_create_partition(DATE) TO horizon_app;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP FUNCTION IF EXISTS events_create_partition(DATE);
+DROP TABLE IF EXISTS events;
+-- +goose StatementEnd
@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+
+-- Outbox events of an org are only visible to its tenant scope, like every
+-- other org-scoped table. Events not about an org (user events) may be
+-- appended under any scope but are read by none. The relay and prune run as
+-- the owner, so they still see every event.
+ALTER TABLE outbox_events ENABLE ROW LEVEL SECURITY;
+CREATE POLICY outbox_events_tenant ON outbox_events
+    USING (app_can_access_org(org_id))
+    WITH CHECK (org_id IS NULL OR app_can_access_org(org_id));
+
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY IF EXISTS outbox_events_tenant ON outbox_events;
+ALTER TABLE outbox_events DISABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd